@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mainflux/mainflux/alerts"
+)
+
+func addRuleEndpoint(svc alerts.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addRuleReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		rule := alerts.Rule{
+			ChanID:    req.chanID,
+			Name:      req.Name,
+			Operator:  req.Operator,
+			Threshold: req.Threshold,
+			Webhook:   req.Webhook,
+			Cooldown:  time.Duration(req.Cooldown) * time.Second,
+		}
+
+		saved, err := svc.AddRule(ctx, req.token, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		res := toRuleRes(saved)
+		res.created = true
+		return res, nil
+	}
+}
+
+func listRulesEndpoint(svc alerts.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRulesReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		rules, err := svc.ListRules(ctx, req.token, req.chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		res := rulesRes{Rules: []ruleRes{}}
+		for _, rule := range rules {
+			res.Rules = append(res.Rules, toRuleRes(rule))
+		}
+
+		return res, nil
+	}
+}
+
+func removeRuleEndpoint(svc alerts.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(removeRuleReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RemoveRule(ctx, req.token, req.chanID, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func toRuleRes(rule alerts.Rule) ruleRes {
+	return ruleRes{
+		ID:        rule.ID,
+		ChanID:    rule.ChanID,
+		Name:      rule.Name,
+		Operator:  rule.Operator,
+		Threshold: rule.Threshold,
+		Webhook:   rule.Webhook,
+		Cooldown:  uint64(rule.Cooldown / time.Second),
+	}
+}
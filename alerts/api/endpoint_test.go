@@ -0,0 +1,254 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/alerts/api"
+	"github.com/mainflux/mainflux/alerts/mocks"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	contentType = "application/json"
+	userToken   = "user-token"
+	chanID      = "1"
+	webhook     = "http://webhook.example.com"
+)
+
+func newService() alerts.Service {
+	channels := mocks.NewChannelsService(map[string]string{userToken: chanID})
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	return alerts.New(channels, mocks.NewRuleRepository(), mocks.NewNotifier(), alerts.Dispatch{Workers: 1}, logger)
+}
+
+func newServer(svc alerts.Service) *httptest.Server {
+	mux := api.MakeHandler(svc, mocktracer.New())
+	return httptest.NewServer(mux)
+}
+
+type testRequest struct {
+	client      *http.Client
+	method      string
+	url         string
+	contentType string
+	token       string
+	body        io.Reader
+}
+
+func (tr testRequest) make() (*http.Response, error) {
+	req, err := http.NewRequest(tr.method, tr.url, tr.body)
+	if err != nil {
+		return nil, err
+	}
+	if tr.token != "" {
+		req.Header.Set("Authorization", tr.token)
+	}
+	if tr.contentType != "" {
+		req.Header.Set("Content-Type", tr.contentType)
+	}
+	return tr.client.Do(req)
+}
+
+func TestAddRule(t *testing.T) {
+	svc := newService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	valid := fmt.Sprintf(`{"operator":"gt","threshold":30,"webhook":"%s","cooldown":60}`, webhook)
+	invalidOp := fmt.Sprintf(`{"operator":"eq","threshold":30,"webhook":"%s"}`, webhook)
+
+	cases := []struct {
+		desc        string
+		chanID      string
+		req         string
+		contentType string
+		token       string
+		status      int
+	}{
+		{
+			desc:        "add valid rule",
+			chanID:      chanID,
+			req:         valid,
+			contentType: contentType,
+			token:       userToken,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "add rule with unsupported operator",
+			chanID:      chanID,
+			req:         invalidOp,
+			contentType: contentType,
+			token:       userToken,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add rule with invalid request format",
+			chanID:      chanID,
+			req:         "}",
+			contentType: contentType,
+			token:       userToken,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add rule without content type",
+			chanID:      chanID,
+			req:         valid,
+			contentType: "",
+			token:       userToken,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "add rule without authorization",
+			chanID:      chanID,
+			req:         valid,
+			contentType: contentType,
+			token:       "",
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "add rule to channel without access",
+			chanID:      "2",
+			req:         valid,
+			contentType: contentType,
+			token:       userToken,
+			status:      http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/channels/%s/rules", ts.URL, tc.chanID),
+			contentType: tc.contentType,
+			token:       tc.token,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestListRules(t *testing.T) {
+	svc := newService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	rule, err := svc.AddRule(context.Background(), userToken, alerts.Rule{ChanID: chanID, Operator: alerts.OpLess, Threshold: -5, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		token  string
+		status int
+		ids    []string
+	}{
+		{
+			desc:   "list channel rules",
+			token:  userToken,
+			status: http.StatusOK,
+			ids:    []string{rule.ID},
+		},
+		{
+			desc:   "list channel rules with invalid token",
+			token:  "invalid",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/rules", ts.URL, chanID),
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Rules []struct {
+				ID string `json:"id"`
+			} `json:"rules"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		ids := []string{}
+		for _, r := range body.Rules {
+			ids = append(ids, r.ID)
+		}
+		assert.Equal(t, tc.ids, ids, fmt.Sprintf("%s: expected rules %v got %v", tc.desc, tc.ids, ids))
+	}
+}
+
+func TestRemoveRule(t *testing.T) {
+	svc := newService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	rule, err := svc.AddRule(context.Background(), userToken, alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		id     string
+		token  string
+		status int
+	}{
+		{
+			desc:   "remove rule with invalid token",
+			id:     rule.ID,
+			token:  "invalid",
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "remove existing rule",
+			id:     rule.ID,
+			token:  userToken,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "remove non-existent rule",
+			id:     rule.ID,
+			token:  userToken,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/channels/%s/rules/%s", ts.URL, chanID, tc.id),
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
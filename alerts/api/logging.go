@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// +build !test
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/logger"
+)
+
+var _ alerts.Service = (*loggingMiddleware)(nil)
+
+type loggingMiddleware struct {
+	logger logger.Logger
+	svc    alerts.Service
+}
+
+// LoggingMiddleware adds logging facilities to the core service.
+func LoggingMiddleware(svc alerts.Service, logger logger.Logger) alerts.Service {
+	return &loggingMiddleware{
+		logger: logger,
+		svc:    svc,
+	}
+}
+
+func (lm *loggingMiddleware) AddRule(ctx context.Context, token string, rule alerts.Rule) (saved alerts.Rule, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method add_rule for channel %s took %s to complete", rule.ChanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.AddRule(ctx, token, rule)
+}
+
+func (lm *loggingMiddleware) ListRules(ctx context.Context, token, chanID string) (rules []alerts.Rule, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_rules for channel %s took %s to complete", chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ListRules(ctx, token, chanID)
+}
+
+func (lm *loggingMiddleware) RemoveRule(ctx context.Context, token, chanID, id string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method remove_rule %s for channel %s took %s to complete", id, chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RemoveRule(ctx, token, chanID, id)
+}
+
+func (lm *loggingMiddleware) Evaluate(msg mainflux.Message) (err error) {
+	defer func(begin time.Time) {
+		if err == nil {
+			return
+		}
+		message := fmt.Sprintf("Method evaluate for channel %s took %s to complete", msg.Channel, time.Since(begin))
+		lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+	}(time.Now())
+
+	return lm.svc.Evaluate(msg)
+}
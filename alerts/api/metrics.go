@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// +build !test
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+)
+
+var _ alerts.Service = (*metricsMiddleware)(nil)
+
+type metricsMiddleware struct {
+	counter metrics.Counter
+	latency metrics.Histogram
+	svc     alerts.Service
+}
+
+// MetricsMiddleware instruments core service by tracking request count and
+// latency.
+func MetricsMiddleware(svc alerts.Service, counter metrics.Counter, latency metrics.Histogram) alerts.Service {
+	return &metricsMiddleware{
+		counter: counter,
+		latency: latency,
+		svc:     svc,
+	}
+}
+
+func (mm *metricsMiddleware) AddRule(ctx context.Context, token string, rule alerts.Rule) (alerts.Rule, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "add_rule").Add(1)
+		mm.latency.With("method", "add_rule").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.AddRule(ctx, token, rule)
+}
+
+func (mm *metricsMiddleware) ListRules(ctx context.Context, token, chanID string) ([]alerts.Rule, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "list_rules").Add(1)
+		mm.latency.With("method", "list_rules").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.ListRules(ctx, token, chanID)
+}
+
+func (mm *metricsMiddleware) RemoveRule(ctx context.Context, token, chanID, id string) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "remove_rule").Add(1)
+		mm.latency.With("method", "remove_rule").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.RemoveRule(ctx, token, chanID, id)
+}
+
+func (mm *metricsMiddleware) Evaluate(msg mainflux.Message) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "evaluate").Add(1)
+		mm.latency.With("method", "evaluate").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Evaluate(msg)
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package api
+
+import "github.com/mainflux/mainflux/alerts"
+
+type apiReq interface {
+	validate() error
+}
+
+type addRuleReq struct {
+	token     string
+	chanID    string
+	Name      string  `json:"name,omitempty"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Webhook   string  `json:"webhook"`
+	Cooldown  uint64  `json:"cooldown,omitempty"`
+}
+
+func (req addRuleReq) validate() error {
+	if req.token == "" {
+		return alerts.ErrUnauthorizedAccess
+	}
+
+	if req.chanID == "" || req.Operator == "" || req.Webhook == "" {
+		return alerts.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type listRulesReq struct {
+	token  string
+	chanID string
+}
+
+func (req listRulesReq) validate() error {
+	if req.token == "" {
+		return alerts.ErrUnauthorizedAccess
+	}
+
+	if req.chanID == "" {
+		return alerts.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type removeRuleReq struct {
+	token  string
+	chanID string
+	id     string
+}
+
+func (req removeRuleReq) validate() error {
+	if req.token == "" {
+		return alerts.ErrUnauthorizedAccess
+	}
+
+	if req.chanID == "" || req.id == "" {
+		return alerts.ErrMalformedEntity
+	}
+
+	return nil
+}
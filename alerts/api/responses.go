@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mainflux/mainflux"
+)
+
+var (
+	_ mainflux.Response = (*ruleRes)(nil)
+	_ mainflux.Response = (*rulesRes)(nil)
+	_ mainflux.Response = (*removeRes)(nil)
+)
+
+type ruleRes struct {
+	ID        string  `json:"id"`
+	ChanID    string  `json:"channel"`
+	Name      string  `json:"name,omitempty"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Webhook   string  `json:"webhook"`
+	Cooldown  uint64  `json:"cooldown"`
+	created   bool
+}
+
+func (res ruleRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res ruleRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/channels/%s/rules/%s", res.ChanID, res.ID),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res ruleRes) Empty() bool {
+	return false
+}
+
+type rulesRes struct {
+	Rules []ruleRes `json:"rules"`
+}
+
+func (res rulesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res rulesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res rulesRes) Empty() bool {
+	return false
+}
+
+type removeRes struct{}
+
+func (res removeRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res removeRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res removeRes) Empty() bool {
+	return true
+}
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	kitot "github.com/go-kit/kit/tracing/opentracing"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/go-zoo/bone"
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const contentType = "application/json"
+
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+// MakeHandler returns a HTTP handler for API endpoints.
+func MakeHandler(svc alerts.Service, tracer opentracing.Tracer) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	r := bone.New()
+
+	r.Post("/channels/:chanID/rules", kithttp.NewServer(
+		kitot.TraceServer(tracer, "add_rule")(addRuleEndpoint(svc)),
+		decodeAddRule,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Get("/channels/:chanID/rules", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_rules")(listRulesEndpoint(svc)),
+		decodeListRules,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Delete("/channels/:chanID/rules/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "remove_rule")(removeRuleEndpoint(svc)),
+		decodeRemoveRule,
+		encodeResponse,
+		opts...,
+	))
+
+	r.GetFunc("/version", mainflux.Version("alerts"))
+	r.Handle("/metrics", promhttp.Handler())
+
+	return r
+}
+
+func decodeAddRule(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := addRuleReq{
+		token:  r.Header.Get("Authorization"),
+		chanID: bone.GetValue(r, "chanID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, alerts.ErrMalformedEntity
+	}
+
+	return req, nil
+}
+
+func decodeListRules(_ context.Context, r *http.Request) (interface{}, error) {
+	req := listRulesReq{
+		token:  r.Header.Get("Authorization"),
+		chanID: bone.GetValue(r, "chanID"),
+	}
+
+	return req, nil
+}
+
+func decodeRemoveRule(_ context.Context, r *http.Request) (interface{}, error) {
+	req := removeRuleReq{
+		token:  r.Header.Get("Authorization"),
+		chanID: bone.GetValue(r, "chanID"),
+		id:     bone.GetValue(r, "id"),
+	}
+
+	return req, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", contentType)
+
+	if ar, ok := response.(mainflux.Response); ok {
+		for k, v := range ar.Headers() {
+			w.Header().Set(k, v)
+		}
+
+		w.WriteHeader(ar.Code())
+
+		if ar.Empty() {
+			return nil
+		}
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", contentType)
+
+	switch err {
+	case alerts.ErrMalformedEntity:
+		w.WriteHeader(http.StatusBadRequest)
+	case alerts.ErrUnauthorizedAccess:
+		w.WriteHeader(http.StatusForbidden)
+	case alerts.ErrNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case errUnsupportedContentType:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,40 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package alerts
+
+import (
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/writers"
+)
+
+var _ writers.MessageRepository = (*consumer)(nil)
+
+type consumer struct {
+	svc Service
+}
+
+// NewConsumer wraps the alerts service into the writers message repository,
+// so that the alerts can reuse the writers NATS consumer to evaluate the
+// incoming messages.
+func NewConsumer(svc Service) writers.MessageRepository {
+	return consumer{svc: svc}
+}
+
+func (c consumer) Save(msg mainflux.Message) error {
+	return c.svc.Evaluate(msg)
+}
+
+func (c consumer) SaveAll(msgs []mainflux.Message) error {
+	for i, msg := range msgs {
+		if err := c.svc.Evaluate(msg); err != nil {
+			return writers.BatchError{Saved: i, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import "github.com/mainflux/mainflux/alerts"
+
+var _ alerts.ChannelsService = (*channelsService)(nil)
+
+type channelsService struct {
+	channels map[string]string
+}
+
+// NewChannelsService returns mock implementation of the channels service. The
+// provided map associates user tokens with the channels the users manage.
+func NewChannelsService(channels map[string]string) alerts.ChannelsService {
+	return channelsService{channels}
+}
+
+func (cs channelsService) Authorize(token, chanID string) error {
+	id, ok := cs.channels[token]
+	if !ok {
+		return alerts.ErrUnauthorizedAccess
+	}
+
+	if id != chanID {
+		return alerts.ErrNotFound
+	}
+
+	return nil
+}
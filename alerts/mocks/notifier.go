@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mainflux/mainflux/alerts"
+)
+
+// ErrNotify is returned by the notifier for the rules having FailingWebhook.
+var ErrNotify = errors.New("failed to notify")
+
+const (
+	// FailingWebhook is the webhook the mock notifier fails to deliver to.
+	FailingWebhook = "http://failing.example.com"
+
+	// BlockingWebhook is the webhook whose deliveries block until the mock
+	// notifier is released.
+	BlockingWebhook = "http://blocking.example.com"
+)
+
+var _ alerts.Notifier = (*Notifier)(nil)
+
+// Notifier is the mock notifier that records delivered alerts.
+type Notifier struct {
+	mu       sync.Mutex
+	alerts   []alerts.Alert
+	attempts int
+	release  chan struct{}
+}
+
+// NewNotifier returns mock notifier.
+func NewNotifier() *Notifier {
+	return &Notifier{release: make(chan struct{})}
+}
+
+// Notify records the alert.
+func (n *Notifier) Notify(alert alerts.Alert) error {
+	n.mu.Lock()
+	n.attempts++
+	n.mu.Unlock()
+
+	switch alert.Rule.Webhook {
+	case FailingWebhook:
+		return ErrNotify
+	case BlockingWebhook:
+		<-n.release
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+// Release unblocks the deliveries to the BlockingWebhook.
+func (n *Notifier) Release() {
+	close(n.release)
+}
+
+// Attempts returns the number of delivery attempts, including the failed ones.
+func (n *Notifier) Attempts() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.attempts
+}
+
+// Alerts returns the delivered alerts.
+func (n *Notifier) Alerts() []alerts.Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]alerts.Alert{}, n.alerts...)
+}
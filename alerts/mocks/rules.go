@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/mainflux/mainflux/alerts"
+)
+
+var _ alerts.RuleRepository = (*RuleRepository)(nil)
+
+// RuleRepository is the in-memory rule repository that counts the rule
+// retrievals.
+type RuleRepository struct {
+	mu         sync.RWMutex
+	rules      map[string]map[string]alerts.Rule
+	retrievals int
+}
+
+// NewRuleRepository creates in-memory rule repository.
+func NewRuleRepository() *RuleRepository {
+	return &RuleRepository{
+		rules: make(map[string]map[string]alerts.Rule),
+	}
+}
+
+// Retrievals returns the number of the rule retrievals by channel.
+func (rr *RuleRepository) Retrievals() int {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	return rr.retrievals
+}
+
+func (rr *RuleRepository) Save(rule alerts.Rule) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rule.ID = id.String()
+	if _, ok := rr.rules[rule.ChanID]; !ok {
+		rr.rules[rule.ChanID] = make(map[string]alerts.Rule)
+	}
+	rr.rules[rule.ChanID][rule.ID] = rule
+
+	return rule.ID, nil
+}
+
+func (rr *RuleRepository) RetrieveByChannel(chanID string) ([]alerts.Rule, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.retrievals++
+
+	rules := []alerts.Rule{}
+	for _, rule := range rr.rules[chanID] {
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules, nil
+}
+
+func (rr *RuleRepository) Remove(chanID, id string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if _, ok := rr.rules[chanID][id]; !ok {
+		return alerts.ErrNotFound
+	}
+
+	delete(rr.rules[chanID], id)
+	if len(rr.rules[chanID]) == 0 {
+		delete(rr.rules, chanID)
+	}
+
+	return nil
+}
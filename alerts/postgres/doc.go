@@ -0,0 +1,10 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package postgres contains rule repository implementation using PostgreSQL as
+// the underlying database.
+package postgres
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux/alerts"
+)
+
+var _ alerts.RuleRepository = (*ruleRepository)(nil)
+
+type ruleRepository struct {
+	db *sqlx.DB
+}
+
+// NewRuleRepository instantiates a PostgreSQL implementation of rule
+// repository.
+func NewRuleRepository(db *sqlx.DB) alerts.RuleRepository {
+	return &ruleRepository{
+		db: db,
+	}
+}
+
+func (rr ruleRepository) Save(rule alerts.Rule) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	rule.ID = id.String()
+
+	q := `INSERT INTO rules (id, channel_id, name, operator, threshold, webhook, cooldown)
+	      VALUES (:id, :channel_id, :name, :operator, :threshold, :webhook, :cooldown);`
+
+	if _, err := rr.db.NamedExec(q, toDBRule(rule)); err != nil {
+		return "", err
+	}
+
+	return rule.ID, nil
+}
+
+func (rr ruleRepository) RetrieveByChannel(chanID string) ([]alerts.Rule, error) {
+	q := `SELECT id, channel_id, name, operator, threshold, webhook, cooldown
+	      FROM rules WHERE channel_id = $1 ORDER BY id;`
+
+	rows, err := rr.db.Queryx(q, chanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []alerts.Rule{}
+	for rows.Next() {
+		var dbr dbRule
+		if err := rows.StructScan(&dbr); err != nil {
+			return nil, err
+		}
+		rules = append(rules, toRule(dbr))
+	}
+
+	return rules, rows.Err()
+}
+
+func (rr ruleRepository) Remove(chanID, id string) error {
+	// Malformed identifiers can't belong to any rule, and would otherwise
+	// fail the UUID column cast.
+	if _, err := uuid.FromString(id); err != nil {
+		return alerts.ErrNotFound
+	}
+
+	q := `DELETE FROM rules WHERE id = $1 AND channel_id = $2;`
+
+	res, err := rr.db.Exec(q, id, chanID)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return alerts.ErrNotFound
+	}
+
+	return nil
+}
+
+type dbRule struct {
+	ID        string  `db:"id"`
+	ChanID    string  `db:"channel_id"`
+	Name      string  `db:"name"`
+	Operator  string  `db:"operator"`
+	Threshold float64 `db:"threshold"`
+	Webhook   string  `db:"webhook"`
+	// Cooldown is stored in nanoseconds.
+	Cooldown int64 `db:"cooldown"`
+}
+
+func toDBRule(rule alerts.Rule) dbRule {
+	return dbRule{
+		ID:        rule.ID,
+		ChanID:    rule.ChanID,
+		Name:      rule.Name,
+		Operator:  rule.Operator,
+		Threshold: rule.Threshold,
+		Webhook:   rule.Webhook,
+		Cooldown:  int64(rule.Cooldown),
+	}
+}
+
+func toRule(dbr dbRule) alerts.Rule {
+	return alerts.Rule{
+		ID:        dbr.ID,
+		ChanID:    dbr.ChanID,
+		Name:      dbr.Name,
+		Operator:  dbr.Operator,
+		Threshold: dbr.Threshold,
+		Webhook:   dbr.Webhook,
+		Cooldown:  time.Duration(dbr.Cooldown),
+	}
+}
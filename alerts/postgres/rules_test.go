@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/alerts/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhook = "http://webhook.example.com"
+
+func TestRuleSave(t *testing.T) {
+	repo := postgres.NewRuleRepository(db)
+
+	rule := alerts.Rule{
+		ChanID:    "save",
+		Name:      "temperature",
+		Operator:  alerts.OpGreater,
+		Threshold: 30.5,
+		Webhook:   webhook,
+		Cooldown:  time.Minute,
+	}
+
+	id, err := repo.Save(rule)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = uuid.FromString(id)
+	assert.Nil(t, err, fmt.Sprintf("expected UUID rule ID got %s", id))
+
+	rules, err := repo.RetrieveByChannel(rule.ChanID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	rule.ID = id
+	assert.Equal(t, []alerts.Rule{rule}, rules, fmt.Sprintf("expected %v got %v", []alerts.Rule{rule}, rules))
+}
+
+func TestRuleRetrieveByChannel(t *testing.T) {
+	repo := postgres.NewRuleRepository(db)
+
+	chanID := "retrieve"
+	n := 3
+	for i := 0; i < n; i++ {
+		_, err := repo.Save(alerts.Rule{ChanID: chanID, Operator: alerts.OpLess, Threshold: float64(i), Webhook: webhook})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	cases := []struct {
+		desc   string
+		chanID string
+		size   int
+	}{
+		{
+			desc:   "retrieve rules of existing channel",
+			chanID: chanID,
+			size:   n,
+		},
+		{
+			desc:   "retrieve rules of channel without rules",
+			chanID: wrongID,
+			size:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		rules, err := repo.RetrieveByChannel(tc.chanID)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.size, len(rules), fmt.Sprintf("%s: expected %d rules got %d", tc.desc, tc.size, len(rules)))
+	}
+}
+
+func TestRuleRemove(t *testing.T) {
+	repo := postgres.NewRuleRepository(db)
+
+	chanID := "remove"
+	id, err := repo.Save(alerts.Rule{ChanID: chanID, Operator: alerts.OpLess, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		chanID string
+		id     string
+		err    error
+	}{
+		{
+			desc:   "remove rule of other channel",
+			chanID: wrongID,
+			id:     id,
+			err:    alerts.ErrNotFound,
+		},
+		{
+			desc:   "remove rule with malformed ID",
+			chanID: chanID,
+			id:     wrongID,
+			err:    alerts.ErrNotFound,
+		},
+		{
+			desc:   "remove existing rule",
+			chanID: chanID,
+			id:     id,
+			err:    nil,
+		},
+		{
+			desc:   "remove removed rule",
+			chanID: chanID,
+			id:     id,
+			err:    alerts.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Remove(tc.chanID, tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
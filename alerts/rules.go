@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package alerts
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux"
+)
+
+// Supported threshold operators.
+const (
+	OpGreater      = "gt"
+	OpGreaterEqual = "ge"
+	OpLess         = "lt"
+	OpLessEqual    = "le"
+)
+
+// Rule represents per-channel threshold rule. Rule is breached when the
+// numeric value of the channel message compared with the threshold using the
+// rule operator holds. Breached rule fires the webhook at most once per
+// cooldown period.
+type Rule struct {
+	ID        string
+	ChanID    string
+	Name      string
+	Operator  string
+	Threshold float64
+	Webhook   string
+	Cooldown  time.Duration
+}
+
+// Validate returns ErrMalformedEntity if the rule is not valid. Webhook has to
+// be the HTTP or HTTPS URL of the public host.
+func (r Rule) Validate() error {
+	switch r.Operator {
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+	default:
+		return ErrMalformedEntity
+	}
+
+	if r.Cooldown < 0 {
+		return ErrMalformedEntity
+	}
+
+	u, err := url.Parse(r.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrMalformedEntity
+	}
+
+	if !PublicHost(u.Hostname()) {
+		return ErrMalformedEntity
+	}
+
+	return nil
+}
+
+// PublicHost returns false if the host is localhost or the address that isn't
+// public. Host names other than localhost aren't resolved, so they have to be
+// checked again once they are.
+func PublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return PublicIP(ip)
+	}
+
+	return true
+}
+
+// privateNets are the private IPv4 (RFC 1918) and IPv6 (RFC 4193) ranges.
+var privateNets = parseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets
+}
+
+// PublicIP returns false for the loopback, link-local, private and unspecified
+// addresses, which webhooks mustn't target.
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Breached determines whether the message breaches the rule. Messages of
+// other channels, messages without numeric value and, if the rule name is
+// set, messages with different name never breach the rule.
+func (r Rule) Breached(msg mainflux.Message) bool {
+	if msg.Channel != r.ChanID || (r.Name != "" && msg.Name != r.Name) {
+		return false
+	}
+
+	v, ok := msg.Value.(*mainflux.Message_FloatValue)
+	if !ok {
+		return false
+	}
+
+	switch r.Operator {
+	case OpGreater:
+		return v.FloatValue > r.Threshold
+	case OpGreaterEqual:
+		return v.FloatValue >= r.Threshold
+	case OpLess:
+		return v.FloatValue < r.Threshold
+	case OpLessEqual:
+		return v.FloatValue <= r.Threshold
+	}
+
+	return false
+}
+
+// RuleRepository specifies a rule persistence API.
+type RuleRepository interface {
+	// Save persists the rule and returns its generated identifier.
+	Save(Rule) (string, error)
+
+	// RetrieveByChannel retrieves all the rules of the specified channel.
+	RetrieveByChannel(string) ([]Rule, error)
+
+	// Remove removes the rule having the provided identifier, that belongs
+	// to the specified channel.
+	Remove(string, string) error
+}
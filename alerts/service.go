@@ -0,0 +1,318 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package alerts contains the domain concept definitions needed to support
+// Mainflux alerts service functionality. Alerts service evaluates threshold
+// rules against the messages consumed from NATS and fires webhooks when the
+// rules are breached.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/logger"
+)
+
+var (
+	// ErrMalformedEntity indicates malformed entity specification (e.g.
+	// unsupported rule operator).
+	ErrMalformedEntity = errors.New("malformed entity specification")
+
+	// ErrUnauthorizedAccess indicates missing or invalid credentials provided
+	// when accessing a protected resource.
+	ErrUnauthorizedAccess = errors.New("missing or invalid credentials provided")
+
+	// ErrNotFound indicates a non-existent entity request.
+	ErrNotFound = errors.New("non-existent entity")
+
+	// ErrQueueFull indicates that the alert couldn't be queued for delivery
+	// because all of the delivery workers are busy and the queue is full.
+	ErrQueueFull = errors.New("alert delivery queue is full")
+)
+
+// Dispatch contains the alert delivery settings. Alerts are queued and
+// delivered by a fixed number of workers, so that slow webhooks don't block
+// the message consumption.
+type Dispatch struct {
+	// Workers is the number of concurrent deliveries.
+	Workers int
+
+	// QueueSize is the number of alerts waiting for a free worker.
+	QueueSize int
+
+	// Timeout is the maximum time to wait for a free queue slot before the
+	// alert is dropped with ErrQueueFull.
+	Timeout time.Duration
+}
+
+// Alert contains the breached rule and the message that breached it.
+type Alert struct {
+	Rule    Rule
+	Message mainflux.Message
+}
+
+// ChannelsService specifies an API used to verify that the user manages the
+// channel.
+type ChannelsService interface {
+	// Authorize returns ErrUnauthorizedAccess if the user token is invalid,
+	// or ErrNotFound if the channel doesn't exist or the user doesn't manage
+	// it.
+	Authorize(string, string) error
+}
+
+// Notifier specifies an API for delivering alerts.
+type Notifier interface {
+	// Notify delivers the alert to the rule webhook.
+	Notify(Alert) error
+}
+
+// Service specifies an API that must be fullfiled by the domain service
+// implementation, and all of its decorators (e.g. logging & metrics).
+type Service interface {
+	// AddRule adds the rule to the channel, if the user identified by the
+	// provided token manages it.
+	AddRule(context.Context, string, Rule) (Rule, error)
+
+	// ListRules retrieves the rules of the channel, if the user identified by
+	// the provided token manages it.
+	ListRules(context.Context, string, string) ([]Rule, error)
+
+	// RemoveRule removes the rule of the channel, if the user identified by
+	// the provided token manages it.
+	RemoveRule(context.Context, string, string, string) error
+
+	// Evaluate evaluates the rules of the message channel and queues the
+	// alerts of the breached rules whose cooldown period expired for
+	// delivery. ErrQueueFull is returned if an alert couldn't be queued.
+	Evaluate(mainflux.Message) error
+}
+
+// ruleCacheTTL bounds the time the cached rules of the channel are used, so
+// that the rules changed through the other replicas of the service take effect
+// as well.
+const ruleCacheTTL = time.Minute
+
+var _ Service = (*alertsService)(nil)
+
+type alertsService struct {
+	channels ChannelsService
+	rules    RuleRepository
+	notifier Notifier
+	queue    chan Alert
+	timeout  time.Duration
+	logger   logger.Logger
+	mu       sync.Mutex
+	fired    map[string]time.Time
+	cacheMu  sync.RWMutex
+	cache    map[string]cachedRules
+	version  uint64
+}
+
+// cachedRules are the rules of the channel, cached since they are evaluated
+// against every message of the channel.
+type cachedRules struct {
+	rules     []Rule
+	expiresAt time.Time
+}
+
+// New instantiates the alerts service implementation and starts the alert
+// delivery workers.
+func New(channels ChannelsService, rules RuleRepository, notifier Notifier, dispatch Dispatch, logger logger.Logger) Service {
+	if dispatch.Workers < 1 {
+		dispatch.Workers = 1
+	}
+	if dispatch.QueueSize < 0 {
+		dispatch.QueueSize = 0
+	}
+
+	as := &alertsService{
+		channels: channels,
+		rules:    rules,
+		notifier: notifier,
+		queue:    make(chan Alert, dispatch.QueueSize),
+		timeout:  dispatch.Timeout,
+		logger:   logger,
+		fired:    make(map[string]time.Time),
+		cache:    make(map[string]cachedRules),
+	}
+
+	for i := 0; i < dispatch.Workers; i++ {
+		go as.deliver()
+	}
+
+	return as
+}
+
+func (as *alertsService) AddRule(_ context.Context, token string, rule Rule) (Rule, error) {
+	if err := as.authorize(token, rule.ChanID); err != nil {
+		return Rule{}, err
+	}
+
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	id, err := as.rules.Save(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+	as.invalidate(rule.ChanID)
+
+	rule.ID = id
+	return rule, nil
+}
+
+func (as *alertsService) ListRules(_ context.Context, token, chanID string) ([]Rule, error) {
+	if err := as.authorize(token, chanID); err != nil {
+		return nil, err
+	}
+
+	return as.rules.RetrieveByChannel(chanID)
+}
+
+func (as *alertsService) RemoveRule(_ context.Context, token, chanID, id string) error {
+	if err := as.authorize(token, chanID); err != nil {
+		return err
+	}
+
+	if err := as.rules.Remove(chanID, id); err != nil {
+		return err
+	}
+	as.invalidate(chanID)
+
+	as.mu.Lock()
+	delete(as.fired, id)
+	as.mu.Unlock()
+
+	return nil
+}
+
+func (as *alertsService) Evaluate(msg mainflux.Message) error {
+	rules, err := as.channelRules(msg.Channel)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, rule := range rules {
+		if !rule.Breached(msg) || !as.cooledDown(rule) {
+			continue
+		}
+
+		if err := as.enqueue(Alert{Rule: rule, Message: msg}); err != nil {
+			as.reset(rule)
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// channelRules returns the rules of the channel, retrieving them from the
+// repository only if they aren't cached. Rules retrieved while the cache was
+// invalidated aren't cached, since they may be stale already.
+func (as *alertsService) channelRules(chanID string) ([]Rule, error) {
+	as.cacheMu.RLock()
+	cached, ok := as.cache[chanID]
+	version := as.version
+	as.cacheMu.RUnlock()
+
+	now := time.Now()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.rules, nil
+	}
+
+	rules, err := as.rules.RetrieveByChannel(chanID)
+	if err != nil {
+		return nil, err
+	}
+
+	as.cacheMu.Lock()
+	if as.version == version {
+		as.cache[chanID] = cachedRules{rules: rules, expiresAt: now.Add(ruleCacheTTL)}
+	}
+	as.cacheMu.Unlock()
+
+	return rules, nil
+}
+
+// invalidate removes the cached rules of the channel.
+func (as *alertsService) invalidate(chanID string) {
+	as.cacheMu.Lock()
+	defer as.cacheMu.Unlock()
+
+	delete(as.cache, chanID)
+	as.version++
+}
+
+func (as *alertsService) enqueue(alert Alert) error {
+	select {
+	case as.queue <- alert:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(as.timeout)
+	defer timer.Stop()
+
+	select {
+	case as.queue <- alert:
+		return nil
+	case <-timer.C:
+		return ErrQueueFull
+	}
+}
+
+func (as *alertsService) deliver() {
+	for alert := range as.queue {
+		if err := as.notifier.Notify(alert); err != nil {
+			// Cooldown starts only after the successful delivery, so that the
+			// next breach retries the failed one.
+			as.reset(alert.Rule)
+			as.logger.Warn(fmt.Sprintf("Failed to deliver alert for rule %s: %s", alert.Rule.ID, err))
+		}
+	}
+}
+
+// cooledDown checks whether the rule cooldown period expired and, if so,
+// marks the rule as fired.
+func (as *alertsService) cooledDown(rule Rule) bool {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := as.fired[rule.ID]; ok && now.Sub(last) < rule.Cooldown {
+		return false
+	}
+
+	as.fired[rule.ID] = now
+	return true
+}
+
+func (as *alertsService) reset(rule Rule) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	delete(as.fired, rule.ID)
+}
+
+func (as *alertsService) authorize(token, chanID string) error {
+	if token == "" {
+		return ErrUnauthorizedAccess
+	}
+
+	return as.channels.Authorize(token, chanID)
+}
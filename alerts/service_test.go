@@ -0,0 +1,329 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package alerts_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/alerts/mocks"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userToken = "user-token"
+	chanID    = "1"
+	webhook   = "http://webhook.example.com"
+)
+
+var dispatch = alerts.Dispatch{Workers: 2, QueueSize: 10, Timeout: 100 * time.Millisecond}
+
+func newService(dispatch alerts.Dispatch) (alerts.Service, *mocks.Notifier) {
+	channels := mocks.NewChannelsService(map[string]string{userToken: chanID})
+	notifier := mocks.NewNotifier()
+	logger, _ := logger.New(os.Stdout, logger.Info.String())
+	return alerts.New(channels, mocks.NewRuleRepository(), notifier, dispatch, logger), notifier
+}
+
+// waitAttempts waits for the queued alerts to be delivered, since the
+// delivery is asynchronous.
+func waitAttempts(notifier *mocks.Notifier, n int) {
+	deadline := time.Now().Add(time.Second)
+	for notifier.Attempts() < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func message(value float64) mainflux.Message {
+	return mainflux.Message{
+		Channel:   chanID,
+		Publisher: "1",
+		Protocol:  "http",
+		Name:      "temperature",
+		Value:     &mainflux.Message_FloatValue{FloatValue: value},
+	}
+}
+
+func TestAddRule(t *testing.T) {
+	svc, _ := newService(dispatch)
+
+	rule := alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 10, Webhook: webhook}
+	invalidOp := rule
+	invalidOp.Operator = "eq"
+	invalidWebhook := rule
+	invalidWebhook.Webhook = "webhook"
+	negativeCooldown := rule
+	negativeCooldown.Cooldown = -time.Second
+	otherChan := rule
+	otherChan.ChanID = "2"
+	loopbackWebhook := rule
+	loopbackWebhook.Webhook = "http://127.0.0.1:8080/alerts"
+	localhostWebhook := rule
+	localhostWebhook.Webhook = "http://localhost/alerts"
+	privateWebhook := rule
+	privateWebhook.Webhook = "https://10.0.0.1/alerts"
+	uniqueLocalWebhook := rule
+	uniqueLocalWebhook.Webhook = "http://[fd00::1]/alerts"
+	linkLocalWebhook := rule
+	linkLocalWebhook.Webhook = "http://169.254.169.254/latest/meta-data"
+
+	cases := []struct {
+		desc  string
+		token string
+		rule  alerts.Rule
+		err   error
+	}{
+		{
+			desc:  "add valid rule",
+			token: userToken,
+			rule:  rule,
+			err:   nil,
+		},
+		{
+			desc:  "add rule with unsupported operator",
+			token: userToken,
+			rule:  invalidOp,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with invalid webhook",
+			token: userToken,
+			rule:  invalidWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with negative cooldown",
+			token: userToken,
+			rule:  negativeCooldown,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule to channel without access",
+			token: userToken,
+			rule:  otherChan,
+			err:   alerts.ErrNotFound,
+		},
+		{
+			desc:  "add rule with invalid token",
+			token: "invalid",
+			rule:  rule,
+			err:   alerts.ErrUnauthorizedAccess,
+		},
+		{
+			desc:  "add rule with loopback webhook",
+			token: userToken,
+			rule:  loopbackWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with localhost webhook",
+			token: userToken,
+			rule:  localhostWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with private webhook",
+			token: userToken,
+			rule:  privateWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with unique local IPv6 webhook",
+			token: userToken,
+			rule:  uniqueLocalWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+		{
+			desc:  "add rule with link-local webhook",
+			token: userToken,
+			rule:  linkLocalWebhook,
+			err:   alerts.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.AddRule(context.Background(), tc.token, tc.rule)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestRemoveRule(t *testing.T) {
+	svc, _ := newService(dispatch)
+
+	rule, err := svc.AddRule(context.Background(), userToken, alerts.Rule{ChanID: chanID, Operator: alerts.OpLess, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "remove existing rule",
+			id:   rule.ID,
+			err:  nil,
+		},
+		{
+			desc: "remove removed rule",
+			id:   rule.ID,
+			err:  alerts.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.RemoveRule(context.Background(), userToken, chanID, tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+
+	rules, err := svc.ListRules(context.Background(), userToken, chanID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Empty(t, rules, "expected no rules after removal")
+}
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		desc   string
+		rule   alerts.Rule
+		values []float64
+		fired  int
+	}{
+		{
+			desc:   "fire breached rule",
+			rule:   alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 30, Webhook: webhook},
+			values: []float64{35},
+			fired:  1,
+		},
+		{
+			desc:   "don't fire rule that is not breached",
+			rule:   alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 30, Webhook: webhook},
+			values: []float64{25, 30},
+			fired:  0,
+		},
+		{
+			desc:   "don't fire rule for messages with other name",
+			rule:   alerts.Rule{ChanID: chanID, Name: "humidity", Operator: alerts.OpGreaterEqual, Threshold: 30, Webhook: webhook},
+			values: []float64{35},
+			fired:  0,
+		},
+		{
+			desc:   "suppress repeated breaches during cooldown",
+			rule:   alerts.Rule{ChanID: chanID, Operator: alerts.OpLessEqual, Threshold: 0, Webhook: webhook, Cooldown: time.Hour},
+			values: []float64{-1, -2, 0},
+			fired:  1,
+		},
+		{
+			desc:   "fire every breach without cooldown",
+			rule:   alerts.Rule{ChanID: chanID, Operator: alerts.OpLess, Threshold: 0, Webhook: webhook},
+			values: []float64{-1, -2, -3},
+			fired:  3,
+		},
+	}
+
+	for _, tc := range cases {
+		svc, notifier := newService(dispatch)
+		rule, err := svc.AddRule(context.Background(), userToken, tc.rule)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		for _, v := range tc.values {
+			err := svc.Evaluate(message(v))
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		}
+
+		waitAttempts(notifier, tc.fired)
+		fired := notifier.Alerts()
+		assert.Equal(t, tc.fired, len(fired), fmt.Sprintf("%s: expected %d alerts got %d", tc.desc, tc.fired, len(fired)))
+		for _, alert := range fired {
+			assert.Equal(t, rule.ID, alert.Rule.ID, fmt.Sprintf("%s: expected rule %s got %s", tc.desc, rule.ID, alert.Rule.ID))
+		}
+	}
+}
+
+func TestEvaluateCachesRules(t *testing.T) {
+	channels := mocks.NewChannelsService(map[string]string{userToken: chanID})
+	repo := mocks.NewRuleRepository()
+	notifier := mocks.NewNotifier()
+	logger, _ := logger.New(os.Stdout, logger.Info.String())
+	svc := alerts.New(channels, repo, notifier, dispatch, logger)
+
+	rule, err := svc.AddRule(context.Background(), userToken, alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 0, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, svc.Evaluate(message(1)))
+	}
+	assert.Equal(t, 1, repo.Retrievals(), fmt.Sprintf("expected 1 retrieval got %d", repo.Retrievals()))
+
+	err = svc.RemoveRule(context.Background(), userToken, chanID, rule.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Nil(t, svc.Evaluate(message(1)))
+	assert.Equal(t, 2, repo.Retrievals(), fmt.Sprintf("expected 2 retrievals got %d", repo.Retrievals()))
+
+	waitAttempts(notifier, 3)
+	assert.Equal(t, 3, len(notifier.Alerts()), "expected removed rule not to fire")
+
+	_, err = svc.AddRule(context.Background(), userToken, alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 0, Webhook: webhook})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Nil(t, svc.Evaluate(message(1)))
+	assert.Equal(t, 3, repo.Retrievals(), fmt.Sprintf("expected 3 retrievals got %d", repo.Retrievals()))
+}
+
+func TestEvaluateCooldownExpiry(t *testing.T) {
+	svc, notifier := newService(dispatch)
+	rule := alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 0, Webhook: webhook, Cooldown: 50 * time.Millisecond}
+	_, err := svc.AddRule(context.Background(), userToken, rule)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	require.Nil(t, svc.Evaluate(message(1)))
+	require.Nil(t, svc.Evaluate(message(2)))
+	waitAttempts(notifier, 1)
+	assert.Equal(t, 1, len(notifier.Alerts()), "expected breach during cooldown to be suppressed")
+
+	time.Sleep(60 * time.Millisecond)
+	require.Nil(t, svc.Evaluate(message(3)))
+	waitAttempts(notifier, 2)
+	assert.Equal(t, 2, len(notifier.Alerts()), "expected breach after cooldown to fire")
+}
+
+func TestEvaluateFailedDelivery(t *testing.T) {
+	svc, notifier := newService(dispatch)
+	rule := alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 0, Webhook: mocks.FailingWebhook, Cooldown: time.Hour}
+	_, err := svc.AddRule(context.Background(), userToken, rule)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Failed delivery doesn't start the cooldown, so every breach is retried.
+	for i := 1; i <= 2; i++ {
+		err := svc.Evaluate(message(1))
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		waitAttempts(notifier, i)
+		assert.Equal(t, i, notifier.Attempts(), fmt.Sprintf("expected %d delivery attempts got %d", i, notifier.Attempts()))
+	}
+}
+
+func TestEvaluateQueueFull(t *testing.T) {
+	svc, notifier := newService(alerts.Dispatch{Workers: 1, QueueSize: 1, Timeout: 10 * time.Millisecond})
+	defer notifier.Release()
+
+	rule := alerts.Rule{ChanID: chanID, Operator: alerts.OpGreater, Threshold: 0, Webhook: mocks.BlockingWebhook}
+	_, err := svc.AddRule(context.Background(), userToken, rule)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// The first alert occupies the only worker and the second one the queue.
+	require.Nil(t, svc.Evaluate(message(1)))
+	waitAttempts(notifier, 1)
+	require.Nil(t, svc.Evaluate(message(2)))
+
+	err = svc.Evaluate(message(3))
+	assert.Equal(t, alerts.ErrQueueFull, err, fmt.Sprintf("expected %s got %s", alerts.ErrQueueFull, err))
+}
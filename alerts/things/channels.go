@@ -0,0 +1,41 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package things contains the channels service implementation that verifies
+// the channels are managed by the users using the things service HTTP API.
+package things
+
+import (
+	"github.com/mainflux/mainflux/alerts"
+	mfsdk "github.com/mainflux/mainflux/sdk/go"
+)
+
+var _ alerts.ChannelsService = (*channelsService)(nil)
+
+type channelsService struct {
+	sdk mfsdk.SDK
+}
+
+// NewChannelsService instantiates the channels service that considers the
+// channel managed by the user if the user can view it.
+func NewChannelsService(sdk mfsdk.SDK) alerts.ChannelsService {
+	return channelsService{sdk: sdk}
+}
+
+func (cs channelsService) Authorize(token, chanID string) error {
+	_, err := cs.sdk.Channel(chanID, token)
+	switch err {
+	case nil:
+		return nil
+	case mfsdk.ErrUnauthorized:
+		return alerts.ErrUnauthorizedAccess
+	case mfsdk.ErrNotFound:
+		return alerts.ErrNotFound
+	default:
+		return err
+	}
+}
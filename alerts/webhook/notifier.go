@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package webhook contains the notifier that delivers alerts by sending them
+// to the rule webhook using HTTP POST request. Requests are signed with the
+// shared secret, if configured, so that the receivers can verify them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+)
+
+const contentType = "application/json"
+
+// ErrDelivery indicates that the webhook did not accept the alert, i.e. it
+// responded with the status other than 2xx.
+var ErrDelivery = errors.New("failed to deliver alert")
+
+// ErrPrivateTarget indicates that the webhook host resolved to the address
+// that isn't public.
+var ErrPrivateTarget = errors.New("webhook target is not public")
+
+var _ alerts.Notifier = (*notifier)(nil)
+
+type alertReq struct {
+	RuleID    string           `json:"rule_id"`
+	Channel   string           `json:"channel"`
+	Name      string           `json:"name,omitempty"`
+	Operator  string           `json:"operator"`
+	Threshold float64          `json:"threshold"`
+	Message   mainflux.Message `json:"message"`
+}
+
+type notifier struct {
+	client http.Client
+	secret string
+}
+
+// New instantiates webhook notifier whose requests time out after the
+// provided duration. Requests are signed using the secret, unless it is
+// empty. Unless the private targets are allowed, connections to the
+// addresses that aren't public are refused.
+func New(timeout time.Duration, secret string, allowPrivate bool) alerts.Notifier {
+	dialer := &net.Dialer{Timeout: timeout}
+	dial := dialer.DialContext
+	if !allowPrivate {
+		dial = guard(dialer)
+	}
+
+	return notifier{
+		client: http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dial},
+		},
+		secret: secret,
+	}
+}
+
+func (n notifier) Notify(alert alerts.Alert) error {
+	req := alertReq{
+		RuleID:    alert.Rule.ID,
+		Channel:   alert.Rule.ChanID,
+		Name:      alert.Rule.Name,
+		Operator:  alert.Rule.Operator,
+		Threshold: alert.Rule.Threshold,
+		Message:   alert.Message,
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequest(http.MethodPost, alert.Rule.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", contentType)
+	if n.secret != "" {
+		r.Header.Set(SignatureHeader, Sign(n.secret, data, time.Now()))
+	}
+
+	res, err := n.client.Do(r)
+	if err != nil {
+		if e, ok := err.(*url.Error); ok && e.Err == ErrPrivateTarget {
+			return ErrPrivateTarget
+		}
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return ErrDelivery
+	}
+
+	return nil
+}
+
+// guard wraps the dialer so that the connections to the addresses that aren't
+// public are refused. Host name is resolved before dialing and the resolved
+// address is dialed, so the check covers the host names resolving to such
+// addresses and the redirects as well, and the host can't resolve to another
+// address in between.
+func guard(dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			if !alerts.PublicIP(addr.IP) {
+				return nil, ErrPrivateTarget
+			}
+		}
+
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: host}
+		}
+
+		return dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].IP.String(), port))
+	}
+}
@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package webhook_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/alerts/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	secret    = "secret"
+	wrong     = "wrong"
+	timeout   = time.Second
+	tolerance = 5 * time.Minute
+)
+
+type request struct {
+	header string
+	body   []byte
+}
+
+func newServer(reqs chan<- request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		reqs <- request{header: r.Header.Get(webhook.SignatureHeader), body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestNotifySigned(t *testing.T) {
+	reqs := make(chan request, 1)
+	ts := newServer(reqs)
+	defer ts.Close()
+
+	alert := alerts.Alert{
+		Rule:    alerts.Rule{ID: "1", ChanID: "1", Operator: alerts.OpGreater, Webhook: ts.URL},
+		Message: mainflux.Message{Channel: "1", Publisher: "1"},
+	}
+
+	n := webhook.New(timeout, secret, true)
+	err := n.Notify(alert)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	req := <-reqs
+
+	cases := []struct {
+		desc   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		err    error
+	}{
+		{
+			desc:   "verify signature with shared secret",
+			secret: secret,
+			header: req.header,
+			body:   req.body,
+			now:    time.Now(),
+			err:    nil,
+		},
+		{
+			desc:   "verify signature with wrong secret",
+			secret: wrong,
+			header: req.header,
+			body:   req.body,
+			now:    time.Now(),
+			err:    webhook.ErrInvalidSignature,
+		},
+		{
+			desc:   "verify signature of tampered body",
+			secret: secret,
+			header: req.header,
+			body:   append(req.body, ' '),
+			now:    time.Now(),
+			err:    webhook.ErrInvalidSignature,
+		},
+		{
+			desc:   "verify replayed signature",
+			secret: secret,
+			header: req.header,
+			body:   req.body,
+			now:    time.Now().Add(2 * tolerance),
+			err:    webhook.ErrStaleSignature,
+		},
+		{
+			desc:   "verify malformed signature",
+			secret: secret,
+			header: "signature",
+			body:   req.body,
+			now:    time.Now(),
+			err:    webhook.ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range cases {
+		err := webhook.Verify(tc.secret, tc.header, tc.body, tolerance, tc.now)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestNotifyUnsigned(t *testing.T) {
+	reqs := make(chan request, 1)
+	ts := newServer(reqs)
+	defer ts.Close()
+
+	alert := alerts.Alert{
+		Rule: alerts.Rule{ID: "1", ChanID: "1", Operator: alerts.OpGreater, Webhook: ts.URL},
+	}
+
+	n := webhook.New(timeout, "", true)
+	err := n.Notify(alert)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	req := <-reqs
+	assert.Empty(t, req.header, fmt.Sprintf("expected no signature got %s", req.header))
+}
+
+func TestNotifyRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	alert := alerts.Alert{
+		Rule: alerts.Rule{ID: "1", ChanID: "1", Operator: alerts.OpGreater, Webhook: ts.URL},
+	}
+
+	n := webhook.New(timeout, secret, true)
+	err := n.Notify(alert)
+	assert.Equal(t, webhook.ErrDelivery, err, fmt.Sprintf("expected %s got %s", webhook.ErrDelivery, err))
+}
+
+func TestNotifyPrivateTarget(t *testing.T) {
+	reqs := make(chan request, 1)
+	ts := newServer(reqs)
+	defer ts.Close()
+
+	alert := alerts.Alert{
+		Rule: alerts.Rule{ID: "1", ChanID: "1", Operator: alerts.OpGreater, Webhook: ts.URL},
+	}
+
+	n := webhook.New(timeout, secret, false)
+	err := n.Notify(alert)
+	assert.Equal(t, webhook.ErrPrivateTarget, err, fmt.Sprintf("expected %s got %s", webhook.ErrPrivateTarget, err))
+	assert.Empty(t, reqs, "expected no request to reach the private target")
+}
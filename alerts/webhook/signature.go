@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header carrying the webhook request signature.
+const SignatureHeader = "X-Mainflux-Signature"
+
+const (
+	timestampKey = "t"
+	signatureKey = "v1"
+)
+
+var (
+	// ErrInvalidSignature indicates malformed signature header or the
+	// signature not matching the request body.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrStaleSignature indicates that the request was signed too long ago,
+	// which is the case with the replayed requests.
+	ErrStaleSignature = errors.New("stale webhook signature")
+)
+
+// Sign returns the signature header value of the request body sent at the
+// given time. The header has the form t=<timestamp>,v1=<signature>, where the
+// signature is the hex encoded HMAC-SHA256 of <timestamp>.<body> keyed with
+// the shared secret. The timestamp is given in seconds since the Unix epoch.
+func Sign(secret string, body []byte, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("%s=%s,%s=%s", timestampKey, ts, signatureKey, hex.EncodeToString(mac(secret, ts, body)))
+}
+
+// Verify checks the signature header value against the request body and the
+// shared secret. Signatures older, or newer, than the tolerance relative to
+// the given time are rejected, so that the captured requests can't be
+// replayed.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidSignature
+		}
+
+		switch kv[0] {
+		case timestampKey:
+			ts = kv[1]
+		case signatureKey:
+			sig = kv[1]
+		}
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, mac(secret, ts, body)) {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(sec, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleSignature
+	}
+
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
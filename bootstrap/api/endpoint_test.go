@@ -144,7 +144,7 @@ func newThingsService(users mainflux.UsersServiceClient) things.Service {
 }
 
 func newThingsServer(svc things.Service) *httptest.Server {
-	mux := thingsapi.MakeHandler(mocktracer.New(), svc)
+	mux := thingsapi.MakeHandler(mocktracer.New(), svc, "", nil)
 	return httptest.NewServer(mux)
 }
 
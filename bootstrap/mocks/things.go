@@ -11,6 +11,7 @@ import (
 	"context"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/things"
@@ -72,7 +73,7 @@ func (svc *mainfluxThings) ViewThing(_ context.Context, owner, id string) (thing
 	return things.Thing{}, things.ErrNotFound
 }
 
-func (svc *mainfluxThings) Connect(_ context.Context, owner, chanID, thingID string) error {
+func (svc *mainfluxThings) Connect(_ context.Context, owner, chanID, thingID string, _ time.Duration) error {
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
@@ -162,11 +163,15 @@ func (svc *mainfluxThings) UpdateThing(context.Context, string, things.Thing) er
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) PatchThingMetadata(context.Context, string, string, map[string]interface{}) (map[string]interface{}, error) {
+	panic("not implemented")
+}
+
 func (svc *mainfluxThings) UpdateKey(context.Context, string, string, string) error {
 	panic("not implemented")
 }
 
-func (svc *mainfluxThings) ListThings(context.Context, string, uint64, uint64, string) (things.ThingsPage, error) {
+func (svc *mainfluxThings) ListThings(context.Context, string, uint64, uint64, string, bool) (things.ThingsPage, error) {
 	panic("not implemented")
 }
 
@@ -174,10 +179,30 @@ func (svc *mainfluxThings) ListChannelsByThing(context.Context, string, string,
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) NameAvailable(context.Context, string, string) (bool, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) AddThings(context.Context, string, []things.Thing, bool) ([]things.BulkResult, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) ProvisionThing(context.Context, string, things.Thing) (things.Provision, error) {
+	panic("not implemented")
+}
+
 func (svc *mainfluxThings) ListThingsByChannel(context.Context, string, string, uint64, uint64) (things.ThingsPage, error) {
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) ListThingsByChannelAfter(context.Context, string, string, string, uint64) ([]things.Thing, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) ListThingsByChannels(context.Context, string, []string, uint64, uint64) (things.ThingsPage, error) {
+	panic("not implemented")
+}
+
 func (svc *mainfluxThings) CreateChannel(context.Context, string, things.Channel) (things.Channel, error) {
 	panic("not implemented")
 }
@@ -186,11 +211,47 @@ func (svc *mainfluxThings) UpdateChannel(context.Context, string, things.Channel
 	panic("not implemented")
 }
 
-func (svc *mainfluxThings) ListChannels(context.Context, string, uint64, uint64, string) (things.ChannelsPage, error) {
+func (svc *mainfluxThings) ListChannels(context.Context, string, uint64, uint64, string, string, bool) (things.ChannelsPage, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) RemoveChannel(context.Context, string, string, bool) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) IssueChannelToken(context.Context, string, string, time.Duration, string) (string, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) RevokeChannelTokens(context.Context, string, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) CreateShareLink(context.Context, string, string, time.Duration) (things.ShareLink, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) RevokeShareLink(context.Context, string, string, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) CanReadShared(context.Context, string, string) error {
 	panic("not implemented")
 }
 
-func (svc *mainfluxThings) RemoveChannel(context.Context, string, string) error {
+func (svc *mainfluxThings) IssueAPIKey(context.Context, string, []string, time.Duration) (things.APIKey, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) ListAPIKeys(context.Context, string) ([]things.APIKey, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) RevokeAPIKey(context.Context, string, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) ReapIdle(context.Context, string, string, time.Duration) ([]string, error) {
 	panic("not implemented")
 }
 
@@ -198,6 +259,10 @@ func (svc *mainfluxThings) CanAccess(context.Context, string, string) (string, e
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) CanReadMessages(context.Context, string, string) (string, bool, error) {
+	panic("not implemented")
+}
+
 func (svc *mainfluxThings) CanAccessByID(context.Context, string, string) error {
 	panic("not implemented")
 }
@@ -206,6 +271,38 @@ func (svc *mainfluxThings) Identify(context.Context, string) (string, error) {
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) IdentifyFull(context.Context, string) (things.Thing, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) IdentifyBatch(context.Context, []string) (map[string]string, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) RotateExpiredKeys(context.Context) ([]string, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) DisconnectExpired(context.Context) ([]things.Connection, error) {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) DisableOwner(context.Context, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) EnableOwner(context.Context, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) ChangeOwnerEmail(context.Context, string, string) error {
+	panic("not implemented")
+}
+
+func (svc *mainfluxThings) Subscribe(context.Context, string) (<-chan things.Event, error) {
+	panic("not implemented")
+}
+
 func findIndex(list []string, val string) int {
 	for i, v := range list {
 		if v == val {
@@ -0,0 +1,276 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/alerts"
+	"github.com/mainflux/mainflux/alerts/api"
+	"github.com/mainflux/mainflux/alerts/postgres"
+	"github.com/mainflux/mainflux/alerts/things"
+	"github.com/mainflux/mainflux/alerts/webhook"
+	"github.com/mainflux/mainflux/logger"
+	mfsdk "github.com/mainflux/mainflux/sdk/go"
+	"github.com/mainflux/mainflux/writers"
+	broker "github.com/nats-io/go-nats"
+	opentracing "github.com/opentracing/opentracing-go"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	jconfig "github.com/uber/jaeger-client-go/config"
+)
+
+const (
+	svcName = "alerts"
+
+	defPort                = "8190"
+	defDBHost              = "localhost"
+	defDBPort              = "5432"
+	defDBUser              = "mainflux"
+	defDBPass              = "mainflux"
+	defDBName              = "alerts"
+	defDBSSLMode           = "disable"
+	defDBSSLCert           = ""
+	defDBSSLKey            = ""
+	defDBSSLRootCert       = ""
+	defLogLevel            = "error"
+	defNatsURL             = broker.DefaultURL
+	defBaseURL             = "http://localhost"
+	defThingsPrefix        = ""
+	defJaegerURL           = ""
+	defWebhookTimeout      = "5" // in seconds
+	defWebhookSecret       = ""
+	defWebhookAllowPrivate = "false"
+	defWorkers             = "10"
+	defQueueSize           = "1000"
+	defQueueTimeout        = "100" // in milliseconds
+
+	envPort                = "MF_ALERTS_HTTP_PORT"
+	envDBHost              = "MF_ALERTS_DB_HOST"
+	envDBPort              = "MF_ALERTS_DB_PORT"
+	envDBUser              = "MF_ALERTS_DB_USER"
+	envDBPass              = "MF_ALERTS_DB_PASS"
+	envDBName              = "MF_ALERTS_DB"
+	envDBSSLMode           = "MF_ALERTS_DB_SSL_MODE"
+	envDBSSLCert           = "MF_ALERTS_DB_SSL_CERT"
+	envDBSSLKey            = "MF_ALERTS_DB_SSL_KEY"
+	envDBSSLRootCert       = "MF_ALERTS_DB_SSL_ROOT_CERT"
+	envLogLevel            = "MF_ALERTS_LOG_LEVEL"
+	envNatsURL             = "MF_NATS_URL"
+	envBaseURL             = "MF_SDK_BASE_URL"
+	envThingsPrefix        = "MF_SDK_THINGS_PREFIX"
+	envJaegerURL           = "MF_JAEGER_URL"
+	envWebhookTimeout      = "MF_ALERTS_WEBHOOK_TIMEOUT"
+	envWebhookSecret       = "MF_ALERTS_WEBHOOK_SECRET"
+	envWebhookAllowPrivate = "MF_ALERTS_WEBHOOK_ALLOW_PRIVATE"
+	envWorkers             = "MF_ALERTS_WORKERS"
+	envQueueSize           = "MF_ALERTS_QUEUE_SIZE"
+	envQueueTimeout        = "MF_ALERTS_QUEUE_TIMEOUT"
+)
+
+type config struct {
+	natsURL             string
+	logLevel            string
+	port                string
+	dbConfig            postgres.Config
+	baseURL             string
+	thingsPrefix        string
+	jaegerURL           string
+	webhookTimeout      time.Duration
+	webhookSecret       string
+	webhookAllowPrivate bool
+	dispatch            alerts.Dispatch
+}
+
+func main() {
+	cfg := loadConfig()
+
+	logger, err := logger.New(os.Stdout, cfg.logLevel)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	nc, err := broker.Connect(cfg.natsURL)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		os.Exit(1)
+	}
+	defer nc.Close()
+
+	db := connectToDB(cfg.dbConfig, logger)
+	defer db.Close()
+
+	tracer, closer := initJaeger(svcName, cfg.jaegerURL, logger)
+	defer closer.Close()
+
+	sdk := mfsdk.NewSDK(mfsdk.Config{
+		BaseURL:      cfg.baseURL,
+		ThingsPrefix: cfg.thingsPrefix,
+	})
+
+	notifier := webhook.New(cfg.webhookTimeout, cfg.webhookSecret, cfg.webhookAllowPrivate)
+	svc := alerts.New(things.NewChannelsService(sdk), postgres.NewRuleRepository(db), notifier, cfg.dispatch, logger)
+	svc = api.LoggingMiddleware(svc, logger)
+	svc = api.MetricsMiddleware(
+		svc,
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: svcName,
+			Subsystem: "api",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, []string{"method"}),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: svcName,
+			Subsystem: "api",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, []string{"method"}),
+	)
+
+	channels := map[string]bool{"*": true}
+	if _, err := writers.Start(nc, alerts.NewConsumer(svc), mainflux.OutputSenML, svcName, channels, logger, writers.WithMetrics(makeLagGauge(), makeFailuresCounter())); err != nil {
+		logger.Error(fmt.Sprintf("Failed to start alerts consumer: %s", err))
+		os.Exit(1)
+	}
+
+	errs := make(chan error, 2)
+
+	go func() {
+		p := fmt.Sprintf(":%s", cfg.port)
+		logger.Info(fmt.Sprintf("Alerts service started on port %s", cfg.port))
+		errs <- http.ListenAndServe(p, api.MakeHandler(svc, tracer))
+	}()
+
+	go func() {
+		c := make(chan os.Signal)
+		signal.Notify(c, syscall.SIGINT)
+		errs <- fmt.Errorf("%s", <-c)
+	}()
+
+	err = <-errs
+	logger.Error(fmt.Sprintf("Alerts service terminated: %s", err))
+}
+
+func loadConfig() config {
+	dbConfig := postgres.Config{
+		Host:        mainflux.Env(envDBHost, defDBHost),
+		Port:        mainflux.Env(envDBPort, defDBPort),
+		User:        mainflux.Env(envDBUser, defDBUser),
+		Pass:        mainflux.Env(envDBPass, defDBPass),
+		Name:        mainflux.Env(envDBName, defDBName),
+		SSLMode:     mainflux.Env(envDBSSLMode, defDBSSLMode),
+		SSLCert:     mainflux.Env(envDBSSLCert, defDBSSLCert),
+		SSLKey:      mainflux.Env(envDBSSLKey, defDBSSLKey),
+		SSLRootCert: mainflux.Env(envDBSSLRootCert, defDBSSLRootCert),
+	}
+
+	webhookTimeout, err := strconv.ParseInt(mainflux.Env(envWebhookTimeout, defWebhookTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envWebhookTimeout, err.Error())
+	}
+
+	allowPrivate, err := strconv.ParseBool(mainflux.Env(envWebhookAllowPrivate, defWebhookAllowPrivate))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envWebhookAllowPrivate, err.Error())
+	}
+
+	workers, err := strconv.Atoi(mainflux.Env(envWorkers, defWorkers))
+	if err != nil || workers < 1 {
+		log.Fatalf("Invalid value passed for %s\n", envWorkers)
+	}
+
+	queueSize, err := strconv.Atoi(mainflux.Env(envQueueSize, defQueueSize))
+	if err != nil || queueSize < 0 {
+		log.Fatalf("Invalid value passed for %s\n", envQueueSize)
+	}
+
+	queueTimeout, err := strconv.ParseInt(mainflux.Env(envQueueTimeout, defQueueTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envQueueTimeout, err.Error())
+	}
+
+	return config{
+		natsURL:             mainflux.Env(envNatsURL, defNatsURL),
+		logLevel:            mainflux.Env(envLogLevel, defLogLevel),
+		port:                mainflux.Env(envPort, defPort),
+		dbConfig:            dbConfig,
+		baseURL:             mainflux.Env(envBaseURL, defBaseURL),
+		thingsPrefix:        mainflux.Env(envThingsPrefix, defThingsPrefix),
+		jaegerURL:           mainflux.Env(envJaegerURL, defJaegerURL),
+		webhookTimeout:      time.Duration(webhookTimeout) * time.Second,
+		webhookSecret:       mainflux.Env(envWebhookSecret, defWebhookSecret),
+		webhookAllowPrivate: allowPrivate,
+		dispatch: alerts.Dispatch{
+			Workers:   workers,
+			QueueSize: queueSize,
+			Timeout:   time.Duration(queueTimeout) * time.Millisecond,
+		},
+	}
+}
+
+func connectToDB(cfg postgres.Config, logger logger.Logger) *sqlx.DB {
+	db, err := postgres.Connect(cfg)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to postgres: %s", err))
+		os.Exit(1)
+	}
+	return db
+}
+
+func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer, io.Closer) {
+	if url == "" {
+		return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
+	}
+
+	tracer, closer, err := jconfig.Configuration{
+		ServiceName: svcName,
+		Sampler: &jconfig.SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+		Reporter: &jconfig.ReporterConfig{
+			LocalAgentHostPort: url,
+			LogSpans:           true,
+		},
+	}.NewTracer()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init Jaeger client: %s", err))
+		os.Exit(1)
+	}
+
+	return tracer, closer
+}
+
+func makeLagGauge() *kitprometheus.Gauge {
+	return kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: svcName,
+		Subsystem: "message_consumer",
+		Name:      "consumption_lag_seconds",
+		Help:      "Difference between the consumption time and the time of the last consumed message.",
+	}, []string{})
+}
+
+func makeFailuresCounter() *kitprometheus.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: svcName,
+		Subsystem: "message_consumer",
+		Name:      "save_failures",
+		Help:      "Number of messages that failed to save, by error class and handling action.",
+	}, []string{"class", "action"})
+}
@@ -50,6 +50,14 @@ const (
 	defCACerts       = ""
 	defJaegerURL     = ""
 	defThingsTimeout = "1" // in seconds
+	defLenientQuery  = "true"
+	defMaxTimeSpan   = "0s"
+	defOffsetWarning = "0"
+	defCacheTTL      = "0s"
+	defCacheSize     = "1000"
+	defAdminToken    = ""
+	defMaxRows       = "100000"
+	defPageSize      = "0"
 
 	envLogLevel      = "MF_CASSANDRA_READER_LOG_LEVEL"
 	envPort          = "MF_CASSANDRA_READER_PORT"
@@ -63,6 +71,14 @@ const (
 	envCACerts       = "MF_CASSANDRA_READER_CA_CERTS"
 	envJaegerURL     = "MF_JAEGER_URL"
 	envThingsTimeout = "MF_CASSANDRA_READER_THINGS_TIMEOUT"
+	envLenientQuery  = "MF_CASSANDRA_READER_LENIENT_QUERY"
+	envMaxTimeSpan   = "MF_CASSANDRA_READER_MAX_TIME_SPAN"
+	envOffsetWarning = "MF_CASSANDRA_READER_OFFSET_WARNING"
+	envCacheTTL      = "MF_CASSANDRA_READER_CACHE_TTL"
+	envCacheSize     = "MF_CASSANDRA_READER_CACHE_SIZE"
+	envAdminToken    = "MF_CASSANDRA_READER_ADMIN_TOKEN"
+	envMaxRows       = "MF_CASSANDRA_READER_MAX_ROWS"
+	envPageSize      = "MF_CASSANDRA_READER_PAGE_SIZE"
 )
 
 type config struct {
@@ -74,6 +90,14 @@ type config struct {
 	caCerts       string
 	jaegerURL     string
 	thingsTimeout time.Duration
+	lenientQuery  bool
+	maxTimeSpan   time.Duration
+	offsetWarning uint64
+	cacheTTL      time.Duration
+	cacheSize     int
+	adminToken    string
+	maxRows       uint64
+	pageSize      int
 }
 
 func main() {
@@ -94,11 +118,11 @@ func main() {
 	defer thingsCloser.Close()
 
 	tc := thingsapi.NewClient(conn, thingsTracer, cfg.thingsTimeout)
-	repo := newService(session, logger)
+	repo := newService(session, cfg.maxRows, cfg.pageSize, cfg.cacheTTL, cfg.cacheSize, logger)
 
 	errs := make(chan error, 2)
 
-	go startHTTPServer(repo, tc, cfg.port, errs, logger)
+	go startHTTPServer(repo, tc, cfg.lenientQuery, cfg.maxTimeSpan, cfg.offsetWarning, cfg.adminToken, cfg.port, errs, logger)
 
 	go func() {
 		c := make(chan os.Signal)
@@ -134,6 +158,41 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envThingsTimeout, err.Error())
 	}
 
+	lenient, err := strconv.ParseBool(mainflux.Env(envLenientQuery, defLenientQuery))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envLenientQuery)
+	}
+
+	maxTimeSpan, err := time.ParseDuration(mainflux.Env(envMaxTimeSpan, defMaxTimeSpan))
+	if err != nil || maxTimeSpan < 0 {
+		log.Fatalf("Invalid %s value", envMaxTimeSpan)
+	}
+
+	offsetWarning, err := strconv.ParseUint(mainflux.Env(envOffsetWarning, defOffsetWarning), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envOffsetWarning)
+	}
+
+	cacheTTL, err := time.ParseDuration(mainflux.Env(envCacheTTL, defCacheTTL))
+	if err != nil || cacheTTL < 0 {
+		log.Fatalf("Invalid %s value", envCacheTTL)
+	}
+
+	cacheSize, err := strconv.Atoi(mainflux.Env(envCacheSize, defCacheSize))
+	if err != nil || cacheSize <= 0 {
+		log.Fatalf("Invalid %s value", envCacheSize)
+	}
+
+	maxRows, err := strconv.ParseUint(mainflux.Env(envMaxRows, defMaxRows), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envMaxRows)
+	}
+
+	pageSize, err := strconv.Atoi(mainflux.Env(envPageSize, defPageSize))
+	if err != nil || pageSize < 0 {
+		log.Fatalf("Invalid %s value", envPageSize)
+	}
+
 	return config{
 		logLevel:      mainflux.Env(envLogLevel, defLogLevel),
 		port:          mainflux.Env(envPort, defPort),
@@ -143,6 +202,14 @@ func loadConfig() config {
 		caCerts:       mainflux.Env(envCACerts, defCACerts),
 		jaegerURL:     mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsTimeout: time.Duration(timeout) * time.Second,
+		lenientQuery:  lenient,
+		maxTimeSpan:   maxTimeSpan,
+		offsetWarning: offsetWarning,
+		cacheTTL:      cacheTTL,
+		cacheSize:     cacheSize,
+		adminToken:    mainflux.Env(envAdminToken, defAdminToken),
+		maxRows:       maxRows,
+		pageSize:      pageSize,
 	}
 }
 
@@ -204,8 +271,11 @@ func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer,
 	return tracer, closer
 }
 
-func newService(session *gocql.Session, logger logger.Logger) readers.MessageRepository {
-	repo := cassandra.New(session)
+func newService(session *gocql.Session, maxRows uint64, pageSize int, cacheTTL time.Duration, cacheSize int, logger logger.Logger) readers.MessageRepository {
+	repo := cassandra.New(session, maxRows, pageSize)
+	if cacheTTL > 0 {
+		repo = readers.NewCachedRepository(repo, cacheTTL, cacheSize)
+	}
 	repo = api.LoggingMiddleware(repo, logger)
 	repo = api.MetricsMiddleware(
 		repo,
@@ -226,8 +296,8 @@ func newService(session *gocql.Session, logger logger.Logger) readers.MessageRep
 	return repo
 }
 
-func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, port string, errs chan error, logger logger.Logger) {
+func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, lenient bool, maxTimeSpan time.Duration, offsetWarning uint64, adminToken string, port string, errs chan error, logger logger.Logger) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Cassandra reader service started, exposed port %s", port))
-	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "cassandra-reader"))
+	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, lenient, maxTimeSpan, offsetWarning, nil, adminToken, "cassandra-reader"))
 }
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
@@ -34,33 +35,59 @@ const (
 	svcName = "cassandra-writer"
 	sep     = ","
 
-	defNatsURL     = nats.DefaultURL
-	defLogLevel    = "error"
-	defPort        = "8180"
-	defCluster     = "127.0.0.1"
-	defKeyspace    = "mainflux"
-	defDBUsername  = ""
-	defDBPassword  = ""
-	defDBPort      = "9042"
-	defChanCfgPath = "/config/channels.toml"
-
-	envNatsURL     = "MF_NATS_URL"
-	envLogLevel    = "MF_CASSANDRA_WRITER_LOG_LEVEL"
-	envPort        = "MF_CASSANDRA_WRITER_PORT"
-	envCluster     = "MF_CASSANDRA_WRITER_DB_CLUSTER"
-	envKeyspace    = "MF_CASSANDRA_WRITER_DB_KEYSPACE"
-	envDBUsername  = "MF_CASSANDRA_WRITER_DB_USERNAME"
-	envDBPassword  = "MF_CASSANDRA_WRITER_DB_PASSWORD"
-	envDBPort      = "MF_CASSANDRA_WRITER_DB_PORT"
-	envChanCfgPath = "MF_CASSANDRA_WRITER_CHANNELS_CONFIG"
+	defNatsURL       = nats.DefaultURL
+	defLogLevel      = "error"
+	defPort          = "8180"
+	defCluster       = "127.0.0.1"
+	defKeyspace      = "mainflux"
+	defDBUsername    = ""
+	defDBPassword    = ""
+	defDBPort        = "9042"
+	defChanCfgPath   = "/config/channels.toml"
+	defTimeWindow    = "0"    // in seconds, 0 disables the override
+	defMaxMsgSize    = "0"    // in bytes, 0 disables the limit
+	defQuarantine    = ""     // file:<path> or nats:<subject>, empty disables the quarantine
+	defDLQSubject    = ""     // NATS subject of the dead-letter queue, empty disables it
+	defPartition     = ""     // <index>/<count>, empty disables the partitioning
+	defBatchSize     = "1"    // 1 disables the batching
+	defFlushInterval = "1000" // in milliseconds
+	defFlushTimeout  = "10"   // in seconds, 0 waits for the flush indefinitely
+	defSubject       = mainflux.OutputSenML
+
+	envNatsURL       = "MF_NATS_URL"
+	envLogLevel      = "MF_CASSANDRA_WRITER_LOG_LEVEL"
+	envPort          = "MF_CASSANDRA_WRITER_PORT"
+	envCluster       = "MF_CASSANDRA_WRITER_DB_CLUSTER"
+	envKeyspace      = "MF_CASSANDRA_WRITER_DB_KEYSPACE"
+	envDBUsername    = "MF_CASSANDRA_WRITER_DB_USERNAME"
+	envDBPassword    = "MF_CASSANDRA_WRITER_DB_PASSWORD"
+	envDBPort        = "MF_CASSANDRA_WRITER_DB_PORT"
+	envChanCfgPath   = "MF_CASSANDRA_WRITER_CHANNELS_CONFIG"
+	envTimeWindow    = "MF_CASSANDRA_WRITER_TIME_WINDOW"
+	envMaxMsgSize    = "MF_CASSANDRA_WRITER_MAX_MESSAGE_SIZE"
+	envQuarantine    = "MF_CASSANDRA_WRITER_QUARANTINE"
+	envDLQSubject    = "MF_WRITER_DLQ_SUBJECT"
+	envPartition     = "MF_CASSANDRA_WRITER_PARTITION"
+	envBatchSize     = "MF_CASSANDRA_WRITER_BATCH_SIZE"
+	envFlushInterval = "MF_CASSANDRA_WRITER_FLUSH_INTERVAL"
+	envFlushTimeout  = "MF_CASSANDRA_WRITER_FLUSH_TIMEOUT"
+	envSubject       = "MF_CASSANDRA_WRITER_SUBJECT"
 )
 
 type config struct {
-	natsURL  string
-	logLevel string
-	port     string
-	dbCfg    cassandra.DBConfig
-	channels map[string]bool
+	natsURL      string
+	logLevel     string
+	port         string
+	dbCfg        cassandra.DBConfig
+	channels     map[string]bool
+	timeWindow   time.Duration
+	maxMsgSize   int
+	quarantine   string
+	dlqSubject   string
+	partition    writers.Partition
+	batch        writers.Batch
+	flushTimeout time.Duration
+	subject      string
 }
 
 func main() {
@@ -78,8 +105,34 @@ func main() {
 	defer session.Close()
 
 	repo := newService(session, logger)
-	if err := writers.Start(nc, repo, svcName, cfg.channels, logger); err != nil {
+	if cfg.timeWindow > 0 {
+		repo = writers.NewTimestampRepository(repo, cfg.timeWindow)
+	}
+
+	quarantine, err := writers.NewQuarantine(nc, cfg.quarantine)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create Cassandra writer quarantine: %s", err))
+		os.Exit(1)
+	}
+
+	dlq, err := writers.NewDeadLetterQueue(nc, cfg.dlqSubject)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create Cassandra writer dead-letter queue: %s", err))
+		os.Exit(1)
+	}
+
+	opts := []writers.Option{
+		writers.WithMetrics(makeLagGauge(), makeFailuresCounter()),
+		writers.WithPartition(cfg.partition),
+		writers.WithMaxSize(cfg.maxMsgSize),
+		writers.WithQuarantine(quarantine),
+		writers.WithDeadLetterQueue(dlq),
+		writers.WithBatch(cfg.batch),
+	}
+	consumer, err := writers.Start(nc, repo, cfg.subject, svcName, cfg.channels, logger, opts...)
+	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Cassandra writer: %s", err))
+		os.Exit(1)
 	}
 
 	errs := make(chan error, 2)
@@ -94,6 +147,14 @@ func main() {
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("Cassandra writer service terminated: %s", err))
+
+	// Stop consuming before flushing, so that the buffer isn't refilled. NATS
+	// connection is closed only after the flush, since the messages failing
+	// to be saved are republished to the dead-letter queue.
+	if err := consumer.Close(); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to unsubscribe from NATS: %s", err))
+	}
+	writers.FlushBuffer(consumer, cfg.flushTimeout, logger)
 }
 
 func loadConfig() config {
@@ -112,12 +173,75 @@ func loadConfig() config {
 
 	chanCfgPath := mainflux.Env(envChanCfgPath, defChanCfgPath)
 	return config{
-		natsURL:  mainflux.Env(envNatsURL, defNatsURL),
-		logLevel: mainflux.Env(envLogLevel, defLogLevel),
-		port:     mainflux.Env(envPort, defPort),
-		dbCfg:    dbCfg,
-		channels: loadChansConfig(chanCfgPath),
+		natsURL:      mainflux.Env(envNatsURL, defNatsURL),
+		logLevel:     mainflux.Env(envLogLevel, defLogLevel),
+		port:         mainflux.Env(envPort, defPort),
+		dbCfg:        dbCfg,
+		channels:     loadChansConfig(chanCfgPath),
+		timeWindow:   loadTimeWindow(),
+		maxMsgSize:   loadMaxMsgSize(),
+		quarantine:   mainflux.Env(envQuarantine, defQuarantine),
+		dlqSubject:   mainflux.Env(envDLQSubject, defDLQSubject),
+		subject:      mainflux.Env(envSubject, defSubject),
+		partition:    loadPartition(),
+		batch:        loadBatch(),
+		flushTimeout: loadFlushTimeout(),
+	}
+}
+
+func loadTimeWindow() time.Duration {
+	window, err := strconv.ParseUint(mainflux.Env(envTimeWindow, defTimeWindow), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTimeWindow, err.Error())
+	}
+
+	return time.Duration(window) * time.Second
+}
+
+func loadPartition() writers.Partition {
+	partition, err := writers.ParsePartition(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
 	}
+
+	return partition
+}
+
+func loadBatch() writers.Batch {
+	size, err := strconv.Atoi(mainflux.Env(envBatchSize, defBatchSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
+	}
+
+	interval, err := strconv.ParseUint(mainflux.Env(envFlushInterval, defFlushInterval), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushInterval, err.Error())
+	}
+
+	batch := writers.Batch{Size: size, Interval: time.Duration(interval) * time.Millisecond}
+	if err := batch.Validate(); err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
+	}
+
+	return batch
+}
+
+func loadFlushTimeout() time.Duration {
+	timeout, err := strconv.ParseUint(mainflux.Env(envFlushTimeout, defFlushTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushTimeout, err.Error())
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+func loadMaxMsgSize() int {
+	size, err := strconv.Atoi(mainflux.Env(envMaxMsgSize, defMaxMsgSize))
+	if err != nil || size < 0 {
+		log.Fatalf("Invalid %s value", envMaxMsgSize)
+	}
+
+	return size
 }
 
 type channels struct {
@@ -189,6 +313,24 @@ func newService(session *gocql.Session, logger logger.Logger) writers.MessageRep
 	return repo
 }
 
+func makeLagGauge() *kitprometheus.Gauge {
+	return kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "cassandra",
+		Subsystem: "message_writer",
+		Name:      "consumption_lag_seconds",
+		Help:      "Difference between the consumption time and the time of the last consumed message.",
+	}, []string{})
+}
+
+func makeFailuresCounter() *kitprometheus.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "cassandra",
+		Subsystem: "message_writer",
+		Name:      "save_failures",
+		Help:      "Number of messages that failed to save, by error class and handling action.",
+	}, []string{"class", "action"})
+}
+
 func startHTTPServer(port string, errs chan error, logger logger.Logger) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Cassandra writer service started, exposed port %s", port))
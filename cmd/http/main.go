@@ -44,6 +44,7 @@ const (
 	defThingsURL     = "localhost:8181"
 	defJaegerURL     = ""
 	defThingsTimeout = "1" // in seconds
+	defCatchAll      = ""
 
 	envClientTLS     = "MF_HTTP_ADAPTER_CLIENT_TLS"
 	envCACerts       = "MF_HTTP_ADAPTER_CA_CERTS"
@@ -53,6 +54,7 @@ const (
 	envThingsURL     = "MF_THINGS_URL"
 	envJaegerURL     = "MF_JAEGER_URL"
 	envThingsTimeout = "MF_HTTP_ADAPTER_THINGS_TIMEOUT"
+	envCatchAll      = "MF_HTTP_ADAPTER_CATCH_ALL_CHANNEL"
 )
 
 type config struct {
@@ -64,6 +66,7 @@ type config struct {
 	caCerts       string
 	jaegerURL     string
 	thingsTimeout time.Duration
+	catchAll      string
 }
 
 func main() {
@@ -94,7 +97,7 @@ func main() {
 	cc := thingsapi.NewClient(conn, thingsTracer, cfg.thingsTimeout)
 	pub := nats.NewMessagePublisher(nc)
 
-	svc := adapter.New(pub, cc)
+	svc := adapter.New(pub, cc, cfg.catchAll)
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -150,6 +153,7 @@ func loadConfig() config {
 		caCerts:       mainflux.Env(envCACerts, defCACerts),
 		jaegerURL:     mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsTimeout: time.Duration(timeout) * time.Second,
+		catchAll:      mainflux.Env(envCatchAll, defCatchAll),
 	}
 }
 
@@ -40,6 +40,12 @@ const (
 	defCACerts       = ""
 	defJaegerURL     = ""
 	defThingsTimeout = "1" // in seconds
+	defLenientQuery  = "true"
+	defMaxTimeSpan   = "0s"
+	defOffsetWarning = "0"
+	defCacheTTL      = "0s"
+	defCacheSize     = "1000"
+	defAdminToken    = ""
 
 	envThingsURL     = "MF_THINGS_URL"
 	envLogLevel      = "MF_INFLUX_READER_LOG_LEVEL"
@@ -53,6 +59,12 @@ const (
 	envCACerts       = "MF_INFLUX_READER_CA_CERTS"
 	envJaegerURL     = "MF_JAEGER_URL"
 	envThingsTimeout = "MF_INFLUX_READER_THINGS_TIMEOUT"
+	envLenientQuery  = "MF_INFLUX_READER_LENIENT_QUERY"
+	envMaxTimeSpan   = "MF_INFLUX_READER_MAX_TIME_SPAN"
+	envOffsetWarning = "MF_INFLUX_READER_OFFSET_WARNING"
+	envCacheTTL      = "MF_INFLUX_READER_CACHE_TTL"
+	envCacheSize     = "MF_INFLUX_READER_CACHE_SIZE"
+	envAdminToken    = "MF_INFLUX_READER_ADMIN_TOKEN"
 )
 
 type config struct {
@@ -68,6 +80,12 @@ type config struct {
 	caCerts       string
 	jaegerURL     string
 	thingsTimeout time.Duration
+	lenientQuery  bool
+	maxTimeSpan   time.Duration
+	offsetWarning uint64
+	cacheTTL      time.Duration
+	cacheSize     int
+	adminToken    string
 }
 
 func main() {
@@ -91,7 +109,7 @@ func main() {
 	}
 	defer client.Close()
 
-	repo := newService(client, cfg.dbName, logger)
+	repo := newService(client, cfg.dbName, cfg.cacheTTL, cfg.cacheSize, logger)
 
 	errs := make(chan error, 2)
 	go func() {
@@ -100,7 +118,7 @@ func main() {
 		errs <- fmt.Errorf("%s", <-c)
 	}()
 
-	go startHTTPServer(repo, tc, cfg.port, logger, errs)
+	go startHTTPServer(repo, tc, cfg.lenientQuery, cfg.maxTimeSpan, cfg.offsetWarning, cfg.adminToken, cfg.port, logger, errs)
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("InfluxDB writer service terminated: %s", err))
@@ -117,6 +135,31 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 		log.Fatalf("Invalid %s value: %s", envThingsTimeout, err.Error())
 	}
 
+	lenient, err := strconv.ParseBool(mainflux.Env(envLenientQuery, defLenientQuery))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envLenientQuery)
+	}
+
+	maxTimeSpan, err := time.ParseDuration(mainflux.Env(envMaxTimeSpan, defMaxTimeSpan))
+	if err != nil || maxTimeSpan < 0 {
+		log.Fatalf("Invalid %s value", envMaxTimeSpan)
+	}
+
+	offsetWarning, err := strconv.ParseUint(mainflux.Env(envOffsetWarning, defOffsetWarning), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envOffsetWarning)
+	}
+
+	cacheTTL, err := time.ParseDuration(mainflux.Env(envCacheTTL, defCacheTTL))
+	if err != nil || cacheTTL < 0 {
+		log.Fatalf("Invalid %s value", envCacheTTL)
+	}
+
+	cacheSize, err := strconv.Atoi(mainflux.Env(envCacheSize, defCacheSize))
+	if err != nil || cacheSize <= 0 {
+		log.Fatalf("Invalid %s value", envCacheSize)
+	}
+
 	cfg := config{
 		thingsURL:     mainflux.Env(envThingsURL, defThingsURL),
 		logLevel:      mainflux.Env(envLogLevel, defLogLevel),
@@ -130,6 +173,12 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 		caCerts:       mainflux.Env(envCACerts, defCACerts),
 		jaegerURL:     mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsTimeout: time.Duration(timeout) * time.Second,
+		lenientQuery:  lenient,
+		maxTimeSpan:   maxTimeSpan,
+		offsetWarning: offsetWarning,
+		cacheTTL:      cacheTTL,
+		cacheSize:     cacheSize,
+		adminToken:    mainflux.Env(envAdminToken, defAdminToken),
 	}
 
 	clientCfg := influxdata.HTTPConfig{
@@ -189,8 +238,11 @@ func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer,
 	return tracer, closer
 }
 
-func newService(client influxdata.Client, dbName string, logger logger.Logger) readers.MessageRepository {
+func newService(client influxdata.Client, dbName string, cacheTTL time.Duration, cacheSize int, logger logger.Logger) readers.MessageRepository {
 	repo := influxdb.New(client, dbName)
+	if cacheTTL > 0 {
+		repo = readers.NewCachedRepository(repo, cacheTTL, cacheSize)
+	}
 	repo = api.LoggingMiddleware(repo, logger)
 	repo = api.MetricsMiddleware(
 		repo,
@@ -211,8 +263,8 @@ func newService(client influxdata.Client, dbName string, logger logger.Logger) r
 	return repo
 }
 
-func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, port string, logger logger.Logger, errs chan error) {
+func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, lenient bool, maxTimeSpan time.Duration, offsetWarning uint64, adminToken string, port string, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("InfluxDB reader service started, exposed port %s", port))
-	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "influxdb-reader"))
+	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, lenient, maxTimeSpan, offsetWarning, nil, adminToken, "influxdb-reader"))
 }
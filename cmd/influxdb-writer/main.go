@@ -44,6 +44,13 @@ const (
 	defDBUser       = "mainflux"
 	defDBPass       = "mainflux"
 	defChanCfgPath  = "/config/channels.toml"
+	defTimeWindow   = "0" // in seconds, 0 disables the override
+	defMaxMsgSize   = "0" // in bytes, 0 disables the limit
+	defQuarantine   = ""  // file:<path> or nats:<subject>, empty disables the quarantine
+	defDLQSubject   = ""  // NATS subject of the dead-letter queue, empty disables it
+	defPartition    = ""  // <index>/<count>, empty disables the partitioning
+	defSubject      = mainflux.OutputSenML
+	defFlushTimeout = "10" // in seconds, 0 waits for the flush indefinitely
 
 	envNatsURL      = "MF_NATS_URL"
 	envLogLevel     = "MF_INFLUX_WRITER_LOG_LEVEL"
@@ -56,6 +63,13 @@ const (
 	envDBUser       = "MF_INFLUX_WRITER_DB_USER"
 	envDBPass       = "MF_INFLUX_WRITER_DB_PASS"
 	envChanCfgPath  = "MF_INFLUX_WRITER_CHANNELS_CONFIG"
+	envTimeWindow   = "MF_INFLUX_WRITER_TIME_WINDOW"
+	envMaxMsgSize   = "MF_INFLUX_WRITER_MAX_MESSAGE_SIZE"
+	envQuarantine   = "MF_INFLUX_WRITER_QUARANTINE"
+	envDLQSubject   = "MF_WRITER_DLQ_SUBJECT"
+	envPartition    = "MF_INFLUX_WRITER_PARTITION"
+	envSubject      = "MF_INFLUX_WRITER_SUBJECT"
+	envFlushTimeout = "MF_INFLUX_WRITER_FLUSH_TIMEOUT"
 )
 
 type config struct {
@@ -70,6 +84,13 @@ type config struct {
 	dbUser       string
 	dbPass       string
 	channels     map[string]bool
+	timeWindow   time.Duration
+	maxMsgSize   int
+	quarantine   string
+	dlqSubject   string
+	partition    writers.Partition
+	subject      string
+	flushTimeout time.Duration
 }
 
 func main() {
@@ -113,10 +134,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Buffered points are flushed using the unwrapped repository.
+	buffer := repo
+
 	counter, latency := makeMetrics()
 	repo = api.LoggingMiddleware(repo, logger)
 	repo = api.MetricsMiddleware(repo, counter, latency)
-	if err := writers.Start(nc, repo, svcName, cfg.channels, logger); err != nil {
+	if cfg.timeWindow > 0 {
+		repo = writers.NewTimestampRepository(repo, cfg.timeWindow)
+	}
+
+	quarantine, err := writers.NewQuarantine(nc, cfg.quarantine)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create InfluxDB writer quarantine: %s", err))
+		os.Exit(1)
+	}
+
+	dlq, err := writers.NewDeadLetterQueue(nc, cfg.dlqSubject)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create InfluxDB writer dead-letter queue: %s", err))
+		os.Exit(1)
+	}
+
+	opts := []writers.Option{
+		writers.WithMetrics(makeLagGauge(), makeFailuresCounter()),
+		writers.WithPartition(cfg.partition),
+		writers.WithMaxSize(cfg.maxMsgSize),
+		writers.WithQuarantine(quarantine),
+		writers.WithDeadLetterQueue(dlq),
+	}
+	consumer, err := writers.Start(nc, repo, cfg.subject, svcName, cfg.channels, logger, opts...)
+	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to start InfluxDB writer: %s", err))
 		os.Exit(1)
 	}
@@ -124,7 +172,7 @@ func main() {
 	errs := make(chan error, 2)
 	go func() {
 		c := make(chan os.Signal)
-		signal.Notify(c, syscall.SIGINT)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 		errs <- fmt.Errorf("%s", <-c)
 	}()
 
@@ -132,6 +180,14 @@ func main() {
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("InfluxDB writer service terminated: %s", err))
+
+	// Stop consuming before flushing, so that the buffer isn't refilled. NATS
+	// connection is closed only after the flush, since the messages failing
+	// to be saved are republished to the dead-letter queue.
+	if err := consumer.Close(); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to unsubscribe from NATS: %s", err))
+	}
+	writers.Flush(buffer, cfg.flushTimeout, logger)
 }
 
 func loadConfigs() (config, influxdata.HTTPConfig) {
@@ -148,6 +204,13 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 		dbUser:       mainflux.Env(envDBUser, defDBUser),
 		dbPass:       mainflux.Env(envDBPass, defDBPass),
 		channels:     loadChansConfig(chanCfgPath),
+		timeWindow:   loadTimeWindow(),
+		maxMsgSize:   loadMaxMsgSize(),
+		quarantine:   mainflux.Env(envQuarantine, defQuarantine),
+		dlqSubject:   mainflux.Env(envDLQSubject, defDLQSubject),
+		subject:      mainflux.Env(envSubject, defSubject),
+		partition:    loadPartition(),
+		flushTimeout: loadFlushTimeout(),
 	}
 
 	clientCfg := influxdata.HTTPConfig{
@@ -159,6 +222,42 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 	return cfg, clientCfg
 }
 
+func loadTimeWindow() time.Duration {
+	window, err := strconv.ParseUint(mainflux.Env(envTimeWindow, defTimeWindow), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTimeWindow, err.Error())
+	}
+
+	return time.Duration(window) * time.Second
+}
+
+func loadPartition() writers.Partition {
+	partition, err := writers.ParsePartition(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
+	}
+
+	return partition
+}
+
+func loadMaxMsgSize() int {
+	size, err := strconv.Atoi(mainflux.Env(envMaxMsgSize, defMaxMsgSize))
+	if err != nil || size < 0 {
+		log.Fatalf("Invalid %s value", envMaxMsgSize)
+	}
+
+	return size
+}
+
+func loadFlushTimeout() time.Duration {
+	timeout, err := strconv.ParseUint(mainflux.Env(envFlushTimeout, defFlushTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushTimeout, err.Error())
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
 type channels struct {
 	List []string `toml:"filter"`
 }
@@ -204,6 +303,24 @@ func makeMetrics() (*kitprometheus.Counter, *kitprometheus.Summary) {
 	return counter, latency
 }
 
+func makeLagGauge() *kitprometheus.Gauge {
+	return kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "influxdb",
+		Subsystem: "message_writer",
+		Name:      "consumption_lag_seconds",
+		Help:      "Difference between the consumption time and the time of the last consumed message.",
+	}, []string{})
+}
+
+func makeFailuresCounter() *kitprometheus.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "influxdb",
+		Subsystem: "message_writer",
+		Name:      "save_failures",
+		Help:      "Number of messages that failed to save, by error class and handling action.",
+	}, []string{"class", "action"})
+}
+
 func startHTTPService(port string, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("InfluxDB writer service started, exposed port %s", p))
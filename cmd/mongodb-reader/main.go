@@ -47,6 +47,14 @@ const (
 	defCACerts       = ""
 	defJaegerURL     = ""
 	defThingsTimeout = "1" // in seconds
+	defLenientQuery  = "true"
+	defMaxTimeSpan   = "0s"
+	defOffsetWarning = "0"
+	defTagKeys       = ""
+	defCacheTTL      = "0s"
+	defCacheSize     = "1000"
+	defAdminToken    = ""
+	defBatchSize     = "0"
 
 	envThingsURL     = "MF_THINGS_URL"
 	envLogLevel      = "MF_MONGO_READER_LOG_LEVEL"
@@ -58,6 +66,14 @@ const (
 	envCACerts       = "MF_MONGO_READER_CA_CERTS"
 	envJaegerURL     = "MF_JAEGER_URL"
 	envThingsTimeout = "MF_MONGO_READER_THINGS_TIMEOUT"
+	envLenientQuery  = "MF_MONGO_READER_LENIENT_QUERY"
+	envMaxTimeSpan   = "MF_MONGO_READER_MAX_TIME_SPAN"
+	envOffsetWarning = "MF_MONGO_READER_OFFSET_WARNING"
+	envTagKeys       = "MF_MONGO_READER_TAG_KEYS"
+	envCacheTTL      = "MF_MONGO_READER_CACHE_TTL"
+	envCacheSize     = "MF_MONGO_READER_CACHE_SIZE"
+	envAdminToken    = "MF_MONGO_READER_ADMIN_TOKEN"
+	envBatchSize     = "MF_MONGO_READER_BATCH_SIZE"
 )
 
 type config struct {
@@ -71,6 +87,14 @@ type config struct {
 	caCerts       string
 	jaegerURL     string
 	thingsTimeout time.Duration
+	lenientQuery  bool
+	maxTimeSpan   time.Duration
+	offsetWarning uint64
+	tagKeys       []string
+	cacheTTL      time.Duration
+	cacheSize     int
+	adminToken    string
+	batchSize     int32
 }
 
 func main() {
@@ -90,7 +114,7 @@ func main() {
 
 	db := connectToMongoDB(cfg.dbHost, cfg.dbPort, cfg.dbName, logger)
 
-	repo := newService(db, logger)
+	repo := newService(db, cfg.batchSize, cfg.cacheTTL, cfg.cacheSize, logger)
 
 	errs := make(chan error, 2)
 	go func() {
@@ -99,7 +123,7 @@ func main() {
 		errs <- fmt.Errorf("%s", <-c)
 	}()
 
-	go startHTTPServer(repo, tc, cfg.port, logger, errs)
+	go startHTTPServer(repo, tc, cfg.lenientQuery, cfg.maxTimeSpan, cfg.offsetWarning, cfg.tagKeys, cfg.adminToken, cfg.port, logger, errs)
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("MongoDB reader service terminated: %s", err))
@@ -116,6 +140,41 @@ func loadConfigs() config {
 		log.Fatalf("Invalid %s value: %s", envThingsTimeout, err.Error())
 	}
 
+	lenient, err := strconv.ParseBool(mainflux.Env(envLenientQuery, defLenientQuery))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envLenientQuery)
+	}
+
+	maxTimeSpan, err := time.ParseDuration(mainflux.Env(envMaxTimeSpan, defMaxTimeSpan))
+	if err != nil || maxTimeSpan < 0 {
+		log.Fatalf("Invalid %s value", envMaxTimeSpan)
+	}
+
+	offsetWarning, err := strconv.ParseUint(mainflux.Env(envOffsetWarning, defOffsetWarning), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envOffsetWarning)
+	}
+
+	tagKeys, err := readers.ParseTagKeys(mainflux.Env(envTagKeys, defTagKeys))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTagKeys, err.Error())
+	}
+
+	cacheTTL, err := time.ParseDuration(mainflux.Env(envCacheTTL, defCacheTTL))
+	if err != nil || cacheTTL < 0 {
+		log.Fatalf("Invalid %s value", envCacheTTL)
+	}
+
+	cacheSize, err := strconv.Atoi(mainflux.Env(envCacheSize, defCacheSize))
+	if err != nil || cacheSize <= 0 {
+		log.Fatalf("Invalid %s value", envCacheSize)
+	}
+
+	batchSize, err := strconv.ParseInt(mainflux.Env(envBatchSize, defBatchSize), 10, 32)
+	if err != nil || batchSize < 0 {
+		log.Fatalf("Invalid %s value", envBatchSize)
+	}
+
 	return config{
 		thingsURL:     mainflux.Env(envThingsURL, defThingsURL),
 		logLevel:      mainflux.Env(envLogLevel, defLogLevel),
@@ -127,6 +186,14 @@ func loadConfigs() config {
 		caCerts:       mainflux.Env(envCACerts, defCACerts),
 		jaegerURL:     mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsTimeout: time.Duration(timeout) * time.Second,
+		lenientQuery:  lenient,
+		maxTimeSpan:   maxTimeSpan,
+		offsetWarning: offsetWarning,
+		tagKeys:       tagKeys,
+		cacheTTL:      cacheTTL,
+		cacheSize:     cacheSize,
+		adminToken:    mainflux.Env(envAdminToken, defAdminToken),
+		batchSize:     int32(batchSize),
 	}
 }
 
@@ -189,8 +256,11 @@ func connectToThings(cfg config, logger logger.Logger) *grpc.ClientConn {
 	return conn
 }
 
-func newService(db *mongo.Database, logger logger.Logger) readers.MessageRepository {
-	repo := mongodb.New(db)
+func newService(db *mongo.Database, batchSize int32, cacheTTL time.Duration, cacheSize int, logger logger.Logger) readers.MessageRepository {
+	repo := mongodb.New(db, batchSize)
+	if cacheTTL > 0 {
+		repo = readers.NewCachedRepository(repo, cacheTTL, cacheSize)
+	}
 	repo = api.LoggingMiddleware(repo, logger)
 	repo = api.MetricsMiddleware(
 		repo,
@@ -211,8 +281,8 @@ func newService(db *mongo.Database, logger logger.Logger) readers.MessageReposit
 	return repo
 }
 
-func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, port string, logger logger.Logger, errs chan error) {
+func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, lenient bool, maxTimeSpan time.Duration, offsetWarning uint64, tagKeys []string, adminToken string, port string, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Mongo reader service started, exposed port %s", port))
-	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "mongodb-reader"))
+	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, lenient, maxTimeSpan, offsetWarning, tagKeys, adminToken, "mongodb-reader"))
 }
@@ -15,7 +15,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
@@ -33,31 +35,60 @@ import (
 const (
 	svcName = "mongodb-writer"
 
-	defNatsURL     = nats.DefaultURL
-	defLogLevel    = "error"
-	defPort        = "8180"
-	defDBName      = "mainflux"
-	defDBHost      = "localhost"
-	defDBPort      = "27017"
-	defChanCfgPath = "/config/channels.toml"
-
-	envNatsURL     = "MF_NATS_URL"
-	envLogLevel    = "MF_MONGO_WRITER_LOG_LEVEL"
-	envPort        = "MF_MONGO_WRITER_PORT"
-	envDBName      = "MF_MONGO_WRITER_DB_NAME"
-	envDBHost      = "MF_MONGO_WRITER_DB_HOST"
-	envDBPort      = "MF_MONGO_WRITER_DB_PORT"
-	envChanCfgPath = "MF_MONGO_WRITER_CHANNELS_CONFIG"
+	defNatsURL       = nats.DefaultURL
+	defLogLevel      = "error"
+	defPort          = "8180"
+	defDBName        = "mainflux"
+	defDBHost        = "localhost"
+	defDBPort        = "27017"
+	defChanCfgPath   = "/config/channels.toml"
+	defTimeWindow    = "0"    // in seconds, 0 disables the override
+	defMaxMsgSize    = "0"    // in bytes, 0 disables the limit
+	defQuarantine    = ""     // file:<path> or nats:<subject>, empty disables the quarantine
+	defDLQSubject    = ""     // NATS subject of the dead-letter queue, empty disables it
+	defPartition     = ""     // <index>/<count>, empty disables the partitioning
+	defBatchSize     = "1"    // 1 disables the batching
+	defFlushInterval = "1000" // in milliseconds
+	defFlushTimeout  = "10"   // in seconds, 0 waits for the flush indefinitely
+	defSubject       = mainflux.OutputSenML
+	defUpsert        = "false"
+
+	envNatsURL       = "MF_NATS_URL"
+	envLogLevel      = "MF_MONGO_WRITER_LOG_LEVEL"
+	envPort          = "MF_MONGO_WRITER_PORT"
+	envDBName        = "MF_MONGO_WRITER_DB_NAME"
+	envDBHost        = "MF_MONGO_WRITER_DB_HOST"
+	envDBPort        = "MF_MONGO_WRITER_DB_PORT"
+	envChanCfgPath   = "MF_MONGO_WRITER_CHANNELS_CONFIG"
+	envTimeWindow    = "MF_MONGO_WRITER_TIME_WINDOW"
+	envMaxMsgSize    = "MF_MONGO_WRITER_MAX_MESSAGE_SIZE"
+	envQuarantine    = "MF_MONGO_WRITER_QUARANTINE"
+	envDLQSubject    = "MF_WRITER_DLQ_SUBJECT"
+	envPartition     = "MF_MONGO_WRITER_PARTITION"
+	envBatchSize     = "MF_MONGO_WRITER_BATCH_SIZE"
+	envFlushInterval = "MF_MONGO_WRITER_FLUSH_INTERVAL"
+	envFlushTimeout  = "MF_MONGO_WRITER_FLUSH_TIMEOUT"
+	envSubject       = "MF_MONGO_WRITER_SUBJECT"
+	envUpsert        = "MF_MONGO_WRITER_UPSERT"
 )
 
 type config struct {
-	natsURL  string
-	logLevel string
-	port     string
-	dbName   string
-	dbHost   string
-	dbPort   string
-	channels map[string]bool
+	natsURL      string
+	logLevel     string
+	port         string
+	dbName       string
+	dbHost       string
+	dbPort       string
+	channels     map[string]bool
+	timeWindow   time.Duration
+	maxMsgSize   int
+	quarantine   string
+	dlqSubject   string
+	partition    writers.Partition
+	batch        writers.Batch
+	flushTimeout time.Duration
+	subject      string
+	upsert       bool
 }
 
 func main() {
@@ -84,11 +115,42 @@ func main() {
 
 	db := client.Database(cfg.dbName)
 	repo := mongodb.New(db)
+	if cfg.upsert {
+		if repo, err = mongodb.NewUpsert(db); err != nil {
+			logger.Error(fmt.Sprintf("Failed to create natural key index: %s", err))
+			os.Exit(1)
+		}
+	}
 
 	counter, latency := makeMetrics()
 	repo = api.LoggingMiddleware(repo, logger)
 	repo = api.MetricsMiddleware(repo, counter, latency)
-	if err := writers.Start(nc, repo, svcName, cfg.channels, logger); err != nil {
+	if cfg.timeWindow > 0 {
+		repo = writers.NewTimestampRepository(repo, cfg.timeWindow)
+	}
+
+	quarantine, err := writers.NewQuarantine(nc, cfg.quarantine)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create MongoDB writer quarantine: %s", err))
+		os.Exit(1)
+	}
+
+	dlq, err := writers.NewDeadLetterQueue(nc, cfg.dlqSubject)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create MongoDB writer dead-letter queue: %s", err))
+		os.Exit(1)
+	}
+
+	opts := []writers.Option{
+		writers.WithMetrics(makeLagGauge(), makeFailuresCounter()),
+		writers.WithPartition(cfg.partition),
+		writers.WithMaxSize(cfg.maxMsgSize),
+		writers.WithQuarantine(quarantine),
+		writers.WithDeadLetterQueue(dlq),
+		writers.WithBatch(cfg.batch),
+	}
+	consumer, err := writers.Start(nc, repo, cfg.subject, svcName, cfg.channels, logger, opts...)
+	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to start MongoDB writer: %s", err))
 		os.Exit(1)
 	}
@@ -104,19 +166,100 @@ func main() {
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("MongoDB writer service terminated: %s", err))
+
+	// Stop consuming before flushing, so that the buffer isn't refilled. NATS
+	// connection is closed only after the flush, since the messages failing
+	// to be saved are republished to the dead-letter queue.
+	if err := consumer.Close(); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to unsubscribe from NATS: %s", err))
+	}
+	writers.FlushBuffer(consumer, cfg.flushTimeout, logger)
 }
 
 func loadConfigs() config {
 	chanCfgPath := mainflux.Env(envChanCfgPath, defChanCfgPath)
 	return config{
-		natsURL:  mainflux.Env(envNatsURL, defNatsURL),
-		logLevel: mainflux.Env(envLogLevel, defLogLevel),
-		port:     mainflux.Env(envPort, defPort),
-		dbName:   mainflux.Env(envDBName, defDBName),
-		dbHost:   mainflux.Env(envDBHost, defDBHost),
-		dbPort:   mainflux.Env(envDBPort, defDBPort),
-		channels: loadChansConfig(chanCfgPath),
+		natsURL:      mainflux.Env(envNatsURL, defNatsURL),
+		logLevel:     mainflux.Env(envLogLevel, defLogLevel),
+		port:         mainflux.Env(envPort, defPort),
+		dbName:       mainflux.Env(envDBName, defDBName),
+		dbHost:       mainflux.Env(envDBHost, defDBHost),
+		dbPort:       mainflux.Env(envDBPort, defDBPort),
+		channels:     loadChansConfig(chanCfgPath),
+		timeWindow:   loadTimeWindow(),
+		maxMsgSize:   loadMaxMsgSize(),
+		quarantine:   mainflux.Env(envQuarantine, defQuarantine),
+		dlqSubject:   mainflux.Env(envDLQSubject, defDLQSubject),
+		subject:      mainflux.Env(envSubject, defSubject),
+		partition:    loadPartition(),
+		batch:        loadBatch(),
+		flushTimeout: loadFlushTimeout(),
+		upsert:       loadUpsert(),
+	}
+}
+
+func loadUpsert() bool {
+	upsert, err := strconv.ParseBool(mainflux.Env(envUpsert, defUpsert))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envUpsert, err.Error())
+	}
+
+	return upsert
+}
+
+func loadTimeWindow() time.Duration {
+	window, err := strconv.ParseUint(mainflux.Env(envTimeWindow, defTimeWindow), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTimeWindow, err.Error())
+	}
+
+	return time.Duration(window) * time.Second
+}
+
+func loadPartition() writers.Partition {
+	partition, err := writers.ParsePartition(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
 	}
+
+	return partition
+}
+
+func loadBatch() writers.Batch {
+	size, err := strconv.Atoi(mainflux.Env(envBatchSize, defBatchSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
+	}
+
+	interval, err := strconv.ParseUint(mainflux.Env(envFlushInterval, defFlushInterval), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushInterval, err.Error())
+	}
+
+	batch := writers.Batch{Size: size, Interval: time.Duration(interval) * time.Millisecond}
+	if err := batch.Validate(); err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
+	}
+
+	return batch
+}
+
+func loadFlushTimeout() time.Duration {
+	timeout, err := strconv.ParseUint(mainflux.Env(envFlushTimeout, defFlushTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushTimeout, err.Error())
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+func loadMaxMsgSize() int {
+	size, err := strconv.Atoi(mainflux.Env(envMaxMsgSize, defMaxMsgSize))
+	if err != nil || size < 0 {
+		log.Fatalf("Invalid %s value", envMaxMsgSize)
+	}
+
+	return size
 }
 
 type channels struct {
@@ -164,6 +307,24 @@ func makeMetrics() (*kitprometheus.Counter, *kitprometheus.Summary) {
 	return counter, latency
 }
 
+func makeLagGauge() *kitprometheus.Gauge {
+	return kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "mongodb",
+		Subsystem: "message_writer",
+		Name:      "consumption_lag_seconds",
+		Help:      "Difference between the consumption time and the time of the last consumed message.",
+	}, []string{})
+}
+
+func makeFailuresCounter() *kitprometheus.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "mongodb",
+		Subsystem: "message_writer",
+		Name:      "save_failures",
+		Help:      "Number of messages that failed to save, by error class and handling action.",
+	}, []string{"class", "action"})
+}
+
 func startHTTPService(port string, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Mongodb writer service started, exposed port %s", p))
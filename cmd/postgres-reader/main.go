@@ -54,6 +54,13 @@ const (
 	defDBSSLRootCert = ""
 	defJaegerURL     = ""
 	defThingsTimeout = "1" // in seconds
+	defLenientQuery  = "true"
+	defMaxTimeSpan   = "0s"
+	defOffsetWarning = "0"
+	defTagKeys       = ""
+	defCacheTTL      = "0s"
+	defCacheSize     = "1000"
+	defAdminToken    = ""
 
 	envThingsURL     = "MF_THINGS_URL"
 	envLogLevel      = "MF_POSTGRES_READER_LOG_LEVEL"
@@ -71,6 +78,13 @@ const (
 	envDBSSLRootCert = "MF_POSTGRES_READER_DB_SSL_ROOT_CERT"
 	envJaegerURL     = "MF_JAEGER_URL"
 	envThingsTimeout = "MF_POSTGRES_READER_THINGS_TIMEOUT"
+	envLenientQuery  = "MF_POSTGRES_READER_LENIENT_QUERY"
+	envMaxTimeSpan   = "MF_POSTGRES_READER_MAX_TIME_SPAN"
+	envOffsetWarning = "MF_POSTGRES_READER_OFFSET_WARNING"
+	envTagKeys       = "MF_POSTGRES_READER_TAG_KEYS"
+	envCacheTTL      = "MF_POSTGRES_READER_CACHE_TTL"
+	envCacheSize     = "MF_POSTGRES_READER_CACHE_SIZE"
+	envAdminToken    = "MF_POSTGRES_READER_ADMIN_TOKEN"
 )
 
 type config struct {
@@ -82,6 +96,13 @@ type config struct {
 	dbConfig      postgres.Config
 	jaegerURL     string
 	thingsTimeout time.Duration
+	lenientQuery  bool
+	maxTimeSpan   time.Duration
+	offsetWarning uint64
+	tagKeys       []string
+	cacheTTL      time.Duration
+	cacheSize     int
+	adminToken    string
 }
 
 func main() {
@@ -103,11 +124,11 @@ func main() {
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
 
-	repo := newService(db, logger)
+	repo := newService(db, cfg.cacheTTL, cfg.cacheSize, logger)
 
 	errs := make(chan error, 2)
 
-	go startHTTPServer(repo, tc, cfg.port, logger, errs)
+	go startHTTPServer(repo, tc, cfg.lenientQuery, cfg.maxTimeSpan, cfg.offsetWarning, cfg.tagKeys, cfg.adminToken, cfg.port, logger, errs)
 
 	go func() {
 		c := make(chan os.Signal)
@@ -137,6 +158,36 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envThingsTimeout, err.Error())
 	}
 
+	lenient, err := strconv.ParseBool(mainflux.Env(envLenientQuery, defLenientQuery))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envLenientQuery)
+	}
+
+	maxTimeSpan, err := time.ParseDuration(mainflux.Env(envMaxTimeSpan, defMaxTimeSpan))
+	if err != nil || maxTimeSpan < 0 {
+		log.Fatalf("Invalid %s value", envMaxTimeSpan)
+	}
+
+	offsetWarning, err := strconv.ParseUint(mainflux.Env(envOffsetWarning, defOffsetWarning), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envOffsetWarning)
+	}
+
+	tagKeys, err := readers.ParseTagKeys(mainflux.Env(envTagKeys, defTagKeys))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTagKeys, err.Error())
+	}
+
+	cacheTTL, err := time.ParseDuration(mainflux.Env(envCacheTTL, defCacheTTL))
+	if err != nil || cacheTTL < 0 {
+		log.Fatalf("Invalid %s value", envCacheTTL)
+	}
+
+	cacheSize, err := strconv.Atoi(mainflux.Env(envCacheSize, defCacheSize))
+	if err != nil || cacheSize <= 0 {
+		log.Fatalf("Invalid %s value", envCacheSize)
+	}
+
 	return config{
 		thingsURL:     mainflux.Env(envThingsURL, defThingsURL),
 		logLevel:      mainflux.Env(envLogLevel, defLogLevel),
@@ -144,6 +195,13 @@ func loadConfig() config {
 		dbConfig:      dbConfig,
 		jaegerURL:     mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsTimeout: time.Duration(timeout) * time.Second,
+		lenientQuery:  lenient,
+		maxTimeSpan:   maxTimeSpan,
+		offsetWarning: offsetWarning,
+		tagKeys:       tagKeys,
+		cacheTTL:      cacheTTL,
+		cacheSize:     cacheSize,
+		adminToken:    mainflux.Env(envAdminToken, defAdminToken),
 	}
 }
 
@@ -204,8 +262,11 @@ func connectToThings(cfg config, logger logger.Logger) *grpc.ClientConn {
 	return conn
 }
 
-func newService(db *sqlx.DB, logger logger.Logger) readers.MessageRepository {
+func newService(db *sqlx.DB, cacheTTL time.Duration, cacheSize int, logger logger.Logger) readers.MessageRepository {
 	svc := postgres.New(db)
+	if cacheTTL > 0 {
+		svc = readers.NewCachedRepository(svc, cacheTTL, cacheSize)
+	}
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -226,8 +287,8 @@ func newService(db *sqlx.DB, logger logger.Logger) readers.MessageRepository {
 	return svc
 }
 
-func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, port string, logger logger.Logger, errs chan error) {
+func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient, lenient bool, maxTimeSpan time.Duration, offsetWarning uint64, tagKeys []string, adminToken string, port string, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Postgres reader service started, exposed port %s", port))
-	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, svcName))
+	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, lenient, maxTimeSpan, offsetWarning, tagKeys, adminToken, svcName))
 }
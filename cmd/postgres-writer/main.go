@@ -14,7 +14,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
@@ -45,6 +47,16 @@ const (
 	defDBSSLKey      = ""
 	defDBSSLRootCert = ""
 	defChanCfgPath   = "/config/channels.toml"
+	defTimeWindow    = "0"    // in seconds, 0 disables the override
+	defMaxMsgSize    = "0"    // in bytes, 0 disables the limit
+	defQuarantine    = ""     // file:<path> or nats:<subject>, empty disables the quarantine
+	defDLQSubject    = ""     // NATS subject of the dead-letter queue, empty disables it
+	defPartition     = ""     // <index>/<count>, empty disables the partitioning
+	defBatchSize     = "1"    // 1 disables the batching
+	defFlushInterval = "1000" // in milliseconds
+	defFlushTimeout  = "10"   // in seconds, 0 waits for the flush indefinitely
+	defSubject       = mainflux.OutputSenML
+	defUpsert        = "false"
 
 	envNatsURL       = "MF_NATS_URL"
 	envLogLevel      = "MF_POSTGRES_WRITER_LOG_LEVEL"
@@ -59,14 +71,33 @@ const (
 	envDBSSLKey      = "MF_POSTGRES_WRITER_DB_SSL_KEY"
 	envDBSSLRootCert = "MF_POSTGRES_WRITER_DB_SSL_ROOT_CERT"
 	envChanCfgPath   = "MF_POSTGRES_WRITER_CHANNELS_CONFIG"
+	envTimeWindow    = "MF_POSTGRES_WRITER_TIME_WINDOW"
+	envMaxMsgSize    = "MF_POSTGRES_WRITER_MAX_MESSAGE_SIZE"
+	envQuarantine    = "MF_POSTGRES_WRITER_QUARANTINE"
+	envDLQSubject    = "MF_WRITER_DLQ_SUBJECT"
+	envPartition     = "MF_POSTGRES_WRITER_PARTITION"
+	envBatchSize     = "MF_POSTGRES_WRITER_BATCH_SIZE"
+	envFlushInterval = "MF_POSTGRES_WRITER_FLUSH_INTERVAL"
+	envFlushTimeout  = "MF_POSTGRES_WRITER_FLUSH_TIMEOUT"
+	envSubject       = "MF_POSTGRES_WRITER_SUBJECT"
+	envUpsert        = "MF_POSTGRES_WRITER_UPSERT"
 )
 
 type config struct {
-	natsURL  string
-	logLevel string
-	port     string
-	dbConfig postgres.Config
-	channels map[string]bool
+	natsURL      string
+	logLevel     string
+	port         string
+	dbConfig     postgres.Config
+	channels     map[string]bool
+	timeWindow   time.Duration
+	maxMsgSize   int
+	quarantine   string
+	dlqSubject   string
+	partition    writers.Partition
+	batch        writers.Batch
+	flushTimeout time.Duration
+	subject      string
+	upsert       bool
 }
 
 func main() {
@@ -83,9 +114,35 @@ func main() {
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
 
-	repo := newService(db, logger)
-	if err = writers.Start(nc, repo, svcName, cfg.channels, logger); err != nil {
+	repo := newService(db, cfg.upsert, logger)
+	if cfg.timeWindow > 0 {
+		repo = writers.NewTimestampRepository(repo, cfg.timeWindow)
+	}
+
+	quarantine, err := writers.NewQuarantine(nc, cfg.quarantine)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create Postgres writer quarantine: %s", err))
+		os.Exit(1)
+	}
+
+	dlq, err := writers.NewDeadLetterQueue(nc, cfg.dlqSubject)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create Postgres writer dead-letter queue: %s", err))
+		os.Exit(1)
+	}
+
+	opts := []writers.Option{
+		writers.WithMetrics(makeLagGauge(), makeFailuresCounter()),
+		writers.WithPartition(cfg.partition),
+		writers.WithMaxSize(cfg.maxMsgSize),
+		writers.WithQuarantine(quarantine),
+		writers.WithDeadLetterQueue(dlq),
+		writers.WithBatch(cfg.batch),
+	}
+	consumer, err := writers.Start(nc, repo, cfg.subject, svcName, cfg.channels, logger, opts...)
+	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Postgres writer: %s", err))
+		os.Exit(1)
 	}
 
 	errs := make(chan error, 2)
@@ -100,6 +157,14 @@ func main() {
 
 	err = <-errs
 	logger.Error(fmt.Sprintf("Postgres writer service terminated: %s", err))
+
+	// Stop consuming before flushing, so that the buffer isn't refilled. NATS
+	// connection is closed only after the flush, since the messages failing
+	// to be saved are republished to the dead-letter queue.
+	if err := consumer.Close(); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to unsubscribe from NATS: %s", err))
+	}
+	writers.FlushBuffer(consumer, cfg.flushTimeout, logger)
 }
 
 func loadConfig() config {
@@ -117,12 +182,85 @@ func loadConfig() config {
 	}
 
 	return config{
-		natsURL:  mainflux.Env(envNatsURL, defNatsURL),
-		logLevel: mainflux.Env(envLogLevel, defLogLevel),
-		port:     mainflux.Env(envPort, defPort),
-		dbConfig: dbConfig,
-		channels: loadChansConfig(chanCfgPath),
+		natsURL:      mainflux.Env(envNatsURL, defNatsURL),
+		logLevel:     mainflux.Env(envLogLevel, defLogLevel),
+		port:         mainflux.Env(envPort, defPort),
+		dbConfig:     dbConfig,
+		channels:     loadChansConfig(chanCfgPath),
+		timeWindow:   loadTimeWindow(),
+		maxMsgSize:   loadMaxMsgSize(),
+		quarantine:   mainflux.Env(envQuarantine, defQuarantine),
+		dlqSubject:   mainflux.Env(envDLQSubject, defDLQSubject),
+		subject:      mainflux.Env(envSubject, defSubject),
+		partition:    loadPartition(),
+		batch:        loadBatch(),
+		flushTimeout: loadFlushTimeout(),
+		upsert:       loadUpsert(),
+	}
+}
+
+func loadUpsert() bool {
+	upsert, err := strconv.ParseBool(mainflux.Env(envUpsert, defUpsert))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envUpsert, err.Error())
+	}
+
+	return upsert
+}
+
+func loadTimeWindow() time.Duration {
+	window, err := strconv.ParseUint(mainflux.Env(envTimeWindow, defTimeWindow), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envTimeWindow, err.Error())
+	}
+
+	return time.Duration(window) * time.Second
+}
+
+func loadPartition() writers.Partition {
+	partition, err := writers.ParsePartition(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
+	}
+
+	return partition
+}
+
+func loadBatch() writers.Batch {
+	size, err := strconv.Atoi(mainflux.Env(envBatchSize, defBatchSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
 	}
+
+	interval, err := strconv.ParseUint(mainflux.Env(envFlushInterval, defFlushInterval), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushInterval, err.Error())
+	}
+
+	batch := writers.Batch{Size: size, Interval: time.Duration(interval) * time.Millisecond}
+	if err := batch.Validate(); err != nil {
+		log.Fatalf("Invalid %s value: %s", envBatchSize, err.Error())
+	}
+
+	return batch
+}
+
+func loadFlushTimeout() time.Duration {
+	timeout, err := strconv.ParseUint(mainflux.Env(envFlushTimeout, defFlushTimeout), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envFlushTimeout, err.Error())
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+func loadMaxMsgSize() int {
+	size, err := strconv.Atoi(mainflux.Env(envMaxMsgSize, defMaxMsgSize))
+	if err != nil || size < 0 {
+		log.Fatalf("Invalid %s value", envMaxMsgSize)
+	}
+
+	return size
 }
 
 type channels struct {
@@ -171,8 +309,15 @@ func connectToDB(dbConfig postgres.Config, logger logger.Logger) *sqlx.DB {
 	return db
 }
 
-func newService(db *sqlx.DB, logger logger.Logger) writers.MessageRepository {
+func newService(db *sqlx.DB, upsert bool, logger logger.Logger) writers.MessageRepository {
 	svc := postgres.New(db)
+	if upsert {
+		var err error
+		if svc, err = postgres.NewUpsert(db); err != nil {
+			logger.Error(fmt.Sprintf("Failed to create natural key index: %s", err))
+			os.Exit(1)
+		}
+	}
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -193,6 +338,24 @@ func newService(db *sqlx.DB, logger logger.Logger) writers.MessageRepository {
 	return svc
 }
 
+func makeLagGauge() *kitprometheus.Gauge {
+	return kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "postgres",
+		Subsystem: "message_writer",
+		Name:      "consumption_lag_seconds",
+		Help:      "Difference between the consumption time and the time of the last consumed message.",
+	}, []string{})
+}
+
+func makeFailuresCounter() *kitprometheus.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "postgres",
+		Subsystem: "message_writer",
+		Name:      "save_failures",
+		Help:      "Number of messages that failed to save, by error class and handling action.",
+	}, []string{"class", "action"})
+}
+
 func startHTTPServer(port string, errs chan error, logger logger.Logger) {
 	p := fmt.Sprintf(":%s", port)
 	logger.Info(fmt.Sprintf("Postgres writer service started, exposed port %s", port))
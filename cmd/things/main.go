@@ -8,6 +8,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/mainflux/mainflux/things/tracing"
 
+	"github.com/BurntSushi/toml"
 	"github.com/jmoiron/sqlx"
 	opentracing "github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc/credentials"
@@ -35,7 +37,9 @@ import (
 	authgrpcapi "github.com/mainflux/mainflux/things/api/auth/grpc"
 	authhttpapi "github.com/mainflux/mainflux/things/api/auth/http"
 	thhttpapi "github.com/mainflux/mainflux/things/api/things/http"
+	thingsjwt "github.com/mainflux/mainflux/things/jwt"
 	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/mainflux/mainflux/things/queue"
 	rediscache "github.com/mainflux/mainflux/things/redis"
 	localusers "github.com/mainflux/mainflux/things/users"
 	"github.com/mainflux/mainflux/things/uuid"
@@ -64,6 +68,13 @@ const (
 	defESURL           = "localhost:6379"
 	defESPass          = ""
 	defESDB            = "0"
+	defEventSink       = "redis"
+	defQueueURL        = ""
+	defQueueCreds      = ""
+	defQueueTimeout    = "5s"
+	defQueueDispatch   = "1s"
+	defQueueMinBackoff = "1s"
+	defQueueMaxBackoff = "5m"
 	defHTTPPort        = "8180"
 	defAuthHTTPPort    = "8989"
 	defAuthGRPCPort    = "8181"
@@ -74,6 +85,23 @@ const (
 	defSingleUserToken = ""
 	defJaegerURL       = ""
 	defUsersTimeout    = "1" // in seconds
+	defKeyTTL          = "0s"
+	defKeyRotation     = "1h"
+	defKeyEncoding     = "uuid"
+	defConnectionMode  = "shared"
+	defConnectionSweep = "1m"
+	defMinConnections  = "0"
+	defCatchAllChannel = ""
+	defIDPrefix        = ""
+	defValidateIDs     = "true"
+	defMaxNameLength   = "1024"
+	defReservedPrefix  = things.ReservedMetadataPrefix
+	defSecret          = ""
+	defAdminToken      = ""
+	defShareURL        = ""
+	defCreationLimit   = "0"
+	defCreationWindow  = "1m"
+	defProvisionTpl    = ""
 
 	envLogLevel        = "MF_THINGS_LOG_LEVEL"
 	envDBHost          = "MF_THINGS_DB_HOST"
@@ -93,6 +121,13 @@ const (
 	envESURL           = "MF_THINGS_ES_URL"
 	envESPass          = "MF_THINGS_ES_PASS"
 	envESDB            = "MF_THINGS_ES_DB"
+	envEventSink       = "MF_THINGS_EVENT_SINK"
+	envQueueURL        = "MF_THINGS_QUEUE_URL"
+	envQueueCreds      = "MF_THINGS_QUEUE_CREDENTIALS"
+	envQueueTimeout    = "MF_THINGS_QUEUE_TIMEOUT"
+	envQueueDispatch   = "MF_THINGS_QUEUE_DISPATCH_INTERVAL"
+	envQueueMinBackoff = "MF_THINGS_QUEUE_MIN_BACKOFF"
+	envQueueMaxBackoff = "MF_THINGS_QUEUE_MAX_BACKOFF"
 	envHTTPPort        = "MF_THINGS_HTTP_PORT"
 	envAuthHTTPPort    = "MF_THINGS_AUTH_HTTP_PORT"
 	envAuthGRPCPort    = "MF_THINGS_AUTH_GRPC_PORT"
@@ -103,6 +138,36 @@ const (
 	envSingleUserToken = "MF_THINGS_SINGLE_USER_TOKEN"
 	envJaegerURL       = "MF_JAEGER_URL"
 	envUsersTimeout    = "MF_THINGS_USERS_TIMEOUT"
+	envKeyTTL          = "MF_THINGS_KEY_TTL"
+	envKeyRotation     = "MF_THINGS_KEY_ROTATION_INTERVAL"
+	envKeyEncoding     = "MF_THINGS_KEY_ENCODING"
+	envConnectionMode  = "MF_THINGS_CONNECTION_MODE"
+	envConnectionSweep = "MF_THINGS_CONNECTION_SWEEP_INTERVAL"
+	envMinConnections  = "MF_THINGS_MIN_CONNECTIONS"
+	envCatchAllChannel = "MF_THINGS_CATCH_ALL_CHANNEL"
+	envIDPrefix        = "MF_THINGS_ID_PREFIX"
+	envValidateIDs     = "MF_THINGS_VALIDATE_IDS"
+	envMaxNameLength   = "MF_THINGS_MAX_NAME_LENGTH"
+	envReservedPrefix  = "MF_THINGS_RESERVED_METADATA_PREFIX"
+	envSecret          = "MF_THINGS_SECRET"
+	envAdminToken      = "MF_THINGS_ADMIN_TOKEN"
+	envShareURL        = "MF_THINGS_SHARE_URL"
+	envCreationLimit   = "MF_THINGS_CREATION_LIMIT"
+	envCreationWindow  = "MF_THINGS_CREATION_WINDOW"
+	envProvisionTpl    = "MF_THINGS_PROVISION_TEMPLATE"
+)
+
+const (
+	redisSink = "redis"
+	queueSink = "queue"
+)
+
+const (
+	// outboxBatch is the maximum number of the events delivered per dispatch.
+	outboxBatch = 100
+
+	// outboxRetention is how long the delivered events are kept in the outbox.
+	outboxRetention = 24 * time.Hour
 )
 
 type config struct {
@@ -116,6 +181,13 @@ type config struct {
 	esURL           string
 	esPass          string
 	esDB            string
+	eventSink       string
+	queueURL        string
+	queueCreds      string
+	queueTimeout    time.Duration
+	queueDispatch   time.Duration
+	queueMinBackoff time.Duration
+	queueMaxBackoff time.Duration
 	httpPort        string
 	authHTTPPort    string
 	authGRPCPort    string
@@ -126,6 +198,23 @@ type config struct {
 	singleUserToken string
 	jaegerURL       string
 	usersTimeout    time.Duration
+	keyTTL          time.Duration
+	keyRotation     time.Duration
+	keyEncoding     things.KeyEncoding
+	connMode        things.ConnectionMode
+	connSweep       time.Duration
+	minConns        uint64
+	catchAll        string
+	idPrefix        string
+	validateIDs     bool
+	maxNameLength   int
+	reservedPrefix  string
+	secret          string
+	adminToken      string
+	shareURL        string
+	creationLimit   int
+	creationWindow  time.Duration
+	provisioning    things.ProvisionTemplate
 }
 
 func main() {
@@ -141,7 +230,7 @@ func main() {
 
 	cacheClient := connectToRedis(cfg.cacheURL, cfg.cachePass, cfg.cacheDB, logger)
 
-	esClient := connectToRedis(cfg.esURL, cfg.esPass, cfg.esDB, logger)
+	sink := newEventSink(cfg, logger)
 
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
@@ -160,11 +249,63 @@ func main() {
 	cacheTracer, cacheCloser := initJaeger("things_cache", cfg.jaegerURL, logger)
 	defer cacheCloser.Close()
 
-	svc := newService(users, dbTracer, cacheTracer, db, cacheClient, esClient, logger)
+	links := postgres.NewShareLinkRepository(db)
+	links = tracing.ShareLinkRepositoryMiddleware(dbTracer, links)
+
+	apiKeys := postgres.NewAPIKeyRepository(db)
+	apiKeys = tracing.APIKeyRepositoryMiddleware(dbTracer, apiKeys)
+
+	owners := postgres.NewOwnerRepository(db)
+	owners = tracing.OwnerRepositoryMiddleware(dbTracer, owners)
+
+	// Events published to the queue are kept in the outbox until they are
+	// delivered, so that they aren't lost when the queue is unavailable or
+	// the service restarts.
+	if cfg.eventSink == queueSink {
+		outbox := postgres.NewOutboxRepository(db)
+		outbox = tracing.OutboxRepositoryMiddleware(dbTracer, outbox)
+
+		dispatcher := things.NewDispatcher(outbox, sink, outboxBatch, cfg.queueMinBackoff, cfg.queueMaxBackoff)
+		go dispatchEvents(dispatcher, cfg.queueDispatch, logger)
+		go removeDelivered(outbox, outboxRetention, logger)
+
+		sink = things.NewOutboxSink(outbox)
+	}
+
+	opts := []things.Option{
+		things.WithKeyTTL(cfg.keyTTL),
+		things.WithKeyEncoding(cfg.keyEncoding),
+		things.WithConnectionMode(cfg.connMode),
+		things.WithMinConnections(cfg.minConns),
+		things.WithCatchAllChannel(cfg.catchAll),
+		things.WithProvisionTemplate(cfg.provisioning),
+		things.WithIDPrefix(cfg.idPrefix),
+		things.WithMaxNameLength(cfg.maxNameLength),
+		things.WithReservedMetadataPrefix(cfg.reservedPrefix),
+		things.WithShareLinks(links, cfg.shareURL),
+		things.WithAPIKeys(apiKeys),
+		things.WithOwners(owners),
+		things.WithLogger(logger),
+	}
+	if cfg.secret != "" {
+		opts = append(opts, things.WithChannelTokenizer(thingsjwt.New(cfg.secret)))
+	}
+
+	svc := newService(users, apiKeys, dbTracer, cacheTracer, db, cfg.idPrefix, cacheClient, sink, cfg.creationLimit, cfg.creationWindow, logger, opts...)
 	errs := make(chan error, 2)
 
-	go startHTTPServer(thhttpapi.MakeHandler(thingsTracer, svc), cfg.httpPort, cfg, logger, errs)
-	go startHTTPServer(authhttpapi.MakeHandler(thingsTracer, svc), cfg.authHTTPPort, cfg, logger, errs)
+	if cfg.keyTTL > 0 {
+		go rotateExpiredKeys(svc, cfg.keyRotation, logger)
+	}
+	go disconnectExpired(svc, cfg.connSweep, logger)
+
+	var validID func(string) bool
+	if cfg.validateIDs {
+		validID = uuid.Valid
+	}
+
+	go startHTTPServer(thhttpapi.MakeHandler(thingsTracer, svc, cfg.idPrefix, validID), cfg.httpPort, cfg, logger, errs)
+	go startHTTPServer(authhttpapi.MakeHandler(thingsTracer, svc, cfg.adminToken), cfg.authHTTPPort, cfg, logger, errs)
 	go startGRPCServer(svc, thingsTracer, cfg, logger, errs)
 
 	go func() {
@@ -188,6 +329,102 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envUsersTimeout, err.Error())
 	}
 
+	keyTTL, err := time.ParseDuration(mainflux.Env(envKeyTTL, defKeyTTL))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envKeyTTL, err.Error())
+	}
+
+	keyRotation, err := time.ParseDuration(mainflux.Env(envKeyRotation, defKeyRotation))
+	if err != nil || keyRotation <= 0 {
+		log.Fatalf("Invalid %s value", envKeyRotation)
+	}
+
+	connSweep, err := time.ParseDuration(mainflux.Env(envConnectionSweep, defConnectionSweep))
+	if err != nil || connSweep <= 0 {
+		log.Fatalf("Invalid %s value", envConnectionSweep)
+	}
+
+	eventSink := mainflux.Env(envEventSink, defEventSink)
+	if eventSink != redisSink && eventSink != queueSink {
+		log.Fatalf("Invalid %s value: %s", envEventSink, eventSink)
+	}
+
+	queueURL := mainflux.Env(envQueueURL, defQueueURL)
+	if eventSink == queueSink && queueURL == "" {
+		log.Fatalf("%s is required for the %s event sink", envQueueURL, queueSink)
+	}
+
+	queueTimeout, err := time.ParseDuration(mainflux.Env(envQueueTimeout, defQueueTimeout))
+	if err != nil || queueTimeout <= 0 {
+		log.Fatalf("Invalid %s value", envQueueTimeout)
+	}
+
+	queueDispatch, err := time.ParseDuration(mainflux.Env(envQueueDispatch, defQueueDispatch))
+	if err != nil || queueDispatch <= 0 {
+		log.Fatalf("Invalid %s value", envQueueDispatch)
+	}
+
+	queueMinBackoff, err := time.ParseDuration(mainflux.Env(envQueueMinBackoff, defQueueMinBackoff))
+	if err != nil || queueMinBackoff <= 0 {
+		log.Fatalf("Invalid %s value", envQueueMinBackoff)
+	}
+
+	queueMaxBackoff, err := time.ParseDuration(mainflux.Env(envQueueMaxBackoff, defQueueMaxBackoff))
+	if err != nil || queueMaxBackoff < queueMinBackoff {
+		log.Fatalf("Invalid %s value", envQueueMaxBackoff)
+	}
+
+	keyEncoding := things.KeyEncoding(mainflux.Env(envKeyEncoding, defKeyEncoding))
+	if !keyEncoding.Valid() {
+		log.Fatalf("Invalid %s value: %s", envKeyEncoding, keyEncoding)
+	}
+
+	connMode := things.ConnectionMode(mainflux.Env(envConnectionMode, defConnectionMode))
+	if !connMode.Valid() {
+		log.Fatalf("Invalid %s value: %s", envConnectionMode, connMode)
+	}
+
+	minConns, err := strconv.ParseUint(mainflux.Env(envMinConnections, defMinConnections), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value", envMinConnections)
+	}
+	if minConns > 1 && connMode != things.SharedConnections {
+		log.Fatalf("Invalid %s value: things can't be connected to %d channels in %s connection mode", envMinConnections, minConns, connMode)
+	}
+
+	idPrefix := mainflux.Env(envIDPrefix, defIDPrefix)
+	if !things.ValidIDPrefix(idPrefix) {
+		log.Fatalf("Invalid %s value: %s", envIDPrefix, idPrefix)
+	}
+
+	validateIDs, err := strconv.ParseBool(mainflux.Env(envValidateIDs, defValidateIDs))
+	if err != nil {
+		log.Fatalf("Invalid %s value", envValidateIDs)
+	}
+
+	maxNameLength, err := strconv.Atoi(mainflux.Env(envMaxNameLength, defMaxNameLength))
+	if err != nil || !things.ValidMaxNameLength(maxNameLength) {
+		log.Fatalf("Invalid %s value", envMaxNameLength)
+	}
+
+	creationLimit, err := strconv.Atoi(mainflux.Env(envCreationLimit, defCreationLimit))
+	if err != nil || creationLimit < 0 {
+		log.Fatalf("Invalid %s value", envCreationLimit)
+	}
+
+	creationWindow, err := time.ParseDuration(mainflux.Env(envCreationWindow, defCreationWindow))
+	if err != nil || creationWindow <= 0 {
+		log.Fatalf("Invalid %s value", envCreationWindow)
+	}
+
+	provisioning := things.DefaultProvisionTemplate
+	if path := mainflux.Env(envProvisionTpl, defProvisionTpl); path != "" {
+		provisioning = loadProvisionTemplate(path)
+	}
+	if err := provisioning.Validate(connMode); err != nil {
+		log.Fatalf("Invalid %s value: template connects more channels than %s connection mode allows", envProvisionTpl, connMode)
+	}
+
 	dbConfig := postgres.Config{
 		Host:        mainflux.Env(envDBHost, defDBHost),
 		Port:        mainflux.Env(envDBPort, defDBPort),
@@ -198,6 +435,7 @@ func loadConfig() config {
 		SSLCert:     mainflux.Env(envDBSSLCert, defDBSSLCert),
 		SSLKey:      mainflux.Env(envDBSSLKey, defDBSSLKey),
 		SSLRootCert: mainflux.Env(envDBSSLRootCert, defDBSSLRootCert),
+		PrefixedIDs: idPrefix != "",
 	}
 
 	return config{
@@ -211,6 +449,13 @@ func loadConfig() config {
 		esURL:           mainflux.Env(envESURL, defESURL),
 		esPass:          mainflux.Env(envESPass, defESPass),
 		esDB:            mainflux.Env(envESDB, defESDB),
+		eventSink:       eventSink,
+		queueURL:        queueURL,
+		queueCreds:      mainflux.Env(envQueueCreds, defQueueCreds),
+		queueTimeout:    queueTimeout,
+		queueDispatch:   queueDispatch,
+		queueMinBackoff: queueMinBackoff,
+		queueMaxBackoff: queueMaxBackoff,
 		httpPort:        mainflux.Env(envHTTPPort, defHTTPPort),
 		authHTTPPort:    mainflux.Env(envAuthHTTPPort, defAuthHTTPPort),
 		authGRPCPort:    mainflux.Env(envAuthGRPCPort, defAuthGRPCPort),
@@ -221,7 +466,59 @@ func loadConfig() config {
 		singleUserToken: mainflux.Env(envSingleUserToken, defSingleUserToken),
 		jaegerURL:       mainflux.Env(envJaegerURL, defJaegerURL),
 		usersTimeout:    time.Duration(timeout) * time.Second,
+		keyTTL:          keyTTL,
+		keyRotation:     keyRotation,
+		keyEncoding:     keyEncoding,
+		connMode:        connMode,
+		connSweep:       connSweep,
+		minConns:        minConns,
+		catchAll:        mainflux.Env(envCatchAllChannel, defCatchAllChannel),
+		idPrefix:        idPrefix,
+		validateIDs:     validateIDs,
+		maxNameLength:   maxNameLength,
+		reservedPrefix:  mainflux.Env(envReservedPrefix, defReservedPrefix),
+		secret:          mainflux.Env(envSecret, defSecret),
+		adminToken:      mainflux.Env(envAdminToken, defAdminToken),
+		shareURL:        mainflux.Env(envShareURL, defShareURL),
+		creationLimit:   creationLimit,
+		creationWindow:  creationWindow,
+		provisioning:    provisioning,
+	}
+}
+
+type channelTemplate struct {
+	Name     string                 `toml:"name"`
+	Metadata map[string]interface{} `toml:"metadata"`
+	Connect  bool                   `toml:"connect"`
+}
+
+type provisionConfig struct {
+	Channels []channelTemplate `toml:"channels"`
+}
+
+// loadProvisionTemplate reads the provisioning template from the TOML file
+// listing the channels created along with the provisioned thing.
+func loadProvisionTemplate(path string) things.ProvisionTemplate {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
 	}
+
+	var cfg provisionConfig
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	tpl := things.ProvisionTemplate{Channels: []things.ChannelTemplate{}}
+	for _, ch := range cfg.Channels {
+		tpl.Channels = append(tpl.Channels, things.ChannelTemplate{
+			Name:     ch.Name,
+			Metadata: ch.Metadata,
+			Connect:  ch.Connect,
+		})
+	}
+
+	return tpl
 }
 
 func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer, io.Closer) {
@@ -305,22 +602,49 @@ func connectToUsers(cfg config, logger logger.Logger) *grpc.ClientConn {
 	return conn
 }
 
-func newService(users mainflux.UsersServiceClient, dbTracer opentracing.Tracer, cacheTracer opentracing.Tracer, db *sqlx.DB, cacheClient *redis.Client, esClient *redis.Client, logger logger.Logger) things.Service {
-	thingsRepo := postgres.NewThingRepository(db)
+func newEventSink(cfg config, logger logger.Logger) things.EventSink {
+	if cfg.eventSink == queueSink {
+		return queue.NewSink(cfg.queueURL, cfg.queueCreds, cfg.queueTimeout)
+	}
+
+	esClient := connectToRedis(cfg.esURL, cfg.esPass, cfg.esDB, logger)
+	return rediscache.NewStreamSink(esClient)
+}
+
+func newService(users mainflux.UsersServiceClient, apiKeys things.APIKeyRepository, dbTracer opentracing.Tracer, cacheTracer opentracing.Tracer, db *sqlx.DB, idPrefix string, cacheClient *redis.Client, sink things.EventSink, creationLimit int, creationWindow time.Duration, logger logger.Logger, opts ...things.Option) things.Service {
+	thingsRepo := postgres.NewThingRepository(db, idPrefix)
 	thingsRepo = tracing.ThingRepositoryMiddleware(dbTracer, thingsRepo)
 
-	channelsRepo := postgres.NewChannelRepository(db)
+	channelsRepo := postgres.NewChannelRepository(db, idPrefix)
 	channelsRepo = tracing.ChannelRepositoryMiddleware(dbTracer, channelsRepo)
 
+	cacheHits := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "things",
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Number of cache lookups that found the entry.",
+	}, []string{"cache"})
+	cacheMisses := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "things",
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Number of cache lookups that didn't find the entry.",
+	}, []string{"cache"})
+
 	chanCache := rediscache.NewChannelCache(cacheClient)
 	chanCache = tracing.ChannelCacheMiddleware(cacheTracer, chanCache)
+	chanCache = things.ChannelCacheMetrics(chanCache, cacheHits, cacheMisses)
 
 	thingCache := rediscache.NewThingCache(cacheClient)
 	thingCache = tracing.ThingCacheMiddleware(cacheTracer, thingCache)
+	thingCache = things.ThingCacheMetrics(thingCache, cacheHits, cacheMisses)
 	idp := uuid.New()
 
-	svc := things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
-	svc = rediscache.NewEventStoreMiddleware(svc, esClient)
+	svc := things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, opts...)
+	if creationLimit > 0 {
+		svc = things.NewRateLimiter(svc, users, apiKeys, creationLimit, creationWindow)
+	}
+	svc = rediscache.NewEventStoreMiddleware(svc, sink)
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -340,6 +664,52 @@ func newService(users mainflux.UsersServiceClient, dbTracer opentracing.Tracer,
 	return svc
 }
 
+func rotateExpiredKeys(svc things.Service, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := svc.RotateExpiredKeys(context.Background()); err != nil {
+			logger.Error(fmt.Sprintf("Failed to rotate expired thing keys: %s", err))
+		}
+	}
+}
+
+func dispatchEvents(dispatcher *things.Dispatcher, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := dispatcher.Dispatch(context.Background(), time.Now()); err != nil {
+			logger.Error(fmt.Sprintf("Failed to dispatch outbox events: %s", err))
+		}
+	}
+}
+
+// removeDelivered removes the events that were delivered more than the
+// retention ago from the outbox, once per hour.
+func removeDelivered(outbox things.OutboxRepository, retention time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := outbox.RemoveDelivered(context.Background(), time.Now().Add(-retention)); err != nil {
+			logger.Error(fmt.Sprintf("Failed to remove delivered outbox events: %s", err))
+		}
+	}
+}
+
+func disconnectExpired(svc things.Service, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := svc.DisconnectExpired(context.Background()); err != nil {
+			logger.Error(fmt.Sprintf("Failed to disconnect expired connections: %s", err))
+		}
+	}
+}
+
 func startHTTPServer(handler http.Handler, port string, cfg config, logger logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", port)
 	if cfg.serverCert != "" || cfg.serverKey != "" {
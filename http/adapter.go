@@ -13,20 +13,28 @@ import (
 	"context"
 
 	"github.com/mainflux/mainflux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ mainflux.MessagePublisher = (*adapterService)(nil)
 
 type adapterService struct {
-	pub    mainflux.MessagePublisher
-	things mainflux.ThingsServiceClient
+	pub      mainflux.MessagePublisher
+	things   mainflux.ThingsServiceClient
+	catchAll string
 }
 
-// New instantiates the HTTP adapter implementation.
-func New(pub mainflux.MessagePublisher, things mainflux.ThingsServiceClient) mainflux.MessagePublisher {
+// New instantiates the HTTP adapter implementation. Messages the thing isn't
+// allowed to publish to their channel are routed to the non-empty catch-all
+// channel instead, provided that the thing can access it. Routed message is
+// published under the subtopic prefixed by the channel it was sent to, so
+// that it can be triaged. Empty catch-all channel rejects such messages.
+func New(pub mainflux.MessagePublisher, things mainflux.ThingsServiceClient, catchAll string) mainflux.MessagePublisher {
 	return &adapterService{
-		pub:    pub,
-		things: things,
+		pub:      pub,
+		things:   things,
+		catchAll: catchAll,
 	}
 }
 
@@ -36,6 +44,12 @@ func (as *adapterService) Publish(ctx context.Context, token string, msg mainflu
 		ChanID: msg.GetChannel(),
 	}
 	thid, err := as.things.CanAccess(ctx, ar)
+	if err != nil && as.routable(msg, err) {
+		thid, err = as.things.CanAccess(ctx, &mainflux.AccessReq{Token: token, ChanID: as.catchAll})
+		if err == nil {
+			msg = as.route(msg)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -43,3 +57,27 @@ func (as *adapterService) Publish(ctx context.Context, token string, msg mainflu
 
 	return as.pub.Publish(ctx, token, msg)
 }
+
+// routable returns true if the message denied access to its channel can be
+// routed to the catch-all channel.
+func (as *adapterService) routable(msg mainflux.RawMessage, err error) bool {
+	if as.catchAll == "" || msg.GetChannel() == as.catchAll {
+		return false
+	}
+
+	e, ok := status.FromError(err)
+	return ok && e.Code() == codes.PermissionDenied
+}
+
+// route moves the message to the catch-all channel, keeping the channel it
+// was sent to as the first token of the subtopic.
+func (as *adapterService) route(msg mainflux.RawMessage) mainflux.RawMessage {
+	subtopic := msg.GetChannel()
+	if msg.GetSubtopic() != "" {
+		subtopic = subtopic + "." + msg.GetSubtopic()
+	}
+
+	msg.Channel = as.catchAll
+	msg.Subtopic = subtopic
+	return msg
+}
@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux"
+	adapter "github.com/mainflux/mainflux/http"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	thingKey = "thing-key"
+	thingID  = "thing-id"
+	chanID   = "chan-id"
+	catchAll = "catch-all"
+)
+
+// thingsClient grants the thing access to the channel it is connected to
+// and, if enabled, to the catch-all channel.
+type thingsClient struct {
+	mainflux.ThingsServiceClient
+	catchAll bool
+}
+
+func (tc thingsClient) CanAccess(_ context.Context, req *mainflux.AccessReq, _ ...grpc.CallOption) (*mainflux.ThingID, error) {
+	if req.GetToken() != thingKey {
+		return nil, status.Error(codes.PermissionDenied, "invalid credentials provided")
+	}
+
+	if req.GetChanID() == chanID || (tc.catchAll && req.GetChanID() == catchAll) {
+		return &mainflux.ThingID{Value: thingID}, nil
+	}
+
+	return nil, status.Error(codes.PermissionDenied, "invalid credentials provided")
+}
+
+// publisher records the published messages.
+type publisher struct {
+	msgs []mainflux.RawMessage
+}
+
+func (pub *publisher) Publish(_ context.Context, _ string, msg mainflux.RawMessage) error {
+	pub.msgs = append(pub.msgs, msg)
+	return nil
+}
+
+func TestPublishCatchAll(t *testing.T) {
+	cases := []struct {
+		desc     string
+		catchAll string
+		enabled  bool
+		key      string
+		msg      mainflux.RawMessage
+		routed   mainflux.RawMessage
+		err      bool
+	}{
+		{
+			desc:     "publish to connected channel",
+			catchAll: catchAll,
+			enabled:  true,
+			key:      thingKey,
+			msg:      mainflux.RawMessage{Channel: chanID, Subtopic: "temp"},
+			routed:   mainflux.RawMessage{Channel: chanID, Subtopic: "temp", Publisher: thingID},
+		},
+		{
+			desc:     "publish to unconnected channel routed to catch-all channel",
+			catchAll: catchAll,
+			enabled:  true,
+			key:      thingKey,
+			msg:      mainflux.RawMessage{Channel: "other"},
+			routed:   mainflux.RawMessage{Channel: catchAll, Subtopic: "other", Publisher: thingID},
+		},
+		{
+			desc:     "publish to unconnected channel subtopic routed to catch-all channel",
+			catchAll: catchAll,
+			enabled:  true,
+			key:      thingKey,
+			msg:      mainflux.RawMessage{Channel: "other", Subtopic: "room.temp"},
+			routed:   mainflux.RawMessage{Channel: catchAll, Subtopic: "other.room.temp", Publisher: thingID},
+		},
+		{
+			desc:     "publish to unconnected channel with invalid key",
+			catchAll: catchAll,
+			enabled:  true,
+			key:      "invalid",
+			msg:      mainflux.RawMessage{Channel: "other"},
+			err:      true,
+		},
+		{
+			desc:     "publish to unconnected channel with catch-all channel denied",
+			catchAll: catchAll,
+			enabled:  false,
+			key:      thingKey,
+			msg:      mainflux.RawMessage{Channel: "other"},
+			err:      true,
+		},
+		{
+			desc:    "publish to unconnected channel without catch-all channel",
+			enabled: true,
+			key:     thingKey,
+			msg:     mainflux.RawMessage{Channel: "other"},
+			err:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		pub := &publisher{}
+		svc := adapter.New(pub, thingsClient{catchAll: tc.enabled}, tc.catchAll)
+
+		err := svc.Publish(context.Background(), tc.key, tc.msg)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		if tc.err {
+			assert.Empty(t, pub.msgs, fmt.Sprintf("%s: expected no published messages got %v", tc.desc, pub.msgs))
+			continue
+		}
+
+		assert.Equal(t, []mainflux.RawMessage{tc.routed}, pub.msgs, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.routed, pub.msgs))
+	}
+}
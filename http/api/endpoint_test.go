@@ -26,7 +26,7 @@ import (
 
 func newService(cc mainflux.ThingsServiceClient) mainflux.MessagePublisher {
 	pub := mocks.NewPublisher()
-	return adapter.New(pub, cc)
+	return adapter.New(pub, cc, "")
 }
 
 func newHTTPServer(pub mainflux.MessagePublisher) *httptest.Server {
@@ -58,6 +58,22 @@ func (tc thingsClient) CanAccessByID(context.Context, *mainflux.AccessByIDReq, .
 	panic("not implemented")
 }
 
+func (tc thingsClient) CanReadShared(context.Context, *mainflux.AccessReq, ...grpc.CallOption) (*empty.Empty, error) {
+	panic("not implemented")
+}
+
+func (tc thingsClient) CanReadMessages(context.Context, *mainflux.AccessReq, ...grpc.CallOption) (*mainflux.ThingID, error) {
+	panic("not implemented")
+}
+
+func (tc thingsClient) ListThingsByChannel(context.Context, *mainflux.AccessReq, ...grpc.CallOption) (mainflux.ThingsService_ListThingsByChannelClient, error) {
+	panic("not implemented")
+}
+
 func (tc thingsClient) Identify(ctx context.Context, req *mainflux.Token, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
 	panic("not implemented")
 }
+
+func (tc thingsClient) IdentifyBatch(ctx context.Context, req *mainflux.Tokens, opts ...grpc.CallOption) (*mainflux.ThingIDs, error) {
+	panic("not implemented")
+}
@@ -81,6 +81,9 @@ func (m *AccessReq) GetChanID() string {
 
 type ThingID struct {
 	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	CreatedAt            int64    `protobuf:"varint,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt            int64    `protobuf:"varint,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	PublisherScoped      bool     `protobuf:"varint,4,opt,name=publisher_scoped,json=publisherScoped,proto3" json:"publisher_scoped,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -126,6 +129,27 @@ func (m *ThingID) GetValue() string {
 	return ""
 }
 
+func (m *ThingID) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+func (m *ThingID) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func (m *ThingID) GetPublisherScoped() bool {
+	if m != nil {
+		return m.PublisherScoped
+	}
+	return false
+}
+
 type AccessByIDReq struct {
 	ThingID              string   `protobuf:"bytes,1,opt,name=thingID,proto3" json:"thingID,omitempty"`
 	ChanID               string   `protobuf:"bytes,2,opt,name=chanID,proto3" json:"chanID,omitempty"`
@@ -275,38 +299,214 @@ func (m *UserID) GetValue() string {
 	return ""
 }
 
+type Thing struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Metadata             string   `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Thing) Reset()         { *m = Thing{} }
+func (m *Thing) String() string { return proto.CompactTextString(m) }
+func (*Thing) ProtoMessage()    {}
+func (*Thing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41f4a519b878ee3b, []int{5}
+}
+func (m *Thing) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Thing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Thing.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Thing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Thing.Merge(m, src)
+}
+func (m *Thing) XXX_Size() int {
+	return m.Size()
+}
+func (m *Thing) XXX_DiscardUnknown() {
+	xxx_messageInfo_Thing.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Thing proto.InternalMessageInfo
+
+func (m *Thing) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Thing) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Thing) GetMetadata() string {
+	if m != nil {
+		return m.Metadata
+	}
+	return ""
+}
+
+type Tokens struct {
+	Values               []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Tokens) Reset()         { *m = Tokens{} }
+func (m *Tokens) String() string { return proto.CompactTextString(m) }
+func (*Tokens) ProtoMessage()    {}
+func (*Tokens) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41f4a519b878ee3b, []int{6}
+}
+func (m *Tokens) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Tokens) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Tokens.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Tokens) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Tokens.Merge(m, src)
+}
+func (m *Tokens) XXX_Size() int {
+	return m.Size()
+}
+func (m *Tokens) XXX_DiscardUnknown() {
+	xxx_messageInfo_Tokens.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Tokens proto.InternalMessageInfo
+
+func (m *Tokens) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type ThingIDs struct {
+	Values               map[string]string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ThingIDs) Reset()         { *m = ThingIDs{} }
+func (m *ThingIDs) String() string { return proto.CompactTextString(m) }
+func (*ThingIDs) ProtoMessage()    {}
+func (*ThingIDs) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41f4a519b878ee3b, []int{7}
+}
+func (m *ThingIDs) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ThingIDs) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ThingIDs.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ThingIDs) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ThingIDs.Merge(m, src)
+}
+func (m *ThingIDs) XXX_Size() int {
+	return m.Size()
+}
+func (m *ThingIDs) XXX_DiscardUnknown() {
+	xxx_messageInfo_ThingIDs.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ThingIDs proto.InternalMessageInfo
+
+func (m *ThingIDs) GetValues() map[string]string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*AccessReq)(nil), "mainflux.AccessReq")
 	proto.RegisterType((*ThingID)(nil), "mainflux.ThingID")
 	proto.RegisterType((*AccessByIDReq)(nil), "mainflux.AccessByIDReq")
 	proto.RegisterType((*Token)(nil), "mainflux.Token")
 	proto.RegisterType((*UserID)(nil), "mainflux.UserID")
+	proto.RegisterType((*Thing)(nil), "mainflux.Thing")
+	proto.RegisterType((*Tokens)(nil), "mainflux.Tokens")
+	proto.RegisterType((*ThingIDs)(nil), "mainflux.ThingIDs")
+	proto.RegisterMapType((map[string]string)(nil), "mainflux.ThingIDs.ValuesEntry")
 }
 
 func init() { proto.RegisterFile("internal.proto", fileDescriptor_41f4a519b878ee3b) }
 
 var fileDescriptor_41f4a519b878ee3b = []byte{
-	// 316 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x50, 0x4d, 0x4e, 0xc2, 0x40,
-	0x18, 0xed, 0x98, 0xf0, 0xf7, 0x45, 0x14, 0x47, 0x83, 0x04, 0x63, 0x35, 0xb3, 0x72, 0x35, 0x18,
-	0x8c, 0x0b, 0x57, 0x06, 0xac, 0x8b, 0x6e, 0x11, 0x0f, 0x50, 0xea, 0xb4, 0x34, 0x96, 0x29, 0xb6,
-	0x53, 0x62, 0x6f, 0xe2, 0x61, 0x3c, 0x80, 0x4b, 0x8f, 0x60, 0xea, 0x45, 0xcc, 0xcc, 0xb4, 0xc5,
-	0x18, 0x70, 0xf9, 0x5e, 0xbe, 0xf7, 0xbd, 0x1f, 0xd8, 0x0b, 0xb8, 0x60, 0x31, 0x77, 0x42, 0xba,
-	0x8c, 0x23, 0x11, 0xe1, 0xe6, 0xc2, 0x09, 0xb8, 0x17, 0xa6, 0xaf, 0xfd, 0x13, 0x3f, 0x8a, 0xfc,
-	0x90, 0x0d, 0x14, 0x3f, 0x4b, 0xbd, 0x01, 0x5b, 0x2c, 0x45, 0xa6, 0xcf, 0xc8, 0x0d, 0xb4, 0x46,
-	0xae, 0xcb, 0x92, 0x64, 0xc2, 0x5e, 0xf0, 0x11, 0xd4, 0x44, 0xf4, 0xcc, 0x78, 0x0f, 0x9d, 0xa3,
-	0x8b, 0xd6, 0x44, 0x03, 0xdc, 0x85, 0xba, 0x3b, 0x77, 0xb8, 0x6d, 0xf5, 0x76, 0x14, 0x5d, 0x20,
-	0x72, 0x06, 0x8d, 0xe9, 0x3c, 0xe0, 0xbe, 0x6d, 0x49, 0xe1, 0xca, 0x09, 0x53, 0x56, 0x0a, 0x15,
-	0x20, 0x23, 0x68, 0xeb, 0xdf, 0xe3, 0xcc, 0xb6, 0xe4, 0xff, 0x1e, 0x34, 0x84, 0x56, 0x14, 0x87,
-	0x25, 0xdc, 0xea, 0x71, 0x0a, 0xb5, 0xa9, 0x0a, 0xb1, 0xd9, 0xc1, 0x84, 0xfa, 0x63, 0xc2, 0xe2,
-	0x6d, 0x09, 0x86, 0xef, 0x08, 0xda, 0x2a, 0x63, 0xf2, 0xc0, 0xe2, 0x55, 0xe0, 0x32, 0x7c, 0x0d,
-	0xad, 0x3b, 0x87, 0xeb, 0x58, 0xf8, 0x90, 0x96, 0x23, 0xd1, 0x6a, 0x84, 0xfe, 0xc1, 0x9a, 0x2c,
-	0xea, 0x11, 0x03, 0x8f, 0xa1, 0x5d, 0xc9, 0x64, 0x1b, 0x7c, 0xfc, 0x57, 0x5a, 0x74, 0xec, 0x77,
-	0xa9, 0x9e, 0x9b, 0x96, 0x73, 0xd3, 0x7b, 0x39, 0x37, 0x31, 0xf0, 0x25, 0x34, 0xed, 0x27, 0xc6,
-	0x45, 0xe0, 0x65, 0x78, 0xff, 0x97, 0x89, 0xec, 0xb7, 0xd1, 0x75, 0x78, 0x0b, 0xbb, 0xb2, 0x5e,
-	0x15, 0x7e, 0xf0, 0xdf, 0x87, 0xce, 0x9a, 0xd0, 0x9b, 0x10, 0x63, 0xdc, 0xf9, 0xc8, 0x4d, 0xf4,
-	0x99, 0x9b, 0xe8, 0x2b, 0x37, 0xd1, 0xdb, 0xb7, 0x69, 0xcc, 0xea, 0x2a, 0xd6, 0xd5, 0x4f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0xcf, 0x3f, 0x05, 0x40, 0x2f, 0x02, 0x00, 0x00,
+	// 551 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x95, 0x52, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0x8e, 0x93, 0x36, 0x4d, 0xa6, 0xa4, 0x09, 0x5b, 0x54, 0x22, 0xa3, 0x46, 0xd5, 0x9e, 0xe8,
+	0xc5, 0xa9, 0x82, 0xf8, 0x29, 0x48, 0x54, 0x49, 0x53, 0xa1, 0x48, 0x70, 0x71, 0x0a, 0xd7, 0x6a,
+	0x63, 0x4f, 0x12, 0xab, 0xce, 0xda, 0x78, 0x37, 0x15, 0x3e, 0x70, 0xe7, 0x11, 0x78, 0x0f, 0x5e,
+	0x82, 0x23, 0x8f, 0x80, 0xe0, 0x45, 0x58, 0xaf, 0xed, 0x34, 0xa4, 0x09, 0x12, 0x87, 0x95, 0x76,
+	0xfe, 0xbe, 0xf9, 0xbe, 0x99, 0x81, 0x3d, 0x8f, 0x4b, 0x8c, 0x38, 0xf3, 0xad, 0x30, 0x0a, 0x64,
+	0x40, 0x2a, 0x33, 0xe6, 0xf1, 0xb1, 0x3f, 0xff, 0x64, 0x3e, 0x9a, 0x04, 0xc1, 0xc4, 0xc7, 0xb6,
+	0xf6, 0x8f, 0xe6, 0xe3, 0x36, 0xce, 0x42, 0x19, 0xa7, 0x69, 0xf4, 0x14, 0xaa, 0x5d, 0xc7, 0x41,
+	0x21, 0x6c, 0xfc, 0x48, 0x1e, 0xc0, 0xb6, 0x0c, 0xae, 0x91, 0x37, 0x8d, 0x23, 0xe3, 0x71, 0xd5,
+	0x4e, 0x0d, 0x72, 0x00, 0x65, 0x67, 0xca, 0xf8, 0xa0, 0xdf, 0x2c, 0x6a, 0x77, 0x66, 0xd1, 0x2f,
+	0x06, 0xec, 0x5c, 0x4e, 0x3d, 0x3e, 0x19, 0xf4, 0x93, 0xca, 0x1b, 0xe6, 0xcf, 0x31, 0xaf, 0xd4,
+	0x06, 0x39, 0x04, 0x70, 0x22, 0x64, 0x12, 0xdd, 0x2b, 0x26, 0x75, 0x75, 0xc9, 0xae, 0x66, 0x9e,
+	0xae, 0x4c, 0xc2, 0xf3, 0xd0, 0xcd, 0xc3, 0xa5, 0x34, 0x9c, 0x79, 0x54, 0xf8, 0x18, 0x1a, 0xe1,
+	0x7c, 0xe4, 0x7b, 0x62, 0x8a, 0xd1, 0x95, 0x70, 0x82, 0x10, 0xdd, 0xe6, 0x96, 0x4a, 0xaa, 0xd8,
+	0xf5, 0x85, 0x7f, 0xa8, 0xdd, 0xb4, 0x0b, 0xb5, 0x54, 0x45, 0x2f, 0x1e, 0xf4, 0x13, 0x25, 0x4d,
+	0xd8, 0x91, 0x29, 0xb5, 0x8c, 0x51, 0x6e, 0x6e, 0x54, 0x73, 0x08, 0xdb, 0x97, 0x5a, 0xee, 0x5a,
+	0x29, 0xb4, 0x05, 0xe5, 0xf7, 0x02, 0xa3, 0x4d, 0x52, 0xe9, 0x1b, 0x55, 0x9e, 0x74, 0x20, 0x7b,
+	0x50, 0xf4, 0xdc, 0x2c, 0xa6, 0x7e, 0x84, 0xc0, 0x16, 0x67, 0x33, 0xcc, 0xba, 0xe9, 0x3f, 0x31,
+	0xa1, 0x32, 0x43, 0xc9, 0x94, 0x50, 0xa6, 0x65, 0x57, 0xed, 0x85, 0x4d, 0x8f, 0xa0, 0xac, 0x79,
+	0x88, 0x84, 0xa9, 0xc6, 0x16, 0x0a, 0xad, 0x94, 0x30, 0x4d, 0x2d, 0xfa, 0x19, 0x2a, 0xd9, 0xd8,
+	0x05, 0x79, 0xf6, 0x57, 0xce, 0x6e, 0xa7, 0x65, 0xe5, 0x6b, 0xb7, 0xf2, 0x1c, 0xeb, 0x83, 0x4e,
+	0xb8, 0xe0, 0x32, 0x8a, 0x73, 0x0c, 0xf3, 0x14, 0x76, 0x97, 0xdc, 0xa4, 0x01, 0xa5, 0x6b, 0x8c,
+	0x33, 0xd6, 0xc9, 0xf7, 0x56, 0x65, 0x71, 0x49, 0xe5, 0xcb, 0xe2, 0x0b, 0xa3, 0xf3, 0xad, 0x04,
+	0x35, 0x8d, 0x2d, 0x86, 0x18, 0xdd, 0x78, 0x0e, 0x92, 0xa7, 0x50, 0x3d, 0x67, 0x3c, 0x5d, 0x00,
+	0xd9, 0xbf, 0x65, 0xb0, 0x38, 0x2c, 0xf3, 0xfe, 0x1d, 0x5a, 0xb4, 0x40, 0x7a, 0x50, 0x5b, 0x94,
+	0x25, 0x7b, 0x23, 0x0f, 0x57, 0x4b, 0xb3, 0x6d, 0x9a, 0x07, 0x56, 0x7a, 0xc2, 0x56, 0x7e, 0xc2,
+	0xd6, 0x45, 0x72, 0xc2, 0x0a, 0xe3, 0x04, 0x2a, 0x03, 0x17, 0xb9, 0xf4, 0xc6, 0x31, 0xa9, 0x2f,
+	0x35, 0x49, 0x26, 0xb8, 0xbe, 0xeb, 0x6b, 0xdd, 0xd5, 0x46, 0xe6, 0x0e, 0xa7, 0x2c, 0x42, 0x77,
+	0x3d, 0xe1, 0xcd, 0x1d, 0x5f, 0x41, 0x3d, 0xab, 0x7f, 0xa7, 0x72, 0xd9, 0x04, 0xff, 0x47, 0xf2,
+	0x19, 0xec, 0xbf, 0xf5, 0x84, 0x4c, 0xc7, 0xd7, 0x8b, 0xcf, 0xd5, 0xe9, 0x71, 0xf4, 0xd7, 0x03,
+	0xd4, 0x57, 0x00, 0x68, 0xe1, 0xc4, 0x20, 0xcf, 0xa1, 0x96, 0xeb, 0xed, 0x31, 0xe9, 0x4c, 0x49,
+	0x63, 0x45, 0xb4, 0x30, 0xc9, 0xdd, 0x13, 0xa0, 0x85, 0xce, 0x19, 0xdc, 0x4b, 0xee, 0x77, 0xb1,
+	0xb3, 0xf6, 0xbf, 0x06, 0xb7, 0x04, 0x9a, 0x1e, 0x3d, 0x2d, 0xf4, 0x1a, 0xdf, 0x7f, 0xb5, 0x8c,
+	0x1f, 0xea, 0xfd, 0x54, 0xef, 0xeb, 0xef, 0x56, 0x61, 0x54, 0xd6, 0xb3, 0x79, 0xf2, 0x07, 0xc5,
+	0x3a, 0xa4, 0x99, 0x7a, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -324,6 +524,10 @@ type ThingsServiceClient interface {
 	CanAccess(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (*ThingID, error)
 	CanAccessByID(ctx context.Context, in *AccessByIDReq, opts ...grpc.CallOption) (*empty.Empty, error)
 	Identify(ctx context.Context, in *Token, opts ...grpc.CallOption) (*ThingID, error)
+	CanReadShared(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (*empty.Empty, error)
+	CanReadMessages(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (*ThingID, error)
+	ListThingsByChannel(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (ThingsService_ListThingsByChannelClient, error)
+	IdentifyBatch(ctx context.Context, in *Tokens, opts ...grpc.CallOption) (*ThingIDs, error)
 }
 
 type thingsServiceClient struct {
@@ -361,11 +565,74 @@ func (c *thingsServiceClient) Identify(ctx context.Context, in *Token, opts ...g
 	return out, nil
 }
 
+func (c *thingsServiceClient) CanReadShared(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/mainflux.ThingsService/CanReadShared", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *thingsServiceClient) CanReadMessages(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (*ThingID, error) {
+	out := new(ThingID)
+	err := c.cc.Invoke(ctx, "/mainflux.ThingsService/CanReadMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *thingsServiceClient) ListThingsByChannel(ctx context.Context, in *AccessReq, opts ...grpc.CallOption) (ThingsService_ListThingsByChannelClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ThingsService_serviceDesc.Streams[0], "/mainflux.ThingsService/ListThingsByChannel", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &thingsServiceListThingsByChannelClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ThingsService_ListThingsByChannelClient interface {
+	Recv() (*Thing, error)
+	grpc.ClientStream
+}
+
+type thingsServiceListThingsByChannelClient struct {
+	grpc.ClientStream
+}
+
+func (x *thingsServiceListThingsByChannelClient) Recv() (*Thing, error) {
+	m := new(Thing)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *thingsServiceClient) IdentifyBatch(ctx context.Context, in *Tokens, opts ...grpc.CallOption) (*ThingIDs, error) {
+	out := new(ThingIDs)
+	err := c.cc.Invoke(ctx, "/mainflux.ThingsService/IdentifyBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ThingsServiceServer is the server API for ThingsService service.
 type ThingsServiceServer interface {
 	CanAccess(context.Context, *AccessReq) (*ThingID, error)
 	CanAccessByID(context.Context, *AccessByIDReq) (*empty.Empty, error)
 	Identify(context.Context, *Token) (*ThingID, error)
+	CanReadShared(context.Context, *AccessReq) (*empty.Empty, error)
+	CanReadMessages(context.Context, *AccessReq) (*ThingID, error)
+	ListThingsByChannel(*AccessReq, ThingsService_ListThingsByChannelServer) error
+	IdentifyBatch(context.Context, *Tokens) (*ThingIDs, error)
 }
 
 func RegisterThingsServiceServer(s *grpc.Server, srv ThingsServiceServer) {
@@ -426,6 +693,81 @@ func _ThingsService_Identify_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ThingsService_CanReadShared_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccessReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThingsServiceServer).CanReadShared(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mainflux.ThingsService/CanReadShared",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThingsServiceServer).CanReadShared(ctx, req.(*AccessReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThingsService_CanReadMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccessReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThingsServiceServer).CanReadMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mainflux.ThingsService/CanReadMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThingsServiceServer).CanReadMessages(ctx, req.(*AccessReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThingsService_ListThingsByChannel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AccessReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ThingsServiceServer).ListThingsByChannel(m, &thingsServiceListThingsByChannelServer{stream})
+}
+
+type ThingsService_ListThingsByChannelServer interface {
+	Send(*Thing) error
+	grpc.ServerStream
+}
+
+type thingsServiceListThingsByChannelServer struct {
+	grpc.ServerStream
+}
+
+func (x *thingsServiceListThingsByChannelServer) Send(m *Thing) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ThingsService_IdentifyBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Tokens)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThingsServiceServer).IdentifyBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mainflux.ThingsService/IdentifyBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThingsServiceServer).IdentifyBatch(ctx, req.(*Tokens))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ThingsService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "mainflux.ThingsService",
 	HandlerType: (*ThingsServiceServer)(nil),
@@ -442,8 +784,26 @@ var _ThingsService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Identify",
 			Handler:    _ThingsService_Identify_Handler,
 		},
+		{
+			MethodName: "CanReadShared",
+			Handler:    _ThingsService_CanReadShared_Handler,
+		},
+		{
+			MethodName: "CanReadMessages",
+			Handler:    _ThingsService_CanReadMessages_Handler,
+		},
+		{
+			MethodName: "IdentifyBatch",
+			Handler:    _ThingsService_IdentifyBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListThingsByChannel",
+			Handler:       _ThingsService_ListThingsByChannel_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal.proto",
 }
 
@@ -565,6 +925,26 @@ func (m *ThingID) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintInternal(dAtA, i, uint64(len(m.Value)))
 		i += copy(dAtA[i:], m.Value)
 	}
+	if m.CreatedAt != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintInternal(dAtA, i, uint64(m.CreatedAt))
+	}
+	if m.UpdatedAt != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintInternal(dAtA, i, uint64(m.UpdatedAt))
+	}
+	if m.PublisherScoped {
+		dAtA[i] = 0x20
+		i++
+		if m.PublisherScoped {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -658,45 +1038,167 @@ func (m *UserID) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func encodeVarintInternal(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Thing) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+	return dAtA[:n], nil
 }
-func (m *AccessReq) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *Thing) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Token)
-	if l > 0 {
-		n += 1 + l + sovInternal(uint64(l))
+	if len(m.Id) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintInternal(dAtA, i, uint64(len(m.Id)))
+		i += copy(dAtA[i:], m.Id)
 	}
-	l = len(m.ChanID)
-	if l > 0 {
-		n += 1 + l + sovInternal(uint64(l))
+	if len(m.Name) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintInternal(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if len(m.Metadata) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintInternal(dAtA, i, uint64(len(m.Metadata)))
+		i += copy(dAtA[i:], m.Metadata)
 	}
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return i, nil
 }
 
-func (m *ThingID) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *Tokens) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	var l int
+	return dAtA[:n], nil
+}
+
+func (m *Tokens) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, s := range m.Values {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func (m *ThingIDs) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ThingIDs) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for k, _ := range m.Values {
+			dAtA[i] = 0xa
+			i++
+			v := m.Values[k]
+			mapSize := 1 + len(k) + sovInternal(uint64(len(k))) + 1 + len(v) + sovInternal(uint64(len(v)))
+			i = encodeVarintInternal(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintInternal(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintInternal(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
+func encodeVarintInternal(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *AccessReq) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovInternal(uint64(l))
+	}
+	l = len(m.ChanID)
+	if l > 0 {
+		n += 1 + l + sovInternal(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ThingID) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
 	_ = l
 	l = len(m.Value)
 	if l > 0 {
 		n += 1 + l + sovInternal(uint64(l))
 	}
+	if m.CreatedAt != 0 {
+		n += 1 + sovInternal(uint64(m.CreatedAt))
+	}
+	if m.UpdatedAt != 0 {
+		n += 1 + sovInternal(uint64(m.UpdatedAt))
+	}
+	if m.PublisherScoped {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -755,6 +1257,68 @@ func (m *UserID) Size() (n int) {
 	return n
 }
 
+func (m *Thing) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovInternal(uint64(l))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovInternal(uint64(l))
+	}
+	l = len(m.Metadata)
+	if l > 0 {
+		n += 1 + l + sovInternal(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Tokens) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, s := range m.Values {
+			l = len(s)
+			n += 1 + l + sovInternal(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ThingIDs) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for k, v := range m.Values {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovInternal(uint64(len(k))) + 1 + len(v) + sovInternal(uint64(len(v)))
+			n += mapEntrySize + 1 + sovInternal(uint64(mapEntrySize))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovInternal(x uint64) (n int) {
 	for {
 		n++
@@ -947,6 +1511,64 @@ func (m *ThingID) Unmarshal(dAtA []byte) error {
 			}
 			m.Value = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAt", wireType)
+			}
+			m.CreatedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpdatedAt", wireType)
+			}
+			m.UpdatedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UpdatedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PublisherScoped", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PublisherScoped = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipInternal(dAtA[iNdEx:])
@@ -1262,6 +1884,427 @@ func (m *UserID) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *Thing) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Thing: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Thing: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Id = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Tokens) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Tokens: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Tokens: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ThingIDs) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ThingIDs: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ThingIDs: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Values == nil {
+				m.Values = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowInternal
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowInternal
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthInternal
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthInternal
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowInternal
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthInternal
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthInternal
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipInternal(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthInternal
+					}
+					if (iNdEx + skippy) < 0 {
+						return ErrInvalidLengthInternal
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Values[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipInternal(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
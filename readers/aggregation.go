@@ -0,0 +1,334 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Supported aggregate functions.
+const (
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateSum   = "sum"
+	AggregateCount = "count"
+
+	// AggregateCountDistinct counts the distinct values of the text field
+	// the aggregation can be grouped by, e.g. the number of publishers.
+	AggregateCountDistinct = "count_distinct"
+)
+
+// Aggregated message fields.
+const (
+	FieldValue    = "value"
+	FieldValueSum = "value_sum"
+)
+
+// Intervals of the aggregation time buckets. Buckets are aligned to the UTC
+// calendar, e.g. weeks start on Monday.
+const (
+	IntervalMinute = "minute"
+	IntervalHour   = "hour"
+	IntervalDay    = "day"
+	IntervalWeek   = "week"
+	IntervalMonth  = "month"
+)
+
+// Orders of the groups of the paged aggregation. Groups ordered by the
+// aggregated value are additionally ordered by key, so that the pages are
+// stable.
+const (
+	OrderKey       = "key"
+	OrderValueAsc  = "value_asc"
+	OrderValueDesc = "value_desc"
+)
+
+// MaxAggregationGroups caps the output cardinality of the bucketed or grouped
+// aggregation, i.e. the number of the buckets times the number of the groups
+// per bucket.
+const MaxAggregationGroups = 1000
+
+// groupFields contains the message fields the aggregation can be grouped by,
+// which are also the fields whose distinct values can be counted.
+var groupFields = NewIdentifiers("subtopic", "publisher", "protocol", "name")
+
+// NullPolicy determines how the messages without the aggregated field value
+// (e.g. messages carrying string values in the mixed-type channel) are
+// handled during aggregation.
+type NullPolicy string
+
+const (
+	// SkipNulls excludes messages without the field value from aggregation.
+	SkipNulls NullPolicy = "skip"
+
+	// RejectNulls fails aggregation if any of the matched messages is
+	// missing the field value.
+	RejectNulls NullPolicy = "error"
+)
+
+var (
+	// ErrNullValue indicates that the aggregated field is missing in some of
+	// the messages while the nulls are rejected.
+	ErrNullValue = errors.New("aggregated field contains null values")
+
+	// ErrInvalidAggregation indicates unsupported aggregate function, field
+	// or null policy.
+	ErrInvalidAggregation = errors.New("invalid aggregation")
+
+	// ErrTooManyGroups indicates that the bucketed or grouped aggregation
+	// yields more than MaxAggregationGroups values.
+	ErrTooManyGroups = errors.New("aggregation exceeds group limit")
+
+	// ErrUnsupportedGrouping indicates that the message repository doesn't
+	// support bucketing or grouping of the aggregation.
+	ErrUnsupportedGrouping = errors.New("aggregation bucketing and grouping are not supported")
+
+	// ErrUnsupportedDistinct indicates that the message repository doesn't
+	// support counting of the distinct values.
+	ErrUnsupportedDistinct = errors.New("count-distinct aggregation is not supported")
+)
+
+// Aggregation specifies the aggregate function applied to the message field.
+// The aggregation is additionally applied per time bucket of the non-empty
+// interval and per value of the non-empty group-by field. Groups of the
+// aggregation grouped, but not bucketed, are paged if the limit is non-zero,
+// in which case the cardinality cap applies to the page only.
+type Aggregation struct {
+	Function   string
+	Field      string
+	NullPolicy NullPolicy
+	Interval   string
+	GroupBy    string
+	Order      string
+	Offset     uint64
+	Limit      uint64
+}
+
+// Validate returns ErrInvalidAggregation if the aggregation is not supported.
+// Distinct values are counted only of the fields the aggregation can be
+// grouped by, while the other functions apply to the numeric fields only.
+func (agg Aggregation) Validate() error {
+	switch agg.Function {
+	case AggregateAvg, AggregateMin, AggregateMax, AggregateSum, AggregateCount:
+		switch agg.Field {
+		case FieldValue, FieldValueSum:
+		default:
+			return ErrInvalidAggregation
+		}
+	case AggregateCountDistinct:
+		if groupFields.Validate(agg.Field) != nil {
+			return ErrInvalidAggregation
+		}
+	default:
+		return ErrInvalidAggregation
+	}
+
+	switch agg.NullPolicy {
+	case SkipNulls, RejectNulls:
+	default:
+		return ErrInvalidAggregation
+	}
+
+	switch agg.Interval {
+	case "", IntervalMinute, IntervalHour, IntervalDay, IntervalWeek, IntervalMonth:
+	default:
+		return ErrInvalidAggregation
+	}
+
+	if agg.GroupBy != "" && groupFields.Validate(agg.GroupBy) != nil {
+		return ErrInvalidAggregation
+	}
+
+	switch agg.Order {
+	case "", OrderKey, OrderValueAsc, OrderValueDesc:
+	default:
+		return ErrInvalidAggregation
+	}
+
+	if !agg.Paged() {
+		if agg.Order != "" || agg.Offset > 0 {
+			return ErrInvalidAggregation
+		}
+		return nil
+	}
+
+	if agg.GroupBy == "" || agg.Interval != "" || agg.Limit > MaxAggregationGroups {
+		return ErrInvalidAggregation
+	}
+
+	return nil
+}
+
+// Grouped returns true if the aggregation is bucketed or grouped.
+func (agg Aggregation) Grouped() bool {
+	return agg.Interval != "" || agg.GroupBy != ""
+}
+
+// Paged returns true if the groups of the aggregation are paged.
+func (agg Aggregation) Paged() bool {
+	return agg.Limit > 0
+}
+
+// Page orders the rows of the paged aggregation and returns the requested
+// page of them along with the total number of the rows. It is meant for the
+// repositories that can't page the groups themselves.
+func (agg Aggregation) Page(rows []AggregationRow) ([]AggregationRow, uint64) {
+	total := uint64(len(rows))
+	if !agg.Paged() {
+		return rows, total
+	}
+
+	sorted := append([]AggregationRow{}, rows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case agg.Order == OrderValueAsc && a.Value != b.Value:
+			return a.Value < b.Value
+		case agg.Order == OrderValueDesc && a.Value != b.Value:
+			return a.Value > b.Value
+		}
+		return a.Key < b.Key
+	})
+
+	if agg.Offset >= total {
+		return []AggregationRow{}, total
+	}
+
+	end := agg.Offset + agg.Limit
+	if end > total {
+		end = total
+	}
+
+	return sorted[agg.Offset:end], total
+}
+
+// Result creates aggregation result out of the aggregated value, number of
+// the aggregated samples and total number of matched messages. Messages that
+// are not aggregated are the ones with the null field value.
+func (agg Aggregation) Result(value float64, samples, total uint64) (AggregationResult, error) {
+	if agg.NullPolicy == RejectNulls && samples < total {
+		return AggregationResult{}, ErrNullValue
+	}
+
+	if samples == 0 {
+		value = 0
+	}
+
+	return AggregationResult{
+		Value:   value,
+		Samples: samples,
+	}, nil
+}
+
+// AggregationRow is the aggregated value of the messages of a single bucket
+// and group, as computed by the repository. Start is zero unless the
+// aggregation is bucketed, while Key is empty unless it is grouped.
+type AggregationRow struct {
+	Start   float64
+	Key     string
+	Value   float64
+	Samples uint64
+	Total   uint64
+}
+
+// Nest arranges the rows, ordered by the bucket start and the group key, into
+// the buckets of the result, or into its groups if the aggregation isn't
+// bucketed. Null policy is applied to every row.
+func (agg Aggregation) Nest(res AggregationResult, rows []AggregationRow) (AggregationResult, error) {
+	if len(rows) > MaxAggregationGroups {
+		return AggregationResult{}, ErrTooManyGroups
+	}
+
+	for _, row := range rows {
+		r, err := agg.Result(row.Value, row.Samples, row.Total)
+		if err != nil {
+			return AggregationResult{}, err
+		}
+
+		if agg.Interval == "" {
+			res.Groups = append(res.Groups, AggregationGroup{Key: row.Key, Value: r.Value, Samples: r.Samples})
+			continue
+		}
+
+		n := len(res.Buckets)
+		if n == 0 || res.Buckets[n-1].Start != row.Start {
+			res.Buckets = append(res.Buckets, AggregationBucket{Start: row.Start})
+			n++
+		}
+
+		b := &res.Buckets[n-1]
+		if agg.GroupBy == "" {
+			b.Value = r.Value
+			b.Samples = r.Samples
+			continue
+		}
+		b.Groups = append(b.Groups, AggregationGroup{Key: row.Key, Value: r.Value, Samples: r.Samples})
+	}
+
+	return res, nil
+}
+
+// BucketStart returns the start of the interval bucket the time, given in
+// seconds since the Unix epoch, falls into.
+func BucketStart(t float64, interval string) float64 {
+	ts := time.Unix(0, int64(t*float64(time.Second))).UTC()
+
+	var start time.Time
+	switch interval {
+	case IntervalMinute:
+		start = ts.Truncate(time.Minute)
+	case IntervalHour:
+		start = ts.Truncate(time.Hour)
+	case IntervalDay:
+		start = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	case IntervalWeek:
+		day := time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		start = day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		start = time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return 0
+	}
+
+	return float64(start.Unix())
+}
+
+// AggregationResult contains the aggregated value and the number of messages
+// it is computed from. Bucketed aggregation additionally contains the value
+// of every non-empty time bucket, while the aggregation grouped, but not
+// bucketed, contains the value of every group, or of the requested page of
+// them along with the total number of the groups if the groups are paged.
+type AggregationResult struct {
+	Value       float64
+	Samples     uint64
+	Buckets     []AggregationBucket
+	Groups      []AggregationGroup
+	TotalGroups uint64
+}
+
+// AggregationBucket contains the aggregated value of the messages published
+// within the bucket, or the values of the groups if the aggregation is
+// grouped as well.
+type AggregationBucket struct {
+	Start   float64
+	Value   float64
+	Samples uint64
+	Groups  []AggregationGroup
+}
+
+// AggregationGroup contains the aggregated value of the messages whose
+// group-by field equals the key.
+type AggregationGroup struct {
+	Key     string
+	Value   float64
+	Samples uint64
+}
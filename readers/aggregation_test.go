@@ -0,0 +1,338 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketStart(t *testing.T) {
+	// Wednesday, 2019-05-15 13:47:21.5 UTC.
+	ts := float64(time.Date(2019, time.May, 15, 13, 47, 21, 0, time.UTC).Unix()) + 0.5
+
+	cases := []struct {
+		desc     string
+		interval string
+		start    time.Time
+	}{
+		{
+			desc:     "minute bucket",
+			interval: readers.IntervalMinute,
+			start:    time.Date(2019, time.May, 15, 13, 47, 0, 0, time.UTC),
+		},
+		{
+			desc:     "hour bucket",
+			interval: readers.IntervalHour,
+			start:    time.Date(2019, time.May, 15, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "day bucket",
+			interval: readers.IntervalDay,
+			start:    time.Date(2019, time.May, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "week bucket starting on Monday",
+			interval: readers.IntervalWeek,
+			start:    time.Date(2019, time.May, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "month bucket",
+			interval: readers.IntervalMonth,
+			start:    time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range cases {
+		start := readers.BucketStart(ts, tc.interval)
+		expected := float64(tc.start.Unix())
+		assert.Equal(t, expected, start, fmt.Sprintf("%s: expected %f got %f", tc.desc, expected, start))
+	}
+}
+
+func TestAggregationValidate(t *testing.T) {
+	valid := readers.Aggregation{
+		Function:   readers.AggregateAvg,
+		Field:      readers.FieldValue,
+		NullPolicy: readers.SkipNulls,
+	}
+
+	cases := []struct {
+		desc     string
+		function string
+		field    string
+		interval string
+		groupBy  string
+		order    string
+		offset   uint64
+		limit    uint64
+		err      error
+	}{
+		{
+			desc: "validate plain aggregation",
+			err:  nil,
+		},
+		{
+			desc:     "validate aggregation with invalid function",
+			function: "median",
+			err:      readers.ErrInvalidAggregation,
+		},
+		{
+			desc:  "validate aggregation of text field",
+			field: "publisher",
+			err:   readers.ErrInvalidAggregation,
+		},
+		{
+			desc:     "validate count-distinct aggregation",
+			function: readers.AggregateCountDistinct,
+			field:    "publisher",
+			err:      nil,
+		},
+		{
+			desc:     "validate grouped count-distinct aggregation",
+			function: readers.AggregateCountDistinct,
+			field:    "publisher",
+			interval: readers.IntervalDay,
+			groupBy:  "subtopic",
+			err:      nil,
+		},
+		{
+			desc:     "validate count-distinct aggregation of numeric field",
+			function: readers.AggregateCountDistinct,
+			field:    readers.FieldValue,
+			err:      readers.ErrInvalidAggregation,
+		},
+		{
+			desc:     "validate count-distinct aggregation of unknown field",
+			function: readers.AggregateCountDistinct,
+			field:    "publisher; DROP TABLE messages",
+			err:      readers.ErrInvalidAggregation,
+		},
+		{
+			desc:     "validate bucketed and grouped aggregation",
+			interval: readers.IntervalHour,
+			groupBy:  "publisher",
+			err:      nil,
+		},
+		{
+			desc:     "validate aggregation with invalid interval",
+			interval: "fortnight",
+			err:      readers.ErrInvalidAggregation,
+		},
+		{
+			desc:    "validate aggregation with invalid group-by field",
+			groupBy: "value",
+			err:     readers.ErrInvalidAggregation,
+		},
+		{
+			desc:    "validate paged grouped aggregation",
+			groupBy: "publisher",
+			order:   readers.OrderValueDesc,
+			offset:  10,
+			limit:   10,
+			err:     nil,
+		},
+		{
+			desc:    "validate paged aggregation with invalid order",
+			groupBy: "publisher",
+			order:   "value",
+			limit:   10,
+			err:     readers.ErrInvalidAggregation,
+		},
+		{
+			desc:    "validate ordered aggregation without limit",
+			groupBy: "publisher",
+			order:   readers.OrderKey,
+			err:     readers.ErrInvalidAggregation,
+		},
+		{
+			desc:    "validate paged aggregation exceeding group limit",
+			groupBy: "publisher",
+			limit:   readers.MaxAggregationGroups + 1,
+			err:     readers.ErrInvalidAggregation,
+		},
+		{
+			desc:  "validate paged ungrouped aggregation",
+			limit: 10,
+			err:   readers.ErrInvalidAggregation,
+		},
+		{
+			desc:     "validate paged bucketed aggregation",
+			interval: readers.IntervalHour,
+			groupBy:  "publisher",
+			limit:    10,
+			err:      readers.ErrInvalidAggregation,
+		},
+	}
+
+	for _, tc := range cases {
+		agg := valid
+		if tc.function != "" {
+			agg.Function = tc.function
+		}
+		if tc.field != "" {
+			agg.Field = tc.field
+		}
+		agg.Interval = tc.interval
+		agg.GroupBy = tc.groupBy
+		agg.Order = tc.order
+		agg.Offset = tc.offset
+		agg.Limit = tc.limit
+		err := agg.Validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestAggregationNest(t *testing.T) {
+	overall := readers.AggregationResult{Value: 3, Samples: 4}
+	rows := []readers.AggregationRow{
+		{Start: 3600, Key: "a", Value: 1, Samples: 1, Total: 1},
+		{Start: 3600, Key: "b", Value: 2, Samples: 1, Total: 2},
+		{Start: 7200, Key: "a", Value: 5, Samples: 2, Total: 2},
+	}
+
+	cases := []struct {
+		desc string
+		agg  readers.Aggregation
+		rows []readers.AggregationRow
+		res  readers.AggregationResult
+		err  error
+	}{
+		{
+			desc: "nest bucketed and grouped rows",
+			agg:  readers.Aggregation{NullPolicy: readers.SkipNulls, Interval: readers.IntervalHour, GroupBy: "publisher"},
+			rows: rows,
+			res: readers.AggregationResult{
+				Value:   3,
+				Samples: 4,
+				Buckets: []readers.AggregationBucket{
+					{Start: 3600, Groups: []readers.AggregationGroup{{Key: "a", Value: 1, Samples: 1}, {Key: "b", Value: 2, Samples: 1}}},
+					{Start: 7200, Groups: []readers.AggregationGroup{{Key: "a", Value: 5, Samples: 2}}},
+				},
+			},
+		},
+		{
+			desc: "nest bucketed rows",
+			agg:  readers.Aggregation{NullPolicy: readers.SkipNulls, Interval: readers.IntervalHour},
+			rows: []readers.AggregationRow{rows[0], rows[2]},
+			res: readers.AggregationResult{
+				Value:   3,
+				Samples: 4,
+				Buckets: []readers.AggregationBucket{
+					{Start: 3600, Value: 1, Samples: 1},
+					{Start: 7200, Value: 5, Samples: 2},
+				},
+			},
+		},
+		{
+			desc: "nest grouped rows",
+			agg:  readers.Aggregation{NullPolicy: readers.SkipNulls, GroupBy: "publisher"},
+			rows: []readers.AggregationRow{{Key: "a", Value: 3, Samples: 3, Total: 3}, {Key: "b", Value: 2, Samples: 1, Total: 2}},
+			res: readers.AggregationResult{
+				Value:   3,
+				Samples: 4,
+				Groups:  []readers.AggregationGroup{{Key: "a", Value: 3, Samples: 3}, {Key: "b", Value: 2, Samples: 1}},
+			},
+		},
+		{
+			desc: "nest rows rejecting nulls",
+			agg:  readers.Aggregation{NullPolicy: readers.RejectNulls, Interval: readers.IntervalHour, GroupBy: "publisher"},
+			rows: rows,
+			err:  readers.ErrNullValue,
+		},
+		{
+			desc: "nest rows exceeding group limit",
+			agg:  readers.Aggregation{NullPolicy: readers.SkipNulls, GroupBy: "publisher"},
+			rows: make([]readers.AggregationRow, readers.MaxAggregationGroups+1),
+			err:  readers.ErrTooManyGroups,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := tc.agg.Nest(overall, tc.rows)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.res, res))
+	}
+}
+
+func TestAggregationPage(t *testing.T) {
+	rows := []readers.AggregationRow{
+		{Key: "a", Value: 3},
+		{Key: "b", Value: 1},
+		{Key: "c", Value: 7},
+		{Key: "d", Value: 3},
+		{Key: "e", Value: 5},
+	}
+
+	cases := []struct {
+		desc   string
+		order  string
+		offset uint64
+		limit  uint64
+		keys   []string
+	}{
+		{
+			desc:  "page rows ordered by key by default",
+			limit: 2,
+			keys:  []string{"a", "b"},
+		},
+		{
+			desc:  "page top rows by value",
+			order: readers.OrderValueDesc,
+			limit: 3,
+			keys:  []string{"c", "e", "a"},
+		},
+		{
+			desc:   "page rows by value breaking ties by key",
+			order:  readers.OrderValueDesc,
+			offset: 2,
+			limit:  2,
+			keys:   []string{"a", "d"},
+		},
+		{
+			desc:  "page bottom rows by value",
+			order: readers.OrderValueAsc,
+			limit: 2,
+			keys:  []string{"b", "a"},
+		},
+		{
+			desc:   "page rows past the end",
+			order:  readers.OrderKey,
+			offset: 5,
+			limit:  2,
+			keys:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		agg := readers.Aggregation{GroupBy: "publisher", Order: tc.order, Offset: tc.offset, Limit: tc.limit}
+		page, total := agg.Page(rows)
+		keys := []string{}
+		for _, row := range page {
+			keys = append(keys, row.Key)
+		}
+		assert.Equal(t, uint64(len(rows)), total, fmt.Sprintf("%s: expected total %d got %d", tc.desc, len(rows), total))
+		assert.Equal(t, tc.keys, keys, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.keys, keys))
+	}
+
+	// Pages of the same order cover all of the rows exactly once.
+	agg := readers.Aggregation{GroupBy: "publisher", Order: readers.OrderValueDesc, Limit: 2}
+	seen := map[string]bool{}
+	for agg.Offset = 0; agg.Offset < uint64(len(rows)); agg.Offset += agg.Limit {
+		page, _ := agg.Page(rows)
+		for _, row := range page {
+			assert.False(t, seen[row.Key], fmt.Sprintf("expected %s to be paged once", row.Key))
+			seen[row.Key] = true
+		}
+	}
+	assert.Len(t, seen, len(rows), fmt.Sprintf("expected %d rows paged got %d", len(rows), len(seen)))
+}
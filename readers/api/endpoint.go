@@ -9,12 +9,17 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/readers"
 )
 
-func listMessagesEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
+func listMessagesEndpoint(svc readers.MessageRepository, warnOffset uint64) endpoint.Endpoint {
 	return func(_ context.Context, request interface{}) (interface{}, error) {
 		req := request.(listMessagesReq)
 
@@ -22,16 +27,292 @@ func listMessagesEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
 			return nil, err
 		}
 
-		page, err := svc.ReadAll(req.chanID, req.offset, req.limit, req.query)
+		if req.explain {
+			explanations, err := svc.Explain(req.chanID, req.offset, req.limit, req.query)
+			if err != nil {
+				return nil, err
+			}
+
+			return newExplainRes(explanations), nil
+		}
+
+		var page readers.MessagesPage
+		var partial bool
+		var err error
+		if req.timeout > 0 {
+			page, partial, err = readTimed(svc, req)
+		} else {
+			page, err = svc.ReadAll(req.chanID, req.offset, req.limit, req.query)
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		return pageRes{
+		if partial && !req.partial {
+			return nil, errTimeout
+		}
+
+		messages := messageList{
+			messages: page.Messages,
+			cursors:  page.Cursors,
+			rename:   req.rename,
+			filename: req.filename,
+			warning:  offsetWarning(req.offset, warnOffset),
+			quote:    req.quote,
+			flat:     req.valueFormat == flatValues,
+		}
+
+		if !req.envelope {
+			return messagesRes{messages}, nil
+		}
+
+		// Page is echoed as requested, so that the empty channel, or the
+		// page past its end, is told apart by the zero total.
+		res := pageRes{
 			Total:    page.Total,
-			Offset:   page.Offset,
-			Limit:    page.Limit,
-			Messages: page.Messages,
+			Offset:   req.offset,
+			Limit:    req.limit,
+			Messages: messages,
+			Partial:  partial,
+		}
+		if partial {
+			res.Next = page.Cursors[len(page.Cursors)-1].String()
+		}
+
+		return res, nil
+	}
+}
+
+// offsetWarning returns the value of the Warning header deprecating the offset
+// pagination, if the offset exceeds the warning threshold.
+func offsetWarning(offset, warnOffset uint64) string {
+	if warnOffset == 0 || offset <= warnOffset {
+		return ""
+	}
+
+	return fmt.Sprintf(`299 - "offset pagination deprecated for offset>%d, use cursor"`, warnOffset)
+}
+
+// readTimed reads the page in the batches following the cursor, until either
+// the page is full or the timeout elapses, in which case the messages read so
+// far are returned as the partial page. Batch in progress is never cut short,
+// so the timeout may be exceeded by the time of reading the single batch.
+// Total is the number of the messages following the initial cursor.
+func readTimed(svc readers.MessageRepository, req listMessagesReq) (readers.MessagesPage, bool, error) {
+	deadline := time.Now().Add(req.timeout)
+
+	query := map[string]string{}
+	for k, v := range req.query {
+		query[k] = v
+	}
+
+	after := query[readers.AfterKey]
+	if after == "" {
+		after = "0"
+	}
+
+	page := readers.MessagesPage{
+		Limit:    req.limit,
+		Messages: []mainflux.Message{},
+		Cursors:  []readers.Cursor{},
+	}
+	for batch := 0; uint64(len(page.Messages)) < req.limit; batch++ {
+		limit := req.limit - uint64(len(page.Messages))
+		if limit > timedBatchSize {
+			limit = timedBatchSize
+		}
+
+		query[readers.AfterKey] = after
+		bp, err := svc.ReadAll(req.chanID, 0, limit, query)
+		if err != nil {
+			return readers.MessagesPage{}, false, err
+		}
+
+		if len(bp.Cursors) != len(bp.Messages) {
+			return readers.MessagesPage{}, false, readers.ErrUnsupportedCursor
+		}
+
+		if batch == 0 {
+			page.Total = bp.Total
+		}
+		page.Messages = append(page.Messages, bp.Messages...)
+		page.Cursors = append(page.Cursors, bp.Cursors...)
+
+		if uint64(len(bp.Messages)) < limit {
+			break
+		}
+		after = bp.Cursors[len(bp.Cursors)-1].String()
+
+		if uint64(len(page.Messages)) < req.limit && time.Now().After(deadline) {
+			return page, true, nil
+		}
+	}
+
+	return page, false, nil
+}
+
+func boundsEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(boundsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		min, max, err := svc.Bounds(req.chanID, req.query)
+		if err != nil {
+			return nil, err
+		}
+
+		return boundsRes{
+			Min: min,
+			Max: max,
 		}, nil
 	}
 }
+
+func aggregateEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(aggregateReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		res, err := svc.Aggregate(req.chanID, req.aggregation, req.query)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.format == grafanaFormat {
+			return grafanaSeriesRes(newGrafanaSeries(grafanaTarget(req.aggregation), req.aggregation, res)), nil
+		}
+
+		return newAggregateRes(req.aggregation, res), nil
+	}
+}
+
+// grafanaTestEndpoint confirms the connection of the Grafana datasource,
+// which is authorized by the decoder.
+func grafanaTestEndpoint() endpoint.Endpoint {
+	return func(_ context.Context, _ interface{}) (interface{}, error) {
+		return grafanaTestRes{}, nil
+	}
+}
+
+// grafanaSearchEndpoint lists the targets containing the searched one, i.e.
+// every supported function and field, optionally grouped.
+func grafanaSearchEndpoint() endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(grafanaSearchReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		res := grafanaSearchRes{}
+		for _, fn := range grafanaFunctions {
+			for _, field := range grafanaFields {
+				for _, groupBy := range grafanaGroups {
+					target := grafanaTarget(readers.Aggregation{Function: fn, Field: field, GroupBy: groupBy})
+					if strings.Contains(target, req.target) {
+						res = append(res, target)
+					}
+				}
+			}
+		}
+
+		return res, nil
+	}
+}
+
+// grafanaQueryEndpoint returns the time series of every queried target, in
+// the order of the targets.
+func grafanaQueryEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(grafanaQueryReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		res := grafanaSeriesRes{}
+		for _, target := range req.targets {
+			agg, err := grafanaAggregation(target, req.interval)
+			if err != nil {
+				return nil, err
+			}
+
+			ar, err := svc.Aggregate(req.chanID, agg, req.query)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, newGrafanaSeries(target, agg, ar)...)
+		}
+
+		return res, nil
+	}
+}
+
+func validateEndpoint(svc readers.MessageRepository) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(validateReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		report, err := readers.Scan(svc, req.chanID, req.profile, req.query, req.sample)
+		if err != nil {
+			return nil, err
+		}
+
+		return newValidateRes(report), nil
+	}
+}
+
+// batchEndpoint executes the sub-queries of the batch concurrently, by the
+// endpoints serving the same queries on their own. Every sub-query results in
+// either its response or its error, so the failing sub-query doesn't fail the
+// rest of the batch.
+func batchEndpoint(svc readers.MessageRepository, warnOffset uint64) endpoint.Endpoint {
+	list := listMessagesEndpoint(svc, warnOffset)
+	aggregate := aggregateEndpoint(svc)
+
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		res := batchRes{Results: map[string]batchResultRes{}}
+		for key, q := range req.queries {
+			wg.Add(1)
+			go func(key string, q batchQuery) {
+				defer wg.Done()
+
+				var result interface{}
+				err := q.err
+				if err == nil {
+					switch q.req.(type) {
+					case aggregateReq:
+						result, err = aggregate(ctx, q.req)
+					default:
+						result, err = list(ctx, q.req)
+					}
+				}
+
+				mu.Lock()
+				res.Results[key] = newBatchResultRes(result, err)
+				mu.Unlock()
+			}(key, q)
+		}
+		wg.Wait()
+
+		return res, nil
+	}
+}
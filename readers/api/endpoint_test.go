@@ -8,16 +8,24 @@
 package api_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -26,16 +34,28 @@ const (
 	invalid       = "invalid"
 	numOfMessages = 42
 	chanID        = "1"
+	emptyChanID   = "2"
 	valueFields   = 6
+	msgTime       = 1560000000
+	adminToken    = "admin"
 )
 
+var tagKeys = []string{"site"}
+
 func newService() readers.MessageRepository {
+	return mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: newMessages(),
+	})
+}
+
+func newMessages() []mainflux.Message {
 	messages := []mainflux.Message{}
 	for i := 0; i < numOfMessages; i++ {
 		msg := mainflux.Message{
 			Channel:   chanID,
 			Publisher: "1",
 			Protocol:  "mqtt",
+			Time:      float64(msgTime + i),
 		}
 		// Mix possible values as well as value sum.
 		count := i % valueFields
@@ -56,13 +76,11 @@ func newService() readers.MessageRepository {
 		messages = append(messages, msg)
 	}
 
-	return mocks.NewMessageRepository(map[string][]mainflux.Message{
-		chanID: messages,
-	})
+	return messages
 }
 
 func newServer(repo readers.MessageRepository, tc mainflux.ThingsServiceClient) *httptest.Server {
-	mux := api.MakeHandler(repo, tc, svcName)
+	mux := api.MakeHandler(repo, tc, false, 0, 0, tagKeys, adminToken, svcName)
 	return httptest.NewServer(mux)
 }
 
@@ -71,16 +89,21 @@ type testRequest struct {
 	method string
 	url    string
 	token  string
+	accept string
+	body   io.Reader
 }
 
 func (tr testRequest) make() (*http.Response, error) {
-	req, err := http.NewRequest(tr.method, tr.url, nil)
+	req, err := http.NewRequest(tr.method, tr.url, tr.body)
 	if err != nil {
 		return nil, err
 	}
 	if tr.token != "" {
 		req.Header.Set("Authorization", tr.token)
 	}
+	if tr.accept != "" {
+		req.Header.Set("Accept", tr.accept)
+	}
 
 	return tr.client.Do(req)
 }
@@ -175,3 +198,2902 @@ func TestReadAll(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
 	}
 }
+
+func TestReadAllEmptyAndUnauthorized(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url      string
+		token    string
+		status   int
+		err      string
+		total    uint64
+		offset   uint64
+		messages int
+	}{
+		"read populated channel": {
+			url:      fmt.Sprintf("%s/channels/%s/messages?limit=10", ts.URL, chanID),
+			token:    token,
+			status:   http.StatusOK,
+			total:    numOfMessages,
+			messages: 10,
+		},
+		"read empty channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?limit=10", ts.URL, emptyChanID),
+			token:  token,
+			status: http.StatusOK,
+		},
+		"read page past end of channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=100&limit=10", ts.URL, chanID),
+			token:  token,
+			status: http.StatusOK,
+			total:  numOfMessages,
+			offset: 100,
+		},
+		"read channel with invalid token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+			token:  invalid,
+			status: http.StatusForbidden,
+			err:    "missing or invalid credentials provided",
+		},
+		"read channel with expired key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+			token:  "expired",
+			status: http.StatusUnauthorized,
+			err:    "thing key expired",
+		},
+		"read channel with unavailable things service": {
+			url:    fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+			token:  "unavailable",
+			status: http.StatusServiceUnavailable,
+			err:    "authorization service unavailable",
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.status != http.StatusOK {
+			var body struct {
+				Err string `json:"error"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&body)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+			assert.Equal(t, tc.err, body.Err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, body.Err))
+			continue
+		}
+
+		var page struct {
+			Total    uint64            `json:"total"`
+			Offset   uint64            `json:"offset"`
+			Limit    uint64            `json:"limit"`
+			Messages []json.RawMessage `json:"messages"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+		assert.Equal(t, tc.offset, page.Offset, fmt.Sprintf("%s: expected offset %d got %d", desc, tc.offset, page.Offset))
+		assert.Equal(t, uint64(10), page.Limit, fmt.Sprintf("%s: expected limit 10 got %d", desc, page.Limit))
+		assert.NotNil(t, page.Messages, fmt.Sprintf("%s: expected messages to be rendered", desc))
+		assert.Len(t, page.Messages, tc.messages, fmt.Sprintf("%s: expected %d messages got %d", desc, tc.messages, len(page.Messages)))
+	}
+}
+
+func TestReadAllProtobuf(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	messages := newMessages()
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d", ts.URL, chanID, numOfMessages),
+		token:  token,
+		accept: "application/vnd.google.protobuf",
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected %d got %d", http.StatusOK, res.StatusCode))
+	assert.Equal(t, "application/vnd.google.protobuf", res.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	received := []mainflux.Message{}
+	for len(body) > 0 {
+		size, n := proto.DecodeVarint(body)
+		require.NotZero(t, n, "expected length prefix")
+		end := n + int(size)
+		require.True(t, end <= len(body), "expected complete message")
+
+		var msg mainflux.Message
+		err := proto.Unmarshal(body[n:end], &msg)
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		received = append(received, msg)
+		body = body[end:]
+	}
+
+	require.Equal(t, len(messages), len(received), fmt.Sprintf("expected %d messages got %d", len(messages), len(received)))
+	for i := range messages {
+		assert.True(t, proto.Equal(&messages[i], &received[i]), fmt.Sprintf("expected %v got %v", messages[i], received[i]))
+	}
+}
+
+func TestReadAllNumberFormat(t *testing.T) {
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: []mainflux.Message{
+			{
+				Channel:   chanID,
+				Publisher: "1",
+				Name:      "big",
+				Value:     &mainflux.Message_FloatValue{FloatValue: 1e21},
+				Time:      1560000000.25,
+			},
+			{
+				Channel:   chanID,
+				Publisher: "1",
+				Name:      "small, precise",
+				Value:     &mainflux.Message_FloatValue{FloatValue: 1e-7},
+				ValueSum:  &mainflux.SumValue{Value: 2.5},
+				Time:      1560000001,
+			},
+			{
+				Channel:   chanID,
+				Publisher: "1",
+				Name:      "state",
+				Value:     &mainflux.Message_BoolValue{BoolValue: true},
+				Time:      1560000002,
+			},
+		},
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	header := "channel,subtopic,publisher,protocol,name,unit,value,stringValue,boolValue,dataValue,valueSum,time,updateTime,link,seq\n"
+
+	cases := map[string]struct {
+		url    string
+		accept string
+		ct     string
+		body   string
+	}{
+		"read messages as JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false", ts.URL, chanID),
+			accept: "application/json",
+			ct:     "application/json",
+			body: `[{"channel":"1","name":"big","publisher":"1","seq":"1560000000.25_00000000","time":1560000000.25,"value":1000000000000000000000},` +
+				`{"channel":"1","name":"small, precise","publisher":"1","seq":"1560000001_00000001","time":1560000001,"value":0.0000001,"valueSum":2.5},` +
+				`{"boolValue":true,"channel":"1","name":"state","publisher":"1","seq":"1560000002_00000002","time":1560000002}]` + "\n",
+		},
+		"read messages as CSV": {
+			url:    fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+			accept: "text/csv",
+			ct:     "text/csv",
+			body: header +
+				"1,,1,,big,,1000000000000000000000,,,,,1560000000.25,0,,1560000000.25_00000000\n" +
+				"1,,1,,\"small, precise\",,0.0000001,,,,2.5,1560000001,0,,1560000001_00000001\n" +
+				"1,,1,,state,,,,true,,,1560000002,0,,1560000002_00000002\n",
+		},
+		"read messages as CSV with quoted numbers": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?quote=true", ts.URL, chanID),
+			accept: "text/csv",
+			ct:     "text/csv",
+			body: header +
+				"1,,1,,big,,\"1000000000000000000000\",,,,,\"1560000000.25\",\"0\",,1560000000.25_00000000\n" +
+				"1,,1,,\"small, precise\",,\"0.0000001\",,,,\"2.5\",\"1560000001\",\"0\",,1560000001_00000001\n" +
+				"1,,1,,state,,,,true,,,\"1560000002\",\"0\",,1560000002_00000002\n",
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+			accept: tc.accept,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, http.StatusOK, res.StatusCode))
+		assert.Equal(t, tc.ct, res.Header.Get("Content-Type"), fmt.Sprintf("%s: expected content type %s got %s", desc, tc.ct, res.Header.Get("Content-Type")))
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.body, string(body), fmt.Sprintf("%s: got unexpected body", desc))
+	}
+}
+
+func TestReadAllFlatValues(t *testing.T) {
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: []mainflux.Message{
+			{
+				Channel: chanID,
+				Name:    "temperature",
+				Value:   &mainflux.Message_FloatValue{FloatValue: 1e-7},
+				Time:    1560000000,
+			},
+			{
+				Channel: chanID,
+				Name:    "status",
+				Value:   &mainflux.Message_StringValue{StringValue: "on"},
+				Time:    1560000001,
+			},
+			{
+				Channel: chanID,
+				Name:    "state",
+				Value:   &mainflux.Message_BoolValue{BoolValue: false},
+				Time:    1560000002,
+			},
+			{
+				Channel: chanID,
+				Name:    "image",
+				Value:   &mainflux.Message_DataValue{DataValue: "base64data"},
+				Time:    1560000003,
+			},
+			{
+				Channel:  chanID,
+				Name:     "energy",
+				ValueSum: &mainflux.SumValue{Value: 45},
+				Time:     1560000004,
+			},
+			{
+				Channel:  chanID,
+				Name:     "power",
+				Value:    &mainflux.Message_FloatValue{FloatValue: 2.5},
+				ValueSum: &mainflux.SumValue{Value: 47.5},
+				Time:     1560000005,
+			},
+		},
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url    string
+		status int
+		body   string
+	}{
+		"read float value as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"temperature","seq":"1560000000_00000000","time":1560000000,"value":0.0000001,"valueType":"float"}]` + "\n",
+		},
+		"read string value as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=1&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"status","seq":"1560000001_00000001","time":1560000001,"value":"on","valueType":"string"}]` + "\n",
+		},
+		"read bool value as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=2&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"state","seq":"1560000002_00000002","time":1560000002,"value":false,"valueType":"bool"}]` + "\n",
+		},
+		"read data value as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=3&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"image","seq":"1560000003_00000003","time":1560000003,"value":"base64data","valueType":"data"}]` + "\n",
+		},
+		"read value sum without value as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=4&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"energy","seq":"1560000004_00000004","time":1560000004,"valueSum":45}]` + "\n",
+		},
+		"read value along with value sum as flat JSON": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=5&limit=1&valueFormat=flat", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"power","seq":"1560000005_00000005","time":1560000005,"value":2.5,"valueSum":47.5,"valueType":"float"}]` + "\n",
+		},
+		"read renamed flat value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=1&limit=1&valueFormat=flat&rename=value:v,valueType:type", ts.URL, chanID),
+			status: http.StatusOK,
+			body:   `[{"channel":"1","name":"status","seq":"1560000001_00000001","time":1560000001,"type":"string","v":"on"}]` + "\n",
+		},
+		"read raw values": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?envelope=false&offset=1&limit=2&valueFormat=raw", ts.URL, chanID),
+			status: http.StatusOK,
+			body: `[{"channel":"1","name":"status","seq":"1560000001_00000001","stringValue":"on","time":1560000001},` +
+				`{"boolValue":false,"channel":"1","name":"state","seq":"1560000002_00000002","time":1560000002}]` + "\n",
+		},
+		"read with invalid value format": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?valueFormat=nested", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.body, string(body), fmt.Sprintf("%s: got unexpected body", desc))
+	}
+}
+
+func TestReadAllDownload(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url         string
+		accept      string
+		status      int
+		disposition string
+	}{
+		"read page as JSON attachment": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?offset=10&limit=5&download=true", ts.URL, chanID),
+			status:      http.StatusOK,
+			disposition: fmt.Sprintf(`attachment; filename="channel-%s-10-15.json"`, chanID),
+		},
+		"read bare messages as JSON attachment": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?envelope=false&download=true", ts.URL, chanID),
+			status:      http.StatusOK,
+			disposition: fmt.Sprintf(`attachment; filename="channel-%s-0-10.json"`, chanID),
+		},
+		"read page as protobuf attachment": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d&download=true", ts.URL, chanID, numOfMessages),
+			accept:      "application/vnd.google.protobuf",
+			status:      http.StatusOK,
+			disposition: fmt.Sprintf(`attachment; filename="channel-%s-0-%d.pb"`, chanID, numOfMessages),
+		},
+		"read page as CSV attachment": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?download=true", ts.URL, chanID),
+			accept:      "text/csv",
+			status:      http.StatusOK,
+			disposition: fmt.Sprintf(`attachment; filename="channel-%s-0-10.csv"`, chanID),
+		},
+		"read page of channel with unsafe ID as attachment": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?download=true", ts.URL, "a.b;c:d"),
+			status:      http.StatusOK,
+			disposition: `attachment; filename="channel-a_b_c_d-0-10.json"`,
+		},
+		"read page without download": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?download=false", ts.URL, chanID),
+			status:      http.StatusOK,
+			disposition: "",
+		},
+		"read page with invalid download": {
+			url:         fmt.Sprintf("%s/channels/%s/messages?download=yes", ts.URL, chanID),
+			status:      http.StatusBadRequest,
+			disposition: "",
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+			accept: tc.accept,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		disposition := res.Header.Get("Content-Disposition")
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.disposition, disposition, fmt.Sprintf("%s: expected content disposition %s got %s", desc, tc.disposition, disposition))
+	}
+}
+
+func TestBounds(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url    string
+		token  string
+		status int
+		min    float64
+		max    float64
+	}{
+		"read bounds of channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range", ts.URL, chanID),
+			token:  token,
+			status: http.StatusOK,
+			min:    msgTime,
+			max:    msgTime + numOfMessages - 1,
+		},
+		"read bounds of empty channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range", ts.URL, emptyChanID),
+			token:  token,
+			status: http.StatusOK,
+			min:    0,
+			max:    0,
+		},
+		"read bounds with invalid token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range", ts.URL, chanID),
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+		"read bounds with empty token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range", ts.URL, chanID),
+			token:  "",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var bounds struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&bounds)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.min, bounds.Min, fmt.Sprintf("%s: expected min %f got %f", desc, tc.min, bounds.Min))
+		assert.Equal(t, tc.max, bounds.Max, fmt.Sprintf("%s: expected max %f got %f", desc, tc.max, bounds.Max))
+	}
+}
+
+func TestReadAllEnvelope(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	limit := 10
+
+	cases := map[string]struct {
+		url      string
+		status   int
+		envelope bool
+	}{
+		"read page with envelope by default": {
+			url:      fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d", ts.URL, chanID, limit),
+			status:   http.StatusOK,
+			envelope: true,
+		},
+		"read page with envelope": {
+			url:      fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d&envelope=true", ts.URL, chanID, limit),
+			status:   http.StatusOK,
+			envelope: true,
+		},
+		"read page as bare array": {
+			url:      fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d&envelope=false", ts.URL, chanID, limit),
+			status:   http.StatusOK,
+			envelope: false,
+		},
+		"read page with invalid envelope value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=%d&envelope=abc", ts.URL, chanID, limit),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		if tc.envelope {
+			var page struct {
+				Total    uint64                   `json:"total"`
+				Messages []map[string]interface{} `json:"messages"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&page)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+			assert.Equal(t, uint64(numOfMessages), page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, numOfMessages, page.Total))
+			assert.Equal(t, limit, len(page.Messages), fmt.Sprintf("%s: expected %d messages got %d", desc, limit, len(page.Messages)))
+			continue
+		}
+
+		var messages []map[string]interface{}
+		err = json.NewDecoder(res.Body).Decode(&messages)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, limit, len(messages), fmt.Sprintf("%s: expected %d messages got %d", desc, limit, len(messages)))
+	}
+}
+
+func TestReadAllRenamed(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url     string
+		status  int
+		present []string
+		absent  []string
+	}{
+		"read page with renamed fields": {
+			url:     fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=time:ts,value:val", ts.URL, chanID),
+			status:  http.StatusOK,
+			present: []string{"ts", "val", "channel", "publisher"},
+			absent:  []string{"time", "value"},
+		},
+		"read page as bare array with renamed fields": {
+			url:     fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&envelope=false&rename=publisher:pub", ts.URL, chanID),
+			status:  http.StatusOK,
+			present: []string{"pub", "time"},
+			absent:  []string{"publisher"},
+		},
+		"read page with swapped fields": {
+			url:     fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=channel:publisher,publisher:channel", ts.URL, chanID),
+			status:  http.StatusOK,
+			present: []string{"channel", "publisher"},
+		},
+		"read page renaming unknown field": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=temperature:temp", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read page renaming field to empty key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=time:", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read page renaming field to existing key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=time:channel", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read page renaming fields to same key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=time:t,link:t", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read page with malformed rename": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=6&rename=time", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+
+		var page struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		messages := []map[string]interface{}{}
+		if err := json.Unmarshal(body, &page); err == nil {
+			messages = page.Messages
+		} else {
+			err = json.Unmarshal(body, &messages)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		}
+
+		// The first message carries float value, so all the fields are set.
+		require.NotEmpty(t, messages, fmt.Sprintf("%s: expected messages", desc))
+		for _, key := range tc.present {
+			assert.Contains(t, messages[0], key, fmt.Sprintf("%s: expected key %s", desc, key))
+		}
+		for _, key := range tc.absent {
+			assert.NotContains(t, messages[0], key, fmt.Sprintf("%s: unexpected key %s", desc, key))
+		}
+	}
+}
+
+func TestReadAllUnknownParams(t *testing.T) {
+	svc := newService()
+	thingsClient := mocks.NewThingsService()
+
+	cases := map[string]struct {
+		lenient bool
+		url     string
+		status  int
+		err     string
+	}{
+		"read page with known filter": {
+			lenient: false,
+			url:     "/channels/%s/messages?offset=0&limit=10&publisher=1",
+			status:  http.StatusOK,
+		},
+		"read page with unknown parameter": {
+			lenient: false,
+			url:     "/channels/%s/messages?offset=0&limit=10&publsher=1",
+			status:  http.StatusBadRequest,
+			err:     "unknown query parameter: publsher",
+		},
+		"read bounds with unknown parameter": {
+			lenient: false,
+			url:     "/channels/%s/messages/range?limit=10",
+			status:  http.StatusBadRequest,
+			err:     "unknown query parameter: limit",
+		},
+		"aggregate with unknown parameter": {
+			lenient: false,
+			url:     "/channels/%s/messages/aggregate?function=avg&fn=max",
+			status:  http.StatusBadRequest,
+			err:     "unknown query parameter: fn",
+		},
+		"read page with unknown parameter in lenient mode": {
+			lenient: true,
+			url:     "/channels/%s/messages?offset=0&limit=10&publsher=1",
+			status:  http.StatusOK,
+		},
+	}
+
+	for desc, tc := range cases {
+		ts := httptest.NewServer(api.MakeHandler(svc, thingsClient, tc.lenient, 0, 0, tagKeys, adminToken, svcName))
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    ts.URL + fmt.Sprintf(tc.url, chanID),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.err != "" {
+			var body struct {
+				Err string `json:"error"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&body)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+			assert.Equal(t, tc.err, body.Err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, body.Err))
+		}
+		ts.Close()
+	}
+}
+
+func TestReadAllFilter(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	groups := []string{}
+	for i := 0; i <= readers.MaxFilterGroups; i++ {
+		groups = append(groups, fmt.Sprintf(`{"publisher":"%d"}`, i))
+	}
+
+	cases := map[string]struct {
+		filter string
+		status int
+	}{
+		"read page with two filter groups": {
+			filter: `[{"publisher":"1","subtopic":"x"},{"publisher":"2","subtopic":"y"}]`,
+			status: http.StatusOK,
+		},
+		"read page with malformed filter": {
+			filter: `{"publisher":"1"}`,
+			status: http.StatusBadRequest,
+		},
+		"read page with empty filter group": {
+			filter: `[{"publisher":"1"},{}]`,
+			status: http.StatusBadRequest,
+		},
+		"read page with filter on unknown field": {
+			filter: `[{"value":"1"}]`,
+			status: http.StatusBadRequest,
+		},
+		"read page with too many filter groups": {
+			filter: fmt.Sprintf("[%s]", strings.Join(groups, ",")),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?filter=%s", ts.URL, chanID, url.QueryEscape(tc.filter)),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestReadAllTimeRange(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		query  string
+		status int
+		total  uint64
+	}{
+		"read page within time range": {
+			query:  fmt.Sprintf("from=%d&to=%d", msgTime+10, msgTime+20),
+			status: http.StatusOK,
+			total:  10,
+		},
+		"read page with fractional time range": {
+			query:  fmt.Sprintf("from=%d.5&to=%d.5", msgTime+10, msgTime+20),
+			status: http.StatusOK,
+			total:  10,
+		},
+		"read page from start of time range": {
+			query:  fmt.Sprintf("from=%d", msgTime+40),
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read page to end of time range": {
+			query:  fmt.Sprintf("to=%d", msgTime+2),
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read page with malformed start of time range": {
+			query:  "from=invalid",
+			status: http.StatusBadRequest,
+		},
+		"read page with malformed end of time range": {
+			query:  "to=invalid",
+			status: http.StatusBadRequest,
+		},
+		"read page with time range ending before it starts": {
+			query:  fmt.Sprintf("from=%d&to=%d", msgTime+20, msgTime+10),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total uint64 `json:"total"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+	}
+}
+
+func TestReadAllOrder(t *testing.T) {
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: []mainflux.Message{
+			{Channel: chanID, Publisher: "b", Time: 1000},
+			{Channel: chanID, Publisher: "a", Time: 1001},
+			{Channel: chanID, Publisher: "c", Time: 1002},
+		},
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		query  string
+		status int
+		times  []float64
+	}{
+		"read page in descending order": {
+			query:  "order=desc",
+			status: http.StatusOK,
+			times:  []float64{1002, 1001, 1000},
+		},
+		"read page in ascending order": {
+			query:  "order=asc",
+			status: http.StatusOK,
+			times:  []float64{1000, 1001, 1002},
+		},
+		"read page sorted by publisher": {
+			query:  "sort_by=publisher",
+			status: http.StatusOK,
+			times:  []float64{1002, 1000, 1001},
+		},
+		"read page sorted by publisher in ascending order": {
+			query:  "sort_by=publisher&order=asc",
+			status: http.StatusOK,
+			times:  []float64{1001, 1000, 1002},
+		},
+		"read page in invalid order": {
+			query:  "order=random",
+			status: http.StatusBadRequest,
+		},
+		"read page sorted by invalid field": {
+			query:  "sort_by=unit",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Messages []struct {
+				Time float64 `json:"time"`
+			} `json:"messages"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range page.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.Equal(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllValueFilter(t *testing.T) {
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: []mainflux.Message{
+			{Channel: chanID, Value: &mainflux.Message_FloatValue{FloatValue: 25}, Time: 1000},
+			{Channel: chanID, Value: &mainflux.Message_FloatValue{FloatValue: 30}, Time: 1001},
+			{Channel: chanID, Value: &mainflux.Message_FloatValue{FloatValue: 35}, Time: 1002},
+			{Channel: chanID, Value: &mainflux.Message_StringValue{StringValue: "value"}, Time: 1003},
+			{Channel: chanID, Value: &mainflux.Message_BoolValue{BoolValue: true}, Time: 1004},
+			{Channel: chanID, Value: &mainflux.Message_BoolValue{BoolValue: false}, Time: 1005},
+			{Channel: chanID, Value: &mainflux.Message_StringValue{StringValue: "on"}, Time: 1006},
+		},
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		query  string
+		status int
+		times  []float64
+	}{
+		"read page with value equal to": {
+			query:  "v=30",
+			status: http.StatusOK,
+			times:  []float64{1001},
+		},
+		"read page with value greater than": {
+			query:  "vg=30.0",
+			status: http.StatusOK,
+			times:  []float64{1002},
+		},
+		"read page with value less than": {
+			query:  "vl=30",
+			status: http.StatusOK,
+			times:  []float64{1000},
+		},
+		"read page with value within range": {
+			query:  "vg=20&vl=32.5",
+			status: http.StatusOK,
+			times:  []float64{1000, 1001},
+		},
+		"read page with malformed value filter": {
+			query:  "vg=hot",
+			status: http.StatusBadRequest,
+		},
+		"read page with empty value range": {
+			query:  "vg=30&vl=20",
+			status: http.StatusBadRequest,
+		},
+		"read page with bool value": {
+			query:  "vb=true",
+			status: http.StatusOK,
+			times:  []float64{1004},
+		},
+		"read page with false bool value": {
+			query:  "vb=false",
+			status: http.StatusOK,
+			times:  []float64{1005},
+		},
+		"read page with string value": {
+			query:  "vs=on",
+			status: http.StatusOK,
+			times:  []float64{1006},
+		},
+		"read page with malformed bool value": {
+			query:  "vb=maybe",
+			status: http.StatusBadRequest,
+		},
+		"read page with float and bool value": {
+			query:  "v=30&vb=true",
+			status: http.StatusBadRequest,
+		},
+		"read page with value range and string value": {
+			query:  "vg=20&vs=on",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Messages []struct {
+				Time float64 `json:"time"`
+			} `json:"messages"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range page.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.Equal(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllTags(t *testing.T) {
+	messages := newMessages()
+	tags := []map[string]string{}
+	for i := range messages {
+		tags = append(tags, map[string]string{"site": fmt.Sprintf("site-%d", i%2), "floor": "1"})
+	}
+	svc := mocks.NewTaggedMessageRepository(map[string][]mainflux.Message{chanID: messages}, map[string][]map[string]string{chanID: tags})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url    string
+		status int
+		total  uint64
+	}{
+		"read page filtered by allowed tag": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?tag.site=site-1", ts.URL, chanID),
+			status: http.StatusOK,
+			total:  numOfMessages / 2,
+		},
+		"read page filtered by allowed tag without matches": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?tag.site=site-2", ts.URL, chanID),
+			status: http.StatusOK,
+			total:  0,
+		},
+		"read page filtered by tag that is not allowed": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?tag.floor=1", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read page filtered by allowed tag without value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?tag.site=", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+		"read range filtered by tag that is not allowed": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range?tag.floor=1", ts.URL, chanID),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total uint64 `json:"total"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d messages got %d", desc, tc.total, page.Total))
+	}
+}
+
+func TestReadAllLast(t *testing.T) {
+	now := time.Now()
+	messages := []mainflux.Message{}
+	for _, age := range []time.Duration{10 * time.Minute, 45 * time.Minute, 2 * time.Hour} {
+		messages = append(messages, mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Time:      float64(now.Add(-age).Unix()),
+		})
+	}
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		query  string
+		status int
+		total  uint64
+	}{
+		"read page of the last hour": {
+			query:  "last=1h",
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read page of the last 30 minutes": {
+			query:  "last=30m",
+			status: http.StatusOK,
+			total:  1,
+		},
+		"read page with last period and from": {
+			query:  fmt.Sprintf("last=1h&from=%d", now.Add(-time.Hour).Unix()),
+			status: http.StatusBadRequest,
+		},
+		"read page with last period and to": {
+			query:  fmt.Sprintf("last=1h&to=%d", now.Unix()),
+			status: http.StatusBadRequest,
+		},
+		"read page with invalid last period": {
+			query:  "last=hour",
+			status: http.StatusBadRequest,
+		},
+		"read page with negative last period": {
+			query:  "last=-1h",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total uint64 `json:"total"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+	}
+}
+
+func TestReadAllCursor(t *testing.T) {
+	// Every two messages are published at the same time.
+	messages := []mainflux.Message{}
+	for i := 0; i < 10; i++ {
+		messages = append(messages, mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Time:      float64(msgTime + i/2),
+		})
+	}
+	data := map[string][]mainflux.Message{chanID: messages}
+	svc := mocks.NewMessageRepository(data)
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	type cursorPage struct {
+		Messages []struct {
+			Time float64 `json:"time"`
+			Seq  string  `json:"seq"`
+		} `json:"messages"`
+	}
+
+	read := func(query string) (int, cursorPage) {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+		var page cursorPage
+		if res.StatusCode == http.StatusOK {
+			err = json.NewDecoder(res.Body).Decode(&page)
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		}
+
+		return res.StatusCode, page
+	}
+
+	// Resume reading from the last cursor, while the new messages are
+	// published between the reads, some of them at the time of the already
+	// read messages.
+	seqs := []string{}
+	seen := map[string]bool{}
+	after := "0"
+	for reads := 0; ; reads++ {
+		status, page := read(fmt.Sprintf("limit=3&after=%s", after))
+		require.Equal(t, http.StatusOK, status, fmt.Sprintf("expected %d got %d", http.StatusOK, status))
+		if len(page.Messages) == 0 {
+			break
+		}
+
+		for _, msg := range page.Messages {
+			assert.False(t, seen[msg.Seq], fmt.Sprintf("duplicate message %s", msg.Seq))
+			seen[msg.Seq] = true
+			seqs = append(seqs, msg.Seq)
+		}
+		after = page.Messages[len(page.Messages)-1].Seq
+
+		if reads < 2 {
+			last := data[chanID][len(data[chanID])-1]
+			data[chanID] = append(data[chanID], last, mainflux.Message{Channel: chanID, Publisher: "1", Time: last.Time + 1})
+		}
+	}
+
+	assert.Equal(t, len(data[chanID]), len(seqs), fmt.Sprintf("expected %d messages got %d", len(data[chanID]), len(seqs)))
+	for i := 1; i < len(seqs); i++ {
+		prev, err := readers.ParseCursor(seqs[i-1])
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		next, err := readers.ParseCursor(seqs[i])
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		assert.True(t, prev.Before(next), fmt.Sprintf("expected %s before %s", seqs[i-1], seqs[i]))
+	}
+
+	cases := map[string]struct {
+		query  string
+		status int
+	}{
+		"read page with malformed cursor": {
+			query:  "after=now",
+			status: http.StatusBadRequest,
+		},
+		"read page with cursor and offset": {
+			query:  fmt.Sprintf("after=%s&offset=1", seqs[0]),
+			status: http.StatusBadRequest,
+		},
+		"read page renaming field to cursor key": {
+			query:  "rename=time:seq",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		status, _ := read(tc.query)
+		assert.Equal(t, tc.status, status, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, status))
+	}
+}
+
+func TestReadAllByID(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	seq := func(i int) string {
+		return fmt.Sprintf("%d_%08d", msgTime+i, i)
+	}
+
+	tooMany := []string{}
+	for i := 0; i <= 100; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("msgID=%s", seq(0)))
+	}
+
+	cases := map[string]struct {
+		query  string
+		status int
+		seqs   []string
+	}{
+		"read message by ID": {
+			query:  fmt.Sprintf("msgID=%s", seq(3)),
+			status: http.StatusOK,
+			seqs:   []string{seq(3)},
+		},
+		"read messages by repeated IDs": {
+			query:  fmt.Sprintf("msgID=%s&msgID=%s", seq(3), seq(7)),
+			status: http.StatusOK,
+			seqs:   []string{seq(3), seq(7)},
+		},
+		"read messages by comma separated IDs": {
+			query:  fmt.Sprintf("msgID=%s,%s", seq(7), seq(3)),
+			status: http.StatusOK,
+			seqs:   []string{seq(3), seq(7)},
+		},
+		"read messages by existing and missing IDs": {
+			query:  fmt.Sprintf("msgID=%s&msgID=%s&msgID=%d_%08d", seq(3), seq(5), msgTime+5, 6),
+			status: http.StatusOK,
+			seqs:   []string{seq(3), seq(5)},
+		},
+		"read messages by missing IDs": {
+			query:  fmt.Sprintf("msgID=%d_%08d", msgTime, numOfMessages),
+			status: http.StatusOK,
+			seqs:   []string{},
+		},
+		"read messages by IDs of other publisher": {
+			query:  fmt.Sprintf("msgID=%s&publisher=2", seq(3)),
+			status: http.StatusOK,
+			seqs:   []string{},
+		},
+		"read message by ID without tiebreaker": {
+			query:  fmt.Sprintf("msgID=%d", msgTime+3),
+			status: http.StatusBadRequest,
+		},
+		"read message by malformed ID": {
+			query:  "msgID=invalid",
+			status: http.StatusBadRequest,
+		},
+		"read messages by too many IDs": {
+			query:  strings.Join(tooMany, "&"),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total    uint64 `json:"total"`
+			Messages []struct {
+				Seq string `json:"seq"`
+			} `json:"messages"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+
+		seqs := []string{}
+		for _, msg := range page.Messages {
+			seqs = append(seqs, msg.Seq)
+		}
+		assert.ElementsMatch(t, tc.seqs, seqs, fmt.Sprintf("%s: expected messages %v got %v", desc, tc.seqs, seqs))
+		assert.Equal(t, uint64(len(tc.seqs)), page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.seqs), page.Total))
+	}
+}
+
+// slowRepository delays the reads of the wrapped repository.
+type slowRepository struct {
+	readers.MessageRepository
+	delay time.Duration
+}
+
+func (sr slowRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
+	time.Sleep(sr.delay)
+	return sr.MessageRepository.ReadAll(chanID, offset, limit, query)
+}
+
+func TestReadAllTimeout(t *testing.T) {
+	messages := []mainflux.Message{}
+	for i := 0; i < 250; i++ {
+		messages = append(messages, mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Time:      float64(msgTime + i),
+		})
+	}
+	svc := slowRepository{
+		MessageRepository: mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages}),
+		delay:             100 * time.Millisecond,
+	}
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	type timedPage struct {
+		Total    uint64 `json:"total"`
+		Partial  bool   `json:"partial"`
+		Next     string `json:"next"`
+		Messages []struct {
+			Seq string `json:"seq"`
+		} `json:"messages"`
+	}
+
+	read := func(query string) (int, timedPage) {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+		var page timedPage
+		if res.StatusCode == http.StatusOK {
+			err = json.NewDecoder(res.Body).Decode(&page)
+			require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		}
+
+		return res.StatusCode, page
+	}
+
+	// Timeout trips after the second batch of 100 messages.
+	status, page := read("limit=250&timeout=150ms&partial=true")
+	require.Equal(t, http.StatusOK, status, fmt.Sprintf("read partial page: expected %d got %d", http.StatusOK, status))
+	assert.True(t, page.Partial, "read partial page: expected partial page")
+	assert.Equal(t, uint64(250), page.Total, fmt.Sprintf("read partial page: expected total %d got %d", 250, page.Total))
+	require.Len(t, page.Messages, 200, fmt.Sprintf("read partial page: expected %d messages got %d", 200, len(page.Messages)))
+	assert.Equal(t, page.Messages[199].Seq, page.Next, fmt.Sprintf("read partial page: expected next cursor %s got %s", page.Messages[199].Seq, page.Next))
+
+	// Reading resumed by the cursor yields the rest of the messages.
+	status, rest := read(fmt.Sprintf("limit=250&timeout=150ms&partial=true&after=%s", page.Next))
+	require.Equal(t, http.StatusOK, status, fmt.Sprintf("resume partial page: expected %d got %d", http.StatusOK, status))
+	assert.False(t, rest.Partial, "resume partial page: expected complete page")
+	assert.Empty(t, rest.Next, fmt.Sprintf("resume partial page: expected no next cursor got %s", rest.Next))
+	require.Len(t, rest.Messages, 50, fmt.Sprintf("resume partial page: expected %d messages got %d", 50, len(rest.Messages)))
+
+	seen := map[string]bool{}
+	for _, msg := range append(page.Messages, rest.Messages...) {
+		assert.False(t, seen[msg.Seq], fmt.Sprintf("duplicate message %s", msg.Seq))
+		seen[msg.Seq] = true
+	}
+	assert.Len(t, seen, 250, fmt.Sprintf("expected %d messages got %d", 250, len(seen)))
+
+	cases := map[string]struct {
+		query   string
+		status  int
+		partial bool
+		count   int
+	}{
+		"read page within timeout": {
+			query:  "limit=150&timeout=10s&partial=true",
+			status: http.StatusOK,
+			count:  150,
+		},
+		"read page exceeding timeout without partial results": {
+			query:  "limit=250&timeout=150ms",
+			status: http.StatusGatewayTimeout,
+		},
+		"read page with partial results without timeout": {
+			query:  "partial=true",
+			status: http.StatusBadRequest,
+		},
+		"read page with partial results without envelope": {
+			query:  "timeout=1s&partial=true&envelope=false",
+			status: http.StatusBadRequest,
+		},
+		"read page with timeout and offset": {
+			query:  "timeout=1s&offset=1",
+			status: http.StatusBadRequest,
+		},
+		"read page with invalid timeout": {
+			query:  "timeout=soon",
+			status: http.StatusBadRequest,
+		},
+		"read page with negative timeout": {
+			query:  "timeout=-1s",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		status, page := read(tc.query)
+		assert.Equal(t, tc.status, status, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, status))
+		assert.Equal(t, tc.partial, page.Partial, fmt.Sprintf("%s: expected partial %t got %t", desc, tc.partial, page.Partial))
+		assert.Len(t, page.Messages, tc.count, fmt.Sprintf("%s: expected %d messages got %d", desc, tc.count, len(page.Messages)))
+	}
+}
+
+func TestReadAllMaxTimeSpan(t *testing.T) {
+	maxSpan := time.Hour
+	now := time.Now()
+	messages := []mainflux.Message{}
+	for _, age := range []time.Duration{10 * time.Minute, 45 * time.Minute, 2 * time.Hour} {
+		messages = append(messages, mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Time:      float64(now.Add(-age).Unix()),
+		})
+	}
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages})
+	thingsClient := mocks.NewThingsService()
+	ts := httptest.NewServer(api.MakeHandler(svc, thingsClient, false, maxSpan, 0, tagKeys, adminToken, svcName))
+	defer ts.Close()
+
+	cases := map[string]struct {
+		query  string
+		status int
+		total  uint64
+	}{
+		"read page within maximum span": {
+			query:  fmt.Sprintf("from=%d&to=%d", now.Add(-50*time.Minute).Unix(), now.Unix()),
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read page spanning exactly maximum span": {
+			query:  fmt.Sprintf("from=%d&to=%d", now.Add(-3*time.Hour).Unix(), now.Add(-2*time.Hour).Unix()),
+			status: http.StatusOK,
+			total:  0,
+		},
+		"read page exceeding maximum span": {
+			query:  fmt.Sprintf("from=%d&to=%d", now.Add(-3*time.Hour).Unix(), now.Unix()),
+			status: http.StatusBadRequest,
+		},
+		"read page without time range": {
+			query:  "",
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read page without end of time range": {
+			query:  fmt.Sprintf("from=%d", now.Add(-150*time.Minute).Unix()),
+			status: http.StatusOK,
+			total:  1,
+		},
+		"read page without start of time range": {
+			query:  fmt.Sprintf("to=%d", now.Add(-30*time.Minute).Unix()),
+			status: http.StatusOK,
+			total:  1,
+		},
+		"read page of the last period within maximum span": {
+			query:  "last=30m",
+			status: http.StatusOK,
+			total:  1,
+		},
+		"read page of the last period exceeding maximum span": {
+			query:  "last=3h",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+
+		if tc.status != http.StatusOK {
+			var body struct {
+				Err     string  `json:"error"`
+				MaxSpan float64 `json:"max_span"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&body)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+			assert.Equal(t, maxSpan.Seconds(), body.MaxSpan, fmt.Sprintf("%s: expected max span %f got %f", desc, maxSpan.Seconds(), body.MaxSpan))
+			continue
+		}
+
+		var page struct {
+			Total uint64 `json:"total"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+	}
+}
+
+func TestReadAllOffsetWarning(t *testing.T) {
+	svc := newService()
+	thingsClient := mocks.NewThingsService()
+	ts := httptest.NewServer(api.MakeHandler(svc, thingsClient, false, 0, 50, tagKeys, adminToken, svcName))
+	defer ts.Close()
+
+	warning := `299 - "offset pagination deprecated for offset>50, use cursor"`
+
+	cases := map[string]struct {
+		query   string
+		warning string
+	}{
+		"read page without offset": {
+			query:   "",
+			warning: "",
+		},
+		"read page below warning threshold": {
+			query:   "offset=10",
+			warning: "",
+		},
+		"read page at warning threshold": {
+			query:   "offset=50",
+			warning: "",
+		},
+		"read page past warning threshold": {
+			query:   "offset=51",
+			warning: warning,
+		},
+		"read bare messages past warning threshold": {
+			query:   "offset=90&envelope=false",
+			warning: warning,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?%s", ts.URL, chanID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, http.StatusOK, res.StatusCode))
+		assert.Equal(t, tc.warning, res.Header.Get("Warning"), fmt.Sprintf("%s: expected warning %s got %s", desc, tc.warning, res.Header.Get("Warning")))
+	}
+}
+
+func TestReadAllWithShareLink(t *testing.T) {
+	svc := newService()
+	shareToken := "share-token"
+	tc := mocks.NewSharingThingsService(map[string]string{shareToken: chanID})
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url    string
+		token  string
+		status int
+	}{
+		"read messages with valid share link": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?share=%s", ts.URL, chanID, shareToken),
+			status: http.StatusOK,
+		},
+		"read bounds with valid share link": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/range?share=%s", ts.URL, chanID, shareToken),
+			status: http.StatusOK,
+		},
+		"read messages of other channel with share link": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?share=%s", ts.URL, emptyChanID, shareToken),
+			status: http.StatusForbidden,
+		},
+		"read messages with invalid share link": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?share=invalid", ts.URL, chanID),
+			status: http.StatusForbidden,
+		},
+		"read messages with empty share link": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?share=", ts.URL, chanID),
+			status: http.StatusForbidden,
+		},
+		"read messages with invalid share link and valid key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?share=invalid", ts.URL, chanID),
+			token:  token,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestReadAllPublisherScoped(t *testing.T) {
+	scopedChanID := "3"
+	sharedChanID := "4"
+
+	// Things 1 and 2 publish to the channels in turns.
+	msgs := map[string][]mainflux.Message{}
+	for _, id := range []string{scopedChanID, sharedChanID} {
+		for i := 0; i < 10; i++ {
+			msgs[id] = append(msgs[id], mainflux.Message{
+				Channel:   id,
+				Publisher: fmt.Sprintf("%d", i%2+1),
+				Time:      float64(msgTime + i),
+				Value:     &mainflux.Message_FloatValue{FloatValue: float64(i)},
+			})
+		}
+	}
+	svc := mocks.NewMessageRepository(msgs)
+	tc := mocks.NewScopingThingsService(map[string]bool{scopedChanID: true})
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url        string
+		status     int
+		total      uint64
+		publishers []string
+	}{
+		"read own messages of publisher-scoped channel": {
+			url:        fmt.Sprintf("%s/channels/%s/messages?limit=10", ts.URL, scopedChanID),
+			status:     http.StatusOK,
+			total:      5,
+			publishers: []string{"1"},
+		},
+		"read own messages of publisher-scoped channel filtered by publisher": {
+			url:        fmt.Sprintf("%s/channels/%s/messages?limit=10&publisher=1", ts.URL, scopedChanID),
+			status:     http.StatusOK,
+			total:      5,
+			publishers: []string{"1"},
+		},
+		"read messages of other publisher of publisher-scoped channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?limit=10&publisher=2", ts.URL, scopedChanID),
+			status: http.StatusForbidden,
+		},
+		"read all messages of channel": {
+			url:        fmt.Sprintf("%s/channels/%s/messages?limit=10", ts.URL, sharedChanID),
+			status:     http.StatusOK,
+			total:      10,
+			publishers: []string{"1", "2"},
+		},
+		"read messages of other publisher of channel": {
+			url:        fmt.Sprintf("%s/channels/%s/messages?limit=10&publisher=2", ts.URL, sharedChanID),
+			status:     http.StatusOK,
+			total:      5,
+			publishers: []string{"2"},
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total    uint64 `json:"total"`
+			Messages []struct {
+				Publisher string `json:"publisher"`
+			} `json:"messages"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+
+		publishers := map[string]bool{}
+		for _, msg := range page.Messages {
+			publishers[msg.Publisher] = true
+		}
+		for _, p := range tc.publishers {
+			assert.True(t, publishers[p], fmt.Sprintf("%s: expected messages of publisher %s", desc, p))
+		}
+		assert.Len(t, publishers, len(tc.publishers), fmt.Sprintf("%s: expected publishers %v got %v", desc, tc.publishers, publishers))
+	}
+
+	agg := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=count", ts.URL, scopedChanID),
+		token:  token,
+	}
+	res, err := agg.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	require.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("aggregate publisher-scoped channel: expected %d got %d", http.StatusOK, res.StatusCode))
+
+	var body struct {
+		Value float64 `json:"value"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, float64(5), body.Value, fmt.Sprintf("aggregate publisher-scoped channel: expected count 5 got %f", body.Value))
+}
+
+func TestAggregate(t *testing.T) {
+	// Mixed-type channel, where only the float values are aggregated. The
+	// message carrying both the value and the value sum is aggregated by
+	// either field. Every publisher but one publishes more than once.
+	messages := []mainflux.Message{
+		{Channel: chanID, Publisher: "a", Value: &mainflux.Message_FloatValue{FloatValue: 1}},
+		{Channel: chanID, Publisher: "b", Value: &mainflux.Message_StringValue{StringValue: "value"}},
+		{Channel: chanID, Publisher: "a", Value: &mainflux.Message_FloatValue{FloatValue: 2}},
+		{Channel: chanID, Value: &mainflux.Message_BoolValue{BoolValue: true}},
+		{Channel: chanID, Publisher: "c", Value: &mainflux.Message_FloatValue{FloatValue: 6}, ValueSum: &mainflux.SumValue{Value: 10}},
+		{Channel: chanID, Publisher: "b"},
+	}
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: messages,
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url     string
+		token   string
+		status  int
+		value   float64
+		samples uint64
+	}{
+		"average skipping nulls by default": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   3,
+			samples: 3,
+		},
+		"average skipping nulls explicitly": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&field=value&nulls=skip", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   3,
+			samples: 3,
+		},
+		"minimum value": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=min", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   1,
+			samples: 3,
+		},
+		"maximum value": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=max", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   6,
+			samples: 3,
+		},
+		"sum of values": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=sum", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   9,
+			samples: 3,
+		},
+		"sum of value sums": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=sum&field=value_sum", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   10,
+			samples: 1,
+		},
+		"count of value sums": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=count&field=value_sum", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   1,
+			samples: 1,
+		},
+		"count of distinct publishers": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=count_distinct&field=publisher", ts.URL, chanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   3,
+			samples: 5,
+		},
+		"count of distinct publishers rejecting nulls": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=count_distinct&field=publisher&nulls=error", ts.URL, chanID),
+			token:  token,
+			status: http.StatusUnprocessableEntity,
+		},
+		"count of distinct values": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=count_distinct", ts.URL, chanID),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"average of empty channel": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg", ts.URL, emptyChanID),
+			token:   token,
+			status:  http.StatusOK,
+			value:   0,
+			samples: 0,
+		},
+		"average rejecting nulls": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&nulls=error", ts.URL, chanID),
+			token:  token,
+			status: http.StatusUnprocessableEntity,
+		},
+		"average with invalid null policy": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&nulls=%s", ts.URL, chanID, invalid),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"aggregate with invalid function": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=%s", ts.URL, chanID, invalid),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"aggregate without function": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate", ts.URL, chanID),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"aggregate invalid field": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&field=%s", ts.URL, chanID, invalid),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"aggregate with invalid token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg", ts.URL, chanID),
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Value   float64 `json:"value"`
+			Samples uint64  `json:"samples"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.value, body.Value, fmt.Sprintf("%s: expected value %f got %f", desc, tc.value, body.Value))
+		assert.Equal(t, tc.samples, body.Samples, fmt.Sprintf("%s: expected %d samples got %d", desc, tc.samples, body.Samples))
+	}
+}
+
+func TestAggregateGrouped(t *testing.T) {
+	hour := int64(time.Hour / time.Second)
+	start := time.Now().Unix()/hour*hour - 2*hour
+	// Two publishers in the first hour, and only the first one in the next.
+	messages := []mainflux.Message{
+		{Channel: chanID, Publisher: "1", Time: float64(start), Value: &mainflux.Message_FloatValue{FloatValue: 1}},
+		{Channel: chanID, Publisher: "1", Time: float64(start + 60), Value: &mainflux.Message_FloatValue{FloatValue: 3}},
+		{Channel: chanID, Publisher: "2", Time: float64(start + 120), Value: &mainflux.Message_FloatValue{FloatValue: 10}},
+		{Channel: chanID, Publisher: "1", Time: float64(start + hour), Value: &mainflux.Message_FloatValue{FloatValue: 5}},
+	}
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: messages,
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	first, second := float64(start), float64(start+hour)
+	avg := func(v float64) *float64 { return &v }
+	samples := func(n uint64) *uint64 { return &n }
+
+	type group struct {
+		Key     string  `json:"key"`
+		Value   float64 `json:"value"`
+		Samples uint64  `json:"samples"`
+	}
+	type bucket struct {
+		Start   float64  `json:"start"`
+		Value   *float64 `json:"value"`
+		Samples *uint64  `json:"samples"`
+		Groups  []group  `json:"groups"`
+	}
+	type response struct {
+		Value   float64  `json:"value"`
+		Samples uint64   `json:"samples"`
+		Buckets []bucket `json:"buckets"`
+		Groups  []group  `json:"groups"`
+	}
+
+	cases := map[string]struct {
+		url    string
+		status int
+		res    response
+	}{
+		"average by hour and publisher": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&interval=hour&groupBy=publisher", ts.URL, chanID),
+			status: http.StatusOK,
+			res: response{
+				Value:   4.75,
+				Samples: 4,
+				Buckets: []bucket{
+					{Start: first, Groups: []group{{Key: "1", Value: 2, Samples: 2}, {Key: "2", Value: 10, Samples: 1}}},
+					{Start: second, Groups: []group{{Key: "1", Value: 5, Samples: 1}}},
+				},
+			},
+		},
+		"average by hour": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&interval=hour", ts.URL, chanID),
+			status: http.StatusOK,
+			res: response{
+				Value:   4.75,
+				Samples: 4,
+				Buckets: []bucket{
+					{Start: first, Value: avg(14.0 / 3), Samples: samples(3)},
+					{Start: second, Value: avg(5), Samples: samples(1)},
+				},
+			},
+		},
+		"maximum by publisher": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=max&groupBy=publisher", ts.URL, chanID),
+			status: http.StatusOK,
+			res: response{
+				Value:   10,
+				Samples: 4,
+				Groups:  []group{{Key: "1", Value: 5, Samples: 3}, {Key: "2", Value: 10, Samples: 1}},
+			},
+		},
+		"aggregate with invalid interval": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&interval=%s", ts.URL, chanID, invalid),
+			status: http.StatusBadRequest,
+		},
+		"aggregate with invalid group-by field": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&groupBy=%s", ts.URL, chanID, invalid),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body response
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.res, body, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, body))
+	}
+}
+
+func TestAggregatePaged(t *testing.T) {
+	// Sum of the values of the publisher equals its index.
+	messages := []mainflux.Message{}
+	for i := 1; i <= 5; i++ {
+		for j := 0; j < i; j++ {
+			messages = append(messages, mainflux.Message{
+				Channel:   chanID,
+				Publisher: fmt.Sprintf("p%d", i),
+				Value:     &mainflux.Message_FloatValue{FloatValue: 1},
+			})
+		}
+	}
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: messages,
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/channels/%s/messages/aggregate?function=sum&groupBy=publisher", ts.URL, chanID)
+	cases := map[string]struct {
+		url    string
+		status int
+		order  string
+		keys   []string
+	}{
+		"read top groups by value": {
+			url:    fmt.Sprintf("%s&order=value_desc&limit=2", url),
+			status: http.StatusOK,
+			order:  readers.OrderValueDesc,
+			keys:   []string{"p5", "p4"},
+		},
+		"read next page of top groups by value": {
+			url:    fmt.Sprintf("%s&order=value_desc&offset=2&limit=2", url),
+			status: http.StatusOK,
+			order:  readers.OrderValueDesc,
+			keys:   []string{"p3", "p2"},
+		},
+		"read bottom groups by value": {
+			url:    fmt.Sprintf("%s&order=value_asc&limit=1", url),
+			status: http.StatusOK,
+			order:  readers.OrderValueAsc,
+			keys:   []string{"p1"},
+		},
+		"read groups ordered by key by default": {
+			url:    fmt.Sprintf("%s&limit=3", url),
+			status: http.StatusOK,
+			order:  readers.OrderKey,
+			keys:   []string{"p1", "p2", "p3"},
+		},
+		"read groups with invalid order": {
+			url:    fmt.Sprintf("%s&order=%s&limit=3", url, invalid),
+			status: http.StatusBadRequest,
+		},
+		"read ordered groups without limit": {
+			url:    fmt.Sprintf("%s&order=value_desc", url),
+			status: http.StatusBadRequest,
+		},
+		"read groups with invalid limit": {
+			url:    fmt.Sprintf("%s&limit=%s", url, invalid),
+			status: http.StatusBadRequest,
+		},
+		"read paged groups of bucketed aggregation": {
+			url:    fmt.Sprintf("%s&interval=hour&limit=3", url),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Value  float64 `json:"value"`
+			Order  string  `json:"order"`
+			Total  uint64  `json:"total"`
+			Groups []struct {
+				Key string `json:"key"`
+			} `json:"groups"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		keys := []string{}
+		for _, g := range body.Groups {
+			keys = append(keys, g.Key)
+		}
+		assert.Equal(t, float64(15), body.Value, fmt.Sprintf("%s: expected value 15 got %f", desc, body.Value))
+		assert.Equal(t, tc.order, body.Order, fmt.Sprintf("%s: expected order %s got %s", desc, tc.order, body.Order))
+		assert.Equal(t, uint64(5), body.Total, fmt.Sprintf("%s: expected 5 groups in total got %d", desc, body.Total))
+		assert.Equal(t, tc.keys, keys, fmt.Sprintf("%s: expected %v got %v", desc, tc.keys, keys))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	// Only every sixth test message carries the float value.
+	required := `{"required":["value"]}`
+	conforming := `{"properties":{"protocol":{"enum":["mqtt"]},"publisher":{"type":"string"}}}`
+
+	cases := map[string]struct {
+		url        string
+		token      string
+		profile    string
+		status     int
+		scanned    uint64
+		violations uint64
+		samples    int
+		complete   bool
+	}{
+		"validate conforming messages": {
+			url:        fmt.Sprintf("%s/channels/%s/messages/validate", ts.URL, chanID),
+			token:      token,
+			profile:    conforming,
+			status:     http.StatusOK,
+			scanned:    numOfMessages,
+			violations: 0,
+			samples:    0,
+			complete:   true,
+		},
+		"validate non-conforming messages": {
+			url:        fmt.Sprintf("%s/channels/%s/messages/validate?sample=100", ts.URL, chanID),
+			token:      token,
+			profile:    required,
+			status:     http.StatusOK,
+			scanned:    numOfMessages,
+			violations: 35,
+			samples:    35,
+			complete:   true,
+		},
+		"validate non-conforming messages until sample is full": {
+			url:        fmt.Sprintf("%s/channels/%s/messages/validate?sample=5", ts.URL, chanID),
+			token:      token,
+			profile:    required,
+			status:     http.StatusOK,
+			scanned:    6,
+			violations: 5,
+			samples:    5,
+			complete:   false,
+		},
+		"validate messages in time range": {
+			url:        fmt.Sprintf("%s/channels/%s/messages/validate?from=%d&to=%d", ts.URL, chanID, msgTime, msgTime+12),
+			token:      token,
+			profile:    required,
+			status:     http.StatusOK,
+			scanned:    12,
+			violations: 10,
+			samples:    10,
+			complete:   true,
+		},
+		"validate with invalid profile": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/validate", ts.URL, chanID),
+			token:   token,
+			profile: `{"properties":{"value":{"type":"integer"}}}`,
+			status:  http.StatusBadRequest,
+		},
+		"validate with malformed profile": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/validate", ts.URL, chanID),
+			token:   token,
+			profile: `{`,
+			status:  http.StatusBadRequest,
+		},
+		"validate with invalid sample size": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/validate?sample=0", ts.URL, chanID),
+			token:   token,
+			profile: required,
+			status:  http.StatusBadRequest,
+		},
+		"validate with too large sample size": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/validate?sample=101", ts.URL, chanID),
+			token:   token,
+			profile: required,
+			status:  http.StatusBadRequest,
+		},
+		"validate with invalid token": {
+			url:     fmt.Sprintf("%s/channels/%s/messages/validate", ts.URL, chanID),
+			token:   invalid,
+			profile: required,
+			status:  http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodPost,
+			url:    tc.url,
+			token:  tc.token,
+			body:   strings.NewReader(tc.profile),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Scanned    uint64 `json:"scanned"`
+			Violations uint64 `json:"violations"`
+			Complete   bool   `json:"complete"`
+			Samples    []struct {
+				Message mainflux.Message `json:"message"`
+				Errors  []string         `json:"errors"`
+			} `json:"samples"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.scanned, body.Scanned, fmt.Sprintf("%s: expected %d scanned got %d", desc, tc.scanned, body.Scanned))
+		assert.Equal(t, tc.violations, body.Violations, fmt.Sprintf("%s: expected %d violations got %d", desc, tc.violations, body.Violations))
+		assert.Equal(t, tc.complete, body.Complete, fmt.Sprintf("%s: expected complete %t got %t", desc, tc.complete, body.Complete))
+		assert.Len(t, body.Samples, tc.samples, fmt.Sprintf("%s: expected %d samples got %d", desc, tc.samples, len(body.Samples)))
+		for _, s := range body.Samples {
+			assert.Equal(t, []string{"value: required field is missing"}, s.Errors, fmt.Sprintf("%s: got unexpected errors %v", desc, s.Errors))
+		}
+	}
+}
+
+func TestReadAllExplain(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url     string
+		token   string
+		status  int
+		queries []map[string]interface{}
+	}{
+		"explain read with admin token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?offset=5&limit=10&explain=true", ts.URL, chanID),
+			token:  adminToken,
+			status: http.StatusOK,
+			queries: []map[string]interface{}{
+				{"query": "read_all", "args": []interface{}{chanID, float64(5), float64(10)}},
+			},
+		},
+		"explain filtered read with admin token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?publisher=1&explain=true", ts.URL, chanID),
+			token:  adminToken,
+			status: http.StatusOK,
+			queries: []map[string]interface{}{
+				{"query": "read_all", "args": []interface{}{chanID, "publisher=1", float64(0), float64(10)}},
+			},
+		},
+		"explain read with thing key": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?explain=true", ts.URL, chanID),
+			token:  token,
+			status: http.StatusForbidden,
+		},
+		"explain read with invalid token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?explain=true", ts.URL, chanID),
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+		"explain read without token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?explain=true", ts.URL, chanID),
+			status: http.StatusForbidden,
+		},
+		"explain read with invalid explain flag": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?explain=yes", ts.URL, chanID),
+			token:  adminToken,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Queries []map[string]interface{} `json:"queries"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.queries, body.Queries, fmt.Sprintf("%s: expected %v got %v", desc, tc.queries, body.Queries))
+	}
+}
+
+func TestReadAllExplainDisabled(t *testing.T) {
+	svc := newService()
+	tc := mocks.NewThingsService()
+	ts := httptest.NewServer(api.MakeHandler(svc, tc, false, 0, 0, tagKeys, "", svcName))
+	defer ts.Close()
+
+	cases := map[string]struct {
+		token  string
+		status int
+	}{
+		"explain read with empty token": {
+			token:  "",
+			status: http.StatusForbidden,
+		},
+		"explain read with thing key": {
+			token:  token,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages?explain=true", ts.URL, chanID),
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestReadAllCrossChannel(t *testing.T) {
+	otherChanID := "3"
+	svc := mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: newMessages(),
+		otherChanID: {
+			{Channel: otherChanID, Publisher: "2", Time: msgTime},
+			{Channel: otherChanID, Publisher: "2", Time: msgTime + numOfMessages},
+		},
+	})
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	from, to := msgTime, msgTime+numOfMessages
+	cases := map[string]struct {
+		url    string
+		token  string
+		status int
+		total  uint64
+	}{
+		"read messages of all channels with admin token": {
+			url:    fmt.Sprintf("%s/messages?from=%d&to=%d", ts.URL, from, to),
+			token:  adminToken,
+			status: http.StatusOK,
+			total:  numOfMessages + 1,
+		},
+		"read messages of all channels by publisher": {
+			url:    fmt.Sprintf("%s/messages?from=%d&to=%d&publisher=2", ts.URL, from, to+1),
+			token:  adminToken,
+			status: http.StatusOK,
+			total:  2,
+		},
+		"read messages of all channels of the last period": {
+			url:    fmt.Sprintf("%s/messages?last=1h", ts.URL),
+			token:  adminToken,
+			status: http.StatusOK,
+			total:  0,
+		},
+		"read messages of all channels without time range": {
+			url:    fmt.Sprintf("%s/messages", ts.URL),
+			token:  adminToken,
+			status: http.StatusBadRequest,
+		},
+		"read messages of all channels without range end": {
+			url:    fmt.Sprintf("%s/messages?from=%d", ts.URL, from),
+			token:  adminToken,
+			status: http.StatusBadRequest,
+		},
+		"read messages of all channels with unknown parameter": {
+			url:    fmt.Sprintf("%s/messages?from=%d&to=%d&explain=true", ts.URL, from, to),
+			token:  adminToken,
+			status: http.StatusBadRequest,
+		},
+		"read messages of all channels with thing key": {
+			url:    fmt.Sprintf("%s/messages?from=%d&to=%d", ts.URL, from, to),
+			token:  token,
+			status: http.StatusForbidden,
+		},
+		"read messages of all channels without token": {
+			url:    fmt.Sprintf("%s/messages?from=%d&to=%d", ts.URL, from, to),
+			status: http.StatusForbidden,
+		},
+		"read messages without channel with thing key": {
+			url:    fmt.Sprintf("%s/channels//messages?from=%d&to=%d", ts.URL, from, to),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page struct {
+			Total uint64 `json:"total"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, tc.total, page.Total))
+	}
+}
+
+func newGrafanaService() readers.MessageRepository {
+	float := func(v float64) *mainflux.Message_FloatValue {
+		return &mainflux.Message_FloatValue{FloatValue: v}
+	}
+
+	return mocks.NewMessageRepository(map[string][]mainflux.Message{
+		chanID: {
+			{Channel: chanID, Publisher: "a", Time: msgTime, Value: float(1)},
+			{Channel: chanID, Publisher: "b", Time: msgTime + 30, Value: float(3)},
+			{Channel: chanID, Publisher: "a", Time: msgTime + 60, Value: float(5)},
+		},
+	})
+}
+
+func TestGrafanaQuery(t *testing.T) {
+	svc := newGrafanaService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	query := func(intervalMs int, targets ...string) string {
+		ts := []string{}
+		for _, t := range targets {
+			ts = append(ts, fmt.Sprintf(`{"target":"%s","refId":"A","type":"timeserie"}`, t))
+		}
+		return fmt.Sprintf(`{"range":{"from":"2019-06-08T13:20:00.000Z","to":"2019-06-08T13:30:00.000Z"},"intervalMs":%d,"targets":[%s],"maxDataPoints":500}`, intervalMs, strings.Join(ts, ","))
+	}
+
+	cases := map[string]struct {
+		method string
+		url    string
+		body   string
+		token  string
+		status int
+		res    string
+	}{
+		"query series by minute": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(30000, "avg:value"),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"avg:value","datapoints":[[2,1560000000000],[5,1560000060000]]}]`,
+		},
+		"query series by hour": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(600000, "count:value"),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"count:value","datapoints":[[3,1559998800000]]}]`,
+		},
+		"query grouped series": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(60000, "max:value:publisher"),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"max:value:publisher=a","datapoints":[[1,1560000000000],[5,1560000060000]]},{"target":"max:value:publisher=b","datapoints":[[3,1560000000000]]}]`,
+		},
+		"query multiple series": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(60000, "min:value", "sum:value"),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"min:value","datapoints":[[1,1560000000000],[5,1560000060000]]},{"target":"sum:value","datapoints":[[4,1560000000000],[5,1560000060000]]}]`,
+		},
+		"query series without datapoints": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, emptyChanID),
+			body:   query(60000, "avg:value"),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"avg:value","datapoints":[]}]`,
+		},
+		"query aggregation in grafana format": {
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&interval=minute&format=grafana", ts.URL, chanID),
+			token:  token,
+			status: http.StatusOK,
+			res:    `[{"target":"avg:value","datapoints":[[2,1560000000000],[5,1560000060000]]}]`,
+		},
+		"query aggregation in grafana format without interval": {
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&format=grafana", ts.URL, chanID),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query aggregation in unknown format": {
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages/aggregate?function=avg&interval=minute&format=csv", ts.URL, chanID),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query series of invalid target": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(60000, "median:value"),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query without targets": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(60000),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query with malformed body": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   "{",
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query with invalid token": {
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/query", ts.URL, chanID),
+			body:   query(60000, "avg:value"),
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: tc.method,
+			url:    tc.url,
+			token:  tc.token,
+			body:   strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.JSONEq(t, tc.res, string(body), fmt.Sprintf("%s: expected %s got %s", desc, tc.res, body))
+	}
+}
+
+func TestGrafanaSearch(t *testing.T) {
+	svc := newGrafanaService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		body    string
+		token   string
+		status  int
+		targets []string
+		count   int
+	}{
+		"search targets of function and field": {
+			body:    `{"target":"count:value_sum"}`,
+			token:   token,
+			status:  http.StatusOK,
+			targets: []string{"count:value_sum", "count:value_sum:subtopic", "count:value_sum:publisher", "count:value_sum:protocol", "count:value_sum:name"},
+		},
+		"search all targets": {
+			body:   `{"target":""}`,
+			token:  token,
+			status: http.StatusOK,
+			count:  50,
+		},
+		"search unknown target": {
+			body:    `{"target":"median"}`,
+			token:   token,
+			status:  http.StatusOK,
+			targets: []string{},
+		},
+		"search with malformed body": {
+			body:   "{",
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"search with invalid token": {
+			body:   `{"target":""}`,
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/channels/%s/grafana/search", ts.URL, chanID),
+			token:  tc.token,
+			body:   strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var targets []string
+		err = json.NewDecoder(res.Body).Decode(&targets)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		if tc.targets == nil {
+			assert.Len(t, targets, tc.count, fmt.Sprintf("%s: expected %d targets got %d", desc, tc.count, len(targets)))
+			continue
+		}
+		assert.Equal(t, tc.targets, targets, fmt.Sprintf("%s: expected targets %v got %v", desc, tc.targets, targets))
+	}
+}
+
+func TestGrafanaTest(t *testing.T) {
+	svc := newGrafanaService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		token  string
+		status int
+	}{
+		"test datasource with thing key": {
+			token:  token,
+			status: http.StatusOK,
+		},
+		"test datasource with invalid token": {
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+		"test datasource without token": {
+			token:  "",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/grafana", ts.URL, chanID),
+			token:  tc.token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestBatch(t *testing.T) {
+	svc := newGrafanaService()
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	type result struct {
+		status  int
+		times   []float64
+		value   float64
+		samples uint64
+	}
+
+	latest := `"latest":{"type":"latest"}`
+	average := fmt.Sprintf(`"average":{"type":"aggregate","params":{"function":"avg","from":"%d","to":"%d"}}`, msgTime, msgTime+60)
+	window := fmt.Sprintf(`"window":{"type":"raw","params":{"from":"%d","to":"%d","limit":"5","order":"desc"}}`, msgTime+30, msgTime+61)
+	batch := func(queries ...string) string {
+		return fmt.Sprintf(`{"queries":{%s}}`, strings.Join(queries, ","))
+	}
+
+	tooMany := []string{}
+	for i := 0; i <= 10; i++ {
+		tooMany = append(tooMany, fmt.Sprintf(`"q%d":{"type":"latest"}`, i))
+	}
+
+	cases := map[string]struct {
+		url     string
+		body    string
+		token   string
+		status  int
+		results map[string]result
+	}{
+		"query latest value, average and raw window": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(latest, average, window),
+			token:  token,
+			status: http.StatusOK,
+			results: map[string]result{
+				"latest":  {status: http.StatusOK, times: []float64{msgTime + 60}},
+				"average": {status: http.StatusOK, value: 2, samples: 2},
+				"window":  {status: http.StatusOK, times: []float64{msgTime + 60, msgTime + 30}},
+			},
+		},
+		"query batch with invalid aggregation": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(latest, `"median":{"type":"aggregate","params":{"function":"median"}}`, window),
+			token:  token,
+			status: http.StatusOK,
+			results: map[string]result{
+				"latest": {status: http.StatusOK, times: []float64{msgTime + 60}},
+				"median": {status: http.StatusBadRequest},
+				"window": {status: http.StatusOK, times: []float64{msgTime + 60, msgTime + 30}},
+			},
+		},
+		"query batch with unknown sub-query type": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(average, `"bounds":{"type":"range"}`),
+			token:  token,
+			status: http.StatusOK,
+			results: map[string]result{
+				"average": {status: http.StatusOK, value: 2, samples: 2},
+				"bounds":  {status: http.StatusBadRequest},
+			},
+		},
+		"query batch with unknown sub-query parameter": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(latest, `"explained":{"type":"raw","params":{"explain":"true"}}`),
+			token:  token,
+			status: http.StatusOK,
+			results: map[string]result{
+				"latest":    {status: http.StatusOK, times: []float64{msgTime + 60}},
+				"explained": {status: http.StatusBadRequest},
+			},
+		},
+		"query batch of empty channel": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, emptyChanID),
+			body:   batch(latest, `"average":{"type":"aggregate","params":{"function":"avg"}}`),
+			token:  token,
+			status: http.StatusOK,
+			results: map[string]result{
+				"latest":  {status: http.StatusOK, times: []float64{}},
+				"average": {status: http.StatusOK},
+			},
+		},
+		"query batch without sub-queries": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query batch with too many sub-queries": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(tooMany...),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query batch with unknown parameter": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch?name=temperature", ts.URL, chanID),
+			body:   batch(latest),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query batch with malformed body": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   "{",
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		"query batch with invalid token": {
+			url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+			body:   batch(latest),
+			token:  invalid,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodPost,
+			url:    tc.url,
+			token:  tc.token,
+			body:   strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Results map[string]struct {
+				Status int    `json:"status"`
+				Error  string `json:"error"`
+				Result struct {
+					Messages []struct {
+						Time float64 `json:"time"`
+					} `json:"messages"`
+					Value   float64 `json:"value"`
+					Samples uint64  `json:"samples"`
+				} `json:"result"`
+			} `json:"results"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, len(tc.results), len(body.Results), fmt.Sprintf("%s: expected %d results got %d", desc, len(tc.results), len(body.Results)))
+
+		for key, expected := range tc.results {
+			r, ok := body.Results[key]
+			require.True(t, ok, fmt.Sprintf("%s: expected result of %s", desc, key))
+			assert.Equal(t, expected.status, r.Status, fmt.Sprintf("%s: expected %s status %d got %d", desc, key, expected.status, r.Status))
+			if expected.status != http.StatusOK {
+				assert.NotEmpty(t, r.Error, fmt.Sprintf("%s: expected %s error", desc, key))
+				continue
+			}
+
+			assert.Empty(t, r.Error, fmt.Sprintf("%s: unexpected %s error %s", desc, key, r.Error))
+			if expected.times != nil {
+				times := []float64{}
+				for _, msg := range r.Result.Messages {
+					times = append(times, msg.Time)
+				}
+				assert.Equal(t, expected.times, times, fmt.Sprintf("%s: expected %s messages at %v got %v", desc, key, expected.times, times))
+				continue
+			}
+
+			assert.Equal(t, expected.value, r.Result.Value, fmt.Sprintf("%s: expected %s value %f got %f", desc, key, expected.value, r.Result.Value))
+			assert.Equal(t, expected.samples, r.Result.Samples, fmt.Sprintf("%s: expected %s samples %d got %d", desc, key, expected.samples, r.Result.Samples))
+		}
+	}
+}
+
+func TestBatchConcurrent(t *testing.T) {
+	delay := 200 * time.Millisecond
+	svc := slowRepository{
+		MessageRepository: newGrafanaService(),
+		delay:             delay,
+	}
+	tc := mocks.NewThingsService()
+	ts := newServer(svc, tc)
+	defer ts.Close()
+
+	queries := []string{}
+	for i := 0; i < 5; i++ {
+		queries = append(queries, fmt.Sprintf(`"q%d":{"type":"latest"}`, i))
+	}
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodPost,
+		url:    fmt.Sprintf("%s/channels/%s/messages/batch", ts.URL, chanID),
+		token:  token,
+		body:   strings.NewReader(fmt.Sprintf(`{"queries":{%s}}`, strings.Join(queries, ","))),
+	}
+
+	start := time.Now()
+	res, err := req.make()
+	elapsed := time.Since(start)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected %d got %d", http.StatusOK, res.StatusCode))
+	assert.True(t, elapsed < time.Duration(len(queries))*delay/2, fmt.Sprintf("expected sub-queries to be executed concurrently, batch took %s", elapsed))
+}
@@ -39,3 +39,27 @@ func (lm *loggingMiddleware) ReadAll(chanID string, offset, limit uint64, query
 
 	return lm.svc.ReadAll(chanID, offset, limit, query)
 }
+
+func (lm *loggingMiddleware) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	defer func(begin time.Time) {
+		lm.logger.Info(fmt.Sprintf(`Method explain for channel %s took %s to complete without errors.`, chanID, time.Since(begin)))
+	}(time.Now())
+
+	return lm.svc.Explain(chanID, offset, limit, query)
+}
+
+func (lm *loggingMiddleware) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	defer func(begin time.Time) {
+		lm.logger.Info(fmt.Sprintf(`Method bounds for channel %s took %s to complete without errors.`, chanID, time.Since(begin)))
+	}(time.Now())
+
+	return lm.svc.Bounds(chanID, query)
+}
+
+func (lm *loggingMiddleware) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	defer func(begin time.Time) {
+		lm.logger.Info(fmt.Sprintf(`Method aggregate %s of %s for channel %s took %s to complete without errors.`, agg.Function, agg.Field, chanID, time.Since(begin)))
+	}(time.Now())
+
+	return lm.svc.Aggregate(chanID, agg, query)
+}
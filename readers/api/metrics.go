@@ -42,3 +42,30 @@ func (mm *metricsMiddleware) ReadAll(chanID string, offset, limit uint64, query
 
 	return mm.svc.ReadAll(chanID, offset, limit, query)
 }
+
+func (mm *metricsMiddleware) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "explain").Add(1)
+		mm.latency.With("method", "explain").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Explain(chanID, offset, limit, query)
+}
+
+func (mm *metricsMiddleware) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "bounds").Add(1)
+		mm.latency.With("method", "bounds").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Bounds(chanID, query)
+}
+
+func (mm *metricsMiddleware) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "aggregate").Add(1)
+		mm.latency.With("method", "aggregate").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Aggregate(chanID, agg, query)
+}
@@ -7,21 +7,240 @@
 
 package api
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mainflux/mainflux/readers"
+)
+
 type apiReq interface {
 	validate() error
 }
 
 type listMessagesReq struct {
-	chanID string
-	offset uint64
-	limit  uint64
-	query  map[string]string
+	chanID      string
+	offset      uint64
+	limit       uint64
+	query       map[string]string
+	envelope    bool
+	rename      map[string]string
+	filename    string
+	quote       bool
+	explain     bool
+	valueFormat string
+	timeout     time.Duration
+	partial     bool
 }
 
 func (req listMessagesReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
 	if req.limit < 1 {
 		return errInvalidRequest
 	}
 
+	// Keyset pagination replaces the offset.
+	if req.query[readers.AfterKey] != "" && req.offset > 0 {
+		return errInvalidRequest
+	}
+
+	if req.valueFormat != rawValues && req.valueFormat != flatValues {
+		return errInvalidRequest
+	}
+
+	// Timed reads are resumed by the cursor, and only the envelope carries
+	// the partial results marker.
+	if req.timeout > 0 && req.offset > 0 {
+		return errInvalidRequest
+	}
+
+	if req.partial && (req.timeout == 0 || !req.envelope) {
+		return errInvalidRequest
+	}
+
+	return validateRename(req.rename)
+}
+
+// validateRename checks that only the known fields are renamed and that the
+// new keys neither clash with each other nor with the kept fields.
+func validateRename(rename map[string]string) error {
+	keys := map[string]bool{}
+	for field := range renameFields {
+		if _, ok := rename[field]; !ok {
+			keys[field] = true
+		}
+	}
+
+	keys[seqKey] = true
+
+	for field, key := range rename {
+		if !renameFields[field] || key == "" || keys[key] {
+			return errInvalidRequest
+		}
+		keys[key] = true
+	}
+
+	return nil
+}
+
+type boundsReq struct {
+	chanID string
+	query  map[string]string
+}
+
+func (req boundsReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	return nil
+}
+
+type aggregateReq struct {
+	chanID      string
+	aggregation readers.Aggregation
+	query       map[string]string
+	format      string
+}
+
+func (req aggregateReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	// Grafana format renders the buckets as the time series.
+	switch req.format {
+	case "":
+	case grafanaFormat:
+		if req.aggregation.Interval == "" {
+			return errInvalidRequest
+		}
+	default:
+		return errInvalidRequest
+	}
+
+	return req.aggregation.Validate()
+}
+
+type grafanaSearchReq struct {
+	chanID string
+	target string
+}
+
+func (req grafanaSearchReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	return nil
+}
+
+type grafanaQueryReq struct {
+	chanID   string
+	interval string
+	targets  []string
+	query    map[string]string
+}
+
+func (req grafanaQueryReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	if len(req.targets) == 0 || len(req.targets) > maxGrafanaTargets {
+		return errInvalidRequest
+	}
+
+	for _, target := range req.targets {
+		if _, err := grafanaAggregation(target, req.interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grafanaAggregation parses the Grafana target formatted as
+// <function>:<field>[:<groupBy>], e.g. avg:value:publisher, into the
+// aggregation bucketed by the interval.
+func grafanaAggregation(target, interval string) (readers.Aggregation, error) {
+	parts := strings.Split(target, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return readers.Aggregation{}, readers.ErrInvalidAggregation
+	}
+
+	agg := readers.Aggregation{
+		Function:   parts[0],
+		Field:      parts[1],
+		NullPolicy: readers.SkipNulls,
+		Interval:   interval,
+	}
+	if len(parts) == 3 {
+		agg.GroupBy = parts[2]
+	}
+
+	return agg, agg.Validate()
+}
+
+// grafanaTarget renders the target the aggregation is queried by.
+func grafanaTarget(agg readers.Aggregation) string {
+	target := fmt.Sprintf("%s:%s", agg.Function, agg.Field)
+	if agg.GroupBy != "" {
+		target = fmt.Sprintf("%s:%s", target, agg.GroupBy)
+	}
+
+	return target
+}
+
+type validateReq struct {
+	chanID  string
+	profile readers.Profile
+	sample  uint64
+	query   map[string]string
+}
+
+func (req validateReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	if req.sample < 1 || req.sample > maxSampleSize {
+		return errInvalidRequest
+	}
+
+	return req.profile.Validate()
+}
+
+type batchReq struct {
+	chanID  string
+	queries map[string]batchQuery
+}
+
+func (req batchReq) validate() error {
+	if req.chanID == "" {
+		return errInvalidRequest
+	}
+
+	if len(req.queries) == 0 || len(req.queries) > maxBatchQueries {
+		return errInvalidRequest
+	}
+
+	for key := range req.queries {
+		if key == "" {
+			return errInvalidRequest
+		}
+	}
+
 	return nil
 }
+
+// batchQuery is the sub-query of the batch, either the listing or the
+// aggregation request, or the error it failed to decode with.
+type batchQuery struct {
+	req apiReq
+	err error
+}
@@ -8,22 +8,44 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/readers"
 )
 
-var _ mainflux.Response = (*pageRes)(nil)
+// seqKey is the JSON key of the message cursor.
+const seqKey = "seq"
 
+var (
+	_ mainflux.Response = (*pageRes)(nil)
+	_ mainflux.Response = (*messagesRes)(nil)
+	_ mainflux.Response = (*boundsRes)(nil)
+	_ mainflux.Response = (*aggregateRes)(nil)
+	_ mainflux.Response = (*validateRes)(nil)
+	_ mainflux.Response = (*explainRes)(nil)
+	_ mainflux.Response = (*grafanaSeriesRes)(nil)
+	_ mainflux.Response = (*grafanaSearchRes)(nil)
+	_ mainflux.Response = (*grafanaTestRes)(nil)
+	_ mainflux.Response = (*batchRes)(nil)
+)
+
+// pageRes is the page envelope. Partial page, read until the timeout elapsed,
+// carries the cursor the reading is resumed after.
 type pageRes struct {
-	Total    uint64             `json:"total"`
-	Offset   uint64             `json:"offset"`
-	Limit    uint64             `json:"limit"`
-	Messages []mainflux.Message `json:"messages"`
+	Total    uint64      `json:"total"`
+	Offset   uint64      `json:"offset"`
+	Limit    uint64      `json:"limit"`
+	Partial  bool        `json:"partial,omitempty"`
+	Next     string      `json:"next,omitempty"`
+	Messages messageList `json:"messages"`
 }
 
 func (res pageRes) Headers() map[string]string {
-	return map[string]string{}
+	return res.Messages.headers()
 }
 
 func (res pageRes) Code() int {
@@ -33,3 +55,457 @@ func (res pageRes) Code() int {
 func (res pageRes) Empty() bool {
 	return false
 }
+
+// messagesRes is rendered as a bare array of messages, without the page
+// envelope.
+type messagesRes struct {
+	messageList
+}
+
+func (res messagesRes) Headers() map[string]string {
+	return res.headers()
+}
+
+func (res messagesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res messagesRes) Empty() bool {
+	return false
+}
+
+// messageList renders the messages with the fields renamed according to the
+// rename map, which maps the message JSON keys to the new ones. Messages are
+// rendered along with their cursors, if the repository provides them. If
+// filename is set, the messages are served as an attachment of that name.
+// Non-empty warning is served as the Warning header.
+// Quote flag applies only to the CSV rendering, while flat flag applies only
+// to the JSON one.
+type messageList struct {
+	messages []mainflux.Message
+	cursors  []readers.Cursor
+	rename   map[string]string
+	filename string
+	warning  string
+	quote    bool
+	flat     bool
+}
+
+func (ml messageList) headers() map[string]string {
+	headers := map[string]string{}
+	if ml.filename != "" {
+		headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=%q", ml.filename)
+	}
+	if ml.warning != "" {
+		headers["Warning"] = ml.warning
+	}
+
+	return headers
+}
+
+// MarshalJSON renders the numeric fields in the fixed-point notation, the
+// same way they are rendered in CSV.
+func (ml messageList) MarshalJSON() ([]byte, error) {
+	renamed := []map[string]json.RawMessage{}
+	for i, msg := range ml.messages {
+		fields, err := messageFields(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if ml.flat {
+			if err := flattenValue(msg, fields); err != nil {
+				return nil, err
+			}
+		}
+
+		msg := map[string]json.RawMessage{}
+		for field, val := range fields {
+			if key, ok := ml.rename[field]; ok {
+				field = key
+			}
+			msg[field] = val
+		}
+
+		if i < len(ml.cursors) {
+			seq, err := json.Marshal(ml.cursors[i].String())
+			if err != nil {
+				return nil, err
+			}
+			msg[seqKey] = seq
+		}
+
+		renamed = append(renamed, msg)
+	}
+
+	return json.Marshal(renamed)
+}
+
+// messageFields returns the JSON encoded message fields, mapped by the JSON
+// key, with the numeric fields formatted by formatFloat.
+func messageFields(msg mainflux.Message) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	numbers := map[string]float64{
+		"value":      msg.GetFloatValue(),
+		"valueSum":   msg.GetValueSum().GetValue(),
+		"time":       msg.Time,
+		"updateTime": msg.UpdateTime,
+	}
+	for field, val := range numbers {
+		if _, ok := fields[field]; ok {
+			fields[field] = json.RawMessage(formatFloat(val))
+		}
+	}
+
+	return fields, nil
+}
+
+// flattenValue replaces the value fields, named after the type of the value,
+// with the value field carrying the value of any type, and the valueType
+// field naming it. Messages without the value are left without both fields.
+func flattenValue(msg mainflux.Message, fields map[string]json.RawMessage) error {
+	for _, field := range []string{"value", "stringValue", "boolValue", "dataValue"} {
+		delete(fields, field)
+	}
+
+	var value json.RawMessage
+	var valueType string
+	var err error
+	switch v := msg.Value.(type) {
+	case *mainflux.Message_FloatValue:
+		value, valueType = json.RawMessage(formatFloat(v.FloatValue)), "float"
+	case *mainflux.Message_StringValue:
+		value, err = json.Marshal(v.StringValue)
+		valueType = "string"
+	case *mainflux.Message_BoolValue:
+		value, err = json.Marshal(v.BoolValue)
+		valueType = "bool"
+	case *mainflux.Message_DataValue:
+		value, err = json.Marshal(v.DataValue)
+		valueType = "data"
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields["value"] = value
+	fields["valueType"] = json.RawMessage(strconv.Quote(valueType))
+	return nil
+}
+
+type boundsRes struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+func (res boundsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res boundsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res boundsRes) Empty() bool {
+	return false
+}
+
+type aggregateRes struct {
+	Function string      `json:"function"`
+	Field    string      `json:"field"`
+	Value    float64     `json:"value"`
+	Samples  uint64      `json:"samples"`
+	Interval string      `json:"interval,omitempty"`
+	GroupBy  string      `json:"groupBy,omitempty"`
+	Buckets  []bucketRes `json:"buckets,omitempty"`
+	Groups   []groupRes  `json:"groups,omitempty"`
+	*groupsPageRes
+}
+
+// groupsPageRes describes the page of the groups of the paged aggregation.
+type groupsPageRes struct {
+	Order  string `json:"order"`
+	Offset uint64 `json:"offset"`
+	Limit  uint64 `json:"limit"`
+	Total  uint64 `json:"total"`
+}
+
+// bucketRes carries either the value of the bucket, or the values of its
+// groups if the aggregation is grouped as well.
+type bucketRes struct {
+	Start   float64    `json:"start"`
+	Value   *float64   `json:"value,omitempty"`
+	Samples *uint64    `json:"samples,omitempty"`
+	Groups  []groupRes `json:"groups,omitempty"`
+}
+
+type groupRes struct {
+	Key     string  `json:"key"`
+	Value   float64 `json:"value"`
+	Samples uint64  `json:"samples"`
+}
+
+func newAggregateRes(agg readers.Aggregation, res readers.AggregationResult) aggregateRes {
+	ar := aggregateRes{
+		Function: agg.Function,
+		Field:    agg.Field,
+		Value:    res.Value,
+		Samples:  res.Samples,
+		Interval: agg.Interval,
+		GroupBy:  agg.GroupBy,
+		Groups:   newGroupsRes(res.Groups),
+	}
+
+	if agg.Paged() {
+		order := agg.Order
+		if order == "" {
+			order = readers.OrderKey
+		}
+		ar.groupsPageRes = &groupsPageRes{
+			Order:  order,
+			Offset: agg.Offset,
+			Limit:  agg.Limit,
+			Total:  res.TotalGroups,
+		}
+	}
+
+	for _, b := range res.Buckets {
+		br := bucketRes{Start: b.Start}
+		if agg.GroupBy != "" {
+			br.Groups = newGroupsRes(b.Groups)
+		} else {
+			value, samples := b.Value, b.Samples
+			br.Value, br.Samples = &value, &samples
+		}
+		ar.Buckets = append(ar.Buckets, br)
+	}
+
+	return ar
+}
+
+func newGroupsRes(groups []readers.AggregationGroup) []groupRes {
+	res := []groupRes{}
+	for _, g := range groups {
+		res = append(res, groupRes{Key: g.Key, Value: g.Value, Samples: g.Samples})
+	}
+
+	return res
+}
+
+func (res aggregateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res aggregateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res aggregateRes) Empty() bool {
+	return false
+}
+
+// grafanaSeries is the time series in the shape the Grafana SimpleJSON
+// datasource expects, i.e. the datapoints are the pairs of the value and the
+// Unix timestamp in milliseconds.
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// newGrafanaSeries creates the time series out of the buckets of the
+// aggregation. Grouped aggregation yields a series per group, targeted as
+// <target>=<key>, in the order of the first appearance of the group.
+func newGrafanaSeries(target string, agg readers.Aggregation, res readers.AggregationResult) []grafanaSeries {
+	if agg.GroupBy == "" {
+		series := grafanaSeries{Target: target, Datapoints: [][2]float64{}}
+		for _, b := range res.Buckets {
+			series.Datapoints = append(series.Datapoints, [2]float64{b.Value, b.Start * 1000})
+		}
+		return []grafanaSeries{series}
+	}
+
+	series := []grafanaSeries{}
+	idxs := map[string]int{}
+	for _, b := range res.Buckets {
+		for _, g := range b.Groups {
+			i, ok := idxs[g.Key]
+			if !ok {
+				i = len(series)
+				idxs[g.Key] = i
+				series = append(series, grafanaSeries{
+					Target:     fmt.Sprintf("%s=%s", target, g.Key),
+					Datapoints: [][2]float64{},
+				})
+			}
+			series[i].Datapoints = append(series[i].Datapoints, [2]float64{g.Value, b.Start * 1000})
+		}
+	}
+
+	return series
+}
+
+type grafanaSeriesRes []grafanaSeries
+
+func (res grafanaSeriesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res grafanaSeriesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res grafanaSeriesRes) Empty() bool {
+	return false
+}
+
+// grafanaSearchRes lists the targets the series can be queried by.
+type grafanaSearchRes []string
+
+func (res grafanaSearchRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res grafanaSearchRes) Code() int {
+	return http.StatusOK
+}
+
+func (res grafanaSearchRes) Empty() bool {
+	return false
+}
+
+// grafanaTestRes confirms the datasource connection.
+type grafanaTestRes struct{}
+
+func (res grafanaTestRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res grafanaTestRes) Code() int {
+	return http.StatusOK
+}
+
+func (res grafanaTestRes) Empty() bool {
+	return true
+}
+
+type errorRes struct {
+	Err     string  `json:"error"`
+	MaxSpan float64 `json:"max_span,omitempty"`
+}
+
+type validateRes struct {
+	Scanned    uint64         `json:"scanned"`
+	Violations uint64         `json:"violations"`
+	Complete   bool           `json:"complete"`
+	Samples    []violationRes `json:"samples"`
+}
+
+type violationRes struct {
+	Message mainflux.Message `json:"message"`
+	Errors  []string         `json:"errors"`
+}
+
+func newValidateRes(report readers.ValidationReport) validateRes {
+	res := validateRes{
+		Scanned:    report.Scanned,
+		Violations: report.Violations,
+		Complete:   report.Complete,
+		Samples:    []violationRes{},
+	}
+
+	for _, v := range report.Samples {
+		res.Samples = append(res.Samples, violationRes{Message: v.Message, Errors: v.Errors})
+	}
+
+	return res
+}
+
+func (res validateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res validateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res validateRes) Empty() bool {
+	return false
+}
+
+type explainRes struct {
+	Queries []queryRes `json:"queries"`
+}
+
+type queryRes struct {
+	Query string        `json:"query"`
+	Args  []interface{} `json:"args,omitempty"`
+	Plan  string        `json:"plan,omitempty"`
+}
+
+func newExplainRes(explanations []readers.Explanation) explainRes {
+	res := explainRes{
+		Queries: []queryRes{},
+	}
+
+	for _, e := range explanations {
+		res.Queries = append(res.Queries, queryRes{Query: e.Query, Args: e.Args, Plan: e.Plan})
+	}
+
+	return res
+}
+
+func (res explainRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res explainRes) Code() int {
+	return http.StatusOK
+}
+
+func (res explainRes) Empty() bool {
+	return false
+}
+
+// batchRes maps the keys of the batch sub-queries to their results.
+type batchRes struct {
+	Results map[string]batchResultRes `json:"results"`
+}
+
+func (res batchRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res batchRes) Code() int {
+	return http.StatusOK
+}
+
+func (res batchRes) Empty() bool {
+	return false
+}
+
+// batchResultRes carries either the response of the sub-query or its error,
+// along with the status code the same query is served with on its own.
+type batchResultRes struct {
+	Status int         `json:"status"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func newBatchResultRes(result interface{}, err error) batchResultRes {
+	if err != nil {
+		return batchResultRes{Status: errorStatus(err), Error: err.Error()}
+	}
+
+	return batchResultRes{Status: http.StatusOK, Result: result}
+}
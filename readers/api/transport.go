@@ -8,15 +8,23 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/go-zoo/bone"
+	"github.com/gogo/protobuf/proto"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -25,60 +33,845 @@ import (
 )
 
 const (
-	contentType = "application/json"
-	defLimit    = 10
-	defOffset   = 0
+	contentType         = "application/json"
+	protobufContentType = "application/vnd.google.protobuf"
+	csvContentType      = "text/csv"
+	defLimit            = 10
+	defOffset           = 0
+	defSampleSize       = 10
+	maxSampleSize       = 100
+	maxIDs              = 100
+	timedBatchSize      = 100
+	shareKey            = "share"
+	lastKey             = "last"
+	rawValues           = "raw"
+	flatValues          = "flat"
+	grafanaFormat       = "grafana"
+	maxGrafanaTargets   = 10
+	maxBatchQueries     = 10
+	batchRaw            = "raw"
+	batchLatest         = "latest"
+	batchAggregate      = "aggregate"
 )
 
 var (
 	errInvalidRequest     = errors.New("received invalid request")
 	errUnauthorizedAccess = errors.New("missing or invalid credentials provided")
+	errKeyExpired         = errors.New("thing key expired")
+	errAuthUnavailable    = errors.New("authorization service unavailable")
+	errTimeout            = errors.New("read timed out")
 	auth                  mainflux.ThingsServiceClient
-	queryFields           = []string{"subtopic", "publisher", "protocol", "name", "value", "v", "vs", "vb", "vd"}
+	queryFields           = []string{"subtopic", "publisher", "protocol", "name", "value", readers.ValueKey, readers.ValueGreaterKey, readers.ValueLessKey, readers.StringValueKey, readers.BoolValueKey, "vd"}
+	renameFields          = map[string]bool{
+		"channel":     true,
+		"subtopic":    true,
+		"publisher":   true,
+		"protocol":    true,
+		"name":        true,
+		"unit":        true,
+		"value":       true,
+		"stringValue": true,
+		"boolValue":   true,
+		"dataValue":   true,
+		"valueSum":    true,
+		"time":        true,
+		"updateTime":  true,
+		"link":        true,
+		"valueType":   true,
+	}
+	csvFields       = []string{"channel", "subtopic", "publisher", "protocol", "name", "unit", "value", "stringValue", "boolValue", "dataValue", "valueSum", "time", "updateTime", "link"}
+	numericFields   = map[string]bool{"value": true, "valueSum": true, "time": true, "updateTime": true}
+	listParams      = []string{"offset", "limit", "envelope", "rename", "download", "quote", "explain", "valueFormat", "timeout", "partial", readers.AfterKey, readers.IDsKey, readers.SortOrderKey, readers.SortByKey}
+	unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	crossParams     = []string{"offset", "limit", "envelope", "rename", readers.AfterKey, readers.SortOrderKey, readers.SortByKey}
+	aggregateParams = []string{"function", "field", "nulls", "interval", "groupBy", "order", "offset", "limit", "format"}
+	validateParams  = []string{"sample"}
+	authStatus      = map[error]int{
+		errUnauthorizedAccess: http.StatusForbidden,
+		errKeyExpired:         http.StatusUnauthorized,
+		errAuthUnavailable:    http.StatusServiceUnavailable,
+	}
+	batchParams = map[string][]string{
+		batchRaw:       {"offset", "limit", "rename", "valueFormat", readers.AfterKey, readers.SortOrderKey, readers.SortByKey},
+		batchLatest:    {},
+		batchAggregate: {"function", "field", "nulls", "interval", "groupBy", "order", "offset", "limit"},
+	}
+)
+
+// Aggregations listed to the Grafana datasource.
+var (
+	grafanaFunctions = []string{readers.AggregateAvg, readers.AggregateMin, readers.AggregateMax, readers.AggregateSum, readers.AggregateCount}
+	grafanaFields    = []string{readers.FieldValue, readers.FieldValueSum}
+	grafanaGroups    = []string{"", "subtopic", "publisher", "protocol", "name"}
 )
 
-// MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(svc readers.MessageRepository, tc mainflux.ThingsServiceClient, svcName string) http.Handler {
-	auth = tc
+// unknownParamError indicates the query parameter not supported by the
+// endpoint, which would otherwise be silently ignored.
+type unknownParamError string
+
+func (e unknownParamError) Error() string {
+	return fmt.Sprintf("unknown query parameter: %s", string(e))
+}
+
+// timeSpanError indicates the time range longer than the maximum span of the
+// single query, carried by the error.
+type timeSpanError time.Duration
+
+func (e timeSpanError) Error() string {
+	return fmt.Sprintf("time range exceeds maximum span of %s", time.Duration(e))
+}
+
+// config holds the settings the requests are decoded with.
+type config struct {
+	lenient    bool
+	maxSpan    time.Duration
+	warnOffset uint64
+	tags       map[string]bool
+	admin      string
+}
+
+// MakeHandler returns a HTTP handler for API endpoints. Requests are
+// authorized by the things service using the thing key or the channel token
+// they carry, while the requests carrying the share query parameter are
+// authorized by the share link token instead. Unless lenient flag is set,
+// requests containing unknown query parameters are rejected. Non-zero
+// maximum span limits the time range of the single query. Message listings
+// paged past the non-zero offset warning threshold carry the Warning header
+// nudging clients towards the cursor pagination. Messages can be filtered
+// only by the tags whose keys are listed in the tag keys, while the filters
+// by any other tag are always rejected. Listing
+// requests carrying the explain query parameter return the queries issued to
+// the database instead of the messages, and are authorized by the admin token
+// only. Empty admin token disables the explaining. Messages of all channels
+// are listed by the admin token only, within the explicit time range. Channel
+// aggregations are additionally served to the Grafana SimpleJSON datasource.
+// Channel messages and aggregations can be queried at once by the batch of
+// sub-queries executed concurrently.
+func MakeHandler(svc readers.MessageRepository, tc mainflux.ThingsServiceClient, lenient bool, maxTimeSpan time.Duration, offsetWarning uint64, tagKeys []string, admin string, svcName string) http.Handler {
+	auth = tc
+	cfg := config{
+		lenient:    lenient,
+		maxSpan:    maxTimeSpan,
+		warnOffset: offsetWarning,
+		tags:       map[string]bool{},
+		admin:      admin,
+	}
+	for _, key := range tagKeys {
+		cfg.tags[key] = true
+	}
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(kithttp.PopulateRequestContext),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	mux := bone.New()
+	mux.Get("/channels/:chanID/messages", kithttp.NewServer(
+		listMessagesEndpoint(svc, cfg.warnOffset),
+		decodeList(cfg),
+		encodeResponse,
+		opts...,
+	))
+	mux.Get("/channels/:chanID/messages/range", kithttp.NewServer(
+		boundsEndpoint(svc),
+		decodeBounds(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Get("/channels/:chanID/messages/aggregate", kithttp.NewServer(
+		aggregateEndpoint(svc),
+		decodeAggregate(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Post("/channels/:chanID/messages/validate", kithttp.NewServer(
+		validateEndpoint(svc),
+		decodeValidate(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Post("/channels/:chanID/messages/batch", kithttp.NewServer(
+		batchEndpoint(svc, cfg.warnOffset),
+		decodeBatch(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Get("/channels/:chanID/grafana", kithttp.NewServer(
+		grafanaTestEndpoint(),
+		decodeGrafanaTest,
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Post("/channels/:chanID/grafana/search", kithttp.NewServer(
+		grafanaSearchEndpoint(),
+		decodeGrafanaSearch,
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Post("/channels/:chanID/grafana/query", kithttp.NewServer(
+		grafanaQueryEndpoint(svc),
+		decodeGrafanaQuery(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.Get("/messages", kithttp.NewServer(
+		listMessagesEndpoint(svc, cfg.warnOffset),
+		decodeCrossChannel(cfg),
+		encodeResponse,
+		opts...,
+	))
+
+	mux.GetFunc("/version", mainflux.Version(svcName))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return mux
+}
+
+func decodeList(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		explain, err := getBoolQuery(r, "explain", false)
+		if err != nil {
+			return nil, err
+		}
+
+		publisher := ""
+		if explain {
+			err = authorizeAdmin(r, cfg.admin)
+		} else {
+			publisher, err = authorize(r, chanID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := readList(r, chanID, publisher, listParams, cfg)
+		if err != nil {
+			return nil, err
+		}
+		req.explain = explain
+
+		return req, nil
+	}
+}
+
+// readList reads the listing of the authorized channel, limited to the
+// messages of the publisher, if any, from the request carrying the given
+// parameters.
+func readList(r *http.Request, chanID, publisher string, params []string, cfg config) (listMessagesReq, error) {
+	if err := checkParams(r, params, cfg.lenient); err != nil {
+		return listMessagesReq{}, err
+	}
+
+	offset, err := getQuery(r, "offset", defOffset)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	limit, err := getQuery(r, "limit", defLimit)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	envelope, err := getBoolQuery(r, "envelope", true)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	rename, err := getRenameQuery(r)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	download, err := getBoolQuery(r, "download", false)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	quote, err := getBoolQuery(r, "quote", false)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	valueFormat, err := getStringQuery(r, "valueFormat", rawValues)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	timeout, err := getDurationQuery(r, "timeout")
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	partial, err := getBoolQuery(r, "partial", false)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	query, err := readQuery(r, cfg)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+
+	if err := scopeQuery(query, publisher); err != nil {
+		return listMessagesReq{}, err
+	}
+
+	after, err := getStringQuery(r, readers.AfterKey, "")
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+	if after != "" {
+		if _, err := readers.ParseCursor(after); err != nil {
+			return listMessagesReq{}, err
+		}
+		query[readers.AfterKey] = after
+	}
+
+	ids, err := getIDsQuery(r)
+	if err != nil {
+		return listMessagesReq{}, err
+	}
+	if len(ids) > 0 {
+		query[readers.IDsKey] = strings.Join(ids, ",")
+	}
+
+	if err := readOrder(r, query); err != nil {
+		return listMessagesReq{}, err
+	}
+
+	req := listMessagesReq{
+		chanID:      chanID,
+		offset:      offset,
+		limit:       limit,
+		query:       query,
+		envelope:    envelope,
+		rename:      rename,
+		quote:       quote,
+		valueFormat: valueFormat,
+		timeout:     timeout,
+		partial:     partial,
+	}
+
+	if download {
+		req.filename = exportFilename(chanID, offset, limit, r.Header.Get("Accept"))
+	}
+
+	return req, nil
+}
+
+// decodeCrossChannel decodes the request listing the messages of all
+// channels. Query must bound the time range explicitly on both sides, rather
+// than relying on the maximum span closing it.
+func decodeCrossChannel(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if err := authorizeAdmin(r, cfg.admin); err != nil {
+			return nil, err
+		}
+
+		if err := checkParams(r, crossParams, cfg.lenient); err != nil {
+			return nil, err
+		}
+
+		q := r.URL.Query()
+		if q.Get(lastKey) == "" && (q.Get(readers.FromKey) == "" || q.Get(readers.ToKey) == "") {
+			return nil, readers.ErrUnboundedQuery
+		}
+
+		offset, err := getQuery(r, "offset", defOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		limit, err := getQuery(r, "limit", defLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope, err := getBoolQuery(r, "envelope", true)
+		if err != nil {
+			return nil, err
+		}
+
+		rename, err := getRenameQuery(r)
+		if err != nil {
+			return nil, err
+		}
+
+		query, err := readQuery(r, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := getStringQuery(r, readers.AfterKey, "")
+		if err != nil {
+			return nil, err
+		}
+		if after != "" {
+			if _, err := readers.ParseCursor(after); err != nil {
+				return nil, err
+			}
+			query[readers.AfterKey] = after
+		}
+
+		if err := readOrder(r, query); err != nil {
+			return nil, err
+		}
+
+		req := listMessagesReq{
+			chanID:      readers.AllChannels,
+			offset:      offset,
+			limit:       limit,
+			query:       query,
+			envelope:    envelope,
+			rename:      rename,
+			valueFormat: rawValues,
+		}
+
+		return req, nil
+	}
+}
+
+func decodeBounds(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		publisher, err := authorize(r, chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkParams(r, nil, cfg.lenient); err != nil {
+			return nil, err
+		}
+
+		query, err := readQuery(r, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := scopeQuery(query, publisher); err != nil {
+			return nil, err
+		}
+
+		req := boundsReq{
+			chanID: chanID,
+			query:  query,
+		}
+
+		return req, nil
+	}
+}
+
+func decodeAggregate(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		publisher, err := authorize(r, chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		return readAggregate(r, chanID, publisher, aggregateParams, cfg)
+	}
+}
+
+// readAggregate reads the aggregation of the authorized channel, limited to
+// the messages of the publisher, if any, from the request carrying the given
+// parameters.
+func readAggregate(r *http.Request, chanID, publisher string, params []string, cfg config) (aggregateReq, error) {
+	if err := checkParams(r, params, cfg.lenient); err != nil {
+		return aggregateReq{}, err
+	}
+
+	fn, err := getStringQuery(r, "function", "")
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	field, err := getStringQuery(r, "field", readers.FieldValue)
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	nulls, err := getStringQuery(r, "nulls", string(readers.SkipNulls))
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	interval, err := getStringQuery(r, "interval", "")
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	groupBy, err := getStringQuery(r, "groupBy", "")
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	order, err := getStringQuery(r, "order", "")
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	format, err := getStringQuery(r, "format", "")
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	// Groups are paged only if the limit is given.
+	offset, err := getQuery(r, "offset", 0)
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	limit, err := getQuery(r, "limit", 0)
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	query, err := readQuery(r, cfg)
+	if err != nil {
+		return aggregateReq{}, err
+	}
+
+	if err := scopeQuery(query, publisher); err != nil {
+		return aggregateReq{}, err
+	}
+
+	req := aggregateReq{
+		chanID: chanID,
+		aggregation: readers.Aggregation{
+			Function:   fn,
+			Field:      field,
+			NullPolicy: readers.NullPolicy(nulls),
+			Interval:   interval,
+			GroupBy:    groupBy,
+			Order:      order,
+			Offset:     offset,
+			Limit:      limit,
+		},
+		query:  query,
+		format: format,
+	}
+
+	return req, nil
+}
+
+func decodeGrafanaTest(_ context.Context, r *http.Request) (interface{}, error) {
+	chanID := bone.GetValue(r, "chanID")
+	if chanID == "" {
+		return nil, errInvalidRequest
+	}
+
+	if _, err := authorize(r, chanID); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func decodeGrafanaSearch(_ context.Context, r *http.Request) (interface{}, error) {
+	chanID := bone.GetValue(r, "chanID")
+	if chanID == "" {
+		return nil, errInvalidRequest
+	}
+
+	if _, err := authorize(r, chanID); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, errInvalidRequest
+	}
+
+	req := grafanaSearchReq{
+		chanID: chanID,
+		target: body.Target,
+	}
+
+	return req, nil
+}
+
+// decodeGrafanaQuery decodes the SimpleJSON query of the time series of the
+// targets within the range. Buckets are sized by the smallest interval
+// covering the interval suggested by Grafana.
+func decodeGrafanaQuery(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		publisher, err := authorize(r, chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		var body struct {
+			Range struct {
+				From time.Time `json:"from"`
+				To   time.Time `json:"to"`
+			} `json:"range"`
+			IntervalMs uint64 `json:"intervalMs"`
+			Targets    []struct {
+				Target string `json:"target"`
+			} `json:"targets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errInvalidRequest
+		}
+
+		query := map[string]string{}
+		if !body.Range.From.IsZero() {
+			query[readers.FromKey] = fmtTime(body.Range.From)
+		}
+		if !body.Range.To.IsZero() {
+			query[readers.ToKey] = fmtTime(body.Range.To)
+		}
+
+		tr, err := readers.ParseTimeRange(query)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := limitTimeRange(tr, query, cfg.maxSpan); err != nil {
+			return nil, err
+		}
+
+		if err := scopeQuery(query, publisher); err != nil {
+			return nil, err
+		}
+
+		req := grafanaQueryReq{
+			chanID:   chanID,
+			interval: grafanaInterval(time.Duration(body.IntervalMs) * time.Millisecond),
+			query:    query,
+		}
+		for _, t := range body.Targets {
+			req.targets = append(req.targets, t.Target)
+		}
+
+		return req, nil
+	}
+}
+
+// grafanaInterval returns the smallest aggregation interval that is at least
+// as long as the given one.
+func grafanaInterval(d time.Duration) string {
+	switch {
+	case d <= time.Minute:
+		return readers.IntervalMinute
+	case d <= time.Hour:
+		return readers.IntervalHour
+	case d <= 24*time.Hour:
+		return readers.IntervalDay
+	case d <= 7*24*time.Hour:
+		return readers.IntervalWeek
+	default:
+		return readers.IntervalMonth
+	}
+}
+
+func decodeValidate(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		publisher, err := authorize(r, chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkParams(r, validateParams, cfg.lenient); err != nil {
+			return nil, err
+		}
+
+		sample, err := getQuery(r, "sample", defSampleSize)
+		if err != nil {
+			return nil, err
+		}
+
+		query, err := readQuery(r, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := scopeQuery(query, publisher); err != nil {
+			return nil, err
+		}
+
+		var profile readers.Profile
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			return nil, readers.ErrInvalidProfile
+		}
+
+		req := validateReq{
+			chanID:  chanID,
+			profile: profile,
+			sample:  sample,
+			query:   query,
+		}
+
+		return req, nil
+	}
+}
+
+// decodeBatch decodes the batch of the keyed sub-queries of the channel, which
+// is authorized once for all of them. Sub-query is given by its type and the
+// query parameters of the endpoint serving the same query on its own, e.g.
+// the aggregate sub-query takes the parameters of the aggregation endpoint.
+// Sub-query failing to decode is reported in its result, without failing the
+// rest of the batch.
+func decodeBatch(cfg config) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		chanID := bone.GetValue(r, "chanID")
+		if chanID == "" {
+			return nil, errInvalidRequest
+		}
+
+		publisher, err := authorize(r, chanID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Sub-queries carry their own parameters, so the batch is only
+		// authorized by the share link token, if any.
+		keys := []string{}
+		for key := range r.URL.Query() {
+			if key != shareKey {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 0 && !cfg.lenient {
+			sort.Strings(keys)
+			return nil, unknownParamError(keys[0])
+		}
+
+		var body struct {
+			Queries map[string]struct {
+				Type   string            `json:"type"`
+				Params map[string]string `json:"params"`
+			} `json:"queries"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errInvalidRequest
+		}
+
+		req := batchReq{
+			chanID:  chanID,
+			queries: map[string]batchQuery{},
+		}
+		for key, q := range body.Queries {
+			req.queries[key] = readBatchQuery(subRequest(r, q.Params), chanID, publisher, q.Type, cfg)
+		}
 
-	opts := []kithttp.ServerOption{
-		kithttp.ServerErrorEncoder(encodeError),
+		return req, nil
 	}
+}
 
-	mux := bone.New()
-	mux.Get("/channels/:chanID/messages", kithttp.NewServer(
-		listMessagesEndpoint(svc),
-		decodeList,
-		encodeResponse,
-		opts...,
-	))
+// readBatchQuery reads the sub-query of the given type from the request
+// carrying its parameters. Latest sub-query reads the newest message, by time
+// regardless of the repository default order.
+func readBatchQuery(r *http.Request, chanID, publisher, typ string, cfg config) batchQuery {
+	params, ok := batchParams[typ]
+	if !ok {
+		return batchQuery{err: errInvalidRequest}
+	}
 
-	mux.GetFunc("/version", mainflux.Version(svcName))
-	mux.Handle("/metrics", promhttp.Handler())
+	if typ == batchAggregate {
+		req, err := readAggregate(r, chanID, publisher, params, cfg)
+		req.format = ""
+		return batchQuery{req: req, err: err}
+	}
 
-	return mux
+	req, err := readList(r, chanID, publisher, params, cfg)
+	if typ == batchLatest && err == nil {
+		req.offset, req.limit = 0, 1
+		req.query[readers.SortByKey] = readers.SortTime
+		req.query[readers.SortOrderKey] = readers.SortDesc
+	}
+
+	return batchQuery{req: req, err: err}
 }
 
-func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
-	chanID := bone.GetValue(r, "chanID")
-	if chanID == "" {
-		return nil, errInvalidRequest
+// subRequest returns the copy of the request carrying the given query
+// parameters instead of its own.
+func subRequest(r *http.Request, params map[string]string) *http.Request {
+	query := url.Values{}
+	for name, value := range params {
+		query.Set(name, value)
 	}
 
-	if err := authorize(r, chanID); err != nil {
-		return nil, err
+	u := *r.URL
+	u.RawQuery = query.Encode()
+
+	sub := r.WithContext(r.Context())
+	sub.URL = &u
+	return sub
+}
+
+// checkParams returns unknownParamError naming the first query parameter
+// that is neither the message filter nor one of the endpoint parameters. Tag
+// filters are checked against the allow-list while reading the query. In
+// lenient mode, unknown parameters are ignored.
+func checkParams(r *http.Request, params []string, lenient bool) error {
+	if lenient {
+		return nil
 	}
 
-	offset, err := getQuery(r, "offset", defOffset)
-	if err != nil {
-		return nil, err
+	known := map[string]bool{
+		readers.FilterKey: true,
+		readers.FromKey:   true,
+		readers.ToKey:     true,
+		lastKey:           true,
+		shareKey:          true,
+	}
+	for _, name := range queryFields {
+		known[name] = true
+	}
+	for _, name := range params {
+		known[name] = true
 	}
 
-	limit, err := getQuery(r, "limit", defLimit)
-	if err != nil {
-		return nil, err
+	keys := []string{}
+	for key := range r.URL.Query() {
+		if !known[key] && !strings.HasPrefix(key, readers.TagPrefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
 	}
 
+	sort.Strings(keys)
+	return unknownParamError(keys[0])
+}
+
+func readQuery(r *http.Request, cfg config) (map[string]string, error) {
 	query := map[string]string{}
 	for _, name := range queryFields {
 		if value := bone.GetQuery(r, name); len(value) == 1 {
@@ -86,24 +879,177 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 		}
 	}
 
-	req := listMessagesReq{
-		chanID: chanID,
-		offset: offset,
-		limit:  limit,
-		query:  query,
+	// Filter is read directly from the URL, since the router splits comma
+	// separated values.
+	vals := r.URL.Query()[readers.FilterKey]
+	if len(vals) > 1 {
+		return nil, errInvalidRequest
 	}
 
-	return req, nil
+	if len(vals) == 1 {
+		if _, err := readers.ParseFilter(vals[0]); err != nil {
+			return nil, err
+		}
+		query[readers.FilterKey] = vals[0]
+	}
+
+	if err := readTags(r, query, cfg.tags); err != nil {
+		return nil, err
+	}
+
+	if err := readTimeRange(r, query, cfg.maxSpan); err != nil {
+		return nil, err
+	}
+
+	if _, err := readers.ParseValueFilter(query); err != nil {
+		return nil, err
+	}
+
+	return query, nil
 }
 
-func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	w.Header().Set("Content-Type", contentType)
+// readTags adds the tag filters to the query. Filters by the tags that are not
+// allowed are rejected even in lenient mode, since ignoring them would return
+// the messages the client filtered out.
+func readTags(r *http.Request, query map[string]string, allowed map[string]bool) error {
+	for name, vals := range r.URL.Query() {
+		if !strings.HasPrefix(name, readers.TagPrefix) {
+			continue
+		}
+
+		if !allowed[strings.TrimPrefix(name, readers.TagPrefix)] {
+			return readers.ErrUnknownTag
+		}
+
+		if len(vals) != 1 || vals[0] == "" {
+			return errInvalidRequest
+		}
+		query[name] = vals[0]
+	}
+
+	return nil
+}
+
+// readTimeRange adds the time range bounds to the query. Range is given either
+// by the from and to timestamps or relative to the current time, by the Go
+// duration of the last period, e.g. last=1h.
+func readTimeRange(r *http.Request, query map[string]string, maxSpan time.Duration) error {
+	for _, name := range []string{readers.FromKey, readers.ToKey} {
+		vals := bone.GetQuery(r, name)
+		if len(vals) > 1 {
+			return errInvalidRequest
+		}
+		if len(vals) == 1 {
+			query[name] = vals[0]
+		}
+	}
+
+	last := bone.GetQuery(r, lastKey)
+	switch {
+	case len(last) > 1:
+		return errInvalidRequest
+	case len(last) == 1:
+		if query[readers.FromKey] != "" || query[readers.ToKey] != "" {
+			return errInvalidRequest
+		}
+
+		d, err := time.ParseDuration(last[0])
+		if err != nil || d <= 0 {
+			return errInvalidRequest
+		}
+
+		now := time.Now()
+		query[readers.FromKey] = fmtTime(now.Add(-d))
+		query[readers.ToKey] = fmtTime(now)
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return err
+	}
+
+	return limitTimeRange(tr, query, maxSpan)
+}
+
+// limitTimeRange rejects the time range longer than the maximum span, so that
+// the clients page through the time instead of scanning the whole channel.
+// Open range is closed to span the maximum period, ending at the current time
+// if it is open on both sides.
+func limitTimeRange(tr readers.TimeRange, query map[string]string, maxSpan time.Duration) error {
+	if maxSpan <= 0 {
+		return nil
+	}
+
+	span := maxSpan.Seconds()
+	switch {
+	case tr.From == nil && tr.To == nil:
+		now := time.Now()
+		query[readers.FromKey] = fmtTime(now.Add(-maxSpan))
+		query[readers.ToKey] = fmtTime(now)
+	case tr.From == nil:
+		query[readers.FromKey] = strconv.FormatFloat(*tr.To-span, 'f', -1, 64)
+	case tr.To == nil:
+		query[readers.ToKey] = strconv.FormatFloat(*tr.From+span, 'f', -1, 64)
+	case *tr.To-*tr.From > span:
+		return timeSpanError(maxSpan)
+	}
+
+	return nil
+}
+
+// fmtTime formats the time as the message timestamp, i.e. seconds since the
+// Unix epoch.
+func fmtTime(t time.Time) string {
+	secs := float64(t.UnixNano()) / float64(time.Second)
+	return strconv.FormatFloat(secs, 'f', -1, 64)
+}
+
+// exportFilename returns the name of the file the requested page is
+// downloaded as, e.g. channel-<id>-<offset>-<offset+limit>.json. Characters
+// other than ASCII letters, digits, dashes and underscores are stripped from
+// the channel ID, so that the name can't break out of the header value.
+func exportFilename(chanID string, offset, limit uint64, accept string) string {
+	ext := "json"
+	switch {
+	case strings.Contains(accept, protobufContentType):
+		ext = "pb"
+	case strings.Contains(accept, csvContentType):
+		ext = "csv"
+	}
 
-	if ar, ok := response.(mainflux.Response); ok {
+	id := unsafeFileChars.ReplaceAllString(chanID, "_")
+	return fmt.Sprintf("channel-%s-%d-%d.%s", id, offset, offset+limit, ext)
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	ar, ok := response.(mainflux.Response)
+	if ok {
 		for k, v := range ar.Headers() {
 			w.Header().Set(k, v)
 		}
+	}
+
+	if acceptsProtobuf(ctx) {
+		switch res := response.(type) {
+		case pageRes:
+			return encodeProtobuf(w, res.Code(), res.Messages.messages)
+		case messagesRes:
+			return encodeProtobuf(w, res.Code(), res.messages)
+		}
+	}
+
+	if accepts(ctx, csvContentType) {
+		switch res := response.(type) {
+		case pageRes:
+			return encodeCSV(w, res.Code(), res.Messages)
+		case messagesRes:
+			return encodeCSV(w, res.Code(), res.messageList)
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
 
+	if ok {
 		w.WriteHeader(ar.Code())
 
 		if ar.Empty() {
@@ -114,39 +1060,291 @@ func encodeResponse(_ context.Context, w http.ResponseWriter, response interface
 	return json.NewEncoder(w).Encode(response)
 }
 
+// encodeProtobuf writes messages as a stream of length-delimited
+// mainflux.Message protobufs, i.e. every message is prefixed with its
+// varint encoded size.
+func encodeProtobuf(w http.ResponseWriter, code int, messages []mainflux.Message) error {
+	buf := []byte{}
+	for _, msg := range messages {
+		data, err := msg.Marshal()
+		if err != nil {
+			return err
+		}
+
+		buf = append(buf, proto.EncodeVarint(uint64(len(data)))...)
+		buf = append(buf, data...)
+	}
+
+	w.Header().Set("Content-Type", protobufContentType)
+	w.WriteHeader(code)
+	_, err := w.Write(buf)
+	return err
+}
+
+// encodeCSV writes messages as the CSV file with the header row, a column
+// per message field and a row per message. Columns are named after the JSON
+// keys of the fields, so they are renamed the same way.
+func encodeCSV(w http.ResponseWriter, code int, ml messageList) error {
+	header := []string{}
+	for _, field := range csvFields {
+		if key, ok := ml.rename[field]; ok {
+			field = key
+		}
+		header = append(header, field)
+	}
+	if len(ml.cursors) > 0 {
+		header = append(header, seqKey)
+	}
+
+	buf := &bytes.Buffer{}
+	writeCSVRow(buf, header, nil, false)
+	for i, msg := range ml.messages {
+		row, numeric := csvRow(msg)
+		if i < len(ml.cursors) {
+			row = append(row, ml.cursors[i].String())
+			numeric = append(numeric, false)
+		}
+		writeCSVRow(buf, row, numeric, ml.quote)
+	}
+
+	w.Header().Set("Content-Type", csvContentType)
+	w.WriteHeader(code)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// csvRow returns the message fields in the order of the CSV columns, along
+// with the flags marking the numeric ones. Fields the message doesn't carry
+// are left empty.
+func csvRow(msg mainflux.Message) ([]string, []bool) {
+	var value, stringValue, boolValue, dataValue, valueSum string
+	switch msg.Value.(type) {
+	case *mainflux.Message_FloatValue:
+		value = formatFloat(msg.GetFloatValue())
+	case *mainflux.Message_StringValue:
+		stringValue = msg.GetStringValue()
+	case *mainflux.Message_BoolValue:
+		boolValue = strconv.FormatBool(msg.GetBoolValue())
+	case *mainflux.Message_DataValue:
+		dataValue = msg.GetDataValue()
+	}
+
+	if msg.GetValueSum() != nil {
+		valueSum = formatFloat(msg.GetValueSum().GetValue())
+	}
+
+	row := []string{
+		msg.Channel,
+		msg.Subtopic,
+		msg.Publisher,
+		msg.Protocol,
+		msg.Name,
+		msg.Unit,
+		value,
+		stringValue,
+		boolValue,
+		dataValue,
+		valueSum,
+		formatFloat(msg.Time),
+		formatFloat(msg.UpdateTime),
+		msg.Link,
+	}
+
+	numeric := make([]bool, len(row))
+	for i, field := range csvFields {
+		numeric[i] = numericFields[field]
+	}
+
+	return row, numeric
+}
+
+// writeCSVRow writes the CSV record. Fields containing separators, quotes or
+// line breaks are quoted, as well as the non-empty numeric fields if quote
+// flag is set, since strict importers treat the quoted fields as text.
+func writeCSVRow(buf *bytes.Buffer, fields []string, numeric []bool, quote bool) {
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		force := quote && i < len(numeric) && numeric[i] && field != ""
+		if force || strings.ContainsAny(field, ",\"\r\n") || strings.HasPrefix(field, " ") {
+			buf.WriteByte('"')
+			buf.WriteString(strings.Replace(field, `"`, `""`, -1))
+			buf.WriteByte('"')
+			continue
+		}
+		buf.WriteString(field)
+	}
+	buf.WriteByte('\n')
+}
+
+// formatFloat renders the float in the fixed-point notation, using dot as
+// the decimal separator and the fewest digits needed to represent the value
+// exactly. Formatting doesn't depend on the locale, nor on the magnitude of
+// the value, which would otherwise switch to the exponent notation.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func acceptsProtobuf(ctx context.Context) bool {
+	return accepts(ctx, protobufContentType)
+}
+
+func accepts(ctx context.Context, ct string) bool {
+	accept, ok := ctx.Value(kithttp.ContextKeyRequestAccept).(string)
+	if !ok {
+		return false
+	}
+
+	return strings.Contains(accept, ct)
+}
+
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	switch e := err.(type) {
+	case unknownParamError:
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorRes{Err: e.Error()})
+		return
+	case timeSpanError:
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorRes{Err: e.Error(), MaxSpan: time.Duration(e).Seconds()})
+		return
+	}
+
 	switch err {
 	case nil:
-	case errInvalidRequest:
-		w.WriteHeader(http.StatusBadRequest)
-	case errUnauthorizedAccess:
-		w.WriteHeader(http.StatusForbidden)
+	case errUnauthorizedAccess, errKeyExpired, errAuthUnavailable:
+		// Authorization failures carry the reason, so that they are never
+		// mistaken for the empty channel.
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(authStatus[err])
+		json.NewEncoder(w).Encode(errorRes{Err: err.Error()})
+	default:
+		w.WriteHeader(errorStatus(err))
+	}
+}
+
+// errorStatus returns the HTTP status code the error is served with.
+func errorStatus(err error) int {
+	switch err.(type) {
+	case unknownParamError, timeSpanError:
+		return http.StatusBadRequest
+	}
+
+	if status, ok := authStatus[err]; ok {
+		return status
+	}
+
+	switch err {
+	case errInvalidRequest, readers.ErrInvalidAggregation, readers.ErrInvalidFilter, readers.ErrUnsupportedFilter, readers.ErrTooManyRows, readers.ErrInvalidTimeRange,
+		readers.ErrInvalidCursor, readers.ErrUnsupportedCursor, readers.ErrTooManyGroups, readers.ErrUnsupportedGrouping, readers.ErrUnsupportedDistinct,
+		readers.ErrUnknownTag, readers.ErrInvalidTagKey, readers.ErrUnsupportedTags, readers.ErrInvalidProfile,
+		readers.ErrMissingChannel, readers.ErrUnboundedQuery, readers.ErrUnsupportedCrossChannel,
+		readers.ErrInvalidOrder, readers.ErrUnsupportedSort, readers.ErrInvalidValueFilter, readers.ErrInvalidIdentifier:
+		return http.StatusBadRequest
+	case readers.ErrNullValue:
+		return http.StatusUnprocessableEntity
+	case errTimeout:
+		return http.StatusGatewayTimeout
 	default:
-		w.WriteHeader(http.StatusInternalServerError)
+		return http.StatusInternalServerError
 	}
 }
 
-func authorize(r *http.Request, chanID string) error {
+// authorize grants read access to the channel. If the thing reading the
+// publisher-scoped channel is restricted to its own messages, the thing ID is
+// returned as the publisher the messages are limited to.
+func authorize(r *http.Request, chanID string) (string, error) {
+	if shares, ok := r.URL.Query()[shareKey]; ok {
+		return "", authorizeShared(shares, chanID)
+	}
+
 	token := r.Header.Get("Authorization")
 	if token == "" {
-		return errUnauthorizedAccess
+		return "", errUnauthorizedAccess
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	_, err := auth.CanAccess(ctx, &mainflux.AccessReq{Token: token, ChanID: chanID})
+	id, err := auth.CanReadMessages(ctx, &mainflux.AccessReq{Token: token, ChanID: chanID})
 	if err != nil {
-		e, ok := status.FromError(err)
-		if ok && e.Code() == codes.PermissionDenied {
-			return errUnauthorizedAccess
-		}
-		return err
+		return "", authError(err)
+	}
+
+	if !id.GetPublisherScoped() {
+		return "", nil
+	}
+
+	return id.GetValue(), nil
+}
+
+// authorizeAdmin grants access to the request carrying the admin token.
+func authorizeAdmin(r *http.Request, admin string) error {
+	token := r.Header.Get("Authorization")
+	if admin == "" || subtle.ConstantTimeCompare([]byte(token), []byte(admin)) != 1 {
+		return errUnauthorizedAccess
+	}
+
+	return nil
+}
+
+// scopeQuery limits the query to the messages of the publisher, if any. The
+// query filtering the messages of another publisher is rejected.
+func scopeQuery(query map[string]string, publisher string) error {
+	if publisher == "" {
+		return nil
+	}
+
+	if p, ok := query["publisher"]; ok && p != publisher {
+		return errUnauthorizedAccess
 	}
 
+	query["publisher"] = publisher
 	return nil
 }
 
+// authorizeShared grants read access to the channel if the share link token
+// carried by the request is valid for it.
+func authorizeShared(shares []string, chanID string) error {
+	if len(shares) != 1 || shares[0] == "" {
+		return errUnauthorizedAccess
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := auth.CanReadShared(ctx, &mainflux.AccessReq{Token: shares[0], ChanID: chanID})
+	return authError(err)
+}
+
+// authError translates the error of the things service call, so that the
+// request failing authorization is never served.
+func authError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	e, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch e.Code() {
+	case codes.PermissionDenied, codes.NotFound, codes.InvalidArgument:
+		return errUnauthorizedAccess
+	case codes.Unauthenticated:
+		return errKeyExpired
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return errAuthUnavailable
+	default:
+		return err
+	}
+}
+
 func getQuery(req *http.Request, name string, fallback uint64) (uint64, error) {
 	vals := bone.GetQuery(req, name)
 	if len(vals) == 0 {
@@ -164,3 +1362,116 @@ func getQuery(req *http.Request, name string, fallback uint64) (uint64, error) {
 
 	return uint64(val), nil
 }
+
+func getBoolQuery(req *http.Request, name string, fallback bool) (bool, error) {
+	vals := bone.GetQuery(req, name)
+	if len(vals) == 0 {
+		return fallback, nil
+	}
+
+	if len(vals) > 1 {
+		return false, errInvalidRequest
+	}
+
+	val, err := strconv.ParseBool(vals[0])
+	if err != nil {
+		return false, errInvalidRequest
+	}
+
+	return val, nil
+}
+
+// getRenameQuery parses the rename query parameter formatted as the comma
+// separated list of field:key pairs, e.g. rename=time:ts,value:val.
+func getRenameQuery(req *http.Request) (map[string]string, error) {
+	// Comma separated query values are already split by the router.
+	vals := bone.GetQuery(req, "rename")
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	rename := map[string]string{}
+	for _, pair := range vals {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 {
+			return nil, errInvalidRequest
+		}
+
+		if _, ok := rename[parts[0]]; ok {
+			return nil, errInvalidRequest
+		}
+		rename[parts[0]] = parts[1]
+	}
+
+	return rename, nil
+}
+
+// getIDsQuery returns the cursors of the messages looked up by their IDs. The
+// parameter is repeatable, and its comma separated values are split by the
+// router already.
+func getIDsQuery(req *http.Request) ([]string, error) {
+	vals := bone.GetQuery(req, readers.IDsKey)
+	if len(vals) > maxIDs {
+		return nil, errInvalidRequest
+	}
+
+	for _, val := range vals {
+		c, err := readers.ParseCursor(val)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.ID == "" {
+			return nil, readers.ErrInvalidCursor
+		}
+	}
+
+	return vals, nil
+}
+
+// getDurationQuery parses the positive duration, e.g. timeout=2.5s. Zero
+// duration is returned if the parameter is omitted.
+func getDurationQuery(req *http.Request, name string) (time.Duration, error) {
+	val, err := getStringQuery(req, name, "")
+	if err != nil || val == "" {
+		return 0, err
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, errInvalidRequest
+	}
+
+	return d, nil
+}
+
+// readOrder copies the listing order of the request, if any, to the query.
+// Order missing from the request is left to the repository default, i.e.
+// the newest messages first.
+func readOrder(r *http.Request, query map[string]string) error {
+	for _, key := range []string{readers.SortOrderKey, readers.SortByKey} {
+		val, err := getStringQuery(r, key, "")
+		if err != nil {
+			return err
+		}
+		if val != "" {
+			query[key] = val
+		}
+	}
+
+	_, err := readers.ParseOrder(query)
+	return err
+}
+
+func getStringQuery(req *http.Request, name string, fallback string) (string, error) {
+	vals := bone.GetQuery(req, name)
+	if len(vals) == 0 {
+		return fallback, nil
+	}
+
+	if len(vals) > 1 {
+		return "", errInvalidRequest
+	}
+
+	return vals[0], nil
+}
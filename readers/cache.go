@@ -0,0 +1,135 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ MessageRepository = (*cachedRepository)(nil)
+
+type cacheEntry struct {
+	key     string
+	page    MessagesPage
+	expires time.Time
+}
+
+type cachedRepository struct {
+	repo    MessageRepository
+	ttl     time.Duration
+	size    int
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewCachedRepository returns message repository that caches the pages read
+// from the wrapped repository for the given TTL, so that identical queries
+// issued within it (e.g. by many viewers of the same dashboard) hit the
+// database only once. Cache holds at most size pages, evicting the oldest
+// ones first. Invalidation is purely TTL based, so the cached pages may miss
+// up to TTL worth of the latest messages. Bounds and aggregations are not
+// cached.
+func NewCachedRepository(repo MessageRepository, ttl time.Duration, size int) MessageRepository {
+	return &cachedRepository{
+		repo:    repo,
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (cr *cachedRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (MessagesPage, error) {
+	key := cacheKey(chanID, offset, limit, query)
+	if page, ok := cr.get(key); ok {
+		return page, nil
+	}
+
+	page, err := cr.repo.ReadAll(chanID, offset, limit, query)
+	if err != nil {
+		return MessagesPage{}, err
+	}
+
+	cr.put(key, page)
+	return page, nil
+}
+
+func (cr *cachedRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	return cr.repo.Bounds(chanID, query)
+}
+
+func (cr *cachedRepository) Aggregate(chanID string, agg Aggregation, query map[string]string) (AggregationResult, error) {
+	return cr.repo.Aggregate(chanID, agg, query)
+}
+
+func (cr *cachedRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]Explanation, error) {
+	return cr.repo.Explain(chanID, offset, limit, query)
+}
+
+func (cr *cachedRepository) get(key string) (MessagesPage, bool) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	elem, ok := cr.entries[key]
+	if !ok {
+		return MessagesPage{}, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expires) {
+		cr.remove(elem)
+		return MessagesPage{}, false
+	}
+
+	return entry.page, true
+}
+
+func (cr *cachedRepository) put(key string, page MessagesPage) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if elem, ok := cr.entries[key]; ok {
+		cr.remove(elem)
+	}
+
+	// All the entries have the same TTL, so the oldest entry is the one
+	// that expires first.
+	for cr.order.Len() >= cr.size && cr.order.Len() > 0 {
+		cr.remove(cr.order.Front())
+	}
+
+	entry := &cacheEntry{
+		key:     key,
+		page:    page,
+		expires: time.Now().Add(cr.ttl),
+	}
+	cr.entries[key] = cr.order.PushBack(entry)
+}
+
+func (cr *cachedRepository) remove(elem *list.Element) {
+	cr.order.Remove(elem)
+	delete(cr.entries, elem.Value.(*cacheEntry).key)
+}
+
+// cacheKey normalizes the query, so that the same query yields the same key
+// regardless of the order of its parameters.
+func cacheKey(chanID string, offset, limit uint64, query map[string]string) string {
+	params := make([]string, 0, len(query))
+	for k, v := range query {
+		params = append(params, fmt.Sprintf("%q=%q", k, v))
+	}
+	sort.Strings(params)
+
+	return fmt.Sprintf("%q:%d:%d:%s", chanID, offset, limit, strings.Join(params, "&"))
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts the reads and returns page with a single message
+// published by the number of the read.
+type countingRepository struct {
+	reads int
+}
+
+func (repo *countingRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
+	repo.reads++
+	msg := mainflux.Message{Channel: chanID, Publisher: fmt.Sprint(repo.reads)}
+	return readers.MessagesPage{Offset: offset, Limit: limit, Total: 1, Messages: []mainflux.Message{msg}}, nil
+}
+
+func (repo *countingRepository) Bounds(string, map[string]string) (float64, float64, error) {
+	return 0, 0, nil
+}
+
+func (repo *countingRepository) Aggregate(string, readers.Aggregation, map[string]string) (readers.AggregationResult, error) {
+	return readers.AggregationResult{}, nil
+}
+
+func (repo *countingRepository) Explain(string, uint64, uint64, map[string]string) ([]readers.Explanation, error) {
+	return nil, nil
+}
+
+func TestCachedReadAll(t *testing.T) {
+	ttl := 50 * time.Millisecond
+	query := map[string]string{"subtopic": "temp", "publisher": "1"}
+
+	cases := []struct {
+		desc   string
+		chanID string
+		offset uint64
+		query  map[string]string
+		wait   time.Duration
+		reads  int
+	}{
+		{
+			desc:   "read page",
+			chanID: "1",
+			query:  query,
+			reads:  1,
+		},
+		{
+			desc:   "read same page within TTL",
+			chanID: "1",
+			query:  map[string]string{"publisher": "1", "subtopic": "temp"},
+			reads:  1,
+		},
+		{
+			desc:   "read page with other offset",
+			chanID: "1",
+			offset: 10,
+			query:  query,
+			reads:  2,
+		},
+		{
+			desc:   "read page with other filter",
+			chanID: "1",
+			query:  map[string]string{"subtopic": "temp"},
+			reads:  3,
+		},
+		{
+			desc:   "read page of other channel",
+			chanID: "2",
+			query:  query,
+			reads:  4,
+		},
+		{
+			desc:   "read same page after TTL",
+			chanID: "1",
+			query:  query,
+			wait:   2 * ttl,
+			reads:  5,
+		},
+	}
+
+	repo := &countingRepository{}
+	cache := readers.NewCachedRepository(repo, ttl, 10)
+
+	for _, tc := range cases {
+		time.Sleep(tc.wait)
+		_, err := cache.ReadAll(tc.chanID, tc.offset, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.reads, repo.reads, fmt.Sprintf("%s: expected %d reads got %d", tc.desc, tc.reads, repo.reads))
+	}
+}
+
+func TestCachedReadAllEviction(t *testing.T) {
+	repo := &countingRepository{}
+	cache := readers.NewCachedRepository(repo, time.Minute, 2)
+
+	for _, chanID := range []string{"1", "2", "3"} {
+		_, err := cache.ReadAll(chanID, 0, 10, nil)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	page, err := cache.ReadAll("3", 0, 10, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, "3", page.Messages[0].Publisher, fmt.Sprintf("expected cached page of read %d got %s", 3, page.Messages[0].Publisher))
+
+	_, err = cache.ReadAll("1", 0, 10, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 4, repo.reads, fmt.Sprintf("expected evicted page to be read again, got %d reads", repo.reads))
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cassandra
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAfterQueries(t *testing.T) {
+	id := gocql.TimeUUID()
+
+	cases := []struct {
+		desc  string
+		after string
+		query map[string]string
+		conds []string
+		vals  [][]interface{}
+		err   error
+	}{
+		{
+			desc:  "read after cursor without ID",
+			after: "1000",
+			query: map[string]string{},
+			conds: []string{"channel = ? AND time >= ?"},
+			vals:  [][]interface{}{{"1", 1000.0}},
+		},
+		{
+			desc:  "read after cursor",
+			after: fmt.Sprintf("1000_%s", id),
+			query: map[string]string{},
+			conds: []string{"channel = ? AND time = ? AND id < ?", "channel = ? AND time > ?"},
+			vals:  [][]interface{}{{"1", 1000.0, id}, {"1", 1000.0}},
+		},
+		{
+			desc:  "read after cursor within time range",
+			after: fmt.Sprintf("1000_%s", id),
+			query: map[string]string{readers.FromKey: "500", readers.ToKey: "2000"},
+			conds: []string{"channel = ? AND time = ? AND id < ?", "channel = ? AND time > ? AND time < ?"},
+			vals:  [][]interface{}{{"1", 1000.0, id}, {"1", 1000.0, 2000.0}},
+		},
+		{
+			desc:  "read after cursor preceding time range",
+			after: fmt.Sprintf("1000_%s", id),
+			query: map[string]string{readers.FromKey: "1500"},
+			conds: []string{"channel = ? AND time >= ?"},
+			vals:  [][]interface{}{{"1", 1500.0}},
+		},
+		{
+			desc:  "read after cursor with filter",
+			after: "1000",
+			query: map[string]string{"publisher": "2"},
+			conds: []string{"channel = ? AND publisher = ? AND time >= ?"},
+			vals:  [][]interface{}{{"1", "2", 1000.0}},
+		},
+		{
+			desc:  "read after cursor with malformed ID",
+			after: "1000_abc",
+			query: map[string]string{},
+			err:   readers.ErrInvalidCursor,
+		},
+		{
+			desc:  "read after malformed cursor",
+			after: "abc",
+			query: map[string]string{},
+			err:   readers.ErrInvalidCursor,
+		},
+		{
+			desc:  "read after cursor looking up messages",
+			after: "1000",
+			query: map[string]string{readers.IDsKey: fmt.Sprintf("1000_%s", id)},
+			err:   readers.ErrUnsupportedCursor,
+		},
+	}
+
+	for _, tc := range cases {
+		steps, err := afterQueries("1", tc.after, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		require.Len(t, steps, len(tc.conds), fmt.Sprintf("%s: expected %d steps got %d", tc.desc, len(tc.conds), len(steps)))
+		for i, s := range steps {
+			asc := readers.Order{Field: readers.SortTime}
+			assert.Equal(t, buildSelectQuery(tc.conds[i], asc), s.selectCQL, fmt.Sprintf("%s: unexpected select statement of step %d", tc.desc, i))
+			assert.Equal(t, buildCountQuery(tc.conds[i]), s.countCQL, fmt.Sprintf("%s: unexpected count statement of step %d", tc.desc, i))
+			assert.Equal(t, tc.vals[i], s.vals, fmt.Sprintf("%s: expected values %v of step %d got %v", tc.desc, tc.vals[i], i, s.vals))
+		}
+	}
+}
@@ -9,6 +9,7 @@ package cassandra
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gocql/gocql"
 	"github.com/mainflux/mainflux"
@@ -18,55 +19,243 @@ import (
 var _ readers.MessageRepository = (*cassandraRepository)(nil)
 
 type cassandraRepository struct {
-	session *gocql.Session
+	session  *gocql.Session
+	maxRows  uint64
+	pageSize int
 }
 
-// New instantiates Cassandra message repository.
-func New(session *gocql.Session) readers.MessageRepository {
+// New instantiates Cassandra message repository. Since Cassandra doesn't
+// support offset, the skipped rows are scanned as well, so reading fails with
+// readers.ErrTooManyRows if the query would scan more than maxRows rows. Zero
+// maxRows disables the limit. Reading after the cursor skips no rows, so the
+// deep pages should be read that way instead. Scanned rows are fetched from
+// the database in pages of pageSize rows, trading the memory for the round
+// trips. Zero pageSize keeps the page size of the session.
+func New(session *gocql.Session, maxRows uint64, pageSize int) readers.MessageRepository {
 	return cassandraRepository{
-		session: session,
+		session:  session,
+		maxRows:  maxRows,
+		pageSize: pageSize,
 	}
 }
 
 func (cr cassandraRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
-	names := []string{}
-	vals := []interface{}{chanID}
-	for name, val := range query {
-		names = append(names, name)
-		vals = append(vals, val)
+	if after := query[readers.AfterKey]; after != "" {
+		return cr.readAfter(chanID, after, limit, query)
 	}
-	vals = append(vals, offset+limit)
 
-	selectCQL := buildSelectQuery(chanID, offset, limit, names)
-	countCQL := buildCountQuery(chanID, names)
+	selectCQL, countCQL, vals, err := readQueries(chanID, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
 
-	iter := cr.session.Query(selectCQL, vals...).Iter()
-	defer iter.Close()
-	scanner := iter.Scanner()
+	messages, cursors, err := cr.scan(selectCQL, append(vals, offset+limit), offset)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
 
-	// skip first OFFSET rows
-	for i := uint64(0); i < offset; i++ {
-		if !scanner.Next() {
-			break
-		}
+	page := readers.MessagesPage{
+		Offset:   offset,
+		Limit:    limit,
+		Messages: messages,
+		Cursors:  cursors,
 	}
 
-	var floatVal, valueSum *float64
-	var strVal, dataVal *string
-	var boolVal *bool
+	if err := cr.session.Query(countCQL, vals...).Scan(&page.Total); err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	return page, nil
+}
+
+// readAfter reads the page of the messages following the cursor, in the
+// ascending order of time. Clustering order can only be reversed as a whole,
+// so the messages published at the same time follow each other in the
+// descending order of their IDs. The page is read in up to two steps: the
+// messages published at the cursor time that follow the cursor, and the
+// messages published after it. Neither of them skips any rows.
+func (cr cassandraRepository) readAfter(chanID, after string, limit uint64, query map[string]string) (readers.MessagesPage, error) {
+	steps, err := afterQueries(chanID, after, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
 
 	page := readers.MessagesPage{
-		Offset:   offset,
 		Limit:    limit,
 		Messages: []mainflux.Message{},
+		Cursors:  []readers.Cursor{},
 	}
-	for scanner.Next() {
+	for _, s := range steps {
+		if rest := limit - uint64(len(page.Messages)); rest > 0 {
+			messages, cursors, err := cr.scan(s.selectCQL, append(s.vals[:len(s.vals):len(s.vals)], rest), 0)
+			if err != nil {
+				return readers.MessagesPage{}, err
+			}
+
+			page.Messages = append(page.Messages, messages...)
+			page.Cursors = append(page.Cursors, cursors...)
+		}
+
+		var total uint64
+		if err := cr.session.Query(s.countCQL, s.vals...).Scan(&total); err != nil {
+			return readers.MessagesPage{}, err
+		}
+		page.Total += total
+	}
+
+	return page, nil
+}
+
+// scan runs the select statement and scans its rows, skipping the first
+// offset of them.
+func (cr cassandraRepository) scan(selectCQL string, vals []interface{}, offset uint64) ([]mainflux.Message, []readers.Cursor, error) {
+	q := cr.session.Query(selectCQL, vals...)
+	if cr.pageSize > 0 {
+		q = q.PageSize(cr.pageSize)
+	}
+
+	iter := q.Iter()
+	messages, cursors, err := scanMessages(iter.Scanner(), offset, cr.maxRows)
+	if cerr := iter.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return messages, cursors, nil
+}
+
+// Explain returns the CQL statements only, since Cassandra doesn't report
+// the execution plan.
+func (cr cassandraRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	if after := query[readers.AfterKey]; after != "" {
+		steps, err := afterQueries(chanID, after, query)
+		if err != nil {
+			return nil, err
+		}
+
+		explanations := []readers.Explanation{}
+		for _, s := range steps {
+			explanations = append(explanations,
+				readers.Explanation{Query: s.selectCQL, Args: append(s.vals[:len(s.vals):len(s.vals)], limit)},
+				readers.Explanation{Query: s.countCQL, Args: s.vals})
+		}
+
+		return explanations, nil
+	}
+
+	selectCQL, countCQL, vals, err := readQueries(chanID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := []readers.Explanation{
+		{Query: selectCQL, Args: append(vals[:len(vals):len(vals)], offset+limit)},
+		{Query: countCQL, Args: vals},
+	}
+
+	return explanations, nil
+}
+
+func (cr cassandraRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	if query[readers.FilterKey] != "" {
+		return 0, 0, readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return 0, 0, readers.ErrUnsupportedTags
+	}
+
+	cond, vals, err := fmtCondition(chanID, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var min, max *float64
+	if err := cr.session.Query(buildBoundsQuery(cond), vals...).Scan(&min, &max); err != nil {
+		return 0, 0, err
+	}
+
+	if min == nil || max == nil {
+		return 0, 0, nil
+	}
+
+	return *min, *max, nil
+}
+
+func (cr cassandraRepository) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	if err := agg.Validate(); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	if agg.Grouped() {
+		return readers.AggregationResult{}, readers.ErrUnsupportedGrouping
+	}
+
+	if agg.Function == readers.AggregateCountDistinct {
+		return readers.AggregationResult{}, readers.ErrUnsupportedDistinct
+	}
+
+	if query[readers.FilterKey] != "" {
+		return readers.AggregationResult{}, readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return readers.AggregationResult{}, readers.ErrUnsupportedTags
+	}
+
+	// Aggregated field is embedded in the query as the column name.
+	if err := readers.Columns.Validate(agg.Field); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	cond, vals, err := fmtCondition(chanID, query)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	var value *float64
+	var samples, total int64
+	if err := cr.session.Query(buildAggregateQuery(agg, cond), vals...).Scan(&value, &samples, &total); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	var v float64
+	if value != nil {
+		v = *value
+	}
+
+	return agg.Result(v, uint64(samples), uint64(total))
+}
+
+// scanMessages skips the first offset rows and returns the rest of them as
+// messages, along with their cursors. It aborts with readers.ErrTooManyRows
+// as soon as the number of scanned rows exceeds non-zero maxRows.
+func scanMessages(scanner gocql.Scanner, offset, maxRows uint64) ([]mainflux.Message, []readers.Cursor, error) {
+	var floatVal, valueSum *float64
+	var strVal, dataVal *string
+	var boolVal *bool
+	var id gocql.UUID
+
+	messages := []mainflux.Message{}
+	cursors := []readers.Cursor{}
+	for rows := uint64(1); scanner.Next(); rows++ {
+		if maxRows > 0 && rows > maxRows {
+			return nil, nil, readers.ErrTooManyRows
+		}
+
+		// skip first OFFSET rows
+		if rows <= offset {
+			continue
+		}
+
 		var msg mainflux.Message
-		err := scanner.Scan(&msg.Channel, &msg.Subtopic, &msg.Publisher, &msg.Protocol,
+		err := scanner.Scan(&id, &msg.Channel, &msg.Subtopic, &msg.Publisher, &msg.Protocol,
 			&msg.Name, &msg.Unit, &floatVal, &strVal, &boolVal,
 			&dataVal, &valueSum, &msg.Time, &msg.UpdateTime, &msg.Link)
 		if err != nil {
-			return readers.MessagesPage{}, err
+			return nil, nil, err
 		}
 
 		switch {
@@ -84,53 +273,257 @@ func (cr cassandraRepository) ReadAll(chanID string, offset, limit uint64, query
 			msg.ValueSum = &mainflux.SumValue{Value: *valueSum}
 		}
 
-		page.Messages = append(page.Messages, msg)
+		messages = append(messages, msg)
+		cursors = append(cursors, readers.Cursor{Time: msg.Time, ID: id.String()})
 	}
 
-	if err := cr.session.Query(countCQL, vals[:len(vals)-1]...).Scan(&page.Total); err != nil {
-		return readers.MessagesPage{}, err
+	return messages, cursors, nil
+}
+
+// fmtCondition creates the CQL condition that matches the channel messages
+// filtered by the query, along with the values of its placeholders. Messages
+// are partitioned by the channel, so the cross-channel queries aren't
+// supported.
+func fmtCondition(chanID string, query map[string]string) (string, []interface{}, error) {
+	cond, vals, err := fmtFilters(chanID, query)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return page, nil
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Time is the clustering column, so the range is matched within the
+	// channel partition.
+	if tr.From != nil {
+		cond = fmt.Sprintf(`%s AND time >= ?`, cond)
+		vals = append(vals, *tr.From)
+	}
+
+	if tr.To != nil {
+		cond = fmt.Sprintf(`%s AND time < ?`, cond)
+		vals = append(vals, *tr.To)
+	}
+
+	return cond, vals, nil
 }
 
-func buildSelectQuery(chanID string, offset, limit uint64, names []string) string {
-	var condCQL string
-	cql := `SELECT channel, subtopic, publisher, protocol, name, unit,
-	        value, string_value, bool_value, data_value, value_sum, time,
-			update_time, link FROM messages WHERE channel = ? %s LIMIT ?
-			ALLOW FILTERING`
-
-	for _, name := range names {
-		switch name {
-		case
-			"channel",
-			"subtopic",
-			"publisher",
-			"name",
-			"protocol":
-			condCQL = fmt.Sprintf(`%s AND %s = ?`, condCQL, name)
+// fmtFilters creates the condition of fmtCondition, except for the time
+// range.
+func fmtFilters(chanID string, query map[string]string) (string, []interface{}, error) {
+	if err := readers.CheckChannel(chanID, query); err != nil {
+		return "", nil, err
+	}
+
+	if chanID == readers.AllChannels {
+		return "", nil, readers.ErrUnsupportedCrossChannel
+	}
+
+	cond := `channel = ?`
+	vals := []interface{}{chanID}
+	for name, val := range query {
+		if filterable(name) {
+			cond = fmt.Sprintf(`%s AND %s = ?`, cond, name)
+			vals = append(vals, val)
 		}
 	}
 
-	return fmt.Sprintf(cql, condCQL)
+	vf, err := readers.ParseValueFilter(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Regular columns can't be restricted to non-null values, while the
+	// comparison with the null value never matches, so the value filters
+	// match the values of the filtered type only.
+	if vf.Equal != nil {
+		cond = fmt.Sprintf(`%s AND value = ?`, cond)
+		vals = append(vals, *vf.Equal)
+	}
+
+	if vf.Greater != nil {
+		cond = fmt.Sprintf(`%s AND value > ?`, cond)
+		vals = append(vals, *vf.Greater)
+	}
+
+	if vf.Less != nil {
+		cond = fmt.Sprintf(`%s AND value < ?`, cond)
+		vals = append(vals, *vf.Less)
+	}
+
+	if vf.Bool != nil {
+		cond = fmt.Sprintf(`%s AND bool_value = ?`, cond)
+		vals = append(vals, *vf.Bool)
+	}
+
+	if vf.String != nil {
+		cond = fmt.Sprintf(`%s AND string_value = ?`, cond)
+		vals = append(vals, *vf.String)
+	}
+
+	return cond, vals, nil
+}
+
+// checkQuery rejects the query parameters the repository doesn't support.
+func checkQuery(query map[string]string) error {
+	if query[readers.FilterKey] != "" {
+		return readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return readers.ErrUnsupportedTags
+	}
+
+	if query[readers.IDsKey] != "" {
+		return readers.ErrUnsupportedCursor
+	}
+
+	return nil
 }
 
-func buildCountQuery(chanID string, names []string) string {
-	var condCQL string
-	cql := `SELECT COUNT(*) FROM messages WHERE channel = ? %s ALLOW FILTERING`
+// readQueries creates the statement selecting the channel messages and the
+// statement counting them, along with the values of their common condition.
+func readQueries(chanID string, query map[string]string) (string, string, []interface{}, error) {
+	if err := checkQuery(query); err != nil {
+		return "", "", nil, err
+	}
+
+	o, err := readers.ParseOrder(query)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Messages are partitioned by the channel and clustered by time, so
+	// the rows are ordered by time within the partition, and time is the
+	// only column the order can be reversed by.
+	if o.Field != readers.SortTime {
+		return "", "", nil, readers.ErrUnsupportedSort
+	}
+
+	cond, vals, err := fmtCondition(chanID, query)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return buildSelectQuery(cond, o), buildCountQuery(cond), vals, nil
+}
+
+// step is the pair of the statements selecting and counting the messages of
+// the single step of reading after the cursor.
+type step struct {
+	selectCQL string
+	countCQL  string
+	vals      []interface{}
+}
 
-	for _, name := range names {
-		switch name {
-		case
-			"channel",
-			"subtopic",
-			"publisher",
-			"name",
-			"protocol":
-			condCQL = fmt.Sprintf(`%s AND %s = ?`, condCQL, name)
+// afterQueries creates the steps of reading the messages following the
+// cursor, as described by readAfter. Cursor time and the time range are
+// merged into the single lower bound, since Cassandra rejects the several
+// ones on the same column.
+func afterQueries(chanID, after string, query map[string]string) ([]step, error) {
+	if err := checkQuery(query); err != nil {
+		return nil, err
+	}
+
+	c, err := readers.ParseCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, vals, err := fmtFilters(chanID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	asc := readers.Order{Field: readers.SortTime}
+	steps := []step{}
+
+	// Cursor without the ID precedes all the messages published at its time.
+	op := ">="
+	if c.ID != "" {
+		id, err := gocql.ParseUUID(c.ID)
+		if err != nil {
+			return nil, readers.ErrInvalidCursor
 		}
+
+		if tr.Contains(c.Time) {
+			sc := fmt.Sprintf(`%s AND time = ? AND id < ?`, cond)
+			sv := append(vals[:len(vals):len(vals)], c.Time, id)
+			steps = append(steps, step{buildSelectQuery(sc, asc), buildCountQuery(sc), sv})
+		}
+		op = ">"
+	}
+
+	from := c.Time
+	if tr.From != nil && *tr.From > from {
+		from = *tr.From
+		op = ">="
+	}
+
+	lc := fmt.Sprintf(`%s AND time %s ?`, cond, op)
+	lv := append(vals[:len(vals):len(vals)], from)
+	if tr.To != nil {
+		lc = fmt.Sprintf(`%s AND time < ?`, lc)
+		lv = append(lv, *tr.To)
+	}
+	steps = append(steps, step{buildSelectQuery(lc, asc), buildCountQuery(lc), lv})
+
+	return steps, nil
+}
+
+// buildSelectQuery creates the statement selecting the channel messages in
+// the given order. Table is expected to be clustered by time, so that the
+// order is applied within the channel partition restricted by the condition.
+func buildSelectQuery(cond string, o readers.Order) string {
+	cql := `SELECT id, channel, subtopic, publisher, protocol, name, unit,
+	        value, string_value, bool_value, data_value, value_sum, time,
+			update_time, link FROM messages WHERE %s ORDER BY time %s
+			LIMIT ? ALLOW FILTERING`
+
+	return fmt.Sprintf(cql, cond, strings.ToUpper(o.Direction()))
+}
+
+func buildCountQuery(cond string) string {
+	cql := `SELECT COUNT(*) FROM messages WHERE %s ALLOW FILTERING`
+	return fmt.Sprintf(cql, cond)
+}
+
+func buildBoundsQuery(cond string) string {
+	cql := `SELECT MIN(time), MAX(time) FROM messages WHERE %s ALLOW FILTERING`
+	return fmt.Sprintf(cql, cond)
+}
+
+// buildAggregateQuery creates query which returns the aggregated value, the
+// number of non-null field values and the total number of matched messages.
+func buildAggregateQuery(agg readers.Aggregation, cond string) string {
+	fn := fmt.Sprintf("%s(%s)", agg.Function, agg.Field)
+	if agg.Function == readers.AggregateCount {
+		// Cast count to double so that it can be scanned as the other
+		// aggregated values.
+		fn = fmt.Sprintf("CAST(COUNT(%s) AS double)", agg.Field)
+	}
+	cql := `SELECT %s, COUNT(%s), COUNT(*) FROM messages WHERE %s ALLOW FILTERING`
+
+	return fmt.Sprintf(cql, fn, agg.Field, cond)
+}
+
+func filterable(name string) bool {
+	switch name {
+	case
+		"channel",
+		"subtopic",
+		"publisher",
+		"name",
+		"protocol":
+		return true
 	}
 
-	return fmt.Sprintf(cql, condCQL)
+	return false
 }
@@ -8,10 +8,14 @@
 package cassandra_test
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gocql/gocql"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/readers"
 	creaders "github.com/mainflux/mainflux/readers/cassandra"
@@ -78,7 +82,7 @@ func TestReadAll(t *testing.T) {
 		}
 	}
 
-	reader := creaders.New(session)
+	reader := creaders.New(session, 0, 0)
 
 	// Since messages are not saved in natural order,
 	// cases that return subset of messages are only
@@ -156,3 +160,497 @@ func TestReadAll(t *testing.T) {
 		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %v got %v", desc, tc.page.Total, result.Total))
 	}
 }
+
+func TestBounds(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	boundsChan := "bounds"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	for i := 0; i < msgsNum; i++ {
+		m := mainflux.Message{
+			Channel:   boundsChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+			Time:      float64(now - int64(i)),
+		}
+		if i%valueFields == 0 {
+			m.Subtopic = subtopic
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := creaders.New(session, 0, 0)
+
+	cases := map[string]struct {
+		chanID string
+		query  map[string]string
+		min    float64
+		max    float64
+	}{
+		"read bounds of channel": {
+			chanID: boundsChan,
+			min:    float64(now - msgsNum + 1),
+			max:    float64(now),
+		},
+		"read bounds of channel with subtopic": {
+			chanID: boundsChan,
+			query:  map[string]string{"subtopic": subtopic},
+			min:    float64(now - (msgsNum-1)/valueFields*valueFields),
+			max:    float64(now),
+		},
+		"read bounds of empty channel": {
+			chanID: emptyChan,
+			min:    0,
+			max:    0,
+		},
+	}
+
+	for desc, tc := range cases {
+		min, max, err := reader.Bounds(tc.chanID, tc.query)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.Equal(t, tc.min, min, fmt.Sprintf("%s: expected min %f got %f", desc, tc.min, min))
+		assert.Equal(t, tc.max, max, fmt.Sprintf("%s: expected max %f got %f", desc, tc.max, max))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	aggChan := "aggregate"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	// Every other message carries string value, so the float value is null.
+	for i := 0; i < 10; i++ {
+		m := mainflux.Message{
+			Channel:   aggChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(now - int64(i)),
+		}
+		switch i % 2 {
+		case 0:
+			m.Value = &mainflux.Message_FloatValue{FloatValue: float64(i)}
+		default:
+			m.Value = &mainflux.Message_StringValue{StringValue: "value"}
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := creaders.New(session, 0, 0)
+	cases := map[string]struct {
+		chanID string
+		agg    readers.Aggregation
+		res    readers.AggregationResult
+		err    error
+	}{
+		"average skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 4, Samples: 5},
+		},
+		"minimum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMin, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 5},
+		},
+		"maximum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMax, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 8, Samples: 5},
+		},
+		"sum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 20, Samples: 5},
+		},
+		"count skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCount, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 5, Samples: 5},
+		},
+		"average rejecting nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			err:    readers.ErrNullValue,
+		},
+		"count of distinct publishers": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCountDistinct, Field: "publisher", NullPolicy: readers.SkipNulls},
+			err:    readers.ErrUnsupportedDistinct,
+		},
+		"average of empty channel": {
+			chanID: emptyChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 0},
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := reader.Aggregate(tc.chanID, tc.agg, nil)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, res))
+	}
+}
+
+// TestReadAllTimeRange checks that the time range includes the message at
+// its start and excludes the one at its end.
+func TestReadAllTimeRange(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	rangeChanID := "time-range"
+
+	from, to := 1000.0, 2000.0
+	for _, tm := range []float64{from - 1, from, (from + to) / 2, to} {
+		msg := mainflux.Message{
+			Channel:   rangeChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      tm,
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := creaders.New(session, 0, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read messages within time range": {
+			query: map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"},
+			times: []float64{from, (from + to) / 2},
+		},
+		"read messages since time": {
+			query: map[string]string{readers.FromKey: "1000"},
+			times: []float64{from, (from + to) / 2, to},
+		},
+		"read messages until time": {
+			query: map[string]string{readers.ToKey: "2000"},
+			times: []float64{from - 1, from, (from + to) / 2},
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(rangeChanID, 0, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
+
+// pagesObserver records the number of rows fetched by each page of the
+// queries selecting the messages.
+type pagesObserver struct {
+	mu    sync.Mutex
+	pages []int
+}
+
+func (po *pagesObserver) ObserveQuery(_ context.Context, q gocql.ObservedQuery) {
+	if !strings.HasPrefix(q.Statement, "SELECT channel") {
+		return
+	}
+
+	po.mu.Lock()
+	defer po.mu.Unlock()
+	po.pages = append(po.pages, q.Rows)
+}
+
+func TestReadAllPageSize(t *testing.T) {
+	observer := &pagesObserver{}
+	cluster := gocql.NewCluster(addr)
+	cluster.Keyspace = keyspace
+	cluster.Consistency = gocql.Quorum
+	cluster.QueryObserver = observer
+	session, err := cluster.CreateSession()
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	pagedChanID := "paged"
+
+	for i := 0; i < 10; i++ {
+		msg := mainflux.Message{
+			Channel:   pagedChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	pageSize := 3
+	reader := creaders.New(session, 0, pageSize)
+
+	result, err := reader.ReadAll(pagedChanID, 2, 8, map[string]string{})
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	assert.Len(t, result.Messages, 8, fmt.Sprintf("expected 8 messages got %d", len(result.Messages)))
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	assert.True(t, len(observer.pages) > 1, fmt.Sprintf("expected rows fetched in several pages got %v", observer.pages))
+	for _, rows := range observer.pages {
+		assert.True(t, rows <= pageSize, fmt.Sprintf("expected pages of at most %d rows got %v", pageSize, observer.pages))
+	}
+}
+
+func TestReadAllOrder(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	orderChanID := "order"
+
+	for i, pub := range []string{"b", "a", "c"} {
+		msg := mainflux.Message{
+			Channel:   orderChanID,
+			Publisher: pub,
+			Protocol:  "mqtt",
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := creaders.New(session, 0, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages in default order": {
+			query: map[string]string{},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages in ascending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortAsc},
+			times: []float64{1000, 1001, 1002},
+		},
+		"read messages in descending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortDesc, readers.SortByKey: readers.SortTime},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages by publisher": {
+			query: map[string]string{readers.SortByKey: "publisher"},
+			err:   readers.ErrUnsupportedSort,
+		},
+		"read messages in invalid order": {
+			query: map[string]string{readers.SortOrderKey: "random"},
+			err:   readers.ErrInvalidOrder,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(orderChanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.Equal(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllValueFilter(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	valueChanID := "value-filter"
+
+	for i, v := range []float64{25, 30, 35} {
+		msg := mainflux.Message{
+			Channel:   valueChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: v},
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+	msg := mainflux.Message{
+		Channel:   valueChanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_StringValue{StringValue: "value"},
+		Time:      1003,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	msg = mainflux.Message{
+		Channel:   valueChanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_BoolValue{BoolValue: true},
+		Time:      1004,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	reader := creaders.New(session, 0, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages with value equal to": {
+			query: map[string]string{readers.ValueKey: "30"},
+			times: []float64{1001},
+		},
+		"read messages with value greater than": {
+			query: map[string]string{readers.ValueGreaterKey: "30"},
+			times: []float64{1002},
+		},
+		"read messages with value less than": {
+			query: map[string]string{readers.ValueLessKey: "30"},
+			times: []float64{1000},
+		},
+		"read messages with value within range": {
+			query: map[string]string{readers.ValueGreaterKey: "20", readers.ValueLessKey: "32.5"},
+			times: []float64{1000, 1001},
+		},
+		"read messages with malformed value filter": {
+			query: map[string]string{readers.ValueGreaterKey: "hot"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		"read messages with bool value": {
+			query: map[string]string{readers.BoolValueKey: "true"},
+			times: []float64{1004},
+		},
+		"read messages with string value": {
+			query: map[string]string{readers.StringValueKey: "value"},
+			times: []float64{1003},
+		},
+		"read messages with float and bool value": {
+			query: map[string]string{readers.ValueKey: "30", readers.BoolValueKey: "true"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(valueChanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
+
+func TestReadAllAfter(t *testing.T) {
+	session, err := creaders.Connect(creaders.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+	defer session.Close()
+	writer := cwriters.New(session)
+	afterChanID := "after"
+
+	// Several messages share the time, so that the page boundary falls
+	// between them.
+	times := []float64{1000, 1001, 1001, 1001, 1002, 1003, 1004}
+	for i, tm := range times {
+		msg := mainflux.Message{
+			Channel:   afterChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Name:      fmt.Sprint(i),
+			Time:      tm,
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	// Maximum scanned rows are below the number of messages, so that the
+	// reading fails if it skips the preceding rows.
+	reader := creaders.New(session, 3, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read all messages after cursor": {
+			query: map[string]string{},
+			times: times,
+		},
+		"read messages after cursor within time range": {
+			query: map[string]string{readers.FromKey: "1001", readers.ToKey: "1004"},
+			times: times[1:6],
+		},
+	}
+
+	for desc, tc := range cases {
+		query := map[string]string{readers.AfterKey: "0"}
+		for k, v := range tc.query {
+			query[k] = v
+		}
+
+		read := []float64{}
+		names := map[string]bool{}
+		for page := 0; page < len(times); page++ {
+			result, err := reader.ReadAll(afterChanID, 0, 2, query)
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+			require.Len(t, result.Cursors, len(result.Messages), fmt.Sprintf("%s: expected cursor of every message", desc))
+			assert.Equal(t, uint64(len(tc.times)-len(read)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times)-len(read), result.Total))
+
+			for _, msg := range result.Messages {
+				assert.False(t, names[msg.Name], fmt.Sprintf("%s: expected message %s read once", desc, msg.Name))
+				names[msg.Name] = true
+				read = append(read, msg.Time)
+			}
+
+			if len(result.Messages) < 2 {
+				break
+			}
+			query[readers.AfterKey] = result.Cursors[len(result.Cursors)-1].String()
+		}
+
+		assert.Equal(t, tc.times, read, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, read))
+	}
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cassandra
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+// rowsScanner mocks the scanner of a query returning the given number of
+// rows, which contain the row number as the message name and the row time.
+type rowsScanner struct {
+	rows    uint64
+	scanned uint64
+}
+
+func (rs *rowsScanner) Next() bool {
+	if rs.scanned == rs.rows {
+		return false
+	}
+	rs.scanned++
+	return true
+}
+
+func (rs *rowsScanner) Scan(dest ...interface{}) error {
+	*dest[5].(*string) = fmt.Sprint(rs.scanned)
+	*dest[12].(*float64) = float64(rs.scanned)
+	return nil
+}
+
+func (rs *rowsScanner) Err() error {
+	return nil
+}
+
+func TestScanMessages(t *testing.T) {
+	cases := []struct {
+		desc     string
+		rows     uint64
+		offset   uint64
+		maxRows  uint64
+		messages int
+		first    string
+		scanned  uint64
+		err      error
+	}{
+		{
+			desc:     "scan rows without limit",
+			rows:     1000,
+			offset:   990,
+			maxRows:  0,
+			messages: 10,
+			first:    "991",
+			scanned:  1000,
+			err:      nil,
+		},
+		{
+			desc:     "scan rows within limit",
+			rows:     100,
+			offset:   90,
+			maxRows:  100,
+			messages: 10,
+			first:    "91",
+			scanned:  100,
+			err:      nil,
+		},
+		{
+			desc:    "scan rows over limit",
+			rows:    1000,
+			offset:  990,
+			maxRows: 100,
+			scanned: 101,
+			err:     readers.ErrTooManyRows,
+		},
+	}
+
+	for _, tc := range cases {
+		scanner := &rowsScanner{rows: tc.rows}
+		messages, cursors, err := scanMessages(scanner, tc.offset, tc.maxRows)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.scanned, scanner.scanned, fmt.Sprintf("%s: expected %d scanned rows got %d", tc.desc, tc.scanned, scanner.scanned))
+		assert.Len(t, messages, tc.messages, fmt.Sprintf("%s: expected %d messages got %d", tc.desc, tc.messages, len(messages)))
+		assert.Len(t, cursors, tc.messages, fmt.Sprintf("%s: expected %d cursors got %d", tc.desc, tc.messages, len(cursors)))
+		if tc.messages > 0 {
+			assert.Equal(t, tc.first, messages[0].Name, fmt.Sprintf("%s: expected first message %s got %s", tc.desc, tc.first, messages[0].Name))
+			assert.Equal(t, messages[0].Time, cursors[0].Time, fmt.Sprintf("%s: expected first cursor time %f got %f", tc.desc, messages[0].Time, cursors[0].Time))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import "errors"
+
+// AllChannels is the channel ID of the cross-channel queries, which match the
+// messages of every channel.
+const AllChannels = "*"
+
+var (
+	// ErrMissingChannel indicates the query that doesn't name the channel,
+	// which is rejected rather than scanning the messages of all channels.
+	ErrMissingChannel = errors.New("missing channel")
+
+	// ErrUnboundedQuery indicates the cross-channel query that isn't bounded
+	// by both ends of the time range.
+	ErrUnboundedQuery = errors.New("cross-channel query without time range")
+
+	// ErrUnsupportedCrossChannel indicates that the message repository
+	// doesn't support the cross-channel queries.
+	ErrUnsupportedCrossChannel = errors.New("cross-channel query is not supported")
+)
+
+// CheckChannel checks that the query is scoped to the channel. Cross-channel
+// queries are accepted only if their time range is closed, so that a single
+// query never scans the whole message store.
+func CheckChannel(chanID string, query map[string]string) error {
+	if chanID == "" {
+		return ErrMissingChannel
+	}
+
+	if chanID != AllChannels {
+		return nil
+	}
+
+	tr, err := ParseTimeRange(query)
+	if err != nil {
+		return err
+	}
+
+	if tr.From == nil || tr.To == nil {
+		return ErrUnboundedQuery
+	}
+
+	return nil
+}
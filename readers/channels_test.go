@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckChannel(t *testing.T) {
+	cases := []struct {
+		desc   string
+		chanID string
+		query  map[string]string
+		err    error
+	}{
+		{
+			desc:   "check channel query",
+			chanID: "1",
+			query:  map[string]string{},
+			err:    nil,
+		},
+		{
+			desc:   "check query without channel",
+			chanID: "",
+			query:  map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"},
+			err:    readers.ErrMissingChannel,
+		},
+		{
+			desc:   "check bounded cross-channel query",
+			chanID: readers.AllChannels,
+			query:  map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"},
+			err:    nil,
+		},
+		{
+			desc:   "check cross-channel query without time range",
+			chanID: readers.AllChannels,
+			query:  map[string]string{},
+			err:    readers.ErrUnboundedQuery,
+		},
+		{
+			desc:   "check cross-channel query without range end",
+			chanID: readers.AllChannels,
+			query:  map[string]string{readers.FromKey: "1000"},
+			err:    readers.ErrUnboundedQuery,
+		},
+		{
+			desc:   "check cross-channel query with invalid time range",
+			chanID: readers.AllChannels,
+			query:  map[string]string{readers.FromKey: "2000", readers.ToKey: "1000"},
+			err:    readers.ErrInvalidTimeRange,
+		},
+	}
+
+	for _, tc := range cases {
+		err := readers.CheckChannel(tc.chanID, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
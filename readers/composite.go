@@ -0,0 +1,421 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+var _ MessageRepository = (*compositeRepository)(nil)
+
+type compositeRepository struct {
+	hot       MessageRepository
+	cold      MessageRepository
+	retention time.Duration
+}
+
+// NewCompositeRepository returns message repository that serves the messages
+// published within the retention period, counting back from the time of the
+// query, from the hot repository, and the older messages from the cold one,
+// e.g. the archive. The query spanning the retention boundary reads both of
+// them and merges the results, so that the split is transparent to the
+// client. Messages are routed by their time only, so the repositories are
+// expected not to hold the same messages on the same side of the boundary.
+func NewCompositeRepository(hot, cold MessageRepository, retention time.Duration) MessageRepository {
+	return compositeRepository{
+		hot:       hot,
+		cold:      cold,
+		retention: retention,
+	}
+}
+
+func (cr compositeRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (MessagesPage, error) {
+	hq, cq, boundary, err := cr.split(query)
+	if err != nil {
+		return MessagesPage{}, err
+	}
+
+	switch {
+	case cq == nil:
+		return cr.hot.ReadAll(chanID, offset, limit, hq)
+	case hq == nil:
+		return cr.cold.ReadAll(chanID, offset, limit, cq)
+	}
+
+	after, ok := query[AfterKey]
+	if !ok || after == "" {
+		o, err := ParseOrder(query)
+		if err != nil {
+			return MessagesPage{}, err
+		}
+
+		switch {
+		case o.Field != SortTime:
+			// Pages sorted by any other field interleave the hot and
+			// the cold messages, so they can't be concatenated.
+			return MessagesPage{}, ErrUnsupportedSort
+		case !o.Desc:
+			return concat(cr.cold, cq, cr.hot, hq, chanID, offset, limit)
+		}
+
+		// Messages are read from the latest one, so the hot messages
+		// precede the cold ones.
+		return concat(cr.hot, hq, cr.cold, cq, chanID, offset, limit)
+	}
+
+	c, err := ParseCursor(after)
+	if err != nil {
+		return MessagesPage{}, err
+	}
+
+	// Messages following the cursor are read from the earliest one, so the
+	// cold messages precede the hot ones, unless the cursor is past the
+	// boundary already.
+	if c.Time >= boundary {
+		return cr.hot.ReadAll(chanID, 0, limit, hq)
+	}
+
+	// Hot messages following the boundary are read in the ascending order
+	// as well. Cursor without the tiebreaker precedes the messages published
+	// right at the boundary.
+	hq[AfterKey] = hq[FromKey]
+	return concat(cr.cold, cq, cr.hot, hq, chanID, 0, limit)
+}
+
+func (cr compositeRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	hq, cq, _, err := cr.split(query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case cq == nil:
+		return cr.hot.Bounds(chanID, hq)
+	case hq == nil:
+		return cr.cold.Bounds(chanID, cq)
+	}
+
+	hotMin, hotMax, err := cr.hot.Bounds(chanID, hq)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	coldMin, coldMax, err := cr.cold.Bounds(chanID, cq)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if coldMin == 0 && coldMax == 0 {
+		return hotMin, hotMax, nil
+	}
+
+	if hotMin == 0 && hotMax == 0 {
+		return coldMin, coldMax, nil
+	}
+
+	return coldMin, hotMax, nil
+}
+
+func (cr compositeRepository) Aggregate(chanID string, agg Aggregation, query map[string]string) (AggregationResult, error) {
+	hq, cq, _, err := cr.split(query)
+	if err != nil {
+		return AggregationResult{}, err
+	}
+
+	switch {
+	case cq == nil:
+		return cr.hot.Aggregate(chanID, agg, hq)
+	case hq == nil:
+		return cr.cold.Aggregate(chanID, agg, cq)
+	}
+
+	// Pages of the groups can't be merged, so the groups are merged first
+	// and paged afterwards, which keeps the merge subject to the cap.
+	whole := agg
+	whole.Order, whole.Offset, whole.Limit = "", 0, 0
+
+	hot, err := cr.hot.Aggregate(chanID, whole, hq)
+	if err != nil {
+		return AggregationResult{}, err
+	}
+
+	cold, err := cr.cold.Aggregate(chanID, whole, cq)
+	if err != nil {
+		return AggregationResult{}, err
+	}
+
+	res, err := mergeAggregations(whole, cold, hot)
+	if err != nil || !agg.Paged() {
+		return res, err
+	}
+
+	return pageGroups(agg, res), nil
+}
+
+func (cr compositeRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]Explanation, error) {
+	hq, cq, boundary, err := cr.split(query)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cq == nil:
+		return cr.hot.Explain(chanID, offset, limit, hq)
+	case hq == nil:
+		return cr.cold.Explain(chanID, offset, limit, cq)
+	}
+
+	// Repositories are read in the same order as by ReadAll.
+	first, fq, second, sq := cr.hot, hq, cr.cold, cq
+	if after := query[AfterKey]; after != "" {
+		c, err := ParseCursor(after)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Time >= boundary {
+			return cr.hot.Explain(chanID, 0, limit, hq)
+		}
+
+		hq[AfterKey] = hq[FromKey]
+		first, fq, second, sq = cr.cold, cq, cr.hot, hq
+		offset = 0
+	}
+
+	fe, err := first.Explain(chanID, offset, limit, fq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offset of the second read depends on the number of the messages read
+	// first, so the second read is explained from its start.
+	se, err := second.Explain(chanID, 0, limit, sq)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(fe, se...), nil
+}
+
+// split splits the query at the retention boundary into the query of the
+// hot repository and the query of the cold one. Query is nil if the time
+// range of the original query lies entirely on the other side.
+func (cr compositeRepository) split(query map[string]string) (map[string]string, map[string]string, float64, error) {
+	tr, err := ParseTimeRange(query)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	boundary := float64(time.Now().Add(-cr.retention).UnixNano()) / float64(time.Second)
+	if tr.From != nil && *tr.From >= boundary {
+		return copyQuery(query), nil, boundary, nil
+	}
+
+	if tr.To != nil && *tr.To <= boundary {
+		return nil, copyQuery(query), boundary, nil
+	}
+
+	b := strconv.FormatFloat(boundary, 'f', -1, 64)
+
+	hq := copyQuery(query)
+	hq[FromKey] = b
+
+	cq := copyQuery(query)
+	cq[ToKey] = b
+
+	return hq, cq, boundary, nil
+}
+
+func copyQuery(query map[string]string) map[string]string {
+	res := make(map[string]string, len(query))
+	for k, v := range query {
+		res[k] = v
+	}
+
+	return res
+}
+
+// concat reads the page out of the messages of the first repository followed
+// by the messages of the second one.
+func concat(first MessageRepository, fq map[string]string, second MessageRepository, sq map[string]string, chanID string, offset, limit uint64) (MessagesPage, error) {
+	fp, err := first.ReadAll(chanID, offset, limit, fq)
+	if err != nil {
+		return MessagesPage{}, err
+	}
+
+	var so uint64
+	if offset > fp.Total {
+		so = offset - fp.Total
+	}
+
+	// The second repository is read even if the page is full already, so
+	// that the total number of messages is known.
+	remaining := limit - uint64(len(fp.Messages))
+	sl := remaining
+	if sl == 0 {
+		sl = 1
+	}
+
+	sp, err := second.ReadAll(chanID, so, sl, sq)
+	if err != nil {
+		return MessagesPage{}, err
+	}
+
+	msgs := sp.Messages
+	curs := sp.Cursors
+	if uint64(len(msgs)) > remaining {
+		msgs = msgs[:remaining]
+	}
+	if uint64(len(curs)) > remaining {
+		curs = curs[:remaining]
+	}
+
+	page := MessagesPage{
+		Total:    fp.Total + sp.Total,
+		Offset:   offset,
+		Limit:    limit,
+		Messages: append(fp.Messages[:len(fp.Messages):len(fp.Messages)], msgs...),
+	}
+
+	// Cursors are only rendered if both of the repositories support them.
+	if len(fp.Cursors) == len(fp.Messages) && len(curs) == len(msgs) {
+		page.Cursors = append(fp.Cursors[:len(fp.Cursors):len(fp.Cursors)], curs...)
+	}
+
+	return page, nil
+}
+
+// mergeAggregations combines the aggregation results of the disjoint sets of
+// messages, the earlier one first.
+func mergeAggregations(agg Aggregation, earlier, later AggregationResult) (AggregationResult, error) {
+	value, samples := combine(agg.Function, earlier.Value, earlier.Samples, later.Value, later.Samples)
+	res := AggregationResult{
+		Value:   value,
+		Samples: samples,
+	}
+
+	if !agg.Grouped() {
+		return res, nil
+	}
+
+	if agg.Interval == "" {
+		res.Groups = mergeGroups(agg.Function, earlier.Groups, later.Groups)
+		if len(res.Groups) > MaxAggregationGroups {
+			return AggregationResult{}, ErrTooManyGroups
+		}
+		return res, nil
+	}
+
+	// Buckets of both results are ordered by the start, and only the
+	// bucket the boundary falls into can be present in both of them.
+	buckets := append(earlier.Buckets[:len(earlier.Buckets):len(earlier.Buckets)], later.Buckets...)
+	for _, b := range buckets {
+		n := len(res.Buckets)
+		if n == 0 || res.Buckets[n-1].Start != b.Start {
+			res.Buckets = append(res.Buckets, b)
+			continue
+		}
+
+		last := &res.Buckets[n-1]
+		last.Value, last.Samples = combine(agg.Function, last.Value, last.Samples, b.Value, b.Samples)
+		last.Groups = mergeGroups(agg.Function, last.Groups, b.Groups)
+	}
+
+	rows := 0
+	for _, b := range res.Buckets {
+		rows += len(b.Groups)
+		if len(b.Groups) == 0 {
+			rows++
+		}
+	}
+
+	if rows > MaxAggregationGroups {
+		return AggregationResult{}, ErrTooManyGroups
+	}
+
+	return res, nil
+}
+
+// pageGroups replaces the groups of the result with the requested page of
+// them.
+func pageGroups(agg Aggregation, res AggregationResult) AggregationResult {
+	rows := []AggregationRow{}
+	for _, g := range res.Groups {
+		rows = append(rows, AggregationRow{Key: g.Key, Value: g.Value, Samples: g.Samples})
+	}
+
+	rows, res.TotalGroups = agg.Page(rows)
+
+	res.Groups = []AggregationGroup{}
+	for _, row := range rows {
+		res.Groups = append(res.Groups, AggregationGroup{Key: row.Key, Value: row.Value, Samples: row.Samples})
+	}
+
+	return res
+}
+
+// mergeGroups combines the groups having the same key, ordering them by key.
+func mergeGroups(function string, first, second []AggregationGroup) []AggregationGroup {
+	if len(first) == 0 {
+		return second
+	}
+
+	if len(second) == 0 {
+		return first
+	}
+
+	idx := map[string]int{}
+	res := []AggregationGroup{}
+	for _, g := range append(first[:len(first):len(first)], second...) {
+		i, ok := idx[g.Key]
+		if !ok {
+			idx[g.Key] = len(res)
+			res = append(res, g)
+			continue
+		}
+
+		res[i].Value, res[i].Samples = combine(function, res[i].Value, res[i].Samples, g.Value, g.Samples)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res
+}
+
+// combine combines the values aggregated by the function out of the given
+// number of samples each.
+func combine(function string, v1 float64, s1 uint64, v2 float64, s2 uint64) (float64, uint64) {
+	switch {
+	case s1 == 0:
+		return v2, s2
+	case s2 == 0:
+		return v1, s1
+	}
+
+	samples := s1 + s2
+	switch function {
+	case AggregateMin:
+		if v2 < v1 {
+			return v2, samples
+		}
+		return v1, samples
+	case AggregateMax:
+		if v2 > v1 {
+			return v2, samples
+		}
+		return v1, samples
+	case AggregateAvg:
+		return (v1*float64(s1) + v2*float64(s2)) / float64(samples), samples
+	default:
+		return v1 + v2, samples
+	}
+}
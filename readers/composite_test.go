@@ -0,0 +1,317 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/readers"
+	"github.com/mainflux/mainflux/readers/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const retention = time.Hour
+
+// newCompositeRepository returns composite repository whose hot repository
+// holds the messages published 10, 20 and 30 minutes ago, while the cold one
+// holds the messages published 2, 3 and 4 hours ago. Messages are stored
+// from the latest one, as the repositories return them. Message value is
+// the number of minutes since it is published, while the publisher tells
+// the repository.
+func newCompositeRepository(chanID string, now time.Time) (readers.MessageRepository, []mainflux.Message) {
+	ago := []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour}
+
+	messages := []mainflux.Message{}
+	for _, d := range ago {
+		publisher := "hot"
+		if d > retention {
+			publisher = "cold"
+		}
+		messages = append(messages, mainflux.Message{
+			Channel:   chanID,
+			Publisher: publisher,
+			Time:      seconds(now.Add(-d)),
+			Value:     &mainflux.Message_FloatValue{FloatValue: d.Minutes()},
+		})
+	}
+
+	hot := mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages[:3]})
+	cold := mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages[3:]})
+
+	return readers.NewCompositeRepository(hot, cold, retention), messages
+}
+
+func seconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(seconds(t), 'f', -1, 64)
+}
+
+func TestCompositeReadAll(t *testing.T) {
+	chanID := "1"
+	now := time.Now()
+	repo, messages := newCompositeRepository(chanID, now)
+
+	cases := []struct {
+		desc     string
+		offset   uint64
+		limit    uint64
+		query    map[string]string
+		total    uint64
+		messages []mainflux.Message
+	}{
+		{
+			desc:     "read messages spanning retention boundary",
+			limit:    10,
+			query:    map[string]string{},
+			total:    6,
+			messages: messages,
+		},
+		{
+			desc:     "read page straddling retention boundary",
+			offset:   2,
+			limit:    2,
+			query:    map[string]string{},
+			total:    6,
+			messages: messages[2:4],
+		},
+		{
+			desc:     "read page of hot messages",
+			limit:    2,
+			query:    map[string]string{},
+			total:    6,
+			messages: messages[0:2],
+		},
+		{
+			desc:     "read page of cold messages",
+			offset:   4,
+			limit:    10,
+			query:    map[string]string{},
+			total:    6,
+			messages: messages[4:],
+		},
+		{
+			desc:     "read time range spanning retention boundary",
+			limit:    10,
+			query:    map[string]string{readers.FromKey: formatTime(now.Add(-150 * time.Minute)), readers.ToKey: formatTime(now.Add(-15 * time.Minute))},
+			total:    3,
+			messages: messages[1:4],
+		},
+		{
+			desc:     "read time range within retention",
+			limit:    10,
+			query:    map[string]string{readers.FromKey: formatTime(now.Add(-25 * time.Minute))},
+			total:    2,
+			messages: messages[0:2],
+		},
+		{
+			desc:     "read time range past retention",
+			limit:    10,
+			query:    map[string]string{readers.ToKey: formatTime(now.Add(-150 * time.Minute))},
+			total:    2,
+			messages: messages[4:],
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.ReadAll(chanID, tc.offset, tc.limit, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", tc.desc, tc.total, page.Total))
+		assert.Equal(t, tc.messages, page.Messages, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.messages, page.Messages))
+		assert.Len(t, page.Cursors, len(page.Messages), fmt.Sprintf("%s: expected cursor per message", tc.desc))
+	}
+}
+
+func TestCompositeReadAllAfterCursor(t *testing.T) {
+	chanID := "1"
+	now := time.Now()
+	repo, messages := newCompositeRepository(chanID, now)
+
+	page, err := repo.ReadAll(chanID, 0, 10, map[string]string{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, page.Cursors, len(messages), "expected cursor per message")
+
+	cases := []struct {
+		desc     string
+		after    string
+		limit    uint64
+		messages []mainflux.Message
+	}{
+		{
+			desc:     "read messages following cold cursor",
+			after:    page.Cursors[4].String(),
+			limit:    10,
+			messages: []mainflux.Message{messages[3], messages[2], messages[1], messages[0]},
+		},
+		{
+			desc:     "read page following cold cursor",
+			after:    page.Cursors[5].String(),
+			limit:    3,
+			messages: []mainflux.Message{messages[4], messages[3], messages[2]},
+		},
+		{
+			desc:     "read messages following hot cursor",
+			after:    page.Cursors[2].String(),
+			limit:    10,
+			messages: []mainflux.Message{messages[1], messages[0]},
+		},
+	}
+
+	for _, tc := range cases {
+		p, err := repo.ReadAll(chanID, 0, tc.limit, map[string]string{readers.AfterKey: tc.after})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.messages, p.Messages, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.messages, p.Messages))
+	}
+}
+
+func TestCompositeReadAllOrder(t *testing.T) {
+	chanID := "1"
+	now := time.Now()
+	repo, messages := newCompositeRepository(chanID, now)
+
+	cases := []struct {
+		desc     string
+		offset   uint64
+		limit    uint64
+		query    map[string]string
+		messages []mainflux.Message
+		err      error
+	}{
+		{
+			desc:     "read messages spanning retention boundary in ascending order",
+			limit:    10,
+			query:    map[string]string{readers.SortOrderKey: readers.SortAsc},
+			messages: []mainflux.Message{messages[5], messages[4], messages[3], messages[2], messages[1], messages[0]},
+		},
+		{
+			desc:     "read page straddling retention boundary in ascending order",
+			offset:   2,
+			limit:    2,
+			query:    map[string]string{readers.SortOrderKey: readers.SortAsc},
+			messages: []mainflux.Message{messages[3], messages[2]},
+		},
+		{
+			desc:  "read messages spanning retention boundary sorted by publisher",
+			limit: 10,
+			query: map[string]string{readers.SortByKey: "publisher"},
+			err:   readers.ErrUnsupportedSort,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.ReadAll(chanID, tc.offset, tc.limit, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.messages, page.Messages, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.messages, page.Messages))
+	}
+}
+
+func TestCompositeBounds(t *testing.T) {
+	chanID := "1"
+	now := time.Now()
+	repo, messages := newCompositeRepository(chanID, now)
+
+	cases := []struct {
+		desc  string
+		query map[string]string
+		min   float64
+		max   float64
+	}{
+		{
+			desc:  "bounds spanning retention boundary",
+			query: map[string]string{},
+			min:   messages[5].Time,
+			max:   messages[0].Time,
+		},
+		{
+			desc:  "bounds within retention",
+			query: map[string]string{readers.FromKey: formatTime(now.Add(-25 * time.Minute))},
+			min:   messages[1].Time,
+			max:   messages[0].Time,
+		},
+		{
+			desc:  "bounds of time range spanning retention boundary",
+			query: map[string]string{readers.ToKey: formatTime(now.Add(-5 * time.Minute)), readers.FromKey: formatTime(now.Add(-150 * time.Minute))},
+			min:   messages[3].Time,
+			max:   messages[0].Time,
+		},
+	}
+
+	for _, tc := range cases {
+		min, max, err := repo.Bounds(chanID, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.min, min, fmt.Sprintf("%s: expected min %f got %f", tc.desc, tc.min, min))
+		assert.Equal(t, tc.max, max, fmt.Sprintf("%s: expected max %f got %f", tc.desc, tc.max, max))
+	}
+}
+
+func TestCompositeAggregate(t *testing.T) {
+	chanID := "1"
+	repo, _ := newCompositeRepository(chanID, time.Now())
+
+	cases := []struct {
+		desc string
+		agg  readers.Aggregation
+		res  readers.AggregationResult
+	}{
+		{
+			desc: "average spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:  readers.AggregationResult{Value: 100, Samples: 6},
+		},
+		{
+			desc: "minimum spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateMin, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:  readers.AggregationResult{Value: 10, Samples: 6},
+		},
+		{
+			desc: "maximum spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateMax, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:  readers.AggregationResult{Value: 240, Samples: 6},
+		},
+		{
+			desc: "count spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateCount, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:  readers.AggregationResult{Value: 6, Samples: 6},
+		},
+		{
+			desc: "sum grouped by publisher spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, GroupBy: "publisher"},
+			res: readers.AggregationResult{
+				Value:   600,
+				Samples: 6,
+				Groups: []readers.AggregationGroup{
+					{Key: "cold", Value: 540, Samples: 3},
+					{Key: "hot", Value: 60, Samples: 3},
+				},
+			},
+		},
+		{
+			desc: "bottom group by sum spanning retention boundary",
+			agg:  readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, GroupBy: "publisher", Order: readers.OrderValueAsc, Limit: 1},
+			res: readers.AggregationResult{
+				Value:       600,
+				Samples:     6,
+				Groups:      []readers.AggregationGroup{{Key: "hot", Value: 60, Samples: 3}},
+				TotalGroups: 2,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := repo.Aggregate(chanID, tc.agg, map[string]string{})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.res, res))
+	}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// AfterKey is the query key carrying the cursor of the message the keyset
+// pagination resumes after.
+const AfterKey = "after"
+
+// IDsKey is the query key carrying the comma separated cursors of the
+// messages looked up by their IDs, e.g. linked to from the alerts.
+const IDsKey = "msgID"
+
+// idsSeparator separates the cursors of the looked up messages.
+const idsSeparator = ","
+
+// cursorSeparator separates the cursor time from the tiebreaker.
+const cursorSeparator = "_"
+
+var (
+	// ErrInvalidCursor indicates malformed message cursor.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrUnsupportedCursor indicates that the message repository doesn't
+	// support the cursors, i.e. the keyset pagination and the lookup of the
+	// messages by their IDs.
+	ErrUnsupportedCursor = errors.New("cursor pagination is not supported")
+)
+
+// Cursor is the stable position of the message in the channel. Messages are
+// ordered by time, while the messages published at the same time are ordered
+// by the tiebreaker, which is the repository specific unique message ID. The
+// cursor is rendered as <time>_<tiebreaker>, e.g. 1556180400.5_1f2e3d.
+type Cursor struct {
+	Time float64
+	ID   string
+}
+
+// ParseCursor parses the rendered cursor. Tiebreaker may be omitted, in which
+// case the cursor precedes all the messages published at the cursor time.
+func ParseCursor(value string) (Cursor, error) {
+	parts := strings.SplitN(value, cursorSeparator, 2)
+
+	t, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	c := Cursor{Time: t}
+	if len(parts) == 2 {
+		if parts[1] == "" {
+			return Cursor{}, ErrInvalidCursor
+		}
+		c.ID = parts[1]
+	}
+
+	return c, nil
+}
+
+// ParseIDs parses the cursors of the messages looked up by the query, if any.
+// Cursors identify the messages, so they must carry the tiebreaker.
+func ParseIDs(query map[string]string) ([]Cursor, error) {
+	if query[IDsKey] == "" {
+		return nil, nil
+	}
+
+	cursors := []Cursor{}
+	for _, value := range strings.Split(query[IDsKey], idsSeparator) {
+		c, err := ParseCursor(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.ID == "" {
+			return nil, ErrInvalidCursor
+		}
+
+		cursors = append(cursors, c)
+	}
+
+	return cursors, nil
+}
+
+// Before returns true if the cursor precedes the other one.
+func (c Cursor) Before(other Cursor) bool {
+	if c.Time != other.Time {
+		return c.Time < other.Time
+	}
+
+	return c.ID < other.ID
+}
+
+// String renders the cursor, so that it can be passed back as the query
+// parameter.
+func (c Cursor) String() string {
+	t := strconv.FormatFloat(c.Time, 'f', -1, 64)
+	if c.ID == "" {
+		return t
+	}
+
+	return t + cursorSeparator + c.ID
+}
@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCursor(t *testing.T) {
+	cases := []struct {
+		desc   string
+		value  string
+		cursor readers.Cursor
+		err    error
+	}{
+		{
+			desc:   "parse cursor",
+			value:  "1000.5_00000001",
+			cursor: readers.Cursor{Time: 1000.5, ID: "00000001"},
+			err:    nil,
+		},
+		{
+			desc:   "parse cursor with tiebreaker containing separator",
+			value:  "1000_a_b",
+			cursor: readers.Cursor{Time: 1000, ID: "a_b"},
+			err:    nil,
+		},
+		{
+			desc:   "parse cursor without tiebreaker",
+			value:  "1000",
+			cursor: readers.Cursor{Time: 1000},
+			err:    nil,
+		},
+		{
+			desc:   "parse cursor with empty tiebreaker",
+			value:  "1000_",
+			cursor: readers.Cursor{},
+			err:    readers.ErrInvalidCursor,
+		},
+		{
+			desc:   "parse cursor with malformed time",
+			value:  "now_00000001",
+			cursor: readers.Cursor{},
+			err:    readers.ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range cases {
+		c, err := readers.ParseCursor(tc.value)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.cursor, c, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.cursor, c))
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	cases := []struct {
+		desc    string
+		query   map[string]string
+		cursors []readers.Cursor
+		err     error
+	}{
+		{
+			desc:    "parse IDs of query without IDs",
+			query:   map[string]string{},
+			cursors: nil,
+			err:     nil,
+		},
+		{
+			desc:    "parse single ID",
+			query:   map[string]string{readers.IDsKey: "1000.5_00000001"},
+			cursors: []readers.Cursor{{Time: 1000.5, ID: "00000001"}},
+			err:     nil,
+		},
+		{
+			desc:    "parse multiple IDs",
+			query:   map[string]string{readers.IDsKey: "1000.5_00000001,1001_00000002"},
+			cursors: []readers.Cursor{{Time: 1000.5, ID: "00000001"}, {Time: 1001, ID: "00000002"}},
+			err:     nil,
+		},
+		{
+			desc:    "parse ID without tiebreaker",
+			query:   map[string]string{readers.IDsKey: "1000.5_00000001,1001"},
+			cursors: nil,
+			err:     readers.ErrInvalidCursor,
+		},
+		{
+			desc:    "parse malformed ID",
+			query:   map[string]string{readers.IDsKey: "now_00000001"},
+			cursors: nil,
+			err:     readers.ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range cases {
+		cursors, err := readers.ParseIDs(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.cursors, cursors, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.cursors, cursors))
+	}
+}
+
+func TestCursorString(t *testing.T) {
+	cursors := []readers.Cursor{
+		{Time: 1560000000.123456, ID: "7f2a"},
+		{Time: 0.1, ID: "00000001"},
+		{Time: 1000},
+	}
+
+	for _, c := range cursors {
+		parsed, err := readers.ParseCursor(c.String())
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", c, err))
+		assert.Equal(t, c, parsed, fmt.Sprintf("%s: expected %v got %v", c, c, parsed))
+	}
+}
+
+func TestCursorBefore(t *testing.T) {
+	cases := []struct {
+		desc   string
+		c      readers.Cursor
+		other  readers.Cursor
+		before bool
+	}{
+		{
+			desc:   "earlier time",
+			c:      readers.Cursor{Time: 1000, ID: "2"},
+			other:  readers.Cursor{Time: 1001, ID: "1"},
+			before: true,
+		},
+		{
+			desc:   "same time with lower tiebreaker",
+			c:      readers.Cursor{Time: 1000, ID: "1"},
+			other:  readers.Cursor{Time: 1000, ID: "2"},
+			before: true,
+		},
+		{
+			desc:   "same time without tiebreaker",
+			c:      readers.Cursor{Time: 1000},
+			other:  readers.Cursor{Time: 1000, ID: "1"},
+			before: true,
+		},
+		{
+			desc:   "same cursor",
+			c:      readers.Cursor{Time: 1000, ID: "1"},
+			other:  readers.Cursor{Time: 1000, ID: "1"},
+			before: false,
+		},
+		{
+			desc:   "later time",
+			c:      readers.Cursor{Time: 1001, ID: "1"},
+			other:  readers.Cursor{Time: 1000, ID: "2"},
+			before: false,
+		},
+	}
+
+	for _, tc := range cases {
+		before := tc.c.Before(tc.other)
+		assert.Equal(t, tc.before, before, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.before, before))
+	}
+}
@@ -0,0 +1,23 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+// Explanation describes the query issued to the database while reading the
+// messages, which helps debugging the slow reads.
+type Explanation struct {
+	// Query is the statement issued to the database, e.g. the SQL or the CQL
+	// statement, or the JSON document of the MongoDB filter.
+	Query string
+
+	// Args are the values bound to the query placeholders, in order.
+	Args []interface{}
+
+	// Plan is the execution plan reported by the database, e.g. the output
+	// of the PostgreSQL EXPLAIN. It is empty unless the database reports it.
+	Plan string
+}
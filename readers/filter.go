@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// FilterKey is the query key carrying the JSON encoded filter groups.
+const FilterKey = "filter"
+
+// MaxFilterGroups limits the number of the OR-ed filter groups, so that the
+// filter can't be used to build pathological queries.
+const MaxFilterGroups = 5
+
+var (
+	// ErrInvalidFilter indicates malformed filter groups.
+	ErrInvalidFilter = errors.New("invalid filter")
+
+	// ErrUnsupportedFilter indicates that the message repository doesn't
+	// support filter groups.
+	ErrUnsupportedFilter = errors.New("filter groups are not supported")
+)
+
+// filterFields contains the message fields filter groups can match.
+var filterFields = NewIdentifiers("subtopic", "publisher", "protocol", "name")
+
+// FilterGroup maps the message fields to the values they must be equal to.
+// Message matches the group if it matches all of the group fields.
+type FilterGroup map[string]string
+
+// ParseFilter parses the JSON array of filter groups, e.g.
+// [{"publisher":"a","subtopic":"x"},{"publisher":"b","subtopic":"y"}].
+// Message matches the filter if it matches any of the groups. Empty filter
+// yields no groups.
+func ParseFilter(filter string) ([]FilterGroup, error) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	var groups []FilterGroup
+	if err := json.Unmarshal([]byte(filter), &groups); err != nil {
+		return nil, ErrInvalidFilter
+	}
+
+	if len(groups) == 0 || len(groups) > MaxFilterGroups {
+		return nil, ErrInvalidFilter
+	}
+
+	for _, group := range groups {
+		if len(group) == 0 {
+			return nil, ErrInvalidFilter
+		}
+
+		for field, value := range group {
+			if filterFields.Validate(field) != nil || value == "" {
+				return nil, ErrInvalidFilter
+			}
+		}
+	}
+
+	return groups, nil
+}
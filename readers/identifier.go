@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidIdentifier indicates the identifier, e.g. the column name, that
+// isn't allowed to be interpolated into the query.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// identifierRegExp restricts the identifiers to the plain lowercase names, so
+// that even the misconfigured allow-list can't let the quotes, the comments
+// or the statement separators into the query.
+var identifierRegExp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// Identifiers is the allow-list of the identifiers, e.g. the message fields
+// the listing can be sorted by. Query values are bound as the statement
+// arguments, while the identifiers can't be, so every identifier taken from
+// the request must pass the allow-list before it's embedded in the query.
+type Identifiers map[string]bool
+
+// NewIdentifiers creates the allow-list of the given identifiers. Allow-lists
+// are static, so the malformed identifier is a programming error and panics.
+func NewIdentifiers(names ...string) Identifiers {
+	ids := Identifiers{}
+	for _, name := range names {
+		if !identifierRegExp.MatchString(name) {
+			panic(fmt.Sprintf("malformed identifier %q", name))
+		}
+		ids[name] = true
+	}
+
+	return ids
+}
+
+// Validate returns ErrInvalidIdentifier unless all the identifiers are
+// allowed.
+func (ids Identifiers) Validate(names ...string) error {
+	for _, name := range names {
+		if !identifierRegExp.MatchString(name) || !ids[name] {
+			return ErrInvalidIdentifier
+		}
+	}
+
+	return nil
+}
+
+var (
+	// Tables contains the tables, or the collections and the measurements,
+	// holding the messages.
+	Tables = NewIdentifiers("messages")
+
+	// Columns contains the message columns, or the fields, that can be
+	// projected, sorted, filtered or aggregated by.
+	Columns = NewIdentifiers("channel", "subtopic", "publisher", "protocol",
+		"name", "unit", "value", "string_value", "bool_value", "data_value",
+		"value_sum", "time", "update_time", "link")
+)
@@ -0,0 +1,132 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifiersValidate(t *testing.T) {
+	cases := []struct {
+		desc  string
+		ids   readers.Identifiers
+		names []string
+		err   error
+	}{
+		{
+			desc:  "validate known columns",
+			ids:   readers.Columns,
+			names: []string{"publisher", "value"},
+			err:   nil,
+		},
+		{
+			desc:  "validate known table",
+			ids:   readers.Tables,
+			names: []string{"messages"},
+			err:   nil,
+		},
+		{
+			desc:  "validate unknown column",
+			ids:   readers.Columns,
+			names: []string{"password"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate projection injection",
+			ids:   readers.Columns,
+			names: []string{"name, key FROM things --"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate quoted projection injection",
+			ids:   readers.Columns,
+			names: []string{`name" FROM things --`},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate table name injection",
+			ids:   readers.Tables,
+			names: []string{"messages; DROP TABLE things"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate table name with comment",
+			ids:   readers.Tables,
+			names: []string{"messages/**/"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate upper case column",
+			ids:   readers.Columns,
+			names: []string{"VALUE"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate empty column",
+			ids:   readers.Columns,
+			names: []string{""},
+			err:   readers.ErrInvalidIdentifier,
+		},
+		{
+			desc:  "validate known and injected columns",
+			ids:   readers.Columns,
+			names: []string{"name", "value--"},
+			err:   readers.ErrInvalidIdentifier,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.ids.Validate(tc.names...)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestNewIdentifiers(t *testing.T) {
+	assert.NotPanics(t, func() { readers.NewIdentifiers("messages", "value_sum") }, "creating well-formed identifiers panicked")
+	assert.Panics(t, func() { readers.NewIdentifiers("messages; DROP TABLE things") }, "creating malformed identifier didn't panic")
+	assert.Panics(t, func() { readers.NewIdentifiers("Messages") }, "creating upper case identifier didn't panic")
+}
+
+func TestIdentifierInjection(t *testing.T) {
+	injections := []string{
+		"time; DROP TABLE messages",
+		"time DESC, (SELECT 1)",
+		"name--",
+		`"name"`,
+		"name/**/",
+	}
+
+	for _, injection := range injections {
+		_, err := readers.ParseOrder(map[string]string{readers.SortByKey: injection})
+		assert.Equal(t, readers.ErrInvalidOrder, err, fmt.Sprintf("order by %q: expected %s got %s", injection, readers.ErrInvalidOrder, err))
+
+		_, err = readers.ParseFilter(fmt.Sprintf(`[{%q: "v"}]`, injection))
+		assert.Equal(t, readers.ErrInvalidFilter, err, fmt.Sprintf("filter by %q: expected %s got %s", injection, readers.ErrInvalidFilter, err))
+
+		agg := readers.Aggregation{
+			Function:   readers.AggregateCountDistinct,
+			Field:      injection,
+			NullPolicy: readers.SkipNulls,
+		}
+		err = agg.Validate()
+		assert.Equal(t, readers.ErrInvalidAggregation, err, fmt.Sprintf("count distinct %q: expected %s got %s", injection, readers.ErrInvalidAggregation, err))
+
+		agg = readers.Aggregation{
+			Function:   readers.AggregateCount,
+			Field:      readers.FieldValue,
+			GroupBy:    injection,
+			NullPolicy: readers.SkipNulls,
+		}
+		err = agg.Validate()
+		assert.Equal(t, readers.ErrInvalidAggregation, err, fmt.Sprintf("group by %q: expected %s got %s", injection, readers.ErrInvalidAggregation, err))
+	}
+}
@@ -39,8 +39,11 @@ func (repo *influxRepository) ReadAll(chanID string, offset, limit uint64, query
 		limit = maxLimit
 	}
 
-	condition := fmtCondition(chanID, query)
-	cmd := fmt.Sprintf(`SELECT * FROM messages WHERE %s ORDER BY time DESC LIMIT %d OFFSET %d`, condition, limit, offset)
+	cmd, countCmd, err := readQueries(chanID, offset, limit, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
+
 	q := influxdata.Query{
 		Command:  cmd,
 		Database: repo.database,
@@ -65,7 +68,7 @@ func (repo *influxRepository) ReadAll(chanID string, offset, limit uint64, query
 		ret = append(ret, parseMessage(result.Columns, v))
 	}
 
-	total, err := repo.count(condition)
+	total, err := repo.count(countCmd)
 	if err != nil {
 		return readers.MessagesPage{}, err
 	}
@@ -78,8 +81,58 @@ func (repo *influxRepository) ReadAll(chanID string, offset, limit uint64, query
 	}, nil
 }
 
-func (repo *influxRepository) count(condition string) (uint64, error) {
-	cmd := fmt.Sprintf(`SELECT COUNT(protocol) FROM messages WHERE %s`, condition)
+// Explain returns the InfluxQL queries only, since InfluxDB doesn't report
+// the execution plan of the queries reading the points.
+func (repo *influxRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	cmd, countCmd, err := readQueries(chanID, offset, limit, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return []readers.Explanation{{Query: cmd}, {Query: countCmd}}, nil
+}
+
+// readQueries creates the query selecting the page of the channel messages
+// and the query counting all of them.
+func readQueries(chanID string, offset, limit uint64, query map[string]string) (string, string, error) {
+	if query[readers.FilterKey] != "" {
+		return "", "", readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return "", "", readers.ErrUnsupportedTags
+	}
+
+	if query[readers.AfterKey] != "" || query[readers.IDsKey] != "" {
+		return "", "", readers.ErrUnsupportedCursor
+	}
+
+	// Points can be ordered by time only.
+	o, err := readers.ParseOrder(query)
+	if err != nil {
+		return "", "", err
+	}
+
+	if o.Field != readers.SortTime {
+		return "", "", readers.ErrUnsupportedSort
+	}
+
+	condition, err := fmtCondition(chanID, query)
+	if err != nil {
+		return "", "", err
+	}
+
+	cmd := fmt.Sprintf(`SELECT * FROM messages WHERE %s ORDER BY time %s LIMIT %d OFFSET %d`, condition, strings.ToUpper(o.Direction()), limit, offset)
+	countCmd := fmt.Sprintf(`SELECT COUNT(protocol) FROM messages WHERE %s`, condition)
+
+	return cmd, countCmd, nil
+}
+
+func (repo *influxRepository) count(cmd string) (uint64, error) {
 	q := influxdata.Query{
 		Command:  cmd,
 		Database: repo.database,
@@ -120,24 +173,239 @@ func (repo *influxRepository) count(condition string) (uint64, error) {
 	return strconv.ParseUint(count.String(), 10, 64)
 }
 
-func fmtCondition(chanID string, query map[string]string) string {
-	condition := fmt.Sprintf(`channel='%s'`, chanID)
+func (repo *influxRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	if query[readers.FilterKey] != "" {
+		return 0, 0, readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return 0, 0, readers.ErrUnsupportedTags
+	}
+
+	condition, err := fmtCondition(chanID, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	min, err := repo.timestamp("FIRST", condition)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	max, err := repo.timestamp("LAST", condition)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return min, max, nil
+}
+
+// timestamp returns the time of the point selected by the given selector
+// function (e.g. FIRST or LAST), or zero if there are no matching points.
+func (repo *influxRepository) timestamp(selector, condition string) (float64, error) {
+	cmd := fmt.Sprintf(`SELECT %s(protocol) FROM messages WHERE %s`, selector, condition)
+	q := influxdata.Query{
+		Command:  cmd,
+		Database: repo.database,
+	}
+
+	resp, err := repo.client.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Error() != nil {
+		return 0, resp.Error()
+	}
+
+	if len(resp.Results) < 1 ||
+		len(resp.Results[0].Series) < 1 ||
+		len(resp.Results[0].Series[0].Values) < 1 {
+		return 0, nil
+	}
+
+	timeIndex := 0
+	for i, col := range resp.Results[0].Series[0].Columns {
+		if col == "time" {
+			timeIndex = i
+			break
+		}
+	}
+
+	result := resp.Results[0].Series[0].Values[0]
+	if len(result) < timeIndex+1 {
+		return 0, nil
+	}
+
+	ts, ok := result[timeIndex].(string)
+	if !ok {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(t.UnixNano()) / float64(time.Second), nil
+}
+
+// functions and fields map the aggregate functions and the aggregated fields
+// to their InfluxQL counterparts.
+var (
+	functions = map[string]string{
+		readers.AggregateAvg:   "MEAN",
+		readers.AggregateMin:   "MIN",
+		readers.AggregateMax:   "MAX",
+		readers.AggregateSum:   "SUM",
+		readers.AggregateCount: "COUNT",
+	}
+	fields = map[string]string{
+		readers.FieldValue:    "value",
+		readers.FieldValueSum: "valueSum",
+	}
+)
+
+func (repo *influxRepository) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	if err := agg.Validate(); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	if agg.Grouped() {
+		return readers.AggregationResult{}, readers.ErrUnsupportedGrouping
+	}
+
+	if agg.Function == readers.AggregateCountDistinct {
+		return readers.AggregationResult{}, readers.ErrUnsupportedDistinct
+	}
+
+	if query[readers.FilterKey] != "" {
+		return readers.AggregationResult{}, readers.ErrUnsupportedFilter
+	}
+
+	if readers.HasTags(query) {
+		return readers.AggregationResult{}, readers.ErrUnsupportedTags
+	}
+
+	// Points are written without the fields they don't carry values of, so
+	// the protocol field, which every point has, is counted in order to get
+	// the number of messages.
+	condition, err := fmtCondition(chanID, query)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	field := fields[agg.Field]
+	cmd := fmt.Sprintf(`SELECT %s("%s") AS result, COUNT("%s") AS samples, COUNT(protocol) AS total FROM messages WHERE %s`,
+		functions[agg.Function], field, field, condition)
+	q := influxdata.Query{
+		Command:  cmd,
+		Database: repo.database,
+	}
+
+	resp, err := repo.client.Query(q)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+	if resp.Error() != nil {
+		return readers.AggregationResult{}, resp.Error()
+	}
+
+	if len(resp.Results) < 1 ||
+		len(resp.Results[0].Series) < 1 ||
+		len(resp.Results[0].Series[0].Values) < 1 {
+		return agg.Result(0, 0, 0)
+	}
+
+	series := resp.Results[0].Series[0]
+	values := map[string]float64{}
+	for i, col := range series.Columns {
+		if i >= len(series.Values[0]) {
+			break
+		}
+		if num, ok := series.Values[0][i].(json.Number); ok {
+			values[col], _ = num.Float64()
+		}
+	}
+
+	return agg.Result(values["result"], uint64(values["samples"]), uint64(values["total"]))
+}
+
+// fmtCondition creates the WHERE clause condition that matches the channel
+// messages filtered by the query. Bounded cross-channel query matches the
+// messages of all channels.
+func fmtCondition(chanID string, query map[string]string) (string, error) {
+	if err := readers.CheckChannel(chanID, query); err != nil {
+		return "", err
+	}
+
+	conditions := []string{}
+	if chanID != readers.AllChannels {
+		conditions = append(conditions, fmt.Sprintf(`channel='%s'`, chanID))
+	}
 	for name, value := range query {
 		switch name {
 		case
 			"channel",
 			"subtopic",
 			"publisher":
-			condition = fmt.Sprintf(`%s AND %s='%s'`, condition, name,
-				strings.Replace(value, "'", "\\'", -1))
+			conditions = append(conditions, fmt.Sprintf(`%s='%s'`, name,
+				strings.Replace(value, "'", "\\'", -1)))
 		case
 			"name",
 			"protocol":
-			condition = fmt.Sprintf(`%s AND "%s"='%s'`, condition, name,
-				strings.Replace(value, "\"", "\\\"", -1))
+			conditions = append(conditions, fmt.Sprintf(`"%s"='%s'`, name,
+				strings.Replace(value, "\"", "\\\"", -1)))
 		}
 	}
-	return condition
+
+	vf, err := readers.ParseValueFilter(query)
+	if err != nil {
+		return "", err
+	}
+
+	// Each value is stored in the field of its type, which is missing from
+	// the other points, so that the comparison matches that type only.
+	if vf.Equal != nil {
+		conditions = append(conditions, fmt.Sprintf(`value = %s`, formatValue(*vf.Equal)))
+	}
+
+	if vf.Greater != nil {
+		conditions = append(conditions, fmt.Sprintf(`value > %s`, formatValue(*vf.Greater)))
+	}
+
+	if vf.Less != nil {
+		conditions = append(conditions, fmt.Sprintf(`value < %s`, formatValue(*vf.Less)))
+	}
+
+	if vf.Bool != nil {
+		conditions = append(conditions, fmt.Sprintf(`boolValue = %t`, *vf.Bool))
+	}
+
+	if vf.String != nil {
+		conditions = append(conditions, fmt.Sprintf(`stringValue = '%s'`,
+			strings.Replace(*vf.String, "'", "\\'", -1)))
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return "", err
+	}
+
+	// Points are timestamped by the message time with nanosecond precision.
+	if tr.From != nil {
+		conditions = append(conditions, fmt.Sprintf(`time >= %d`, int64(*tr.From*1e9)))
+	}
+
+	if tr.To != nil {
+		conditions = append(conditions, fmt.Sprintf(`time < %d`, int64(*tr.To*1e9)))
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// formatValue renders the float value as the InfluxQL float literal.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
 }
 
 // ParseMessage and parseValues are util methods. Since InfluxDB client returns
@@ -172,3 +172,140 @@ func TestReadAll(t *testing.T) {
 		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %d got %d", desc, tc.page.Total, result.Total))
 	}
 }
+
+func TestBounds(t *testing.T) {
+	writer, err := writer.New(client, testDB, 1, time.Second)
+	require.Nil(t, err, fmt.Sprintf("Creating new InfluxDB writer expected to succeed: %s.\n", err))
+	boundsChan := "bounds"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	for i := 0; i < msgsNum; i++ {
+		m := mainflux.Message{
+			Channel:   boundsChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+			Time:      float64(now - int64(i)),
+		}
+		if i%valueFields == 0 {
+			m.Subtopic = subtopic
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := reader.New(client, testDB)
+
+	cases := map[string]struct {
+		chanID string
+		query  map[string]string
+		min    float64
+		max    float64
+	}{
+		"read bounds of channel": {
+			chanID: boundsChan,
+			min:    float64(now - msgsNum + 1),
+			max:    float64(now),
+		},
+		"read bounds of channel with subtopic": {
+			chanID: boundsChan,
+			query:  map[string]string{"subtopic": subtopic},
+			min:    float64(now - (msgsNum-1)/valueFields*valueFields),
+			max:    float64(now),
+		},
+		"read bounds of empty channel": {
+			chanID: emptyChan,
+			min:    0,
+			max:    0,
+		},
+	}
+
+	for desc, tc := range cases {
+		min, max, err := reader.Bounds(tc.chanID, tc.query)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.Equal(t, tc.min, min, fmt.Sprintf("%s: expected min %f got %f", desc, tc.min, min))
+		assert.Equal(t, tc.max, max, fmt.Sprintf("%s: expected max %f got %f", desc, tc.max, max))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	writer, err := writer.New(client, testDB, 1, time.Second)
+	require.Nil(t, err, fmt.Sprintf("Creating new InfluxDB writer expected to succeed: %s.\n", err))
+	aggChan := "aggregate"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	// Every other message carries string value, so the float value is null.
+	for i := 0; i < 10; i++ {
+		m := mainflux.Message{
+			Channel:   aggChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(now - int64(i)),
+		}
+		switch i % 2 {
+		case 0:
+			m.Value = &mainflux.Message_FloatValue{FloatValue: float64(i)}
+		default:
+			m.Value = &mainflux.Message_StringValue{StringValue: "value"}
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := reader.New(client, testDB)
+	cases := map[string]struct {
+		chanID string
+		agg    readers.Aggregation
+		res    readers.AggregationResult
+		err    error
+	}{
+		"average skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 4, Samples: 5},
+		},
+		"minimum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMin, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 5},
+		},
+		"maximum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMax, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 8, Samples: 5},
+		},
+		"sum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 20, Samples: 5},
+		},
+		"count skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCount, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 5, Samples: 5},
+		},
+		"average rejecting nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			err:    readers.ErrNullValue,
+		},
+		"count of distinct publishers": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCountDistinct, Field: "publisher", NullPolicy: readers.SkipNulls},
+			err:    readers.ErrUnsupportedDistinct,
+		},
+		"average of empty channel": {
+			chanID: emptyChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 0},
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := reader.Aggregate(tc.chanID, tc.agg, nil)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, res))
+	}
+}
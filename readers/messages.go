@@ -13,14 +13,39 @@ import (
 	"github.com/mainflux/mainflux"
 )
 
-// ErrNotFound indicates that requested entity doesn't exist.
-var ErrNotFound = errors.New("entity not found")
+var (
+	// ErrNotFound indicates that requested entity doesn't exist.
+	ErrNotFound = errors.New("entity not found")
+
+	// ErrTooManyRows indicates that the query would scan more rows than the
+	// repository is allowed to hold in memory.
+	ErrTooManyRows = errors.New("query exceeds row scan limit")
+)
 
 // MessageRepository specifies message reader API.
 type MessageRepository interface {
 	// ReadAll skips given number of messages for given channel and returns next
-	// limited number of messages.
+	// limited number of messages. If the query contains the cursor under the
+	// AfterKey, the offset is ignored and the limited number of messages
+	// following the cursor is returned in the ascending cursor order, so that
+	// the reading can be resumed without skipping or repeating messages.
+	// Otherwise, messages are returned in the listing order of the query,
+	// which defaults to the newest messages first.
 	ReadAll(string, uint64, uint64, map[string]string) (MessagesPage, error)
+
+	// Bounds returns the earliest and the latest timestamp of the messages
+	// that belong to the given channel and match the given query. Both
+	// timestamps are zero if there are no such messages.
+	Bounds(string, map[string]string) (float64, float64, error)
+
+	// Aggregate applies the aggregation to the messages that belong to the
+	// given channel and match the given query. Messages without the
+	// aggregated field value are handled according to the null policy.
+	Aggregate(string, Aggregation, map[string]string) (AggregationResult, error)
+
+	// Explain returns the queries ReadAll issues to the database for the
+	// same arguments, in order, without reading the messages.
+	Explain(string, uint64, uint64, map[string]string) ([]Explanation, error)
 }
 
 // MessagesPage contains page related metadata as well as list of messages that
@@ -30,4 +55,8 @@ type MessagesPage struct {
 	Offset   uint64
 	Limit    uint64
 	Messages []mainflux.Message
+
+	// Cursors contains the cursors of the page messages, in the same order.
+	// It is empty if the repository doesn't support keyset pagination.
+	Cursors []Cursor
 }
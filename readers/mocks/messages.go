@@ -8,6 +8,8 @@
 package mocks
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/mainflux/mainflux"
@@ -19,13 +21,22 @@ var _ readers.MessageRepository = (*messageRepositoryMock)(nil)
 type messageRepositoryMock struct {
 	mutex    sync.Mutex
 	messages map[string][]mainflux.Message
+	tags     map[string][]map[string]string
 }
 
 // NewMessageRepository returns mock implementation of message repository.
 func NewMessageRepository(messages map[string][]mainflux.Message) readers.MessageRepository {
+	return NewTaggedMessageRepository(messages, nil)
+}
+
+// NewTaggedMessageRepository returns mock implementation of message repository
+// whose messages are tagged. Tags of the channel messages are given in the
+// same order as the messages.
+func NewTaggedMessageRepository(messages map[string][]mainflux.Message, tags map[string][]map[string]string) readers.MessageRepository {
 	return &messageRepositoryMock{
 		mutex:    sync.Mutex{},
 		messages: messages,
+		tags:     tags,
 	}
 }
 
@@ -33,11 +44,40 @@ func (repo *messageRepositoryMock) ReadAll(chanID string, offset, limit uint64,
 	repo.mutex.Lock()
 	defer repo.mutex.Unlock()
 
+	messages, cursors, err := repo.inRangeCursors(chanID, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	if after := query[readers.AfterKey]; after != "" {
+		messages, cursors, err = following(messages, cursors, after)
+		if err != nil {
+			return readers.MessagesPage{}, err
+		}
+		offset = 0
+	}
+
+	if query[readers.IDsKey] != "" {
+		messages, cursors, err = identified(messages, cursors, query)
+		if err != nil {
+			return readers.MessagesPage{}, err
+		}
+	}
+
+	_, byOrder := query[readers.SortOrderKey]
+	_, byField := query[readers.SortByKey]
+	if (byOrder || byField) && query[readers.AfterKey] == "" {
+		messages, cursors, err = sorted(messages, cursors, query)
+		if err != nil {
+			return readers.MessagesPage{}, err
+		}
+	}
+
 	end := offset + limit
 
-	numOfMessages := uint64(len(repo.messages[chanID]))
+	numOfMessages := uint64(len(messages))
 	if offset < 0 || offset >= numOfMessages {
-		return readers.MessagesPage{}, nil
+		return readers.MessagesPage{Total: numOfMessages, Offset: offset, Limit: limit}, nil
 	}
 
 	if limit < 1 {
@@ -52,6 +92,414 @@ func (repo *messageRepositoryMock) ReadAll(chanID string, offset, limit uint64,
 		Total:    numOfMessages,
 		Limit:    limit,
 		Offset:   offset,
-		Messages: repo.messages[chanID][offset:end],
+		Messages: messages[offset:end],
+		Cursors:  cursors[offset:end],
 	}, nil
 }
+
+// Explain describes the read by the arguments, since the mock issues no
+// queries.
+func (repo *messageRepositoryMock) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	keys := []string{}
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []interface{}{chanID}
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("%s=%s", k, query[k]))
+	}
+	args = append(args, offset, limit)
+
+	return []readers.Explanation{{Query: "read_all", Args: args}}, nil
+}
+
+func (repo *messageRepositoryMock) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	messages, err := repo.inRange(chanID, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var min, max float64
+	for i, msg := range messages {
+		if i == 0 || msg.Time < min {
+			min = msg.Time
+		}
+		if i == 0 || msg.Time > max {
+			max = msg.Time
+		}
+	}
+
+	return min, max, nil
+}
+
+func (repo *messageRepositoryMock) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	messages, err := repo.inRange(chanID, query)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	value, samples := aggregate(agg, messages)
+	res, err := agg.Result(value, samples, uint64(len(messages)))
+	if err != nil || !agg.Grouped() {
+		return res, err
+	}
+
+	type group struct {
+		start float64
+		key   string
+	}
+	partitions := map[group][]mainflux.Message{}
+	groups := []group{}
+	for _, msg := range messages {
+		g := group{key: groupKey(msg, agg.GroupBy)}
+		if agg.Interval != "" {
+			g.start = readers.BucketStart(msg.Time, agg.Interval)
+		}
+		if _, ok := partitions[g]; !ok {
+			groups = append(groups, g)
+		}
+		partitions[g] = append(partitions[g], msg)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].start != groups[j].start {
+			return groups[i].start < groups[j].start
+		}
+		return groups[i].key < groups[j].key
+	})
+
+	rows := []readers.AggregationRow{}
+	for _, g := range groups {
+		value, samples := aggregate(agg, partitions[g])
+		rows = append(rows, readers.AggregationRow{
+			Start:   g.start,
+			Key:     g.key,
+			Value:   value,
+			Samples: samples,
+			Total:   uint64(len(partitions[g])),
+		})
+	}
+
+	if agg.Paged() {
+		rows, res.TotalGroups = agg.Page(rows)
+	}
+
+	return agg.Nest(res, rows)
+}
+
+func aggregate(agg readers.Aggregation, messages []mainflux.Message) (float64, uint64) {
+	if agg.Function == readers.AggregateCountDistinct {
+		return countDistinct(agg.Field, messages)
+	}
+
+	var value float64
+	var samples uint64
+	for _, msg := range messages {
+		v, ok := fieldValue(msg, agg.Field)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case samples == 0:
+			value = v
+		case agg.Function == readers.AggregateMin && v < value:
+			value = v
+		case agg.Function == readers.AggregateMax && v > value:
+			value = v
+		case agg.Function == readers.AggregateAvg, agg.Function == readers.AggregateSum:
+			value += v
+		}
+		samples++
+	}
+
+	switch agg.Function {
+	case readers.AggregateAvg:
+		if samples > 0 {
+			value /= float64(samples)
+		}
+	case readers.AggregateCount:
+		value = float64(samples)
+	}
+
+	return value, samples
+}
+
+// countDistinct counts the distinct values of the text field, which is
+// treated as null if empty.
+func countDistinct(field string, messages []mainflux.Message) (float64, uint64) {
+	distinct := map[string]bool{}
+	var samples uint64
+	for _, msg := range messages {
+		key := groupKey(msg, field)
+		if key == "" {
+			continue
+		}
+		distinct[key] = true
+		samples++
+	}
+
+	return float64(len(distinct)), samples
+}
+
+func groupKey(msg mainflux.Message, field string) string {
+	switch field {
+	case "subtopic":
+		return msg.Subtopic
+	case "publisher":
+		return msg.Publisher
+	case "protocol":
+		return msg.Protocol
+	case "name":
+		return msg.Name
+	default:
+		return ""
+	}
+}
+
+// inRange returns the channel messages within the time range of the query.
+func (repo *messageRepositoryMock) inRange(chanID string, query map[string]string) ([]mainflux.Message, error) {
+	messages, _, err := repo.inRangeCursors(chanID, query)
+	return messages, err
+}
+
+// inRangeCursors returns the channel messages within the time range of the
+// query, having the query tags and published by the query publisher, if any,
+// along with their cursors. Position of the
+// message in the channel breaks the ties between the messages published at
+// the same time. Cross-channel query returns the messages of the channels in
+// the order of their IDs, which prefix the positions.
+func (repo *messageRepositoryMock) inRangeCursors(chanID string, query map[string]string) ([]mainflux.Message, []readers.Cursor, error) {
+	if err := readers.CheckChannel(chanID, query); err != nil {
+		return nil, nil, err
+	}
+
+	if chanID == readers.AllChannels {
+		return repo.crossChannel(query)
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags, err := readers.Tags(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vf, err := readers.ParseValueFilter(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages := []mainflux.Message{}
+	cursors := []readers.Cursor{}
+	for i, msg := range repo.messages[chanID] {
+		if p := query["publisher"]; p != "" && msg.Publisher != p {
+			continue
+		}
+		if !vf.Matches(msg) {
+			continue
+		}
+		if tr.Contains(msg.Time) && repo.tagged(chanID, i, tags) {
+			messages = append(messages, msg)
+			cursors = append(cursors, readers.Cursor{Time: msg.Time, ID: fmt.Sprintf("%08d", i)})
+		}
+	}
+
+	return messages, cursors, nil
+}
+
+func (repo *messageRepositoryMock) crossChannel(query map[string]string) ([]mainflux.Message, []readers.Cursor, error) {
+	ids := []string{}
+	for id := range repo.messages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	messages := []mainflux.Message{}
+	cursors := []readers.Cursor{}
+	for _, id := range ids {
+		msgs, cs, err := repo.inRangeCursors(id, query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := range cs {
+			cs[i].ID = fmt.Sprintf("%s-%s", id, cs[i].ID)
+		}
+		messages = append(messages, msgs...)
+		cursors = append(cursors, cs...)
+	}
+
+	return messages, cursors, nil
+}
+
+// tagged returns true if the channel message at the given position has all of
+// the tags.
+func (repo *messageRepositoryMock) tagged(chanID string, i int, tags map[string]string) bool {
+	var msgTags map[string]string
+	if i < len(repo.tags[chanID]) {
+		msgTags = repo.tags[chanID][i]
+	}
+
+	for key, value := range tags {
+		if msgTags[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// following returns the messages following the cursor, in the cursor order.
+func following(messages []mainflux.Message, cursors []readers.Cursor, after string) ([]mainflux.Message, []readers.Cursor, error) {
+	c, err := readers.ParseCursor(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idxs := []int{}
+	for i := range cursors {
+		if c.Before(cursors[i]) {
+			idxs = append(idxs, i)
+		}
+	}
+	sort.Slice(idxs, func(i, j int) bool {
+		return cursors[idxs[i]].Before(cursors[idxs[j]])
+	})
+
+	msgs := []mainflux.Message{}
+	curs := []readers.Cursor{}
+	for _, i := range idxs {
+		msgs = append(msgs, messages[i])
+		curs = append(curs, cursors[i])
+	}
+
+	return msgs, curs, nil
+}
+
+// sorted returns the messages in the listing order of the query. Messages are
+// otherwise kept in the order they are given in, so that the order is only
+// applied if the query asks for it explicitly.
+func sorted(messages []mainflux.Message, cursors []readers.Cursor, query map[string]string) ([]mainflux.Message, []readers.Cursor, error) {
+	o, err := readers.ParseOrder(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idxs := make([]int, len(messages))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.SliceStable(idxs, func(i, j int) bool {
+		a, b := idxs[i], idxs[j]
+		if o.Desc {
+			a, b = b, a
+		}
+
+		switch c := compareField(messages[a], messages[b], o.Field); {
+		case c != 0:
+			return c < 0
+		case cursors[a] != cursors[b]:
+			return cursors[a].Before(cursors[b])
+		}
+
+		return false
+	})
+
+	msgs := []mainflux.Message{}
+	curs := []readers.Cursor{}
+	for _, i := range idxs {
+		msgs = append(msgs, messages[i])
+		curs = append(curs, cursors[i])
+	}
+
+	return msgs, curs, nil
+}
+
+// compareField compares the sort field values of the messages, returning a
+// negative number if the first one precedes the second one.
+func compareField(a, b mainflux.Message, field string) int {
+	var x, y string
+	switch field {
+	case readers.SortTime:
+		return compareFloat(a.Time, b.Time)
+	case readers.FieldValue:
+		return compareFloat(a.GetFloatValue(), b.GetFloatValue())
+	case "subtopic":
+		x, y = a.Subtopic, b.Subtopic
+	case "publisher":
+		x, y = a.Publisher, b.Publisher
+	case "protocol":
+		x, y = a.Protocol, b.Protocol
+	case "name":
+		x, y = a.Name, b.Name
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
+}
+
+// identified returns the messages looked up by their cursors.
+func identified(messages []mainflux.Message, cursors []readers.Cursor, query map[string]string) ([]mainflux.Message, []readers.Cursor, error) {
+	ids, err := readers.ParseIDs(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msgs := []mainflux.Message{}
+	curs := []readers.Cursor{}
+	for i, c := range cursors {
+		for _, id := range ids {
+			if c == id {
+				msgs = append(msgs, messages[i])
+				curs = append(curs, c)
+				break
+			}
+		}
+	}
+
+	return msgs, curs, nil
+}
+
+func fieldValue(msg mainflux.Message, field string) (float64, bool) {
+	switch field {
+	case readers.FieldValue:
+		if v, ok := msg.Value.(*mainflux.Message_FloatValue); ok {
+			return v.FloatValue, true
+		}
+	case readers.FieldValueSum:
+		if msg.ValueSum != nil {
+			return msg.ValueSum.Value, true
+		}
+	}
+
+	return 0, false
+}
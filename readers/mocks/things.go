@@ -18,17 +18,38 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-var errUnauthorized = status.Error(codes.PermissionDenied, "missing or invalid credentials provided")
+var (
+	errUnauthorized = status.Error(codes.PermissionDenied, "missing or invalid credentials provided")
+	errExpired      = status.Error(codes.Unauthenticated, "thing key expired")
+	errUnavailable  = status.Error(codes.Unavailable, "things service unavailable")
+)
 
 var _ mainflux.ThingsServiceClient = (*thingsServiceMock)(nil)
 
-type thingsServiceMock struct{}
+type thingsServiceMock struct {
+	shares map[string]string
+	scoped map[string]bool
+}
 
 // NewThingsService returns mock implementation of things service
 func NewThingsService() mainflux.ThingsServiceClient {
 	return thingsServiceMock{}
 }
 
+// NewSharingThingsService returns mock implementation of things service that
+// accepts the provided share link tokens. The map associates share link
+// tokens with the channels they grant access to.
+func NewSharingThingsService(shares map[string]string) mainflux.ThingsServiceClient {
+	return thingsServiceMock{shares: shares}
+}
+
+// NewScopingThingsService returns mock implementation of things service that
+// restricts the things to their own messages of the publisher-scoped
+// channels. The map tells whether the channel is publisher-scoped.
+func NewScopingThingsService(scoped map[string]bool) mainflux.ThingsServiceClient {
+	return thingsServiceMock{scoped: scoped}
+}
+
 func (svc thingsServiceMock) CanAccess(ctx context.Context, in *mainflux.AccessReq, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
 	token := in.GetToken()
 	if token == "invalid" {
@@ -39,6 +60,13 @@ func (svc thingsServiceMock) CanAccess(ctx context.Context, in *mainflux.AccessR
 		return nil, errUnauthorized
 	}
 
+	switch token {
+	case "expired":
+		return nil, errExpired
+	case "unavailable":
+		return nil, errUnavailable
+	}
+
 	return &mainflux.ThingID{Value: token}, nil
 }
 
@@ -46,6 +74,33 @@ func (svc thingsServiceMock) CanAccessByID(context.Context, *mainflux.AccessByID
 	panic("not implemented")
 }
 
+func (svc thingsServiceMock) CanReadShared(ctx context.Context, in *mainflux.AccessReq, opts ...grpc.CallOption) (*empty.Empty, error) {
+	chanID, ok := svc.shares[in.GetToken()]
+	if !ok || chanID != in.GetChanID() {
+		return nil, errUnauthorized
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (svc thingsServiceMock) CanReadMessages(ctx context.Context, in *mainflux.AccessReq, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
+	id, err := svc.CanAccess(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	id.PublisherScoped = svc.scoped[in.GetChanID()]
+	return id, nil
+}
+
+func (svc thingsServiceMock) ListThingsByChannel(context.Context, *mainflux.AccessReq, ...grpc.CallOption) (mainflux.ThingsService_ListThingsByChannelClient, error) {
+	panic("not implemented")
+}
+
 func (svc thingsServiceMock) Identify(context.Context, *mainflux.Token, ...grpc.CallOption) (*mainflux.ThingID, error) {
 	panic("not implemented")
 }
+
+func (svc thingsServiceMock) IdentifyBatch(context.Context, *mainflux.Tokens, ...grpc.CallOption) (*mainflux.ThingIDs, error) {
+	panic("not implemented")
+}
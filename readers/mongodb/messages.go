@@ -9,61 +9,77 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/readers"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const collection = "mainflux"
 
+// fields maps the aggregated message fields to the document fields.
+var fields = map[string]string{
+	readers.FieldValue:    "value",
+	readers.FieldValueSum: "valueSum",
+}
+
 var _ readers.MessageRepository = (*mongoRepository)(nil)
 
 type mongoRepository struct {
-	db *mongo.Database
+	db        *mongo.Database
+	batchSize int32
 }
 
 // Message struct is used as a MongoDB representation of Mainflux message.
 type message struct {
-	Channel     string   `bson:"channel,omitempty"`
-	Subtopic    string   `bson:"subtopic,omitempty"`
-	Publisher   string   `bson:"publisher,omitempty"`
-	Protocol    string   `bson:"protocol,omitempty"`
-	Name        string   `bson:"name,omitempty"`
-	Unit        string   `bson:"unit,omitempty"`
-	FloatValue  *float64 `bson:"value,omitempty"`
-	StringValue *string  `bson:"stringValue,omitempty"`
-	BoolValue   *bool    `bson:"boolValue,omitempty"`
-	DataValue   *string  `bson:"dataValue,omitempty"`
-	ValueSum    *float64 `bson:"valueSum,omitempty"`
-	Time        float64  `bson:"time,omitempty"`
-	UpdateTime  float64  `bson:"updateTime,omitempty"`
-	Link        string   `bson:"link,omitempty"`
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Channel     string             `bson:"channel,omitempty"`
+	Subtopic    string             `bson:"subtopic,omitempty"`
+	Publisher   string             `bson:"publisher,omitempty"`
+	Protocol    string             `bson:"protocol,omitempty"`
+	Name        string             `bson:"name,omitempty"`
+	Unit        string             `bson:"unit,omitempty"`
+	FloatValue  *float64           `bson:"value,omitempty"`
+	StringValue *string            `bson:"stringValue,omitempty"`
+	BoolValue   *bool              `bson:"boolValue,omitempty"`
+	DataValue   *string            `bson:"dataValue,omitempty"`
+	ValueSum    *float64           `bson:"valueSum,omitempty"`
+	Time        float64            `bson:"time,omitempty"`
+	UpdateTime  float64            `bson:"updateTime,omitempty"`
+	Link        string             `bson:"link,omitempty"`
 }
 
-// New returns new MongoDB reader.
-func New(db *mongo.Database) readers.MessageRepository {
+// New returns new MongoDB reader. Read messages are fetched from the
+// database in batches of batchSize documents, trading the memory for the
+// round trips. Zero batchSize keeps the batch size of the server.
+func New(db *mongo.Database, batchSize int32) readers.MessageRepository {
 	return mongoRepository{
-		db: db,
+		db:        db,
+		batchSize: batchSize,
 	}
 }
 
 func (repo mongoRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
 	col := repo.db.Collection(collection)
-	sortMap := map[string]interface{}{
-		"time": -1,
+
+	filter, opts, err := repo.findQuery(chanID, offset, limit, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
 	}
 
-	filter := fmtCondition(chanID, query)
-	cursor, err := col.Find(context.Background(), filter, options.Find().SetSort(sortMap).SetLimit(int64(limit)).SetSkip(int64(offset)))
+	cursor, err := col.Find(context.Background(), filter, opts)
 	if err != nil {
 		return readers.MessagesPage{}, err
 	}
 	defer cursor.Close(context.Background())
 
 	messages := []mainflux.Message{}
+	cursors := []readers.Cursor{}
 	for cursor.Next(context.Background()) {
 		var m message
 		if err := cursor.Decode(&m); err != nil {
@@ -98,6 +114,7 @@ func (repo mongoRepository) ReadAll(chanID string, offset, limit uint64, query m
 		}
 
 		messages = append(messages, msg)
+		cursors = append(cursors, readers.Cursor{Time: m.Time, ID: m.ID.Hex()})
 	}
 
 	total, err := col.CountDocuments(context.Background(), filter)
@@ -110,19 +127,246 @@ func (repo mongoRepository) ReadAll(chanID string, offset, limit uint64, query m
 
 	return readers.MessagesPage{
 		Total:    uint64(total),
-		Offset:   offset,
+		Offset:   uint64(*opts.Skip),
 		Limit:    limit,
 		Messages: messages,
+		Cursors:  cursors,
 	}, nil
 }
 
-func fmtCondition(chanID string, query map[string]string) *bson.D {
-	filter := bson.D{
-		bson.E{
-			Key:   "channel",
-			Value: chanID,
+// Explain returns the commands only, leaving the plan to the server side
+// explain command.
+func (repo mongoRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	filter, opts, err := repo.findQuery(chanID, offset, limit, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Documents are counted by the aggregation, the way the driver counts
+	// them.
+	cmds := []bson.D{
+		{
+			{Key: "find", Value: collection},
+			{Key: "filter", Value: filter},
+			{Key: "sort", Value: opts.Sort},
+			{Key: "skip", Value: opts.Skip},
+			{Key: "limit", Value: opts.Limit},
+		},
+		{
+			{Key: "aggregate", Value: collection},
+			{Key: "pipeline", Value: bson.A{
+				bson.M{"$match": filter},
+				bson.M{"$group": bson.M{"_id": 1, "n": bson.M{"$sum": 1}}},
+			}},
 		},
 	}
+
+	explanations := []readers.Explanation{}
+	for _, cmd := range cmds {
+		doc, err := bson.MarshalExtJSON(cmd, false, false)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, readers.Explanation{Query: string(doc)})
+	}
+
+	return explanations, nil
+}
+
+// findQuery creates the filter and the options of the query finding the page
+// of the channel messages.
+func (repo mongoRepository) findQuery(chanID string, offset, limit uint64, query map[string]string) (*bson.D, *options.FindOptions, error) {
+	filter, err := fmtCondition(chanID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o, err := readers.ParseOrder(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort := sortDoc(o)
+	if after := query[readers.AfterKey]; after != "" {
+		cond, err := fmtCursorCondition(after)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		*filter = append(*filter, bson.E{Key: "$and", Value: bson.A{cond}})
+		sort = bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}}
+		offset = 0
+	}
+
+	if query[readers.IDsKey] != "" {
+		cond, err := fmtIDsCondition(query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		*filter = append(*filter, bson.E{Key: "$and", Value: bson.A{cond}})
+	}
+
+	opts := options.Find().SetSort(sort).SetLimit(int64(limit)).SetSkip(int64(offset))
+	if repo.batchSize > 0 {
+		opts = opts.SetBatchSize(repo.batchSize)
+	}
+
+	return filter, opts, nil
+}
+
+// sortDoc creates the sort document of the listing order. Document ID breaks
+// the ties between the messages published at the same time, so that the order
+// is stable and the cursors are unique.
+func sortDoc(o readers.Order) bson.D {
+	dir := 1
+	if o.Desc {
+		dir = -1
+	}
+
+	sort := bson.D{}
+	if o.Field != readers.SortTime {
+		sort = append(sort, bson.E{Key: o.Field, Value: dir})
+	}
+
+	return append(sort, bson.E{Key: "time", Value: dir}, bson.E{Key: "_id", Value: dir})
+}
+
+func (repo mongoRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	col := repo.db.Collection(collection)
+
+	filter, err := fmtCondition(chanID, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id": nil,
+			"min": bson.M{"$min": "$time"},
+			"max": bson.M{"$max": "$time"},
+		}},
+	}
+
+	cursor, err := col.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	if !cursor.Next(context.Background()) {
+		return 0, 0, cursor.Err()
+	}
+
+	var bounds struct {
+		Min float64 `bson:"min"`
+		Max float64 `bson:"max"`
+	}
+	if err := cursor.Decode(&bounds); err != nil {
+		return 0, 0, err
+	}
+
+	return bounds.Min, bounds.Max, nil
+}
+
+func (repo mongoRepository) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	if err := agg.Validate(); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	if agg.Grouped() {
+		return readers.AggregationResult{}, readers.ErrUnsupportedGrouping
+	}
+
+	name := fields[agg.Field]
+	if agg.Function == readers.AggregateCountDistinct {
+		// Text fields are stored under the names they are grouped by.
+		name = agg.Field
+	}
+	field := fmt.Sprintf("$%s", name)
+	// Messages are stored without the fields they don't carry values of, so
+	// the missing field is treated the same way as the null one.
+	hasValue := bson.M{"$cond": bson.A{
+		bson.M{"$in": bson.A{bson.M{"$type": field}, bson.A{"missing", "null"}}}, 0, 1,
+	}}
+
+	value := bson.M{fmt.Sprintf("$%s", agg.Function): field}
+	switch agg.Function {
+	case readers.AggregateCount:
+		value = bson.M{"$sum": hasValue}
+	case readers.AggregateCountDistinct:
+		value = bson.M{"$addToSet": field}
+	}
+
+	filter, err := fmtCondition(chanID, query)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id":     nil,
+			"value":   value,
+			"samples": bson.M{"$sum": hasValue},
+			"total":   bson.M{"$sum": 1},
+		}},
+	}
+	if agg.Function == readers.AggregateCountDistinct {
+		pipeline = append(pipeline, bson.M{"$project": bson.M{
+			"value":   bson.M{"$size": "$value"},
+			"samples": 1,
+			"total":   1,
+		}})
+	}
+
+	col := repo.db.Collection(collection)
+	cursor, err := col.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+	defer cursor.Close(context.Background())
+
+	if !cursor.Next(context.Background()) {
+		return readers.AggregationResult{}, cursor.Err()
+	}
+
+	var res struct {
+		Value   *float64 `bson:"value"`
+		Samples int64    `bson:"samples"`
+		Total   int64    `bson:"total"`
+	}
+	if err := cursor.Decode(&res); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	var v float64
+	if res.Value != nil {
+		v = *res.Value
+	}
+
+	return agg.Result(v, uint64(res.Samples), uint64(res.Total))
+}
+
+// fmtCondition creates the filter that matches the channel messages filtered
+// by the query. Bounded cross-channel query matches the messages of all
+// channels. Filter groups are OR-ed, while the fields within the group
+// are AND-ed. Tag filters match the fields of the message tags document.
+func fmtCondition(chanID string, query map[string]string) (*bson.D, error) {
+	groups, err := readers.ParseFilter(query[readers.FilterKey])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := readers.CheckChannel(chanID, query); err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{}
+	if chanID != readers.AllChannels {
+		filter = append(filter, bson.E{Key: "channel", Value: chanID})
+	}
 	for name, value := range query {
 		switch name {
 		case
@@ -135,5 +379,121 @@ func fmtCondition(chanID string, query map[string]string) *bson.D {
 		}
 	}
 
-	return &filter
+	vf, err := readers.ParseValueFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if vf.Float() {
+		// Float value filters match the float values only, i.e. the SenML
+		// records carrying the value field.
+		bounds := bson.M{"$exists": true}
+		if vf.Equal != nil {
+			bounds["$eq"] = *vf.Equal
+		}
+		if vf.Greater != nil {
+			bounds["$gt"] = *vf.Greater
+		}
+		if vf.Less != nil {
+			bounds["$lt"] = *vf.Less
+		}
+		filter = append(filter, bson.E{Key: "value", Value: bounds})
+	}
+
+	if vf.Bool != nil {
+		filter = append(filter, bson.E{Key: "boolValue", Value: *vf.Bool})
+	}
+
+	if vf.String != nil {
+		filter = append(filter, bson.E{Key: "stringValue", Value: *vf.String})
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if tr.From != nil || tr.To != nil {
+		bounds := bson.M{}
+		if tr.From != nil {
+			bounds["$gte"] = *tr.From
+		}
+		if tr.To != nil {
+			bounds["$lt"] = *tr.To
+		}
+		filter = append(filter, bson.E{Key: "time", Value: bounds})
+	}
+
+	tags, err := readers.Tags(query)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		filter = append(filter, bson.E{Key: fmt.Sprintf("tags.%s", key), Value: tags[key]})
+	}
+
+	if len(groups) > 0 {
+		or := bson.A{}
+		for _, group := range groups {
+			cond := bson.M{}
+			for field, value := range group {
+				cond[field] = value
+			}
+			or = append(or, cond)
+		}
+		filter = append(filter, bson.E{Key: "$or", Value: or})
+	}
+
+	return &filter, nil
+}
+
+// fmtCursorCondition creates the condition that matches the messages
+// following the cursor.
+func fmtCursorCondition(after string) (bson.M, error) {
+	c, err := readers.ParseCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ID == "" {
+		return bson.M{"time": bson.M{"$gte": c.Time}}, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, readers.ErrInvalidCursor
+	}
+
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"time": bson.M{"$gt": c.Time}},
+			bson.M{"time": c.Time, "_id": bson.M{"$gt": id}},
+		},
+	}, nil
+}
+
+// fmtIDsCondition creates the condition that matches the messages looked up
+// by their cursors.
+func fmtIDsCondition(query map[string]string) (bson.M, error) {
+	cursors, err := readers.ParseIDs(query)
+	if err != nil {
+		return nil, err
+	}
+
+	or := bson.A{}
+	for _, c := range cursors {
+		id, err := primitive.ObjectIDFromHex(c.ID)
+		if err != nil {
+			return nil, readers.ErrInvalidCursor
+		}
+		or = append(or, bson.M{"time": c.Time, "_id": id})
+	}
+
+	return bson.M{"$or": or}, nil
 }
@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,6 +24,8 @@ import (
 	"github.com/mainflux/mainflux"
 
 	log "github.com/mainflux/mainflux/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -86,7 +89,7 @@ func TestReadAll(t *testing.T) {
 		}
 	}
 
-	reader := mreaders.New(db)
+	reader := mreaders.New(db, 0)
 
 	cases := map[string]struct {
 		chanID string
@@ -161,3 +164,523 @@ func TestReadAll(t *testing.T) {
 		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %v got %v", desc, tc.page.Total, result.Total))
 	}
 }
+
+func TestBounds(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	boundsChan := "bounds"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	for i := 0; i < msgsNum; i++ {
+		m := mainflux.Message{
+			Channel:   boundsChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+			Time:      float64(now - int64(i)),
+		}
+		if i%valueFields == 0 {
+			m.Subtopic = subtopic
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		chanID string
+		query  map[string]string
+		min    float64
+		max    float64
+	}{
+		"read bounds of channel": {
+			chanID: boundsChan,
+			min:    float64(now - msgsNum + 1),
+			max:    float64(now),
+		},
+		"read bounds of channel with subtopic": {
+			chanID: boundsChan,
+			query:  map[string]string{"subtopic": subtopic},
+			min:    float64(now - (msgsNum-1)/valueFields*valueFields),
+			max:    float64(now),
+		},
+		"read bounds of empty channel": {
+			chanID: emptyChan,
+			min:    0,
+			max:    0,
+		},
+	}
+
+	for desc, tc := range cases {
+		min, max, err := reader.Bounds(tc.chanID, tc.query)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.Equal(t, tc.min, min, fmt.Sprintf("%s: expected min %f got %f", desc, tc.min, min))
+		assert.Equal(t, tc.max, max, fmt.Sprintf("%s: expected max %f got %f", desc, tc.max, max))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	aggChan := "aggregate"
+	emptyChan := "empty"
+	now := time.Now().Unix()
+	// Every other message carries string value, so the float value is null.
+	// Messages are published by three publishers.
+	for i := 0; i < 10; i++ {
+		m := mainflux.Message{
+			Channel:   aggChan,
+			Publisher: fmt.Sprintf("%d", i%3),
+			Protocol:  "mqtt",
+			Time:      float64(now - int64(i)),
+		}
+		switch i % 2 {
+		case 0:
+			m.Value = &mainflux.Message_FloatValue{FloatValue: float64(i)}
+		default:
+			m.Value = &mainflux.Message_StringValue{StringValue: "value"}
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := mreaders.New(db, 0)
+	cases := map[string]struct {
+		chanID string
+		agg    readers.Aggregation
+		res    readers.AggregationResult
+		err    error
+	}{
+		"average skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 4, Samples: 5},
+		},
+		"minimum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMin, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 5},
+		},
+		"maximum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMax, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 8, Samples: 5},
+		},
+		"sum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 20, Samples: 5},
+		},
+		"count skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCount, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 5, Samples: 5},
+		},
+		"average rejecting nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			err:    readers.ErrNullValue,
+		},
+		"count of distinct publishers": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCountDistinct, Field: "publisher", NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 3, Samples: 10},
+		},
+		"average of empty channel": {
+			chanID: emptyChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 0},
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := reader.Aggregate(tc.chanID, tc.agg, nil)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, res))
+	}
+}
+
+func TestReadAllFilterGroups(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+
+	filterChanID := "filter"
+	pubs := []string{"a", "b"}
+	subtopics := []string{"x", "y"}
+	matched := []mainflux.Message{}
+	now := time.Now().Unix()
+	for i, pub := range pubs {
+		for j, sub := range subtopics {
+			msg := mainflux.Message{
+				Channel:   filterChanID,
+				Publisher: pub,
+				Subtopic:  sub,
+				Protocol:  "mqtt",
+				Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+				Time:      float64(now - int64(i*len(subtopics)+j)),
+			}
+			err := writer.Save(msg)
+			require.Nil(t, err, fmt.Sprintf("failed to store message to MongoDB: %s", err))
+			if (pub == "a" && sub == "x") || (pub == "b" && sub == "y") {
+				matched = append(matched, msg)
+			}
+		}
+	}
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		filter   string
+		messages []mainflux.Message
+		err      error
+	}{
+		"read messages matching any of two filter groups": {
+			filter:   `[{"publisher":"a","subtopic":"x"},{"publisher":"b","subtopic":"y"}]`,
+			messages: matched,
+		},
+		"read messages matching single filter group": {
+			filter:   `[{"publisher":"a","subtopic":"x"}]`,
+			messages: matched[:1],
+		},
+		"read messages with malformed filter": {
+			filter:   `{"publisher":"a"}`,
+			messages: nil,
+			err:      readers.ErrInvalidFilter,
+		},
+	}
+
+	for desc, tc := range cases {
+		query := map[string]string{readers.FilterKey: tc.filter}
+		result, err := reader.ReadAll(filterChanID, 0, 10, query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+		assert.ElementsMatch(t, tc.messages, result.Messages, fmt.Sprintf("%s: expected %v got %v", desc, tc.messages, result.Messages))
+		assert.Equal(t, uint64(len(tc.messages)), result.Total, fmt.Sprintf("%s: expected %d got %d", desc, len(tc.messages), result.Total))
+	}
+}
+
+// TestReadAllTimeRange checks that the time range includes the message at
+// its start and excludes the one at its end.
+func TestReadAllTimeRange(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	rangeChanID := "time-range"
+
+	from, to := 1000.0, 2000.0
+	for _, tm := range []float64{from - 1, from, (from + to) / 2, to} {
+		msg := mainflux.Message{
+			Channel:   rangeChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      tm,
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read messages within time range": {
+			query: map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"},
+			times: []float64{from, (from + to) / 2},
+		},
+		"read messages since time": {
+			query: map[string]string{readers.FromKey: "1000"},
+			times: []float64{from, (from + to) / 2, to},
+		},
+		"read messages until time": {
+			query: map[string]string{readers.ToKey: "2000"},
+			times: []float64{from - 1, from, (from + to) / 2},
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(rangeChanID, 0, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
+
+func TestReadAllTags(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	tagsChanID := "tags"
+
+	// Writer doesn't store the tags, so the tagged documents are inserted
+	// directly.
+	for i, site := range []string{"plant-1", "plant-2", "plant-1"} {
+		doc := bson.M{
+			"channel":   tagsChanID,
+			"publisher": "1",
+			"protocol":  "mqtt",
+			"time":      float64(1000 + i),
+			"tags":      bson.M{"site": site},
+		}
+		_, err := db.Collection(collection).InsertOne(context.Background(), doc)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read messages filtered by tag": {
+			query: map[string]string{"tag.site": "plant-1"},
+			times: []float64{1000, 1002},
+		},
+		"read messages filtered by tag without matches": {
+			query: map[string]string{"tag.site": "plant-3"},
+			times: []float64{},
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(tagsChanID, 0, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllBatchSize(t *testing.T) {
+	var mu sync.Mutex
+	batches := map[string][]int32{}
+	monitor := &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if e.CommandName != "find" && e.CommandName != "getMore" {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			batches[e.CommandName] = append(batches[e.CommandName], e.Command.Lookup("batchSize").Int32())
+		},
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr).SetMonitor(monitor))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	batchedChanID := "batched"
+
+	for i := 0; i < 10; i++ {
+		msg := mainflux.Message{
+			Channel:   batchedChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	batchSize := int32(3)
+	reader := mreaders.New(db, batchSize)
+
+	result, err := reader.ReadAll(batchedChanID, 0, 10, map[string]string{})
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	assert.Len(t, result.Messages, 10, fmt.Sprintf("expected 10 messages got %d", len(result.Messages)))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int32{batchSize}, batches["find"], fmt.Sprintf("expected find batch size %d got %v", batchSize, batches["find"]))
+	assert.NotEmpty(t, batches["getMore"], "expected messages fetched in several batches")
+}
+
+func TestReadAllOrder(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	orderChanID := "order"
+
+	for i, pub := range []string{"b", "a", "c"} {
+		msg := mainflux.Message{
+			Channel:   orderChanID,
+			Publisher: pub,
+			Protocol:  "mqtt",
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages in default order": {
+			query: map[string]string{},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages in ascending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortAsc},
+			times: []float64{1000, 1001, 1002},
+		},
+		"read messages in descending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortDesc, readers.SortByKey: readers.SortTime},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages by publisher in ascending order": {
+			query: map[string]string{readers.SortByKey: "publisher", readers.SortOrderKey: readers.SortAsc},
+			times: []float64{1001, 1000, 1002},
+		},
+		"read messages by publisher in descending order": {
+			query: map[string]string{readers.SortByKey: "publisher"},
+			times: []float64{1002, 1000, 1001},
+		},
+		"read messages in invalid order": {
+			query: map[string]string{readers.SortOrderKey: "random"},
+			err:   readers.ErrInvalidOrder,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(orderChanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.Equal(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllValueFilter(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	writer := mwriters.New(db)
+	valueChanID := "value-filter"
+
+	for i, v := range []float64{25, 30, 35} {
+		msg := mainflux.Message{
+			Channel:   valueChanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: v},
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+	msg := mainflux.Message{
+		Channel:   valueChanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_StringValue{StringValue: "value"},
+		Time:      1003,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	msg = mainflux.Message{
+		Channel:   valueChanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_BoolValue{BoolValue: true},
+		Time:      1004,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	reader := mreaders.New(db, 0)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages with value equal to": {
+			query: map[string]string{readers.ValueKey: "30"},
+			times: []float64{1001},
+		},
+		"read messages with value greater than": {
+			query: map[string]string{readers.ValueGreaterKey: "30"},
+			times: []float64{1002},
+		},
+		"read messages with value less than": {
+			query: map[string]string{readers.ValueLessKey: "30"},
+			times: []float64{1000},
+		},
+		"read messages with value within range": {
+			query: map[string]string{readers.ValueGreaterKey: "20", readers.ValueLessKey: "32.5"},
+			times: []float64{1000, 1001},
+		},
+		"read messages with malformed value filter": {
+			query: map[string]string{readers.ValueGreaterKey: "hot"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		"read messages with bool value": {
+			query: map[string]string{readers.BoolValueKey: "true"},
+			times: []float64{1004},
+		},
+		"read messages with string value": {
+			query: map[string]string{readers.StringValueKey: "value"},
+			times: []float64{1003},
+		},
+		"read messages with float and bool value": {
+			query: map[string]string{readers.ValueKey: "30", readers.BoolValueKey: "true"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(valueChanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import "errors"
+
+// Query keys of the listing order.
+const (
+	// SortOrderKey is the query key carrying the direction of the listing,
+	// i.e. SortAsc or SortDesc.
+	SortOrderKey = "order"
+
+	// SortByKey is the query key carrying the message field the listing is
+	// sorted by.
+	SortByKey = "sort_by"
+)
+
+// Directions of the listing order.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// SortTime is the message field the listing is sorted by default.
+const SortTime = "time"
+
+var (
+	// ErrInvalidOrder indicates unsupported listing direction or sort field.
+	ErrInvalidOrder = errors.New("invalid listing order")
+
+	// ErrUnsupportedSort indicates that the message repository doesn't
+	// support sorting by the requested field.
+	ErrUnsupportedSort = errors.New("sorting by field is not supported")
+)
+
+// sortFields contains the message fields the listing can be sorted by.
+var sortFields = NewIdentifiers("time", "subtopic", "publisher", "protocol", "name", "value")
+
+// Order is the order of the listed messages. Messages sharing the sort field
+// value are additionally ordered by time, in the same direction.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+// ParseOrder parses the listing order of the query. Messages are listed by
+// time, newest first, unless the query says otherwise.
+func ParseOrder(query map[string]string) (Order, error) {
+	o := Order{Field: SortTime, Desc: true}
+
+	if field, ok := query[SortByKey]; ok {
+		if err := sortFields.Validate(field); err != nil {
+			return Order{}, ErrInvalidOrder
+		}
+		o.Field = field
+	}
+
+	if dir, ok := query[SortOrderKey]; ok {
+		switch dir {
+		case SortAsc:
+			o.Desc = false
+		case SortDesc:
+			o.Desc = true
+		default:
+			return Order{}, ErrInvalidOrder
+		}
+	}
+
+	return o, nil
+}
+
+// Direction returns the direction of the order, i.e. SortAsc or SortDesc.
+func (o Order) Direction() string {
+	if o.Desc {
+		return SortDesc
+	}
+
+	return SortAsc
+}
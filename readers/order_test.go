@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrder(t *testing.T) {
+	cases := []struct {
+		desc  string
+		query map[string]string
+		order readers.Order
+		err   error
+	}{
+		{
+			desc:  "parse default order",
+			query: map[string]string{},
+			order: readers.Order{Field: readers.SortTime, Desc: true},
+			err:   nil,
+		},
+		{
+			desc:  "parse ascending order",
+			query: map[string]string{readers.SortOrderKey: readers.SortAsc},
+			order: readers.Order{Field: readers.SortTime, Desc: false},
+			err:   nil,
+		},
+		{
+			desc:  "parse descending order by field",
+			query: map[string]string{readers.SortOrderKey: readers.SortDesc, readers.SortByKey: "publisher"},
+			order: readers.Order{Field: "publisher", Desc: true},
+			err:   nil,
+		},
+		{
+			desc:  "parse invalid order",
+			query: map[string]string{readers.SortOrderKey: "up"},
+			order: readers.Order{},
+			err:   readers.ErrInvalidOrder,
+		},
+		{
+			desc:  "parse order by invalid field",
+			query: map[string]string{readers.SortByKey: "unit"},
+			order: readers.Order{},
+			err:   readers.ErrInvalidOrder,
+		},
+	}
+
+	for _, tc := range cases {
+		order, err := readers.ParseOrder(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.order, order, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.order, order))
+	}
+}
@@ -75,6 +75,17 @@ func migrateDB(db *sqlx.DB) error {
 					"DROP TABLE messages",
 				},
 			},
+			{
+				Id: "messages_2",
+				Up: []string{
+					`ALTER TABLE messages ADD COLUMN IF NOT EXISTS tags JSONB`,
+					`CREATE INDEX IF NOT EXISTS messages_tags_idx ON messages USING GIN (tags)`,
+				},
+				Down: []string{
+					"DROP INDEX IF EXISTS messages_tags_idx",
+					"ALTER TABLE messages DROP COLUMN IF EXISTS tags",
+				},
+			},
 		},
 	}
 
@@ -8,8 +8,11 @@
 package postgres
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/jmoiron/sqlx" // required for DB access
 	"github.com/mainflux/mainflux"
@@ -34,31 +37,22 @@ func New(db *sqlx.DB) readers.MessageRepository {
 }
 
 func (tr postgresRepository) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
-	subtopicQuery := ""
-	if query["subtopic"] != "" {
-		subtopicQuery = `AND subtopic = :subtopic`
-	}
-	q := fmt.Sprintf(`SELECT * FROM messages
-    WHERE channel = :channel %s ORDER BY time DESC
-    LIMIT :limit OFFSET :offset;`, subtopicQuery)
-
-	params := map[string]interface{}{
-		"channel":  chanID,
-		"limit":    limit,
-		"offset":   offset,
-		"subtopic": query["subtopic"],
+	selectQ, countQ, params, err := readQueries(chanID, offset, limit, query)
+	if err != nil {
+		return readers.MessagesPage{}, err
 	}
 
-	rows, err := tr.db.NamedQuery(q, params)
+	rows, err := tr.db.NamedQuery(selectQ, params)
 	if err != nil {
 		return readers.MessagesPage{}, err
 	}
 	defer rows.Close()
 
 	page := readers.MessagesPage{
-		Offset:   offset,
+		Offset:   params["offset"].(uint64),
 		Limit:    limit,
 		Messages: []mainflux.Message{},
+		Cursors:  []readers.Cursor{},
 	}
 	for rows.Next() {
 		dbm := dbMessage{Channel: chanID}
@@ -72,21 +66,420 @@ func (tr postgresRepository) ReadAll(chanID string, offset, limit uint64, query
 		}
 
 		page.Messages = append(page.Messages, msg)
+		page.Cursors = append(page.Cursors, readers.Cursor{Time: dbm.Time, ID: dbm.ID})
+	}
+
+	if err := tr.queryRow(countQ, params, &page.Total); err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	return page, nil
+}
+
+func (tr postgresRepository) Explain(chanID string, offset, limit uint64, query map[string]string) ([]readers.Explanation, error) {
+	selectQ, countQ, params, err := readQueries(chanID, offset, limit, query)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := []readers.Explanation{}
+	for _, q := range []string{selectQ, countQ} {
+		q, args, err := sqlx.Named(q, params)
+		if err != nil {
+			return nil, err
+		}
+		q = tr.db.Rebind(q)
+
+		plan, err := tr.explain(q, args)
+		if err != nil {
+			return nil, err
+		}
+
+		explanations = append(explanations, readers.Explanation{
+			Query: q,
+			Args:  args,
+			Plan:  plan,
+		})
+	}
+
+	return explanations, nil
+}
+
+// explain returns the execution plan of the query, one plan node per line.
+func (tr postgresRepository) explain(q string, args []interface{}) (string, error) {
+	rows, err := tr.db.Query(fmt.Sprintf("EXPLAIN %s", q), args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	lines := []string{}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n"), rows.Err()
+}
+
+// readQueries creates the query selecting the page of the channel messages
+// and the query counting all of them, along with their named parameters.
+func readQueries(chanID string, offset, limit uint64, query map[string]string) (string, string, map[string]interface{}, error) {
+	condition, params, err := fmtCondition(chanID, query)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	o, err := readers.ParseOrder(query)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if err := readers.Columns.Validate(o.Field); err != nil {
+		return "", "", nil, err
 	}
 
-	q = `SELECT COUNT(*) FROM messages WHERE channel = $1;`
-	qParams := []interface{}{chanID}
+	order := orderBy(o)
+	if after := query[readers.AfterKey]; after != "" {
+		c, err := readers.ParseCursor(after)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		condition = fmt.Sprintf(`%s AND (time > :after_time OR (time = :after_time AND id::text > :after_id))`, condition)
+		params["after_time"] = c.Time
+		params["after_id"] = c.ID
+		order = "time, id"
+		offset = 0
+	}
+
+	ids, err := readers.ParseIDs(query)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if len(ids) > 0 {
+		ors := []string{}
+		for i, c := range ids {
+			ors = append(ors, fmt.Sprintf(`(time = :msg_time_%d AND id::text = :msg_id_%d)`, i, i))
+			params[fmt.Sprintf("msg_time_%d", i)] = c.Time
+			params[fmt.Sprintf("msg_id_%d", i)] = c.ID
+		}
+		condition = fmt.Sprintf(`%s AND (%s)`, condition, strings.Join(ors, " OR "))
+	}
+
+	selectQ := fmt.Sprintf(`SELECT * FROM messages
+    WHERE %s ORDER BY %s
+    LIMIT :limit OFFSET :offset;`, condition, order)
+	countQ := fmt.Sprintf(`SELECT COUNT(*) FROM messages WHERE %s;`, condition)
+
+	params["limit"] = limit
+	params["offset"] = offset
+
+	return selectQ, countQ, params, nil
+}
+
+// orderBy creates the ORDER BY clause of the listing order, whose field is
+// expected to be validated against readers.Columns. Message ID breaks the
+// ties between the messages published at the same time, so that the order
+// is stable and the cursors are unique.
+func orderBy(o readers.Order) string {
+	dir := strings.ToUpper(o.Direction())
+	if o.Field == readers.SortTime {
+		return fmt.Sprintf("time %s, id %s", dir, dir)
+	}
+
+	return fmt.Sprintf("%s %s, time %s, id %s", o.Field, dir, dir, dir)
+}
+
+func (tr postgresRepository) Bounds(chanID string, query map[string]string) (float64, float64, error) {
+	condition, params, err := fmtCondition(chanID, query)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	q := fmt.Sprintf(`SELECT COALESCE(MIN(time), 0), COALESCE(MAX(time), 0) FROM messages WHERE %s;`, condition)
+
+	var min, max float64
+	if err := tr.queryRow(q, params, &min, &max); err != nil {
+		return 0, 0, err
+	}
+
+	return min, max, nil
+}
+
+func (tr postgresRepository) Aggregate(chanID string, agg readers.Aggregation, query map[string]string) (readers.AggregationResult, error) {
+	if err := agg.Validate(); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	if err := validateColumns(agg); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	condition, params, err := fmtCondition(chanID, query)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	// Aggregate functions skip NULL values, so the total number of messages
+	// is counted separately in order to detect them.
+	q := fmt.Sprintf(`SELECT COALESCE(%s, 0), COUNT(%s), COUNT(*) FROM messages WHERE %s`,
+		aggregateExpr(agg), aggregateField(agg), condition)
+
+	var value float64
+	var samples, total uint64
+	if err := tr.queryRow(q, params, &value, &samples, &total); err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	res, err := agg.Result(value, samples, total)
+	if err != nil || !agg.Grouped() {
+		return res, err
+	}
+
+	if agg.Paged() {
+		q := fmt.Sprintf(`SELECT COUNT(DISTINCT COALESCE(%s, '')) FROM messages WHERE %s`, agg.GroupBy, condition)
+		if err := tr.queryRow(q, params, &res.TotalGroups); err != nil {
+			return readers.AggregationResult{}, err
+		}
+	}
+
+	rows, err := tr.aggregateGroups(agg, condition, params)
+	if err != nil {
+		return readers.AggregationResult{}, err
+	}
+
+	return agg.Nest(res, rows)
+}
+
+// aggregateGroups applies the aggregation per time bucket and group. One row
+// over the cardinality cap is fetched, so that exceeding it is detected,
+// unless the groups are paged.
+func (tr postgresRepository) aggregateGroups(agg readers.Aggregation, condition string, params map[string]interface{}) ([]readers.AggregationRow, error) {
+	start, key := "0", "''"
+	groups := []string{}
+	if agg.Interval != "" {
+		// Interval is safe to embed since it's validated against the known
+		// values, while the group-by field is validated by validateColumns.
+		start = fmt.Sprintf(`EXTRACT(EPOCH FROM date_trunc('%s', to_timestamp(time) AT TIME ZONE 'UTC'))`, agg.Interval)
+		groups = append(groups, "bucket_start")
+	}
+	if agg.GroupBy != "" {
+		key = fmt.Sprintf(`COALESCE(%s, '')`, agg.GroupBy)
+		groups = append(groups, "group_key")
+	}
+
+	order, limit := "bucket_start, group_key", "LIMIT :max_groups"
+	params["max_groups"] = readers.MaxAggregationGroups + 1
+	if agg.Paged() {
+		switch agg.Order {
+		case readers.OrderValueAsc:
+			order = "group_value, group_key"
+		case readers.OrderValueDesc:
+			order = "group_value DESC, group_key"
+		default:
+			order = "group_key"
+		}
+		limit = "LIMIT :limit OFFSET :offset"
+		params["limit"] = agg.Limit
+		params["offset"] = agg.Offset
+	}
+
+	q := fmt.Sprintf(`SELECT %s AS bucket_start, %s AS group_key, COALESCE(%s, 0) AS group_value, COUNT(%s), COUNT(*)
+    FROM messages WHERE %s GROUP BY %s ORDER BY %s %s;`,
+		start, key, aggregateExpr(agg), aggregateField(agg), condition, strings.Join(groups, ", "), order, limit)
+
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []readers.AggregationRow{}
+	for rows.Next() {
+		var row readers.AggregationRow
+		if err := rows.Scan(&row.Start, &row.Key, &row.Value, &row.Samples, &row.Total); err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+
+	return res, rows.Err()
+}
+
+// aggregateExpr returns the aggregate function applied to the field. Function
+// is safe to embed since it's validated against the known values, while the
+// field is validated by validateColumns.
+func aggregateExpr(agg readers.Aggregation) string {
+	if agg.Function == readers.AggregateCountDistinct {
+		return fmt.Sprintf(`COUNT(DISTINCT %s)`, aggregateField(agg))
+	}
+
+	return fmt.Sprintf(`%s(%s)`, strings.ToUpper(agg.Function), agg.Field)
+}
+
+// aggregateField returns the aggregated field. Text fields are stored empty
+// if the message doesn't carry them, so the empty text is treated as null.
+func aggregateField(agg readers.Aggregation) string {
+	if agg.Function == readers.AggregateCountDistinct {
+		return fmt.Sprintf(`NULLIF(%s, '')`, agg.Field)
+	}
+
+	return agg.Field
+}
+
+// validateColumns validates the aggregated and the group-by fields embedded
+// in the aggregation queries as the column names.
+func validateColumns(agg readers.Aggregation) error {
+	columns := []string{agg.Field}
+	if agg.GroupBy != "" {
+		columns = append(columns, agg.GroupBy)
+	}
+
+	return readers.Columns.Validate(columns...)
+}
+
+// queryRow executes the query with named parameters that is expected to
+// return a single row and scans it into the destination values.
+func (tr postgresRepository) queryRow(q string, params map[string]interface{}, dest ...interface{}) error {
+	q, args, err := sqlx.Named(q, params)
+	if err != nil {
+		return err
+	}
+
+	return tr.db.QueryRow(tr.db.Rebind(q), args...).Scan(dest...)
+}
+
+// fmtCondition creates the WHERE clause condition that matches the channel
+// messages filtered by the query, along with its named parameters. Bounded
+// cross-channel query matches the messages of all channels. Filter
+// groups are OR-ed, while the fields within the group are AND-ed. Tag filters
+// are matched by the JSONB containment, which uses the tags index.
+func fmtCondition(chanID string, query map[string]string) (string, map[string]interface{}, error) {
+	groups, err := readers.ParseFilter(query[readers.FilterKey])
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := readers.CheckChannel(chanID, query); err != nil {
+		return "", nil, err
+	}
+
+	condition := `channel = :channel`
+	params := map[string]interface{}{
+		"channel": chanID,
+	}
+	if chanID == readers.AllChannels {
+		condition = `TRUE`
+		params = map[string]interface{}{}
+	}
 
 	if query["subtopic"] != "" {
-		q = `SELECT COUNT(*) FROM messages WHERE channel = $1 AND subtopic = $2;`
-		qParams = append(qParams, query["subtopic"])
+		condition = fmt.Sprintf(`%s AND subtopic = :subtopic`, condition)
+		params["subtopic"] = query["subtopic"]
 	}
 
-	if err := tr.db.QueryRow(q, qParams...).Scan(&page.Total); err != nil {
-		return readers.MessagesPage{}, err
+	if query["publisher"] != "" {
+		condition = fmt.Sprintf(`%s AND publisher = :publisher`, condition)
+		params["publisher"] = query["publisher"]
 	}
 
-	return page, nil
+	vf, err := readers.ParseValueFilter(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if vf.Float() {
+		// Float value filters match the float values only, i.e. the SenML
+		// records carrying the value column.
+		condition = fmt.Sprintf(`%s AND value IS NOT NULL`, condition)
+	}
+
+	if vf.Equal != nil {
+		condition = fmt.Sprintf(`%s AND value = :value_eq`, condition)
+		params["value_eq"] = *vf.Equal
+	}
+
+	if vf.Greater != nil {
+		condition = fmt.Sprintf(`%s AND value > :value_gt`, condition)
+		params["value_gt"] = *vf.Greater
+	}
+
+	if vf.Less != nil {
+		condition = fmt.Sprintf(`%s AND value < :value_lt`, condition)
+		params["value_lt"] = *vf.Less
+	}
+
+	if vf.Bool != nil {
+		condition = fmt.Sprintf(`%s AND bool_value = :value_bool`, condition)
+		params["value_bool"] = *vf.Bool
+	}
+
+	if vf.String != nil {
+		condition = fmt.Sprintf(`%s AND string_value = :value_string`, condition)
+		params["value_string"] = *vf.String
+	}
+
+	tr, err := readers.ParseTimeRange(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if tr.From != nil {
+		condition = fmt.Sprintf(`%s AND time >= :from`, condition)
+		params["from"] = *tr.From
+	}
+
+	if tr.To != nil {
+		condition = fmt.Sprintf(`%s AND time < :to`, condition)
+		params["to"] = *tr.To
+	}
+
+	tags, err := readers.Tags(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(tags) > 0 {
+		data, err := json.Marshal(tags)
+		if err != nil {
+			return "", nil, err
+		}
+		condition = fmt.Sprintf(`%s AND tags @> CAST(:tags AS JSONB)`, condition)
+		params["tags"] = string(data)
+	}
+
+	if len(groups) == 0 {
+		return condition, params, nil
+	}
+
+	ors := []string{}
+	for i, group := range groups {
+		fields := []string{}
+		for field := range group {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		ands := []string{}
+		for _, field := range fields {
+			if err := readers.Columns.Validate(field); err != nil {
+				return "", nil, err
+			}
+
+			param := fmt.Sprintf("filter_%d_%s", i, field)
+			ands = append(ands, fmt.Sprintf(`%s = :%s`, field, param))
+			params[param] = group[field]
+		}
+		ors = append(ors, fmt.Sprintf(`(%s)`, strings.Join(ands, " AND ")))
+	}
+
+	condition = fmt.Sprintf(`%s AND (%s)`, condition, strings.Join(ors, " OR "))
+	return condition, params, nil
 }
 
 type dbMessage struct {
@@ -129,7 +522,10 @@ func toMessage(dbm dbMessage) (mainflux.Message, error) {
 		msg.Value = &mainflux.Message_BoolValue{BoolValue: *dbm.BoolValue}
 	case dbm.DataValue != nil:
 		msg.Value = &mainflux.Message_DataValue{DataValue: *dbm.DataValue}
-	case dbm.ValueSum != nil:
+	}
+
+	// Value sum is carried along with the value of any type.
+	if dbm.ValueSum != nil {
 		msg.ValueSum = &mainflux.SumValue{Value: *dbm.ValueSum}
 	}
 
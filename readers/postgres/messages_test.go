@@ -9,6 +9,7 @@ package postgres_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -151,3 +152,819 @@ func TestMessageReadAll(t *testing.T) {
 		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %v got %v", desc, tc.page.Total, result.Total))
 	}
 }
+
+func TestBounds(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	emptyID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	boundsChan := chanID.String()
+	emptyChan := emptyID.String()
+	now := time.Now().Unix()
+	for i := 0; i < msgsNum; i++ {
+		m := mainflux.Message{
+			Channel:   boundsChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+			Time:      float64(now - int64(i)),
+		}
+		if i%valueFields == 0 {
+			m.Subtopic = subtopic
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		chanID string
+		query  map[string]string
+		min    float64
+		max    float64
+	}{
+		"read bounds of channel": {
+			chanID: boundsChan,
+			min:    float64(now - msgsNum + 1),
+			max:    float64(now),
+		},
+		"read bounds of channel with subtopic": {
+			chanID: boundsChan,
+			query:  map[string]string{"subtopic": subtopic},
+			min:    float64(now - (msgsNum-1)/valueFields*valueFields),
+			max:    float64(now),
+		},
+		"read bounds of empty channel": {
+			chanID: emptyChan,
+			min:    0,
+			max:    0,
+		},
+	}
+
+	for desc, tc := range cases {
+		min, max, err := reader.Bounds(tc.chanID, tc.query)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.Equal(t, tc.min, min, fmt.Sprintf("%s: expected min %f got %f", desc, tc.min, min))
+		assert.Equal(t, tc.max, max, fmt.Sprintf("%s: expected max %f got %f", desc, tc.max, max))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	emptyID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	aggChan := chanID.String()
+	emptyChan := emptyID.String()
+	now := time.Now().Unix()
+	// Every other message carries string value, so the float value is null.
+	// First four messages additionally carry the value sum. Messages are
+	// published by three publishers.
+	for i := 0; i < 10; i++ {
+		m := mainflux.Message{
+			Channel:   aggChan,
+			Publisher: fmt.Sprintf("%d", i%3),
+			Protocol:  "mqtt",
+			Time:      float64(now - int64(i)),
+		}
+		switch i % 2 {
+		case 0:
+			m.Value = &mainflux.Message_FloatValue{FloatValue: float64(i)}
+		default:
+			m.Value = &mainflux.Message_StringValue{StringValue: "value"}
+		}
+		if i < 4 {
+			m.ValueSum = &mainflux.SumValue{Value: 10}
+		}
+
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	reader := preader.New(db)
+	cases := map[string]struct {
+		chanID string
+		agg    readers.Aggregation
+		res    readers.AggregationResult
+		err    error
+	}{
+		"average skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 4, Samples: 5},
+		},
+		"minimum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMin, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 5},
+		},
+		"maximum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateMax, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 8, Samples: 5},
+		},
+		"sum skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 20, Samples: 5},
+		},
+		"count skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCount, Field: readers.FieldValue, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 5, Samples: 5},
+		},
+		"average rejecting nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			err:    readers.ErrNullValue,
+		},
+		"sum of value sums skipping nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValueSum, NullPolicy: readers.SkipNulls},
+			res:    readers.AggregationResult{Value: 40, Samples: 4},
+		},
+		"sum of value sums rejecting nulls": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValueSum, NullPolicy: readers.RejectNulls},
+			err:    readers.ErrNullValue,
+		},
+		"count of distinct publishers": {
+			chanID: aggChan,
+			agg:    readers.Aggregation{Function: readers.AggregateCountDistinct, Field: "publisher", NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 3, Samples: 10},
+		},
+		"average of empty channel": {
+			chanID: emptyChan,
+			agg:    readers.Aggregation{Function: readers.AggregateAvg, Field: readers.FieldValue, NullPolicy: readers.RejectNulls},
+			res:    readers.AggregationResult{Value: 0, Samples: 0},
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := reader.Aggregate(tc.chanID, tc.agg, nil)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, res))
+	}
+}
+
+func TestAggregateGrouped(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	aggChan := chanID.String()
+	hour := int64(time.Hour / time.Second)
+	start := time.Now().Unix()/hour*hour - 2*hour
+	// Two publishers in the first hour, and only the first one in the next.
+	msgs := []mainflux.Message{
+		{Channel: aggChan, Publisher: "1", Protocol: "mqtt", Time: float64(start), Value: &mainflux.Message_FloatValue{FloatValue: 1}},
+		{Channel: aggChan, Publisher: "1", Protocol: "mqtt", Time: float64(start + 60), Value: &mainflux.Message_FloatValue{FloatValue: 3}},
+		{Channel: aggChan, Publisher: "2", Protocol: "mqtt", Time: float64(start + 120), Value: &mainflux.Message_FloatValue{FloatValue: 10}},
+		{Channel: aggChan, Publisher: "1", Protocol: "mqtt", Time: float64(start + hour), Value: &mainflux.Message_FloatValue{FloatValue: 5}},
+	}
+	for _, m := range msgs {
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+	}
+
+	first, second := float64(start), float64(start+hour)
+	reader := preader.New(db)
+	cases := map[string]struct {
+		agg readers.Aggregation
+		res readers.AggregationResult
+	}{
+		"sum by hour and publisher": {
+			agg: readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, Interval: readers.IntervalHour, GroupBy: "publisher"},
+			res: readers.AggregationResult{
+				Value:   19,
+				Samples: 4,
+				Buckets: []readers.AggregationBucket{
+					{Start: first, Groups: []readers.AggregationGroup{{Key: "1", Value: 4, Samples: 2}, {Key: "2", Value: 10, Samples: 1}}},
+					{Start: second, Groups: []readers.AggregationGroup{{Key: "1", Value: 5, Samples: 1}}},
+				},
+			},
+		},
+		"sum by hour": {
+			agg: readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, Interval: readers.IntervalHour},
+			res: readers.AggregationResult{
+				Value:   19,
+				Samples: 4,
+				Buckets: []readers.AggregationBucket{
+					{Start: first, Value: 14, Samples: 3},
+					{Start: second, Value: 5, Samples: 1},
+				},
+			},
+		},
+		"sum by publisher": {
+			agg: readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, GroupBy: "publisher"},
+			res: readers.AggregationResult{
+				Value:   19,
+				Samples: 4,
+				Groups:  []readers.AggregationGroup{{Key: "1", Value: 9, Samples: 3}, {Key: "2", Value: 10, Samples: 1}},
+			},
+		},
+		"top publisher by sum": {
+			agg: readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, GroupBy: "publisher", Order: readers.OrderValueDesc, Limit: 1},
+			res: readers.AggregationResult{
+				Value:       19,
+				Samples:     4,
+				Groups:      []readers.AggregationGroup{{Key: "2", Value: 10, Samples: 1}},
+				TotalGroups: 2,
+			},
+		},
+		"second page of publishers by sum": {
+			agg: readers.Aggregation{Function: readers.AggregateSum, Field: readers.FieldValue, NullPolicy: readers.SkipNulls, GroupBy: "publisher", Order: readers.OrderValueDesc, Offset: 1, Limit: 1},
+			res: readers.AggregationResult{
+				Value:       19,
+				Samples:     4,
+				Groups:      []readers.AggregationGroup{{Key: "1", Value: 9, Samples: 3}},
+				TotalGroups: 2,
+			},
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := reader.Aggregate(aggChan, tc.agg, nil)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", desc, tc.res, res))
+	}
+}
+
+func TestReadAllFilterGroups(t *testing.T) {
+	messageRepo := pwriter.New(db)
+
+	chanID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	pubA, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	pubB, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	pubs := []string{pubA.String(), pubB.String()}
+	subtopics := []string{"x", "y"}
+	matched := []mainflux.Message{}
+	now := time.Now().Unix()
+	for i, pub := range pubs {
+		for j, sub := range subtopics {
+			msg := mainflux.Message{
+				Channel:   chanID.String(),
+				Publisher: pub,
+				Subtopic:  sub,
+				Protocol:  "mqtt",
+				Value:     &mainflux.Message_FloatValue{FloatValue: 5},
+				Time:      float64(now - int64(i*len(subtopics)+j)),
+			}
+			err := messageRepo.Save(msg)
+			require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+			if (i == 0 && sub == "x") || (i == 1 && sub == "y") {
+				matched = append(matched, msg)
+			}
+		}
+	}
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		filter   string
+		messages []mainflux.Message
+		err      error
+	}{
+		"read messages matching any of two filter groups": {
+			filter:   fmt.Sprintf(`[{"publisher":"%s","subtopic":"x"},{"publisher":"%s","subtopic":"y"}]`, pubA, pubB),
+			messages: matched,
+		},
+		"read messages matching single filter group": {
+			filter:   fmt.Sprintf(`[{"publisher":"%s","subtopic":"x"}]`, pubA),
+			messages: matched[:1],
+		},
+		"read messages with filter on unknown field": {
+			filter:   `[{"value":"5"}]`,
+			messages: nil,
+			err:      readers.ErrInvalidFilter,
+		},
+	}
+
+	for desc, tc := range cases {
+		query := map[string]string{readers.FilterKey: tc.filter}
+		result, err := reader.ReadAll(chanID.String(), 0, 10, query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+		assert.ElementsMatch(t, tc.messages, result.Messages, fmt.Sprintf("%s: expected %v got %v", desc, tc.messages, result.Messages))
+		assert.Equal(t, uint64(len(tc.messages)), result.Total, fmt.Sprintf("%s: expected %d got %d", desc, len(tc.messages), result.Total))
+	}
+}
+
+// TestReadAllTimeRange checks that the time range includes the message at
+// its start and excludes the one at its end.
+func TestReadAllTimeRange(t *testing.T) {
+	writer := pwriter.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	from, to := 1000.0, 2000.0
+	for _, tm := range []float64{from - 1, from, (from + to) / 2, to} {
+		msg := mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      tm,
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read messages within time range": {
+			query: map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"},
+			times: []float64{from, (from + to) / 2},
+		},
+		"read messages since time": {
+			query: map[string]string{readers.FromKey: "1000"},
+			times: []float64{from, (from + to) / 2, to},
+		},
+		"read messages until time": {
+			query: map[string]string{readers.ToKey: "2000"},
+			times: []float64{from - 1, from, (from + to) / 2},
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(chanID, 0, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
+
+func TestReadAllValueAndSum(t *testing.T) {
+	writer := pwriter.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	messages := []mainflux.Message{
+		{
+			Channel:  chanID,
+			Name:     "float",
+			Value:    &mainflux.Message_FloatValue{FloatValue: 5},
+			ValueSum: &mainflux.SumValue{Value: 45},
+			Time:     1000,
+		},
+		{
+			Channel:  chanID,
+			Name:     "string",
+			Value:    &mainflux.Message_StringValue{StringValue: "value"},
+			ValueSum: &mainflux.SumValue{Value: 50},
+			Time:     1001,
+		},
+		{
+			Channel:  chanID,
+			Name:     "sum",
+			ValueSum: &mainflux.SumValue{Value: 55},
+			Time:     1002,
+		},
+	}
+	for _, msg := range messages {
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := preader.New(db)
+	result, err := reader.ReadAll(chanID, 0, 10, nil)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	assert.ElementsMatch(t, messages, result.Messages, fmt.Sprintf("expected messages %v got %v", messages, result.Messages))
+}
+
+func TestReadAllChannelScope(t *testing.T) {
+	writer := pwriter.New(db)
+
+	// Times are unique to the test, so that the cross-channel reads don't
+	// match the messages of the other tests.
+	from := 500.0
+	chans := []string{}
+	for i := 0; i < 2; i++ {
+		id, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		chans = append(chans, id.String())
+
+		msg := mainflux.Message{
+			Channel:   id.String(),
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      from + float64(i),
+		}
+		err = writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		chanID string
+		query  map[string]string
+		chans  []string
+		err    error
+	}{
+		"read messages without channel": {
+			chanID: "",
+			query:  map[string]string{readers.FromKey: "500", readers.ToKey: "600"},
+			err:    readers.ErrMissingChannel,
+		},
+		"read messages of all channels within time range": {
+			chanID: readers.AllChannels,
+			query:  map[string]string{readers.FromKey: "500", readers.ToKey: "600"},
+			chans:  chans,
+			err:    nil,
+		},
+		"read messages of all channels since time": {
+			chanID: readers.AllChannels,
+			query:  map[string]string{readers.FromKey: "500"},
+			err:    readers.ErrUnboundedQuery,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(tc.chanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+
+		got := []string{}
+		for _, msg := range result.Messages {
+			got = append(got, msg.Channel)
+		}
+		assert.ElementsMatch(t, tc.chans, got, fmt.Sprintf("%s: expected channels %v got %v", desc, tc.chans, got))
+	}
+}
+
+func TestReadAllCursor(t *testing.T) {
+	writer := pwriter.New(db)
+	reader := preader.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	save := func(tm float64) {
+		msg := mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      tm,
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	// Every two messages are published at the same time.
+	saved := 10
+	for i := 0; i < saved; i++ {
+		save(float64(1000 + i/2))
+	}
+
+	// Resume reading from the last cursor, while the new messages are
+	// published between the reads, both at the same time.
+	cursors := []readers.Cursor{}
+	seen := map[readers.Cursor]bool{}
+	after := "0"
+	for reads := 0; ; reads++ {
+		page, err := reader.ReadAll(chanID, 0, 3, map[string]string{readers.AfterKey: after})
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+		require.Equal(t, len(page.Messages), len(page.Cursors), fmt.Sprintf("expected %d cursors got %d", len(page.Messages), len(page.Cursors)))
+		if len(page.Cursors) == 0 {
+			break
+		}
+
+		for _, c := range page.Cursors {
+			assert.False(t, seen[c], fmt.Sprintf("duplicate message %s", c))
+			seen[c] = true
+			cursors = append(cursors, c)
+		}
+		last := page.Cursors[len(page.Cursors)-1]
+		after = last.String()
+
+		if reads < 2 {
+			save(last.Time + 10)
+			save(last.Time + 10)
+			saved += 2
+		}
+	}
+
+	assert.Equal(t, saved, len(cursors), fmt.Sprintf("expected %d messages got %d", saved, len(cursors)))
+	for i := 1; i < len(cursors); i++ {
+		assert.True(t, cursors[i-1].Before(cursors[i]), fmt.Sprintf("expected %s before %s", cursors[i-1], cursors[i]))
+	}
+
+	_, err = reader.ReadAll(chanID, 0, 3, map[string]string{readers.AfterKey: "now"})
+	assert.Equal(t, readers.ErrInvalidCursor, err, fmt.Sprintf("expected %s got %s", readers.ErrInvalidCursor, err))
+}
+
+func TestReadAllByID(t *testing.T) {
+	writer := pwriter.New(db)
+	reader := preader.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	for i := 0; i < 5; i++ {
+		msg := mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	page, err := reader.ReadAll(chanID, 0, 5, map[string]string{})
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	cursors := page.Cursors
+
+	missing, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := map[string]struct {
+		ids     []readers.Cursor
+		cursors []readers.Cursor
+	}{
+		"read message by ID": {
+			ids:     cursors[1:2],
+			cursors: cursors[1:2],
+		},
+		"read messages by IDs": {
+			ids:     []readers.Cursor{cursors[3], cursors[1]},
+			cursors: []readers.Cursor{cursors[1], cursors[3]},
+		},
+		"read messages by existing and missing IDs": {
+			ids:     []readers.Cursor{cursors[1], {Time: cursors[1].Time, ID: missing.String()}, {Time: cursors[2].Time, ID: cursors[3].ID}},
+			cursors: cursors[1:2],
+		},
+	}
+
+	for desc, tc := range cases {
+		ids := []string{}
+		for _, c := range tc.ids {
+			ids = append(ids, c.String())
+		}
+
+		page, err := reader.ReadAll(chanID, 0, 5, map[string]string{readers.IDsKey: strings.Join(ids, ",")})
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+		assert.ElementsMatch(t, tc.cursors, page.Cursors, fmt.Sprintf("%s: expected %v got %v", desc, tc.cursors, page.Cursors))
+		assert.Equal(t, uint64(len(tc.cursors)), page.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.cursors), page.Total))
+	}
+
+	_, err = reader.ReadAll(chanID, 0, 5, map[string]string{readers.IDsKey: "1000"})
+	assert.Equal(t, readers.ErrInvalidCursor, err, fmt.Sprintf("expected %s got %s", readers.ErrInvalidCursor, err))
+}
+
+func TestReadAllTags(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	tagsChan := chanID.String()
+
+	// Writer doesn't store the tags, so they are set directly.
+	for i, site := range []string{"plant-1", "plant-2", "plant-1"} {
+		m := mainflux.Message{
+			Channel:   tagsChan,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(m)
+		require.Nil(t, err, fmt.Sprintf("failed to store message: %s", err))
+
+		_, err = db.Exec(`UPDATE messages SET tags = CAST($1 AS JSONB) WHERE channel = $2 AND time = $3`, fmt.Sprintf(`{"site":"%s"}`, site), tagsChan, m.Time)
+		require.Nil(t, err, fmt.Sprintf("failed to tag message: %s", err))
+	}
+
+	reader := preader.New(db)
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+	}{
+		"read messages filtered by tag": {
+			query: map[string]string{"tag.site": "plant-1"},
+			times: []float64{1000, 1002},
+		},
+		"read messages filtered by tag without matches": {
+			query: map[string]string{"tag.site": "plant-3"},
+			times: []float64{},
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(tagsChan, 0, 10, tc.query)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", desc, err))
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	reader := preader.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	query := map[string]string{"publisher": "1", readers.FromKey: "1000"}
+	explanations, err := reader.Explain(chanID, 5, 10, query)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	require.Len(t, explanations, 2, fmt.Sprintf("expected select and count explained got %v", explanations))
+
+	for _, e := range explanations {
+		assert.Contains(t, e.Query, "FROM messages", fmt.Sprintf("expected query of messages got %s", e.Query))
+		assert.Contains(t, e.Args, chanID, fmt.Sprintf("expected channel among args got %v", e.Args))
+		assert.NotEmpty(t, e.Plan, fmt.Sprintf("expected plan of %s", e.Query))
+	}
+}
+
+func TestReadAllOrder(t *testing.T) {
+	writer := pwriter.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	for i, pub := range []string{"b", "a", "c"} {
+		msg := mainflux.Message{
+			Channel:   chanID,
+			Publisher: pub,
+			Protocol:  "mqtt",
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages in default order": {
+			query: map[string]string{},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages in ascending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortAsc},
+			times: []float64{1000, 1001, 1002},
+		},
+		"read messages in descending order": {
+			query: map[string]string{readers.SortOrderKey: readers.SortDesc, readers.SortByKey: readers.SortTime},
+			times: []float64{1002, 1001, 1000},
+		},
+		"read messages by publisher in ascending order": {
+			query: map[string]string{readers.SortByKey: "publisher", readers.SortOrderKey: readers.SortAsc},
+			times: []float64{1001, 1000, 1002},
+		},
+		"read messages by publisher in descending order": {
+			query: map[string]string{readers.SortByKey: "publisher"},
+			times: []float64{1002, 1000, 1001},
+		},
+		"read messages in invalid order": {
+			query: map[string]string{readers.SortOrderKey: "random"},
+			err:   readers.ErrInvalidOrder,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(chanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.Equal(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+	}
+}
+
+func TestReadAllValueFilter(t *testing.T) {
+	writer := pwriter.New(db)
+
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID := id.String()
+
+	for i, v := range []float64{25, 30, 35} {
+		msg := mainflux.Message{
+			Channel:   chanID,
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: v},
+			Time:      float64(1000 + i),
+		}
+		err := writer.Save(msg)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	}
+	msg := mainflux.Message{
+		Channel:   chanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_StringValue{StringValue: "value"},
+		Time:      1003,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	msg = mainflux.Message{
+		Channel:   chanID,
+		Publisher: "1",
+		Protocol:  "mqtt",
+		Value:     &mainflux.Message_BoolValue{BoolValue: true},
+		Time:      1004,
+	}
+	err = writer.Save(msg)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	reader := preader.New(db)
+
+	cases := map[string]struct {
+		query map[string]string
+		times []float64
+		err   error
+	}{
+		"read messages with value equal to": {
+			query: map[string]string{readers.ValueKey: "30"},
+			times: []float64{1001},
+		},
+		"read messages with value greater than": {
+			query: map[string]string{readers.ValueGreaterKey: "30"},
+			times: []float64{1002},
+		},
+		"read messages with value less than": {
+			query: map[string]string{readers.ValueLessKey: "30"},
+			times: []float64{1000},
+		},
+		"read messages with value within range": {
+			query: map[string]string{readers.ValueGreaterKey: "20", readers.ValueLessKey: "32.5"},
+			times: []float64{1000, 1001},
+		},
+		"read messages with malformed value filter": {
+			query: map[string]string{readers.ValueGreaterKey: "hot"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		"read messages with bool value": {
+			query: map[string]string{readers.BoolValueKey: "true"},
+			times: []float64{1004},
+		},
+		"read messages with string value": {
+			query: map[string]string{readers.StringValueKey: "value"},
+			times: []float64{1003},
+		},
+		"read messages with float and bool value": {
+			query: map[string]string{readers.ValueKey: "30", readers.BoolValueKey: "true"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+	}
+
+	for desc, tc := range cases {
+		result, err := reader.ReadAll(chanID, 0, 10, tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		times := []float64{}
+		for _, msg := range result.Messages {
+			times = append(times, msg.Time)
+		}
+		assert.ElementsMatch(t, tc.times, times, fmt.Sprintf("%s: expected times %v got %v", desc, tc.times, times))
+		assert.Equal(t, uint64(len(tc.times)), result.Total, fmt.Sprintf("%s: expected total %d got %d", desc, len(tc.times), result.Total))
+	}
+}
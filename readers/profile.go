@@ -0,0 +1,222 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/mainflux/mainflux"
+)
+
+// Types of the profile properties.
+const (
+	StringType  = "string"
+	NumberType  = "number"
+	BooleanType = "boolean"
+)
+
+// scanPageSize is the number of messages read from the repository per page
+// while scanning the channel.
+const scanPageSize = 100
+
+// ErrInvalidProfile indicates malformed message profile.
+var ErrInvalidProfile = errors.New("invalid message profile")
+
+var propertyTypes = map[string]bool{
+	StringType:  true,
+	NumberType:  true,
+	BooleanType: true,
+}
+
+// Profile declares the shape of the channel messages. It is the subset of
+// the JSON schema, which is applied to the JSON representation of the
+// message, e.g. {"required":["unit"],"properties":{"value":{"minimum":0}}}.
+type Profile struct {
+	Required   []string            `json:"required,omitempty"`
+	Properties map[string]Property `json:"properties,omitempty"`
+
+	// AdditionalProperties rejects the message fields not listed in the
+	// properties if set to false.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// Property constrains the value of the single message field.
+type Property struct {
+	Type    string        `json:"type,omitempty"`
+	Enum    []interface{} `json:"enum,omitempty"`
+	Minimum *float64      `json:"minimum,omitempty"`
+	Maximum *float64      `json:"maximum,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+}
+
+// Violation is the message that doesn't conform to the profile, along with
+// the descriptions of the profile constraints it violates.
+type Violation struct {
+	Message mainflux.Message
+	Errors  []string
+}
+
+// ValidationReport summarizes the scan of the channel messages. Unless the
+// report is complete, the scan stopped once the sample was full, so the
+// counts cover only the scanned messages.
+type ValidationReport struct {
+	Scanned    uint64
+	Violations uint64
+	Complete   bool
+	Samples    []Violation
+}
+
+// Validate checks that the profile is well formed.
+func (p Profile) Validate() error {
+	for _, field := range p.Required {
+		if field == "" {
+			return ErrInvalidProfile
+		}
+	}
+
+	for field, prop := range p.Properties {
+		if field == "" {
+			return ErrInvalidProfile
+		}
+
+		if prop.Type != "" && !propertyTypes[prop.Type] {
+			return ErrInvalidProfile
+		}
+
+		if prop.Minimum != nil && prop.Maximum != nil && *prop.Minimum > *prop.Maximum {
+			return ErrInvalidProfile
+		}
+
+		if _, err := regexp.Compile(prop.Pattern); err != nil {
+			return ErrInvalidProfile
+		}
+	}
+
+	return nil
+}
+
+// Check returns the descriptions of the profile constraints the message
+// violates, ordered by the field. Message conforms to the profile if there
+// are none.
+func (p Profile) Check(msg mainflux.Message) ([]string, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	errs := []string{}
+	for _, field := range p.Required {
+		if _, ok := fields[field]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: required field is missing", field))
+		}
+	}
+
+	for field, val := range fields {
+		prop, ok := p.Properties[field]
+		if !ok {
+			if p.AdditionalProperties != nil && !*p.AdditionalProperties {
+				errs = append(errs, fmt.Sprintf("%s: field is not allowed", field))
+			}
+			continue
+		}
+
+		if err := prop.check(val); err != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", field, err))
+		}
+	}
+
+	sort.Strings(errs)
+	return errs, nil
+}
+
+func (prop Property) check(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		if prop.Type != "" && prop.Type != StringType {
+			return fmt.Sprintf("expected %s got %s", prop.Type, StringType)
+		}
+		if prop.Pattern != "" && !regexp.MustCompile(prop.Pattern).MatchString(v) {
+			return fmt.Sprintf("value doesn't match pattern %s", prop.Pattern)
+		}
+	case float64:
+		if prop.Type != "" && prop.Type != NumberType {
+			return fmt.Sprintf("expected %s got %s", prop.Type, NumberType)
+		}
+		if prop.Minimum != nil && v < *prop.Minimum {
+			return fmt.Sprintf("value is less than minimum %v", *prop.Minimum)
+		}
+		if prop.Maximum != nil && v > *prop.Maximum {
+			return fmt.Sprintf("value is greater than maximum %v", *prop.Maximum)
+		}
+	case bool:
+		if prop.Type != "" && prop.Type != BooleanType {
+			return fmt.Sprintf("expected %s got %s", prop.Type, BooleanType)
+		}
+	}
+
+	if len(prop.Enum) == 0 {
+		return ""
+	}
+
+	for _, e := range prop.Enum {
+		if e == val {
+			return ""
+		}
+	}
+
+	return "value is not one of the enumerated values"
+}
+
+// Scan reads the messages that belong to the given channel and match the
+// given query, page by page, and checks them against the profile. The scan
+// stops early once the sample size of the violations is collected.
+func Scan(repo MessageRepository, chanID string, p Profile, query map[string]string, sampleSize uint64) (ValidationReport, error) {
+	report := ValidationReport{Samples: []Violation{}}
+
+	var offset uint64
+	for {
+		page, err := repo.ReadAll(chanID, offset, scanPageSize, query)
+		if err != nil {
+			return ValidationReport{}, err
+		}
+
+		for _, msg := range page.Messages {
+			errs, err := p.Check(msg)
+			if err != nil {
+				return ValidationReport{}, err
+			}
+
+			report.Scanned++
+			if len(errs) == 0 {
+				continue
+			}
+
+			report.Violations++
+			report.Samples = append(report.Samples, Violation{Message: msg, Errors: errs})
+			if uint64(len(report.Samples)) >= sampleSize {
+				report.Complete = report.Scanned == page.Total
+				return report, nil
+			}
+		}
+
+		offset += uint64(len(page.Messages))
+		if len(page.Messages) < scanPageSize || offset >= page.Total {
+			report.Complete = true
+			return report, nil
+		}
+	}
+}
@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/readers"
+	"github.com/mainflux/mainflux/readers/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseProfile(t *testing.T, data string) readers.Profile {
+	var p readers.Profile
+	err := json.Unmarshal([]byte(data), &p)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	return p
+}
+
+func TestProfileValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		profile string
+		err     error
+	}{
+		{
+			desc:    "validate profile",
+			profile: `{"required":["unit"],"properties":{"value":{"type":"number","minimum":0,"maximum":100},"unit":{"pattern":"^[A-Z]$"}}}`,
+			err:     nil,
+		},
+		{
+			desc:    "validate empty profile",
+			profile: `{}`,
+			err:     nil,
+		},
+		{
+			desc:    "validate profile with unknown type",
+			profile: `{"properties":{"value":{"type":"integer"}}}`,
+			err:     readers.ErrInvalidProfile,
+		},
+		{
+			desc:    "validate profile with minimum above maximum",
+			profile: `{"properties":{"value":{"minimum":10,"maximum":1}}}`,
+			err:     readers.ErrInvalidProfile,
+		},
+		{
+			desc:    "validate profile with malformed pattern",
+			profile: `{"properties":{"unit":{"pattern":"("}}}`,
+			err:     readers.ErrInvalidProfile,
+		},
+		{
+			desc:    "validate profile with empty required field",
+			profile: `{"required":[""]}`,
+			err:     readers.ErrInvalidProfile,
+		},
+	}
+
+	for _, tc := range cases {
+		err := parseProfile(t, tc.profile).Validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestProfileCheck(t *testing.T) {
+	p := parseProfile(t, `{
+		"required": ["unit", "value"],
+		"properties": {
+			"value": {"type": "number", "minimum": 0, "maximum": 100},
+			"unit": {"enum": ["C", "F"]},
+			"name": {"pattern": "^temp"}
+		}
+	}`)
+
+	cases := []struct {
+		desc string
+		msg  mainflux.Message
+		errs []string
+	}{
+		{
+			desc: "check conforming message",
+			msg:  mainflux.Message{Name: "temperature", Unit: "C", Value: &mainflux.Message_FloatValue{FloatValue: 21.5}},
+			errs: []string{},
+		},
+		{
+			desc: "check message with missing fields",
+			msg:  mainflux.Message{Name: "temperature"},
+			errs: []string{"unit: required field is missing", "value: required field is missing"},
+		},
+		{
+			desc: "check message with value out of range",
+			msg:  mainflux.Message{Unit: "C", Value: &mainflux.Message_FloatValue{FloatValue: 120}},
+			errs: []string{"value: value is greater than maximum 100"},
+		},
+		{
+			desc: "check message with name matching pattern",
+			msg:  mainflux.Message{Unit: "C", Value: &mainflux.Message_FloatValue{FloatValue: 1}, Name: "temp"},
+			errs: []string{},
+		},
+		{
+			desc: "check message with unknown enumerated value",
+			msg:  mainflux.Message{Unit: "K", Value: &mainflux.Message_FloatValue{FloatValue: 300}},
+			errs: []string{"unit: value is not one of the enumerated values", "value: value is greater than maximum 100"},
+		},
+		{
+			desc: "check message with value not matching pattern",
+			msg:  mainflux.Message{Name: "humidity", Unit: "F", Value: &mainflux.Message_FloatValue{FloatValue: 50}},
+			errs: []string{"name: value doesn't match pattern ^temp"},
+		},
+	}
+
+	for _, tc := range cases {
+		errs, err := p.Check(tc.msg)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.errs, errs, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.errs, errs))
+	}
+
+	strict := parseProfile(t, `{"properties":{"value":{"type":"boolean"}},"additionalProperties":false}`)
+	errs, err := strict.Check(mainflux.Message{Unit: "C", Value: &mainflux.Message_FloatValue{FloatValue: 1}})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, []string{"unit: field is not allowed", "value: expected boolean got number"}, errs, fmt.Sprintf("check strict profile: got %v", errs))
+}
+
+func TestScan(t *testing.T) {
+	chanID := "1"
+	messages := []mainflux.Message{}
+	for i := 0; i < 250; i++ {
+		msg := mainflux.Message{Channel: chanID, Time: float64(1000 + i), Unit: "C"}
+		// Every fifth message lacks the unit.
+		if i%5 == 0 {
+			msg.Unit = ""
+		}
+		messages = append(messages, msg)
+	}
+	repo := mocks.NewMessageRepository(map[string][]mainflux.Message{chanID: messages})
+	p := parseProfile(t, `{"required":["unit"]}`)
+
+	cases := []struct {
+		desc       string
+		query      map[string]string
+		sample     uint64
+		scanned    uint64
+		violations uint64
+		complete   bool
+	}{
+		{
+			desc:       "scan whole channel",
+			query:      map[string]string{},
+			sample:     100,
+			scanned:    250,
+			violations: 50,
+			complete:   true,
+		},
+		{
+			desc:       "scan channel until sample is full",
+			query:      map[string]string{},
+			sample:     3,
+			scanned:    11,
+			violations: 3,
+			complete:   false,
+		},
+		{
+			desc:       "scan time range",
+			query:      map[string]string{readers.FromKey: "1000", readers.ToKey: "1010"},
+			sample:     100,
+			scanned:    10,
+			violations: 2,
+			complete:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		report, err := readers.Scan(repo, chanID, p, tc.query, tc.sample)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.scanned, report.Scanned, fmt.Sprintf("%s: expected %d scanned got %d", tc.desc, tc.scanned, report.Scanned))
+		assert.Equal(t, tc.violations, report.Violations, fmt.Sprintf("%s: expected %d violations got %d", tc.desc, tc.violations, report.Violations))
+		assert.Equal(t, tc.complete, report.Complete, fmt.Sprintf("%s: expected complete %t got %t", tc.desc, tc.complete, report.Complete))
+		assert.Len(t, report.Samples, int(tc.violations), fmt.Sprintf("%s: expected %d samples got %d", tc.desc, tc.violations, len(report.Samples)))
+	}
+}
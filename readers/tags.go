@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// TagPrefix prefixes the query keys filtering the messages by the tag the
+// writer stored along with the message, e.g. tag.site=plant-1.
+const TagPrefix = "tag."
+
+var (
+	// ErrInvalidTagKey indicates malformed tag key in the allow-list.
+	ErrInvalidTagKey = errors.New("invalid tag key")
+
+	// ErrUnknownTag indicates filtering by the tag that is not allowed.
+	ErrUnknownTag = errors.New("tag is not allowed")
+
+	// ErrUnsupportedTags indicates that the message repository doesn't
+	// support tag filters.
+	ErrUnsupportedTags = errors.New("tag filters are not supported")
+)
+
+// tagKeyRegExp restricts the tag keys, so that they can be safely used as
+// column or field names.
+var tagKeyRegExp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ParseTagKeys parses the comma separated allow-list of the tag keys the
+// messages can be filtered by. Empty list yields no keys.
+func ParseTagKeys(keys string) ([]string, error) {
+	if keys == "" {
+		return nil, nil
+	}
+
+	res := []string{}
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if !tagKeyRegExp.MatchString(key) {
+			return nil, ErrInvalidTagKey
+		}
+		res = append(res, key)
+	}
+
+	return res, nil
+}
+
+// HasTags returns true if the query contains any tag filters.
+func HasTags(query map[string]string) bool {
+	for name := range query {
+		if strings.HasPrefix(name, TagPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tags returns the tag filters of the query, mapping the tag keys to the
+// values the message tags must be equal to.
+func Tags(query map[string]string) (map[string]string, error) {
+	tags := map[string]string{}
+	for name, value := range query {
+		if !strings.HasPrefix(name, TagPrefix) {
+			continue
+		}
+
+		key := strings.TrimPrefix(name, TagPrefix)
+		if !tagKeyRegExp.MatchString(key) {
+			return nil, ErrInvalidTagKey
+		}
+		tags[key] = value
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTagKeys(t *testing.T) {
+	cases := []struct {
+		desc string
+		keys string
+		res  []string
+		err  error
+	}{
+		{
+			desc: "parse tag keys",
+			keys: "site, floor_no",
+			res:  []string{"site", "floor_no"},
+			err:  nil,
+		},
+		{
+			desc: "parse empty tag keys",
+			keys: "",
+			res:  nil,
+			err:  nil,
+		},
+		{
+			desc: "parse tag keys with empty key",
+			keys: "site,,floor",
+			res:  nil,
+			err:  readers.ErrInvalidTagKey,
+		},
+		{
+			desc: "parse tag keys with malformed key",
+			keys: "site,$where",
+			res:  nil,
+			err:  readers.ErrInvalidTagKey,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := readers.ParseTagKeys(tc.keys)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.res, res))
+	}
+}
+
+func TestTags(t *testing.T) {
+	cases := []struct {
+		desc  string
+		query map[string]string
+		tags  map[string]string
+		err   error
+	}{
+		{
+			desc:  "read tags",
+			query: map[string]string{"publisher": "1", "tag.site": "plant-1", "tag.floor": "2"},
+			tags:  map[string]string{"site": "plant-1", "floor": "2"},
+			err:   nil,
+		},
+		{
+			desc:  "read tags from query without tags",
+			query: map[string]string{"publisher": "1"},
+			tags:  map[string]string{},
+			err:   nil,
+		},
+		{
+			desc:  "read tags with malformed key",
+			query: map[string]string{"tag.site.name": "plant-1"},
+			tags:  nil,
+			err:   readers.ErrInvalidTagKey,
+		},
+	}
+
+	for _, tc := range cases {
+		tags, err := readers.Tags(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.tags, tags, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.tags, tags))
+	}
+}
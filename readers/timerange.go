@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Keys of the query parameters bounding the message time, given in seconds
+// since the Unix epoch.
+const (
+	FromKey = "from"
+	ToKey   = "to"
+)
+
+// ErrInvalidTimeRange indicates malformed time range bounds or the range
+// ending before it starts.
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// TimeRange bounds the time of the messages. The range is half-open, i.e.
+// From is inclusive while To is exclusive, so that the adjacent ranges can be
+// stitched together without gaps and without matching the same message twice.
+// Nil bound leaves the range open on its side.
+type TimeRange struct {
+	From *float64
+	To   *float64
+}
+
+// ParseTimeRange reads the time range bounds from the query.
+func ParseTimeRange(query map[string]string) (TimeRange, error) {
+	var tr TimeRange
+
+	from, err := parseBound(query[FromKey])
+	if err != nil {
+		return TimeRange{}, err
+	}
+	tr.From = from
+
+	to, err := parseBound(query[ToKey])
+	if err != nil {
+		return TimeRange{}, err
+	}
+	tr.To = to
+
+	if tr.From != nil && tr.To != nil && *tr.From > *tr.To {
+		return TimeRange{}, ErrInvalidTimeRange
+	}
+
+	return tr, nil
+}
+
+// Contains returns true if the time falls within the range.
+func (tr TimeRange) Contains(t float64) bool {
+	if tr.From != nil && t < *tr.From {
+		return false
+	}
+
+	if tr.To != nil && t >= *tr.To {
+		return false
+	}
+
+	return true
+}
+
+func parseBound(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, ErrInvalidTimeRange
+	}
+
+	return &t, nil
+}
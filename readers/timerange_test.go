@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	cases := []struct {
+		desc  string
+		query map[string]string
+		err   error
+	}{
+		{
+			desc:  "parse range",
+			query: map[string]string{readers.FromKey: "1000", readers.ToKey: "2000.5"},
+			err:   nil,
+		},
+		{
+			desc:  "parse open range",
+			query: map[string]string{},
+			err:   nil,
+		},
+		{
+			desc:  "parse empty range",
+			query: map[string]string{readers.FromKey: "1000", readers.ToKey: "1000"},
+			err:   nil,
+		},
+		{
+			desc:  "parse range with malformed start",
+			query: map[string]string{readers.FromKey: "yesterday"},
+			err:   readers.ErrInvalidTimeRange,
+		},
+		{
+			desc:  "parse range with malformed end",
+			query: map[string]string{readers.ToKey: "now"},
+			err:   readers.ErrInvalidTimeRange,
+		},
+		{
+			desc:  "parse range ending before start",
+			query: map[string]string{readers.FromKey: "2000", readers.ToKey: "1000"},
+			err:   readers.ErrInvalidTimeRange,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := readers.ParseTimeRange(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	tr, err := readers.ParseTimeRange(map[string]string{readers.FromKey: "1000", readers.ToKey: "2000"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		time     float64
+		contains bool
+	}{
+		{
+			desc:     "time before start",
+			time:     999.999,
+			contains: false,
+		},
+		{
+			desc:     "time at start",
+			time:     1000,
+			contains: true,
+		},
+		{
+			desc:     "time within range",
+			time:     1500,
+			contains: true,
+		},
+		{
+			desc:     "time just before end",
+			time:     1999.999,
+			contains: true,
+		},
+		{
+			desc:     "time at end",
+			time:     2000,
+			contains: false,
+		},
+	}
+
+	for _, tc := range cases {
+		contains := tr.Contains(tc.time)
+		assert.Equal(t, tc.contains, contains, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.contains, contains))
+	}
+}
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/mainflux/mainflux"
+)
+
+// Keys of the query parameters filtering the messages by their value.
+const (
+	ValueKey        = "v"
+	ValueGreaterKey = "vg"
+	ValueLessKey    = "vl"
+	BoolValueKey    = "vb"
+	StringValueKey  = "vs"
+)
+
+// ErrInvalidValueFilter indicates malformed value filter, the float value
+// range that can't match any value, or the filters by the values of
+// different types, which no message can match.
+var ErrInvalidValueFilter = errors.New("invalid value filter")
+
+// ValueFilter filters the messages by their value. Equal matches the float
+// value exactly, while Greater and Less bound it exclusively. Bool and String
+// match the bool and the string value exactly. Nil bound or value leaves the
+// filter open. Non-empty filter matches the messages carrying the value of
+// the filtered type only.
+type ValueFilter struct {
+	Equal   *float64
+	Greater *float64
+	Less    *float64
+	Bool    *bool
+	String  *string
+}
+
+// ParseValueFilter reads the value filter from the query.
+func ParseValueFilter(query map[string]string) (ValueFilter, error) {
+	var vf ValueFilter
+
+	for key, dest := range map[string]**float64{
+		ValueKey:        &vf.Equal,
+		ValueGreaterKey: &vf.Greater,
+		ValueLessKey:    &vf.Less,
+	} {
+		value, ok := query[key]
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return ValueFilter{}, ErrInvalidValueFilter
+		}
+		*dest = &v
+	}
+
+	if vf.Greater != nil && vf.Less != nil && *vf.Greater >= *vf.Less {
+		return ValueFilter{}, ErrInvalidValueFilter
+	}
+
+	if value, ok := query[BoolValueKey]; ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return ValueFilter{}, ErrInvalidValueFilter
+		}
+		vf.Bool = &b
+	}
+
+	if value, ok := query[StringValueKey]; ok {
+		vf.String = &value
+	}
+
+	types := 0
+	for _, filtered := range []bool{vf.Float(), vf.Bool != nil, vf.String != nil} {
+		if filtered {
+			types++
+		}
+	}
+	if types > 1 {
+		return ValueFilter{}, ErrInvalidValueFilter
+	}
+
+	return vf, nil
+}
+
+// Empty returns true if the filter doesn't constrain the value.
+func (vf ValueFilter) Empty() bool {
+	return !vf.Float() && vf.Bool == nil && vf.String == nil
+}
+
+// Float returns true if the filter constrains the float value.
+func (vf ValueFilter) Float() bool {
+	return vf.Equal != nil || vf.Greater != nil || vf.Less != nil
+}
+
+// Matches returns true if the message value passes the filter.
+func (vf ValueFilter) Matches(msg mainflux.Message) bool {
+	switch v := msg.Value.(type) {
+	case *mainflux.Message_FloatValue:
+		return vf.Bool == nil && vf.String == nil && vf.Contains(v.FloatValue)
+	case *mainflux.Message_BoolValue:
+		return !vf.Float() && vf.String == nil && (vf.Bool == nil || *vf.Bool == v.BoolValue)
+	case *mainflux.Message_StringValue:
+		return !vf.Float() && vf.Bool == nil && (vf.String == nil || *vf.String == v.StringValue)
+	}
+
+	return vf.Empty()
+}
+
+// Contains returns true if the float value passes the filter.
+func (vf ValueFilter) Contains(v float64) bool {
+	if vf.Equal != nil && v != *vf.Equal {
+		return false
+	}
+
+	if vf.Greater != nil && v <= *vf.Greater {
+		return false
+	}
+
+	if vf.Less != nil && v >= *vf.Less {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,151 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package readers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/readers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValueFilter(t *testing.T) {
+	cases := []struct {
+		desc     string
+		query    map[string]string
+		err      error
+		empty    bool
+		contains map[float64]bool
+	}{
+		{
+			desc:     "parse empty value filter",
+			query:    map[string]string{},
+			err:      nil,
+			empty:    true,
+			contains: map[float64]bool{-1: true, 30: true},
+		},
+		{
+			desc:     "parse equal value filter",
+			query:    map[string]string{readers.ValueKey: "30"},
+			err:      nil,
+			contains: map[float64]bool{30: true, 30.5: false},
+		},
+		{
+			desc:     "parse greater value filter",
+			query:    map[string]string{readers.ValueGreaterKey: "30.0"},
+			err:      nil,
+			contains: map[float64]bool{30: false, 30.5: true, 29: false},
+		},
+		{
+			desc:     "parse value range filter",
+			query:    map[string]string{readers.ValueGreaterKey: "-10", readers.ValueLessKey: "10"},
+			err:      nil,
+			contains: map[float64]bool{-10: false, 0: true, 10: false},
+		},
+		{
+			desc:  "parse malformed value filter",
+			query: map[string]string{readers.ValueLessKey: "hot"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		{
+			desc:  "parse empty value range filter",
+			query: map[string]string{readers.ValueGreaterKey: "10", readers.ValueLessKey: "10"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+	}
+
+	for _, tc := range cases {
+		vf, err := readers.ParseValueFilter(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		assert.Equal(t, tc.empty, vf.Empty(), fmt.Sprintf("%s: expected empty %t got %t", tc.desc, tc.empty, vf.Empty()))
+		for v, contains := range tc.contains {
+			assert.Equal(t, contains, vf.Contains(v), fmt.Sprintf("%s: expected %f contained %t got %t", tc.desc, v, contains, vf.Contains(v)))
+		}
+	}
+}
+
+func TestValueFilterMatches(t *testing.T) {
+	float := mainflux.Message{Value: &mainflux.Message_FloatValue{FloatValue: 30}}
+	on := mainflux.Message{Value: &mainflux.Message_StringValue{StringValue: "on"}}
+	off := mainflux.Message{Value: &mainflux.Message_StringValue{StringValue: "off"}}
+	yes := mainflux.Message{Value: &mainflux.Message_BoolValue{BoolValue: true}}
+	no := mainflux.Message{Value: &mainflux.Message_BoolValue{BoolValue: false}}
+	data := mainflux.Message{Value: &mainflux.Message_DataValue{DataValue: "data"}}
+
+	cases := []struct {
+		desc    string
+		query   map[string]string
+		err     error
+		matches []mainflux.Message
+		misses  []mainflux.Message
+	}{
+		{
+			desc:    "match empty value filter",
+			query:   map[string]string{},
+			err:     nil,
+			matches: []mainflux.Message{float, on, off, yes, no, data},
+		},
+		{
+			desc:    "match float value filter",
+			query:   map[string]string{readers.ValueGreaterKey: "20"},
+			err:     nil,
+			matches: []mainflux.Message{float},
+			misses:  []mainflux.Message{on, off, yes, no, data},
+		},
+		{
+			desc:    "match bool value filter",
+			query:   map[string]string{readers.BoolValueKey: "true"},
+			err:     nil,
+			matches: []mainflux.Message{yes},
+			misses:  []mainflux.Message{float, on, off, no, data},
+		},
+		{
+			desc:    "match string value filter",
+			query:   map[string]string{readers.StringValueKey: "on"},
+			err:     nil,
+			matches: []mainflux.Message{on},
+			misses:  []mainflux.Message{float, off, yes, no, data},
+		},
+		{
+			desc:  "match malformed bool value filter",
+			query: map[string]string{readers.BoolValueKey: "maybe"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		{
+			desc:  "match float and bool value filter",
+			query: map[string]string{readers.ValueKey: "30", readers.BoolValueKey: "true"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+		{
+			desc:  "match bool and string value filter",
+			query: map[string]string{readers.BoolValueKey: "true", readers.StringValueKey: "on"},
+			err:   readers.ErrInvalidValueFilter,
+		},
+	}
+
+	for _, tc := range cases {
+		vf, err := readers.ParseValueFilter(tc.query)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		for _, msg := range tc.matches {
+			assert.True(t, vf.Matches(msg), fmt.Sprintf("%s: expected %v to match", tc.desc, msg.Value))
+		}
+		for _, msg := range tc.misses {
+			assert.False(t, vf.Matches(msg), fmt.Sprintf("%s: expected %v not to match", tc.desc, msg.Value))
+		}
+	}
+}
@@ -23,7 +23,7 @@ import (
 
 func newMessageService(cc mainflux.ThingsServiceClient) mainflux.MessagePublisher {
 	pub := mocks.NewPublisher()
-	return adapter.New(pub, cc)
+	return adapter.New(pub, cc, "")
 }
 
 func newMessageServer(pub mainflux.MessagePublisher) *httptest.Server {
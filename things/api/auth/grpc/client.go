@@ -23,10 +23,14 @@ import (
 var _ mainflux.ThingsServiceClient = (*grpcClient)(nil)
 
 type grpcClient struct {
-	timeout       time.Duration
-	canAccess     endpoint.Endpoint
-	canAccessByID endpoint.Endpoint
-	identify      endpoint.Endpoint
+	conn            *grpc.ClientConn
+	timeout         time.Duration
+	canAccess       endpoint.Endpoint
+	canAccessByID   endpoint.Endpoint
+	canReadShared   endpoint.Endpoint
+	canReadMessages endpoint.Endpoint
+	identify        endpoint.Endpoint
+	identifyBatch   endpoint.Endpoint
 }
 
 // NewClient returns new gRPC client instance.
@@ -34,6 +38,7 @@ func NewClient(conn *grpc.ClientConn, tracer opentracing.Tracer, timeout time.Du
 	svcName := "mainflux.ThingsService"
 
 	return &grpcClient{
+		conn:    conn,
 		timeout: timeout,
 		canAccess: kitot.TraceClient(tracer, "can_access")(kitgrpc.NewClient(
 			conn,
@@ -51,6 +56,22 @@ func NewClient(conn *grpc.ClientConn, tracer opentracing.Tracer, timeout time.Du
 			decodeEmptyResponse,
 			empty.Empty{},
 		).Endpoint()),
+		canReadShared: kitot.TraceClient(tracer, "can_read_shared")(kitgrpc.NewClient(
+			conn,
+			svcName,
+			"CanReadShared",
+			encodeCanReadSharedRequest,
+			decodeEmptyResponse,
+			empty.Empty{},
+		).Endpoint()),
+		canReadMessages: kitot.TraceClient(tracer, "can_read_messages")(kitgrpc.NewClient(
+			conn,
+			svcName,
+			"CanReadMessages",
+			encodeCanAccessRequest,
+			decodeIdentityResponse,
+			mainflux.ThingID{},
+		).Endpoint()),
 		identify: kitot.TraceClient(tracer, "identify")(kitgrpc.NewClient(
 			conn,
 			svcName,
@@ -59,6 +80,14 @@ func NewClient(conn *grpc.ClientConn, tracer opentracing.Tracer, timeout time.Du
 			decodeIdentityResponse,
 			mainflux.ThingID{},
 		).Endpoint()),
+		identifyBatch: kitot.TraceClient(tracer, "identify_batch")(kitgrpc.NewClient(
+			conn,
+			svcName,
+			"IdentifyBatch",
+			encodeIdentifyBatchRequest,
+			decodeIdentityBatchResponse,
+			mainflux.ThingIDs{},
+		).Endpoint()),
 	}
 }
 
@@ -90,6 +119,37 @@ func (client grpcClient) CanAccessByID(ctx context.Context, req *mainflux.Access
 	return &empty.Empty{}, er.err
 }
 
+func (client grpcClient) CanReadShared(ctx context.Context, req *mainflux.AccessReq, _ ...grpc.CallOption) (*empty.Empty, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	sr := sharedAccessReq{linkToken: req.GetToken(), chanID: req.GetChanID()}
+	res, err := client.canReadShared(ctx, sr)
+	if err != nil {
+		return nil, err
+	}
+
+	er := res.(emptyRes)
+	return &empty.Empty{}, er.err
+}
+
+func (client grpcClient) CanReadMessages(ctx context.Context, req *mainflux.AccessReq, _ ...grpc.CallOption) (*mainflux.ThingID, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	ar := accessReq{
+		thingKey: req.GetToken(),
+		chanID:   req.GetChanID(),
+	}
+	res, err := client.canReadMessages(ctx, ar)
+	if err != nil {
+		return nil, err
+	}
+
+	ir := res.(identityRes)
+	return &mainflux.ThingID{Value: ir.id, PublisherScoped: ir.scoped}, ir.err
+}
+
 func (client grpcClient) Identify(ctx context.Context, req *mainflux.Token, _ ...grpc.CallOption) (*mainflux.ThingID, error) {
 	ctx, cancel := context.WithTimeout(ctx, client.timeout)
 	defer cancel()
@@ -100,7 +160,27 @@ func (client grpcClient) Identify(ctx context.Context, req *mainflux.Token, _ ..
 	}
 
 	ir := res.(identityRes)
-	return &mainflux.ThingID{Value: ir.id}, ir.err
+	return &mainflux.ThingID{Value: ir.id, CreatedAt: ir.createdAt, UpdatedAt: ir.updatedAt}, ir.err
+}
+
+func (client grpcClient) IdentifyBatch(ctx context.Context, req *mainflux.Tokens, _ ...grpc.CallOption) (*mainflux.ThingIDs, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.identifyBatch(ctx, identifyBatchReq{keys: req.GetValues()})
+	if err != nil {
+		return nil, err
+	}
+
+	ir := res.(identityBatchRes)
+	return &mainflux.ThingIDs{Values: ir.ids}, ir.err
+}
+
+// ListThingsByChannel isn't bound by the client timeout, since the stream
+// lasts as long as it takes to receive all the connected things. The caller
+// controls its lifetime through the context.
+func (client grpcClient) ListThingsByChannel(ctx context.Context, req *mainflux.AccessReq, opts ...grpc.CallOption) (mainflux.ThingsService_ListThingsByChannelClient, error) {
+	return mainflux.NewThingsServiceClient(client.conn).ListThingsByChannel(ctx, req, opts...)
 }
 
 func encodeCanAccessRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -113,14 +193,29 @@ func encodeCanAccessByIDRequest(_ context.Context, grpcReq interface{}) (interfa
 	return &mainflux.AccessByIDReq{ThingID: req.thingID, ChanID: req.chanID}, nil
 }
 
+func encodeCanReadSharedRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(sharedAccessReq)
+	return &mainflux.AccessReq{Token: req.linkToken, ChanID: req.chanID}, nil
+}
+
 func encodeIdentifyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(identifyReq)
 	return &mainflux.Token{Value: req.key}, nil
 }
 
+func encodeIdentifyBatchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(identifyBatchReq)
+	return &mainflux.Tokens{Values: req.keys}, nil
+}
+
 func decodeIdentityResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(*mainflux.ThingID)
-	return identityRes{id: res.GetValue(), err: nil}, nil
+	return identityRes{id: res.GetValue(), createdAt: res.GetCreatedAt(), updatedAt: res.GetUpdatedAt(), scoped: res.GetPublisherScoped(), err: nil}, nil
+}
+
+func decodeIdentityBatchResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*mainflux.ThingIDs)
+	return identityBatchRes{ids: res.GetValues(), err: nil}, nil
 }
 
 func decodeEmptyResponse(_ context.Context, _ interface{}) (interface{}, error) {
@@ -40,13 +40,61 @@ func canAccessByIDEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func canReadSharedEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sharedAccessReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		err := svc.CanReadShared(ctx, req.linkToken, req.chanID)
+		return emptyRes{err: err}, err
+	}
+}
+
+func canReadMessagesEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(accessReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		id, scoped, err := svc.CanReadMessages(ctx, req.chanID, req.thingKey)
+		if err != nil {
+			return identityRes{err: err}, err
+		}
+		return identityRes{id: id, scoped: scoped, err: nil}, nil
+	}
+}
+
 func identifyEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(identifyReq)
-		id, err := svc.Identify(ctx, req.key)
+		thing, err := svc.IdentifyFull(ctx, req.key)
 		if err != nil {
 			return identityRes{err: err}, err
 		}
-		return identityRes{id: id, err: nil}, nil
+
+		res := identityRes{
+			id:        thing.ID,
+			createdAt: unixNano(thing.CreatedAt),
+			updatedAt: unixNano(thing.UpdatedAt),
+		}
+		return res, nil
+	}
+}
+
+func identifyBatchEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(identifyBatchReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ids, err := svc.IdentifyBatch(ctx, req.keys)
+		if err != nil {
+			return identityBatchRes{err: err}, err
+		}
+		return identityBatchRes{ids: ids, err: nil}, nil
 	}
 }
@@ -10,6 +10,8 @@ package grpc_test
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/mainflux/mainflux/things"
 	grpcapi "github.com/mainflux/mainflux/things/api/auth/grpc"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -35,7 +38,7 @@ func TestCanAccess(t *testing.T) {
 	oth, _ := svc.AddThing(context.Background(), token, thing)
 	cth, _ := svc.AddThing(context.Background(), token, thing)
 	sch, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, sch.ID, cth.ID)
+	svc.Connect(context.Background(), token, sch.ID, cth.ID, 0)
 
 	usersAddr := fmt.Sprintf("localhost:%d", port)
 	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
@@ -88,7 +91,7 @@ func TestCanAccessByID(t *testing.T) {
 	oth, _ := svc.AddThing(context.Background(), token, thing)
 	cth, _ := svc.AddThing(context.Background(), token, thing)
 	sch, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, sch.ID, cth.ID)
+	svc.Connect(context.Background(), token, sch.ID, cth.ID, 0)
 
 	usersAddr := fmt.Sprintf("localhost:%d", port)
 	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
@@ -136,6 +139,117 @@ func TestCanAccessByID(t *testing.T) {
 	}
 }
 
+func TestCanReadShared(t *testing.T) {
+	sch, _ := svc.CreateChannel(context.Background(), token, channel)
+	och, _ := svc.CreateChannel(context.Background(), token, channel)
+	link, _ := svc.CreateShareLink(context.Background(), token, sch.ID, time.Hour)
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cases := map[string]struct {
+		link   string
+		chanID string
+		code   codes.Code
+	}{
+		"check if link grants access to shared channel": {
+			link:   link.Token,
+			chanID: sch.ID,
+			code:   codes.OK,
+		},
+		"check if link grants access to other channel": {
+			link:   link.Token,
+			chanID: och.ID,
+			code:   codes.PermissionDenied,
+		},
+		"check if non-existent link grants access to channel": {
+			link:   wrong,
+			chanID: sch.ID,
+			code:   codes.PermissionDenied,
+		},
+		"check if empty link grants access to channel": {
+			link:   "",
+			chanID: sch.ID,
+			code:   codes.InvalidArgument,
+		},
+		"check if link grants access to channel with empty ID": {
+			link:   link.Token,
+			chanID: "",
+			code:   codes.InvalidArgument,
+		},
+	}
+
+	for desc, tc := range cases {
+		_, err := cli.CanReadShared(ctx, &mainflux.AccessReq{Token: tc.link, ChanID: tc.chanID})
+		e, ok := status.FromError(err)
+		assert.True(t, ok, "OK expected to be true")
+		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
+	}
+}
+
+func TestCanReadMessages(t *testing.T) {
+	th, _ := svc.AddThing(context.Background(), token, thing)
+	ch, _ := svc.CreateChannel(context.Background(), token, channel)
+	scoped := channel
+	scoped.PublisherScoped = true
+	sch, _ := svc.CreateChannel(context.Background(), token, scoped)
+	svc.Connect(context.Background(), token, ch.ID, th.ID, 0)
+	svc.Connect(context.Background(), token, sch.ID, th.ID, 0)
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cases := map[string]struct {
+		key     string
+		chanID  string
+		thingID string
+		scoped  bool
+		code    codes.Code
+	}{
+		"check if connected thing can read messages of channel": {
+			key:     th.Key,
+			chanID:  ch.ID,
+			thingID: th.ID,
+			scoped:  false,
+			code:    codes.OK,
+		},
+		"check if connected thing can read messages of publisher-scoped channel": {
+			key:     th.Key,
+			chanID:  sch.ID,
+			thingID: th.ID,
+			scoped:  true,
+			code:    codes.OK,
+		},
+		"check if thing with wrong access key can read messages of channel": {
+			key:     wrong,
+			chanID:  sch.ID,
+			thingID: wrongID,
+			code:    codes.PermissionDenied,
+		},
+		"check if connected thing can read messages of non-existent channel": {
+			key:     th.Key,
+			chanID:  wrongID,
+			thingID: wrongID,
+			code:    codes.InvalidArgument,
+		},
+	}
+
+	for desc, tc := range cases {
+		id, err := cli.CanReadMessages(ctx, &mainflux.AccessReq{Token: tc.key, ChanID: tc.chanID})
+		e, ok := status.FromError(err)
+		assert.True(t, ok, "OK expected to be true")
+		assert.Equal(t, tc.thingID, id.GetValue(), fmt.Sprintf("%s: expected %s got %s", desc, tc.thingID, id.GetValue()))
+		assert.Equal(t, tc.scoped, id.GetPublisherScoped(), fmt.Sprintf("%s: expected %t got %t", desc, tc.scoped, id.GetPublisherScoped()))
+		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
+	}
+}
+
 func TestIdentify(t *testing.T) {
 	sth, _ := svc.AddThing(context.Background(), token, thing)
 
@@ -170,3 +284,149 @@ func TestIdentify(t *testing.T) {
 		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
 	}
 }
+
+func TestIdentifyTimestamps(t *testing.T) {
+	sth, _ := svc.AddThing(context.Background(), token, thing)
+	sth.Name = "updated"
+	err := svc.UpdateThing(context.Background(), token, sth)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	th, err := svc.ViewThing(context.Background(), token, sth.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.False(t, th.CreatedAt.IsZero(), "expected thing creation time to be set")
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	id, err := cli.Identify(ctx, &mainflux.Token{Value: sth.Key})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	createdAt := time.Unix(0, id.GetCreatedAt())
+	updatedAt := time.Unix(0, id.GetUpdatedAt())
+	assert.True(t, th.CreatedAt.Equal(createdAt), fmt.Sprintf("expected creation time %s got %s", th.CreatedAt, createdAt))
+	assert.True(t, th.UpdatedAt.Equal(updatedAt), fmt.Sprintf("expected update time %s got %s", th.UpdatedAt, updatedAt))
+	assert.True(t, updatedAt.After(createdAt), fmt.Sprintf("expected update time %s to follow creation time %s", updatedAt, createdAt))
+}
+
+func TestIdentifyBatch(t *testing.T) {
+	sth1, _ := svc.AddThing(context.Background(), token, thing)
+	sth2, _ := svc.AddThing(context.Background(), token, thing)
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cases := map[string]struct {
+		keys []string
+		ids  map[string]string
+		code codes.Code
+	}{
+		"identify existing things": {
+			keys: []string{sth1.Key, sth2.Key},
+			ids:  map[string]string{sth1.Key: sth1.ID, sth2.Key: sth2.ID},
+			code: codes.OK,
+		},
+		"identify existing and non-existent things": {
+			keys: []string{sth1.Key, wrong},
+			ids:  map[string]string{sth1.Key: sth1.ID},
+			code: codes.OK,
+		},
+		"identify non-existent things": {
+			keys: []string{wrong},
+			ids:  nil,
+			code: codes.OK,
+		},
+		"identify without keys": {
+			keys: []string{},
+			ids:  nil,
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for desc, tc := range cases {
+		res, err := cli.IdentifyBatch(ctx, &mainflux.Tokens{Values: tc.keys})
+		e, ok := status.FromError(err)
+		assert.True(t, ok, "OK expected to be true")
+		assert.Equal(t, tc.ids, res.GetValues(), fmt.Sprintf("%s: expected %v got %v", desc, tc.ids, res.GetValues()))
+		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
+	}
+}
+
+func TestListThingsByChannel(t *testing.T) {
+	n := 250
+	sch, _ := svc.CreateChannel(context.Background(), token, channel)
+	ech, _ := svc.CreateChannel(context.Background(), token, channel)
+
+	ids := []string{}
+	for i := 0; i < n; i++ {
+		th, _ := svc.AddThing(context.Background(), token, thing)
+		svc.Connect(context.Background(), token, sch.ID, th.ID, 0)
+		ids = append(ids, th.ID)
+	}
+	sort.Strings(ids)
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.Dial(usersAddr, grpc.WithInsecure())
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+
+	cases := map[string]struct {
+		token  string
+		chanID string
+		ids    []string
+		code   codes.Code
+	}{
+		"list things connected to channel": {
+			token:  token,
+			chanID: sch.ID,
+			ids:    ids,
+			code:   codes.OK,
+		},
+		"list things connected to channel without things": {
+			token:  token,
+			chanID: ech.ID,
+			ids:    []string{},
+			code:   codes.OK,
+		},
+		"list things connected to channel with invalid credentials": {
+			token:  wrong,
+			chanID: sch.ID,
+			ids:    []string{},
+			code:   codes.PermissionDenied,
+		},
+		"list things connected to channel without channel ID": {
+			token:  token,
+			chanID: "",
+			ids:    []string{},
+			code:   codes.InvalidArgument,
+		},
+	}
+
+	for desc, tc := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		stream, err := cli.ListThingsByChannel(ctx, &mainflux.AccessReq{Token: tc.token, ChanID: tc.chanID})
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+
+		received := []string{}
+		for {
+			th, err := stream.Recv()
+			if err == io.EOF {
+				err = nil
+			}
+			if err != nil || th == nil {
+				e, ok := status.FromError(err)
+				assert.True(t, ok, "OK expected to be true")
+				assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
+				break
+			}
+			received = append(received, th.GetId())
+		}
+		cancel()
+
+		assert.Equal(t, tc.ids, received, fmt.Sprintf("%s: expected %d things in order got %d", desc, len(tc.ids), len(received)))
+	}
+}
@@ -9,6 +9,10 @@ package grpc
 
 import "github.com/mainflux/mainflux/things"
 
+// maxBatchSize is the maximum number of keys resolved by a single batch
+// identify request.
+const maxBatchSize = 1000
+
 type accessReq struct {
 	thingKey string
 	chanID   string
@@ -35,6 +39,19 @@ func (req accessByIDReq) validate() error {
 	return nil
 }
 
+type sharedAccessReq struct {
+	linkToken string
+	chanID    string
+}
+
+func (req sharedAccessReq) validate() error {
+	if req.linkToken == "" || req.chanID == "" {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
 type identifyReq struct {
 	key string
 }
@@ -46,3 +63,15 @@ func (req identifyReq) validate() error {
 
 	return nil
 }
+
+type identifyBatchReq struct {
+	keys []string
+}
+
+func (req identifyBatchReq) validate() error {
+	if len(req.keys) == 0 || len(req.keys) > maxBatchSize {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
@@ -7,11 +7,34 @@
 
 package grpc
 
+import "time"
+
+// identityRes carries the thing ID and, if the thing is identified by the
+// key, its creation and update times in nanoseconds since the Unix epoch.
+// Scoped tells whether the thing reads only its own messages of the channel.
 type identityRes struct {
-	id  string
-	err error
+	id        string
+	createdAt int64
+	updatedAt int64
+	scoped    bool
+	err       error
 }
 
 type emptyRes struct {
 	err error
 }
+
+type identityBatchRes struct {
+	ids map[string]string
+	err error
+}
+
+// unixNano returns the time in nanoseconds since the Unix epoch, or zero if
+// the time isn't set.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
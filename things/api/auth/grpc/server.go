@@ -8,6 +8,8 @@
 package grpc
 
 import (
+	"encoding/json"
+
 	kitot "github.com/go-kit/kit/tracing/opentracing"
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
 	"github.com/golang/protobuf/ptypes/empty"
@@ -19,17 +21,28 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// streamBatchSize is the number of things retrieved from the repository per
+// batch while streaming the things connected to the channel.
+const streamBatchSize = 100
+
 var _ mainflux.ThingsServiceServer = (*grpcServer)(nil)
 
 type grpcServer struct {
-	canAccess     kitgrpc.Handler
-	canAccessByID kitgrpc.Handler
-	identify      kitgrpc.Handler
+	svc             things.Service
+	tracer          opentracing.Tracer
+	canAccess       kitgrpc.Handler
+	canAccessByID   kitgrpc.Handler
+	canReadShared   kitgrpc.Handler
+	canReadMessages kitgrpc.Handler
+	identify        kitgrpc.Handler
+	identifyBatch   kitgrpc.Handler
 }
 
 // NewServer returns new ThingsServiceServer instance.
 func NewServer(tracer opentracing.Tracer, svc things.Service) mainflux.ThingsServiceServer {
 	return &grpcServer{
+		svc:    svc,
+		tracer: tracer,
 		canAccess: kitgrpc.NewServer(
 			kitot.TraceServer(tracer, "can_access")(canAccessEndpoint(svc)),
 			decodeCanAccessRequest,
@@ -40,11 +53,26 @@ func NewServer(tracer opentracing.Tracer, svc things.Service) mainflux.ThingsSer
 			decodeCanAccessByIDRequest,
 			encodeEmptyResponse,
 		),
+		canReadShared: kitgrpc.NewServer(
+			kitot.TraceServer(tracer, "can_read_shared")(canReadSharedEndpoint(svc)),
+			decodeCanReadSharedRequest,
+			encodeEmptyResponse,
+		),
+		canReadMessages: kitgrpc.NewServer(
+			kitot.TraceServer(tracer, "can_read_messages")(canReadMessagesEndpoint(svc)),
+			decodeCanAccessRequest,
+			encodeIdentityResponse,
+		),
 		identify: kitgrpc.NewServer(
 			kitot.TraceServer(tracer, "identify")(identifyEndpoint(svc)),
 			decodeIdentifyRequest,
 			encodeIdentityResponse,
 		),
+		identifyBatch: kitgrpc.NewServer(
+			kitot.TraceServer(tracer, "identify_batch")(identifyBatchEndpoint(svc)),
+			decodeIdentifyBatchRequest,
+			encodeIdentityBatchResponse,
+		),
 	}
 }
 
@@ -66,6 +94,24 @@ func (gs *grpcServer) CanAccessByID(ctx context.Context, req *mainflux.AccessByI
 	return res.(*empty.Empty), nil
 }
 
+func (gs *grpcServer) CanReadShared(ctx context.Context, req *mainflux.AccessReq) (*empty.Empty, error) {
+	_, res, err := gs.canReadShared.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return res.(*empty.Empty), nil
+}
+
+func (gs *grpcServer) CanReadMessages(ctx context.Context, req *mainflux.AccessReq) (*mainflux.ThingID, error) {
+	_, res, err := gs.canReadMessages.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return res.(*mainflux.ThingID), nil
+}
+
 func (gs *grpcServer) Identify(ctx context.Context, req *mainflux.Token) (*mainflux.ThingID, error) {
 	_, res, err := gs.identify.ServeGRPC(ctx, req)
 	if err != nil {
@@ -75,6 +121,57 @@ func (gs *grpcServer) Identify(ctx context.Context, req *mainflux.Token) (*mainf
 	return res.(*mainflux.ThingID), nil
 }
 
+func (gs *grpcServer) IdentifyBatch(ctx context.Context, req *mainflux.Tokens) (*mainflux.ThingIDs, error) {
+	_, res, err := gs.identifyBatch.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return res.(*mainflux.ThingIDs), nil
+}
+
+// ListThingsByChannel streams all the things connected to the channel owned by
+// the user identified by the request token. Things are read from the
+// repository in batches ordered by ID, so the memory footprint doesn't grow
+// with the number of connected things.
+func (gs *grpcServer) ListThingsByChannel(req *mainflux.AccessReq, stream mainflux.ThingsService_ListThingsByChannelServer) error {
+	span, ctx := opentracing.StartSpanFromContextWithTracer(stream.Context(), gs.tracer, "list_things_by_channel")
+	defer span.Finish()
+
+	if req.GetChanID() == "" {
+		return encodeError(things.ErrMalformedEntity)
+	}
+
+	after := ""
+	for {
+		ths, err := gs.svc.ListThingsByChannelAfter(ctx, req.GetToken(), req.GetChanID(), after, streamBatchSize)
+		if err != nil {
+			return encodeError(err)
+		}
+
+		for _, th := range ths {
+			md, err := json.Marshal(th.Metadata)
+			if err != nil {
+				return encodeError(err)
+			}
+
+			thing := &mainflux.Thing{
+				Id:       th.ID,
+				Name:     th.Name,
+				Metadata: string(md),
+			}
+			if err := stream.Send(thing); err != nil {
+				return err
+			}
+		}
+
+		if len(ths) < streamBatchSize {
+			return nil
+		}
+		after = ths[len(ths)-1].ID
+	}
+}
+
 func decodeCanAccessRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*mainflux.AccessReq)
 	return accessReq{thingKey: req.GetToken(), chanID: req.GetChanID()}, nil
@@ -85,14 +182,29 @@ func decodeCanAccessByIDRequest(_ context.Context, grpcReq interface{}) (interfa
 	return accessByIDReq{thingID: req.GetThingID(), chanID: req.GetChanID()}, nil
 }
 
+func decodeCanReadSharedRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*mainflux.AccessReq)
+	return sharedAccessReq{linkToken: req.GetToken(), chanID: req.GetChanID()}, nil
+}
+
 func decodeIdentifyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*mainflux.Token)
 	return identifyReq{key: req.GetValue()}, nil
 }
 
+func decodeIdentifyBatchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*mainflux.Tokens)
+	return identifyBatchReq{keys: req.GetValues()}, nil
+}
+
 func encodeIdentityResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(identityRes)
-	return &mainflux.ThingID{Value: res.id}, encodeError(res.err)
+	return &mainflux.ThingID{Value: res.id, CreatedAt: res.createdAt, UpdatedAt: res.updatedAt, PublisherScoped: res.scoped}, encodeError(res.err)
+}
+
+func encodeIdentityBatchResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(identityBatchRes)
+	return &mainflux.ThingIDs{Values: res.ids}, encodeError(res.err)
 }
 
 func encodeEmptyResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
@@ -108,6 +220,14 @@ func encodeError(err error) error {
 		return status.Error(codes.InvalidArgument, "received invalid can access request")
 	case things.ErrUnauthorizedAccess:
 		return status.Error(codes.PermissionDenied, "missing or invalid credentials provided")
+	case things.ErrKeyExpired:
+		return status.Error(codes.Unauthenticated, "thing key expired")
+	case things.ErrOwnerDisabled:
+		return status.Error(codes.PermissionDenied, "thing owner is disabled")
+	case things.ErrNotActivated:
+		return status.Error(codes.PermissionDenied, "thing is not activated")
+	case things.ErrNotFound:
+		return status.Error(codes.NotFound, "entity not found")
 	default:
 		return status.Error(codes.Internal, "internal server error")
 	}
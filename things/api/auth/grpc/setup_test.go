@@ -53,5 +53,7 @@ func newService(tokens map[string]string) things.Service {
 	thingCache := mocks.NewThingCache()
 	idp := mocks.NewIdentityProvider()
 
-	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
+	links := mocks.NewShareLinkRepository()
+
+	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, things.WithShareLinks(links, ""))
 }
@@ -34,6 +34,51 @@ func identifyEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func identifyFullEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(identifyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		thing, err := svc.IdentifyFull(ctx, req.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		res := thingRes{
+			ID:       thing.ID,
+			Name:     thing.Name,
+			Metadata: thing.Metadata,
+		}
+		if !thing.KeyExpiry.IsZero() {
+			res.KeyExpiry = &thing.KeyExpiry
+		}
+
+		return res, nil
+	}
+}
+
+func identifyBatchEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(identifyBatchReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ids, err := svc.IdentifyBatch(ctx, req.Tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		res := identityBatchRes{
+			IDs: ids,
+		}
+
+		return res, nil
+	}
+}
+
 func canAccessEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(canAccessReq)
@@ -69,3 +114,48 @@ func canAccessByIDEndpoint(svc things.Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+func disableOwnerEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ownerReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.DisableOwner(ctx, req.owner); err != nil {
+			return nil, err
+		}
+
+		return ownerRes{}, nil
+	}
+}
+
+func enableOwnerEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ownerReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.EnableOwner(ctx, req.owner); err != nil {
+			return nil, err
+		}
+
+		return ownerRes{}, nil
+	}
+}
+
+func changeOwnerEmailEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeOwnerEmailReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.ChangeOwnerEmail(ctx, req.owner, req.Email); err != nil {
+			return nil, err
+		}
+
+		return ownerRes{}, nil
+	}
+}
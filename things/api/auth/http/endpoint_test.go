@@ -30,6 +30,7 @@ const (
 	contentType = "application/json"
 	email       = "user@example.com"
 	token       = "token"
+	adminToken  = "admin-token"
 	wrong       = "wrong_value"
 	wrongID     = "0"
 )
@@ -50,6 +51,7 @@ type testRequest struct {
 	method      string
 	url         string
 	contentType string
+	token       string
 	body        io.Reader
 }
 
@@ -61,6 +63,9 @@ func (tr testRequest) make() (*http.Response, error) {
 	if tr.contentType != "" {
 		req.Header.Set("Content-Type", tr.contentType)
 	}
+	if tr.token != "" {
+		req.Header.Set("Authorization", tr.token)
+	}
 	return tr.client.Do(req)
 }
 
@@ -69,7 +74,7 @@ func toJSON(data interface{}) string {
 	return string(jsonData)
 }
 
-func newService(tokens map[string]string) things.Service {
+func newService(tokens map[string]string, opts ...things.Option) things.Service {
 	users := mocks.NewUsersService(tokens)
 	conns := make(chan mocks.Connection)
 	thingsRepo := mocks.NewThingRepository(conns)
@@ -78,11 +83,11 @@ func newService(tokens map[string]string) things.Service {
 	thingCache := mocks.NewThingCache()
 	idp := mocks.NewIdentityProvider()
 
-	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
+	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, opts...)
 }
 
 func newServer(svc things.Service) *httptest.Server {
-	mux := httpapi.MakeHandler(mocktracer.New(), svc)
+	mux := httpapi.MakeHandler(mocktracer.New(), svc, adminToken)
 	return httptest.NewServer(mux)
 }
 
@@ -145,6 +150,145 @@ func TestIdentify(t *testing.T) {
 	}
 }
 
+func TestIdentifyFull(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("failed to create thing: %s", err))
+
+	data := toJSON(identifyReq{Token: sth.Key})
+	nonexistentData := toJSON(identifyReq{Token: wrong})
+
+	cases := map[string]struct {
+		contentType string
+		req         string
+		status      int
+		res         map[string]interface{}
+	}{
+		"identify existing thing": {
+			contentType: contentType,
+			req:         data,
+			status:      http.StatusOK,
+			res: map[string]interface{}{
+				"id":       sth.ID,
+				"name":     sth.Name,
+				"metadata": sth.Metadata,
+			},
+		},
+		"identify non-existent thing": {
+			contentType: contentType,
+			req:         nonexistentData,
+			status:      http.StatusForbidden,
+		},
+		"identify with missing content type": {
+			contentType: wrong,
+			req:         data,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		"identify with empty JSON request": {
+			contentType: contentType,
+			req:         "{}",
+			status:      http.StatusForbidden,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/identify/full", ts.URL),
+			contentType: tc.contentType,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		if tc.res == nil {
+			continue
+		}
+
+		var body map[string]interface{}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.res, body, fmt.Sprintf("%s: expected body %v got %v", desc, tc.res, body))
+	}
+}
+
+func TestIdentifyBatch(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	sth1, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("failed to create thing: %s", err))
+	sth2, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("failed to create thing: %s", err))
+
+	cases := map[string]struct {
+		contentType string
+		req         string
+		status      int
+		res         map[string]string
+	}{
+		"identify existing things": {
+			contentType: contentType,
+			req:         toJSON(identifyBatchReq{Tokens: []string{sth1.Key, sth2.Key}}),
+			status:      http.StatusOK,
+			res:         map[string]string{sth1.Key: sth1.ID, sth2.Key: sth2.ID},
+		},
+		"identify existing and non-existent things": {
+			contentType: contentType,
+			req:         toJSON(identifyBatchReq{Tokens: []string{sth1.Key, wrong}}),
+			status:      http.StatusOK,
+			res:         map[string]string{sth1.Key: sth1.ID},
+		},
+		"identify non-existent things": {
+			contentType: contentType,
+			req:         toJSON(identifyBatchReq{Tokens: []string{wrong}}),
+			status:      http.StatusOK,
+			res:         map[string]string{},
+		},
+		"identify with missing content type": {
+			contentType: wrong,
+			req:         toJSON(identifyBatchReq{Tokens: []string{sth1.Key}}),
+			status:      http.StatusUnsupportedMediaType,
+		},
+		"identify with empty JSON request": {
+			contentType: contentType,
+			req:         "{}",
+			status:      http.StatusBadRequest,
+		},
+		"identify with invalid JSON request": {
+			contentType: contentType,
+			req:         "}",
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/identify/batch", ts.URL),
+			contentType: tc.contentType,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		if tc.res == nil {
+			continue
+		}
+
+		var body identityBatchRes
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.res, body.IDs, fmt.Sprintf("%s: expected ids %v got %v", desc, tc.res, body.IDs))
+	}
+}
+
 func TestCanAccess(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
@@ -156,7 +300,7 @@ func TestCanAccess(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, channel)
 	require.Nil(t, err, fmt.Sprintf("failed to create channel: %s", err))
 
-	err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	require.Nil(t, err, fmt.Sprintf("failed to connect thing and channel: %s", err))
 
 	car := canAccessReq{
@@ -233,7 +377,7 @@ func TestCanAccessByID(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, channel)
 	require.Nil(t, err, fmt.Sprintf("failed to create channel: %s", err))
 
-	err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	require.Nil(t, err, fmt.Sprintf("failed to connect thing and channel: %s", err))
 
 	car := canAccessByIDReq{
@@ -303,6 +447,14 @@ type identifyReq struct {
 	Token string `json:"token"`
 }
 
+type identifyBatchReq struct {
+	Tokens []string `json:"tokens"`
+}
+
+type identityBatchRes struct {
+	IDs map[string]string `json:"ids"`
+}
+
 type canAccessReq struct {
 	Token string `json:"token"`
 }
@@ -310,3 +462,225 @@ type canAccessReq struct {
 type canAccessByIDReq struct {
 	ThingID string `json:"thing_id"`
 }
+
+func TestDisableOwner(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithOwners(mocks.NewOwnerRepository()))
+	ts := newServer(svc)
+	defer ts.Close()
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("failed to create thing: %s", err))
+
+	data := toJSON(identifyReq{Token: sth.Key})
+
+	cases := []struct {
+		desc     string
+		method   string
+		owner    string
+		auth     string
+		status   int
+		identify int
+	}{
+		{
+			desc:     "disable owner without admin token",
+			method:   http.MethodPut,
+			owner:    email,
+			auth:     "",
+			status:   http.StatusForbidden,
+			identify: http.StatusOK,
+		},
+		{
+			desc:     "disable owner with invalid admin token",
+			method:   http.MethodPut,
+			owner:    email,
+			auth:     wrong,
+			status:   http.StatusForbidden,
+			identify: http.StatusOK,
+		},
+		{
+			desc:     "disable owner",
+			method:   http.MethodPut,
+			owner:    email,
+			auth:     adminToken,
+			status:   http.StatusNoContent,
+			identify: http.StatusForbidden,
+		},
+		{
+			desc:     "disable disabled owner",
+			method:   http.MethodPut,
+			owner:    email,
+			auth:     adminToken,
+			status:   http.StatusNoContent,
+			identify: http.StatusForbidden,
+		},
+		{
+			desc:     "enable owner with invalid admin token",
+			method:   http.MethodDelete,
+			owner:    email,
+			auth:     wrong,
+			status:   http.StatusForbidden,
+			identify: http.StatusForbidden,
+		},
+		{
+			desc:     "enable owner",
+			method:   http.MethodDelete,
+			owner:    email,
+			auth:     adminToken,
+			status:   http.StatusNoContent,
+			identify: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: tc.method,
+			url:    fmt.Sprintf("%s/owners/%s/disabled", ts.URL, tc.owner),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		req = testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/identify", ts.URL),
+			contentType: contentType,
+			body:        strings.NewReader(data),
+		}
+		res, err = req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.identify, res.StatusCode, fmt.Sprintf("%s: expected identify status code %d got %d", tc.desc, tc.identify, res.StatusCode))
+	}
+}
+
+func TestChangeOwnerEmail(t *testing.T) {
+	newEmail := "changed@example.com"
+	newToken := "new-token"
+	svc := newService(map[string]string{token: email, newToken: newEmail}, things.WithOwners(mocks.NewOwnerRepository()))
+	ts := newServer(svc)
+	defer ts.Close()
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("failed to create thing: %s", err))
+
+	cases := []struct {
+		desc        string
+		owner       string
+		auth        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{
+			desc:        "change email without admin token",
+			owner:       email,
+			auth:        "",
+			contentType: contentType,
+			body:        toJSON(map[string]string{"email": newEmail}),
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "change email with invalid admin token",
+			owner:       email,
+			auth:        wrong,
+			contentType: contentType,
+			body:        toJSON(map[string]string{"email": newEmail}),
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "change email with invalid content type",
+			owner:       email,
+			auth:        adminToken,
+			contentType: "text/plain",
+			body:        toJSON(map[string]string{"email": newEmail}),
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "change email with malformed body",
+			owner:       email,
+			auth:        adminToken,
+			contentType: contentType,
+			body:        "{",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "change email to empty email",
+			owner:       email,
+			auth:        adminToken,
+			contentType: contentType,
+			body:        toJSON(map[string]string{"email": ""}),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "change email of unknown owner",
+			owner:       "unknown@example.com",
+			auth:        adminToken,
+			contentType: contentType,
+			body:        toJSON(map[string]string{"email": newEmail}),
+			status:      http.StatusNotFound,
+		},
+		{
+			desc:        "change email",
+			owner:       email,
+			auth:        adminToken,
+			contentType: contentType,
+			body:        toJSON(map[string]string{"email": newEmail}),
+			status:      http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPut,
+			url:         fmt.Sprintf("%s/owners/%s/email", ts.URL, tc.owner),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+
+	_, err = svc.ViewThing(context.Background(), newToken, sth.ID)
+	assert.Nil(t, err, fmt.Sprintf("view thing after email change: unexpected error: %s", err))
+}
+
+func TestDisableOwnerUnsupported(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req := testRequest{
+			client: ts.Client(),
+			method: method,
+			url:    fmt.Sprintf("%s/owners/%s/disabled", ts.URL, email),
+			token:  adminToken,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", method, err))
+		assert.Equal(t, http.StatusNotImplemented, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", method, http.StatusNotImplemented, res.StatusCode))
+	}
+}
+
+func TestOwnerManagementWithoutAdminToken(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithOwners(mocks.NewOwnerRepository()))
+	ts := httptest.NewServer(httpapi.MakeHandler(mocktracer.New(), svc, ""))
+	defer ts.Close()
+
+	for _, auth := range []string{"", adminToken} {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodPut,
+			url:    fmt.Sprintf("%s/owners/%s/disabled", ts.URL, email),
+			token:  auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%q: unexpected error %s", auth, err))
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, fmt.Sprintf("%q: expected status code %d got %d", auth, http.StatusForbidden, res.StatusCode))
+	}
+}
@@ -9,6 +9,10 @@ package http
 
 import "github.com/mainflux/mainflux/things"
 
+// maxBatchSize is the maximum number of keys resolved by a single batch
+// identify request.
+const maxBatchSize = 1000
+
 var _ apiReq = (*identifyReq)(nil)
 
 type apiReq interface {
@@ -27,6 +31,18 @@ func (req identifyReq) validate() error {
 	return nil
 }
 
+type identifyBatchReq struct {
+	Tokens []string `json:"tokens"`
+}
+
+func (req identifyBatchReq) validate() error {
+	if len(req.Tokens) == 0 || len(req.Tokens) > maxBatchSize {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
 type canAccessReq struct {
 	chanID string
 	Token  string `json:"token"`
@@ -52,3 +68,28 @@ func (req canAccessByIDReq) validate() error {
 
 	return nil
 }
+
+type ownerReq struct {
+	owner string
+}
+
+func (req ownerReq) validate() error {
+	if req.owner == "" {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type changeOwnerEmailReq struct {
+	owner string
+	Email string `json:"email"`
+}
+
+func (req changeOwnerEmailReq) validate() error {
+	if req.owner == "" || req.Email == "" {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
@@ -7,7 +7,10 @@
 
 package http
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type identityRes struct {
 	ID string `json:"id"`
@@ -25,6 +28,41 @@ func (res identityRes) Empty() bool {
 	return false
 }
 
+type identityBatchRes struct {
+	IDs map[string]string `json:"ids"`
+}
+
+func (res identityBatchRes) Code() int {
+	return http.StatusOK
+}
+
+func (res identityBatchRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res identityBatchRes) Empty() bool {
+	return false
+}
+
+type thingRes struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	KeyExpiry *time.Time             `json:"key_expiry,omitempty"`
+}
+
+func (res thingRes) Code() int {
+	return http.StatusOK
+}
+
+func (res thingRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res thingRes) Empty() bool {
+	return false
+}
+
 type canAccessByIDRes struct{}
 
 func (res canAccessByIDRes) Code() int {
@@ -38,3 +76,17 @@ func (res canAccessByIDRes) Headers() map[string]string {
 func (res canAccessByIDRes) Empty() bool {
 	return true
 }
+
+type ownerRes struct{}
+
+func (res ownerRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res ownerRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res ownerRes) Empty() bool {
+	return true
+}
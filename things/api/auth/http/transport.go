@@ -9,6 +9,7 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"io"
@@ -27,8 +28,10 @@ const contentType = "application/json"
 
 var errUnsupportedContentType = errors.New("unsupported content type")
 
-// MakeHandler returns a HTTP handler for auth API endpoints.
-func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
+// MakeHandler returns a HTTP handler for auth API endpoints. Owner management
+// endpoints require the admin token in the Authorization header, and are
+// disabled if the admin token is empty.
+func MakeHandler(tracer opentracing.Tracer, svc things.Service, adminToken string) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(encodeError),
 	}
@@ -42,6 +45,20 @@ func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
 		opts...,
 	))
 
+	r.Post("/identify/full", kithttp.NewServer(
+		kitot.TraceServer(tracer, "identify_full")(identifyFullEndpoint(svc)),
+		decodeIdentify,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Post("/identify/batch", kithttp.NewServer(
+		kitot.TraceServer(tracer, "identify_batch")(identifyBatchEndpoint(svc)),
+		decodeIdentifyBatch,
+		encodeResponse,
+		opts...,
+	))
+
 	r.Post("/channels/:chanId/access", kithttp.NewServer(
 		kitot.TraceServer(tracer, "can_access")(canAccessEndpoint(svc)),
 		decodeCanAccess,
@@ -56,6 +73,27 @@ func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
 		opts...,
 	))
 
+	r.Put("/owners/:owner/disabled", kithttp.NewServer(
+		kitot.TraceServer(tracer, "disable_owner")(disableOwnerEndpoint(svc)),
+		decodeOwner(adminToken),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Delete("/owners/:owner/disabled", kithttp.NewServer(
+		kitot.TraceServer(tracer, "enable_owner")(enableOwnerEndpoint(svc)),
+		decodeOwner(adminToken),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Put("/owners/:owner/email", kithttp.NewServer(
+		kitot.TraceServer(tracer, "change_owner_email")(changeOwnerEmailEndpoint(svc)),
+		decodeChangeOwnerEmail(adminToken),
+		encodeResponse,
+		opts...,
+	))
+
 	return r
 }
 
@@ -72,6 +110,19 @@ func decodeIdentify(_ context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+func decodeIdentifyBatch(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := identifyBatchReq{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 func decodeCanAccess(_ context.Context, r *http.Request) (interface{}, error) {
 	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
 		return nil, errUnsupportedContentType
@@ -102,6 +153,52 @@ func decodeCanAccessByID(_ context.Context, r *http.Request) (interface{}, error
 	return req, nil
 }
 
+func decodeOwner(adminToken string) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if err := authorizeAdmin(r, adminToken); err != nil {
+			return nil, err
+		}
+
+		req := ownerReq{
+			owner: bone.GetValue(r, "owner"),
+		}
+
+		return req, nil
+	}
+}
+
+func decodeChangeOwnerEmail(adminToken string) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if err := authorizeAdmin(r, adminToken); err != nil {
+			return nil, err
+		}
+
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := changeOwnerEmailReq{
+			owner: bone.GetValue(r, "owner"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+// authorizeAdmin compares the Authorization header to the admin token in
+// constant time. Empty admin token rejects all requests.
+func authorizeAdmin(r *http.Request, adminToken string) error {
+	token := r.Header.Get("Authorization")
+	if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		return things.ErrUnauthorizedAccess
+	}
+
+	return nil
+}
+
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	w.Header().Set("Content-Type", contentType)
 
@@ -126,6 +223,20 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	switch err {
 	case things.ErrUnauthorizedAccess:
 		w.WriteHeader(http.StatusForbidden)
+	case things.ErrKeyExpired:
+		w.WriteHeader(http.StatusUnauthorized)
+	case things.ErrOwnerDisabled:
+		w.WriteHeader(http.StatusForbidden)
+	case things.ErrNotActivated:
+		w.WriteHeader(http.StatusForbidden)
+	case things.ErrOwnerManagementDisabled:
+		w.WriteHeader(http.StatusNotImplemented)
+	case things.ErrMalformedEntity:
+		w.WriteHeader(http.StatusBadRequest)
+	case things.ErrNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case things.ErrConflict:
+		w.WriteHeader(http.StatusConflict)
 	case errUnsupportedContentType:
 		w.WriteHeader(http.StatusUnsupportedMediaType)
 	case io.ErrUnexpectedEOF:
@@ -12,6 +12,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	log "github.com/mainflux/mainflux/logger"
@@ -43,6 +44,32 @@ func (lm *loggingMiddleware) AddThing(ctx context.Context, token string, thing t
 	return lm.svc.AddThing(ctx, token, thing)
 }
 
+func (lm *loggingMiddleware) AddThings(ctx context.Context, token string, ths []things.Thing, stopOnError bool) (results []things.BulkResult, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method add_things for token %s and %d things took %s to complete", token, len(ths), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.AddThings(ctx, token, ths, stopOnError)
+}
+
+func (lm *loggingMiddleware) ProvisionThing(ctx context.Context, token string, thing things.Thing) (prov things.Provision, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method provision_thing for token %s and thing %s took %s to complete", token, prov.Thing.ID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ProvisionThing(ctx, token, thing)
+}
+
 func (lm *loggingMiddleware) UpdateThing(ctx context.Context, token string, thing things.Thing) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method update_thing for token %s and thing %s took %s to complete", token, thing.ID, time.Since(begin))
@@ -56,6 +83,19 @@ func (lm *loggingMiddleware) UpdateThing(ctx context.Context, token string, thin
 	return lm.svc.UpdateThing(ctx, token, thing)
 }
 
+func (lm *loggingMiddleware) PatchThingMetadata(ctx context.Context, token, id string, patch map[string]interface{}) (metadata map[string]interface{}, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method patch_thing_metadata for token %s and thing %s took %s to complete", token, id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.PatchThingMetadata(ctx, token, id, patch)
+}
+
 func (lm *loggingMiddleware) UpdateKey(ctx context.Context, token, id, key string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method update_key for thing %s and key %s took %s to complete", id, key, time.Since(begin))
@@ -82,7 +122,7 @@ func (lm *loggingMiddleware) ViewThing(ctx context.Context, token, id string) (t
 	return lm.svc.ViewThing(ctx, token, id)
 }
 
-func (lm *loggingMiddleware) ListThings(ctx context.Context, token string, offset, limit uint64, name string) (_ things.ThingsPage, err error) {
+func (lm *loggingMiddleware) ListThings(ctx context.Context, token string, offset, limit uint64, name string, compact bool) (_ things.ThingsPage, err error) {
 	defer func(begin time.Time) {
 		nlog := ""
 		if name != "" {
@@ -96,7 +136,20 @@ func (lm *loggingMiddleware) ListThings(ctx context.Context, token string, offse
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.ListThings(ctx, token, offset, limit, name)
+	return lm.svc.ListThings(ctx, token, offset, limit, name, compact)
+}
+
+func (lm *loggingMiddleware) NameAvailable(ctx context.Context, token, name string) (available bool, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method name_available for name %s took %s to complete", name, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.NameAvailable(ctx, token, name)
 }
 
 func (lm *loggingMiddleware) ListThingsByChannel(ctx context.Context, token, id string, offset, limit uint64) (_ things.ThingsPage, err error) {
@@ -111,6 +164,32 @@ func (lm *loggingMiddleware) ListThingsByChannel(ctx context.Context, token, id
 	return lm.svc.ListThingsByChannel(ctx, token, id, offset, limit)
 }
 
+func (lm *loggingMiddleware) ListThingsByChannelAfter(ctx context.Context, token, id, after string, limit uint64) (_ []things.Thing, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_things_by_channel_after for channel %s took %s to complete", id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ListThingsByChannelAfter(ctx, token, id, after, limit)
+}
+
+func (lm *loggingMiddleware) ListThingsByChannels(ctx context.Context, token string, ids []string, offset, limit uint64) (_ things.ThingsPage, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_things_by_channels for channels %s took %s to complete", strings.Join(ids, ", "), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ListThingsByChannels(ctx, token, ids, offset, limit)
+}
+
 func (lm *loggingMiddleware) RemoveThing(ctx context.Context, token, id string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method remove_thing for token %s and thing %s took %s to complete", token, id, time.Since(begin))
@@ -163,12 +242,15 @@ func (lm *loggingMiddleware) ViewChannel(ctx context.Context, token, id string)
 	return lm.svc.ViewChannel(ctx, token, id)
 }
 
-func (lm *loggingMiddleware) ListChannels(ctx context.Context, token string, offset, limit uint64, name string) (_ things.ChannelsPage, err error) {
+func (lm *loggingMiddleware) ListChannels(ctx context.Context, token string, offset, limit uint64, name, parent string, compact bool) (_ things.ChannelsPage, err error) {
 	defer func(begin time.Time) {
 		nlog := ""
 		if name != "" {
 			nlog = fmt.Sprintf("with name %s ", name)
 		}
+		if parent != "" {
+			nlog = fmt.Sprintf("%sunder parent %s ", nlog, parent)
+		}
 		message := fmt.Sprintf("Method list_channels %sfor token %s took %s to complete", nlog, token, time.Since(begin))
 		if err != nil {
 			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
@@ -177,7 +259,7 @@ func (lm *loggingMiddleware) ListChannels(ctx context.Context, token string, off
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.ListChannels(ctx, token, offset, limit, name)
+	return lm.svc.ListChannels(ctx, token, offset, limit, name, parent, compact)
 }
 
 func (lm *loggingMiddleware) ListChannelsByThing(ctx context.Context, token, id string, offset, limit uint64) (_ things.ChannelsPage, err error) {
@@ -192,9 +274,87 @@ func (lm *loggingMiddleware) ListChannelsByThing(ctx context.Context, token, id
 	return lm.svc.ListChannelsByThing(ctx, token, id, offset, limit)
 }
 
-func (lm *loggingMiddleware) RemoveChannel(ctx context.Context, token, id string) (err error) {
+func (lm *loggingMiddleware) RemoveChannel(ctx context.Context, token, id string, force bool) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method remove_channel for token %s and channel %s (force %t) took %s to complete", token, id, force, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RemoveChannel(ctx, token, id, force)
+}
+
+func (lm *loggingMiddleware) IssueChannelToken(ctx context.Context, token, chanID string, ttl time.Duration, scope string) (_ string, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method issue_channel_token for channel %s and scope %s took %s to complete", chanID, scope, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.IssueChannelToken(ctx, token, chanID, ttl, scope)
+}
+
+func (lm *loggingMiddleware) RevokeChannelTokens(ctx context.Context, token, chanID string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method revoke_channel_tokens for channel %s took %s to complete", chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RevokeChannelTokens(ctx, token, chanID)
+}
+
+func (lm *loggingMiddleware) CreateShareLink(ctx context.Context, token, chanID string, ttl time.Duration) (_ things.ShareLink, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method create_share_link for channel %s took %s to complete", chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.CreateShareLink(ctx, token, chanID, ttl)
+}
+
+func (lm *loggingMiddleware) RevokeShareLink(ctx context.Context, token, chanID, linkToken string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method revoke_share_link for channel %s took %s to complete", chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RevokeShareLink(ctx, token, chanID, linkToken)
+}
+
+func (lm *loggingMiddleware) CanReadShared(ctx context.Context, linkToken, chanID string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method can_read_shared for channel %s took %s to complete", chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.CanReadShared(ctx, linkToken, chanID)
+}
+
+func (lm *loggingMiddleware) IssueAPIKey(ctx context.Context, token string, scopes []string, ttl time.Duration) (key things.APIKey, err error) {
 	defer func(begin time.Time) {
-		message := fmt.Sprintf("Method remove_channel for token %s and channel %s took %s to complete", token, id, time.Since(begin))
+		message := fmt.Sprintf("Method issue_api_key with scopes %v and ttl %s took %s to complete", scopes, ttl, time.Since(begin))
 		if err != nil {
 			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
@@ -202,12 +362,12 @@ func (lm *loggingMiddleware) RemoveChannel(ctx context.Context, token, id string
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.RemoveChannel(ctx, token, id)
+	return lm.svc.IssueAPIKey(ctx, token, scopes, ttl)
 }
 
-func (lm *loggingMiddleware) Connect(ctx context.Context, token, chanID, thingID string) (err error) {
+func (lm *loggingMiddleware) ListAPIKeys(ctx context.Context, token string) (keys []things.APIKey, err error) {
 	defer func(begin time.Time) {
-		message := fmt.Sprintf("Method connect for token %s, channel %s and thing %s took %s to complete", token, chanID, thingID, time.Since(begin))
+		message := fmt.Sprintf("Method list_api_keys took %s to complete", time.Since(begin))
 		if err != nil {
 			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
 			return
@@ -215,7 +375,33 @@ func (lm *loggingMiddleware) Connect(ctx context.Context, token, chanID, thingID
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.Connect(ctx, token, chanID, thingID)
+	return lm.svc.ListAPIKeys(ctx, token)
+}
+
+func (lm *loggingMiddleware) RevokeAPIKey(ctx context.Context, token, id string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method revoke_api_key for key %s took %s to complete", id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RevokeAPIKey(ctx, token, id)
+}
+
+func (lm *loggingMiddleware) Connect(ctx context.Context, token, chanID, thingID string, ttl time.Duration) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method connect for token %s, channel %s and thing %s with ttl %s took %s to complete", token, chanID, thingID, ttl, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Connect(ctx, token, chanID, thingID, ttl)
 }
 
 func (lm *loggingMiddleware) Disconnect(ctx context.Context, token, chanID, thingID string) (err error) {
@@ -231,6 +417,19 @@ func (lm *loggingMiddleware) Disconnect(ctx context.Context, token, chanID, thin
 	return lm.svc.Disconnect(ctx, token, chanID, thingID)
 }
 
+func (lm *loggingMiddleware) ReapIdle(ctx context.Context, token, chanID string, olderThan time.Duration) (ids []string, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method reap_idle for token %s and channel %s disconnected %d things idle for %s and took %s to complete", token, chanID, len(ids), olderThan, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ReapIdle(ctx, token, chanID, olderThan)
+}
+
 func (lm *loggingMiddleware) CanAccess(ctx context.Context, id, key string) (thing string, err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method can_access for channel %s and thing %s took %s to complete", id, thing, time.Since(begin))
@@ -244,6 +443,19 @@ func (lm *loggingMiddleware) CanAccess(ctx context.Context, id, key string) (thi
 	return lm.svc.CanAccess(ctx, id, key)
 }
 
+func (lm *loggingMiddleware) CanReadMessages(ctx context.Context, id, key string) (thing string, scoped bool, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method can_read_messages for channel %s and thing %s took %s to complete", id, thing, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.CanReadMessages(ctx, id, key)
+}
+
 func (lm *loggingMiddleware) CanAccessByID(ctx context.Context, chanID, thingID string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method can_access_by_id for channel %s and thing %s took %s to complete", chanID, thingID, time.Since(begin))
@@ -268,3 +480,107 @@ func (lm *loggingMiddleware) Identify(ctx context.Context, key string) (id strin
 
 	return lm.svc.Identify(ctx, key)
 }
+
+func (lm *loggingMiddleware) IdentifyFull(ctx context.Context, key string) (thing things.Thing, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method identify_full for key %s and thing %s took %s to complete", key, thing.ID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.IdentifyFull(ctx, key)
+}
+
+func (lm *loggingMiddleware) IdentifyBatch(ctx context.Context, keys []string) (ids map[string]string, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method identify_batch for %d keys resolving %d things took %s to complete", len(keys), len(ids), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.IdentifyBatch(ctx, keys)
+}
+
+func (lm *loggingMiddleware) RotateExpiredKeys(ctx context.Context) (ids []string, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method rotate_expired_keys rotating %d keys took %s to complete", len(ids), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RotateExpiredKeys(ctx)
+}
+
+func (lm *loggingMiddleware) DisconnectExpired(ctx context.Context) (conns []things.Connection, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method disconnect_expired removing %d connections took %s to complete", len(conns), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.DisconnectExpired(ctx)
+}
+
+func (lm *loggingMiddleware) DisableOwner(ctx context.Context, owner string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method disable_owner for owner %s took %s to complete", owner, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.DisableOwner(ctx, owner)
+}
+
+func (lm *loggingMiddleware) EnableOwner(ctx context.Context, owner string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method enable_owner for owner %s took %s to complete", owner, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.EnableOwner(ctx, owner)
+}
+
+func (lm *loggingMiddleware) ChangeOwnerEmail(ctx context.Context, email, newEmail string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method change_owner_email for owner %s to %s took %s to complete", email, newEmail, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ChangeOwnerEmail(ctx, email, newEmail)
+}
+
+func (lm *loggingMiddleware) Subscribe(ctx context.Context, token string) (_ <-chan things.Event, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method subscribe took %s to complete", time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Subscribe(ctx, token)
+}
@@ -44,6 +44,24 @@ func (ms *metricsMiddleware) AddThing(ctx context.Context, token string, thing t
 	return ms.svc.AddThing(ctx, token, thing)
 }
 
+func (ms *metricsMiddleware) AddThings(ctx context.Context, token string, ths []things.Thing, stopOnError bool) ([]things.BulkResult, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "add_things").Add(1)
+		ms.latency.With("method", "add_things").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.AddThings(ctx, token, ths, stopOnError)
+}
+
+func (ms *metricsMiddleware) ProvisionThing(ctx context.Context, token string, thing things.Thing) (things.Provision, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "provision_thing").Add(1)
+		ms.latency.With("method", "provision_thing").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ProvisionThing(ctx, token, thing)
+}
+
 func (ms *metricsMiddleware) UpdateThing(ctx context.Context, token string, thing things.Thing) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "update_thing").Add(1)
@@ -53,6 +71,15 @@ func (ms *metricsMiddleware) UpdateThing(ctx context.Context, token string, thin
 	return ms.svc.UpdateThing(ctx, token, thing)
 }
 
+func (ms *metricsMiddleware) PatchThingMetadata(ctx context.Context, token, id string, patch map[string]interface{}) (map[string]interface{}, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "patch_thing_metadata").Add(1)
+		ms.latency.With("method", "patch_thing_metadata").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.PatchThingMetadata(ctx, token, id, patch)
+}
+
 func (ms *metricsMiddleware) UpdateKey(ctx context.Context, token, id, key string) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "update_key").Add(1)
@@ -71,13 +98,22 @@ func (ms *metricsMiddleware) ViewThing(ctx context.Context, token, id string) (t
 	return ms.svc.ViewThing(ctx, token, id)
 }
 
-func (ms *metricsMiddleware) ListThings(ctx context.Context, token string, offset, limit uint64, name string) (things.ThingsPage, error) {
+func (ms *metricsMiddleware) ListThings(ctx context.Context, token string, offset, limit uint64, name string, compact bool) (things.ThingsPage, error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "list_things").Add(1)
 		ms.latency.With("method", "list_things").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.ListThings(ctx, token, offset, limit, name)
+	return ms.svc.ListThings(ctx, token, offset, limit, name, compact)
+}
+
+func (ms *metricsMiddleware) NameAvailable(ctx context.Context, token, name string) (bool, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "name_available").Add(1)
+		ms.latency.With("method", "name_available").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.NameAvailable(ctx, token, name)
 }
 
 func (ms *metricsMiddleware) ListThingsByChannel(ctx context.Context, token, id string, offset, limit uint64) (things.ThingsPage, error) {
@@ -89,6 +125,24 @@ func (ms *metricsMiddleware) ListThingsByChannel(ctx context.Context, token, id
 	return ms.svc.ListThingsByChannel(ctx, token, id, offset, limit)
 }
 
+func (ms *metricsMiddleware) ListThingsByChannelAfter(ctx context.Context, token, id, after string, limit uint64) ([]things.Thing, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_things_by_channel_after").Add(1)
+		ms.latency.With("method", "list_things_by_channel_after").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListThingsByChannelAfter(ctx, token, id, after, limit)
+}
+
+func (ms *metricsMiddleware) ListThingsByChannels(ctx context.Context, token string, ids []string, offset, limit uint64) (things.ThingsPage, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_things_by_channels").Add(1)
+		ms.latency.With("method", "list_things_by_channels").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListThingsByChannels(ctx, token, ids, offset, limit)
+}
+
 func (ms *metricsMiddleware) RemoveThing(ctx context.Context, token, id string) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "remove_thing").Add(1)
@@ -104,116 +158,151 @@ func (ms *metricsMiddleware) CreateChannel(ctx context.Context, token string, ch
 		ms.latency.With("method", "create_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.CreateChannel(token, channel)
+	return ms.svc.CreateChannel(ctx, token, channel)
 }
 
-func (ms *metricsMiddleware) UpdateChannel(token string, channel things.Channel) error {
-=======
-	return ms.svc.CreateChannel(ctx, token, channel)
+func (ms *metricsMiddleware) UpdateChannel(ctx context.Context, token string, channel things.Channel) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "update_channel").Add(1)
 		ms.latency.With("method", "update_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-}
-
-func (ms *metricsMiddleware) ViewChannel(token, id string) (things.Channel, error) {
-=======
 	return ms.svc.UpdateChannel(ctx, token, channel)
 }
 
 func (ms *metricsMiddleware) ViewChannel(ctx context.Context, token, id string) (things.Channel, error) {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
 	defer func(begin time.Time) {
 		ms.counter.With("method", "view_channel").Add(1)
 		ms.latency.With("method", "view_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.ViewChannel(token, id)
-}
-
-func (ms *metricsMiddleware) ListChannels(token string, offset, limit uint64, name string) (things.ChannelsPage, error) {
-=======
 	return ms.svc.ViewChannel(ctx, token, id)
 }
 
-func (ms *metricsMiddleware) ListChannels(ctx context.Context, token string, offset, limit uint64, name string) (things.ChannelsPage, error) {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
+func (ms *metricsMiddleware) ListChannels(ctx context.Context, token string, offset, limit uint64, name, parent string, compact bool) (things.ChannelsPage, error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "list_channels").Add(1)
 		ms.latency.With("method", "list_channels").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.ListChannels(token, offset, limit, name)
-}
-
-func (ms *metricsMiddleware) ListChannelsByThing(token, id string, offset, limit uint64) (things.ChannelsPage, error) {
-=======
-	return ms.svc.ListChannels(ctx, token, offset, limit, name)
+	return ms.svc.ListChannels(ctx, token, offset, limit, name, parent, compact)
 }
 
 func (ms *metricsMiddleware) ListChannelsByThing(ctx context.Context, token, id string, offset, limit uint64) (things.ChannelsPage, error) {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
 	defer func(begin time.Time) {
 		ms.counter.With("method", "list_channels_by_thing").Add(1)
 		ms.latency.With("method", "list_channels_by_thing").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.ListChannelsByThing(token, id, offset, limit)
-}
-
-func (ms *metricsMiddleware) RemoveChannel(token, id string) error {
-=======
 	return ms.svc.ListChannelsByThing(ctx, token, id, offset, limit)
 }
 
-func (ms *metricsMiddleware) RemoveChannel(ctx context.Context, token, id string) error {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
+func (ms *metricsMiddleware) RemoveChannel(ctx context.Context, token, id string, force bool) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "remove_channel").Add(1)
 		ms.latency.With("method", "remove_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.RemoveChannel(token, id)
+	return ms.svc.RemoveChannel(ctx, token, id, force)
 }
 
-func (ms *metricsMiddleware) Connect(token, chanID, thingID string) error {
-=======
-	return ms.svc.RemoveChannel(ctx, token, id)
+func (ms *metricsMiddleware) IssueChannelToken(ctx context.Context, token, chanID string, ttl time.Duration, scope string) (string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "issue_channel_token").Add(1)
+		ms.latency.With("method", "issue_channel_token").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.IssueChannelToken(ctx, token, chanID, ttl, scope)
 }
 
-func (ms *metricsMiddleware) Connect(ctx context.Context, token, chanID, thingID string) error {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
+func (ms *metricsMiddleware) RevokeChannelTokens(ctx context.Context, token, chanID string) error {
 	defer func(begin time.Time) {
-		ms.counter.With("method", "connect").Add(1)
-		ms.latency.With("method", "connect").Observe(time.Since(begin).Seconds())
+		ms.counter.With("method", "revoke_channel_tokens").Add(1)
+		ms.latency.With("method", "revoke_channel_tokens").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.Connect(token, chanID, thingID)
+	return ms.svc.RevokeChannelTokens(ctx, token, chanID)
 }
 
-func (ms *metricsMiddleware) Disconnect(token, chanID, thingID string) error {
-=======
-	return ms.svc.Connect(ctx, token, chanID, thingID)
+func (ms *metricsMiddleware) CreateShareLink(ctx context.Context, token, chanID string, ttl time.Duration) (things.ShareLink, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "create_share_link").Add(1)
+		ms.latency.With("method", "create_share_link").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.CreateShareLink(ctx, token, chanID, ttl)
+}
+
+func (ms *metricsMiddleware) RevokeShareLink(ctx context.Context, token, chanID, linkToken string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "revoke_share_link").Add(1)
+		ms.latency.With("method", "revoke_share_link").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RevokeShareLink(ctx, token, chanID, linkToken)
+}
+
+func (ms *metricsMiddleware) CanReadShared(ctx context.Context, linkToken, chanID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "can_read_shared").Add(1)
+		ms.latency.With("method", "can_read_shared").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.CanReadShared(ctx, linkToken, chanID)
+}
+
+func (ms *metricsMiddleware) IssueAPIKey(ctx context.Context, token string, scopes []string, ttl time.Duration) (things.APIKey, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "issue_api_key").Add(1)
+		ms.latency.With("method", "issue_api_key").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.IssueAPIKey(ctx, token, scopes, ttl)
+}
+
+func (ms *metricsMiddleware) ListAPIKeys(ctx context.Context, token string) ([]things.APIKey, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_api_keys").Add(1)
+		ms.latency.With("method", "list_api_keys").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListAPIKeys(ctx, token)
+}
+
+func (ms *metricsMiddleware) RevokeAPIKey(ctx context.Context, token, id string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "revoke_api_key").Add(1)
+		ms.latency.With("method", "revoke_api_key").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RevokeAPIKey(ctx, token, id)
+}
+
+func (ms *metricsMiddleware) Connect(ctx context.Context, token, chanID, thingID string, ttl time.Duration) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "connect").Add(1)
+		ms.latency.With("method", "connect").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.Connect(ctx, token, chanID, thingID, ttl)
 }
 
 func (ms *metricsMiddleware) Disconnect(ctx context.Context, token, chanID, thingID string) error {
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
 	defer func(begin time.Time) {
 		ms.counter.With("method", "disconnect").Add(1)
 		ms.latency.With("method", "disconnect").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-<<<<<<< HEAD
-	return ms.svc.Disconnect(token, chanID, thingID)
-=======
 	return ms.svc.Disconnect(ctx, token, chanID, thingID)
->>>>>>> 649986b19faad871678d27a061e55cf4775bcac5
+}
+
+func (ms *metricsMiddleware) ReapIdle(ctx context.Context, token, chanID string, olderThan time.Duration) ([]string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "reap_idle").Add(1)
+		ms.latency.With("method", "reap_idle").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ReapIdle(ctx, token, chanID, olderThan)
 }
 
 func (ms *metricsMiddleware) CanAccess(ctx context.Context, id, key string) (string, error) {
@@ -225,6 +314,15 @@ func (ms *metricsMiddleware) CanAccess(ctx context.Context, id, key string) (str
 	return ms.svc.CanAccess(ctx, id, key)
 }
 
+func (ms *metricsMiddleware) CanReadMessages(ctx context.Context, id, key string) (string, bool, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "can_read_messages").Add(1)
+		ms.latency.With("method", "can_read_messages").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.CanReadMessages(ctx, id, key)
+}
+
 func (ms *metricsMiddleware) CanAccessByID(ctx context.Context, chanID, thingID string) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "can_access_by_id").Add(1)
@@ -242,3 +340,75 @@ func (ms *metricsMiddleware) Identify(ctx context.Context, key string) (string,
 
 	return ms.svc.Identify(ctx, key)
 }
+
+func (ms *metricsMiddleware) IdentifyFull(ctx context.Context, key string) (things.Thing, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "identify_full").Add(1)
+		ms.latency.With("method", "identify_full").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.IdentifyFull(ctx, key)
+}
+
+func (ms *metricsMiddleware) IdentifyBatch(ctx context.Context, keys []string) (map[string]string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "identify_batch").Add(1)
+		ms.latency.With("method", "identify_batch").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.IdentifyBatch(ctx, keys)
+}
+
+func (ms *metricsMiddleware) RotateExpiredKeys(ctx context.Context) ([]string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "rotate_expired_keys").Add(1)
+		ms.latency.With("method", "rotate_expired_keys").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RotateExpiredKeys(ctx)
+}
+
+func (ms *metricsMiddleware) DisconnectExpired(ctx context.Context) ([]things.Connection, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "disconnect_expired").Add(1)
+		ms.latency.With("method", "disconnect_expired").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DisconnectExpired(ctx)
+}
+
+func (ms *metricsMiddleware) DisableOwner(ctx context.Context, owner string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "disable_owner").Add(1)
+		ms.latency.With("method", "disable_owner").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DisableOwner(ctx, owner)
+}
+
+func (ms *metricsMiddleware) EnableOwner(ctx context.Context, owner string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "enable_owner").Add(1)
+		ms.latency.With("method", "enable_owner").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.EnableOwner(ctx, owner)
+}
+
+func (ms *metricsMiddleware) ChangeOwnerEmail(ctx context.Context, email, newEmail string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "change_owner_email").Add(1)
+		ms.latency.With("method", "change_owner_email").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ChangeOwnerEmail(ctx, email, newEmail)
+}
+
+func (ms *metricsMiddleware) Subscribe(ctx context.Context, token string) (<-chan things.Event, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "subscribe").Add(1)
+		ms.latency.With("method", "subscribe").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.Subscribe(ctx, token)
+}
@@ -9,6 +9,7 @@ package http
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/mainflux/mainflux/things"
@@ -40,6 +41,106 @@ func addThingEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func provisionThingEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addThingReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		thing := things.Thing{
+			Key:      req.Key,
+			Name:     req.Name,
+			Metadata: req.Metadata,
+		}
+		prov, err := svc.ProvisionThing(ctx, req.token, thing)
+		if err != nil {
+			return nil, err
+		}
+
+		res := provisionRes{
+			Thing: viewThingRes{
+				ID:        prov.Thing.ID,
+				Name:      prov.Thing.Name,
+				Key:       prov.Thing.Key,
+				KeyExpiry: optionalTime(prov.Thing.KeyExpiry),
+				Metadata:  prov.Thing.Metadata,
+			},
+			Channels: []provisionedChannelRes{},
+		}
+		for _, pc := range prov.Channels {
+			res.Channels = append(res.Channels, provisionedChannelRes{
+				ID:        pc.Channel.ID,
+				Name:      pc.Channel.Name,
+				Connected: pc.Connected,
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func importThingsEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importThingsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		// Rows that failed to be read don't reach the service. If importing
+		// stops at the first failure, the rows following such a row aren't
+		// imported either.
+		rows := req.rows
+		ths := []things.Thing{}
+		for i, row := range rows {
+			if row.err != nil {
+				if req.stopOnError {
+					rows = rows[:i+1]
+					break
+				}
+				continue
+			}
+			ths = append(ths, row.thing)
+		}
+
+		results, err := svc.AddThings(ctx, req.token, ths, req.stopOnError)
+		if err != nil {
+			return nil, err
+		}
+
+		res := importRes{
+			Rows: []importRowRes{},
+			csv:  req.csvReport,
+		}
+		for _, row := range rows {
+			err := row.err
+			if err == nil {
+				// Service stopped at the failure of the previous row.
+				if len(results) == 0 {
+					break
+				}
+				result := results[0]
+				results = results[1:]
+
+				if result.Err == nil {
+					res.Created++
+					res.Rows = append(res.Rows, importRowRes{Row: row.row, ID: result.Thing.ID})
+					continue
+				}
+				err = result.Err
+			}
+
+			msg, code := errorMessage(err)
+			res.Failed++
+			res.Rows = append(res.Rows, importRowRes{Row: row.row, ID: row.thing.ID, Error: msg, Code: code})
+		}
+
+		return res, nil
+	}
+}
+
 func updateThingEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(updateThingReq)
@@ -63,6 +164,23 @@ func updateThingEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func patchThingMetadataEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(patchMetadataReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		metadata, err := svc.PatchThingMetadata(ctx, req.token, req.id, req.patch)
+		if err != nil {
+			return nil, err
+		}
+
+		return metadataRes{Metadata: metadata}, nil
+	}
+}
+
 func updateKeyEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(updateKeyReq)
@@ -80,6 +198,41 @@ func updateKeyEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func nameAvailabilityEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(nameAvailabilityReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		available, err := svc.NameAvailable(ctx, req.token, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return nameAvailabilityRes{Name: req.name, Available: available}, nil
+	}
+}
+
+// thingExistsEndpoint checks whether the thing exists and belongs to the
+// user, without rendering it.
+func thingExistsEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if _, err := svc.ViewThing(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+
+		return existsRes{}, nil
+	}
+}
+
 func viewThingEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(viewResourceReq)
@@ -94,11 +247,14 @@ func viewThingEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 
 		res := viewThingRes{
-			ID:       thing.ID,
-			Owner:    thing.Owner,
-			Name:     thing.Name,
-			Key:      thing.Key,
-			Metadata: thing.Metadata,
+			ID:        thing.ID,
+			Owner:     thing.Owner,
+			Name:      thing.Name,
+			Key:       thing.Key,
+			KeyExpiry: optionalTime(thing.KeyExpiry),
+			LastSeen:  optionalTime(thing.LastSeen),
+			Metadata:  thing.Metadata,
+			Channels:  &thing.Connections,
 		}
 		return res, nil
 	}
@@ -112,11 +268,33 @@ func listThingsEndpoint(svc things.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		page, err := svc.ListThings(ctx, req.token, req.offset, req.limit, req.name)
+		var page things.ThingsPage
+		var err error
+		if len(req.channels) > 0 {
+			page, err = svc.ListThingsByChannels(ctx, req.token, req.channels, req.offset, req.limit)
+		} else {
+			page, err = svc.ListThings(ctx, req.token, req.offset, req.limit, req.name, req.compact())
+		}
 		if err != nil {
 			return nil, err
 		}
 
+		if req.compact() {
+			res := compactThingsPageRes{
+				pageRes: pageRes{
+					Total:  page.Total,
+					Offset: page.Offset,
+					Limit:  page.Limit,
+				},
+				Things: []compactRes{},
+			}
+			for _, thing := range page.Things {
+				res.Things = append(res.Things, compactRes{ID: thing.ID, Name: thing.Name})
+			}
+
+			return res, nil
+		}
+
 		res := thingsPageRes{
 			pageRes: pageRes{
 				Total:  page.Total,
@@ -127,11 +305,12 @@ func listThingsEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 		for _, thing := range page.Things {
 			view := viewThingRes{
-				ID:       thing.ID,
-				Owner:    thing.Owner,
-				Name:     thing.Name,
-				Key:      thing.Key,
-				Metadata: thing.Metadata,
+				ID:        thing.ID,
+				Owner:     thing.Owner,
+				Name:      thing.Name,
+				Key:       thing.Key,
+				KeyExpiry: optionalTime(thing.KeyExpiry),
+				Metadata:  thing.Metadata,
 			}
 			res.Things = append(res.Things, view)
 		}
@@ -163,11 +342,12 @@ func listThingsByChannelEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 		for _, thing := range page.Things {
 			view := viewThingRes{
-				ID:       thing.ID,
-				Owner:    thing.Owner,
-				Key:      thing.Key,
-				Name:     thing.Name,
-				Metadata: thing.Metadata,
+				ID:        thing.ID,
+				Owner:     thing.Owner,
+				Key:       thing.Key,
+				KeyExpiry: optionalTime(thing.KeyExpiry),
+				Name:      thing.Name,
+				Metadata:  thing.Metadata,
 			}
 			res.Things = append(res.Things, view)
 		}
@@ -205,7 +385,14 @@ func createChannelEndpoint(svc things.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		channel := things.Channel{Name: req.Name, Metadata: req.Metadata}
+		channel := things.Channel{
+			ParentID:        req.ParentID,
+			Name:            req.Name,
+			Metadata:        req.Metadata,
+			Membership:      req.Membership,
+			Protected:       req.Protected,
+			PublisherScoped: req.Scoped,
+		}
 		saved, err := svc.CreateChannel(ctx, req.token, channel)
 		if err != nil {
 			return nil, err
@@ -228,9 +415,13 @@ func updateChannelEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 
 		channel := things.Channel{
-			ID:       req.id,
-			Name:     req.Name,
-			Metadata: req.Metadata,
+			ID:              req.id,
+			ParentID:        req.parentID(),
+			Name:            req.Name,
+			Metadata:        req.Metadata,
+			Membership:      req.Membership,
+			Protected:       req.Protected,
+			PublisherScoped: req.Scoped,
 		}
 		if err := svc.UpdateChannel(ctx, req.token, channel); err != nil {
 			return nil, err
@@ -244,6 +435,24 @@ func updateChannelEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+// channelExistsEndpoint checks whether the channel exists and belongs to the
+// user, without rendering it.
+func channelExistsEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if _, err := svc.ViewChannel(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+
+		return existsRes{}, nil
+	}
+}
+
 func viewChannelEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(viewResourceReq)
@@ -258,10 +467,15 @@ func viewChannelEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 
 		res := viewChannelRes{
-			ID:       channel.ID,
-			Owner:    channel.Owner,
-			Name:     channel.Name,
-			Metadata: channel.Metadata,
+			ID:         channel.ID,
+			Owner:      channel.Owner,
+			ParentID:   channel.ParentID,
+			Name:       channel.Name,
+			Metadata:   channel.Metadata,
+			Membership: channel.Membership,
+			Protected:  channel.Protected,
+			Scoped:     channel.PublisherScoped,
+			Connected:  &channel.Connections,
 		}
 
 		return res, nil
@@ -276,11 +490,27 @@ func listChannelsEndpoint(svc things.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		page, err := svc.ListChannels(ctx, req.token, req.offset, req.limit, req.name)
+		page, err := svc.ListChannels(ctx, req.token, req.offset, req.limit, req.name, req.parent, req.compact())
 		if err != nil {
 			return nil, err
 		}
 
+		if req.compact() {
+			res := compactChannelsPageRes{
+				pageRes: pageRes{
+					Total:  page.Total,
+					Offset: page.Offset,
+					Limit:  page.Limit,
+				},
+				Channels: []compactRes{},
+			}
+			for _, channel := range page.Channels {
+				res.Channels = append(res.Channels, compactRes{ID: channel.ID, Name: channel.Name})
+			}
+
+			return res, nil
+		}
+
 		res := channelsPageRes{
 			pageRes: pageRes{
 				Total:  page.Total,
@@ -292,10 +522,14 @@ func listChannelsEndpoint(svc things.Service) endpoint.Endpoint {
 		// Cast channels
 		for _, channel := range page.Channels {
 			view := viewChannelRes{
-				ID:       channel.ID,
-				Owner:    channel.Owner,
-				Name:     channel.Name,
-				Metadata: channel.Metadata,
+				ID:         channel.ID,
+				Owner:      channel.Owner,
+				ParentID:   channel.ParentID,
+				Name:       channel.Name,
+				Metadata:   channel.Metadata,
+				Membership: channel.Membership,
+				Protected:  channel.Protected,
+				Scoped:     channel.PublisherScoped,
 			}
 
 			res.Channels = append(res.Channels, view)
@@ -328,10 +562,14 @@ func listChannelsByThingEndpoint(svc things.Service) endpoint.Endpoint {
 		}
 		for _, channel := range page.Channels {
 			view := viewChannelRes{
-				ID:       channel.ID,
-				Owner:    channel.Owner,
-				Name:     channel.Name,
-				Metadata: channel.Metadata,
+				ID:         channel.ID,
+				Owner:      channel.Owner,
+				ParentID:   channel.ParentID,
+				Name:       channel.Name,
+				Metadata:   channel.Metadata,
+				Membership: channel.Membership,
+				Protected:  channel.Protected,
+				Scoped:     channel.PublisherScoped,
 			}
 			res.Channels = append(res.Channels, view)
 		}
@@ -342,7 +580,7 @@ func listChannelsByThingEndpoint(svc things.Service) endpoint.Endpoint {
 
 func removeChannelEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(viewResourceReq)
+		req := request.(removeChannelReq)
 
 		if err := req.validate(); err != nil {
 			if err == things.ErrNotFound {
@@ -351,7 +589,7 @@ func removeChannelEndpoint(svc things.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		if err := svc.RemoveChannel(ctx, req.token, req.id); err != nil {
+		if err := svc.RemoveChannel(ctx, req.token, req.id, req.force); err != nil {
 			return nil, err
 		}
 
@@ -359,6 +597,153 @@ func removeChannelEndpoint(svc things.Service) endpoint.Endpoint {
 	}
 }
 
+func issueChannelTokenEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(issueChannelTokenReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ttl := time.Duration(req.TTL) * time.Second
+		token, err := svc.IssueChannelToken(ctx, req.token, req.id, ttl, req.Scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return channelTokenRes{Token: token}, nil
+	}
+}
+
+func createShareLinkEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createShareLinkReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ttl := time.Duration(req.TTL) * time.Second
+		link, err := svc.CreateShareLink(ctx, req.token, req.id, ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		res := shareLinkRes{
+			Token:     link.Token,
+			URL:       link.URL,
+			ExpiresAt: link.ExpiresAt,
+		}
+		return res, nil
+	}
+}
+
+func revokeChannelTokensEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewResourceReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RevokeChannelTokens(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func revokeShareLinkEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeShareLinkReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RevokeShareLink(ctx, req.token, req.id, req.linkToken); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func issueAPIKeyEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(issueAPIKeyReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		ttl := time.Duration(req.TTL) * time.Second
+		key, err := svc.IssueAPIKey(ctx, req.token, req.Scopes, ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		return newAPIKeyRes(key, true), nil
+	}
+}
+
+func listAPIKeysEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAPIKeysReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		keys, err := svc.ListAPIKeys(ctx, req.token)
+		if err != nil {
+			return nil, err
+		}
+
+		res := apiKeysRes{Keys: []apiKeyRes{}}
+		for _, key := range keys {
+			res.Keys = append(res.Keys, newAPIKeyRes(key, false))
+		}
+
+		return res, nil
+	}
+}
+
+func revokeAPIKeyEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeAPIKeyReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RevokeAPIKey(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func reapIdleEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reapIdleReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		olderThan := time.Duration(req.OlderThan) * time.Second
+		ids, err := svc.ReapIdle(ctx, req.token, req.id, olderThan)
+		if err != nil {
+			return nil, err
+		}
+
+		return reapRes{Things: ids}, nil
+	}
+}
+
 func connectEndpoint(svc things.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		cr := request.(connectionReq)
@@ -367,7 +752,8 @@ func connectEndpoint(svc things.Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		if err := svc.Connect(ctx, cr.token, cr.chanID, cr.thingID); err != nil {
+		ttl := time.Duration(cr.ttl) * time.Second
+		if err := svc.Connect(ctx, cr.token, cr.chanID, cr.thingID, ttl); err != nil {
 			return nil, err
 		}
 
@@ -390,3 +776,11 @@ func disconnectEndpoint(svc things.Service) endpoint.Endpoint {
 		return disconnectionRes{}, nil
 	}
 }
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -8,6 +8,7 @@
 package http_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/mainflux/mainflux/things"
 	httpapi "github.com/mainflux/mainflux/things/api/things/http"
 	"github.com/mainflux/mainflux/things/mocks"
+	"github.com/mainflux/mainflux/things/uuid"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,6 +32,7 @@ import (
 
 const (
 	contentType = "application/json"
+	csvType     = "text/csv"
 	email       = "user@example.com"
 	token       = "token"
 	wrongValue  = "wrong_value"
@@ -47,13 +50,21 @@ var (
 		Metadata: map[string]interface{}{"test": "data"},
 	}
 	invalidName = strings.Repeat("m", maxNameSize+1)
+	notFoundRes = toJSON(errorRes{Err: things.ErrNotFound.Error(), Code: "not_found"})
+	unauthRes   = toJSON(errorRes{Err: things.ErrUnauthorizedAccess.Error(), Code: "unauthorized_access"})
 )
 
+type errorRes struct {
+	Err  string `json:"error"`
+	Code string `json:"code"`
+}
+
 type testRequest struct {
 	client      *http.Client
 	method      string
 	url         string
 	contentType string
+	accept      string
 	token       string
 	body        io.Reader
 }
@@ -69,10 +80,13 @@ func (tr testRequest) make() (*http.Response, error) {
 	if tr.contentType != "" {
 		req.Header.Set("Content-Type", tr.contentType)
 	}
+	if tr.accept != "" {
+		req.Header.Set("Accept", tr.accept)
+	}
 	return tr.client.Do(req)
 }
 
-func newService(tokens map[string]string) things.Service {
+func newService(tokens map[string]string, opts ...things.Option) things.Service {
 	users := mocks.NewUsersService(tokens)
 	conns := make(chan mocks.Connection)
 	thingsRepo := mocks.NewThingRepository(conns)
@@ -81,11 +95,11 @@ func newService(tokens map[string]string) things.Service {
 	thingCache := mocks.NewThingCache()
 	idp := mocks.NewIdentityProvider()
 
-	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
+	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, opts...)
 }
 
 func newServer(svc things.Service) *httptest.Server {
-	mux := httpapi.MakeHandler(mocktracer.New(), svc)
+	mux := httpapi.MakeHandler(mocktracer.New(), svc, "", nil)
 	return httptest.NewServer(mux)
 }
 
@@ -206,6 +220,271 @@ func TestAddThing(t *testing.T) {
 	}
 }
 
+func TestProvisionThing(t *testing.T) {
+	tpl := things.ProvisionTemplate{
+		Channels: []things.ChannelTemplate{
+			{Name: "{name}-data", Connect: true},
+			{Name: "{name}-control", Connect: false},
+		},
+	}
+	svc := newService(map[string]string{token: email}, things.WithProvisionTemplate(tpl), things.WithMaxNameLength(20))
+	ts := newServer(svc)
+	defer ts.Close()
+
+	cases := []struct {
+		desc        string
+		req         string
+		contentType string
+		auth        string
+		status      int
+		channels    []provisionedChannel
+	}{
+		{
+			desc:        "provision thing",
+			req:         toJSON(things.Thing{Name: "sensor"}),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusCreated,
+			channels: []provisionedChannel{
+				{Name: "sensor-data", Connected: true},
+				{Name: "sensor-control", Connected: false},
+			},
+		},
+		{
+			desc:        "provision thing failing to create channel",
+			req:         toJSON(things.Thing{Name: "sensor-number"}),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "provision thing with invalid auth token",
+			req:         toJSON(things.Thing{Name: "sensor"}),
+			contentType: contentType,
+			auth:        wrongValue,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "provision thing without content type",
+			req:         toJSON(things.Thing{Name: "sensor"}),
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/things/provision", ts.URL),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusCreated {
+			continue
+		}
+
+		var body struct {
+			Thing struct {
+				ID  string `json:"id"`
+				Key string `json:"key"`
+			} `json:"thing"`
+			Channels []provisionedChannel `json:"channels"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.NotEmpty(t, body.Thing.Key, fmt.Sprintf("%s: expected thing key", tc.desc))
+		assert.Equal(t, fmt.Sprintf("/things/%s", body.Thing.ID), res.Header.Get("Location"), fmt.Sprintf("%s: unexpected location %s", tc.desc, res.Header.Get("Location")))
+		require.Len(t, body.Channels, len(tc.channels), fmt.Sprintf("%s: expected %d channels got %d", tc.desc, len(tc.channels), len(body.Channels)))
+		for i, ch := range body.Channels {
+			assert.NotEmpty(t, ch.ID, fmt.Sprintf("%s: expected channel ID", tc.desc))
+			ch.ID = ""
+			assert.Equal(t, tc.channels[i], ch, fmt.Sprintf("%s: expected channel %v got %v", tc.desc, tc.channels[i], ch))
+		}
+	}
+
+	// Failed provisioning leaves the single provisioned thing only.
+	page, err := svc.ListThings(context.Background(), token, 0, 10, "", false)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Len(t, page.Things, 1, fmt.Sprintf("expected 1 thing got %d", len(page.Things)))
+}
+
+type provisionedChannel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Connected bool   `json:"connected"`
+}
+
+func TestImportThings(t *testing.T) {
+	cases := []struct {
+		desc        string
+		url         string
+		req         string
+		contentType string
+		accept      string
+		auth        string
+		status      int
+		res         string
+	}{
+		{
+			desc:        "import valid things",
+			url:         "/things/import",
+			req:         "name,metadata\nfirst,\"{\"\"test\"\": \"\"data\"\"}\"\nsecond,\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusOK,
+			res: toJSON(importRes{
+				Created: 2,
+				Rows:    []importRowRes{{Row: 2, ID: "1"}, {Row: 3, ID: "2"}},
+			}),
+		},
+		{
+			desc:        "import things with malformed rows",
+			url:         "/things/import",
+			req:         fmt.Sprintf("name,metadata\nfirst,{invalid}\n%s,\nthird,\nfourth,{},extra\nfifth,{}\n", invalidName),
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusOK,
+			res: toJSON(importRes{
+				Created: 2,
+				Failed:  3,
+				Rows: []importRowRes{
+					{Row: 2, Error: things.ErrMalformedEntity.Error(), Code: "malformed_entity"},
+					{Row: 3, Error: things.ErrMalformedEntity.Error(), Code: "malformed_entity"},
+					{Row: 4, ID: "1"},
+					{Row: 5, Error: things.ErrMalformedEntity.Error(), Code: "malformed_entity"},
+					{Row: 6, ID: "2"},
+				},
+			}),
+		},
+		{
+			desc:        "import things stopping on malformed row",
+			url:         "/things/import?stop_on_error=true",
+			req:         "name,metadata\nfirst,{}\nsecond,{invalid}\nthird,{}\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusOK,
+			res: toJSON(importRes{
+				Created: 1,
+				Failed:  1,
+				Rows: []importRowRes{
+					{Row: 2, ID: "1"},
+					{Row: 3, Error: things.ErrMalformedEntity.Error(), Code: "malformed_entity"},
+				},
+			}),
+		},
+		{
+			desc:        "import things stopping on invalid thing",
+			url:         "/things/import?stop_on_error=true",
+			req:         fmt.Sprintf("name\nfirst\n%s\nthird\n", invalidName),
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusOK,
+			res: toJSON(importRes{
+				Created: 1,
+				Failed:  1,
+				Rows: []importRowRes{
+					{Row: 2, ID: "1"},
+					{Row: 3, Error: things.ErrMalformedEntity.Error(), Code: "malformed_entity"},
+				},
+			}),
+		},
+		{
+			desc:        "import things with CSV report",
+			url:         "/things/import",
+			req:         "name,metadata\nfirst,{}\nsecond,{invalid}\n",
+			contentType: csvType,
+			accept:      csvType,
+			auth:        token,
+			status:      http.StatusOK,
+			res:         fmt.Sprintf("row,id,error\n2,1,\n3,,%s", things.ErrMalformedEntity),
+		},
+		{
+			desc:        "import things with invalid stop on error flag",
+			url:         "/things/import?stop_on_error=maybe",
+			req:         "name\nfirst\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			res:         toJSON(errorRes{Err: "invalid query params", Code: "invalid_query_params"}),
+		},
+		{
+			desc:        "import things without name column",
+			url:         "/things/import",
+			req:         "metadata\n{}\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			res:         toJSON(errorRes{Err: things.ErrMalformedEntity.Error(), Code: "malformed_entity"}),
+		},
+		{
+			desc:        "import things with unknown column",
+			url:         "/things/import",
+			req:         "name,key\nfirst,key\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			res:         toJSON(errorRes{Err: things.ErrMalformedEntity.Error(), Code: "malformed_entity"}),
+		},
+		{
+			desc:        "import things without rows",
+			url:         "/things/import",
+			req:         "name,metadata\n",
+			contentType: csvType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			res:         toJSON(errorRes{Err: things.ErrMalformedEntity.Error(), Code: "malformed_entity"}),
+		},
+		{
+			desc:        "import things with invalid content type",
+			url:         "/things/import",
+			req:         "name\nfirst\n",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+			res:         toJSON(errorRes{Err: "unsupported content type", Code: "unsupported_content_type"}),
+		},
+		{
+			desc:        "import things with invalid token",
+			url:         "/things/import",
+			req:         "name\nfirst\n",
+			contentType: csvType,
+			auth:        wrongValue,
+			status:      http.StatusForbidden,
+			res:         unauthRes,
+		},
+	}
+
+	for _, tc := range cases {
+		svc := newService(map[string]string{token: email})
+		ts := newServer(svc)
+
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s%s", ts.URL, tc.url),
+			contentType: tc.contentType,
+			accept:      tc.accept,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		data := strings.Trim(string(body), "\n")
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.res, data, fmt.Sprintf("%s: expected body %s got %s", tc.desc, tc.res, data))
+
+		ts.Close()
+	}
+}
+
 func TestUpdateThing(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
@@ -218,10 +497,6 @@ func TestUpdateThing(t *testing.T) {
 	th.Name = invalidName
 	invalidData := toJSON(th)
 
-	th := thing
-	th.Name = invalidName
-	invalidData := toJSON(th)
-
 	cases := []struct {
 		desc        string
 		req         string
@@ -326,6 +601,143 @@ func TestUpdateThing(t *testing.T) {
 	}
 }
 
+func TestPatchThingMetadata(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	th := thing
+	th.Metadata = map[string]interface{}{"test": "data", "site": "plant-1"}
+	sth, _ := svc.AddThing(context.Background(), token, th)
+
+	cases := []struct {
+		desc        string
+		req         string
+		id          string
+		contentType string
+		auth        string
+		status      int
+		metadata    map[string]interface{}
+	}{
+		{
+			desc:        "patch metadata adding key",
+			req:         `{"floor":"3"}`,
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusOK,
+			metadata:    map[string]interface{}{"test": "data", "site": "plant-1", "floor": "3"},
+		},
+		{
+			desc:        "patch metadata removing key",
+			req:         `{"site":null}`,
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusOK,
+			metadata:    map[string]interface{}{"test": "data", "floor": "3"},
+		},
+		{
+			desc:        "patch metadata with JSON content type",
+			req:         `{"floor":"4"}`,
+			id:          sth.ID,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusOK,
+			metadata:    map[string]interface{}{"test": "data", "floor": "4"},
+		},
+		{
+			desc:        "patch metadata with empty patch",
+			req:         "{}",
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusOK,
+			metadata:    map[string]interface{}{"test": "data", "floor": "4"},
+		},
+		{
+			desc:        "patch metadata with null patch",
+			req:         "null",
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "patch metadata with non-object patch",
+			req:         `["floor"]`,
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "patch metadata with invalid data format",
+			req:         "{",
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "patch metadata with reserved key",
+			req:         `{"mf_key":"value"}`,
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "patch metadata without content type",
+			req:         `{"floor":"5"}`,
+			id:          sth.ID,
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "patch metadata of non-existent thing",
+			req:         `{"floor":"5"}`,
+			id:          strconv.FormatUint(wrongID, 10),
+			contentType: "application/merge-patch+json",
+			auth:        token,
+			status:      http.StatusNotFound,
+		},
+		{
+			desc:        "patch metadata with invalid user token",
+			req:         `{"floor":"5"}`,
+			id:          sth.ID,
+			contentType: "application/merge-patch+json",
+			auth:        wrongValue,
+			status:      http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPatch,
+			url:         fmt.Sprintf("%s/things/%s/metadata", ts.URL, tc.id),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.metadata, body.Metadata, fmt.Sprintf("%s: expected metadata %v got %v", tc.desc, tc.metadata, body.Metadata))
+	}
+}
+
 func TestUpdateKey(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
@@ -454,11 +866,14 @@ func TestViewThing(t *testing.T) {
 	sth, err := svc.AddThing(context.Background(), token, thing)
 	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
+	var connections uint64
 	thres := thingRes{
 		ID:       sth.ID,
 		Name:     sth.Name,
 		Key:      sth.Key,
+		LastSeen: &sth.LastSeen,
 		Metadata: sth.Metadata,
+		Channels: &connections,
 	}
 	data := toJSON(thres)
 
@@ -481,28 +896,28 @@ func TestViewThing(t *testing.T) {
 			id:     strconv.FormatUint(wrongID, 10),
 			auth:   token,
 			status: http.StatusNotFound,
-			res:    "",
+			res:    notFoundRes,
 		},
 		{
 			desc:   "view thing by passing invalid token",
 			id:     sth.ID,
 			auth:   wrongValue,
 			status: http.StatusForbidden,
-			res:    "",
+			res:    unauthRes,
 		},
 		{
 			desc:   "view thing by passing empty token",
 			id:     sth.ID,
 			auth:   "",
 			status: http.StatusForbidden,
-			res:    "",
+			res:    unauthRes,
 		},
 		{
 			desc:   "view thing by passing invalid id",
 			id:     "invalid",
 			auth:   token,
 			status: http.StatusNotFound,
-			res:    "",
+			res:    notFoundRes,
 		},
 	}
 
@@ -523,45 +938,207 @@ func TestViewThing(t *testing.T) {
 	}
 }
 
-func TestListThings(t *testing.T) {
-	svc := newService(map[string]string{token: email})
+func TestResourceExists(t *testing.T) {
+	otherToken := "other_token"
+	otherEmail := "other_user@example.com"
+	svc := newService(map[string]string{token: email, otherToken: otherEmail})
 	ts := newServer(svc)
 	defer ts.Close()
 
-	data := []thingRes{}
-	for i := 0; i < 100; i++ {
-		sth, err := svc.AddThing(context.Background(), token, thing)
-		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		thres := thingRes{
-			ID:       sth.ID,
-			Name:     sth.Name,
-			Key:      sth.Key,
-			Metadata: sth.Metadata,
-		}
-		data = append(data, thres)
-	}
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
-	thingURL := fmt.Sprintf("%s/things", ts.URL)
 	cases := []struct {
 		desc   string
+		url    string
 		auth   string
 		status int
-		url    string
-		res    []thingRes
 	}{
 		{
-			desc:   "get a list of things",
+			desc:   "check existing owned thing",
+			url:    fmt.Sprintf("%s/things/%s", ts.URL, sth.ID),
 			auth:   token,
 			status: http.StatusOK,
-			url:    fmt.Sprintf("%s?offset=%d&limit=%d", thingURL, 0, 5),
-			res:    data[0:5],
 		},
 		{
-			desc:   "get a list of things with invalid token",
-			auth:   wrongValue,
-			status: http.StatusForbidden,
-			url:    fmt.Sprintf("%s?offset=%d&limit=%d", thingURL, 0, 1),
-			res:    nil,
+			desc:   "check existing thing owned by other user",
+			url:    fmt.Sprintf("%s/things/%s", ts.URL, sth.ID),
+			auth:   otherToken,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "check non-existent thing",
+			url:    fmt.Sprintf("%s/things/%d", ts.URL, wrongID),
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "check thing with invalid token",
+			url:    fmt.Sprintf("%s/things/%s", ts.URL, sth.ID),
+			auth:   wrongValue,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "check existing owned channel",
+			url:    fmt.Sprintf("%s/channels/%s", ts.URL, sch.ID),
+			auth:   token,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "check existing channel owned by other user",
+			url:    fmt.Sprintf("%s/channels/%s", ts.URL, sch.ID),
+			auth:   otherToken,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "check non-existent channel",
+			url:    fmt.Sprintf("%s/channels/%d", ts.URL, wrongID),
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "check channel with empty token",
+			url:    fmt.Sprintf("%s/channels/%s", ts.URL, sch.ID),
+			auth:   "",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodHead,
+			url:    tc.url,
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Empty(t, body, fmt.Sprintf("%s: expected empty body got %s", tc.desc, body))
+	}
+}
+
+func TestNameAvailability(t *testing.T) {
+	otherToken := "other_token"
+	otherEmail := "other_user@example.com"
+	svc := newService(map[string]string{token: email, otherToken: otherEmail})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	_, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		name   string
+		auth   string
+		status int
+		res    string
+	}{
+		{
+			desc:   "check available name",
+			name:   "available",
+			auth:   token,
+			status: http.StatusOK,
+			res:    toJSON(nameAvailabilityRes{Name: "available", Available: true}),
+		},
+		{
+			desc:   "check taken name",
+			name:   thing.Name,
+			auth:   token,
+			status: http.StatusOK,
+			res:    toJSON(nameAvailabilityRes{Name: thing.Name, Available: false}),
+		},
+		{
+			desc:   "check name taken by other user",
+			name:   thing.Name,
+			auth:   otherToken,
+			status: http.StatusOK,
+			res:    toJSON(nameAvailabilityRes{Name: thing.Name, Available: true}),
+		},
+		{
+			desc:   "check empty name",
+			name:   "",
+			auth:   token,
+			status: http.StatusBadRequest,
+			res:    toJSON(errorRes{Err: things.ErrMalformedEntity.Error(), Code: "malformed_entity"}),
+		},
+		{
+			desc:   "check too long name",
+			name:   invalidName,
+			auth:   token,
+			status: http.StatusBadRequest,
+			res:    toJSON(errorRes{Err: things.ErrMalformedEntity.Error(), Code: "malformed_entity"}),
+		},
+		{
+			desc:   "check name with invalid token",
+			name:   thing.Name,
+			auth:   wrongValue,
+			status: http.StatusForbidden,
+			res:    unauthRes,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/available?name=%s", ts.URL, tc.name),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		data := strings.Trim(string(body), "\n")
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.res, data, fmt.Sprintf("%s: expected body %s got %s", tc.desc, tc.res, data))
+	}
+}
+
+func TestListThings(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	data := []thingRes{}
+	for i := 0; i < 100; i++ {
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		thres := thingRes{
+			ID:       sth.ID,
+			Name:     sth.Name,
+			Key:      sth.Key,
+			Metadata: sth.Metadata,
+		}
+		data = append(data, thres)
+	}
+
+	thingURL := fmt.Sprintf("%s/things", ts.URL)
+	cases := []struct {
+		desc   string
+		auth   string
+		status int
+		url    string
+		res    []thingRes
+	}{
+		{
+			desc:   "get a list of things",
+			auth:   token,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?offset=%d&limit=%d", thingURL, 0, 5),
+			res:    data[0:5],
+		},
+		{
+			desc:   "get a list of things with invalid token",
+			auth:   wrongValue,
+			status: http.StatusForbidden,
+			url:    fmt.Sprintf("%s?offset=%d&limit=%d", thingURL, 0, 1),
+			res:    nil,
 		},
 		{
 			desc:   "get a list of things with empty token",
@@ -684,7 +1261,7 @@ func TestListThingsByChannel(t *testing.T) {
 	for i := 0; i < 101; i++ {
 		sth, err := svc.AddThing(context.Background(), token, thing)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+		err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
 		thres := thingRes{
@@ -823,6 +1400,109 @@ func TestListThingsByChannel(t *testing.T) {
 	}
 }
 
+func TestListThingsByChannels(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	ch1, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch2, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	data := []thingRes{}
+	for i := 0; i < 5; i++ {
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		chIDs := []string{ch1.ID}
+		if i%2 == 0 {
+			chIDs = append(chIDs, ch2.ID)
+		}
+		for _, chID := range chIDs {
+			err = svc.Connect(context.Background(), token, chID, sth.ID, 0)
+			require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		}
+
+		thres := thingRes{
+			ID:       sth.ID,
+			Name:     sth.Name,
+			Key:      sth.Key,
+			Metadata: sth.Metadata,
+		}
+		data = append(data, thres)
+	}
+	thingURL := fmt.Sprintf("%s/things", ts.URL)
+
+	// Wait for things and channels to connect.
+	time.Sleep(time.Second)
+
+	cases := []struct {
+		desc   string
+		auth   string
+		status int
+		url    string
+		res    []thingRes
+	}{
+		{
+			desc:   "get a list of things connected to multiple channels",
+			auth:   token,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?channel=%s&channel=%s", thingURL, ch1.ID, ch2.ID),
+			res:    data,
+		},
+		{
+			desc:   "get a list of things connected to single channel",
+			auth:   token,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?channel=%s", thingURL, ch2.ID),
+			res:    []thingRes{data[0], data[2], data[4]},
+		},
+		{
+			desc:   "get a page of things connected to multiple channels",
+			auth:   token,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?channel=%s&channel=%s&offset=%d&limit=%d", thingURL, ch1.ID, ch2.ID, 1, 2),
+			res:    data[1:3],
+		},
+		{
+			desc:   "get a list of things connected to multiple channels with invalid token",
+			auth:   wrongValue,
+			status: http.StatusForbidden,
+			url:    fmt.Sprintf("%s?channel=%s&channel=%s", thingURL, ch1.ID, ch2.ID),
+			res:    nil,
+		},
+		{
+			desc:   "get a list of things connected to multiple channels filtered by name",
+			auth:   token,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?channel=%s&channel=%s&name=%s", thingURL, ch1.ID, ch2.ID, "name"),
+			res:    nil,
+		},
+		{
+			desc:   "get a list of things connected to empty channel",
+			auth:   token,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?channel=%s&channel=", thingURL, ch1.ID),
+			res:    nil,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		var data thingsPageRes
+		json.NewDecoder(res.Body).Decode(&data)
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.ElementsMatch(t, tc.res, data.Things, fmt.Sprintf("%s: expected body %v got %v", tc.desc, tc.res, data.Things))
+	}
+}
+
 func TestRemoveThing(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
@@ -992,13 +1672,6 @@ func TestUpdateChannel(t *testing.T) {
 	ch.Name = invalidName
 	invalidData := toJSON(ch)
 
-	ch := channel
-	ch.Name = "updated_channel"
-	updateData := toJSON(ch)
-
-	ch.Name = invalidName
-	invalidData := toJSON(ch)
-
 	cases := []struct {
 		desc        string
 		req         string
@@ -1103,6 +1776,59 @@ func TestUpdateChannel(t *testing.T) {
 	}
 }
 
+func TestUpdateChannelParent(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	parent, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	child := channel
+	child.ParentID = parent.ID
+	sch, err := svc.CreateChannel(context.Background(), token, child)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		req    string
+		parent string
+	}{
+		{
+			desc:   "update channel without parent",
+			req:    `{"name":"updated"}`,
+			parent: parent.ID,
+		},
+		{
+			desc:   "update channel with empty parent",
+			req:    `{"name":"updated","parent_id":""}`,
+			parent: "",
+		},
+		{
+			desc:   "update channel with parent",
+			req:    fmt.Sprintf(`{"name":"updated","parent_id":"%s"}`, parent.ID),
+			parent: parent.ID,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPut,
+			url:         fmt.Sprintf("%s/channels/%s", ts.URL, sch.ID),
+			contentType: contentType,
+			token:       token,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, http.StatusOK, res.StatusCode))
+
+		ch, err := svc.ViewChannel(context.Background(), token, sch.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.parent, ch.ParentID, fmt.Sprintf("%s: expected parent %s got %s", tc.desc, tc.parent, ch.ParentID))
+	}
+}
+
 func TestViewChannel(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
@@ -1111,12 +1837,14 @@ func TestViewChannel(t *testing.T) {
 	sch, _ := svc.CreateChannel(context.Background(), token, channel)
 
 	sth, _ := svc.AddThing(context.Background(), token, thing)
-	svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 
+	connections := uint64(1)
 	chres := channelRes{
-		ID:       sch.ID,
-		Name:     sch.Name,
-		Metadata: sch.Metadata,
+		ID:        sch.ID,
+		Name:      sch.Name,
+		Metadata:  sch.Metadata,
+		Connected: &connections,
 	}
 	data := toJSON(chres)
 
@@ -1139,28 +1867,28 @@ func TestViewChannel(t *testing.T) {
 			id:     strconv.FormatUint(wrongID, 10),
 			auth:   token,
 			status: http.StatusNotFound,
-			res:    "",
+			res:    notFoundRes,
 		},
 		{
 			desc:   "view channel with invalid token",
 			id:     sch.ID,
 			auth:   wrongValue,
 			status: http.StatusForbidden,
-			res:    "",
+			res:    unauthRes,
 		},
 		{
 			desc:   "view channel with empty token",
 			id:     sch.ID,
 			auth:   "",
 			status: http.StatusForbidden,
-			res:    "",
+			res:    unauthRes,
 		},
 		{
 			desc:   "view channel with invalid id",
 			id:     "invalid",
 			auth:   token,
 			status: http.StatusNotFound,
-			res:    "",
+			res:    notFoundRes,
 		},
 	}
 
@@ -1192,7 +1920,7 @@ func TestListChannels(t *testing.T) {
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 		sth, err := svc.AddThing(context.Background(), token, thing)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		svc.Connect(context.Background(), token, sch.ID, sth.ID)
+		svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 
 		chres := channelRes{
 			ID:       sch.ID,
@@ -1333,19 +2061,125 @@ func TestListChannels(t *testing.T) {
 	}
 }
 
-func TestListChannelsByThing(t *testing.T) {
+func TestListView(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	ts := newServer(svc)
 	defer ts.Close()
 
 	sth, err := svc.AddThing(context.Background(), token, thing)
 	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
-	channels := []channelRes{}
-	for i := 0; i < 101; i++ {
+	cases := []struct {
+		desc     string
+		url      string
+		items    string
+		status   int
+		id       string
+		included []string
+		omitted  []string
+	}{
+		{
+			desc:     "list things in default view",
+			url:      fmt.Sprintf("%s/things", ts.URL),
+			items:    "things",
+			status:   http.StatusOK,
+			id:       sth.ID,
+			included: []string{"id", "name", "key", "metadata"},
+		},
+		{
+			desc:     "list things in full view",
+			url:      fmt.Sprintf("%s/things?view=full", ts.URL),
+			items:    "things",
+			status:   http.StatusOK,
+			id:       sth.ID,
+			included: []string{"id", "name", "key", "metadata"},
+		},
+		{
+			desc:     "list things in compact view",
+			url:      fmt.Sprintf("%s/things?view=compact", ts.URL),
+			items:    "things",
+			status:   http.StatusOK,
+			id:       sth.ID,
+			included: []string{"id", "name"},
+			omitted:  []string{"key", "metadata"},
+		},
+		{
+			desc:   "list things in invalid view",
+			url:    fmt.Sprintf("%s/things?view=tiny", ts.URL),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:     "list channels in default view",
+			url:      fmt.Sprintf("%s/channels", ts.URL),
+			items:    "channels",
+			status:   http.StatusOK,
+			id:       sch.ID,
+			included: []string{"id", "name", "metadata"},
+		},
+		{
+			desc:     "list channels in compact view",
+			url:      fmt.Sprintf("%s/channels?view=compact", ts.URL),
+			items:    "channels",
+			status:   http.StatusOK,
+			id:       sch.ID,
+			included: []string{"id", "name"},
+			omitted:  []string{"metadata"},
+		},
+		{
+			desc:   "list channels in invalid view",
+			url:    fmt.Sprintf("%s/channels?view=tiny", ts.URL),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.status != http.StatusOK {
+			continue
+		}
+
+		var page map[string]json.RawMessage
+		err = json.NewDecoder(res.Body).Decode(&page)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		var items []map[string]interface{}
+		err = json.Unmarshal(page[tc.items], &items)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		require.Len(t, items, 1, fmt.Sprintf("%s: expected single item got %v", tc.desc, items))
+
+		item := items[0]
+		assert.Equal(t, tc.id, item["id"], fmt.Sprintf("%s: expected id %s got %v", tc.desc, tc.id, item["id"]))
+		for _, key := range tc.included {
+			assert.Contains(t, item, key, fmt.Sprintf("%s: expected %s included", tc.desc, key))
+		}
+		for _, key := range tc.omitted {
+			assert.NotContains(t, item, key, fmt.Sprintf("%s: expected %s omitted", tc.desc, key))
+		}
+	}
+}
+
+func TestListChannelsByThing(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	channels := []channelRes{}
+	for i := 0; i < 101; i++ {
 		sch, err := svc.CreateChannel(context.Background(), token, channel)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+		err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
 		chres := channelRes{
@@ -1538,6 +2372,223 @@ func TestRemoveChannel(t *testing.T) {
 	}
 }
 
+func TestRemoveProtectedChannel(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	ch := channel
+	ch.Protected = true
+	sch, _ := svc.CreateChannel(context.Background(), token, ch)
+
+	cases := []struct {
+		desc   string
+		query  string
+		status int
+	}{
+		{
+			desc:   "remove protected channel",
+			query:  "",
+			status: http.StatusConflict,
+		},
+		{
+			desc:   "remove protected channel with invalid force flag",
+			query:  "?force=yes",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "force remove protected channel",
+			query:  "?force=true",
+			status: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/channels/%s%s", ts.URL, sch.ID, tc.query),
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+	ts := newServer(svc)
+	defer ts.Close()
+
+	valid := toJSON(map[string]interface{}{"scopes": []string{"thing:read"}, "ttl": 3600})
+
+	cases := []struct {
+		desc        string
+		req         string
+		contentType string
+		auth        string
+		status      int
+	}{
+		{
+			desc:        "issue key",
+			req:         valid,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "issue non-expiring key",
+			req:         toJSON(map[string]interface{}{"scopes": []string{"*:*"}}),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "issue key without scopes",
+			req:         toJSON(map[string]interface{}{"ttl": 3600}),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "issue key with invalid scope",
+			req:         toJSON(map[string]interface{}{"scopes": []string{"user:read"}}),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "issue key with malformed request",
+			req:         "}",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "issue key with invalid token",
+			req:         valid,
+			contentType: contentType,
+			auth:        wrongValue,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "issue key without content type",
+			req:         valid,
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/keys", ts.URL),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestAPIKeyAccess(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+	ts := newServer(svc)
+	defer ts.Close()
+
+	req := testRequest{
+		client:      ts.Client(),
+		method:      http.MethodPost,
+		url:         fmt.Sprintf("%s/keys", ts.URL),
+		contentType: contentType,
+		token:       token,
+		body:        strings.NewReader(toJSON(map[string]interface{}{"scopes": []string{"thing:list"}})),
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	var key struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	listKeys := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/keys", ts.URL),
+		token:  token,
+	}
+	res, err = listKeys.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	body, err := ioutil.ReadAll(res.Body)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("list keys: expected status code %d got %d", http.StatusOK, res.StatusCode))
+	assert.Contains(t, string(body), key.ID, "list keys: expected issued key to be listed")
+	assert.NotContains(t, string(body), key.Key, "list keys: expected key value to be omitted")
+
+	cases := []struct {
+		desc   string
+		method string
+		url    string
+		auth   string
+		status int
+	}{
+		{
+			desc:   "list things using API key",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things", ts.URL),
+			auth:   key.Key,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "list channels using API key without channel scope",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels", ts.URL),
+			auth:   key.Key,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "revoke key using API key",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/keys/%s", ts.URL, key.ID),
+			auth:   key.Key,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "revoke key",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/keys/%s", ts.URL, key.ID),
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "list things using revoked API key",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things", ts.URL),
+			auth:   key.Key,
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: tc.method,
+			url:    tc.url,
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
 func TestConnect(t *testing.T) {
 	otherToken := "other_token"
 	otherEmail := "other_user@example.com"
@@ -1630,6 +2681,57 @@ func TestConnect(t *testing.T) {
 	}
 }
 
+func TestConnectWithTTL(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	ath, _ := svc.AddThing(context.Background(), token, thing)
+	ach, _ := svc.CreateChannel(context.Background(), token, channel)
+
+	cases := []struct {
+		desc   string
+		ttl    string
+		status int
+	}{
+		{
+			desc:   "connect with invalid ttl",
+			ttl:    "invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "connect with negative ttl",
+			ttl:    "-1",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "connect with ttl",
+			ttl:    "1",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodPut,
+			url:    fmt.Sprintf("%s/channels/%s/things/%s?ttl=%s", ts.URL, ach.ID, ath.ID, tc.ttl),
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+
+	err := svc.CanAccessByID(context.Background(), ach.ID, ath.ID)
+	assert.Nil(t, err, fmt.Sprintf("access before expiry: unexpected error %s", err))
+
+	time.Sleep(time.Second)
+
+	err = svc.CanAccessByID(context.Background(), ach.ID, ath.ID)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access after expiry: expected %s got %s", things.ErrUnauthorizedAccess, err))
+}
+
 func TestDisconnnect(t *testing.T) {
 	otherToken := "other_token"
 	otherEmail := "other_user@example.com"
@@ -1642,7 +2744,7 @@ func TestDisconnnect(t *testing.T) {
 
 	ath, _ := svc.AddThing(context.Background(), token, thing)
 	ach, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, ach.ID, ath.ID)
+	svc.Connect(context.Background(), token, ach.ID, ath.ID, 0)
 	bch, _ := svc.CreateChannel(context.Background(), otherToken, channel)
 
 	cases := []struct {
@@ -1730,17 +2832,190 @@ func TestDisconnnect(t *testing.T) {
 	}
 }
 
+func TestIDValidation(t *testing.T) {
+	prefix := "prod-"
+	validID := "123e4567-e89b-12d3-a456-426655440000"
+	svc := newService(map[string]string{token: email})
+	ts := httptest.NewServer(httpapi.MakeHandler(mocktracer.New(), svc, prefix, uuid.Valid))
+	defer ts.Close()
+
+	cases := []struct {
+		desc   string
+		method string
+		url    string
+		status int
+	}{
+		{
+			desc:   "view thing with valid id",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/%s%s", ts.URL, prefix, validID),
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "view thing with malformed id",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/%sinvalid", ts.URL, prefix),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "view thing with legacy id without prefix",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/%s", ts.URL, validID),
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "view thing with id carrying old prefix",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/dev-%s", ts.URL, validID),
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "view thing with id in non-canonical form",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/%s%s", ts.URL, prefix, strings.ToUpper(validID)),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "view channel with malformed id",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s%d", ts.URL, prefix, wrongID),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "remove channel with malformed id",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/channels/%s%d", ts.URL, prefix, wrongID),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "connect thing with valid ids",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("%s/channels/%s%s/things/%s%s", ts.URL, prefix, validID, prefix, validID),
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "connect thing with malformed id",
+			method: http.MethodPut,
+			url:    fmt.Sprintf("%s/channels/%s%s/things/%sinvalid", ts.URL, prefix, validID, prefix),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "disconnect thing from channel with malformed id",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/channels/%sinvalid/things/%s%s", ts.URL, prefix, prefix, validID),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "revoke API key with prefixed id",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/keys/%s%s", ts.URL, prefix, validID),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: tc.method,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	otherToken := "other_token"
+	otherEmail := "other_user@example.com"
+	svc := newService(map[string]string{token: email, otherToken: otherEmail})
+	ts := newServer(svc)
+	defer ts.Close()
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/things/events", ts.URL),
+		token:  wrongValue,
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, fmt.Sprintf("stream events with invalid token: expected status %d got %d", http.StatusForbidden, res.StatusCode))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/things/events", ts.URL), nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	r.Header.Set("Authorization", token)
+	res, err = ts.Client().Do(r.WithContext(ctx))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("stream events: expected status %d got %d", http.StatusOK, res.StatusCode))
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"), fmt.Sprintf("stream events: unexpected content type %s", res.Header.Get("Content-Type")))
+
+	// Other user's events are published first, so that they would be
+	// received first if they weren't filtered out.
+	_, err = svc.AddThing(context.Background(), otherToken, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	expected := []string{
+		toJSON(things.Event{Operation: things.ThingCreate, ID: sth.ID}),
+		toJSON(things.Event{Operation: things.ChannelCreate, ID: sch.ID}),
+		toJSON(things.Event{Operation: things.ThingConnect, ChanID: sch.ID, ThingID: sth.ID}),
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	for _, data := range expected {
+		var line string
+		for scanner.Scan() {
+			line = scanner.Text()
+			if strings.HasPrefix(line, "data: ") {
+				break
+			}
+		}
+		assert.Equal(t, fmt.Sprintf("data: %s", data), line, fmt.Sprintf("stream events: expected %s got %s", data, line))
+	}
+}
+
 type thingRes struct {
 	ID       string                 `json:"id"`
 	Name     string                 `json:"name,omitempty"`
 	Key      string                 `json:"key"`
+	LastSeen *time.Time             `json:"last_seen,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Channels *uint64                `json:"connected_channels,omitempty"`
+}
+
+type importRowRes struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+type importRes struct {
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Rows    []importRowRes `json:"rows"`
+}
+
+type nameAvailabilityRes struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
 }
 
 type channelRes struct {
-	ID       string                 `json:"id"`
-	Name     string                 `json:"name,omitempty"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Connected *uint64                `json:"connected_things,omitempty"`
 }
 
 type thingsPageRes struct {
@@ -7,10 +7,15 @@
 
 package http
 
-import "github.com/mainflux/mainflux/things"
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mainflux/mainflux/things"
+)
 
 const maxLimitSize = 100
-const maxNameSize = 1024
+const maxNameSize = things.MaxNameLength
 
 type apiReq interface {
 	validate() error
@@ -28,7 +33,34 @@ func (req addThingReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if len(req.Name) > maxNameSize {
+	if utf8.RuneCountInString(req.Name) > maxNameSize {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+// importRow is the thing read from the single row of the imported CSV file,
+// or the error the row failed to be read with.
+type importRow struct {
+	row   int
+	thing things.Thing
+	err   error
+}
+
+type importThingsReq struct {
+	token       string
+	stopOnError bool
+	csvReport   bool
+	rows        []importRow
+}
+
+func (req importThingsReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if len(req.rows) == 0 {
 		return things.ErrMalformedEntity
 	}
 
@@ -36,6 +68,7 @@ func (req addThingReq) validate() error {
 }
 
 type updateThingReq struct {
+	ids      idScheme
 	token    string
 	id       string
 	Name     string                 `json:"name,omitempty"`
@@ -47,11 +80,32 @@ func (req updateThingReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.id == "" {
+	if !req.ids.validResource(req.id) {
+		return things.ErrMalformedEntity
+	}
+
+	if utf8.RuneCountInString(req.Name) > maxNameSize {
 		return things.ErrMalformedEntity
 	}
 
-	if len(req.Name) > maxNameSize {
+	return nil
+}
+
+// patchMetadataReq carries the JSON merge patch of the thing metadata, which
+// has to be an object.
+type patchMetadataReq struct {
+	ids   idScheme
+	token string
+	id    string
+	patch map[string]interface{}
+}
+
+func (req patchMetadataReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) || req.patch == nil {
 		return things.ErrMalformedEntity
 	}
 
@@ -59,6 +113,7 @@ func (req updateThingReq) validate() error {
 }
 
 type updateKeyReq struct {
+	ids   idScheme
 	token string
 	id    string
 	Key   string `json:"key"`
@@ -69,7 +124,7 @@ func (req updateKeyReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.id == "" || req.Key == "" {
+	if !req.ids.validResource(req.id) || req.Key == "" {
 		return things.ErrMalformedEntity
 	}
 
@@ -77,9 +132,13 @@ func (req updateKeyReq) validate() error {
 }
 
 type createChannelReq struct {
-	token    string
-	Name     string                 `json:"name,omitempty"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	token      string
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Membership things.MembershipQuery `json:"membership,omitempty"`
+	Protected  bool                   `json:"protected,omitempty"`
+	Scoped     bool                   `json:"publisher_scoped,omitempty"`
 }
 
 func (req createChannelReq) validate() error {
@@ -87,7 +146,7 @@ func (req createChannelReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if len(req.Name) > maxNameSize {
+	if utf8.RuneCountInString(req.Name) > maxNameSize {
 		return things.ErrMalformedEntity
 	}
 
@@ -95,10 +154,29 @@ func (req createChannelReq) validate() error {
 }
 
 type updateChannelReq struct {
-	token    string
-	id       string
-	Name     string                 `json:"name,omitempty"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	ids        idScheme
+	token      string
+	id         string
+	ParentID   *string                `json:"parent_id,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Membership things.MembershipQuery `json:"membership,omitempty"`
+	Protected  bool                   `json:"protected,omitempty"`
+	Scoped     bool                   `json:"publisher_scoped,omitempty"`
+}
+
+// parentID maps the parent ID of the request to the one of the updated
+// channel. Missing parent ID keeps the stored parent, while the empty one
+// detaches the channel from its parent.
+func (req updateChannelReq) parentID() string {
+	switch {
+	case req.ParentID == nil:
+		return ""
+	case *req.ParentID == "":
+		return things.NoParent
+	default:
+		return *req.ParentID
+	}
 }
 
 func (req updateChannelReq) validate() error {
@@ -106,11 +184,11 @@ func (req updateChannelReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.id == "" {
+	if !req.ids.validResource(req.id) {
 		return things.ErrMalformedEntity
 	}
 
-	if len(req.Name) > maxNameSize {
+	if utf8.RuneCountInString(req.Name) > maxNameSize {
 		return things.ErrMalformedEntity
 	}
 
@@ -118,6 +196,7 @@ func (req updateChannelReq) validate() error {
 }
 
 type viewResourceReq struct {
+	ids   idScheme
 	token string
 	id    string
 }
@@ -127,7 +206,43 @@ func (req viewResourceReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.id == "" {
+	if !req.ids.validResource(req.id) {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type removeChannelReq struct {
+	ids   idScheme
+	token string
+	id    string
+	force bool
+}
+
+func (req removeChannelReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type nameAvailabilityReq struct {
+	token string
+	name  string
+}
+
+func (req nameAvailabilityReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if req.name == "" {
 		return things.ErrMalformedEntity
 	}
 
@@ -135,10 +250,17 @@ func (req viewResourceReq) validate() error {
 }
 
 type listResourcesReq struct {
-	token  string
-	offset uint64
-	limit  uint64
-	name   string
+	token    string
+	offset   uint64
+	limit    uint64
+	name     string
+	parent   string
+	view     string
+	channels []string
+}
+
+func (req listResourcesReq) compact() bool {
+	return req.view == compactView
 }
 
 func (req *listResourcesReq) validate() error {
@@ -150,14 +272,35 @@ func (req *listResourcesReq) validate() error {
 		return things.ErrMalformedEntity
 	}
 
-	if len(req.name) > maxNameSize {
+	if utf8.RuneCountInString(req.name) > maxNameSize {
+		return things.ErrMalformedEntity
+	}
+
+	if req.view != "" && req.view != fullView && req.view != compactView {
+		return things.ErrMalformedEntity
+	}
+
+	// Filtering by name is not supported when listing things connected to
+	// the channels.
+	if len(req.channels) > 0 && req.name != "" {
+		return things.ErrMalformedEntity
+	}
+
+	if len(req.channels) > maxLimitSize {
 		return things.ErrMalformedEntity
 	}
 
+	for _, id := range req.channels {
+		if id == "" {
+			return things.ErrMalformedEntity
+		}
+	}
+
 	return nil
 }
 
 type listByConnectionReq struct {
+	ids    idScheme
 	token  string
 	id     string
 	offset uint64
@@ -169,7 +312,7 @@ func (req listByConnectionReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.id == "" {
+	if !req.ids.validResource(req.id) {
 		return things.ErrMalformedEntity
 	}
 
@@ -180,10 +323,137 @@ func (req listByConnectionReq) validate() error {
 	return nil
 }
 
+type issueChannelTokenReq struct {
+	ids   idScheme
+	token string
+	id    string
+	TTL   uint64 `json:"ttl"`
+	Scope string `json:"scope"`
+}
+
+func (req issueChannelTokenReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) || req.TTL == 0 {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type createShareLinkReq struct {
+	ids   idScheme
+	token string
+	id    string
+	TTL   uint64 `json:"ttl"`
+}
+
+func (req createShareLinkReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) || req.TTL == 0 {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type reapIdleReq struct {
+	ids       idScheme
+	token     string
+	id        string
+	OlderThan uint64 `json:"older_than"`
+}
+
+func (req reapIdleReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) || req.OlderThan == 0 {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type revokeShareLinkReq struct {
+	ids       idScheme
+	token     string
+	id        string
+	linkToken string
+}
+
+func (req revokeShareLinkReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validResource(req.id) || req.linkToken == "" {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type issueAPIKeyReq struct {
+	token  string
+	Scopes []string `json:"scopes"`
+	TTL    uint64   `json:"ttl,omitempty"`
+}
+
+func (req issueAPIKeyReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !things.ValidScopes(req.Scopes) {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type listAPIKeysReq struct {
+	token string
+}
+
+func (req listAPIKeysReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	return nil
+}
+
+type revokeAPIKeyReq struct {
+	ids   idScheme
+	token string
+	id    string
+}
+
+func (req revokeAPIKeyReq) validate() error {
+	if req.token == "" {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if !req.ids.validKey(req.id) {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
+
 type connectionReq struct {
+	ids     idScheme
 	token   string
 	chanID  string
 	thingID string
+	ttl     uint64
 }
 
 func (req connectionReq) validate() error {
@@ -191,9 +461,45 @@ func (req connectionReq) validate() error {
 		return things.ErrUnauthorizedAccess
 	}
 
-	if req.chanID == "" || req.thingID == "" {
+	if !req.ids.validResource(req.chanID) || !req.ids.validResource(req.thingID) {
 		return things.ErrMalformedEntity
 	}
 
 	return nil
 }
+
+// idScheme describes the configured ID scheme of path params. Nil validator
+// only rejects empty identifiers.
+type idScheme struct {
+	prefix    string
+	validator func(string) bool
+}
+
+// validResource returns true if the thing or channel identifier consists of
+// the identifier matching the ID scheme, preceded by the configured ID prefix.
+// Identifiers without the prefix, or carrying another well-formed prefix, are
+// accepted as well, so that the resources created before the prefix was set or
+// changed remain reachable.
+func (ids idScheme) validResource(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	if ids.validator == nil || ids.validator(strings.TrimPrefix(id, ids.prefix)) {
+		return true
+	}
+
+	for i := 0; i <= len(id) && things.ValidIDPrefix(id[:i]); i++ {
+		if ids.validator(id[i:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validKey returns true if the API key identifier matches the ID scheme.
+// Unlike thing and channel identifiers, API key identifiers aren't prefixed.
+func (ids idScheme) validKey(id string) bool {
+	return id != "" && (ids.validator == nil || ids.validator(id))
+}
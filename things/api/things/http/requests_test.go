@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package http
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIDs(t *testing.T) {
+	id := "123e4567-e89b-12d3-a456-426655440000"
+	prefixed := idScheme{prefix: "prod-", validator: uuid.Valid}
+	unprefixed := idScheme{validator: uuid.Valid}
+	unvalidated := idScheme{}
+
+	cases := []struct {
+		desc string
+		req  apiReq
+		err  error
+	}{
+		{
+			desc: "view resource with valid id",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: "prod-" + id},
+			err:  nil,
+		},
+		{
+			desc: "view resource with unprefixed legacy id",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: id},
+			err:  nil,
+		},
+		{
+			desc: "view resource with id carrying old prefix",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: "dev-" + id},
+			err:  nil,
+		},
+		{
+			desc: "view resource with malformed id",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: "prod-1"},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "view resource with id carrying malformed prefix",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: "prod/" + id},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "view resource with empty id",
+			req:  viewResourceReq{ids: prefixed, token: "token", id: ""},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "view resource with empty id without validation",
+			req:  viewResourceReq{ids: unvalidated, token: "token", id: ""},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "view resource with arbitrary id without validation",
+			req:  viewResourceReq{ids: unvalidated, token: "token", id: "1"},
+			err:  nil,
+		},
+		{
+			desc: "connect with valid ids",
+			req:  connectionReq{ids: unprefixed, token: "token", chanID: id, thingID: id},
+			err:  nil,
+		},
+		{
+			desc: "connect with malformed thing id",
+			req:  connectionReq{ids: unprefixed, token: "token", chanID: id, thingID: "1"},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "connect with empty channel id",
+			req:  connectionReq{ids: unprefixed, token: "token", chanID: "", thingID: id},
+			err:  things.ErrMalformedEntity,
+		},
+		{
+			desc: "revoke API key with unprefixed id",
+			req:  revokeAPIKeyReq{ids: prefixed, token: "token", id: id},
+			err:  nil,
+		},
+		{
+			desc: "revoke API key with prefixed id",
+			req:  revokeAPIKeyReq{ids: prefixed, token: "token", id: "prod-" + id},
+			err:  things.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.req.validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
@@ -10,18 +10,29 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/things"
 )
 
 var (
 	_ mainflux.Response = (*removeRes)(nil)
 	_ mainflux.Response = (*thingRes)(nil)
 	_ mainflux.Response = (*viewThingRes)(nil)
+	_ mainflux.Response = (*metadataRes)(nil)
 	_ mainflux.Response = (*thingsPageRes)(nil)
 	_ mainflux.Response = (*channelRes)(nil)
 	_ mainflux.Response = (*viewChannelRes)(nil)
 	_ mainflux.Response = (*channelsPageRes)(nil)
+	_ mainflux.Response = (*channelTokenRes)(nil)
+	_ mainflux.Response = (*shareLinkRes)(nil)
+	_ mainflux.Response = (*reapRes)(nil)
+	_ mainflux.Response = (*apiKeyRes)(nil)
+	_ mainflux.Response = (*apiKeysRes)(nil)
+	_ mainflux.Response = (*nameAvailabilityRes)(nil)
+	_ mainflux.Response = (*importRes)(nil)
+	_ mainflux.Response = (*provisionRes)(nil)
 	_ mainflux.Response = (*connectionRes)(nil)
 	_ mainflux.Response = (*disconnectionRes)(nil)
 )
@@ -40,6 +51,21 @@ func (res removeRes) Empty() bool {
 	return true
 }
 
+// existsRes answers the HEAD request, which carries no body.
+type existsRes struct{}
+
+func (res existsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res existsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res existsRes) Empty() bool {
+	return true
+}
+
 type thingRes struct {
 	id      string
 	created bool
@@ -67,12 +93,16 @@ func (res thingRes) Empty() bool {
 	return true
 }
 
+// viewThingRes Channels count is set only when a single thing is viewed.
 type viewThingRes struct {
-	ID       string                 `json:"id"`
-	Owner    string                 `json:"-"`
-	Name     string                 `json:"name,omitempty"`
-	Key      string                 `json:"key"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	ID        string                 `json:"id"`
+	Owner     string                 `json:"-"`
+	Name      string                 `json:"name,omitempty"`
+	Key       string                 `json:"key"`
+	KeyExpiry *time.Time             `json:"key_expiry,omitempty"`
+	LastSeen  *time.Time             `json:"last_seen,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Channels  *uint64                `json:"connected_channels,omitempty"`
 }
 
 func (res viewThingRes) Code() int {
@@ -87,6 +117,23 @@ func (res viewThingRes) Empty() bool {
 	return false
 }
 
+// metadataRes carries the thing metadata resulting from the patch.
+type metadataRes struct {
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+func (res metadataRes) Code() int {
+	return http.StatusOK
+}
+
+func (res metadataRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res metadataRes) Empty() bool {
+	return false
+}
+
 type thingsPageRes struct {
 	pageRes
 	Things []viewThingRes `json:"things"`
@@ -104,6 +151,29 @@ func (res thingsPageRes) Empty() bool {
 	return false
 }
 
+// compactRes is the compact view of the listed thing or channel.
+type compactRes struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type compactThingsPageRes struct {
+	pageRes
+	Things []compactRes `json:"things"`
+}
+
+func (res compactThingsPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res compactThingsPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res compactThingsPageRes) Empty() bool {
+	return false
+}
+
 type channelRes struct {
 	id      string
 	created bool
@@ -131,12 +201,18 @@ func (res channelRes) Empty() bool {
 	return true
 }
 
+// viewChannelRes Connected count is set only when a single channel is viewed.
 type viewChannelRes struct {
-	ID       string                 `json:"id"`
-	Owner    string                 `json:"-"`
-	Name     string                 `json:"name,omitempty"`
-	Things   []viewThingRes         `json:"connected,omitempty"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	ID         string                 `json:"id"`
+	Owner      string                 `json:"-"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	Things     []viewThingRes         `json:"connected,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Membership things.MembershipQuery `json:"membership,omitempty"`
+	Protected  bool                   `json:"protected,omitempty"`
+	Scoped     bool                   `json:"publisher_scoped,omitempty"`
+	Connected  *uint64                `json:"connected_things,omitempty"`
 }
 
 func (res viewChannelRes) Code() int {
@@ -168,6 +244,197 @@ func (res channelsPageRes) Empty() bool {
 	return false
 }
 
+type compactChannelsPageRes struct {
+	pageRes
+	Channels []compactRes `json:"channels"`
+}
+
+func (res compactChannelsPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res compactChannelsPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res compactChannelsPageRes) Empty() bool {
+	return false
+}
+
+type channelTokenRes struct {
+	Token string `json:"token"`
+}
+
+func (res channelTokenRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res channelTokenRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res channelTokenRes) Empty() bool {
+	return false
+}
+
+type shareLinkRes struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (res shareLinkRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res shareLinkRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res shareLinkRes) Empty() bool {
+	return false
+}
+
+type apiKeyRes struct {
+	ID        string     `json:"id"`
+	Key       string     `json:"key,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	created   bool
+}
+
+func newAPIKeyRes(key things.APIKey, created bool) apiKeyRes {
+	res := apiKeyRes{
+		ID:       key.ID,
+		Key:      key.Key,
+		Scopes:   key.Scopes,
+		IssuedAt: key.IssuedAt,
+		created:  created,
+	}
+	if !key.ExpiresAt.IsZero() {
+		res.ExpiresAt = &key.ExpiresAt
+	}
+
+	return res
+}
+
+func (res apiKeyRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res apiKeyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res apiKeyRes) Empty() bool {
+	return false
+}
+
+type apiKeysRes struct {
+	Keys []apiKeyRes `json:"keys"`
+}
+
+func (res apiKeysRes) Code() int {
+	return http.StatusOK
+}
+
+func (res apiKeysRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res apiKeysRes) Empty() bool {
+	return false
+}
+
+type importRowRes struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+type importRes struct {
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Rows    []importRowRes `json:"rows"`
+	csv     bool
+}
+
+func (res importRes) Code() int {
+	return http.StatusOK
+}
+
+func (res importRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res importRes) Empty() bool {
+	return false
+}
+
+type provisionedChannelRes struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Connected bool   `json:"connected"`
+}
+
+type provisionRes struct {
+	Thing    viewThingRes            `json:"thing"`
+	Channels []provisionedChannelRes `json:"channels"`
+}
+
+func (res provisionRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res provisionRes) Headers() map[string]string {
+	return map[string]string{
+		"Location": fmt.Sprintf("/things/%s", res.Thing.ID),
+	}
+}
+
+func (res provisionRes) Empty() bool {
+	return false
+}
+
+type nameAvailabilityRes struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+}
+
+func (res nameAvailabilityRes) Code() int {
+	return http.StatusOK
+}
+
+func (res nameAvailabilityRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res nameAvailabilityRes) Empty() bool {
+	return false
+}
+
+type reapRes struct {
+	Things []string `json:"things"`
+}
+
+func (res reapRes) Code() int {
+	return http.StatusOK
+}
+
+func (res reapRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res reapRes) Empty() bool {
+	return false
+}
+
 type connectionRes struct{}
 
 func (res connectionRes) Code() int {
@@ -201,3 +468,10 @@ type pageRes struct {
 	Offset uint64 `json:"offset"`
 	Limit  uint64 `json:"limit"`
 }
+
+// errorRes is the body of the error responses. Code identifies the error and,
+// unlike the message, is guaranteed to stay the same.
+type errorRes struct {
+	Err  string `json:"error"`
+	Code string `json:"code"`
+}
@@ -9,8 +9,10 @@ package http
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -27,9 +29,23 @@ import (
 
 const (
 	contentType = "application/json"
+	patchType   = "application/merge-patch+json"
+	eventsType  = "text/event-stream"
+	csvType     = "text/csv"
 	offset      = "offset"
 	limit       = "limit"
 	name        = "name"
+	parent      = "parent"
+	view        = "view"
+	channel     = "channel"
+	stopOnError = "stop_on_error"
+	ttl         = "ttl"
+
+	fullView    = "full"
+	compactView = "compact"
+
+	metadataColumn = "metadata"
+	idColumn       = "id"
 
 	defOffset = 0
 	defLimit  = 10
@@ -41,7 +57,14 @@ var (
 )
 
 // MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
+//
+// Thing and channel identifiers have to consist of the identifier accepted by
+// the validator, optionally preceded by the ID prefix, while API key
+// identifiers aren't prefixed. Malformed identifiers are rejected before
+// reaching the service.
+func MakeHandler(tracer opentracing.Tracer, svc things.Service, prefix string, validator func(string) bool) http.Handler {
+	ids := idScheme{prefix: prefix, validator: validator}
+
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(encodeError),
 	}
@@ -55,44 +78,83 @@ func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
 		opts...,
 	))
 
+	r.Post("/things/provision", kithttp.NewServer(
+		kitot.TraceServer(tracer, "provision_thing")(provisionThingEndpoint(svc)),
+		decodeThingCreation,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Post("/things/import", kithttp.NewServer(
+		kitot.TraceServer(tracer, "import_things")(importThingsEndpoint(svc)),
+		decodeImportThings,
+		encodeResponse,
+		opts...,
+	))
+
 	r.Patch("/things/:id/key", kithttp.NewServer(
 		kitot.TraceServer(tracer, "update_key")(updateKeyEndpoint(svc)),
-		decodeKeyUpdate,
+		decodeKeyUpdate(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Patch("/things/:id/metadata", kithttp.NewServer(
+		kitot.TraceServer(tracer, "patch_thing_metadata")(patchThingMetadataEndpoint(svc)),
+		decodeMetadataPatch(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Put("/things/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "update_thing")(updateThingEndpoint(svc)),
-		decodeThingUpdate,
+		decodeThingUpdate(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Delete("/things/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "remove_thing")(removeThingEndpoint(svc)),
-		decodeView,
+		decodeView(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	// Events and availability routes have to be registered before the
+	// routes of the single thing, so that they aren't matched as thing IDs.
+	r.Get("/things/events", streamEvents(svc))
+
+	r.Get("/things/available", kithttp.NewServer(
+		kitot.TraceServer(tracer, "name_available")(nameAvailabilityEndpoint(svc)),
+		decodeNameAvailability,
 		encodeResponse,
 		opts...,
 	))
 
 	r.Get("/things/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_thing")(viewThingEndpoint(svc)),
-		decodeView,
+		decodeView(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Head("/things/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "thing_exists")(thingExistsEndpoint(svc)),
+		decodeView(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Get("/things/:id/channels", kithttp.NewServer(
 		kitot.TraceServer(tracer, "list_channels_by_thing")(listChannelsByThingEndpoint(svc)),
-		decodeListByConnection,
+		decodeListByConnection(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Get("/things", kithttp.NewServer(
 		kitot.TraceServer(tracer, "list_things")(listThingsEndpoint(svc)),
-		decodeList,
+		decodeListThings,
 		encodeResponse,
 		opts...,
 	))
@@ -106,28 +168,35 @@ func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
 
 	r.Put("/channels/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "update_channel")(updateChannelEndpoint(svc)),
-		decodeChannelUpdate,
+		decodeChannelUpdate(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Delete("/channels/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "remove_channel")(removeChannelEndpoint(svc)),
-		decodeView,
+		decodeRemoveChannel(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Get("/channels/:id", kithttp.NewServer(
 		kitot.TraceServer(tracer, "view_channel")(viewChannelEndpoint(svc)),
-		decodeView,
+		decodeView(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Head("/channels/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "channel_exists")(channelExistsEndpoint(svc)),
+		decodeView(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Get("/channels/:id/things", kithttp.NewServer(
 		kitot.TraceServer(tracer, "list_things_by_channel")(listThingsByChannelEndpoint(svc)),
-		decodeListByConnection,
+		decodeListByConnection(ids),
 		encodeResponse,
 		opts...,
 	))
@@ -139,16 +208,72 @@ func MakeHandler(tracer opentracing.Tracer, svc things.Service) http.Handler {
 		opts...,
 	))
 
+	r.Post("/channels/:id/tokens", kithttp.NewServer(
+		kitot.TraceServer(tracer, "issue_channel_token")(issueChannelTokenEndpoint(svc)),
+		decodeChannelToken(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Delete("/channels/:id/tokens", kithttp.NewServer(
+		kitot.TraceServer(tracer, "revoke_channel_tokens")(revokeChannelTokensEndpoint(svc)),
+		decodeView(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Post("/channels/:id/share", kithttp.NewServer(
+		kitot.TraceServer(tracer, "create_share_link")(createShareLinkEndpoint(svc)),
+		decodeShareLink(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Delete("/channels/:id/share/:token", kithttp.NewServer(
+		kitot.TraceServer(tracer, "revoke_share_link")(revokeShareLinkEndpoint(svc)),
+		decodeRevokeShareLink(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Post("/channels/:id/reap", kithttp.NewServer(
+		kitot.TraceServer(tracer, "reap_idle")(reapIdleEndpoint(svc)),
+		decodeReapIdle(ids),
+		encodeResponse,
+		opts...,
+	))
+
 	r.Put("/channels/:chanId/things/:thingId", kithttp.NewServer(
 		kitot.TraceServer(tracer, "connect")(connectEndpoint(svc)),
-		decodeConnection,
+		decodeConnect(ids),
 		encodeResponse,
 		opts...,
 	))
 
 	r.Delete("/channels/:chanId/things/:thingId", kithttp.NewServer(
 		kitot.TraceServer(tracer, "disconnect")(disconnectEndpoint(svc)),
-		decodeConnection,
+		decodeConnection(ids),
+		encodeResponse,
+		opts...,
+	))
+
+	r.Post("/keys", kithttp.NewServer(
+		kitot.TraceServer(tracer, "issue_api_key")(issueAPIKeyEndpoint(svc)),
+		decodeAPIKey,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Get("/keys", kithttp.NewServer(
+		kitot.TraceServer(tracer, "list_api_keys")(listAPIKeysEndpoint(svc)),
+		decodeListAPIKeys,
+		encodeResponse,
+		opts...,
+	))
+
+	r.Delete("/keys/:id", kithttp.NewServer(
+		kitot.TraceServer(tracer, "revoke_api_key")(revokeAPIKeyEndpoint(svc)),
+		decodeRevokeAPIKey(ids),
 		encodeResponse,
 		opts...,
 	))
@@ -172,36 +297,152 @@ func decodeThingCreation(_ context.Context, r *http.Request) (interface{}, error
 	return req, nil
 }
 
-func decodeThingUpdate(_ context.Context, r *http.Request) (interface{}, error) {
-	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+// decodeImportThings reads the things from the CSV file whose header names
+// the columns. Name column is required, while metadata and ID columns are
+// optional. Malformed rows don't fail the request, but are reported back.
+func decodeImportThings(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), csvType) {
 		return nil, errUnsupportedContentType
 	}
 
-	req := updateThingReq{
-		token: r.Header.Get("Authorization"),
-		id:    bone.GetValue(r, "id"),
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	stop, err := readBoolQuery(r, stopOnError, false)
+	if err != nil {
 		return nil, err
 	}
 
+	req := importThingsReq{
+		token:       r.Header.Get("Authorization"),
+		stopOnError: stop,
+		csvReport:   strings.Contains(r.Header.Get("Accept"), csvType),
+		rows:        []importRow{},
+	}
+
+	cr := csv.NewReader(r.Body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, things.ErrMalformedEntity
+	}
+
+	columns := map[string]int{}
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if _, ok := columns[col]; ok {
+			return nil, things.ErrMalformedEntity
+		}
+		switch col {
+		case name, metadataColumn, idColumn:
+			columns[col] = i
+		default:
+			return nil, things.ErrMalformedEntity
+		}
+	}
+	if _, ok := columns[name]; !ok {
+		return nil, things.ErrMalformedEntity
+	}
+
+	// Header is the first row of the file.
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Reading can't recover from the malformed quoting, so only the
+			// rows with the wrong number of fields are skipped.
+			if pe, ok := err.(*csv.ParseError); !ok || pe.Err != csv.ErrFieldCount {
+				return nil, things.ErrMalformedEntity
+			}
+		}
+
+		req.rows = append(req.rows, readImportRow(row, record, len(header), columns))
+	}
+
 	return req, nil
 }
 
-func decodeKeyUpdate(_ context.Context, r *http.Request) (interface{}, error) {
-	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
-		return nil, errUnsupportedContentType
+func readImportRow(row int, record []string, fields int, columns map[string]int) importRow {
+	if len(record) != fields {
+		return importRow{row: row, err: things.ErrMalformedEntity}
 	}
 
-	req := updateKeyReq{
-		token: r.Header.Get("Authorization"),
-		id:    bone.GetValue(r, "id"),
+	thing := things.Thing{
+		Name: record[columns[name]],
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return nil, err
+
+	if i, ok := columns[idColumn]; ok {
+		thing.ID = strings.TrimSpace(record[i])
 	}
 
-	return req, nil
+	if i, ok := columns[metadataColumn]; ok && strings.TrimSpace(record[i]) != "" {
+		if err := json.Unmarshal([]byte(record[i]), &thing.Metadata); err != nil {
+			return importRow{row: row, thing: thing, err: things.ErrMalformedEntity}
+		}
+	}
+
+	return importRow{row: row, thing: thing}
+}
+
+func decodeThingUpdate(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := updateThingReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+func decodeKeyUpdate(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := updateKeyReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+// decodeMetadataPatch accepts the merge patch sent either as the
+// application/merge-patch+json or as the plain JSON.
+func decodeMetadataPatch(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		ct := r.Header.Get("Content-Type")
+		if !strings.Contains(ct, contentType) && !strings.Contains(ct, patchType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := patchMetadataReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req.patch); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
 }
 
 func decodeChannelCreation(_ context.Context, r *http.Request) (interface{}, error) {
@@ -217,26 +458,64 @@ func decodeChannelCreation(_ context.Context, r *http.Request) (interface{}, err
 	return req, nil
 }
 
-func decodeChannelUpdate(_ context.Context, r *http.Request) (interface{}, error) {
-	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
-		return nil, errUnsupportedContentType
-	}
+func decodeChannelUpdate(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
 
-	req := updateChannelReq{
-		token: r.Header.Get("Authorization"),
-		id:    bone.GetValue(r, "id"),
+		req := updateChannelReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return nil, err
+}
+
+func decodeView(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		req := viewResourceReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+
+		return req, nil
 	}
+}
 
-	return req, nil
+func decodeRemoveChannel(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		force, err := readBoolQuery(r, "force", false)
+		if err != nil {
+			return nil, err
+		}
+
+		req := removeChannelReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+			force: force,
+		}
+
+		return req, nil
+	}
 }
 
-func decodeView(_ context.Context, r *http.Request) (interface{}, error) {
-	req := viewResourceReq{
+func decodeNameAvailability(_ context.Context, r *http.Request) (interface{}, error) {
+	n, err := readStringQuery(r, name)
+	if err != nil {
+		return nil, err
+	}
+
+	req := nameAvailabilityReq{
 		token: r.Header.Get("Authorization"),
-		id:    bone.GetValue(r, "id"),
+		name:  n,
 	}
 
 	return req, nil
@@ -258,48 +537,202 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 		return nil, err
 	}
 
+	p, err := readStringQuery(r, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := readStringQuery(r, view)
+	if err != nil {
+		return nil, err
+	}
+
 	req := listResourcesReq{
 		token:  r.Header.Get("Authorization"),
 		offset: o,
 		limit:  l,
 		name:   n,
+		parent: p,
+		view:   v,
 	}
 
 	return req, nil
 }
 
-func decodeListByConnection(_ context.Context, r *http.Request) (interface{}, error) {
-	o, err := readUintQuery(r, offset, defOffset)
+func decodeListThings(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeList(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 
-	l, err := readUintQuery(r, limit, defLimit)
-	if err != nil {
-		return nil, err
+	lr := req.(listResourcesReq)
+	lr.channels = bone.GetQuery(r, channel)
+
+	return lr, nil
+}
+
+func decodeListByConnection(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		o, err := readUintQuery(r, offset, defOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		l, err := readUintQuery(r, limit, defLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		req := listByConnectionReq{
+			ids:    ids,
+			token:  r.Header.Get("Authorization"),
+			id:     bone.GetValue(r, "id"),
+			offset: o,
+			limit:  l,
+		}
+
+		return req, nil
 	}
+}
 
-	req := listByConnectionReq{
-		token:  r.Header.Get("Authorization"),
-		id:     bone.GetValue(r, "id"),
-		offset: o,
-		limit:  l,
+func decodeChannelToken(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := issueChannelTokenReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
 	}
+}
 
-	return req, nil
+func decodeShareLink(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := createShareLinkReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
 }
 
-func decodeConnection(_ context.Context, r *http.Request) (interface{}, error) {
-	req := connectionReq{
-		token:   r.Header.Get("Authorization"),
-		chanID:  bone.GetValue(r, "chanId"),
-		thingID: bone.GetValue(r, "thingId"),
+func decodeReapIdle(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+			return nil, errUnsupportedContentType
+		}
+
+		req := reapIdleReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, err
+		}
+
+		return req, nil
+	}
+}
+
+func decodeRevokeShareLink(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		req := revokeShareLinkReq{
+			ids:       ids,
+			token:     r.Header.Get("Authorization"),
+			id:        bone.GetValue(r, "id"),
+			linkToken: bone.GetValue(r, "token"),
+		}
+
+		return req, nil
+	}
+}
+
+func decodeAPIKey(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := issueAPIKeyReq{token: r.Header.Get("Authorization")}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
 	}
 
 	return req, nil
 }
 
-func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+func decodeListAPIKeys(_ context.Context, r *http.Request) (interface{}, error) {
+	req := listAPIKeysReq{token: r.Header.Get("Authorization")}
+
+	return req, nil
+}
+
+func decodeRevokeAPIKey(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		req := revokeAPIKeyReq{
+			ids:   ids,
+			token: r.Header.Get("Authorization"),
+			id:    bone.GetValue(r, "id"),
+		}
+
+		return req, nil
+	}
+}
+
+func decodeConnection(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		req := connectionReq{
+			ids:     ids,
+			token:   r.Header.Get("Authorization"),
+			chanID:  bone.GetValue(r, "chanId"),
+			thingID: bone.GetValue(r, "thingId"),
+		}
+
+		return req, nil
+	}
+}
+
+func decodeConnect(ids idScheme) kithttp.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		t, err := readUintQuery(r, ttl, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		req := connectionReq{
+			ids:     ids,
+			token:   r.Header.Get("Authorization"),
+			chanID:  bone.GetValue(r, "chanId"),
+			thingID: bone.GetValue(r, "thingId"),
+			ttl:     t,
+		}
+
+		return req, nil
+	}
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if res, ok := response.(importRes); ok && res.csv {
+		return encodeImportReport(ctx, w, res)
+	}
+
 	w.Header().Set("Content-Type", contentType)
 
 	if ar, ok := response.(mainflux.Response); ok {
@@ -317,35 +750,124 @@ func encodeResponse(_ context.Context, w http.ResponseWriter, response interface
 	return json.NewEncoder(w).Encode(response)
 }
 
+// encodeImportReport writes the import report as the CSV file, with a row for
+// every imported row of the uploaded file.
+func encodeImportReport(_ context.Context, w http.ResponseWriter, res importRes) error {
+	w.Header().Set("Content-Type", csvType)
+	w.WriteHeader(res.Code())
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "id", "error"}); err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+		if err := cw.Write([]string{strconv.Itoa(row.Row), row.ID, row.Error}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// streamEvents returns the handler streaming the topology events of the user's
+// things and channels as server-sent events, until the client disconnects.
+func streamEvents(svc things.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			encodeError(ctx, errors.New("streaming unsupported"), w)
+			return
+		}
+
+		events, err := svc.Subscribe(ctx, r.Header.Get("Authorization"))
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+
+		w.Header().Set("Content-Type", eventsType)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Events channel is closed once the request context is canceled,
+		// i.e. when the client disconnects.
+		for event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Operation, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	status, code := errorStatus(err)
+	msg, _ := errorMessage(err)
+
 	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorRes{Err: msg, Code: code})
+}
+
+// errorMessage returns the error message sent to the client and the stable
+// error code. Unexpected errors may reveal the internals of the service (e.g.
+// the database errors), so their messages aren't sent to the client.
+func errorMessage(err error) (string, string) {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		return http.StatusText(status), code
+	}
 
+	return err.Error(), code
+}
+
+// errorStatus returns the HTTP status of the error response and the stable
+// error code clients can switch on.
+func errorStatus(err error) (int, string) {
 	switch err {
 	case things.ErrMalformedEntity:
-		w.WriteHeader(http.StatusBadRequest)
+		return http.StatusBadRequest, "malformed_entity"
 	case things.ErrUnauthorizedAccess:
-		w.WriteHeader(http.StatusForbidden)
+		return http.StatusForbidden, "unauthorized_access"
 	case things.ErrNotFound:
-		w.WriteHeader(http.StatusNotFound)
+		return http.StatusNotFound, "not_found"
 	case things.ErrConflict:
-		w.WriteHeader(http.StatusUnprocessableEntity)
+		return http.StatusUnprocessableEntity, "conflict"
+	case things.ErrChannelTokensDisabled:
+		return http.StatusNotImplemented, "channel_tokens_disabled"
+	case things.ErrShareLinksDisabled:
+		return http.StatusNotImplemented, "share_links_disabled"
+	case things.ErrAPIKeysDisabled:
+		return http.StatusNotImplemented, "api_keys_disabled"
+	case things.ErrRateLimited:
+		return http.StatusTooManyRequests, "rate_limited"
+	case things.ErrDynamicChannel:
+		return http.StatusConflict, "dynamic_channel"
+	case things.ErrProtected:
+		return http.StatusConflict, "protected_channel"
 	case errUnsupportedContentType:
-		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return http.StatusUnsupportedMediaType, "unsupported_content_type"
 	case errInvalidQueryParams:
-		w.WriteHeader(http.StatusBadRequest)
-	case io.ErrUnexpectedEOF:
-		w.WriteHeader(http.StatusBadRequest)
-	case io.EOF:
-		w.WriteHeader(http.StatusBadRequest)
+		return http.StatusBadRequest, "invalid_query_params"
+	case io.ErrUnexpectedEOF, io.EOF:
+		return http.StatusBadRequest, "malformed_request"
+	}
+
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return http.StatusBadRequest, "malformed_request"
 	default:
-		switch err.(type) {
-		case *json.SyntaxError:
-			w.WriteHeader(http.StatusBadRequest)
-		case *json.UnmarshalTypeError:
-			w.WriteHeader(http.StatusBadRequest)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		return http.StatusInternalServerError, "internal_error"
 	}
 }
 
@@ -368,6 +890,24 @@ func readUintQuery(r *http.Request, key string, def uint64) (uint64, error) {
 	return val, nil
 }
 
+func readBoolQuery(r *http.Request, key string, def bool) (bool, error) {
+	vals := bone.GetQuery(r, key)
+	if len(vals) > 1 {
+		return false, errInvalidQueryParams
+	}
+
+	if len(vals) == 0 {
+		return def, nil
+	}
+
+	val, err := strconv.ParseBool(vals[0])
+	if err != nil {
+		return false, errInvalidQueryParams
+	}
+
+	return val, nil
+}
+
 func readStringQuery(r *http.Request, key string) (string, error) {
 	vals := bone.GetQuery(r, key)
 	if len(vals) > 1 {
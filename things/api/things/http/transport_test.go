@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+		msg    string
+	}{
+		{things.ErrMalformedEntity, http.StatusBadRequest, "malformed_entity", things.ErrMalformedEntity.Error()},
+		{things.ErrUnauthorizedAccess, http.StatusForbidden, "unauthorized_access", things.ErrUnauthorizedAccess.Error()},
+		{things.ErrNotFound, http.StatusNotFound, "not_found", things.ErrNotFound.Error()},
+		{things.ErrConflict, http.StatusUnprocessableEntity, "conflict", things.ErrConflict.Error()},
+		{things.ErrChannelTokensDisabled, http.StatusNotImplemented, "channel_tokens_disabled", things.ErrChannelTokensDisabled.Error()},
+		{things.ErrShareLinksDisabled, http.StatusNotImplemented, "share_links_disabled", things.ErrShareLinksDisabled.Error()},
+		{things.ErrAPIKeysDisabled, http.StatusNotImplemented, "api_keys_disabled", things.ErrAPIKeysDisabled.Error()},
+		{things.ErrRateLimited, http.StatusTooManyRequests, "rate_limited", things.ErrRateLimited.Error()},
+		{errUnsupportedContentType, http.StatusUnsupportedMediaType, "unsupported_content_type", errUnsupportedContentType.Error()},
+		{errInvalidQueryParams, http.StatusBadRequest, "invalid_query_params", errInvalidQueryParams.Error()},
+		{io.EOF, http.StatusBadRequest, "malformed_request", io.EOF.Error()},
+		{&json.SyntaxError{}, http.StatusBadRequest, "malformed_request", (&json.SyntaxError{}).Error()},
+		{errors.New("connection refused"), http.StatusInternalServerError, "internal_error", http.StatusText(http.StatusInternalServerError)},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		encodeError(context.Background(), tc.err, w)
+
+		var res errorRes
+		err := json.NewDecoder(w.Body).Decode(&res)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.err, err))
+		assert.Equal(t, tc.status, w.Code, fmt.Sprintf("%s: expected status code %d got %d", tc.err, tc.status, w.Code))
+		assert.Equal(t, tc.code, res.Code, fmt.Sprintf("%s: expected code %s got %s", tc.err, tc.code, res.Code))
+		assert.Equal(t, tc.msg, res.Err, fmt.Sprintf("%s: expected message %s got %s", tc.err, tc.msg, res.Err))
+	}
+}
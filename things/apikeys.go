@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix prefixes the API key values, which distinguishes them from
+// the user tokens.
+const APIKeyPrefix = "mfk_"
+
+// AnyScope is the wildcard matching any resource type or any action.
+const AnyScope = "*"
+
+const scopeSeparator = ":"
+
+var (
+	scopeResources = map[string]bool{
+		ThingResource:   true,
+		ChannelResource: true,
+		AnyScope:        true,
+	}
+
+	scopeActions = map[string]bool{
+		CreateAction:  true,
+		ReadAction:    true,
+		ListAction:    true,
+		UpdateAction:  true,
+		DeleteAction:  true,
+		ConnectAction: true,
+		ShareAction:   true,
+		AnyScope:      true,
+	}
+)
+
+// APIKey is the credential used by the automation instead of the user token.
+// It acts on behalf of its owner, but only for the operations granted by its
+// scopes. Scope has the form <resource>:<action>, e.g. thing:read, where
+// either part can be the AnyScope wildcard. Only the hash of the key value is
+// persisted, the value itself is exposed once, when the key is issued.
+type APIKey struct {
+	ID        string
+	Key       string
+	Owner     string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the key has an expiry time and is no longer valid
+// at the given time.
+func (key APIKey) Expired(now time.Time) bool {
+	return !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt)
+}
+
+// Grants returns true if any of the key scopes allows performing the action
+// on the resource of the given type.
+func (key APIKey) Grants(action, resource string) bool {
+	for _, scope := range key.Scopes {
+		parts := strings.SplitN(scope, scopeSeparator, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if (parts[0] == AnyScope || parts[0] == resource) && (parts[1] == AnyScope || parts[1] == action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidScopes returns true if there is at least one scope and all of the
+// scopes refer to the known resource types and actions.
+func ValidScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+
+	for _, scope := range scopes {
+		parts := strings.SplitN(scope, scopeSeparator, 2)
+		if len(parts) != 2 || !scopeResources[parts[0]] || !scopeActions[parts[1]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// APIKeyRepository specifies an API key persistence API.
+type APIKeyRepository interface {
+	// Save persists the API key, whose Key holds the hash of the key value.
+	Save(context.Context, APIKey) error
+
+	// RetrieveByKey retrieves the API key having the provided value hash.
+	RetrieveByKey(context.Context, string) (APIKey, error)
+
+	// RetrieveAll retrieves the API keys that belong to the specified user.
+	RetrieveAll(context.Context, string) ([]APIKey, error)
+
+	// Remove removes the API key identified by the provided ID, that
+	// belongs to the specified user.
+	Remove(context.Context, string, string) error
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "context"
+
+// Actions performed on the things and channels.
+const (
+	CreateAction  = "create"
+	ReadAction    = "read"
+	ListAction    = "list"
+	UpdateAction  = "update"
+	DeleteAction  = "delete"
+	ConnectAction = "connect"
+	ShareAction   = "share"
+)
+
+// Types of the authorized resources.
+const (
+	ThingResource   = "thing"
+	ChannelResource = "channel"
+)
+
+// Resource identifies the thing or the channel the action is performed on.
+// ID and Owner are empty for the actions that don't target a single entity,
+// i.e. for creating and listing. Otherwise, the owner of the entity is
+// resolved by the service before the authorizer is consulted, and the
+// operation is performed on the entities of that owner.
+type Resource struct {
+	Type  string
+	ID    string
+	Owner string
+}
+
+// Authorizer specifies an API for authorization policy engines consulted
+// by the service for every operation performed on behalf of a user.
+type Authorizer interface {
+	// Authorize returns ErrUnauthorizedAccess, or ErrNotFound if the
+	// existence of the resource mustn't be disclosed, if the subject,
+	// identified by the user ID, is not allowed to perform the action on
+	// the resource.
+	Authorize(context.Context, string, string, Resource) error
+}
+
+var _ Authorizer = (*ownerAuthorizer)(nil)
+
+type ownerAuthorizer struct{}
+
+// NewOwnerAuthorizer returns the default authorizer, which allows users to
+// perform any action on the things and channels they own. The entities of
+// other users are reported as not found.
+func NewOwnerAuthorizer() Authorizer {
+	return ownerAuthorizer{}
+}
+
+func (oa ownerAuthorizer) Authorize(_ context.Context, subject, _ string, res Resource) error {
+	if res.ID != "" && res.Owner != subject {
+		return ErrNotFound
+	}
+
+	return nil
+}
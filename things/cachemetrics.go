@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+var (
+	_ ChannelCache = (*channelCacheMetrics)(nil)
+	_ ThingCache   = (*thingCacheMetrics)(nil)
+)
+
+type channelCacheMetrics struct {
+	cache  ChannelCache
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// ChannelCacheMetrics instruments the channel cache by counting the lookups of
+// the connections that hit and that miss the cache. Counters are labeled by
+// the cache name, i.e. channel.
+func ChannelCacheMetrics(cache ChannelCache, hits, misses metrics.Counter) ChannelCache {
+	return channelCacheMetrics{
+		cache:  cache,
+		hits:   hits.With("cache", "channel"),
+		misses: misses.With("cache", "channel"),
+	}
+}
+
+func (ccm channelCacheMetrics) Connect(ctx context.Context, chanID, thingID string) error {
+	return ccm.cache.Connect(ctx, chanID, thingID)
+}
+
+func (ccm channelCacheMetrics) HasThing(ctx context.Context, chanID, thingID string) bool {
+	connected := ccm.cache.HasThing(ctx, chanID, thingID)
+	if connected {
+		ccm.hits.Add(1)
+	} else {
+		ccm.misses.Add(1)
+	}
+
+	return connected
+}
+
+func (ccm channelCacheMetrics) Disconnect(ctx context.Context, chanID, thingID string) error {
+	return ccm.cache.Disconnect(ctx, chanID, thingID)
+}
+
+func (ccm channelCacheMetrics) Remove(ctx context.Context, chanID string) error {
+	return ccm.cache.Remove(ctx, chanID)
+}
+
+type thingCacheMetrics struct {
+	cache  ThingCache
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// ThingCacheMetrics instruments the thing cache by counting the lookups of
+// the thing keys that hit and that miss the cache. Counters are labeled by
+// the cache name, i.e. thing.
+func ThingCacheMetrics(cache ThingCache, hits, misses metrics.Counter) ThingCache {
+	return thingCacheMetrics{
+		cache:  cache,
+		hits:   hits.With("cache", "thing"),
+		misses: misses.With("cache", "thing"),
+	}
+}
+
+func (tcm thingCacheMetrics) Save(ctx context.Context, key, id string) error {
+	return tcm.cache.Save(ctx, key, id)
+}
+
+func (tcm thingCacheMetrics) ID(ctx context.Context, key string) (string, error) {
+	id, err := tcm.cache.ID(ctx, key)
+	if err != nil {
+		tcm.misses.Add(1)
+		return "", err
+	}
+
+	tcm.hits.Add(1)
+	return id, nil
+}
+
+func (tcm thingCacheMetrics) Remove(ctx context.Context, id string) error {
+	return tcm.cache.Remove(ctx, id)
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counter records the counts by the label values it is bound to.
+type counter struct {
+	mu     *sync.Mutex
+	counts map[string]float64
+	labels string
+}
+
+func newCounter() counter {
+	return counter{
+		mu:     &sync.Mutex{},
+		counts: make(map[string]float64),
+	}
+}
+
+func (c counter) With(labelValues ...string) metrics.Counter {
+	c.labels = strings.Join(append([]string{c.labels}, labelValues...), ",")
+	return c
+}
+
+func (c counter) Add(delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.labels] += delta
+}
+
+func (c counter) count(cache string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[fmt.Sprintf(",cache,%s", cache)]
+}
+
+func TestCacheMetrics(t *testing.T) {
+	hits, misses := newCounter(), newCounter()
+
+	users := mocks.NewUsersService(map[string]string{token: email})
+	conns := make(chan mocks.Connection)
+	thingsRepo := mocks.NewThingRepository(conns)
+	channelsRepo := mocks.NewChannelRepository(thingsRepo, conns)
+	chanCache := things.ChannelCacheMetrics(mocks.NewChannelCache(), hits, misses)
+	thingCache := things.ThingCacheMetrics(mocks.NewThingCache(), hits, misses)
+	svc := things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, mocks.NewIdentityProvider())
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc          string
+		thingHits     float64
+		thingMisses   float64
+		channelHits   float64
+		channelMisses float64
+	}{
+		{
+			desc:        "access missing the cache",
+			thingMisses: 1,
+		},
+		{
+			desc:        "repeated access hitting the cache",
+			thingHits:   1,
+			thingMisses: 1,
+			channelHits: 1,
+		},
+		{
+			desc:        "another access hitting the cache",
+			thingHits:   2,
+			thingMisses: 1,
+			channelHits: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.CanAccess(context.Background(), sch.ID, sth.Key)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		assert.Equal(t, tc.thingHits, hits.count("thing"), fmt.Sprintf("%s: expected %v thing cache hits got %v", tc.desc, tc.thingHits, hits.count("thing")))
+		assert.Equal(t, tc.thingMisses, misses.count("thing"), fmt.Sprintf("%s: expected %v thing cache misses got %v", tc.desc, tc.thingMisses, misses.count("thing")))
+		assert.Equal(t, tc.channelHits, hits.count("channel"), fmt.Sprintf("%s: expected %v channel cache hits got %v", tc.desc, tc.channelHits, hits.count("channel")))
+		assert.Equal(t, tc.channelMisses, misses.count("channel"), fmt.Sprintf("%s: expected %v channel cache misses got %v", tc.desc, tc.channelMisses, misses.count("channel")))
+	}
+}
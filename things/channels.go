@@ -7,15 +7,76 @@
 
 package things
 
-import "context"
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// NoParent is the parent ID of the updated channel that detaches the channel
+// from its parent, making it the top-level channel. It can't be the ID of a
+// channel, since generated IDs end with the UUID.
+const NoParent = "none"
 
 // Channel represents a Mainflux "communication group". This group contains the
 // things that can exchange messages between eachother.
+// Channels can be organized into a hierarchy by referencing the parent
+// channel, which is empty for the top-level channels.
+// Connections is the number of the connected things and it is populated only
+// when a single channel is viewed.
+// Channel having the membership query is dynamic, i.e. its members are the
+// owner's things matching the query instead of the connected things.
 type Channel struct {
-	ID       string
-	Owner    string
-	Name     string
-	Metadata map[string]interface{}
+	ID          string
+	Owner       string
+	ParentID    string
+	Name        string
+	Metadata    map[string]interface{}
+	Membership  MembershipQuery
+	Connections uint64
+
+	// Protected channel can't be removed unless the removal is forced.
+	Protected bool
+
+	// PublisherScoped channel restricts the thing reading its message
+	// history to the messages the thing published itself.
+	PublisherScoped bool
+}
+
+// MembershipQuery defines the dynamic channel membership by the thing
+// metadata. Thing matches the query if its metadata contains all the query
+// keys with equal values, e.g. {"floor": 3} matches all the things on the
+// third floor. Query values are limited to strings, numbers and booleans.
+type MembershipQuery map[string]interface{}
+
+// Validate returns ErrMalformedEntity if the query contains an empty key or
+// a value other than string, number or boolean.
+func (q MembershipQuery) Validate() error {
+	for key, val := range q {
+		if key == "" {
+			return ErrMalformedEntity
+		}
+
+		switch val.(type) {
+		case string, float64, bool:
+		default:
+			return ErrMalformedEntity
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the thing metadata matches the query.
+func (q MembershipQuery) Matches(metadata map[string]interface{}) bool {
+	for key, val := range q {
+		mval, ok := metadata[key]
+		if !ok || !reflect.DeepEqual(val, mval) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // ChannelsPage contains page related metadata as well as list of channels that
@@ -40,33 +101,75 @@ type ChannelRepository interface {
 	// by the specified user.
 	RetrieveByID(context.Context, string, string) (Channel, error)
 
+	// RetrieveOwner retrieves the ID of the owner of the channel having the
+	// provided identifier.
+	RetrieveOwner(context.Context, string) (string, error)
+
+	// RetrieveTokenVersion retrieves the version of the access tokens of the
+	// channel having the provided identifier.
+	RetrieveTokenVersion(context.Context, string) (uint64, error)
+
+	// RevokeTokens increments the version of the access tokens of the
+	// channel having the provided identifier, that is owned by the specified
+	// user, which invalidates the tokens issued before.
+	RevokeTokens(context.Context, string, string) error
+
 	// RetrieveAll retrieves the subset of channels owned by the specified user.
-	RetrieveAll(context.Context, string, uint64, uint64, string) (ChannelsPage, error)
+	// If the parent channel is specified, only the channels belonging to its
+	// subtree are retrieved. Compact retrieval populates only the IDs and the
+	// names of the channels.
+	RetrieveAll(context.Context, string, uint64, uint64, string, string, bool) (ChannelsPage, error)
 
 	// RetrieveByThing retrieves the subset of channels owned by the specified
-	// user and have specified thing connected to them.
+	// user and have specified thing connected to them. Expired connections
+	// are omitted, here and in the rest of the repository, even before they
+	// are removed.
 	RetrieveByThing(context.Context, string, string, uint64, uint64) (ChannelsPage, error)
 
+	// RetrieveDynamic retrieves all the channels owned by the specified user
+	// whose membership is defined by the membership query.
+	RetrieveDynamic(context.Context, string) ([]Channel, error)
+
 	// Remove removes the channel having the provided identifier, that is owned
 	// by the specified user.
 	Remove(context.Context, string, string) error
 
-	// Connect adds thing to the channel's list of connected things.
-	Connect(context.Context, string, string, string) error
+	// Connect adds thing to the channel's list of connected things. The
+	// connection expires at the given time, unless the time is zero.
+	// Connecting the thing that is already connected replaces the expiry
+	// time of the connection. Existence of both the channel and the thing
+	// is verified along with the connection, so ErrNotFound is returned
+	// for the entity removed in the meantime.
+	Connect(context.Context, string, string, string, time.Time) error
 
 	// Disconnect removes thing from the channel's list of connected
 	// things.
 	Disconnect(context.Context, string, string, string) error
 
+	// RemoveExpired removes all the connections that expired before the
+	// given time and returns them.
+	RemoveExpired(context.Context, time.Time) ([]Connection, error)
+
 	// HasThing determines whether the thing with the provided access key, is
-	// "connected" to the specified channel. If that's the case, it returns
-	// thing's ID.
+	// "connected" to the specified channel, i.e. the member of the dynamic
+	// channel. If that's the case, it returns thing's ID.
 	HasThing(context.Context, string, string) (string, error)
 
+	// CountThings retrieves the number of things connected to the channel
+	// having the provided identifier, that is owned by the specified user.
+	CountThings(context.Context, string, string) (uint64, error)
+
+	// CountChannels retrieves the number of channels the thing having the
+	// provided identifier, that is owned by the specified user, is connected
+	// to.
+	CountChannels(context.Context, string, string) (uint64, error)
+
 	// HasThingByID determines whether the thing with the provided ID, is
-	// "connected" to the specified channel. If that's the case, then
-	// returned error will be nil.
-	HasThingByID(context.Context, string, string) error
+	// "connected" to the specified channel, i.e. the member of the dynamic
+	// channel. If that's the case, then returned error will be nil, and the
+	// returned time is the expiry time of the connection, which is zero for
+	// the permanent connections and the members of the dynamic channels.
+	HasThingByID(context.Context, string, string) (time.Time, error)
 }
 
 // ChannelCache contains channel-thing connection caching interface.
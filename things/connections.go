@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "time"
+
+// ConnectionMode determines the number of channels a thing can be connected
+// to at the same time.
+type ConnectionMode string
+
+const (
+	// SharedConnections lets the thing be connected to any number of
+	// channels.
+	SharedConnections ConnectionMode = "shared"
+
+	// ExclusiveConnections lets the thing be connected to at most one
+	// channel. Connecting the thing that is already connected to another
+	// channel is rejected.
+	ExclusiveConnections ConnectionMode = "exclusive"
+
+	// MoveConnections lets the thing be connected to at most one channel.
+	// Connecting the thing that is already connected to another channel
+	// disconnects it from that channel.
+	MoveConnections ConnectionMode = "move"
+)
+
+// Valid returns true if the connection mode is supported.
+func (mode ConnectionMode) Valid() bool {
+	switch mode {
+	case SharedConnections, ExclusiveConnections, MoveConnections:
+		return true
+	}
+
+	return false
+}
+
+// Connection represents the connection of the thing to the channel, both
+// owned by the same user. Zero ExpiresAt value indicates that the connection
+// never expires.
+type Connection struct {
+	ChanID    string
+	ThingID   string
+	Owner     string
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the connection has an expiry time and is no longer
+// valid at the given time.
+func (conn Connection) Expired(now time.Time) bool {
+	return !conn.ExpiresAt.IsZero() && !now.Before(conn.ExpiresAt)
+}
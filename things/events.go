@@ -0,0 +1,106 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+	"sync"
+)
+
+// Operations of the topology events. They match the operations of the events
+// published to the event store.
+const (
+	ThingCreate     = "thing.create"
+	ThingRemove     = "thing.remove"
+	ThingConnect    = "thing.connect"
+	ThingDisconnect = "thing.disconnect"
+	ChannelCreate   = "channel.create"
+	ChannelRemove   = "channel.remove"
+)
+
+// EventSink specifies the destination the encoded topology and provisioning
+// events are published to, e.g. the event store or the cloud queue. Event is
+// encoded as the map of its fields, including the operation.
+type EventSink interface {
+	// Publish publishes the event. A non-nil error is returned to indicate
+	// that the event wasn't accepted by the sink.
+	Publish(map[string]interface{}) error
+}
+
+// eventsBuffer is the number of events buffered for every subscriber.
+const eventsBuffer = 64
+
+// Event describes the change of the user's things and channels topology.
+// Creation and removal events carry the ID of the created or removed entity,
+// while (dis)connection events carry the IDs of the channel and the thing.
+type Event struct {
+	Operation string `json:"operation"`
+	ID        string `json:"id,omitempty"`
+	ChanID    string `json:"chan_id,omitempty"`
+	ThingID   string `json:"thing_id,omitempty"`
+}
+
+type subscriber struct {
+	owner  string
+	events chan Event
+}
+
+// eventHub fans the topology events out to the subscribed owners.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subs: make(map[*subscriber]bool),
+	}
+}
+
+// subscribe registers the owner's subscriber, which is removed and whose
+// channel is closed once the context is done.
+func (eh *eventHub) subscribe(ctx context.Context, owner string) <-chan Event {
+	sub := &subscriber{
+		owner:  owner,
+		events: make(chan Event, eventsBuffer),
+	}
+
+	eh.mu.Lock()
+	eh.subs[sub] = true
+	eh.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		eh.mu.Lock()
+		delete(eh.subs, sub)
+		close(sub.events)
+		eh.mu.Unlock()
+	}()
+
+	return sub.events
+}
+
+// publish delivers the event to the owner's subscribers. Events are dropped
+// for the subscribers that don't keep up, so that a slow client can't block
+// the service.
+func (eh *eventHub) publish(owner string, event Event) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+
+	for sub := range eh.subs {
+		if sub.owner != owner {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package jwt provides a JWT channel tokenizer.
+package jwt
+
+import (
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/mainflux/mainflux/things"
+)
+
+const issuer = "mainflux.things"
+
+var _ things.ChannelTokenizer = (*channelTokenizer)(nil)
+
+type claims struct {
+	jwt.StandardClaims
+	Scope   string `json:"scope"`
+	Version uint64 `json:"ver,omitempty"`
+}
+
+type channelTokenizer struct {
+	secret string
+}
+
+// New instantiates a JWT channel tokenizer.
+func New(secret string) things.ChannelTokenizer {
+	return &channelTokenizer{secret}
+}
+
+func (ct *channelTokenizer) Issue(token things.ChannelToken) (string, error) {
+	c := claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   token.ChanID,
+			Issuer:    issuer,
+			IssuedAt:  time.Now().UTC().Unix(),
+			ExpiresAt: token.ExpiresAt.UTC().Unix(),
+		},
+		Scope:   token.Scope,
+		Version: token.Version,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(ct.secret))
+}
+
+func (ct *channelTokenizer) Parse(token string) (things.ChannelToken, error) {
+	c := claims{}
+	parsed, err := jwt.ParseWithClaims(token, &c, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, things.ErrUnauthorizedAccess
+		}
+
+		return []byte(ct.secret), nil
+	})
+	if err != nil || !parsed.Valid || c.Issuer != issuer {
+		return things.ChannelToken{}, things.ErrUnauthorizedAccess
+	}
+
+	return things.ChannelToken{
+		ChanID:    c.Subject,
+		Scope:     c.Scope,
+		Version:   c.Version,
+		ExpiresAt: time.Unix(c.ExpiresAt, 0),
+	}, nil
+}
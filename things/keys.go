@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// KeyEncoding represents the format in which generated thing keys are
+// rendered.
+type KeyEncoding string
+
+const (
+	// UUIDKeyEncoding renders keys as canonical UUID strings.
+	UUIDKeyEncoding KeyEncoding = "uuid"
+
+	// HexKeyEncoding renders keys as lowercase hex strings.
+	HexKeyEncoding KeyEncoding = "hex"
+
+	// Base64URLKeyEncoding renders keys as unpadded base64url strings.
+	Base64URLKeyEncoding KeyEncoding = "base64url"
+)
+
+// Valid returns true if the key encoding is supported.
+func (enc KeyEncoding) Valid() bool {
+	switch enc {
+	case UUIDKeyEncoding, HexKeyEncoding, Base64URLKeyEncoding:
+		return true
+	}
+
+	return false
+}
+
+// encode renders generated identifier in the given encoding. Since encoded
+// key is the one that is persisted, it is also the one used for lookups.
+func (enc KeyEncoding) encode(id string) (string, error) {
+	raw := strings.ToLower(strings.Replace(id, "-", "", -1))
+
+	switch enc {
+	case HexKeyEncoding:
+		if _, err := hex.DecodeString(raw); err != nil {
+			return "", err
+		}
+		return raw, nil
+	case Base64URLKeyEncoding:
+		data, err := hex.DecodeString(raw)
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(data), nil
+	default:
+		return id, nil
+	}
+}
@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ShareLink grants time-boxed read-only access to a single channel to
+// anyone holding its opaque token. Unlike channel tokens, share links are
+// persisted, so they can be revoked before they expire. Only the hash of the
+// token is persisted, the token itself is returned once, on creation.
+type ShareLink struct {
+	Token     string
+	ChanID    string
+	Owner     string
+	ExpiresAt time.Time
+	URL       string
+}
+
+// shareURL builds the URL of the channel messages readable using the share
+// link token. The base URL points to the message reader, if it is empty the
+// URL is relative.
+func shareURL(base, chanID, token string) string {
+	return fmt.Sprintf("%s/channels/%s/messages?share=%s", base, url.PathEscape(chanID), url.QueryEscape(token))
+}
+
+// hashToken returns the hex encoded SHA-256 hash of the token. Tokens are
+// random and long enough, so they don't need to be salted and stretched, and
+// the hash can be looked up as it is.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Expired returns true if the link is no longer valid at the given time.
+func (sl ShareLink) Expired(now time.Time) bool {
+	return !now.Before(sl.ExpiresAt)
+}
+
+// ShareLinkRepository specifies a share link persistence API.
+type ShareLinkRepository interface {
+	// Save persists the share link. Token of the saved link is the token
+	// hash.
+	Save(context.Context, ShareLink) error
+
+	// RetrieveByToken retrieves the share link having the provided token
+	// hash.
+	RetrieveByToken(context.Context, string) (ShareLink, error)
+
+	// Remove removes the share link of the specified channel having the
+	// provided token hash, that belongs to the specified user. If there is
+	// no such link, ErrNotFound is returned.
+	Remove(context.Context, string, string, string) error
+}
@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "strings"
+
+// ReservedMetadataPrefix is the default prefix of the metadata keys reserved
+// for the internal use.
+const ReservedMetadataPrefix = "mf_"
+
+// normalizeMetadata strips null values from the metadata, including the ones
+// of the nested objects, and rejects the top-level keys starting with the
+// reserved prefix. Elements of the arrays are kept as they are, so that their
+// positions don't change.
+func (ts *thingsService) normalizeMetadata(metadata map[string]interface{}) (map[string]interface{}, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	if ts.hasReservedKey(metadata) {
+		return nil, ErrMalformedEntity
+	}
+
+	return stripNulls(metadata), nil
+}
+
+// hasReservedKey returns true if any of the top-level metadata keys starts
+// with the reserved prefix.
+func (ts *thingsService) hasReservedKey(metadata map[string]interface{}) bool {
+	if ts.reservedPrefix == "" {
+		return false
+	}
+
+	for key := range metadata {
+		if strings.HasPrefix(key, ts.reservedPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergePatch applies the JSON merge patch (RFC 7386) to the metadata and
+// returns the result, leaving both the metadata and the patch intact. Keys
+// patched with null are removed, objects are merged recursively, while any
+// other value, including the array, replaces the patched one.
+func mergePatch(metadata, patch map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(metadata)+len(patch))
+	for key, val := range metadata {
+		res[key] = val
+	}
+
+	for key, val := range patch {
+		switch v := val.(type) {
+		case nil:
+			delete(res, key)
+		case map[string]interface{}:
+			target, _ := res[key].(map[string]interface{})
+			res[key] = mergePatch(target, v)
+		case []interface{}:
+			res[key] = stripArrayNulls(v)
+		default:
+			res[key] = val
+		}
+	}
+
+	return res
+}
+
+func stripNulls(obj map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(obj))
+	for key, val := range obj {
+		switch v := val.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			res[key] = stripNulls(v)
+		case []interface{}:
+			res[key] = stripArrayNulls(v)
+		default:
+			res[key] = val
+		}
+	}
+
+	return res
+}
+
+// stripArrayNulls strips null values from the objects nested in the array.
+func stripArrayNulls(arr []interface{}) []interface{} {
+	res := make([]interface{}, len(arr))
+	for i, val := range arr {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			res[i] = stripNulls(v)
+		case []interface{}:
+			res[i] = stripArrayNulls(v)
+		default:
+			res[i] = val
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.APIKeyRepository = (*apiKeyRepositoryMock)(nil)
+
+type apiKeyRepositoryMock struct {
+	mu   sync.Mutex
+	keys map[string]things.APIKey
+}
+
+// NewAPIKeyRepository creates in-memory API key repository.
+func NewAPIKeyRepository() things.APIKeyRepository {
+	return &apiKeyRepositoryMock{
+		keys: make(map[string]things.APIKey),
+	}
+}
+
+func (akrm *apiKeyRepositoryMock) Save(_ context.Context, key things.APIKey) error {
+	akrm.mu.Lock()
+	defer akrm.mu.Unlock()
+
+	for _, k := range akrm.keys {
+		if k.ID == key.ID || k.Key == key.Key {
+			return things.ErrConflict
+		}
+	}
+
+	akrm.keys[key.ID] = key
+	return nil
+}
+
+func (akrm *apiKeyRepositoryMock) RetrieveByKey(_ context.Context, value string) (things.APIKey, error) {
+	akrm.mu.Lock()
+	defer akrm.mu.Unlock()
+
+	for _, k := range akrm.keys {
+		if k.Key == value {
+			return k, nil
+		}
+	}
+
+	return things.APIKey{}, things.ErrNotFound
+}
+
+func (akrm *apiKeyRepositoryMock) RetrieveAll(_ context.Context, owner string) ([]things.APIKey, error) {
+	akrm.mu.Lock()
+	defer akrm.mu.Unlock()
+
+	keys := []things.APIKey{}
+	for _, k := range akrm.keys {
+		if k.Owner == owner {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys, nil
+}
+
+func (akrm *apiKeyRepositoryMock) Remove(_ context.Context, owner, id string) error {
+	akrm.mu.Lock()
+	defer akrm.mu.Unlock()
+
+	if k, ok := akrm.keys[id]; ok && k.Owner == owner {
+		delete(akrm.keys, id)
+	}
+
+	return nil
+}
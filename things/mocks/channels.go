@@ -14,16 +14,21 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mainflux/mainflux/things"
 )
 
 // Connection represents connection between channel and thing that is used for
-// testing purposes.
+// testing purposes. It also carries the membership query of the dynamic
+// channel, if membership is set.
 type Connection struct {
-	chanID    string
-	thing     things.Thing
-	connected bool
+	chanID     string
+	thing      things.Thing
+	connected  bool
+	expiresAt  time.Time
+	membership things.MembershipQuery
+	dynamic    bool
 }
 
 var _ things.ChannelRepository = (*channelRepositoryMock)(nil)
@@ -34,6 +39,8 @@ type channelRepositoryMock struct {
 	channels map[string]things.Channel
 	tconns   chan Connection                      // used for syncronization with thing repo
 	cconns   map[string]map[string]things.Channel // used to track connections
+	expiry   map[string]time.Time                 // expiry of connections by channel and thing
+	versions map[string]uint64                    // token versions by channel
 	things   things.ThingRepository
 }
 
@@ -43,6 +50,8 @@ func NewChannelRepository(repo things.ThingRepository, tconns chan Connection) t
 		channels: make(map[string]things.Channel),
 		tconns:   tconns,
 		cconns:   make(map[string]map[string]things.Channel),
+		expiry:   make(map[string]time.Time),
+		versions: make(map[string]uint64),
 		things:   repo,
 	}
 }
@@ -54,6 +63,7 @@ func (crm *channelRepositoryMock) Save(_ context.Context, channel things.Channel
 	crm.counter++
 	channel.ID = strconv.FormatUint(crm.counter, 10)
 	crm.channels[key(channel.Owner, channel.ID)] = channel
+	crm.syncMembership(channel)
 
 	return channel.ID, nil
 }
@@ -69,9 +79,21 @@ func (crm *channelRepositoryMock) Update(_ context.Context, channel things.Chann
 	}
 
 	crm.channels[dbKey] = channel
+	crm.syncMembership(channel)
 	return nil
 }
 
+// syncMembership passes the membership query of the channel to the thing
+// repository, which resolves the channel members.
+func (crm *channelRepositoryMock) syncMembership(channel things.Channel) {
+	crm.tconns <- Connection{
+		chanID:     channel.ID,
+		thing:      things.Thing{Owner: channel.Owner},
+		membership: channel.Membership,
+		dynamic:    true,
+	}
+}
+
 func (crm *channelRepositoryMock) RetrieveByID(_ context.Context, owner, id string) (things.Channel, error) {
 	if c, ok := crm.channels[key(owner, id)]; ok {
 		return c, nil
@@ -80,13 +102,56 @@ func (crm *channelRepositoryMock) RetrieveByID(_ context.Context, owner, id stri
 	return things.Channel{}, things.ErrNotFound
 }
 
-func (crm *channelRepositoryMock) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string) (things.ChannelsPage, error) {
+func (crm *channelRepositoryMock) RetrieveOwner(_ context.Context, id string) (string, error) {
+	for _, ch := range crm.channels {
+		if ch.ID == id {
+			return ch.Owner, nil
+		}
+	}
+
+	return "", things.ErrNotFound
+}
+
+func (crm *channelRepositoryMock) RetrieveTokenVersion(_ context.Context, id string) (uint64, error) {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	for _, ch := range crm.channels {
+		if ch.ID == id {
+			return crm.versions[id], nil
+		}
+	}
+
+	return 0, things.ErrNotFound
+}
+
+func (crm *channelRepositoryMock) RevokeTokens(_ context.Context, owner, id string) error {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	if _, ok := crm.channels[key(owner, id)]; !ok {
+		return things.ErrNotFound
+	}
+
+	crm.versions[id]++
+	return nil
+}
+
+func (crm *channelRepositoryMock) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name, parent string, compact bool) (things.ChannelsPage, error) {
 	channels := make([]things.Channel, 0)
 
 	if offset < 0 || limit <= 0 {
 		return things.ChannelsPage{}, nil
 	}
 
+	if parent != "" {
+		page := crm.retrieveSubtree(owner, offset, limit, parent)
+		if compact {
+			page.Channels = compactChannels(page.Channels)
+		}
+		return page, nil
+	}
+
 	first := uint64(offset) + 1
 	last := first + uint64(limit)
 
@@ -104,6 +169,10 @@ func (crm *channelRepositoryMock) RetrieveAll(_ context.Context, owner string, o
 		return channels[i].ID < channels[j].ID
 	})
 
+	if compact {
+		channels = compactChannels(channels)
+	}
+
 	page := things.ChannelsPage{
 		Channels: channels,
 		PageMetadata: things.PageMetadata{
@@ -116,6 +185,56 @@ func (crm *channelRepositoryMock) RetrieveAll(_ context.Context, owner string, o
 	return page, nil
 }
 
+// compactChannels keeps only the IDs and the names of the channels.
+func compactChannels(channels []things.Channel) []things.Channel {
+	compact := make([]things.Channel, len(channels))
+	for i, ch := range channels {
+		compact[i] = things.Channel{ID: ch.ID, Owner: ch.Owner, Name: ch.Name}
+	}
+
+	return compact
+}
+
+func (crm *channelRepositoryMock) retrieveSubtree(owner string, offset, limit uint64, parent string) things.ChannelsPage {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	subtree := []things.Channel{}
+	for parents := []string{parent}; len(parents) > 0; parents = parents[1:] {
+		for _, v := range crm.channels {
+			if v.Owner == owner && v.ParentID == parents[0] {
+				subtree = append(subtree, v)
+				parents = append(parents, v.ID)
+			}
+		}
+	}
+
+	sort.SliceStable(subtree, func(i, j int) bool {
+		id1, _ := strconv.ParseUint(subtree[i].ID, 10, 64)
+		id2, _ := strconv.ParseUint(subtree[j].ID, 10, 64)
+		return id1 < id2
+	})
+
+	total := uint64(len(subtree))
+	channels := []things.Channel{}
+	if offset < total {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		channels = subtree[offset:end]
+	}
+
+	return things.ChannelsPage{
+		Channels: channels,
+		PageMetadata: things.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}
+}
+
 func (crm *channelRepositoryMock) RetrieveByThing(_ context.Context, owner, thingID string, offset, limit uint64) (things.ChannelsPage, error) {
 	channels := make([]things.Channel, 0)
 
@@ -128,7 +247,7 @@ func (crm *channelRepositoryMock) RetrieveByThing(_ context.Context, owner, thin
 
 	for _, v := range crm.cconns[thingID] {
 		id, _ := strconv.ParseUint(v.ID, 10, 64)
-		if id >= first && id < last {
+		if id >= first && id < last && crm.live(v.ID, thingID) {
 			channels = append(channels, v)
 		}
 	}
@@ -149,8 +268,36 @@ func (crm *channelRepositoryMock) RetrieveByThing(_ context.Context, owner, thin
 	return page, nil
 }
 
+func (crm *channelRepositoryMock) RetrieveDynamic(_ context.Context, owner string) ([]things.Channel, error) {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	items := []things.Channel{}
+	for _, ch := range crm.channels {
+		if ch.Owner == owner && len(ch.Membership) > 0 {
+			items = append(items, ch)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+
+	return items, nil
+}
+
 func (crm *channelRepositoryMock) Remove(_ context.Context, owner, id string) error {
+	crm.mu.Lock()
 	delete(crm.channels, key(owner, id))
+	// detach children of the removed channel
+	for k, v := range crm.channels {
+		if v.Owner == owner && v.ParentID == id {
+			v.ParentID = ""
+			crm.channels[k] = v
+		}
+	}
+	crm.mu.Unlock()
+
 	// delete channel from any thing list
 	for thk := range crm.cconns {
 		delete(crm.cconns[thk], key(owner, id))
@@ -162,7 +309,7 @@ func (crm *channelRepositoryMock) Remove(_ context.Context, owner, id string) er
 	return nil
 }
 
-func (crm *channelRepositoryMock) Connect(_ context.Context, owner, chanID, thingID string) error {
+func (crm *channelRepositoryMock) Connect(_ context.Context, owner, chanID, thingID string, expiresAt time.Time) error {
 	channel, err := crm.RetrieveByID(context.Background(), owner, chanID)
 	if err != nil {
 		return err
@@ -177,11 +324,13 @@ func (crm *channelRepositoryMock) Connect(_ context.Context, owner, chanID, thin
 		chanID:    chanID,
 		thing:     thing,
 		connected: true,
+		expiresAt: expiresAt,
 	}
 	if _, ok := crm.cconns[thingID]; !ok {
 		crm.cconns[thingID] = make(map[string]things.Channel)
 	}
 	crm.cconns[thingID][chanID] = channel
+	crm.expiry[key(chanID, thingID)] = expiresAt
 	return nil
 }
 
@@ -200,38 +349,128 @@ func (crm *channelRepositoryMock) Disconnect(_ context.Context, owner, chanID, t
 		connected: false,
 	}
 	delete(crm.cconns[thingID], chanID)
+	delete(crm.expiry, key(chanID, thingID))
 	return nil
 }
 
+func (crm *channelRepositoryMock) RemoveExpired(_ context.Context, t time.Time) ([]things.Connection, error) {
+	conns := []things.Connection{}
+	for thingID, chans := range crm.cconns {
+		for chanID, ch := range chans {
+			exp := crm.expiry[key(chanID, thingID)]
+			if exp.IsZero() || exp.After(t) {
+				continue
+			}
+
+			crm.tconns <- Connection{
+				chanID:    chanID,
+				thing:     things.Thing{ID: thingID, Owner: ch.Owner},
+				connected: false,
+			}
+			delete(chans, chanID)
+			delete(crm.expiry, key(chanID, thingID))
+			conns = append(conns, things.Connection{ChanID: chanID, ThingID: thingID, Owner: ch.Owner, ExpiresAt: exp})
+		}
+	}
+
+	return conns, nil
+}
+
+// live returns true if the connection of the thing to the channel hasn't
+// expired.
+func (crm *channelRepositoryMock) live(chanID, thingID string) bool {
+	exp := crm.expiry[key(chanID, thingID)]
+	return exp.IsZero() || time.Now().Before(exp)
+}
+
 func (crm *channelRepositoryMock) HasThing(_ context.Context, chanID, token string) (string, error) {
-	tid, err := crm.things.RetrieveByKey(context.Background(), token)
+	th, err := crm.things.RetrieveByKey(context.Background(), token)
 	if err != nil {
 		return "", things.ErrNotFound
 	}
+	tid := th.ID
+
+	if ch, ok := crm.dynamic(chanID, th.Owner); ok {
+		if !ch.Membership.Matches(th.Metadata) {
+			return "", things.ErrNotFound
+		}
+		return tid, nil
+	}
 
 	chans, ok := crm.cconns[tid]
 	if !ok {
 		return "", things.ErrNotFound
 	}
 
-	if _, ok := chans[chanID]; !ok {
+	if _, ok := chans[chanID]; !ok || !crm.live(chanID, tid) {
 		return "", things.ErrNotFound
 	}
 
 	return tid, nil
 }
 
-func (crm *channelRepositoryMock) HasThingByID(_ context.Context, chanID, thingID string) error {
+func (crm *channelRepositoryMock) HasThingByID(_ context.Context, chanID, thingID string) (time.Time, error) {
+	for _, ch := range crm.channels {
+		if ch.ID != chanID || len(ch.Membership) == 0 {
+			continue
+		}
+
+		th, err := crm.things.RetrieveByID(context.Background(), ch.Owner, thingID)
+		if err != nil || !ch.Membership.Matches(th.Metadata) {
+			return time.Time{}, things.ErrNotFound
+		}
+		return time.Time{}, nil
+	}
+
 	chans, ok := crm.cconns[thingID]
 	if !ok {
-		return things.ErrNotFound
+		return time.Time{}, things.ErrNotFound
 	}
 
-	if _, ok := chans[chanID]; !ok {
-		return things.ErrNotFound
+	ch, ok := chans[chanID]
+	if !ok || !crm.live(chanID, thingID) {
+		return time.Time{}, things.ErrNotFound
 	}
 
-	return nil
+	// Connections of the removed things are not cleaned up.
+	if _, err := crm.things.RetrieveByID(context.Background(), ch.Owner, thingID); err != nil {
+		return time.Time{}, things.ErrNotFound
+	}
+
+	return crm.expiry[key(chanID, thingID)], nil
+}
+
+// dynamic returns the owner's channel if it is dynamic.
+func (crm *channelRepositoryMock) dynamic(chanID, owner string) (things.Channel, bool) {
+	ch, ok := crm.channels[key(owner, chanID)]
+	if !ok || len(ch.Membership) == 0 {
+		return things.Channel{}, false
+	}
+
+	return ch, true
+}
+
+func (crm *channelRepositoryMock) CountThings(_ context.Context, owner, chanID string) (uint64, error) {
+	var count uint64
+	for thingID, chans := range crm.cconns {
+		if ch, ok := chans[chanID]; ok && ch.Owner == owner && crm.live(chanID, thingID) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (crm *channelRepositoryMock) CountChannels(_ context.Context, owner, thingID string) (uint64, error) {
+	var count uint64
+	for _, ch := range crm.cconns[thingID] {
+		// Connections of the removed channels are not cleaned up.
+		if _, ok := crm.channels[key(ch.Owner, ch.ID)]; ok && ch.Owner == owner && crm.live(ch.ID, thingID) {
+			count++
+		}
+	}
+
+	return count, nil
 }
 
 type channelCacheMock struct {
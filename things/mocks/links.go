@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.ShareLinkRepository = (*shareLinkRepositoryMock)(nil)
+
+type shareLinkRepositoryMock struct {
+	mu    sync.Mutex
+	links map[string]things.ShareLink
+}
+
+// NewShareLinkRepository creates in-memory share link repository.
+func NewShareLinkRepository() things.ShareLinkRepository {
+	return &shareLinkRepositoryMock{
+		links: make(map[string]things.ShareLink),
+	}
+}
+
+func (slrm *shareLinkRepositoryMock) Save(_ context.Context, link things.ShareLink) error {
+	slrm.mu.Lock()
+	defer slrm.mu.Unlock()
+
+	if _, ok := slrm.links[link.Token]; ok {
+		return things.ErrConflict
+	}
+
+	slrm.links[link.Token] = link
+	return nil
+}
+
+func (slrm *shareLinkRepositoryMock) RetrieveByToken(_ context.Context, token string) (things.ShareLink, error) {
+	slrm.mu.Lock()
+	defer slrm.mu.Unlock()
+
+	link, ok := slrm.links[token]
+	if !ok {
+		return things.ShareLink{}, things.ErrNotFound
+	}
+
+	return link, nil
+}
+
+func (slrm *shareLinkRepositoryMock) Remove(_ context.Context, owner, chanID, token string) error {
+	slrm.mu.Lock()
+	defer slrm.mu.Unlock()
+
+	link, ok := slrm.links[token]
+	if !ok || link.Owner != owner || link.ChanID != chanID {
+		return things.ErrNotFound
+	}
+
+	delete(slrm.links, token)
+	return nil
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.OutboxRepository = (*outboxRepositoryMock)(nil)
+
+type outboxEvent struct {
+	event       things.OutboxEvent
+	deliveredAt *time.Time
+}
+
+type outboxRepositoryMock struct {
+	mu      sync.Mutex
+	counter uint64
+	events  map[uint64]outboxEvent
+}
+
+// NewOutboxRepository creates in-memory outbox repository.
+func NewOutboxRepository() things.OutboxRepository {
+	return &outboxRepositoryMock{
+		events: make(map[uint64]outboxEvent),
+	}
+}
+
+func (orm *outboxRepositoryMock) Save(_ context.Context, event things.OutboxEvent) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	orm.counter++
+	event.ID = orm.counter
+	orm.events[event.ID] = outboxEvent{event: event}
+	return nil
+}
+
+func (orm *outboxRepositoryMock) RetrieveDue(_ context.Context, now time.Time, limit uint64) ([]things.OutboxEvent, error) {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	due := []things.OutboxEvent{}
+	for _, oe := range orm.events {
+		if oe.deliveredAt == nil && !oe.event.NextAttempt.After(now) {
+			due = append(due, oe.event)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].ID < due[j].ID
+	})
+
+	if uint64(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (orm *outboxRepositoryMock) Retry(_ context.Context, id uint64, next time.Time) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	oe, ok := orm.events[id]
+	if !ok {
+		return things.ErrNotFound
+	}
+
+	oe.event.Attempts++
+	oe.event.NextAttempt = next
+	orm.events[id] = oe
+	return nil
+}
+
+func (orm *outboxRepositoryMock) MarkDelivered(_ context.Context, id uint64, at time.Time) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	oe, ok := orm.events[id]
+	if !ok {
+		return things.ErrNotFound
+	}
+
+	oe.deliveredAt = &at
+	orm.events[id] = oe
+	return nil
+}
+
+func (orm *outboxRepositoryMock) RemoveDelivered(_ context.Context, before time.Time) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	for id, oe := range orm.events {
+		if oe.deliveredAt != nil && oe.deliveredAt.Before(before) {
+			delete(orm.events, id)
+		}
+	}
+
+	return nil
+}
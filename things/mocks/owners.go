@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.OwnerRepository = (*ownerRepositoryMock)(nil)
+
+type ownerRepositoryMock struct {
+	mu       sync.Mutex
+	ids      map[string]string
+	disabled map[string]bool
+}
+
+// NewOwnerRepository creates in-memory owner repository.
+func NewOwnerRepository() things.OwnerRepository {
+	return &ownerRepositoryMock{
+		ids:      make(map[string]string),
+		disabled: make(map[string]bool),
+	}
+}
+
+func (orm *ownerRepositoryMock) RetrieveByEmail(_ context.Context, email string) (string, error) {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	id, ok := orm.ids[email]
+	if !ok {
+		return "", things.ErrNotFound
+	}
+
+	return id, nil
+}
+
+func (orm *ownerRepositoryMock) Resolve(_ context.Context, email, id string) (string, error) {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	if existing, ok := orm.ids[email]; ok {
+		return existing, nil
+	}
+
+	orm.ids[email] = id
+	return id, nil
+}
+
+func (orm *ownerRepositoryMock) ChangeEmail(_ context.Context, email, newEmail string) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	id, ok := orm.ids[email]
+	if !ok {
+		return things.ErrNotFound
+	}
+
+	if email == newEmail {
+		return nil
+	}
+
+	if _, ok := orm.ids[newEmail]; ok {
+		return things.ErrConflict
+	}
+
+	delete(orm.ids, email)
+	orm.ids[newEmail] = id
+	return nil
+}
+
+func (orm *ownerRepositoryMock) Disable(_ context.Context, owner string) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	orm.disabled[owner] = true
+	return nil
+}
+
+func (orm *ownerRepositoryMock) Enable(_ context.Context, owner string) error {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	delete(orm.disabled, owner)
+	return nil
+}
+
+func (orm *ownerRepositoryMock) Disabled(_ context.Context, owner string) (bool, error) {
+	orm.mu.Lock()
+	defer orm.mu.Unlock()
+
+	return orm.disabled[owner], nil
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mainflux/mainflux/things"
 )
@@ -21,22 +22,30 @@ import (
 var _ things.ThingRepository = (*thingRepositoryMock)(nil)
 
 type thingRepositoryMock struct {
-	mu      sync.Mutex
-	counter uint64
-	conns   chan Connection
-	tconns  map[string]map[string]things.Thing
-	things  map[string]things.Thing
+	mu          sync.Mutex
+	counter     uint64
+	conns       chan Connection
+	tconns      map[string]map[string]things.Thing
+	expiry      map[string]time.Time
+	things      map[string]things.Thing
+	memberships map[string]things.MembershipQuery
 }
 
 // NewThingRepository creates in-memory thing repository.
 func NewThingRepository(conns chan Connection) things.ThingRepository {
 	repo := &thingRepositoryMock{
-		conns:  conns,
-		things: make(map[string]things.Thing),
-		tconns: make(map[string]map[string]things.Thing),
+		conns:       conns,
+		things:      make(map[string]things.Thing),
+		tconns:      make(map[string]map[string]things.Thing),
+		expiry:      make(map[string]time.Time),
+		memberships: make(map[string]things.MembershipQuery),
 	}
 	go func(conns chan Connection, repo *thingRepositoryMock) {
 		for conn := range conns {
+			if conn.dynamic {
+				repo.setMembership(conn)
+				continue
+			}
 			if !conn.connected {
 				repo.disconnect(conn)
 				continue
@@ -60,6 +69,15 @@ func (trm *thingRepositoryMock) Save(_ context.Context, thing things.Thing) (str
 
 	trm.counter++
 	thing.ID = strconv.FormatUint(trm.counter, 10)
+	if thing.LastSeen.IsZero() {
+		thing.LastSeen = time.Now()
+	}
+	if thing.CreatedAt.IsZero() {
+		thing.CreatedAt = time.Now()
+	}
+	if thing.UpdatedAt.IsZero() {
+		thing.UpdatedAt = thing.CreatedAt
+	}
 	trm.things[key(thing.Owner, thing.ID)] = thing
 
 	return thing.ID, nil
@@ -71,16 +89,21 @@ func (trm *thingRepositoryMock) Update(_ context.Context, thing things.Thing) er
 
 	dbKey := key(thing.Owner, thing.ID)
 
-	if _, ok := trm.things[dbKey]; !ok {
+	th, ok := trm.things[dbKey]
+	if !ok {
 		return things.ErrNotFound
 	}
 
-	trm.things[dbKey] = thing
+	// Only the name and the metadata are updated, as in the database.
+	th.Name = thing.Name
+	th.Metadata = thing.Metadata
+	th.UpdatedAt = time.Now()
+	trm.things[dbKey] = th
 
 	return nil
 }
 
-func (trm *thingRepositoryMock) UpdateKey(_ context.Context, owner, id, val string) error {
+func (trm *thingRepositoryMock) UpdateKey(_ context.Context, owner, id, val string, expiry time.Time) error {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
 
@@ -98,6 +121,27 @@ func (trm *thingRepositoryMock) UpdateKey(_ context.Context, owner, id, val stri
 	}
 
 	th.Key = val
+	th.KeyExpiry = expiry
+	th.UpdatedAt = time.Now()
+	trm.things[dbKey] = th
+
+	return nil
+}
+
+func (trm *thingRepositoryMock) RotateKey(_ context.Context, thing things.Thing, val string, expiry time.Time) error {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	dbKey := key(thing.Owner, thing.ID)
+
+	th, ok := trm.things[dbKey]
+	if !ok || th.Key != thing.Key {
+		return things.ErrNotFound
+	}
+
+	th.Key = val
+	th.KeyExpiry = expiry
+	th.UpdatedAt = time.Now()
 	trm.things[dbKey] = th
 
 	return nil
@@ -114,7 +158,20 @@ func (trm *thingRepositoryMock) RetrieveByID(_ context.Context, owner, id string
 	return things.Thing{}, things.ErrNotFound
 }
 
-func (trm *thingRepositoryMock) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string) (things.ThingsPage, error) {
+func (trm *thingRepositoryMock) RetrieveOwner(_ context.Context, id string) (string, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	for _, th := range trm.things {
+		if th.ID == id {
+			return th.Owner, nil
+		}
+	}
+
+	return "", things.ErrNotFound
+}
+
+func (trm *thingRepositoryMock) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string, compact bool) (things.ThingsPage, error) {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
 
@@ -133,6 +190,9 @@ func (trm *thingRepositoryMock) RetrieveAll(_ context.Context, owner string, off
 	for k, v := range trm.things {
 		id, _ := strconv.ParseUint(v.ID, 10, 64)
 		if strings.HasPrefix(k, prefix) && id >= first && id < last {
+			if compact {
+				v = things.Thing{ID: v.ID, Owner: v.Owner, Name: v.Name}
+			}
 			items = append(items, v)
 		}
 	}
@@ -153,6 +213,28 @@ func (trm *thingRepositoryMock) RetrieveAll(_ context.Context, owner string, off
 	return page, nil
 }
 
+func (trm *thingRepositoryMock) RetrieveByChannelAfter(_ context.Context, owner, chanID, after string, limit uint64) ([]things.Thing, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	items := make([]things.Thing, 0)
+	for _, v := range trm.members(owner, chanID) {
+		if v.Owner == owner && v.ID > after {
+			items = append(items, v)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+
+	if uint64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
 func (trm *thingRepositoryMock) RetrieveByChannel(_ context.Context, owner, chanID string, offset, limit uint64) (things.ThingsPage, error) {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
@@ -166,8 +248,8 @@ func (trm *thingRepositoryMock) RetrieveByChannel(_ context.Context, owner, chan
 	first := uint64(offset) + 1
 	last := first + uint64(limit)
 
-	ths, ok := trm.tconns[chanID]
-	if !ok {
+	ths := trm.members(owner, chanID)
+	if len(ths) == 0 {
 		return things.ThingsPage{}, nil
 	}
 
@@ -194,6 +276,53 @@ func (trm *thingRepositoryMock) RetrieveByChannel(_ context.Context, owner, chan
 	return page, nil
 }
 
+func (trm *thingRepositoryMock) RetrieveByChannels(_ context.Context, owner string, channels []string, offset, limit uint64) (things.ThingsPage, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	union := map[string]things.Thing{}
+	for _, chanID := range channels {
+		for id, th := range trm.tconns[chanID] {
+			if th.Owner == owner && trm.live(chanID, id) {
+				union[id] = th
+			}
+		}
+	}
+
+	items := make([]things.Thing, 0)
+	for _, th := range union {
+		items = append(items, th)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		idi, _ := strconv.ParseUint(items[i].ID, 10, 64)
+		idj, _ := strconv.ParseUint(items[j].ID, 10, 64)
+		return idi < idj
+	})
+
+	total := uint64(len(items))
+	if offset >= total {
+		items = []things.Thing{}
+	} else {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		items = items[offset:end]
+	}
+
+	page := things.ThingsPage{
+		Things: items,
+		PageMetadata: things.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}
+
+	return page, nil
+}
+
 func (trm *thingRepositoryMock) Remove(_ context.Context, owner, id string) error {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
@@ -201,17 +330,135 @@ func (trm *thingRepositoryMock) Remove(_ context.Context, owner, id string) erro
 	return nil
 }
 
-func (trm *thingRepositoryMock) RetrieveByKey(_ context.Context, key string) (string, error) {
+func (trm *thingRepositoryMock) RetrieveByKey(_ context.Context, key string) (things.Thing, error) {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
 
 	for _, thing := range trm.things {
 		if thing.Key == key {
-			return thing.ID, nil
+			return thing, nil
 		}
 	}
 
-	return "", things.ErrNotFound
+	return things.Thing{}, things.ErrNotFound
+}
+
+func (trm *thingRepositoryMock) RetrieveByKeys(_ context.Context, keys []string) ([]things.Thing, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	wanted := make(map[string]bool)
+	for _, k := range keys {
+		wanted[k] = true
+	}
+
+	items := make([]things.Thing, 0)
+	for _, thing := range trm.things {
+		if wanted[thing.Key] {
+			items = append(items, thing)
+		}
+	}
+
+	return items, nil
+}
+
+func (trm *thingRepositoryMock) RetrieveExpired(_ context.Context, t time.Time, limit uint64) ([]things.Thing, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	items := make([]things.Thing, 0)
+	for _, thing := range trm.things {
+		if !thing.KeyExpiry.IsZero() && thing.KeyExpiry.Before(t) {
+			items = append(items, thing)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].KeyExpiry.Equal(items[j].KeyExpiry) {
+			return items[i].ID < items[j].ID
+		}
+		return items[i].KeyExpiry.Before(items[j].KeyExpiry)
+	})
+
+	if uint64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func (trm *thingRepositoryMock) RetrieveIdle(_ context.Context, owner, chanID string, t time.Time) ([]things.Thing, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	items := make([]things.Thing, 0)
+	for id := range trm.tconns[chanID] {
+		thing, ok := trm.things[key(owner, id)]
+		if ok && thing.LastSeen.Before(t) && trm.live(chanID, id) {
+			items = append(items, thing)
+		}
+	}
+
+	return items, nil
+}
+
+func (trm *thingRepositoryMock) UpdateLastSeen(_ context.Context, id string, t time.Time) error {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	for k, thing := range trm.things {
+		if thing.ID == id {
+			thing.LastSeen = t
+			trm.things[k] = thing
+			return nil
+		}
+	}
+
+	return things.ErrNotFound
+}
+
+func (trm *thingRepositoryMock) NameExists(_ context.Context, owner, name string) (bool, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	for _, thing := range trm.things {
+		if thing.Owner == owner && thing.Name == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (trm *thingRepositoryMock) setMembership(conn Connection) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	trm.memberships[key(conn.thing.Owner, conn.chanID)] = conn.membership
+}
+
+// members returns the things connected to the channel or, if the channel is
+// dynamic, the owner's things matching its membership query.
+func (trm *thingRepositoryMock) members(owner, chanID string) map[string]things.Thing {
+	mq := trm.memberships[key(owner, chanID)]
+	if len(mq) == 0 {
+		ths := make(map[string]things.Thing)
+		for id, th := range trm.tconns[chanID] {
+			if trm.live(chanID, id) {
+				ths[id] = th
+			}
+		}
+		return ths
+	}
+
+	ths := make(map[string]things.Thing)
+	for _, th := range trm.things {
+		if th.Owner == owner && mq.Matches(th.Metadata) {
+			ths[th.ID] = th
+		}
+	}
+
+	return ths
 }
 
 func (trm *thingRepositoryMock) connect(conn Connection) {
@@ -222,6 +469,7 @@ func (trm *thingRepositoryMock) connect(conn Connection) {
 		trm.tconns[conn.chanID] = make(map[string]things.Thing)
 	}
 	trm.tconns[conn.chanID][conn.thing.ID] = conn.thing
+	trm.expiry[key(conn.chanID, conn.thing.ID)] = conn.expiresAt
 }
 
 func (trm *thingRepositoryMock) disconnect(conn Connection) {
@@ -229,10 +477,21 @@ func (trm *thingRepositoryMock) disconnect(conn Connection) {
 	defer trm.mu.Unlock()
 
 	if conn.thing.ID == "" {
+		for id := range trm.tconns[conn.chanID] {
+			delete(trm.expiry, key(conn.chanID, id))
+		}
 		delete(trm.tconns, conn.chanID)
 		return
 	}
 	delete(trm.tconns[conn.chanID], conn.thing.ID)
+	delete(trm.expiry, key(conn.chanID, conn.thing.ID))
+}
+
+// live returns true if the connection of the thing to the channel hasn't
+// expired.
+func (trm *thingRepositoryMock) live(chanID, thingID string) bool {
+	exp := trm.expiry[key(chanID, thingID)]
+	return exp.IsZero() || time.Now().Before(exp)
 }
 
 type thingCacheMock struct {
@@ -0,0 +1,179 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/mainflux/mainflux/logger"
+)
+
+// maxIDPrefixSize leaves room for the generated UUID in the identifier.
+const maxIDPrefixSize = 64
+
+// MaxNameLength is the default, as well as the largest allowed, limit of the
+// thing and channel name length.
+const MaxNameLength = 1024
+
+var idPrefixRegexp = regexp.MustCompile("^[a-zA-Z0-9_-]*$")
+
+// Option configures optional behaviour of the things service.
+type Option func(*thingsService)
+
+// WithKeyTTL sets the thing key lifetime. Keys of newly created things, as
+// well as updated and rotated keys, expire after the given duration. Zero
+// duration, which is the default, means that keys never expire.
+func WithKeyTTL(ttl time.Duration) Option {
+	return func(ts *thingsService) {
+		ts.keyTTL = ttl
+	}
+}
+
+// WithKeyEncoding sets the encoding of generated thing keys. Keys are stored
+// in the chosen encoding, so the same value is returned on creation, shown
+// on view and accepted on identification. Defaults to UUIDKeyEncoding.
+func WithKeyEncoding(enc KeyEncoding) Option {
+	return func(ts *thingsService) {
+		ts.keyEncoding = enc
+	}
+}
+
+// WithIDPrefix sets the prefix of generated thing and channel identifiers,
+// e.g. the name of the environment the service runs in. Thing keys are not
+// prefixed.
+func WithIDPrefix(prefix string) Option {
+	return func(ts *thingsService) {
+		ts.idPrefix = prefix
+	}
+}
+
+// ValidIDPrefix returns true if the identifier prefix is short enough and
+// contains only characters that are safe to use in URLs and cache keys.
+func ValidIDPrefix(prefix string) bool {
+	return len(prefix) <= maxIDPrefixSize && idPrefixRegexp.MatchString(prefix)
+}
+
+// WithMaxNameLength limits the length of thing and channel names. The length
+// is measured in characters (runes) rather than bytes, so that names written
+// in non-Latin scripts aren't penalized. Defaults to MaxNameLength.
+func WithMaxNameLength(length int) Option {
+	return func(ts *thingsService) {
+		ts.maxNameLength = length
+	}
+}
+
+// ValidMaxNameLength returns true if the name length limit is positive and
+// doesn't exceed MaxNameLength.
+func ValidMaxNameLength(length int) bool {
+	return length > 0 && length <= MaxNameLength
+}
+
+// WithReservedMetadataPrefix sets the prefix of the metadata keys reserved
+// for the internal use. Thing and channel metadata containing the top-level
+// key with the prefix is rejected. Empty prefix reserves no keys. Defaults to
+// ReservedMetadataPrefix.
+func WithReservedMetadataPrefix(prefix string) Option {
+	return func(ts *thingsService) {
+		ts.reservedPrefix = prefix
+	}
+}
+
+// WithConnectionMode determines the number of channels a thing can be
+// connected to at the same time. Defaults to SharedConnections.
+func WithConnectionMode(mode ConnectionMode) Option {
+	return func(ts *thingsService) {
+		ts.connMode = mode
+	}
+}
+
+// WithMinConnections sets the number of channels a thing has to be connected
+// to before it is activated. Keys of the things that aren't activated are
+// rejected on access, even on the channels they are connected to. Since the
+// access requires the connection anyway, zero and one, the former being the
+// default, disable the activation check.
+func WithMinConnections(count uint64) Option {
+	return func(ts *thingsService) {
+		ts.minConns = count
+	}
+}
+
+// WithCatchAllChannel sets the channel every thing can access, whether it is
+// connected to the channel or not, so that the adapters can route the
+// messages published to the channels the thing isn't connected to there for
+// triage. Empty channel ID, which is the default, disables the catch-all
+// channel.
+func WithCatchAllChannel(chanID string) Option {
+	return func(ts *thingsService) {
+		ts.catchAll = chanID
+	}
+}
+
+// WithProvisionTemplate sets the template of the channels created along with
+// the provisioned thing. Defaults to DefaultProvisionTemplate.
+func WithProvisionTemplate(tpl ProvisionTemplate) Option {
+	return func(ts *thingsService) {
+		ts.provisioning = tpl
+	}
+}
+
+// WithChannelTokenizer enables issuing of channel tokens signed by the given
+// tokenizer.
+func WithChannelTokenizer(tokenizer ChannelTokenizer) Option {
+	return func(ts *thingsService) {
+		ts.tokenizer = tokenizer
+	}
+}
+
+// WithShareLinks enables creation of revocable channel share links persisted
+// in the given repository. Links point to the message reader located at the
+// given base URL.
+func WithShareLinks(links ShareLinkRepository, baseURL string) Option {
+	return func(ts *thingsService) {
+		ts.links = links
+		ts.shareURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithAPIKeys enables issuing of the scoped API keys persisted in the given
+// repository.
+func WithAPIKeys(keys APIKeyRepository) Option {
+	return func(ts *thingsService) {
+		ts.apiKeys = keys
+	}
+}
+
+// WithOwners enables the management of the owners, whose identities and
+// state are persisted in the given repository. Resources are owned by the
+// stable IDs of the owners instead of their emails, so that they survive the
+// email changes. Keys of the things that belong to the disabled owner are
+// rejected until the owner is enabled again.
+func WithOwners(owners OwnerRepository) Option {
+	return func(ts *thingsService) {
+		ts.owners = owners
+	}
+}
+
+// WithAuthorizer sets the authorization policy engine consulted for every
+// operation performed on behalf of a user. Defaults to the owner based
+// authorizer returned by NewOwnerAuthorizer.
+func WithAuthorizer(auth Authorizer) Option {
+	return func(ts *thingsService) {
+		ts.auth = auth
+	}
+}
+
+// WithLogger sets the logger of the failures that aren't returned to the
+// caller, e.g. of persisting the time the thing was last seen at. Such
+// failures are ignored without the logger.
+func WithLogger(logger log.Logger) Option {
+	return func(ts *thingsService) {
+		ts.logger = logger
+	}
+}
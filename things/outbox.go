@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxEvent is the encoded event pending delivery to the event sink. Events
+// failing to be delivered are retried once their next attempt is due.
+type OutboxEvent struct {
+	ID          uint64
+	Event       map[string]interface{}
+	Attempts    uint64
+	NextAttempt time.Time
+}
+
+// OutboxRepository specifies the persistence API of the events pending
+// delivery, which keeps them across the service restarts.
+type OutboxRepository interface {
+	// Save persists the event, whose ID is assigned by the repository.
+	Save(context.Context, OutboxEvent) error
+
+	// RetrieveDue retrieves up to the given number of the undelivered events
+	// whose next attempt is due at the given time, in the order they were
+	// saved.
+	RetrieveDue(context.Context, time.Time, uint64) ([]OutboxEvent, error)
+
+	// Retry counts the failed delivery attempt of the event with the given
+	// ID and schedules the next attempt at the given time.
+	Retry(context.Context, uint64, time.Time) error
+
+	// MarkDelivered marks the event with the given ID as delivered at the
+	// given time, so that it isn't retrieved anymore.
+	MarkDelivered(context.Context, uint64, time.Time) error
+
+	// RemoveDelivered removes the events delivered before the given time.
+	RemoveDelivered(context.Context, time.Time) error
+}
+
+var _ EventSink = (*outboxSink)(nil)
+
+type outboxSink struct {
+	repo OutboxRepository
+}
+
+// NewOutboxSink returns the event sink that saves the events to the outbox,
+// from which they are delivered by the dispatcher. Event is accepted as soon
+// as it's saved, so it isn't lost if the service restarts before the event is
+// delivered.
+func NewOutboxSink(repo OutboxRepository) EventSink {
+	return outboxSink{repo: repo}
+}
+
+func (obs outboxSink) Publish(event map[string]interface{}) error {
+	oe := OutboxEvent{
+		Event:       event,
+		NextAttempt: time.Now(),
+	}
+
+	return obs.repo.Save(context.Background(), oe)
+}
+
+// Dispatcher delivers the events pending in the outbox to the event sink,
+// e.g. the webhook of the cloud queue. Event is marked as delivered only once
+// the sink accepts it, so every event is delivered at least once, possibly
+// more than once if the service stops between the delivery and the marking.
+type Dispatcher struct {
+	repo       OutboxRepository
+	sink       EventSink
+	batch      uint64
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewDispatcher returns the dispatcher delivering up to the batch number of
+// events per dispatch. Failed delivery is retried after the backoff, which
+// starts at the minimum and doubles with every failed attempt, up to the
+// maximum.
+func NewDispatcher(repo OutboxRepository, sink EventSink, batch uint64, minBackoff, maxBackoff time.Duration) *Dispatcher {
+	return &Dispatcher{
+		repo:       repo,
+		sink:       sink,
+		batch:      batch,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// Dispatch delivers the events due at the given time and returns the number
+// of the delivered ones. Events failing to be delivered are scheduled for the
+// next attempt without stopping the dispatch, so the events aren't
+// necessarily delivered in the order they were published.
+func (d *Dispatcher) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	events, err := d.repo.RetrieveDue(ctx, now, d.batch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, event := range events {
+		if err := d.sink.Publish(event.Event); err != nil {
+			next := now.Add(d.Backoff(event.Attempts + 1))
+			if err := d.repo.Retry(ctx, event.ID, next); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		if err := d.repo.MarkDelivered(ctx, event.ID, now); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// Backoff returns the delay before the next delivery attempt of the event
+// that failed the given number of attempts.
+func (d *Dispatcher) Backoff(attempts uint64) time.Duration {
+	backoff := d.minBackoff
+	for i := uint64(1); i < attempts && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > d.maxBackoff {
+		return d.maxBackoff
+	}
+
+	return backoff
+}
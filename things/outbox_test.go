@@ -0,0 +1,189 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 10 * time.Second
+)
+
+var errUnavailable = errors.New("webhook unavailable")
+
+// webhookMock fails the given number of deliveries and records the events it
+// accepts, as well as the number of the delivery attempts.
+type webhookMock struct {
+	failures  int
+	attempts  int
+	delivered []map[string]interface{}
+}
+
+func (wm *webhookMock) Publish(event map[string]interface{}) error {
+	wm.attempts++
+	if wm.failures > 0 {
+		wm.failures--
+		return errUnavailable
+	}
+
+	wm.delivered = append(wm.delivered, event)
+	return nil
+}
+
+// unmarkedOutbox fails to mark the events as delivered, as if the service
+// stopped right after delivering them.
+type unmarkedOutbox struct {
+	things.OutboxRepository
+}
+
+func (uo unmarkedOutbox) MarkDelivered(context.Context, uint64, time.Time) error {
+	return errUnavailable
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	dispatcher := things.NewDispatcher(mocks.NewOutboxRepository(), &webhookMock{}, 10, minBackoff, maxBackoff)
+
+	cases := map[uint64]time.Duration{
+		1:  minBackoff,
+		2:  2 * minBackoff,
+		3:  4 * minBackoff,
+		4:  8 * minBackoff,
+		5:  maxBackoff,
+		64: maxBackoff,
+	}
+
+	for attempts, backoff := range cases {
+		got := dispatcher.Backoff(attempts)
+		assert.Equal(t, backoff, got, fmt.Sprintf("backoff after %d attempts: expected %s got %s", attempts, backoff, got))
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	outbox := mocks.NewOutboxRepository()
+	webhook := &webhookMock{failures: 2}
+	dispatcher := things.NewDispatcher(outbox, webhook, 10, minBackoff, maxBackoff)
+
+	event := map[string]interface{}{"operation": things.ThingCreate, "id": "1"}
+	err := things.NewOutboxSink(outbox).Publish(event)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	now := time.Now()
+	cases := []struct {
+		desc      string
+		now       time.Time
+		delivered int
+		attempts  int
+	}{
+		{
+			desc:      "dispatch event to unavailable webhook",
+			now:       now,
+			delivered: 0,
+			attempts:  1,
+		},
+		{
+			desc:      "dispatch event before backoff elapses",
+			now:       now.Add(minBackoff / 2),
+			delivered: 0,
+			attempts:  1,
+		},
+		{
+			desc:      "dispatch event to webhook still unavailable",
+			now:       now.Add(minBackoff),
+			delivered: 0,
+			attempts:  2,
+		},
+		{
+			desc:      "dispatch event before doubled backoff elapses",
+			now:       now.Add(2 * minBackoff),
+			delivered: 0,
+			attempts:  2,
+		},
+		{
+			desc:      "dispatch event to available webhook",
+			now:       now.Add(3 * minBackoff),
+			delivered: 1,
+			attempts:  3,
+		},
+		{
+			desc:      "dispatch delivered event",
+			now:       now.Add(maxBackoff),
+			delivered: 0,
+			attempts:  3,
+		},
+	}
+
+	for _, tc := range cases {
+		delivered, err := dispatcher.Dispatch(context.Background(), tc.now)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.delivered, delivered, fmt.Sprintf("%s: expected %d delivered events got %d", tc.desc, tc.delivered, delivered))
+		assert.Equal(t, tc.attempts, webhook.attempts, fmt.Sprintf("%s: expected %d delivery attempts got %d", tc.desc, tc.attempts, webhook.attempts))
+	}
+
+	assert.Equal(t, []map[string]interface{}{event}, webhook.delivered, fmt.Sprintf("expected delivered events %v got %v", []map[string]interface{}{event}, webhook.delivered))
+}
+
+func TestDispatchAfterRestart(t *testing.T) {
+	event := map[string]interface{}{"operation": things.ThingRemove, "id": "1"}
+
+	cases := []struct {
+		desc      string
+		outbox    func(things.OutboxRepository) things.OutboxRepository
+		failures  int
+		delivered int
+	}{
+		{
+			desc:      "deliver event that failed before restart",
+			outbox:    func(repo things.OutboxRepository) things.OutboxRepository { return repo },
+			failures:  1,
+			delivered: 1,
+		},
+		{
+			desc:      "redeliver event that wasn't marked as delivered before restart",
+			outbox:    func(repo things.OutboxRepository) things.OutboxRepository { return unmarkedOutbox{repo} },
+			failures:  0,
+			delivered: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		// Outbox repository outlives the service, like the database does.
+		outbox := mocks.NewOutboxRepository()
+		err := things.NewOutboxSink(outbox).Publish(event)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		now := time.Now()
+
+		webhook := &webhookMock{failures: tc.failures}
+		dispatcher := things.NewDispatcher(tc.outbox(outbox), webhook, 10, minBackoff, maxBackoff)
+		dispatcher.Dispatch(context.Background(), now)
+		assert.Equal(t, 1, webhook.attempts, fmt.Sprintf("%s: expected delivery attempt before restart", tc.desc))
+
+		// Restarted service creates the new dispatcher of the same outbox.
+		dispatcher = things.NewDispatcher(outbox, webhook, 10, minBackoff, maxBackoff)
+		_, err = dispatcher.Dispatch(context.Background(), now.Add(maxBackoff))
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		_, err = dispatcher.Dispatch(context.Background(), now.Add(2*maxBackoff))
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		assert.Equal(t, tc.delivered, len(webhook.delivered), fmt.Sprintf("%s: expected %d deliveries got %d", tc.desc, tc.delivered, len(webhook.delivered)))
+		for _, delivered := range webhook.delivered {
+			assert.Equal(t, event, delivered, fmt.Sprintf("%s: expected delivered event %v got %v", tc.desc, event, delivered))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "context"
+
+// OwnerRepository specifies the persistence API of the owners' identities
+// and state, e.g. of the suspended accounts. Owners are referred to by their
+// stable IDs, which outlive the changes of their emails, and are enabled
+// unless disabled explicitly.
+type OwnerRepository interface {
+	// RetrieveByEmail returns the ID of the owner having the specified email,
+	// or ErrNotFound if the owner is unknown.
+	RetrieveByEmail(ctx context.Context, email string) (string, error)
+
+	// Resolve returns the ID of the owner having the specified email. The
+	// unknown owner is saved with the given ID, which is returned then.
+	Resolve(ctx context.Context, email, id string) (string, error)
+
+	// ChangeEmail replaces the email of the owner, keeping its ID. It
+	// returns ErrNotFound if the owner is unknown, and ErrConflict if the
+	// new email belongs to another owner.
+	ChangeEmail(ctx context.Context, email, newEmail string) error
+
+	// Disable marks the owner identified by the provided ID as disabled.
+	// Disabling the disabled owner has no effect.
+	Disable(context.Context, string) error
+
+	// Enable clears the disabled mark of the owner identified by the
+	// provided ID. Enabling the enabled owner has no effect.
+	Enable(context.Context, string) error
+
+	// Disabled returns true if the owner identified by the provided ID is
+	// disabled.
+	Disabled(context.Context, string) (bool, error)
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.APIKeyRepository = (*apiKeyRepository)(nil)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeyRepository instantiates a PostgreSQL implementation of API key
+// repository.
+func NewAPIKeyRepository(db *sqlx.DB) things.APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (akr apiKeyRepository) Save(_ context.Context, key things.APIKey) error {
+	q := `INSERT INTO api_keys (id, key_hash, owner, scopes, issued_at, expires_at)
+	      VALUES (:id, :key_hash, :owner, :scopes, :issued_at, :expires_at);`
+
+	if _, err := akr.db.NamedExec(q, toDBAPIKey(key)); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation:
+				return things.ErrMalformedEntity
+			case errDuplicate:
+				return things.ErrConflict
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (akr apiKeyRepository) RetrieveByKey(_ context.Context, value string) (things.APIKey, error) {
+	q := `SELECT id, key_hash, owner, scopes, issued_at, expires_at FROM api_keys WHERE key_hash = $1;`
+
+	var dbak dbAPIKey
+	if err := akr.db.QueryRowx(q, value).StructScan(&dbak); err != nil {
+		if err == sql.ErrNoRows {
+			return things.APIKey{}, things.ErrNotFound
+		}
+
+		return things.APIKey{}, err
+	}
+
+	return toAPIKey(dbak), nil
+}
+
+func (akr apiKeyRepository) RetrieveAll(_ context.Context, owner string) ([]things.APIKey, error) {
+	q := `SELECT id, key_hash, owner, scopes, issued_at, expires_at FROM api_keys WHERE owner = $1 ORDER BY id;`
+
+	rows, err := akr.db.Queryx(q, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []things.APIKey{}
+	for rows.Next() {
+		var dbak dbAPIKey
+		if err := rows.StructScan(&dbak); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, toAPIKey(dbak))
+	}
+
+	return keys, nil
+}
+
+func (akr apiKeyRepository) Remove(_ context.Context, owner, id string) error {
+	q := `DELETE FROM api_keys WHERE id = $1 AND owner = $2;`
+
+	akr.db.Exec(q, id, owner)
+	return nil
+}
+
+type dbAPIKey struct {
+	ID        string         `db:"id"`
+	Key       string         `db:"key_hash"`
+	Owner     string         `db:"owner"`
+	Scopes    pq.StringArray `db:"scopes"`
+	IssuedAt  time.Time      `db:"issued_at"`
+	ExpiresAt pq.NullTime    `db:"expires_at"`
+}
+
+func toDBAPIKey(key things.APIKey) dbAPIKey {
+	return dbAPIKey{
+		ID:        key.ID,
+		Key:       key.Key,
+		Owner:     key.Owner,
+		Scopes:    pq.StringArray(key.Scopes),
+		IssuedAt:  key.IssuedAt,
+		ExpiresAt: toNullTime(key.ExpiresAt),
+	}
+}
+
+func toAPIKey(dbak dbAPIKey) things.APIKey {
+	key := things.APIKey{
+		ID:       dbak.ID,
+		Key:      dbak.Key,
+		Owner:    dbak.Owner,
+		Scopes:   []string(dbak.Scopes),
+		IssuedAt: dbak.IssuedAt,
+	}
+	if dbak.ExpiresAt.Valid {
+		key.ExpiresAt = dbak.ExpiresAt.Time
+	}
+
+	return key
+}
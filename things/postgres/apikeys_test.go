@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/mainflux/mainflux/things/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeySave(t *testing.T) {
+	email := "api-key-save@example.com"
+	keyRepo := postgres.NewAPIKeyRepository(db)
+
+	id, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	value, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	key := things.APIKey{
+		ID:       id,
+		Key:      things.APIKeyPrefix + value,
+		Owner:    email,
+		Scopes:   []string{"thing:read"},
+		IssuedAt: time.Now(),
+	}
+
+	cases := []struct {
+		desc string
+		key  things.APIKey
+		err  error
+	}{
+		{
+			desc: "save valid key",
+			key:  key,
+			err:  nil,
+		},
+		{
+			desc: "save existing key",
+			key:  key,
+			err:  things.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		err := keyRepo.Save(context.Background(), tc.key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestAPIKeyRetrieveAndRemove(t *testing.T) {
+	email := "api-key-retrieve@example.com"
+	keyRepo := postgres.NewAPIKeyRepository(db)
+
+	id, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	value, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	key := things.APIKey{
+		ID:        id,
+		Key:       things.APIKeyPrefix + value,
+		Owner:     email,
+		Scopes:    []string{"thing:read", "channel:*"},
+		IssuedAt:  time.Now().Round(time.Second),
+		ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+	}
+	err = keyRepo.Save(context.Background(), key)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	rk, err := keyRepo.RetrieveByKey(context.Background(), key.Key)
+	assert.Nil(t, err, fmt.Sprintf("retrieve key: got unexpected error: %s", err))
+	assert.Equal(t, key.ID, rk.ID, fmt.Sprintf("retrieve key: expected ID %s got %s", key.ID, rk.ID))
+	assert.Equal(t, key.Scopes, rk.Scopes, fmt.Sprintf("retrieve key: expected scopes %v got %v", key.Scopes, rk.Scopes))
+	assert.True(t, key.ExpiresAt.Equal(rk.ExpiresAt), fmt.Sprintf("retrieve key: expected expiry %s got %s", key.ExpiresAt, rk.ExpiresAt))
+
+	_, err = keyRepo.RetrieveByKey(context.Background(), things.APIKeyPrefix+id)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve unknown key: expected %s got %s", things.ErrNotFound, err))
+
+	keys, err := keyRepo.RetrieveAll(context.Background(), email)
+	assert.Nil(t, err, fmt.Sprintf("retrieve keys: got unexpected error: %s", err))
+	assert.Equal(t, 1, len(keys), fmt.Sprintf("retrieve keys: expected 1 key got %d", len(keys)))
+
+	err = keyRepo.Remove(context.Background(), "other@example.com", key.ID)
+	assert.Nil(t, err, fmt.Sprintf("remove key of other user: got unexpected error: %s", err))
+	_, err = keyRepo.RetrieveByKey(context.Background(), key.Key)
+	assert.Nil(t, err, fmt.Sprintf("remove key of other user: expected key to remain, got %s", err))
+
+	err = keyRepo.Remove(context.Background(), email, key.ID)
+	assert.Nil(t, err, fmt.Sprintf("remove key: got unexpected error: %s", err))
+	_, err = keyRepo.RetrieveByKey(context.Background(), key.Key)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("remove key: expected %s got %s", things.ErrNotFound, err))
+}
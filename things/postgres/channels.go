@@ -13,8 +13,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/mainflux/mainflux/things"
@@ -23,20 +23,27 @@ import (
 var _ things.ChannelRepository = (*channelRepository)(nil)
 
 type channelRepository struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	idPrefix string
 }
 
 // NewChannelRepository instantiates a PostgreSQL implementation of channel
-// repository.
-func NewChannelRepository(db *sqlx.DB) things.ChannelRepository {
+// repository. Thing and channel IDs are expected to be UUIDs, optionally
+// preceded by the given ID prefix.
+func NewChannelRepository(db *sqlx.DB, idPrefix string) things.ChannelRepository {
 	return &channelRepository{
-		db: db,
+		db:       db,
+		idPrefix: idPrefix,
 	}
 }
 
 func (cr channelRepository) Save(_ context.Context, channel things.Channel) (string, error) {
-	q := `INSERT INTO channels (id, owner, name, metadata)
-        VALUES (:id, :owner, :name, :metadata);`
+	q := `INSERT INTO channels (id, owner, parent_id, name, metadata, membership, protected, publisher_scoped)
+        VALUES (:id, :owner, :parent_id, :name, :metadata, :membership, :protected, :publisher_scoped);`
+
+	if !validID(cr.idPrefix, channel.ID) || (channel.ParentID != "" && !validID(cr.idPrefix, channel.ParentID)) {
+		return "", things.ErrMalformedEntity
+	}
 
 	dbch, err := toDBChannel(channel)
 	if err != nil {
@@ -59,7 +66,12 @@ func (cr channelRepository) Save(_ context.Context, channel things.Channel) (str
 }
 
 func (cr channelRepository) Update(_ context.Context, channel things.Channel) error {
-	q := `UPDATE channels SET name = :name, metadata = :metadata WHERE owner = :owner AND id = :id;`
+	q := `UPDATE channels SET parent_id = :parent_id, name = :name, metadata = :metadata, membership = :membership,
+	      protected = :protected, publisher_scoped = :publisher_scoped WHERE owner = :owner AND id = :id;`
+
+	if !validID(cr.idPrefix, channel.ID) || (channel.ParentID != "" && !validID(cr.idPrefix, channel.ParentID)) {
+		return things.ErrMalformedEntity
+	}
 
 	dbch, err := toDBChannel(channel)
 	if err != nil {
@@ -92,7 +104,7 @@ func (cr channelRepository) Update(_ context.Context, channel things.Channel) er
 }
 
 func (cr channelRepository) RetrieveByID(_ context.Context, owner, id string) (things.Channel, error) {
-	q := `SELECT name, metadata FROM channels WHERE id = $1 AND owner = $2;`
+	q := `SELECT parent_id, name, metadata, membership, protected, publisher_scoped FROM channels WHERE id = $1 AND owner = $2;`
 	dbch := dbChannel{
 		ID:    id,
 		Owner: owner,
@@ -109,7 +121,75 @@ func (cr channelRepository) RetrieveByID(_ context.Context, owner, id string) (t
 	return toChannel(dbch)
 }
 
-func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string) (things.ChannelsPage, error) {
+func (cr channelRepository) RetrieveOwner(_ context.Context, id string) (string, error) {
+	if !validID(cr.idPrefix, id) {
+		return "", things.ErrNotFound
+	}
+
+	var owner string
+	if err := cr.db.QueryRowx(`SELECT owner FROM channels WHERE id = $1;`, id).Scan(&owner); err != nil {
+		if err == sql.ErrNoRows {
+			return "", things.ErrNotFound
+		}
+		return "", err
+	}
+
+	return owner, nil
+}
+
+func (cr channelRepository) RetrieveTokenVersion(_ context.Context, id string) (uint64, error) {
+	if !validID(cr.idPrefix, id) {
+		return 0, things.ErrNotFound
+	}
+
+	var version uint64
+	if err := cr.db.QueryRowx(`SELECT token_version FROM channels WHERE id = $1;`, id).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, things.ErrNotFound
+		}
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (cr channelRepository) RevokeTokens(_ context.Context, owner, id string) error {
+	if !validID(cr.idPrefix, id) {
+		return things.ErrNotFound
+	}
+
+	q := `UPDATE channels SET token_version = token_version + 1 WHERE owner = $1 AND id = $2;`
+
+	res, err := cr.db.Exec(q, owner, id)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name, parent string, compact bool) (things.ChannelsPage, error) {
+	// The subtree of the parent channel is resolved using recursive query,
+	// while the top-level listing reads from the channels table directly.
+	from := `channels`
+	if parent != "" {
+		// Verify if ID format is valid to avoid needless DB round trips
+		if !validID(cr.idPrefix, parent) {
+			return things.ChannelsPage{}, things.ErrNotFound
+		}
+
+		from = `subtree`
+	}
+
 	name = strings.ToLower(name)
 	nq := ""
 	if name != "" {
@@ -117,11 +197,17 @@ func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset,
 		nq = `AND LOWER(name) LIKE :name`
 	}
 
-	q := fmt.Sprintf(`SELECT id, name, metadata FROM channels
-	      WHERE owner = :owner %s ORDER BY id LIMIT :limit OFFSET :offset;`, nq)
+	cols := `id, parent_id, name, metadata, membership, protected, publisher_scoped`
+	if compact {
+		cols = `id, name`
+	}
+
+	q := fmt.Sprintf(`%s SELECT %s FROM %s
+	      WHERE owner = :owner %s ORDER BY id LIMIT :limit OFFSET :offset;`, subtreeQuery(parent), cols, from, nq)
 
 	params := map[string]interface{}{
 		"owner":  owner,
+		"parent": parent,
 		"limit":  limit,
 		"offset": offset,
 		"name":   name,
@@ -134,7 +220,8 @@ func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset,
 
 	items := []things.Channel{}
 	for rows.Next() {
-		dbch := dbChannel{Owner: owner}
+		// Compact listing doesn't select the metadata, which is left nil.
+		dbch := dbChannel{Owner: owner, Metadata: "null"}
 		if err := rows.StructScan(&dbch); err != nil {
 			return things.ChannelsPage{}, err
 		}
@@ -146,23 +233,11 @@ func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset,
 		items = append(items, ch)
 	}
 
-	cq := ""
-	if name != "" {
-		cq = `AND LOWER(name) LIKE $2`
-	}
-
-	q = fmt.Sprintf(`SELECT COUNT(*) FROM channels WHERE owner = $1 %s;`, cq)
+	q = fmt.Sprintf(`%s SELECT COUNT(*) FROM %s WHERE owner = :owner %s;`, subtreeQuery(parent), from, nq)
 
-	total := uint64(0)
-	switch name {
-	case "":
-		if err := cr.db.Get(&total, q, owner); err != nil {
-			return things.ChannelsPage{}, err
-		}
-	default:
-		if err := cr.db.Get(&total, q, owner, name); err != nil {
-			return things.ChannelsPage{}, err
-		}
+	total, err := cr.total(q, params)
+	if err != nil {
+		return things.ChannelsPage{}, err
 	}
 
 	page := things.ChannelsPage{
@@ -177,17 +252,79 @@ func (cr channelRepository) RetrieveAll(_ context.Context, owner string, offset,
 	return page, nil
 }
 
+func (cr channelRepository) total(query string, params map[string]interface{}) (uint64, error) {
+	rows, err := cr.db.NamedQuery(query, params)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	total := uint64(0)
+	if rows.Next() {
+		if err := rows.Scan(&total); err != nil {
+			return 0, err
+		}
+	}
+
+	return total, nil
+}
+
+// subtreeQuery returns the common table expression that resolves all the
+// descendants of the parent channel. UNION is used instead of UNION ALL so
+// that the query terminates even if the hierarchy is corrupted.
+func subtreeQuery(parent string) string {
+	if parent == "" {
+		return ""
+	}
+
+	return `WITH RECURSIVE subtree AS (
+	          SELECT id, owner, parent_id, name, metadata, membership, protected, publisher_scoped FROM channels
+	          WHERE owner = :owner AND parent_id = :parent
+	          UNION
+	          SELECT ch.id, ch.owner, ch.parent_id, ch.name, ch.metadata, ch.membership, ch.protected, ch.publisher_scoped FROM channels ch
+	          INNER JOIN subtree st ON ch.owner = st.owner AND ch.parent_id = st.id
+	        )`
+}
+
+func (cr channelRepository) RetrieveDynamic(_ context.Context, owner string) ([]things.Channel, error) {
+	q := `SELECT id, parent_id, name, metadata, membership, protected, publisher_scoped FROM channels
+	      WHERE owner = $1 AND membership IS NOT NULL ORDER BY id;`
+
+	rows, err := cr.db.Queryx(q, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []things.Channel{}
+	for rows.Next() {
+		dbch := dbChannel{Owner: owner}
+		if err := rows.StructScan(&dbch); err != nil {
+			return nil, err
+		}
+
+		ch, err := toChannel(dbch)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, ch)
+	}
+
+	return items, nil
+}
+
 func (cr channelRepository) RetrieveByThing(_ context.Context, owner, thing string, offset, limit uint64) (things.ChannelsPage, error) {
-	// Verify if UUID format is valid to avoid internal Postgres error
-	if _, err := uuid.FromString(thing); err != nil {
+	// Verify if ID format is valid to avoid needless DB round trips
+	if !validID(cr.idPrefix, thing) {
 		return things.ChannelsPage{}, things.ErrNotFound
 	}
 
-	q := `SELECT id, name, metadata
+	q := `SELECT id, parent_id, name, metadata, protected, publisher_scoped
 	      FROM channels ch
 	      INNER JOIN connections co
 		  ON ch.id = co.channel_id
-		  WHERE ch.owner = :owner AND co.thing_id = :thing
+		  WHERE ch.owner = :owner AND co.thing_id = :thing AND ` + liveCondition + `
 		  ORDER BY ch.id
 		  LIMIT :limit
 		  OFFSET :offset`
@@ -224,7 +361,7 @@ func (cr channelRepository) RetrieveByThing(_ context.Context, owner, thing stri
 	     FROM channels ch
 	     INNER JOIN connections co
 	     ON ch.id = co.channel_id
-	     WHERE ch.owner = $1 AND co.thing_id = $2`
+	     WHERE ch.owner = $1 AND co.thing_id = $2 AND ` + liveCondition
 
 	var total uint64
 	if err := cr.db.Get(&total, q, owner, thing); err != nil {
@@ -246,19 +383,26 @@ func (cr channelRepository) Remove(_ context.Context, owner, id string) error {
 		ID:    id,
 		Owner: owner,
 	}
-	q := `DELETE FROM channels WHERE id = :id AND owner = :owner`
+	q := `UPDATE channels SET parent_id = NULL WHERE parent_id = :id AND owner = :owner`
+	cr.db.NamedExec(q, dbch)
+
+	q = `DELETE FROM channels WHERE id = :id AND owner = :owner`
 	cr.db.NamedExec(q, dbch)
 	return nil
 }
 
-func (cr channelRepository) Connect(_ context.Context, owner, chanID, thingID string) error {
-	q := `INSERT INTO connections (channel_id, channel_owner, thing_id, thing_owner)
-	      VALUES (:channel, :owner, :thing, :owner);`
+func (cr channelRepository) Connect(_ context.Context, owner, chanID, thingID string, expiresAt time.Time) error {
+	// connect is idempotent, apart from replacing the expiry
+	q := `INSERT INTO connections (channel_id, channel_owner, thing_id, thing_owner, expires_at)
+	      VALUES (:channel, :owner, :thing, :owner, :expires_at)
+	      ON CONFLICT (channel_id, channel_owner, thing_id, thing_owner)
+	      DO UPDATE SET expires_at = EXCLUDED.expires_at;`
 
 	conn := dbConnection{
-		Channel: chanID,
-		Thing:   thingID,
-		Owner:   owner,
+		Channel:   chanID,
+		Thing:     thingID,
+		Owner:     owner,
+		ExpiresAt: toNullTime(expiresAt),
 	}
 
 	if _, err := cr.db.NamedExec(q, conn); err != nil {
@@ -268,11 +412,6 @@ func (cr channelRepository) Connect(_ context.Context, owner, chanID, thingID st
 			return things.ErrNotFound
 		}
 
-		// connect is idempotent
-		if ok && errDuplicate == pqErr.Code.Name() {
-			return nil
-		}
-
 		return err
 	}
 
@@ -307,6 +446,34 @@ func (cr channelRepository) Disconnect(_ context.Context, owner, chanID, thingID
 	return nil
 }
 
+func (cr channelRepository) RemoveExpired(_ context.Context, t time.Time) ([]things.Connection, error) {
+	q := `DELETE FROM connections WHERE expires_at <= $1
+	      RETURNING channel_id AS channel, thing_id AS thing, channel_owner AS owner, expires_at;`
+
+	rows, err := cr.db.Queryx(q, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conns := []things.Connection{}
+	for rows.Next() {
+		var dbconn dbConnection
+		if err := rows.StructScan(&dbconn); err != nil {
+			return nil, err
+		}
+
+		conns = append(conns, things.Connection{
+			ChanID:    dbconn.Channel,
+			ThingID:   dbconn.Thing,
+			Owner:     dbconn.Owner,
+			ExpiresAt: dbconn.ExpiresAt.Time,
+		})
+	}
+
+	return conns, nil
+}
+
 func (cr channelRepository) HasThing(_ context.Context, chanID, key string) (string, error) {
 	var thingID string
 
@@ -316,7 +483,7 @@ func (cr channelRepository) HasThing(_ context.Context, chanID, key string) (str
 
 	}
 
-	if err := cr.hasThing(chanID, thingID); err != nil {
+	if _, err := cr.hasThing(chanID, thingID); err != nil {
 		return "", err
 
 	}
@@ -324,70 +491,62 @@ func (cr channelRepository) HasThing(_ context.Context, chanID, key string) (str
 	return thingID, nil
 }
 
-func (cr channelRepository) HasThingByID(_ context.Context, chanID, thingID string) error {
+func (cr channelRepository) HasThingByID(_ context.Context, chanID, thingID string) (time.Time, error) {
 	return cr.hasThing(chanID, thingID)
 }
 
-func (cr channelRepository) hasThing(chanID, thingID string) error {
-	q := `SELECT EXISTS (SELECT 1 FROM connections WHERE channel_id = $1 AND thing_id = $2);`
-	exists := false
-	if err := cr.db.QueryRow(q, chanID, thingID).Scan(&exists); err != nil {
-		return err
-	}
-
-	if !exists {
-		return things.ErrUnauthorizedAccess
+func (cr channelRepository) hasThing(chanID, thingID string) (time.Time, error) {
+	q := fmt.Sprintf(`SELECT (
+	        SELECT co.expires_at FROM connections co
+	        WHERE co.channel_id = ch.id AND co.channel_owner = ch.owner AND co.thing_id = th.id AND co.thing_owner = th.owner
+	      )
+	      FROM channels ch, things th
+	      WHERE ch.id = $1 AND th.id = $2 AND th.owner = ch.owner AND %s;`, memberCondition)
+
+	var expiresAt pq.NullTime
+	if err := cr.db.QueryRow(q, chanID, thingID).Scan(&expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, things.ErrUnauthorizedAccess
+		}
+		return time.Time{}, err
 	}
 
-	return nil
+	return expiresAt.Time, nil
 }
 
-type dbChannel struct {
-	ID       string `db:"id"`
-	Owner    string `db:"owner"`
-	Name     string `db:"name"`
-	Metadata string `db:"metadata"`
+func (cr channelRepository) CountThings(_ context.Context, owner, chanID string) (uint64, error) {
+	q := `SELECT COUNT(*) FROM connections co WHERE channel_id = $1 AND channel_owner = $2 AND ` + liveCondition + `;`
+	return cr.count(q, chanID, owner)
 }
 
-func toDBChannel(ch things.Channel) (dbChannel, error) {
-	data, err := json.Marshal(ch.Metadata)
-	if err != nil {
-		return dbChannel{}, err
-	}
-
-	return dbChannel{
-		ID:       ch.ID,
-		Owner:    ch.Owner,
-		Name:     ch.Name,
-		Metadata: string(data),
-	}, nil
+func (cr channelRepository) CountChannels(_ context.Context, owner, thingID string) (uint64, error) {
+	q := `SELECT COUNT(*) FROM connections co WHERE thing_id = $1 AND thing_owner = $2 AND ` + liveCondition + `;`
+	return cr.count(q, thingID, owner)
 }
 
-func toChannel(ch dbChannel) (things.Channel, error) {
-	var metadata map[string]interface{}
-	if err := json.Unmarshal([]byte(ch.Metadata), &metadata); err != nil {
-		return things.Channel{}, err
+func (cr channelRepository) count(q, id, owner string) (uint64, error) {
+	// Verify if ID format is valid to avoid needless DB round trips
+	if !validID(cr.idPrefix, id) {
+		return 0, things.ErrNotFound
 	}
 
-	return things.Channel{
-		ID:       ch.ID,
-		Owner:    ch.Owner,
-		Name:     ch.Name,
-		Metadata: metadata,
-	}, nil
-}
+	var count uint64
+	if err := cr.db.QueryRow(q, id, owner).Scan(&count); err != nil {
+		return 0, err
+	}
 
-type dbConnection struct {
-	Channel string `db:"channel"`
-	Thing   string `db:"thing"`
-	Owner   string `db:"owner"`
+	return count, nil
 }
 
 type dbChannel struct {
-	ID       string `db:"id"`
-	Owner    string `db:"owner"`
-	Name     string `db:"name"`
-	Metadata string `db:"metadata"`
+	ID         string         `db:"id"`
+	Owner      string         `db:"owner"`
+	ParentID   sql.NullString `db:"parent_id"`
+	Name       string         `db:"name"`
+	Metadata   string         `db:"metadata"`
+	Membership sql.NullString `db:"membership"`
+	Protected  bool           `db:"protected"`
+	Scoped     bool           `db:"publisher_scoped"`
 }
 
 func toDBChannel(ch things.Channel) (dbChannel, error) {
@@ -396,11 +555,26 @@ func toDBChannel(ch things.Channel) (dbChannel, error) {
 		return dbChannel{}, err
 	}
 
+	// Channels without the membership query store NULL, so that they are
+	// told apart from the dynamic channels by the queries.
+	var membership sql.NullString
+	if len(ch.Membership) > 0 {
+		mq, err := json.Marshal(ch.Membership)
+		if err != nil {
+			return dbChannel{}, err
+		}
+		membership = sql.NullString{String: string(mq), Valid: true}
+	}
+
 	return dbChannel{
-		ID:       ch.ID,
-		Owner:    ch.Owner,
-		Name:     ch.Name,
-		Metadata: string(data),
+		ID:         ch.ID,
+		Owner:      ch.Owner,
+		ParentID:   sql.NullString{String: ch.ParentID, Valid: ch.ParentID != ""},
+		Name:       ch.Name,
+		Metadata:   string(data),
+		Membership: membership,
+		Protected:  ch.Protected,
+		Scoped:     ch.PublisherScoped,
 	}, nil
 }
 
@@ -410,16 +584,28 @@ func toChannel(ch dbChannel) (things.Channel, error) {
 		return things.Channel{}, err
 	}
 
+	var membership things.MembershipQuery
+	if ch.Membership.Valid {
+		if err := json.Unmarshal([]byte(ch.Membership.String), &membership); err != nil {
+			return things.Channel{}, err
+		}
+	}
+
 	return things.Channel{
-		ID:       ch.ID,
-		Owner:    ch.Owner,
-		Name:     ch.Name,
-		Metadata: metadata,
+		ID:              ch.ID,
+		Owner:           ch.Owner,
+		ParentID:        ch.ParentID.String,
+		Name:            ch.Name,
+		Metadata:        metadata,
+		Membership:      membership,
+		Protected:       ch.Protected,
+		PublisherScoped: ch.Scoped,
 	}, nil
 }
 
 type dbConnection struct {
-	Channel string `db:"channel"`
-	Thing   string `db:"thing"`
-	Owner   string `db:"owner"`
+	Channel   string      `db:"channel"`
+	Thing     string      `db:"thing"`
+	Owner     string      `db:"owner"`
+	ExpiresAt pq.NullTime `db:"expires_at"`
 }
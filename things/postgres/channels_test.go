@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -22,7 +23,7 @@ import (
 
 func TestChannelSave(t *testing.T) {
 	email := "channel-save@example.com"
-	channelRepo := postgres.NewChannelRepository(db)
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
 
 	id, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -69,7 +70,7 @@ func TestChannelSave(t *testing.T) {
 
 func TestChannelUpdate(t *testing.T) {
 	email := "channel-update@example.com"
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 
 	cid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -128,8 +129,8 @@ func TestChannelUpdate(t *testing.T) {
 
 func TestSingleChannelRetrieval(t *testing.T) {
 	email := "channel-single-retrieval@example.com"
-	chanRepo := postgres.NewChannelRepository(db)
-	thingRepo := postgres.NewThingRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -152,10 +153,7 @@ func TestSingleChannelRetrieval(t *testing.T) {
 	}
 
 	c.ID, _ = chanRepo.Save(context.Background(), c)
-	chanRepo.Connect(context.Background(), email, c.ID, th.ID)
-
-	nonexistentChanID, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanRepo.Connect(context.Background(), email, c.ID, th.ID, time.Time{})
 
 	nonexistentChanID, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -193,9 +191,78 @@ func TestSingleChannelRetrieval(t *testing.T) {
 	}
 }
 
+func TestChannelRetrieveOwner(t *testing.T) {
+	email := "channel-retrieve-owner@example.com"
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	chid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	id, err := chanRepo.Save(context.Background(), things.Channel{
+		ID:    chid,
+		Owner: email,
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentChanID, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := map[string]struct {
+		ID    string
+		owner string
+		err   error
+	}{
+		"retrieve owner of existing channel": {
+			ID:    id,
+			owner: email,
+			err:   nil,
+		},
+		"retrieve owner of non-existing channel": {
+			ID:  nonexistentChanID,
+			err: things.ErrNotFound,
+		},
+		"retrieve owner of channel with malformed ID": {
+			ID:  wrongValue,
+			err: things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		owner, err := chanRepo.RetrieveOwner(context.Background(), tc.ID)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		assert.Equal(t, tc.owner, owner, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.owner, owner))
+	}
+}
+
+func TestProtectedChannelRetrieval(t *testing.T) {
+	email := "channel-protected-retrieval@example.com"
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	chid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	c := things.Channel{
+		ID:        chid,
+		Owner:     email,
+		Protected: true,
+	}
+	c.ID, err = chanRepo.Save(context.Background(), c)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	ch, err := chanRepo.RetrieveByID(context.Background(), email, c.ID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.True(t, ch.Protected, "expected saved channel to be protected")
+
+	c.Protected = false
+	err = chanRepo.Update(context.Background(), c)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	ch, err = chanRepo.RetrieveByID(context.Background(), email, c.ID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.False(t, ch.Protected, "expected updated channel not to be protected")
+}
+
 func TestMultiChannelRetrieval(t *testing.T) {
 	email := "channel-multi-retrieval@example.com"
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 	channelName := "channel_name"
 
 	n := uint64(10)
@@ -264,7 +331,7 @@ func TestMultiChannelRetrieval(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		page, err := chanRepo.RetrieveAll(context.Background(), tc.owner, tc.offset, tc.limit, tc.name)
+		page, err := chanRepo.RetrieveAll(context.Background(), tc.owner, tc.offset, tc.limit, tc.name, "", false)
 		size := uint64(len(page.Channels))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
@@ -272,11 +339,68 @@ func TestMultiChannelRetrieval(t *testing.T) {
 	}
 }
 
+func TestChannelSubtreeRetrieval(t *testing.T) {
+	email := "channel-subtree-retrieval@example.com"
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	// Build the hierarchy root -> floor -> room and a channel outside of it.
+	ids := map[string]string{}
+	for _, name := range []string{"root", "floor", "room", "other"} {
+		id, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[name] = id
+	}
+
+	chs := []things.Channel{
+		{ID: ids["root"], Owner: email},
+		{ID: ids["floor"], Owner: email, ParentID: ids["root"]},
+		{ID: ids["room"], Owner: email, ParentID: ids["floor"]},
+		{ID: ids["other"], Owner: email},
+	}
+	for _, ch := range chs {
+		_, err := chanRepo.Save(context.Background(), ch)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	cases := map[string]struct {
+		parent string
+		total  uint64
+	}{
+		"retrieve subtree of root channel": {
+			parent: ids["root"],
+			total:  2,
+		},
+		"retrieve subtree of intermediate channel": {
+			parent: ids["floor"],
+			total:  1,
+		},
+		"retrieve subtree of leaf channel": {
+			parent: ids["room"],
+			total:  0,
+		},
+		"retrieve subtree of channel with malformed ID": {
+			parent: wrongValue,
+			total:  0,
+		},
+	}
+
+	for desc, tc := range cases {
+		page, _ := chanRepo.RetrieveAll(context.Background(), email, 0, 10, "", tc.parent, false)
+		size := uint64(len(page.Channels))
+		assert.Equal(t, tc.total, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, size))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
+	}
+
+	ch, err := chanRepo.RetrieveByID(context.Background(), email, ids["room"])
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, ids["floor"], ch.ParentID, fmt.Sprintf("expected parent %s got %s\n", ids["floor"], ch.ParentID))
+}
+
 func TestMultiChannelRetrievalByThing(t *testing.T) {
 	email := "channel-multi-retrieval-by-thing@example.com"
 	idp := uuid.New()
-	chanRepo := postgres.NewChannelRepository(db)
-	thingRepo := postgres.NewThingRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := idp.ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -296,7 +420,7 @@ func TestMultiChannelRetrievalByThing(t *testing.T) {
 		}
 		cid, err := chanRepo.Save(context.Background(), c)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		err = chanRepo.Connect(context.Background(), email, cid, tid)
+		err = chanRepo.Connect(context.Background(), email, cid, tid, time.Time{})
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 	}
 
@@ -360,7 +484,7 @@ func TestMultiChannelRetrievalByThing(t *testing.T) {
 
 func TestChannelRemoval(t *testing.T) {
 	email := "channel-removal@example.com"
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 
 	chid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -382,7 +506,7 @@ func TestChannelRemoval(t *testing.T) {
 
 func TestConnect(t *testing.T) {
 	email := "channel-connect@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -397,7 +521,7 @@ func TestConnect(t *testing.T) {
 	}
 	thingID, _ := thingRepo.Save(context.Background(), thing)
 
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 
 	chid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -412,6 +536,19 @@ func TestConnect(t *testing.T) {
 	nonexistentChanID, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
+	rmid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	rmkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	removedThingID, _ := thingRepo.Save(context.Background(), things.Thing{
+		ID:       rmid,
+		Owner:    email,
+		Key:      rmkey,
+		Metadata: map[string]interface{}{},
+	})
+	err = thingRepo.Remove(context.Background(), email, removedThingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
 	cases := []struct {
 		desc    string
 		owner   string
@@ -454,17 +591,24 @@ func TestConnect(t *testing.T) {
 			thingID: nonexistentThingID,
 			err:     things.ErrNotFound,
 		},
+		{
+			desc:    "connect removed thing",
+			owner:   email,
+			chanID:  chanID,
+			thingID: removedThingID,
+			err:     things.ErrNotFound,
+		},
 	}
 
 	for _, tc := range cases {
-		err := chanRepo.Connect(context.Background(), tc.owner, tc.chanID, tc.thingID)
+		err := chanRepo.Connect(context.Background(), tc.owner, tc.chanID, tc.thingID, time.Time{})
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 	}
 }
 
 func TestDisconnect(t *testing.T) {
 	email := "channel-disconnect@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -479,20 +623,14 @@ func TestDisconnect(t *testing.T) {
 	}
 	thingID, _ := thingRepo.Save(context.Background(), thing)
 
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 	chid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 	chanID, _ := chanRepo.Save(context.Background(), things.Channel{
 		ID:    chid,
 		Owner: email,
 	})
-	chanRepo.Connect(context.Background(), email, chanID, thingID)
-
-	nonexistentThingID, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
-
-	nonexistentChanID, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanRepo.Connect(context.Background(), email, chanID, thingID, time.Time{})
 
 	nonexistentThingID, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -552,7 +690,7 @@ func TestDisconnect(t *testing.T) {
 
 func TestHasThing(t *testing.T) {
 	email := "channel-access-check@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -566,17 +704,14 @@ func TestHasThing(t *testing.T) {
 	}
 	thingID, _ := thingRepo.Save(context.Background(), thing)
 
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 	chid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 	chanID, _ := chanRepo.Save(context.Background(), things.Channel{
 		ID:    chid,
 		Owner: email,
 	})
-	chanRepo.Connect(context.Background(), email, chanID, thingID)
-
-	nonexistentChanID, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanRepo.Connect(context.Background(), email, chanID, thingID, time.Time{})
 
 	nonexistentChanID, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -612,7 +747,7 @@ func TestHasThing(t *testing.T) {
 
 func TestHasThingByID(t *testing.T) {
 	email := "channel-access-check@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -638,14 +773,14 @@ func TestHasThingByID(t *testing.T) {
 	}
 	disconnectedThingID, _ := thingRepo.Save(context.Background(), disconnectedThing)
 
-	chanRepo := postgres.NewChannelRepository(db)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
 	chid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 	chanID, _ := chanRepo.Save(context.Background(), things.Channel{
 		ID:    chid,
 		Owner: email,
 	})
-	chanRepo.Connect(context.Background(), email, chanID, thingID)
+	chanRepo.Connect(context.Background(), email, chanID, thingID, time.Time{})
 
 	nonexistentChanID, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -678,8 +813,224 @@ func TestHasThingByID(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		err := chanRepo.HasThingByID(context.Background(), tc.chanID, tc.thingID)
+		_, err := chanRepo.HasThingByID(context.Background(), tc.chanID, tc.thingID)
 		hasAccess := err == nil
 		assert.Equal(t, tc.hasAccess, hasAccess, fmt.Sprintf("%s: expected %t got %t\n", desc, tc.hasAccess, hasAccess))
 	}
 }
+
+func TestConnectionExpiry(t *testing.T) {
+	email := "channel-connection-expiry@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	chid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID, err := chanRepo.Save(context.Background(), things.Channel{ID: chid, Owner: email})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	now := time.Now().Round(time.Microsecond)
+	expiries := map[string]time.Time{
+		"permanent": {},
+		"temporary": now.Add(time.Hour),
+		"expired":   now.Add(-time.Hour),
+	}
+
+	ids := map[string]string{}
+	for name, expiresAt := range expiries {
+		thid, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		thkey, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		ids[name], err = thingRepo.Save(context.Background(), things.Thing{ID: thid, Owner: email, Key: thkey})
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		err = chanRepo.Connect(context.Background(), email, chanID, ids[name], expiresAt)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	cases := map[string]struct {
+		thingID   string
+		hasAccess bool
+		expiresAt time.Time
+	}{
+		"access check for permanent connection": {
+			thingID:   ids["permanent"],
+			hasAccess: true,
+		},
+		"access check for unexpired connection": {
+			thingID:   ids["temporary"],
+			hasAccess: true,
+			expiresAt: expiries["temporary"],
+		},
+		"access check for expired connection": {
+			thingID:   ids["expired"],
+			hasAccess: false,
+		},
+	}
+
+	for desc, tc := range cases {
+		expiresAt, err := chanRepo.HasThingByID(context.Background(), chanID, tc.thingID)
+		hasAccess := err == nil
+		assert.Equal(t, tc.hasAccess, hasAccess, fmt.Sprintf("%s: expected %t got %t\n", desc, tc.hasAccess, hasAccess))
+		assert.True(t, tc.expiresAt.Equal(expiresAt), fmt.Sprintf("%s: expected expiry %s got %s\n", desc, tc.expiresAt, expiresAt))
+	}
+
+	count, err := chanRepo.CountThings(context.Background(), email, chanID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, uint64(2), count, fmt.Sprintf("expected 2 connected things got %d\n", count))
+
+	page, err := thingRepo.RetrieveByChannel(context.Background(), email, chanID, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, uint64(2), page.Total, fmt.Sprintf("expected 2 connected things got %d\n", page.Total))
+
+	conns, err := chanRepo.RemoveExpired(context.Background(), now)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	expected := []things.Connection{{ChanID: chanID, ThingID: ids["expired"], Owner: email, ExpiresAt: expiries["expired"]}}
+	require.Len(t, conns, 1, fmt.Sprintf("expected removed connections %v got %v\n", expected, conns))
+	assert.True(t, conns[0].ExpiresAt.Equal(expected[0].ExpiresAt), fmt.Sprintf("expected expiry %s got %s\n", expected[0].ExpiresAt, conns[0].ExpiresAt))
+	conns[0].ExpiresAt = expected[0].ExpiresAt
+	assert.Equal(t, expected, conns, fmt.Sprintf("expected removed connections %v got %v\n", expected, conns))
+
+	err = chanRepo.Connect(context.Background(), email, chanID, ids["temporary"], time.Time{})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	expiresAt, err := chanRepo.HasThingByID(context.Background(), chanID, ids["temporary"])
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.True(t, expiresAt.IsZero(), fmt.Sprintf("expected reconnecting to replace expiry got %s\n", expiresAt))
+}
+
+func TestDynamicMembership(t *testing.T) {
+	email := "channel-dynamic-membership@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	thing := things.Thing{
+		ID:       thid,
+		Owner:    email,
+		Key:      thkey,
+		Metadata: map[string]interface{}{"floor": float64(3), "type": "sensor"},
+	}
+	thingID, err := thingRepo.Save(context.Background(), thing)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chanID, err := chanRepo.Save(context.Background(), things.Channel{
+		ID:         chid,
+		Owner:      email,
+		Membership: things.MembershipQuery{"floor": float64(3)},
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	dynamic, err := chanRepo.RetrieveDynamic(context.Background(), email)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Len(t, dynamic, 1, fmt.Sprintf("expected single dynamic channel got %d", len(dynamic)))
+
+	cases := []struct {
+		desc     string
+		metadata map[string]interface{}
+		member   bool
+	}{
+		{
+			desc:     "thing with matching metadata",
+			metadata: map[string]interface{}{"floor": float64(3), "type": "sensor"},
+			member:   true,
+		},
+		{
+			desc:     "thing losing membership",
+			metadata: map[string]interface{}{"floor": float64(4), "type": "sensor"},
+			member:   false,
+		},
+		{
+			desc:     "thing regaining membership",
+			metadata: map[string]interface{}{"floor": float64(3)},
+			member:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		thing.Metadata = tc.metadata
+		err := thingRepo.Update(context.Background(), thing)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		_, err = chanRepo.HasThingByID(context.Background(), chanID, thingID)
+		assert.Equal(t, tc.member, err == nil, fmt.Sprintf("%s: expected membership %t got %s", tc.desc, tc.member, err))
+
+		page, err := thingRepo.RetrieveByChannel(context.Background(), email, chanID, 0, 10)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.member, len(page.Things) == 1, fmt.Sprintf("%s: expected membership %t got %d things", tc.desc, tc.member, len(page.Things)))
+	}
+}
+
+func TestConnectionCounts(t *testing.T) {
+	email := "connection-count@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	chanRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thingID, err := thingRepo.Save(context.Background(), things.Thing{ID: thid, Owner: email, Key: thkey})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chanIDs := []string{}
+	for i := 0; i < 2; i++ {
+		chid, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		chanID, err := chanRepo.Save(context.Background(), things.Channel{ID: chid, Owner: email})
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		chanIDs = append(chanIDs, chanID)
+	}
+
+	cases := []struct {
+		desc    string
+		op      func() error
+		channel uint64
+		thing   uint64
+	}{
+		{
+			desc:    "count without connections",
+			op:      func() error { return nil },
+			channel: 0,
+			thing:   0,
+		},
+		{
+			desc:    "count after connecting thing to channel",
+			op:      func() error { return chanRepo.Connect(context.Background(), email, chanIDs[0], thingID, time.Time{}) },
+			channel: 1,
+			thing:   1,
+		},
+		{
+			desc:    "count after connecting thing to another channel",
+			op:      func() error { return chanRepo.Connect(context.Background(), email, chanIDs[1], thingID, time.Time{}) },
+			channel: 1,
+			thing:   2,
+		},
+		{
+			desc:    "count after disconnecting thing from channel",
+			op:      func() error { return chanRepo.Disconnect(context.Background(), email, chanIDs[0], thingID) },
+			channel: 0,
+			thing:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		count, err := chanRepo.CountThings(context.Background(), email, chanIDs[0])
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.channel, count, fmt.Sprintf("%s: expected %d connected things got %d", tc.desc, tc.channel, count))
+
+		count, err = chanRepo.CountChannels(context.Background(), email, thingID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.thing, count, fmt.Sprintf("%s: expected %d connected channels got %d", tc.desc, tc.thing, count))
+	}
+}
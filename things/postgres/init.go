@@ -26,6 +26,10 @@ type Config struct {
 	SSLCert     string
 	SSLKey      string
 	SSLRootCert string
+
+	// PrefixedIDs stores thing and channel IDs as text, so that they can
+	// carry the configured ID prefix. IDs are stored as UUIDs otherwise.
+	PrefixedIDs bool
 }
 
 // Connect creates a connection to the PostgreSQL instance and applies any
@@ -39,14 +43,16 @@ func Connect(cfg Config) (*sqlx.DB, error) {
 		return nil, err
 	}
 
-	if err := migrateDB(db); err != nil {
+	if err := migrateDB(db, cfg.PrefixedIDs); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func migrateDB(db *sqlx.DB) error {
+// Migrations are ordered by their IDs compared as strings. Migrations following
+// things_1 are therefore versioned with zero-padded numbers.
+func migrateDB(db *sqlx.DB, prefixedIDs bool) error {
 	migrations := &migrate.MemoryMigrationSource{
 		Migrations: []*migrate.Migration{
 			{
@@ -83,9 +89,227 @@ func migrateDB(db *sqlx.DB) error {
 					"DROP TABLE channels",
 				},
 			},
+			{
+				Id: "things_v02",
+				Up: []string{
+					`ALTER TABLE things ADD COLUMN key_expiry TIMESTAMPTZ`,
+				},
+				Down: []string{
+					`ALTER TABLE things DROP COLUMN key_expiry`,
+				},
+			},
+			{
+				Id: "things_v03",
+				Up: []string{
+					`ALTER TABLE channels ADD COLUMN parent_id UUID`,
+				},
+				Down: []string{
+					`ALTER TABLE channels DROP COLUMN parent_id`,
+				},
+			},
+			{
+				Id: "things_v04",
+				Up: []string{
+					`CREATE INDEX IF NOT EXISTS connections_thing_idx ON connections (thing_id, thing_owner)`,
+				},
+				Down: []string{
+					`DROP INDEX IF EXISTS connections_thing_idx`,
+				},
+			},
+			{
+				Id: "things_v05",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS share_links (
+						token_hash    VARCHAR(254) PRIMARY KEY,
+						channel_id    UUID NOT NULL,
+						channel_owner VARCHAR(254) NOT NULL,
+						expires_at    TIMESTAMPTZ NOT NULL,
+						FOREIGN KEY (channel_id, channel_owner) REFERENCES channels (id, owner) ON DELETE CASCADE ON UPDATE CASCADE
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS share_links`,
+				},
+			},
+			{
+				Id: "things_v06",
+				Up: []string{
+					`ALTER TABLE things ADD COLUMN last_seen TIMESTAMPTZ NOT NULL DEFAULT now()`,
+				},
+				Down: []string{
+					`ALTER TABLE things DROP COLUMN last_seen`,
+				},
+			},
+			{
+				Id: "things_v07",
+				Up: []string{
+					`ALTER TABLE channels ADD COLUMN membership JSON`,
+				},
+				Down: []string{
+					`ALTER TABLE channels DROP COLUMN membership`,
+				},
+			},
+			{
+				Id: "things_v08",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS api_keys (
+						id         VARCHAR(254) PRIMARY KEY,
+						key_hash   VARCHAR(254) UNIQUE NOT NULL,
+						owner      VARCHAR(254) NOT NULL,
+						scopes     TEXT[] NOT NULL,
+						issued_at  TIMESTAMPTZ NOT NULL,
+						expires_at TIMESTAMPTZ
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS api_keys`,
+				},
+			},
+			{
+				Id: "things_v09",
+				Up: []string{
+					`ALTER TABLE channels ADD COLUMN protected BOOLEAN NOT NULL DEFAULT FALSE`,
+				},
+				Down: []string{
+					`ALTER TABLE channels DROP COLUMN protected`,
+				},
+			},
+			{
+				Id: "things_v10",
+				Up: []string{
+					`ALTER TABLE things ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+					`ALTER TABLE things ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+				},
+				Down: []string{
+					`ALTER TABLE things DROP COLUMN created_at`,
+					`ALTER TABLE things DROP COLUMN updated_at`,
+				},
+			},
+			{
+				Id: "things_v11",
+				Up: []string{
+					`ALTER TABLE channels ADD COLUMN publisher_scoped BOOLEAN NOT NULL DEFAULT FALSE`,
+				},
+				Down: []string{
+					`ALTER TABLE channels DROP COLUMN publisher_scoped`,
+				},
+			},
+			{
+				Id: "things_v12",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS disabled_owners (
+						owner VARCHAR(254) PRIMARY KEY
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS disabled_owners`,
+				},
+			},
+			{
+				Id: "things_v13",
+				Up: []string{
+					`ALTER TABLE connections ADD COLUMN expires_at TIMESTAMPTZ`,
+				},
+				Down: []string{
+					`ALTER TABLE connections DROP COLUMN expires_at`,
+				},
+			},
+			{
+				Id: "things_v14",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS owners (
+						id    VARCHAR(254) PRIMARY KEY,
+						email VARCHAR(254) UNIQUE NOT NULL
+					)`,
+					// Existing owners keep their emails as the IDs, so
+					// that the resources they own needn't be rewritten.
+					`INSERT INTO owners (id, email)
+					 SELECT owner, owner FROM (
+					   SELECT owner FROM things
+					   UNION SELECT owner FROM channels
+					   UNION SELECT owner FROM api_keys
+					   UNION SELECT owner FROM disabled_owners
+					 ) o
+					 ON CONFLICT DO NOTHING`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS owners`,
+				},
+			},
+			{
+				Id: "things_v15",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS outbox (
+						id           BIGSERIAL PRIMARY KEY,
+						event        JSONB NOT NULL,
+						attempts     BIGINT NOT NULL DEFAULT 0,
+						next_attempt TIMESTAMPTZ NOT NULL,
+						delivered_at TIMESTAMPTZ
+					)`,
+					`CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (next_attempt, id) WHERE delivered_at IS NULL`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS outbox`,
+				},
+			},
+			{
+				Id: "things_v17",
+				Up: []string{
+					`ALTER TABLE channels ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0`,
+				},
+				Down: []string{
+					`ALTER TABLE channels DROP COLUMN token_version`,
+				},
+			},
 		},
 	}
 
+	if prefixedIDs {
+		migrations.Migrations = append(migrations.Migrations, prefixedIDsMigration)
+	}
+
 	_, err := migrate.Exec(db.DB, "postgres", migrations, migrate.Up)
 	return err
 }
+
+// prefixedIDsMigration converts the thing and channel ID columns to text. It's
+// applied only if the ID prefix is used, and it has to be reverted using its
+// down migration before the prefix is dropped. Reverting it strips the
+// prefixes from the stored IDs.
+var prefixedIDsMigration = &migrate.Migration{
+	Id: "things_v16",
+	Up: []string{
+		`ALTER TABLE connections DROP CONSTRAINT connections_channel_id_channel_owner_fkey`,
+		`ALTER TABLE connections DROP CONSTRAINT connections_thing_id_thing_owner_fkey`,
+		`ALTER TABLE share_links DROP CONSTRAINT share_links_channel_id_channel_owner_fkey`,
+		`ALTER TABLE things ALTER COLUMN id TYPE VARCHAR(254) USING id::VARCHAR`,
+		`ALTER TABLE channels ALTER COLUMN id TYPE VARCHAR(254) USING id::VARCHAR,
+			ALTER COLUMN parent_id TYPE VARCHAR(254) USING parent_id::VARCHAR`,
+		`ALTER TABLE connections ALTER COLUMN channel_id TYPE VARCHAR(254) USING channel_id::VARCHAR,
+			ALTER COLUMN thing_id TYPE VARCHAR(254) USING thing_id::VARCHAR`,
+		`ALTER TABLE share_links ALTER COLUMN channel_id TYPE VARCHAR(254) USING channel_id::VARCHAR`,
+		`ALTER TABLE connections ADD CONSTRAINT connections_channel_id_channel_owner_fkey
+			FOREIGN KEY (channel_id, channel_owner) REFERENCES channels (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+		`ALTER TABLE connections ADD CONSTRAINT connections_thing_id_thing_owner_fkey
+			FOREIGN KEY (thing_id, thing_owner) REFERENCES things (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+		`ALTER TABLE share_links ADD CONSTRAINT share_links_channel_id_channel_owner_fkey
+			FOREIGN KEY (channel_id, channel_owner) REFERENCES channels (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+	},
+	Down: []string{
+		`ALTER TABLE connections DROP CONSTRAINT connections_channel_id_channel_owner_fkey`,
+		`ALTER TABLE connections DROP CONSTRAINT connections_thing_id_thing_owner_fkey`,
+		`ALTER TABLE share_links DROP CONSTRAINT share_links_channel_id_channel_owner_fkey`,
+		`ALTER TABLE things ALTER COLUMN id TYPE UUID USING RIGHT(id, 36)::UUID`,
+		`ALTER TABLE channels ALTER COLUMN id TYPE UUID USING RIGHT(id, 36)::UUID,
+			ALTER COLUMN parent_id TYPE UUID USING RIGHT(parent_id, 36)::UUID`,
+		`ALTER TABLE connections ALTER COLUMN channel_id TYPE UUID USING RIGHT(channel_id, 36)::UUID,
+			ALTER COLUMN thing_id TYPE UUID USING RIGHT(thing_id, 36)::UUID`,
+		`ALTER TABLE share_links ALTER COLUMN channel_id TYPE UUID USING RIGHT(channel_id, 36)::UUID`,
+		`ALTER TABLE connections ADD CONSTRAINT connections_channel_id_channel_owner_fkey
+			FOREIGN KEY (channel_id, channel_owner) REFERENCES channels (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+		`ALTER TABLE connections ADD CONSTRAINT connections_thing_id_thing_owner_fkey
+			FOREIGN KEY (thing_id, thing_owner) REFERENCES things (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+		`ALTER TABLE share_links ADD CONSTRAINT share_links_channel_id_channel_owner_fkey
+			FOREIGN KEY (channel_id, channel_owner) REFERENCES channels (id, owner) ON DELETE CASCADE ON UPDATE CASCADE`,
+	},
+}
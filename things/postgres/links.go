@@ -0,0 +1,121 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.ShareLinkRepository = (*shareLinkRepository)(nil)
+
+type shareLinkRepository struct {
+	db *sqlx.DB
+}
+
+// NewShareLinkRepository instantiates a PostgreSQL implementation of share
+// link repository.
+func NewShareLinkRepository(db *sqlx.DB) things.ShareLinkRepository {
+	return &shareLinkRepository{
+		db: db,
+	}
+}
+
+func (slr shareLinkRepository) Save(_ context.Context, link things.ShareLink) error {
+	q := `INSERT INTO share_links (token_hash, channel_id, channel_owner, expires_at)
+	      VALUES (:token_hash, :channel_id, :channel_owner, :expires_at);`
+
+	if _, err := slr.db.NamedExec(q, toDBShareLink(link)); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation:
+				return things.ErrMalformedEntity
+			case errFK:
+				return things.ErrNotFound
+			case errDuplicate:
+				return things.ErrConflict
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (slr shareLinkRepository) RetrieveByToken(_ context.Context, token string) (things.ShareLink, error) {
+	q := `SELECT token_hash, channel_id, channel_owner, expires_at FROM share_links WHERE token_hash = $1;`
+
+	var dbsl dbShareLink
+	if err := slr.db.QueryRowx(q, token).StructScan(&dbsl); err != nil {
+		if err == sql.ErrNoRows {
+			return things.ShareLink{}, things.ErrNotFound
+		}
+
+		return things.ShareLink{}, err
+	}
+
+	return toShareLink(dbsl), nil
+}
+
+func (slr shareLinkRepository) Remove(_ context.Context, owner, chanID, token string) error {
+	q := `DELETE FROM share_links WHERE token_hash = :token_hash AND channel_id = :channel_id AND channel_owner = :channel_owner;`
+
+	dbsl := dbShareLink{
+		Token:   token,
+		Channel: chanID,
+		Owner:   owner,
+	}
+
+	res, err := slr.db.NamedExec(q, dbsl)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+type dbShareLink struct {
+	Token     string    `db:"token_hash"`
+	Channel   string    `db:"channel_id"`
+	Owner     string    `db:"channel_owner"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBShareLink(link things.ShareLink) dbShareLink {
+	return dbShareLink{
+		Token:     link.Token,
+		Channel:   link.ChanID,
+		Owner:     link.Owner,
+		ExpiresAt: link.ExpiresAt,
+	}
+}
+
+func toShareLink(dbsl dbShareLink) things.ShareLink {
+	return things.ShareLink{
+		Token:     dbsl.Token,
+		ChanID:    dbsl.Channel,
+		Owner:     dbsl.Owner,
+		ExpiresAt: dbsl.ExpiresAt,
+	}
+}
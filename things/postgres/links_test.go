@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/mainflux/mainflux/things/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLinkSave(t *testing.T) {
+	email := "link-save@example.com"
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
+	linkRepo := postgres.NewShareLinkRepository(db)
+
+	chanID, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = channelRepo.Save(context.Background(), things.Channel{ID: chanID, Owner: email})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	token, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	link := things.ShareLink{
+		Token:     token,
+		ChanID:    chanID,
+		Owner:     email,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	cases := []struct {
+		desc string
+		link things.ShareLink
+		err  error
+	}{
+		{
+			desc: "save valid link",
+			link: link,
+			err:  nil,
+		},
+		{
+			desc: "save existing link",
+			link: link,
+			err:  things.ErrConflict,
+		},
+		{
+			desc: "save link of non-existing channel",
+			link: things.ShareLink{
+				Token:     chanID,
+				ChanID:    token,
+				Owner:     email,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			err: things.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := linkRepo.Save(context.Background(), tc.link)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestShareLinkRetrieveAndRemove(t *testing.T) {
+	email := "link-retrieve@example.com"
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
+	linkRepo := postgres.NewShareLinkRepository(db)
+
+	chanID, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = channelRepo.Save(context.Background(), things.Channel{ID: chanID, Owner: email})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	token, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	link := things.ShareLink{
+		Token:     token,
+		ChanID:    chanID,
+		Owner:     email,
+		ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+	}
+	err = linkRepo.Save(context.Background(), link)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	saved, err := linkRepo.RetrieveByToken(context.Background(), token)
+	assert.Nil(t, err, fmt.Sprintf("retrieve existing link: unexpected error: %s", err))
+	assert.Equal(t, chanID, saved.ChanID, fmt.Sprintf("retrieve existing link: expected channel %s got %s", chanID, saved.ChanID))
+	assert.True(t, link.ExpiresAt.Equal(saved.ExpiresAt), fmt.Sprintf("retrieve existing link: expected expiry %s got %s", link.ExpiresAt, saved.ExpiresAt))
+
+	err = linkRepo.Remove(context.Background(), "other@example.com", chanID, token)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("remove link of other user: expected %s got %s", things.ErrNotFound, err))
+	_, err = linkRepo.RetrieveByToken(context.Background(), token)
+	assert.Nil(t, err, fmt.Sprintf("remove link of other user: unexpected error: %s", err))
+
+	err = linkRepo.Remove(context.Background(), email, chanID, "unknown")
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("remove unknown link: expected %s got %s", things.ErrNotFound, err))
+
+	err = linkRepo.Remove(context.Background(), email, chanID, token)
+	assert.Nil(t, err, fmt.Sprintf("remove link: unexpected error: %s", err))
+	_, err = linkRepo.RetrieveByToken(context.Background(), token)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve removed link: expected %s got %s", things.ErrNotFound, err))
+
+	err = linkRepo.Remove(context.Background(), email, chanID, token)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("remove removed link: expected %s got %s", things.ErrNotFound, err))
+}
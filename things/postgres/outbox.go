@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.OutboxRepository = (*outboxRepository)(nil)
+
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository instantiates a PostgreSQL implementation of outbox
+// repository.
+func NewOutboxRepository(db *sqlx.DB) things.OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (obr outboxRepository) Save(_ context.Context, event things.OutboxEvent) error {
+	q := `INSERT INTO outbox (event, attempts, next_attempt) VALUES (:event, :attempts, :next_attempt);`
+
+	dboe, err := toDBOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = obr.db.NamedExec(q, dboe)
+	return err
+}
+
+func (obr outboxRepository) RetrieveDue(_ context.Context, now time.Time, limit uint64) ([]things.OutboxEvent, error) {
+	q := `SELECT id, event, attempts, next_attempt FROM outbox
+	      WHERE delivered_at IS NULL AND next_attempt <= $1 ORDER BY id LIMIT $2;`
+
+	rows, err := obr.db.Queryx(q, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []things.OutboxEvent{}
+	for rows.Next() {
+		var dboe dbOutboxEvent
+		if err := rows.StructScan(&dboe); err != nil {
+			return nil, err
+		}
+
+		oe, err := toOutboxEvent(dboe)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, oe)
+	}
+
+	return events, rows.Err()
+}
+
+func (obr outboxRepository) Retry(_ context.Context, id uint64, next time.Time) error {
+	q := `UPDATE outbox SET attempts = attempts + 1, next_attempt = $2 WHERE id = $1;`
+
+	res, err := obr.db.Exec(q, id, next)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+func (obr outboxRepository) MarkDelivered(_ context.Context, id uint64, at time.Time) error {
+	q := `UPDATE outbox SET delivered_at = $2 WHERE id = $1;`
+
+	res, err := obr.db.Exec(q, id, at)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+func (obr outboxRepository) RemoveDelivered(_ context.Context, before time.Time) error {
+	q := `DELETE FROM outbox WHERE delivered_at < $1;`
+
+	_, err := obr.db.Exec(q, before)
+	return err
+}
+
+type dbOutboxEvent struct {
+	ID          uint64    `db:"id"`
+	Event       string    `db:"event"`
+	Attempts    uint64    `db:"attempts"`
+	NextAttempt time.Time `db:"next_attempt"`
+}
+
+func toDBOutboxEvent(oe things.OutboxEvent) (dbOutboxEvent, error) {
+	data, err := json.Marshal(oe.Event)
+	if err != nil {
+		return dbOutboxEvent{}, err
+	}
+
+	return dbOutboxEvent{
+		ID:          oe.ID,
+		Event:       string(data),
+		Attempts:    oe.Attempts,
+		NextAttempt: oe.NextAttempt,
+	}, nil
+}
+
+func toOutboxEvent(dboe dbOutboxEvent) (things.OutboxEvent, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(dboe.Event), &event); err != nil {
+		return things.OutboxEvent{}, err
+	}
+
+	return things.OutboxEvent{
+		ID:          dboe.ID,
+		Event:       event,
+		Attempts:    dboe.Attempts,
+		NextAttempt: dboe.NextAttempt,
+	}, nil
+}
@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRetrieveDue(t *testing.T) {
+	outboxRepo := postgres.NewOutboxRepository(db)
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	err := outboxRepo.RemoveDelivered(context.Background(), now.Add(time.Hour))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	events, err := outboxRepo.RetrieveDue(context.Background(), now.Add(time.Hour), 100)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for _, oe := range events {
+		err := outboxRepo.MarkDelivered(context.Background(), oe.ID, now.Add(-time.Hour))
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	event := map[string]interface{}{"operation": things.ThingCreate, "id": "1"}
+	for i := 0; i < 3; i++ {
+		oe := things.OutboxEvent{Event: event, NextAttempt: now.Add(time.Duration(i) * time.Minute)}
+		err := outboxRepo.Save(context.Background(), oe)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	cases := []struct {
+		desc  string
+		now   time.Time
+		limit uint64
+		size  int
+	}{
+		{
+			desc:  "retrieve events due now",
+			now:   now,
+			limit: 10,
+			size:  1,
+		},
+		{
+			desc:  "retrieve events due later",
+			now:   now.Add(2 * time.Minute),
+			limit: 10,
+			size:  3,
+		},
+		{
+			desc:  "retrieve limited number of due events",
+			now:   now.Add(2 * time.Minute),
+			limit: 2,
+			size:  2,
+		},
+		{
+			desc:  "retrieve events before any is due",
+			now:   now.Add(-time.Minute),
+			limit: 10,
+			size:  0,
+		},
+	}
+
+	for _, tc := range cases {
+		events, err := outboxRepo.RetrieveDue(context.Background(), tc.now, tc.limit)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.size, len(events), fmt.Sprintf("%s: expected %d events got %d", tc.desc, tc.size, len(events)))
+		for _, oe := range events {
+			assert.Equal(t, event, oe.Event, fmt.Sprintf("%s: expected event %v got %v", tc.desc, event, oe.Event))
+		}
+	}
+}
+
+func TestOutboxDelivery(t *testing.T) {
+	outboxRepo := postgres.NewOutboxRepository(db)
+	event := map[string]interface{}{"operation": things.ThingRemove, "id": "2"}
+	now := time.Now().UTC().Add(-24 * time.Hour)
+
+	err := outboxRepo.Save(context.Background(), things.OutboxEvent{Event: event, NextAttempt: now})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	events, err := outboxRepo.RetrieveDue(context.Background(), now, 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Equal(t, 1, len(events), fmt.Sprintf("expected 1 due event got %d", len(events)))
+	id := events[0].ID
+
+	err = outboxRepo.Retry(context.Background(), id, now.Add(time.Minute))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Repository instantiated after the restart retrieves the same event.
+	outboxRepo = postgres.NewOutboxRepository(db)
+	events, err = outboxRepo.RetrieveDue(context.Background(), now.Add(time.Minute), 1)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Equal(t, 1, len(events), fmt.Sprintf("expected 1 due event got %d", len(events)))
+	assert.Equal(t, id, events[0].ID, fmt.Sprintf("expected event %d got %d", id, events[0].ID))
+	assert.Equal(t, uint64(1), events[0].Attempts, fmt.Sprintf("expected 1 attempt got %d", events[0].Attempts))
+
+	cases := []struct {
+		desc string
+		op   func(context.Context, uint64, time.Time) error
+		id   uint64
+		err  error
+	}{
+		{
+			desc: "retry non-existing event",
+			op:   outboxRepo.Retry,
+			id:   id + 1000,
+			err:  things.ErrNotFound,
+		},
+		{
+			desc: "mark non-existing event as delivered",
+			op:   outboxRepo.MarkDelivered,
+			id:   id + 1000,
+			err:  things.ErrNotFound,
+		},
+		{
+			desc: "mark existing event as delivered",
+			op:   outboxRepo.MarkDelivered,
+			id:   id,
+			err:  nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op(context.Background(), tc.id, now.Add(time.Minute))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+
+	events, err = outboxRepo.RetrieveDue(context.Background(), now.Add(time.Hour), 100)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for _, oe := range events {
+		assert.NotEqual(t, id, oe.ID, fmt.Sprintf("expected delivered event %d not to be due", id))
+	}
+
+	err = outboxRepo.RemoveDelivered(context.Background(), now.Add(time.Hour))
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.OwnerRepository = (*ownerRepository)(nil)
+
+type ownerRepository struct {
+	db *sqlx.DB
+}
+
+// NewOwnerRepository instantiates a PostgreSQL implementation of owner
+// repository.
+func NewOwnerRepository(db *sqlx.DB) things.OwnerRepository {
+	return &ownerRepository{
+		db: db,
+	}
+}
+
+func (or ownerRepository) RetrieveByEmail(_ context.Context, email string) (string, error) {
+	var owner string
+	if err := or.db.QueryRowx(`SELECT id FROM owners WHERE email = $1;`, email).Scan(&owner); err != nil {
+		if err == sql.ErrNoRows {
+			return "", things.ErrNotFound
+		}
+		return "", err
+	}
+
+	return owner, nil
+}
+
+func (or ownerRepository) Resolve(ctx context.Context, email, id string) (string, error) {
+	// The owner saved concurrently wins, so its ID is retrieved afterwards.
+	q := `INSERT INTO owners (id, email) VALUES ($1, $2) ON CONFLICT (email) DO NOTHING;`
+
+	if _, err := or.db.Exec(q, id, email); err != nil {
+		return "", err
+	}
+
+	return or.RetrieveByEmail(ctx, email)
+}
+
+func (or ownerRepository) ChangeEmail(_ context.Context, email, newEmail string) error {
+	q := `UPDATE owners SET email = $2 WHERE email = $1;`
+
+	res, err := or.db.Exec(q, email, newEmail)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid, errTruncation:
+				return things.ErrMalformedEntity
+			case errDuplicate:
+				return things.ErrConflict
+			}
+		}
+
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+func (or ownerRepository) Disable(_ context.Context, owner string) error {
+	q := `INSERT INTO disabled_owners (owner) VALUES ($1) ON CONFLICT (owner) DO NOTHING;`
+
+	_, err := or.db.Exec(q, owner)
+	return err
+}
+
+func (or ownerRepository) Enable(_ context.Context, owner string) error {
+	q := `DELETE FROM disabled_owners WHERE owner = $1;`
+
+	_, err := or.db.Exec(q, owner)
+	return err
+}
+
+func (or ownerRepository) Disabled(_ context.Context, owner string) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM disabled_owners WHERE owner = $1);`
+
+	var disabled bool
+	if err := or.db.QueryRow(q, owner).Scan(&disabled); err != nil {
+		return false, err
+	}
+
+	return disabled, nil
+}
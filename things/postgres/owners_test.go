@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/mainflux/mainflux/things/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerDisable(t *testing.T) {
+	email := "owner-disable@example.com"
+	ownerRepo := postgres.NewOwnerRepository(db)
+
+	cases := []struct {
+		desc     string
+		op       func(context.Context, string) error
+		disabled bool
+	}{
+		{
+			desc:     "disable enabled owner",
+			op:       ownerRepo.Disable,
+			disabled: true,
+		},
+		{
+			desc:     "disable disabled owner",
+			op:       ownerRepo.Disable,
+			disabled: true,
+		},
+		{
+			desc:     "enable disabled owner",
+			op:       ownerRepo.Enable,
+			disabled: false,
+		},
+		{
+			desc:     "enable enabled owner",
+			op:       ownerRepo.Enable,
+			disabled: false,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op(context.Background(), email)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		disabled, err := ownerRepo.Disabled(context.Background(), email)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.disabled, disabled, fmt.Sprintf("%s: expected disabled %t got %t", tc.desc, tc.disabled, disabled))
+	}
+}
+
+func TestOwnerResolve(t *testing.T) {
+	email := "owner-resolve@example.com"
+	newEmail := "owner-resolve-changed@example.com"
+	otherEmail := "owner-resolve-other@example.com"
+	ownerRepo := postgres.NewOwnerRepository(db)
+
+	id, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	otherID, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	_, err = ownerRepo.RetrieveByEmail(context.Background(), email)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve unknown owner: expected %s got %s", things.ErrNotFound, err))
+
+	owner, err := ownerRepo.Resolve(context.Background(), email, id)
+	require.Nil(t, err, fmt.Sprintf("resolve unknown owner: unexpected error: %s", err))
+	assert.Equal(t, id, owner, fmt.Sprintf("resolve unknown owner: expected %s got %s", id, owner))
+
+	owner, err = ownerRepo.RetrieveByEmail(context.Background(), email)
+	require.Nil(t, err, fmt.Sprintf("retrieve known owner: unexpected error: %s", err))
+	assert.Equal(t, id, owner, fmt.Sprintf("retrieve known owner: expected %s got %s", id, owner))
+
+	owner, err = ownerRepo.Resolve(context.Background(), email, otherID)
+	require.Nil(t, err, fmt.Sprintf("resolve known owner: unexpected error: %s", err))
+	assert.Equal(t, id, owner, fmt.Sprintf("resolve known owner: expected %s got %s", id, owner))
+
+	_, err = ownerRepo.Resolve(context.Background(), otherEmail, otherID)
+	require.Nil(t, err, fmt.Sprintf("resolve other owner: unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		email    string
+		newEmail string
+		err      error
+	}{
+		{
+			desc:     "change email of unknown owner",
+			email:    "owner-resolve-unknown@example.com",
+			newEmail: newEmail,
+			err:      things.ErrNotFound,
+		},
+		{
+			desc:     "change email to email of another owner",
+			email:    email,
+			newEmail: otherEmail,
+			err:      things.ErrConflict,
+		},
+		{
+			desc:     "change email",
+			email:    email,
+			newEmail: newEmail,
+			err:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := ownerRepo.ChangeEmail(context.Background(), tc.email, tc.newEmail)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+
+	owner, err = ownerRepo.Resolve(context.Background(), newEmail, otherID)
+	require.Nil(t, err, fmt.Sprintf("resolve owner by new email: unexpected error: %s", err))
+	assert.Equal(t, id, owner, fmt.Sprintf("resolve owner by new email: expected %s got %s", id, owner))
+}
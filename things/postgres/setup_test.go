@@ -24,6 +24,7 @@ import (
 const (
 	wrongID    = "0"
 	wrongValue = "wrong-value"
+	idPrefix   = "prod-"
 )
 
 var (
@@ -70,6 +71,7 @@ func TestMain(m *testing.M) {
 		SSLCert:     "",
 		SSLKey:      "",
 		SSLRootCert: "",
+		PrefixedIDs: true,
 	}
 
 	if db, err = postgres.Connect(dbConfig); err != nil {
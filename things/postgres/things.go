@@ -13,6 +13,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
@@ -27,23 +28,47 @@ const (
 	errTruncation = "string_data_right_truncation"
 )
 
+// uuidLen is the length of the canonical UUID string.
+const uuidLen = 36
+
+// memberCondition matches the thing th that is the member of the channel ch,
+// i.e. connected to it or, if the channel is dynamic, having the metadata
+// that contains its membership query.
+const memberCondition = `CASE WHEN ch.membership IS NULL
+	  THEN EXISTS (SELECT 1 FROM connections co
+	    WHERE co.channel_id = ch.id AND co.channel_owner = ch.owner AND co.thing_id = th.id AND co.thing_owner = th.owner
+	    AND ` + liveCondition + `)
+	  ELSE th.metadata::jsonb @> ch.membership::jsonb END`
+
+// liveCondition matches the connection co that hasn't expired yet. Expired
+// connections are ignored until they are removed.
+const liveCondition = `(co.expires_at IS NULL OR co.expires_at > now())`
+
 var _ things.ThingRepository = (*thingRepository)(nil)
 
 type thingRepository struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	idPrefix string
 }
 
 // NewThingRepository instantiates a PostgreSQL implementation of thing
-// repository.
-func NewThingRepository(db *sqlx.DB) things.ThingRepository {
+// repository. Thing and channel IDs are expected to be UUIDs, optionally
+// preceded by the given ID prefix.
+func NewThingRepository(db *sqlx.DB, idPrefix string) things.ThingRepository {
 	return &thingRepository{
-		db: db,
+		db:       db,
+		idPrefix: idPrefix,
 	}
 }
 
 func (tr thingRepository) Save(ctx context.Context, thing things.Thing) (string, error) {
-	q := `INSERT INTO things (id, owner, name, key, metadata)
-		  VALUES (:id, :owner, :name, :key, :metadata);`
+	q := `INSERT INTO things (id, owner, name, key, key_expiry, last_seen, created_at, updated_at, metadata)
+		  VALUES (:id, :owner, :name, :key, :key_expiry, COALESCE(:last_seen, now()), COALESCE(:created_at, now()),
+		  COALESCE(:updated_at, :created_at, now()), :metadata);`
+
+	if !validID(tr.idPrefix, thing.ID) {
+		return "", things.ErrMalformedEntity
+	}
 
 	dbth, err := toDBThing(thing)
 	if err != nil {
@@ -69,7 +94,11 @@ func (tr thingRepository) Save(ctx context.Context, thing things.Thing) (string,
 }
 
 func (tr thingRepository) Update(_ context.Context, thing things.Thing) error {
-	q := `UPDATE things SET name = :name, metadata = :metadata WHERE owner = :owner AND id = :id;`
+	q := `UPDATE things SET name = :name, metadata = :metadata, updated_at = now() WHERE owner = :owner AND id = :id;`
+
+	if !validID(tr.idPrefix, thing.ID) {
+		return things.ErrMalformedEntity
+	}
 
 	dbth, err := toDBThing(thing)
 	if err != nil {
@@ -101,12 +130,18 @@ func (tr thingRepository) Update(_ context.Context, thing things.Thing) error {
 	return nil
 }
 
-func (tr thingRepository) UpdateKey(_ context.Context, owner, id, key string) error {
-	q := `UPDATE things SET key = :key WHERE owner = :owner AND id = :id;`
+func (tr thingRepository) UpdateKey(_ context.Context, owner, id, key string, expiry time.Time) error {
+	q := `UPDATE things SET key = :key, key_expiry = :key_expiry, updated_at = now() WHERE owner = :owner AND id = :id;`
+
+	if !validID(tr.idPrefix, id) {
+		return things.ErrMalformedEntity
+	}
+
 	dbth := dbThing{
-		ID:    id,
-		Owner: owner,
-		Key:   key,
+		ID:        id,
+		Owner:     owner,
+		Key:       key,
+		KeyExpiry: toNullTime(expiry),
 	}
 
 	res, err := tr.db.NamedExec(q, dbth)
@@ -136,8 +171,43 @@ func (tr thingRepository) UpdateKey(_ context.Context, owner, id, key string) er
 	return nil
 }
 
+func (tr thingRepository) RotateKey(_ context.Context, thing things.Thing, key string, expiry time.Time) error {
+	q := `UPDATE things SET key = $1, key_expiry = $2, updated_at = now()
+	      WHERE owner = $3 AND id = $4 AND key = $5;`
+
+	if !validID(tr.idPrefix, thing.ID) {
+		return things.ErrMalformedEntity
+	}
+
+	res, err := tr.db.Exec(q, key, toNullTime(expiry), thing.Owner, thing.ID, thing.Key)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok {
+			switch pqErr.Code.Name() {
+			case errInvalid:
+				return things.ErrMalformedEntity
+			case errDuplicate:
+				return things.ErrConflict
+			}
+		}
+
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
 func (tr thingRepository) RetrieveByID(_ context.Context, owner, id string) (things.Thing, error) {
-	q := `SELECT name, key, metadata FROM things WHERE id = $1 AND owner = $2;`
+	q := `SELECT name, key, key_expiry, last_seen, created_at, updated_at, metadata FROM things WHERE id = $1 AND owner = $2;`
 
 	dbth := dbThing{
 		ID:    id,
@@ -158,20 +228,158 @@ func (tr thingRepository) RetrieveByID(_ context.Context, owner, id string) (thi
 	return toThing(dbth)
 }
 
-func (tr thingRepository) RetrieveByKey(_ context.Context, key string) (string, error) {
-	q := `SELECT id FROM things WHERE key = $1;`
-	var id string
-	if err := tr.db.QueryRowx(q, key).Scan(&id); err != nil {
+func (tr thingRepository) RetrieveOwner(_ context.Context, id string) (string, error) {
+	if !validID(tr.idPrefix, id) {
+		return "", things.ErrNotFound
+	}
+
+	var owner string
+	if err := tr.db.QueryRowx(`SELECT owner FROM things WHERE id = $1;`, id).Scan(&owner); err != nil {
 		if err == sql.ErrNoRows {
 			return "", things.ErrNotFound
 		}
 		return "", err
 	}
 
-	return id, nil
+	return owner, nil
+}
+
+func (tr thingRepository) RetrieveByKey(_ context.Context, key string) (things.Thing, error) {
+	q := `SELECT id, owner, name, key, key_expiry, created_at, updated_at, metadata FROM things WHERE key = $1;`
+
+	var dbth dbThing
+	if err := tr.db.QueryRowx(q, key).StructScan(&dbth); err != nil {
+		if err == sql.ErrNoRows {
+			return things.Thing{}, things.ErrNotFound
+		}
+		return things.Thing{}, err
+	}
+
+	return toThing(dbth)
+}
+
+func (tr thingRepository) RetrieveByKeys(_ context.Context, keys []string) ([]things.Thing, error) {
+	q := `SELECT id, owner, name, key, key_expiry, created_at, updated_at, metadata FROM things WHERE key = ANY($1);`
+
+	rows, err := tr.db.Queryx(q, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []things.Thing{}
+	for rows.Next() {
+		var dbth dbThing
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, err
+		}
+
+		th, err := toThing(dbth)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, th)
+	}
+
+	return items, nil
+}
+
+func (tr thingRepository) RetrieveExpired(_ context.Context, t time.Time, limit uint64) ([]things.Thing, error) {
+	q := `SELECT id, owner, name, key, key_expiry, metadata FROM things
+	      WHERE key_expiry < $1 ORDER BY key_expiry, id LIMIT $2;`
+
+	rows, err := tr.db.Queryx(q, t, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []things.Thing{}
+	for rows.Next() {
+		var dbth dbThing
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, err
+		}
+
+		th, err := toThing(dbth)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, th)
+	}
+
+	return items, nil
 }
 
-func (tr thingRepository) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string) (things.ThingsPage, error) {
+func (tr thingRepository) RetrieveIdle(_ context.Context, owner, chanID string, t time.Time) ([]things.Thing, error) {
+	if !validID(tr.idPrefix, chanID) {
+		return []things.Thing{}, nil
+	}
+
+	q := `SELECT id, owner, name, key, key_expiry, last_seen, metadata
+	      FROM things th
+	      INNER JOIN connections co
+		  ON th.id = co.thing_id AND th.owner = co.thing_owner
+		  WHERE th.owner = $1 AND co.channel_id = $2 AND th.last_seen < $3 AND ` + liveCondition + `;`
+
+	rows, err := tr.db.Queryx(q, owner, chanID, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []things.Thing{}
+	for rows.Next() {
+		var dbth dbThing
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, err
+		}
+
+		th, err := toThing(dbth)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, th)
+	}
+
+	return items, nil
+}
+
+func (tr thingRepository) UpdateLastSeen(_ context.Context, id string, t time.Time) error {
+	q := `UPDATE things SET last_seen = $2 WHERE id = $1;`
+
+	res, err := tr.db.Exec(q, id, t)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return things.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr thingRepository) NameExists(_ context.Context, owner, name string) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM things WHERE owner = $1 AND name = $2);`
+
+	exists := false
+	if err := tr.db.QueryRow(q, owner, name).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (tr thingRepository) RetrieveAll(_ context.Context, owner string, offset, limit uint64, name string, compact bool) (things.ThingsPage, error) {
 	name = strings.ToLower(name)
 	nq := ""
 	if name != "" {
@@ -179,8 +387,13 @@ func (tr thingRepository) RetrieveAll(_ context.Context, owner string, offset, l
 		nq = `AND LOWER(name) LIKE :name`
 	}
 
-	q := fmt.Sprintf(`SELECT id, name, key, metadata FROM things
-	      WHERE owner = :owner %s ORDER BY id LIMIT :limit OFFSET :offset;`, nq)
+	cols := `id, name, key, key_expiry, metadata`
+	if compact {
+		cols = `id, name`
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM things
+	      WHERE owner = :owner %s ORDER BY id LIMIT :limit OFFSET :offset;`, cols, nq)
 
 	params := map[string]interface{}{
 		"owner":  owner,
@@ -197,7 +410,8 @@ func (tr thingRepository) RetrieveAll(_ context.Context, owner string, offset, l
 
 	items := []things.Thing{}
 	for rows.Next() {
-		dbth := dbThing{Owner: owner}
+		// Compact listing doesn't select the metadata, which is left nil.
+		dbth := dbThing{Owner: owner, Metadata: "null"}
 		if err := rows.StructScan(&dbth); err != nil {
 			return things.ThingsPage{}, err
 		}
@@ -242,19 +456,17 @@ func (tr thingRepository) RetrieveAll(_ context.Context, owner string, offset, l
 }
 
 func (tr thingRepository) RetrieveByChannel(_ context.Context, owner, channel string, offset, limit uint64) (things.ThingsPage, error) {
-	// Verify if UUID format is valid to avoid internal Postgres error
-	if _, err := uuid.FromString(channel); err != nil {
+	// Verify if ID format is valid to avoid needless DB round trips
+	if !validID(tr.idPrefix, channel) {
 		return things.ThingsPage{}, things.ErrNotFound
 	}
 
-	q := `SELECT id, name, key, metadata
-	      FROM things th
-	      INNER JOIN connections co
-		  ON th.id = co.thing_id
-		  WHERE th.owner = :owner AND co.channel_id = :channel
+	q := fmt.Sprintf(`SELECT th.id, th.name, th.key, th.key_expiry, th.metadata
+	      FROM things th, channels ch
+		  WHERE th.owner = :owner AND ch.owner = :owner AND ch.id = :channel AND %s
 		  ORDER BY th.id
 		  LIMIT :limit
-		  OFFSET :offset;`
+		  OFFSET :offset;`, memberCondition)
 
 	params := map[string]interface{}{
 		"owner":   owner,
@@ -284,14 +496,124 @@ func (tr thingRepository) RetrieveByChannel(_ context.Context, owner, channel st
 		items = append(items, th)
 	}
 
+	q = fmt.Sprintf(`SELECT COUNT(*)
+	     FROM things th, channels ch
+	     WHERE th.owner = $1 AND ch.owner = $1 AND ch.id = $2 AND %s;`, memberCondition)
+
+	var total uint64
+	if err := tr.db.Get(&total, q, owner, channel); err != nil {
+		return things.ThingsPage{}, err
+	}
+
+	return things.ThingsPage{
+		Things: items,
+		PageMetadata: things.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (tr thingRepository) RetrieveByChannelAfter(_ context.Context, owner, channel, after string, limit uint64) ([]things.Thing, error) {
+	// Verify if ID format is valid to avoid needless DB round trips
+	if !validID(tr.idPrefix, channel) {
+		return nil, things.ErrNotFound
+	}
+
+	cursor := ""
+	if after != "" {
+		if !validID(tr.idPrefix, after) {
+			return nil, things.ErrMalformedEntity
+		}
+		cursor = "AND th.id > :after"
+	}
+
+	q := fmt.Sprintf(`SELECT th.id, th.name, th.key, th.key_expiry, th.metadata
+	      FROM things th, channels ch
+		  WHERE th.owner = :owner AND ch.owner = :owner AND ch.id = :channel AND %s %s
+		  ORDER BY th.id
+		  LIMIT :limit;`, memberCondition, cursor)
+
+	params := map[string]interface{}{
+		"owner":   owner,
+		"channel": channel,
+		"after":   after,
+		"limit":   limit,
+	}
+
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []things.Thing{}
+	for rows.Next() {
+		dbth := dbThing{Owner: owner}
+		if err := rows.StructScan(&dbth); err != nil {
+			return nil, err
+		}
+
+		th, err := toThing(dbth)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, th)
+	}
+
+	return items, nil
+}
+
+func (tr thingRepository) RetrieveByChannels(_ context.Context, owner string, channels []string, offset, limit uint64) (things.ThingsPage, error) {
+	// Verify if ID format is valid to avoid needless DB round trips
+	for _, channel := range channels {
+		if !validID(tr.idPrefix, channel) {
+			return things.ThingsPage{}, things.ErrNotFound
+		}
+	}
+
+	// Semi-join keeps every thing once, no matter how many of the channels
+	// it is connected to.
+	q := `SELECT id, name, key, key_expiry, metadata
+	      FROM things th
+	      WHERE th.owner = $1 AND EXISTS (
+	        SELECT 1 FROM connections co
+	        WHERE co.thing_id = th.id AND co.thing_owner = th.owner AND co.channel_id = ANY($2) AND ` + liveCondition + `)
+	      ORDER BY th.id
+	      LIMIT $3
+	      OFFSET $4;`
+
+	rows, err := tr.db.Queryx(q, owner, pq.Array(channels), limit, offset)
+	if err != nil {
+		return things.ThingsPage{}, err
+	}
+	defer rows.Close()
+
+	items := []things.Thing{}
+	for rows.Next() {
+		dbth := dbThing{Owner: owner}
+		if err := rows.StructScan(&dbth); err != nil {
+			return things.ThingsPage{}, err
+		}
+
+		th, err := toThing(dbth)
+		if err != nil {
+			return things.ThingsPage{}, err
+		}
+
+		items = append(items, th)
+	}
+
 	q = `SELECT COUNT(*)
 	     FROM things th
-	     INNER JOIN connections co
-	     ON th.id = co.thing_id
-	     WHERE th.owner = $1 AND co.channel_id = $2;`
+	     WHERE th.owner = $1 AND EXISTS (
+	       SELECT 1 FROM connections co
+	       WHERE co.thing_id = th.id AND co.thing_owner = th.owner AND co.channel_id = ANY($2) AND ` + liveCondition + `);`
 
 	var total uint64
-	if err := tr.db.Get(&total, q, owner, channel); err != nil {
+	if err := tr.db.Get(&total, q, owner, pq.Array(channels)); err != nil {
 		return things.ThingsPage{}, err
 	}
 
@@ -316,11 +638,15 @@ func (tr thingRepository) Remove(_ context.Context, owner, id string) error {
 }
 
 type dbThing struct {
-	ID       string `db:"id"`
-	Owner    string `db:"owner"`
-	Name     string `db:"name"`
-	Key      string `db:"key"`
-	Metadata string `db:"metadata"`
+	ID        string      `db:"id"`
+	Owner     string      `db:"owner"`
+	Name      string      `db:"name"`
+	Key       string      `db:"key"`
+	KeyExpiry pq.NullTime `db:"key_expiry"`
+	LastSeen  pq.NullTime `db:"last_seen"`
+	CreatedAt pq.NullTime `db:"created_at"`
+	UpdatedAt pq.NullTime `db:"updated_at"`
+	Metadata  string      `db:"metadata"`
 }
 
 func toDBThing(th things.Thing) (dbThing, error) {
@@ -330,11 +656,15 @@ func toDBThing(th things.Thing) (dbThing, error) {
 	}
 
 	return dbThing{
-		ID:       th.ID,
-		Owner:    th.Owner,
-		Name:     th.Name,
-		Key:      th.Key,
-		Metadata: string(data),
+		ID:        th.ID,
+		Owner:     th.Owner,
+		Name:      th.Name,
+		Key:       th.Key,
+		KeyExpiry: toNullTime(th.KeyExpiry),
+		LastSeen:  toNullTime(th.LastSeen),
+		CreatedAt: toNullTime(th.CreatedAt),
+		UpdatedAt: toNullTime(th.UpdatedAt),
+		Metadata:  string(data),
 	}, nil
 }
 
@@ -345,10 +675,37 @@ func toThing(dbth dbThing) (things.Thing, error) {
 	}
 
 	return things.Thing{
-		ID:       dbth.ID,
-		Owner:    dbth.Owner,
-		Name:     dbth.Name,
-		Key:      dbth.Key,
-		Metadata: metadata,
+		ID:        dbth.ID,
+		Owner:     dbth.Owner,
+		Name:      dbth.Name,
+		Key:       dbth.Key,
+		KeyExpiry: dbth.KeyExpiry.Time,
+		LastSeen:  dbth.LastSeen.Time,
+		CreatedAt: dbth.CreatedAt.Time,
+		UpdatedAt: dbth.UpdatedAt.Time,
+		Metadata:  metadata,
 	}, nil
 }
+
+func toNullTime(t time.Time) pq.NullTime {
+	return pq.NullTime{
+		Time:  t,
+		Valid: !t.IsZero(),
+	}
+}
+
+// validID checks whether the identifier is the UUID, optionally preceded by
+// exactly the configured identifier prefix. Unprefixed UUIDs are accepted so
+// that the resources created before the prefix was configured stay reachable.
+func validID(prefix, id string) bool {
+	if len(id) < uuidLen {
+		return false
+	}
+
+	if p := id[:len(id)-uuidLen]; p != "" && p != prefix {
+		return false
+	}
+
+	_, err := uuid.FromString(id[len(id)-uuidLen:])
+	return err == nil
+}
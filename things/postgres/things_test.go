@@ -10,8 +10,10 @@ package postgres_test
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -26,7 +28,7 @@ const maxNameSize = 1024
 var invalidName = strings.Repeat("m", maxNameSize+1)
 
 func TestThingSave(t *testing.T) {
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	email := "thing-save@example.com"
 
@@ -96,7 +98,7 @@ func TestThingSave(t *testing.T) {
 }
 
 func TestThingUpdate(t *testing.T) {
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	email := "thing-update@example.com"
 	validName := "mfx_device"
@@ -180,95 +182,44 @@ func TestThingUpdate(t *testing.T) {
 	}
 }
 
-func TestUpdateKey(t *testing.T) {
-	email := "thing-update=key@example.com"
-	newKey := "new-key"
-	thingRepo := postgres.NewThingRepository(db)
-
-	ethid, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
-	ethkey, err := uuid.New().ID()
-	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+func TestThingTimestamps(t *testing.T) {
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
-	existingThing := things.Thing{
-		ID:    ethid,
-		Owner: email,
-		Key:   ethkey,
-	}
-	existingID, _ := thingRepo.Save(context.Background(), existingThing)
-	existingThing.ID = existingID
+	email := "thing-timestamps@example.com"
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 	thkey, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
+	createdAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
 	thing := things.Thing{
-		ID:    thid,
-		Owner: email,
-		Key:   thkey,
+		ID:        thid,
+		Owner:     email,
+		Key:       thkey,
+		CreatedAt: createdAt,
 	}
+	_, err = thingRepo.Save(context.Background(), thing)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
-	id, _ := thingRepo.Save(context.Background(), thing)
-	thing.ID = id
-
-	nonexistentThingID, err := uuid.New().ID()
+	th, err := thingRepo.RetrieveByKey(context.Background(), thkey)
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.True(t, createdAt.Equal(th.CreatedAt), fmt.Sprintf("expected creation time %s got %s", createdAt, th.CreatedAt))
+	assert.True(t, createdAt.Equal(th.UpdatedAt), fmt.Sprintf("expected update time %s got %s", createdAt, th.UpdatedAt))
 
-	cases := []struct {
-		desc  string
-		owner string
-		id    string
-		key   string
-		err   error
-	}{
-		{
-			desc:  "update key of an existing thing",
-			owner: thing.Owner,
-			id:    thing.ID,
-			key:   newKey,
-			err:   nil,
-		},
-		{
-			desc:  "update key of a non-existing thing with existing user",
-			owner: thing.Owner,
-			id:    nonexistentThingID,
-			key:   newKey,
-			err:   things.ErrNotFound,
-		},
-		{
-			desc:  "update key of an existing thing with non-existing user",
-			owner: wrongValue,
-			id:    thing.ID,
-			key:   newKey,
-			err:   things.ErrNotFound,
-		},
-		{
-			desc:  "update key of a non-existing thing with non-existing user",
-			owner: wrongValue,
-			id:    nonexistentThingID,
-			key:   newKey,
-			err:   things.ErrNotFound,
-		},
-		{
-			desc:  "update key with existing key value",
-			owner: thing.Owner,
-			id:    thing.ID,
-			key:   existingThing.Key,
-			err:   things.ErrConflict,
-		},
-	}
+	err = thingRepo.Update(context.Background(), thing)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
-	for _, tc := range cases {
-		err := thingRepo.UpdateKey(context.Background(), tc.owner, tc.id, tc.key)
-		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
-	}
+	th, err = thingRepo.RetrieveByKey(context.Background(), thkey)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.True(t, createdAt.Equal(th.CreatedAt), fmt.Sprintf("expected creation time %s got %s", createdAt, th.CreatedAt))
+	assert.True(t, th.UpdatedAt.After(createdAt), fmt.Sprintf("expected update time %s to follow creation time %s", th.UpdatedAt, createdAt))
 }
 
 func TestUpdateKey(t *testing.T) {
 	email := "thing-update=key@example.com"
 	newKey := "new-key"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	ethid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -280,7 +231,7 @@ func TestUpdateKey(t *testing.T) {
 		Owner: email,
 		Key:   ethkey,
 	}
-	existingID, _ := thingRepo.Save(existingThing)
+	existingID, _ := thingRepo.Save(context.Background(), existingThing)
 	existingThing.ID = existingID
 
 	thid, err := uuid.New().ID()
@@ -294,7 +245,7 @@ func TestUpdateKey(t *testing.T) {
 		Key:   thkey,
 	}
 
-	id, _ := thingRepo.Save(thing)
+	id, _ := thingRepo.Save(context.Background(), thing)
 	thing.ID = id
 
 	nonexistentThingID, err := uuid.New().ID()
@@ -345,14 +296,14 @@ func TestUpdateKey(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		err := thingRepo.UpdateKey(tc.owner, tc.id, tc.key)
+		err := thingRepo.UpdateKey(context.Background(), tc.owner, tc.id, tc.key, time.Time{})
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 	}
 }
 
 func TestSingleThingRetrieval(t *testing.T) {
 	email := "thing-single-retrieval@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -404,9 +355,55 @@ func TestSingleThingRetrieval(t *testing.T) {
 	}
 }
 
+func TestThingRetrieveOwner(t *testing.T) {
+	email := "thing-retrieve-owner@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	id, err := thingRepo.Save(context.Background(), things.Thing{
+		ID:    thid,
+		Owner: email,
+		Key:   thkey,
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentThingID, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := map[string]struct {
+		ID    string
+		owner string
+		err   error
+	}{
+		"retrieve owner of existing thing": {
+			ID:    id,
+			owner: email,
+			err:   nil,
+		},
+		"retrieve owner of non-existing thing": {
+			ID:  nonexistentThingID,
+			err: things.ErrNotFound,
+		},
+		"retrieve owner of thing with malformed ID": {
+			ID:  wrongValue,
+			err: things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		owner, err := thingRepo.RetrieveOwner(context.Background(), tc.ID)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		assert.Equal(t, tc.owner, owner, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.owner, owner))
+	}
+}
+
 func TestThingRetrieveByKey(t *testing.T) {
 	email := "thing-retrieved-by-key@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -440,17 +437,164 @@ func TestThingRetrieveByKey(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		id, err := thingRepo.RetrieveByKey(context.Background(), tc.key)
-		assert.Equal(t, tc.ID, id, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.ID, id))
+		th, err := thingRepo.RetrieveByKey(context.Background(), tc.key)
+		assert.Equal(t, tc.ID, th.ID, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.ID, th.ID))
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
 	}
 }
 
+func TestThingRetrieveByKeys(t *testing.T) {
+	email := "thing-retrieved-by-keys@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+
+	ids := map[string]string{}
+	for i := 0; i < 2; i++ {
+		thid, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		thkey, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		th := things.Thing{
+			ID:    thid,
+			Owner: email,
+			Key:   thkey,
+		}
+		id, err := thingRepo.Save(context.Background(), th)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[thkey] = id
+	}
+
+	keys := []string{wrongValue}
+	for k := range ids {
+		keys = append(keys, k)
+	}
+
+	cases := map[string]struct {
+		keys []string
+		ids  map[string]string
+	}{
+		"retrieve existing and non-existent things by keys": {
+			keys: keys,
+			ids:  ids,
+		},
+		"retrieve non-existent things by keys": {
+			keys: []string{wrongValue},
+			ids:  map[string]string{},
+		},
+	}
+
+	for desc, tc := range cases {
+		ths, err := thingRepo.RetrieveByKeys(context.Background(), tc.keys)
+		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s\n", desc, err))
+
+		got := map[string]string{}
+		for _, th := range ths {
+			got[th.Key] = th.ID
+		}
+		assert.Equal(t, tc.ids, got, fmt.Sprintf("%s: expected %v got %v\n", desc, tc.ids, got))
+	}
+}
+
+func TestThingRetrieveExpired(t *testing.T) {
+	email := "thing-retrieved-expired@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+
+	now := time.Now()
+	expiries := map[string]time.Time{
+		"expired":   now.Add(-time.Hour),
+		"unexpired": now.Add(time.Hour),
+		"permanent": time.Time{},
+	}
+
+	ids := map[string]string{}
+	for name, expiry := range expiries {
+		thid, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		thkey, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		th := things.Thing{
+			ID:        thid,
+			Owner:     email,
+			Name:      name,
+			Key:       thkey,
+			KeyExpiry: expiry,
+		}
+		id, err := thingRepo.Save(context.Background(), th)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[name] = id
+	}
+
+	expired, err := thingRepo.RetrieveExpired(context.Background(), now, 100)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	found := map[string]bool{}
+	for _, th := range expired {
+		found[th.ID] = true
+	}
+
+	assert.True(t, found[ids["expired"]], "expected expired thing to be retrieved")
+	assert.False(t, found[ids["unexpired"]], "expected unexpired thing not to be retrieved")
+	assert.False(t, found[ids["permanent"]], "expected thing without key expiry not to be retrieved")
+
+	expired, err = thingRepo.RetrieveExpired(context.Background(), now, 0)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Empty(t, expired, "expected no things to be retrieved with zero limit")
+}
+
+func TestThingRotateKey(t *testing.T) {
+	email := "thing-rotate-key@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	th := things.Thing{
+		ID:        thid,
+		Owner:     email,
+		Key:       thkey,
+		KeyExpiry: time.Now().Add(-time.Hour),
+	}
+	th.ID, err = thingRepo.Save(context.Background(), th)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	newKey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc  string
+		thing things.Thing
+		err   error
+	}{
+		{
+			desc:  "rotate expired key",
+			thing: th,
+			err:   nil,
+		},
+		{
+			desc:  "rotate already rotated key",
+			thing: th,
+			err:   things.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := thingRepo.RotateKey(context.Background(), tc.thing, newKey, time.Now().Add(time.Hour))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	id, err := thingRepo.RetrieveByKey(context.Background(), newKey)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, th.ID, id.ID, fmt.Sprintf("expected %s got %s\n", th.ID, id.ID))
+}
+
 func TestMultiThingRetrieval(t *testing.T) {
 	email := "thing-multi-retrieval@example.com"
 	name := "mainflux"
 	idp := uuid.New()
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	n := uint64(10)
 	for i := uint64(0); i < n; i++ {
@@ -521,7 +665,7 @@ func TestMultiThingRetrieval(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		page, err := thingRepo.RetrieveAll(context.Background(), tc.owner, tc.offset, tc.limit, tc.name)
+		page, err := thingRepo.RetrieveAll(context.Background(), tc.owner, tc.offset, tc.limit, tc.name, false)
 		size := uint64(len(page.Things))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
@@ -529,11 +673,52 @@ func TestMultiThingRetrieval(t *testing.T) {
 	}
 }
 
+func TestThingRetrieveAllCompact(t *testing.T) {
+	email := "thing-compact-retrieval@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	th := things.Thing{
+		Owner:    email,
+		ID:       thid,
+		Key:      thkey,
+		Name:     "compact",
+		Metadata: map[string]interface{}{"field": "value"},
+	}
+	_, err = thingRepo.Save(context.Background(), th)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := map[string]struct {
+		compact bool
+		thing   things.Thing
+	}{
+		"retrieve all things": {
+			compact: false,
+			thing:   things.Thing{Owner: email, ID: thid, Key: thkey, Name: "compact", Metadata: th.Metadata},
+		},
+		"retrieve all things in compact view": {
+			compact: true,
+			thing:   things.Thing{Owner: email, ID: thid, Name: "compact"},
+		},
+	}
+
+	for desc, tc := range cases {
+		page, err := thingRepo.RetrieveAll(context.Background(), email, 0, 10, "", tc.compact)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", desc, err))
+		require.Len(t, page.Things, 1, fmt.Sprintf("%s: expected single thing got %v\n", desc, page.Things))
+		assert.Equal(t, tc.thing, page.Things[0], fmt.Sprintf("%s: expected %v got %v\n", desc, tc.thing, page.Things[0]))
+	}
+}
+
 func TestMultiThingRetrievalByChannel(t *testing.T) {
 	email := "thing-multi-retrieval-by-channel@example.com"
 	idp := uuid.New()
-	thingRepo := postgres.NewThingRepository(db)
-	channelRepo := postgres.NewChannelRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
 
 	n := uint64(10)
 
@@ -558,7 +743,7 @@ func TestMultiThingRetrievalByChannel(t *testing.T) {
 
 		tid, err := thingRepo.Save(context.Background(), th)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		err = channelRepo.Connect(context.Background(), email, cid, tid)
+		err = channelRepo.Connect(context.Background(), email, cid, tid, time.Time{})
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 	}
 
@@ -619,9 +804,171 @@ func TestMultiThingRetrievalByChannel(t *testing.T) {
 	}
 }
 
+func TestThingRetrievalByChannelAfter(t *testing.T) {
+	email := "thing-retrieval-by-channel-after@example.com"
+	idp := uuid.New()
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	n := uint64(10)
+
+	chid, err := idp.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	cid, err := channelRepo.Save(context.Background(), things.Channel{
+		ID:    chid,
+		Owner: email,
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	ids := []string{}
+	for i := uint64(0); i < n; i++ {
+		thid, err := idp.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		thkey, err := idp.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		th := things.Thing{
+			ID:    thid,
+			Owner: email,
+			Key:   thkey,
+		}
+
+		tid, err := thingRepo.Save(context.Background(), th)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		err = channelRepo.Connect(context.Background(), email, cid, tid, time.Time{})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		ids = append(ids, tid)
+	}
+	sort.Strings(ids)
+
+	// Read the things in batches, following the cursor.
+	retrieved := []string{}
+	after := ""
+	for {
+		ths, err := thingRepo.RetrieveByChannelAfter(context.Background(), email, cid, after, 3)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		for _, th := range ths {
+			retrieved = append(retrieved, th.ID)
+		}
+		if len(ths) < 3 {
+			break
+		}
+		after = ths[len(ths)-1].ID
+	}
+	assert.Equal(t, ids, retrieved, fmt.Sprintf("expected %v got %v", ids, retrieved))
+
+	_, err = thingRepo.RetrieveByChannelAfter(context.Background(), email, wrongValue, "", n)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve things with malformed channel ID: expected %s got %s", things.ErrNotFound, err))
+
+	_, err = thingRepo.RetrieveByChannelAfter(context.Background(), email, cid, wrongValue, n)
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("retrieve things with malformed cursor: expected %s got %s", things.ErrMalformedEntity, err))
+}
+
+func TestMultiThingRetrievalByChannels(t *testing.T) {
+	email := "thing-multi-retrieval-by-channels@example.com"
+	idp := uuid.New()
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	n := uint64(10)
+
+	chids := []string{}
+	for i := 0; i < 2; i++ {
+		chid, err := idp.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		cid, err := channelRepo.Save(context.Background(), things.Channel{
+			ID:    chid,
+			Owner: email,
+		})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		chids = append(chids, cid)
+	}
+
+	// Every other thing is connected to both channels.
+	for i := uint64(0); i < n; i++ {
+		thid, err := idp.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		thkey, err := idp.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		th := things.Thing{
+			ID:    thid,
+			Owner: email,
+			Key:   thkey,
+		}
+
+		tid, err := thingRepo.Save(context.Background(), th)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		err = channelRepo.Connect(context.Background(), email, chids[0], tid, time.Time{})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		if i%2 == 0 {
+			err = channelRepo.Connect(context.Background(), email, chids[1], tid, time.Time{})
+			require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		}
+	}
+
+	cases := map[string]struct {
+		owner    string
+		channels []string
+		offset   uint64
+		limit    uint64
+		size     uint64
+		total    uint64
+		err      error
+	}{
+		"retrieve all things by channels with existing owner": {
+			owner:    email,
+			channels: chids,
+			offset:   0,
+			limit:    n,
+			size:     n,
+			total:    n,
+		},
+		"retrieve subset of things by channels with existing owner": {
+			owner:    email,
+			channels: chids,
+			offset:   n / 2,
+			limit:    n,
+			size:     n / 2,
+			total:    n,
+		},
+		"retrieve things by single channel with existing owner": {
+			owner:    email,
+			channels: chids[1:],
+			offset:   0,
+			limit:    n,
+			size:     n / 2,
+			total:    n / 2,
+		},
+		"retrieve things by channels with non-existing owner": {
+			owner:    wrongValue,
+			channels: chids,
+			offset:   0,
+			limit:    n,
+			size:     0,
+			total:    0,
+		},
+		"retrieve things with malformed UUID": {
+			owner:    email,
+			channels: []string{chids[0], wrongValue},
+			offset:   0,
+			limit:    n,
+			size:     0,
+			total:    0,
+			err:      things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		page, err := thingRepo.RetrieveByChannels(context.Background(), tc.owner, tc.channels, tc.offset, tc.limit)
+		size := uint64(len(page.Things))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d\n", desc, tc.total, page.Total))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+	}
+}
+
 func TestThingRemoval(t *testing.T) {
 	email := "thing-removal@example.com"
-	thingRepo := postgres.NewThingRepository(db)
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
 
 	thid, err := uuid.New().ID()
 	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
@@ -647,3 +994,66 @@ func TestThingRemoval(t *testing.T) {
 		require.Equal(t, things.ErrNotFound, err, fmt.Sprintf("#%d: expected %s got %s", i, things.ErrNotFound, err))
 	}
 }
+
+func TestPrefixedIDs(t *testing.T) {
+	email := "thing-prefixed-ids@example.com"
+	thingRepo := postgres.NewThingRepository(db, idPrefix)
+	channelRepo := postgres.NewChannelRepository(db, idPrefix)
+
+	thid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	thkey, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chid, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	thid, err = thingRepo.Save(context.Background(), things.Thing{
+		ID:    idPrefix + thid,
+		Owner: email,
+		Key:   thkey,
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	chid, err = channelRepo.Save(context.Background(), things.Channel{
+		ID:    idPrefix + chid,
+		Owner: email,
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = channelRepo.Connect(context.Background(), email, chid, thid, time.Time{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	th, err := thingRepo.RetrieveByID(context.Background(), email, thid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, thid, th.ID, fmt.Sprintf("expected thing %s got %s", thid, th.ID))
+
+	th, err = thingRepo.RetrieveByKey(context.Background(), thkey)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, thid, th.ID, fmt.Sprintf("expected thing %s got %s", thid, th.ID))
+
+	_, err = channelRepo.RetrieveByID(context.Background(), email, chid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	id, err := channelRepo.HasThing(context.Background(), chid, thkey)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, thid, id, fmt.Sprintf("expected thing %s got %s", thid, id))
+
+	page, err := thingRepo.RetrieveByChannel(context.Background(), email, chid, 0, 10)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("expected 1 connected thing got %d", page.Total))
+
+	_, err = thingRepo.Save(context.Background(), things.Thing{
+		ID:    idPrefix + "invalid",
+		Owner: email,
+		Key:   thkey + idPrefix,
+	})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("expected %s got %s", things.ErrMalformedEntity, err))
+
+	foreign, err := uuid.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = thingRepo.Save(context.Background(), things.Thing{
+		ID:    "dev-" + foreign,
+		Owner: email,
+		Key:   thkey + "dev-",
+	})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("expected %s got %s", things.ErrMalformedEntity, err))
+}
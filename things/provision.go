@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "strings"
+
+const (
+	// ProvisionNameVar is replaced by the name of the provisioned thing in
+	// the channel name template.
+	ProvisionNameVar = "{name}"
+
+	// ProvisionIDVar is replaced by the ID of the provisioned thing in the
+	// channel name template.
+	ProvisionIDVar = "{id}"
+)
+
+// DefaultProvisionTemplate creates the channel named after the thing and
+// connects the thing to it.
+var DefaultProvisionTemplate = ProvisionTemplate{
+	Channels: []ChannelTemplate{
+		{Name: ProvisionNameVar, Connect: true},
+	},
+}
+
+// ProvisionTemplate determines the channels created along with the
+// provisioned thing.
+type ProvisionTemplate struct {
+	Channels []ChannelTemplate
+}
+
+// ChannelTemplate describes the channel created along with the provisioned
+// thing. Name may refer to the thing using ProvisionNameVar and
+// ProvisionIDVar.
+type ChannelTemplate struct {
+	Name     string
+	Metadata map[string]interface{}
+	Connect  bool
+}
+
+// Provision contains the thing and the channels created by its provisioning.
+type Provision struct {
+	Thing    Thing
+	Channels []ProvisionedChannel
+}
+
+// ProvisionedChannel is the channel created by the provisioning, along with
+// whether the thing is connected to it.
+type ProvisionedChannel struct {
+	Channel   Channel
+	Connected bool
+}
+
+// Validate returns ErrMalformedEntity if the template connects the thing to
+// more channels than the connection mode allows.
+func (tpl ProvisionTemplate) Validate(mode ConnectionMode) error {
+	connections := 0
+	for _, ch := range tpl.Channels {
+		if ch.Connect {
+			connections++
+		}
+	}
+
+	if mode != SharedConnections && connections > 1 {
+		return ErrMalformedEntity
+	}
+
+	return nil
+}
+
+// channel renders the channel of the given thing.
+func (ct ChannelTemplate) channel(thing Thing) Channel {
+	r := strings.NewReplacer(ProvisionNameVar, thing.Name, ProvisionIDVar, thing.ID)
+	return Channel{
+		Name:     r.Replace(ct.Name),
+		Metadata: ct.Metadata,
+	}
+}
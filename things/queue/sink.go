@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package queue contains the event sink publishing the things events to the
+// managed cloud queue through its HTTP endpoint.
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+const contentType = "application/json"
+
+// ErrRejected indicates that the queue responded with the non-success
+// status code.
+var ErrRejected = errors.New("event rejected by the queue")
+
+var _ things.EventSink = (*sink)(nil)
+
+type sink struct {
+	url         string
+	credentials string
+	client      *http.Client
+}
+
+// NewSink returns the event sink that posts every event, encoded as the JSON
+// object, to the queue endpoint. Non-empty credentials are sent as the value
+// of the Authorization header. Publishing fails unless the event is accepted
+// within the timeout.
+func NewSink(url, credentials string, timeout time.Duration) things.EventSink {
+	return sink{
+		url:         url,
+		credentials: credentials,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+func (s sink) Publish(event map[string]interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.credentials != "" {
+		req.Header.Set("Authorization", s.credentials)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: %d", ErrRejected, res.StatusCode)
+	}
+
+	return nil
+}
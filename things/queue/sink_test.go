@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package queue_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/things/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	credentials = "credentials"
+	timeout     = time.Second
+)
+
+type request struct {
+	contentType   string
+	authorization string
+	event         map[string]interface{}
+}
+
+func newServer(status int, requests chan<- request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&event)
+		requests <- request{
+			contentType:   r.Header.Get("Content-Type"),
+			authorization: r.Header.Get("Authorization"),
+			event:         event,
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func TestPublish(t *testing.T) {
+	event := map[string]interface{}{
+		"id":        "123",
+		"owner":     "john.doe@example.com",
+		"operation": "thing.create",
+	}
+
+	cases := map[string]struct {
+		status      int
+		credentials string
+		err         bool
+	}{
+		"publish accepted event": {
+			status:      http.StatusAccepted,
+			credentials: credentials,
+			err:         false,
+		},
+		"publish event without credentials": {
+			status:      http.StatusOK,
+			credentials: "",
+			err:         false,
+		},
+		"publish rejected event": {
+			status:      http.StatusForbidden,
+			credentials: credentials,
+			err:         true,
+		},
+	}
+
+	for desc, tc := range cases {
+		requests := make(chan request, 1)
+		ts := newServer(tc.status, requests)
+
+		err := queue.NewSink(ts.URL, tc.credentials, timeout).Publish(event)
+		ts.Close()
+
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: unexpected error %s", desc, err))
+
+		req := <-requests
+		assert.Equal(t, "application/json", req.contentType, fmt.Sprintf("%s: expected JSON content type got %s", desc, req.contentType))
+		assert.Equal(t, tc.credentials, req.authorization, fmt.Sprintf("%s: expected authorization %s got %s", desc, tc.credentials, req.authorization))
+		assert.Equal(t, event, req.event, fmt.Sprintf("%s: expected event %v got %v", desc, event, req.event))
+	}
+}
+
+func TestPublishUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	err := queue.NewSink(url, credentials, timeout).Publish(map[string]interface{}{"id": "123"})
+	assert.NotNil(t, err, "publishing to unreachable queue: expected error got nil")
+}
@@ -0,0 +1,370 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux"
+)
+
+var _ Service = (*rateLimiter)(nil)
+
+type rateLimiter struct {
+	svc    Service
+	users  mainflux.UsersServiceClient
+	keys   APIKeyRepository
+	limit  int
+	window time.Duration
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+// NewRateLimiter returns wrapper around things service that allows each
+// owner to create at most limit things and channels within the window.
+// Requests over the limit fail with ErrRateLimited, while entities the
+// wrapped service fails to create aren't counted. Windows are fixed, so the
+// counts of all the owners are reset once the window elapses. Requests made
+// using the API keys, if the keys repository is provided, are counted against
+// the limit of the key owner. Counts are kept in memory, so each replica of
+// the service enforces the limit on its own.
+func NewRateLimiter(svc Service, users mainflux.UsersServiceClient, keys APIKeyRepository, limit int, window time.Duration) Service {
+	return &rateLimiter{
+		svc:    svc,
+		users:  users,
+		keys:   keys,
+		limit:  limit,
+		window: window,
+		start:  time.Now(),
+		counts: make(map[string]int),
+	}
+}
+
+func (rl *rateLimiter) AddThing(ctx context.Context, token string, thing Thing) (Thing, error) {
+	ctx, res, err := rl.reserve(ctx, token, 1)
+	if err != nil {
+		return Thing{}, err
+	}
+
+	saved, err := rl.svc.AddThing(ctx, token, thing)
+	if err != nil {
+		rl.settle(res, 0)
+		return saved, err
+	}
+	rl.settle(res, 1)
+
+	return saved, nil
+}
+
+func (rl *rateLimiter) AddThings(ctx context.Context, token string, things []Thing, stopOnError bool) ([]BulkResult, error) {
+	ctx, res, err := rl.reserve(ctx, token, len(things))
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := rl.svc.AddThings(ctx, token, things, stopOnError)
+
+	created := 0
+	for _, r := range results {
+		if r.Err == nil {
+			created++
+		}
+	}
+	rl.settle(res, created)
+
+	return results, err
+}
+
+// ProvisionThing reserves the thing up front, and counts the channels once
+// they are created, since their number is up to the provisioning template.
+func (rl *rateLimiter) ProvisionThing(ctx context.Context, token string, thing Thing) (Provision, error) {
+	ctx, res, err := rl.reserve(ctx, token, 1)
+	if err != nil {
+		return Provision{}, err
+	}
+
+	prov, err := rl.svc.ProvisionThing(ctx, token, thing)
+	if err != nil {
+		rl.settle(res, 0)
+		return prov, err
+	}
+	rl.settle(res, 1+len(prov.Channels))
+
+	return prov, nil
+}
+
+func (rl *rateLimiter) CreateChannel(ctx context.Context, token string, channel Channel) (Channel, error) {
+	ctx, res, err := rl.reserve(ctx, token, 1)
+	if err != nil {
+		return Channel{}, err
+	}
+
+	saved, err := rl.svc.CreateChannel(ctx, token, channel)
+	if err != nil {
+		rl.settle(res, 0)
+		return saved, err
+	}
+	rl.settle(res, 1)
+
+	return saved, nil
+}
+
+// reservation holds the entities counted against the limit of the owner
+// before they are created.
+type reservation struct {
+	owner  string
+	window time.Time
+	n      int
+}
+
+// reserve counts the request creating n entities against the limit of the
+// owner identified by the provided token, until the request is settled.
+// Requests with invalid tokens aren't counted, they are left to the wrapped
+// service to reject. The returned context carries the identity of the user,
+// so that the wrapped service doesn't identify the token again.
+func (rl *rateLimiter) reserve(ctx context.Context, token string, n int) (context.Context, reservation, error) {
+	ctx, owner, err := rl.identify(ctx, token)
+	if err != nil {
+		return ctx, reservation{}, nil
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.roll()
+	if rl.counts[owner]+n > rl.limit {
+		return ctx, reservation{}, ErrRateLimited
+	}
+	rl.counts[owner] += n
+
+	return ctx, reservation{owner: owner, window: rl.start, n: n}, nil
+}
+
+// settle replaces the reserved entities with the created ones. Entities
+// created once the window of the reservation elapsed are counted in the
+// current window.
+func (rl *rateLimiter) settle(res reservation, created int) {
+	if res.owner == "" {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.roll()
+	if rl.start.Equal(res.window) {
+		created -= res.n
+	}
+	rl.counts[res.owner] += created
+}
+
+// roll resets the counts once the window elapses.
+func (rl *rateLimiter) roll() {
+	if now := time.Now(); now.Sub(rl.start) >= rl.window {
+		rl.start = now
+		rl.counts = make(map[string]int)
+	}
+}
+
+func (rl *rateLimiter) identify(ctx context.Context, token string) (context.Context, string, error) {
+	if rl.keys != nil && strings.HasPrefix(token, APIKeyPrefix) {
+		key, err := rl.keys.RetrieveByKey(ctx, hashToken(token))
+		if err != nil {
+			return ctx, "", err
+		}
+
+		return ctx, key.Owner, nil
+	}
+
+	res, err := rl.users.Identify(ctx, &mainflux.Token{Value: token})
+	if err != nil {
+		return ctx, "", err
+	}
+
+	return withIdentity(ctx, token, res.GetValue()), res.GetValue(), nil
+}
+
+type identityKey struct{}
+
+// identity is the user the users service identified the token as.
+type identity struct {
+	token string
+	email string
+}
+
+// withIdentity returns the context carrying the user identified by the token.
+func withIdentity(ctx context.Context, token, email string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity{token: token, email: email})
+}
+
+// identityFrom returns the user the context carries, provided it was
+// identified by the same token.
+func identityFrom(ctx context.Context, token string) (string, bool) {
+	id, ok := ctx.Value(identityKey{}).(identity)
+	if !ok || id.token != token {
+		return "", false
+	}
+
+	return id.email, true
+}
+
+func (rl *rateLimiter) UpdateThing(ctx context.Context, token string, thing Thing) error {
+	return rl.svc.UpdateThing(ctx, token, thing)
+}
+
+func (rl *rateLimiter) PatchThingMetadata(ctx context.Context, token, id string, patch map[string]interface{}) (map[string]interface{}, error) {
+	return rl.svc.PatchThingMetadata(ctx, token, id, patch)
+}
+
+func (rl *rateLimiter) UpdateKey(ctx context.Context, token, id, key string) error {
+	return rl.svc.UpdateKey(ctx, token, id, key)
+}
+
+func (rl *rateLimiter) ViewThing(ctx context.Context, token, id string) (Thing, error) {
+	return rl.svc.ViewThing(ctx, token, id)
+}
+
+func (rl *rateLimiter) ListThings(ctx context.Context, token string, offset, limit uint64, name string, compact bool) (ThingsPage, error) {
+	return rl.svc.ListThings(ctx, token, offset, limit, name, compact)
+}
+
+func (rl *rateLimiter) NameAvailable(ctx context.Context, token, name string) (bool, error) {
+	return rl.svc.NameAvailable(ctx, token, name)
+}
+
+func (rl *rateLimiter) ListThingsByChannel(ctx context.Context, token, id string, offset, limit uint64) (ThingsPage, error) {
+	return rl.svc.ListThingsByChannel(ctx, token, id, offset, limit)
+}
+
+func (rl *rateLimiter) ListThingsByChannelAfter(ctx context.Context, token, id, after string, limit uint64) ([]Thing, error) {
+	return rl.svc.ListThingsByChannelAfter(ctx, token, id, after, limit)
+}
+
+func (rl *rateLimiter) ListThingsByChannels(ctx context.Context, token string, ids []string, offset, limit uint64) (ThingsPage, error) {
+	return rl.svc.ListThingsByChannels(ctx, token, ids, offset, limit)
+}
+
+func (rl *rateLimiter) RemoveThing(ctx context.Context, token, id string) error {
+	return rl.svc.RemoveThing(ctx, token, id)
+}
+
+func (rl *rateLimiter) UpdateChannel(ctx context.Context, token string, channel Channel) error {
+	return rl.svc.UpdateChannel(ctx, token, channel)
+}
+
+func (rl *rateLimiter) ViewChannel(ctx context.Context, token, id string) (Channel, error) {
+	return rl.svc.ViewChannel(ctx, token, id)
+}
+
+func (rl *rateLimiter) ListChannels(ctx context.Context, token string, offset, limit uint64, name, parent string, compact bool) (ChannelsPage, error) {
+	return rl.svc.ListChannels(ctx, token, offset, limit, name, parent, compact)
+}
+
+func (rl *rateLimiter) ListChannelsByThing(ctx context.Context, token, id string, offset, limit uint64) (ChannelsPage, error) {
+	return rl.svc.ListChannelsByThing(ctx, token, id, offset, limit)
+}
+
+func (rl *rateLimiter) RemoveChannel(ctx context.Context, token, id string, force bool) error {
+	return rl.svc.RemoveChannel(ctx, token, id, force)
+}
+
+func (rl *rateLimiter) IssueChannelToken(ctx context.Context, token, chanID string, ttl time.Duration, scope string) (string, error) {
+	return rl.svc.IssueChannelToken(ctx, token, chanID, ttl, scope)
+}
+
+func (rl *rateLimiter) RevokeChannelTokens(ctx context.Context, token, chanID string) error {
+	return rl.svc.RevokeChannelTokens(ctx, token, chanID)
+}
+
+func (rl *rateLimiter) CreateShareLink(ctx context.Context, token, chanID string, ttl time.Duration) (ShareLink, error) {
+	return rl.svc.CreateShareLink(ctx, token, chanID, ttl)
+}
+
+func (rl *rateLimiter) RevokeShareLink(ctx context.Context, token, chanID, linkToken string) error {
+	return rl.svc.RevokeShareLink(ctx, token, chanID, linkToken)
+}
+
+func (rl *rateLimiter) CanReadShared(ctx context.Context, linkToken, chanID string) error {
+	return rl.svc.CanReadShared(ctx, linkToken, chanID)
+}
+
+func (rl *rateLimiter) IssueAPIKey(ctx context.Context, token string, scopes []string, ttl time.Duration) (APIKey, error) {
+	return rl.svc.IssueAPIKey(ctx, token, scopes, ttl)
+}
+
+func (rl *rateLimiter) ListAPIKeys(ctx context.Context, token string) ([]APIKey, error) {
+	return rl.svc.ListAPIKeys(ctx, token)
+}
+
+func (rl *rateLimiter) RevokeAPIKey(ctx context.Context, token, id string) error {
+	return rl.svc.RevokeAPIKey(ctx, token, id)
+}
+
+func (rl *rateLimiter) Connect(ctx context.Context, token, chanID, thingID string, ttl time.Duration) error {
+	return rl.svc.Connect(ctx, token, chanID, thingID, ttl)
+}
+
+func (rl *rateLimiter) Disconnect(ctx context.Context, token, chanID, thingID string) error {
+	return rl.svc.Disconnect(ctx, token, chanID, thingID)
+}
+
+func (rl *rateLimiter) ReapIdle(ctx context.Context, token, chanID string, olderThan time.Duration) ([]string, error) {
+	return rl.svc.ReapIdle(ctx, token, chanID, olderThan)
+}
+
+func (rl *rateLimiter) CanAccess(ctx context.Context, chanID string, key string) (string, error) {
+	return rl.svc.CanAccess(ctx, chanID, key)
+}
+
+func (rl *rateLimiter) CanReadMessages(ctx context.Context, chanID string, key string) (string, bool, error) {
+	return rl.svc.CanReadMessages(ctx, chanID, key)
+}
+
+func (rl *rateLimiter) CanAccessByID(ctx context.Context, chanID string, thingID string) error {
+	return rl.svc.CanAccessByID(ctx, chanID, thingID)
+}
+
+func (rl *rateLimiter) Identify(ctx context.Context, key string) (string, error) {
+	return rl.svc.Identify(ctx, key)
+}
+
+func (rl *rateLimiter) IdentifyFull(ctx context.Context, key string) (Thing, error) {
+	return rl.svc.IdentifyFull(ctx, key)
+}
+
+func (rl *rateLimiter) IdentifyBatch(ctx context.Context, keys []string) (map[string]string, error) {
+	return rl.svc.IdentifyBatch(ctx, keys)
+}
+
+func (rl *rateLimiter) RotateExpiredKeys(ctx context.Context) ([]string, error) {
+	return rl.svc.RotateExpiredKeys(ctx)
+}
+
+func (rl *rateLimiter) DisconnectExpired(ctx context.Context) ([]Connection, error) {
+	return rl.svc.DisconnectExpired(ctx)
+}
+
+func (rl *rateLimiter) DisableOwner(ctx context.Context, owner string) error {
+	return rl.svc.DisableOwner(ctx, owner)
+}
+
+func (rl *rateLimiter) EnableOwner(ctx context.Context, owner string) error {
+	return rl.svc.EnableOwner(ctx, owner)
+}
+
+func (rl *rateLimiter) ChangeOwnerEmail(ctx context.Context, email, newEmail string) error {
+	return rl.svc.ChangeOwnerEmail(ctx, email, newEmail)
+}
+
+func (rl *rateLimiter) Subscribe(ctx context.Context, token string) (<-chan Event, error) {
+	return rl.svc.Subscribe(ctx, token)
+}
@@ -0,0 +1,175 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestRateLimiter(t *testing.T) {
+	otherToken := "other-token"
+	window := 100 * time.Millisecond
+	tokens := map[string]string{token: email, otherToken: "other@example.com"}
+	keys := mocks.NewAPIKeyRepository()
+	svc := things.NewRateLimiter(newService(tokens, things.WithAPIKeys(keys)), mocks.NewUsersService(tokens), keys, 3, window)
+
+	key, err := svc.IssueAPIKey(context.Background(), token, []string{"*:create"}, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		token  string
+		create func(string) error
+		wait   time.Duration
+		err    error
+	}{
+		{
+			desc:   "create channel with unknown parent",
+			token:  token,
+			create: createChannel(svc, things.Channel{ParentID: wrongValue}),
+			err:    things.ErrNotFound,
+		},
+		{
+			desc:   "add thing within limit",
+			token:  token,
+			create: addThing(svc),
+			err:    nil,
+		},
+		{
+			desc:   "create channel within limit",
+			token:  token,
+			create: createChannel(svc, channel),
+			err:    nil,
+		},
+		{
+			desc:   "add thing at limit",
+			token:  token,
+			create: addThing(svc),
+			err:    nil,
+		},
+		{
+			desc:   "add thing over limit",
+			token:  token,
+			create: addThing(svc),
+			err:    things.ErrRateLimited,
+		},
+		{
+			desc:   "create channel over limit",
+			token:  token,
+			create: createChannel(svc, channel),
+			err:    things.ErrRateLimited,
+		},
+		{
+			desc:   "add thing over limit using API key",
+			token:  key.Key,
+			create: addThing(svc),
+			err:    things.ErrRateLimited,
+		},
+		{
+			desc:   "add thing of other user",
+			token:  otherToken,
+			create: addThing(svc),
+			err:    nil,
+		},
+		{
+			desc:   "provision thing of other user",
+			token:  otherToken,
+			create: provisionThing(svc),
+			err:    nil,
+		},
+		{
+			desc:   "add thing of other user over limit after provisioning",
+			token:  otherToken,
+			create: addThing(svc),
+			err:    things.ErrRateLimited,
+		},
+		{
+			desc:   "add thing with invalid token",
+			token:  wrongValue,
+			create: addThing(svc),
+			err:    things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:   "add thing after window elapsed",
+			token:  token,
+			create: addThing(svc),
+			wait:   window,
+			err:    nil,
+		},
+	}
+
+	for _, tc := range cases {
+		time.Sleep(tc.wait)
+		err := tc.create(tc.token)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestRateLimiterIdentifiesOnce(t *testing.T) {
+	users := &countingUsers{UsersServiceClient: mocks.NewUsersService(map[string]string{token: email})}
+	conns := make(chan mocks.Connection)
+	thingsRepo := mocks.NewThingRepository(conns)
+	channelsRepo := mocks.NewChannelRepository(thingsRepo, conns)
+	svc := things.New(users, thingsRepo, channelsRepo, mocks.NewChannelCache(), mocks.NewThingCache(), mocks.NewIdentityProvider())
+	svc = things.NewRateLimiter(svc, users, nil, 10, time.Minute)
+
+	_, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, 1, users.count(), fmt.Sprintf("expected 1 identification got %d\n", users.count()))
+}
+
+type countingUsers struct {
+	mainflux.UsersServiceClient
+	mu    sync.Mutex
+	calls int
+}
+
+func (cu *countingUsers) Identify(ctx context.Context, in *mainflux.Token, opts ...grpc.CallOption) (*mainflux.UserID, error) {
+	cu.mu.Lock()
+	cu.calls++
+	cu.mu.Unlock()
+	return cu.UsersServiceClient.Identify(ctx, in, opts...)
+}
+
+func (cu *countingUsers) count() int {
+	cu.mu.Lock()
+	defer cu.mu.Unlock()
+	return cu.calls
+}
+
+func addThing(svc things.Service) func(string) error {
+	return func(token string) error {
+		_, err := svc.AddThing(context.Background(), token, thing)
+		return err
+	}
+}
+
+func provisionThing(svc things.Service) func(string) error {
+	return func(token string) error {
+		_, err := svc.ProvisionThing(context.Background(), token, thing)
+		return err
+	}
+}
+
+func createChannel(svc things.Service, channel things.Channel) func(string) error {
+	return func(token string) error {
+		_, err := svc.CreateChannel(context.Background(), token, channel)
+		return err
+	}
+}
@@ -14,6 +14,10 @@ const (
 	channelCreate = channelPrefix + "create"
 	channelUpdate = channelPrefix + "update"
 	channelRemove = channelPrefix + "remove"
+
+	keyEventPrefix = "key."
+	keyRotate      = keyEventPrefix + "rotated"
+	keyExpire      = keyEventPrefix + "expired"
 )
 
 type event interface {
@@ -29,6 +33,8 @@ var (
 	_ event = (*removeChannelEvent)(nil)
 	_ event = (*connectThingEvent)(nil)
 	_ event = (*disconnectThingEvent)(nil)
+	_ event = (*rotateKeyEvent)(nil)
+	_ event = (*expireKeyEvent)(nil)
 )
 
 type createThingEvent struct {
@@ -103,6 +109,7 @@ func (rte removeThingEvent) Encode() map[string]interface{} {
 type createChannelEvent struct {
 	id       string
 	owner    string
+	parentID string
 	name     string
 	metadata map[string]interface{}
 }
@@ -114,6 +121,10 @@ func (cce createChannelEvent) Encode() map[string]interface{} {
 		"operation": channelCreate,
 	}
 
+	if cce.parentID != "" {
+		val["parent_id"] = cce.parentID
+	}
+
 	if cce.name != "" {
 		val["name"] = cce.name
 	}
@@ -132,6 +143,7 @@ func (cce createChannelEvent) Encode() map[string]interface{} {
 
 type updateChannelEvent struct {
 	id       string
+	parentID string
 	name     string
 	metadata map[string]interface{}
 }
@@ -142,6 +154,10 @@ func (uce updateChannelEvent) Encode() map[string]interface{} {
 		"operation": channelUpdate,
 	}
 
+	if uce.parentID != "" {
+		val["parent_id"] = uce.parentID
+	}
+
 	if uce.name != "" {
 		val["name"] = uce.name
 	}
@@ -194,3 +210,34 @@ func (dte disconnectThingEvent) Encode() map[string]interface{} {
 		"operation": thingDisconnect,
 	}
 }
+
+// rotateKeyEvent carries the ID of the thing whose key is replaced, and
+// whether it's replaced due to the expiry. Key value is never sent.
+type rotateKeyEvent struct {
+	id      string
+	expired bool
+}
+
+func (rke rotateKeyEvent) Encode() map[string]interface{} {
+	reason := "update"
+	if rke.expired {
+		reason = "expiry"
+	}
+
+	return map[string]interface{}{
+		"id":        rke.id,
+		"reason":    reason,
+		"operation": keyRotate,
+	}
+}
+
+type expireKeyEvent struct {
+	id string
+}
+
+func (eke expireKeyEvent) Encode() map[string]interface{} {
+	return map[string]interface{}{
+		"id":        eke.id,
+		"operation": keyExpire,
+	}
+}
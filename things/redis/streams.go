@@ -9,6 +9,7 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/mainflux/mainflux/things"
@@ -19,19 +20,42 @@ const (
 	streamLen = 1000
 )
 
-var _ things.Service = (*eventStore)(nil)
+var (
+	_ things.Service   = (*eventStore)(nil)
+	_ things.EventSink = (*streamSink)(nil)
+)
 
-type eventStore struct {
-	svc    things.Service
+type streamSink struct {
 	client *redis.Client
 }
 
-// NewEventStoreMiddleware returns wrapper around things service that sends
-// events to event store.
-func NewEventStoreMiddleware(svc things.Service, client *redis.Client) things.Service {
+// NewStreamSink returns the event sink that appends the events to the
+// Redis stream, capped to the latest events.
+func NewStreamSink(client *redis.Client) things.EventSink {
+	return streamSink{client: client}
+}
+
+func (ss streamSink) Publish(event map[string]interface{}) error {
+	record := &redis.XAddArgs{
+		Stream:       streamID,
+		MaxLenApprox: streamLen,
+		Values:       event,
+	}
+
+	return ss.client.XAdd(record).Err()
+}
+
+type eventStore struct {
+	svc  things.Service
+	sink things.EventSink
+}
+
+// NewEventStoreMiddleware returns wrapper around things service that
+// publishes events to the given sink, e.g. the event store.
+func NewEventStoreMiddleware(svc things.Service, sink things.EventSink) things.Service {
 	return eventStore{
-		svc:    svc,
-		client: client,
+		svc:  svc,
+		sink: sink,
 	}
 }
 
@@ -47,16 +71,67 @@ func (es eventStore) AddThing(ctx context.Context, token string, thing things.Th
 		name:     sth.Name,
 		metadata: sth.Metadata,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return sth, err
 }
 
+func (es eventStore) AddThings(ctx context.Context, token string, ths []things.Thing, stopOnError bool) ([]things.BulkResult, error) {
+	results, err := es.svc.AddThings(ctx, token, ths, stopOnError)
+
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+
+		event := createThingEvent{
+			id:       res.Thing.ID,
+			owner:    res.Thing.Owner,
+			name:     res.Thing.Name,
+			metadata: res.Thing.Metadata,
+		}
+		es.sink.Publish(event.Encode())
+	}
+
+	return results, err
+}
+
+func (es eventStore) ProvisionThing(ctx context.Context, token string, thing things.Thing) (things.Provision, error) {
+	prov, err := es.svc.ProvisionThing(ctx, token, thing)
+	if err != nil {
+		return prov, err
+	}
+
+	events := []event{
+		createThingEvent{
+			id:       prov.Thing.ID,
+			owner:    prov.Thing.Owner,
+			name:     prov.Thing.Name,
+			metadata: prov.Thing.Metadata,
+		},
+	}
+	for _, pc := range prov.Channels {
+		events = append(events, createChannelEvent{
+			id:       pc.Channel.ID,
+			owner:    pc.Channel.Owner,
+			name:     pc.Channel.Name,
+			metadata: pc.Channel.Metadata,
+		})
+		if pc.Connected {
+			events = append(events, connectThingEvent{
+				chanID:  pc.Channel.ID,
+				thingID: prov.Thing.ID,
+			})
+		}
+	}
+
+	for _, e := range events {
+		es.sink.Publish(e.Encode())
+	}
+
+	return prov, nil
+}
+
 func (es eventStore) UpdateThing(ctx context.Context, token string, thing things.Thing) error {
 	if err := es.svc.UpdateThing(ctx, token, thing); err != nil {
 		return err
@@ -67,35 +142,67 @@ func (es eventStore) UpdateThing(ctx context.Context, token string, thing things
 		name:     thing.Name,
 		metadata: thing.Metadata,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
 
-// UpdateKey doesn't send event because key shouldn't be sent over stream.
-// Maybe we can start publishing this event at some point, without key value
-// in order to notify adapters to disconnect connected things after key update.
+// PatchThingMetadata sends the thing update event carrying the resulting
+// metadata, so that the consumers don't have to apply the patch themselves.
+func (es eventStore) PatchThingMetadata(ctx context.Context, token, id string, patch map[string]interface{}) (map[string]interface{}, error) {
+	metadata, err := es.svc.PatchThingMetadata(ctx, token, id, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	event := updateThingEvent{
+		id:       id,
+		metadata: metadata,
+	}
+	es.sink.Publish(event.Encode())
+
+	return metadata, nil
+}
+
+// UpdateKey sends the key rotation event without the key value, because the
+// key shouldn't be sent over stream.
 func (es eventStore) UpdateKey(ctx context.Context, token, id, key string) error {
-	return es.svc.UpdateKey(ctx, token, id, key)
+	if err := es.svc.UpdateKey(ctx, token, id, key); err != nil {
+		return err
+	}
+
+	event := rotateKeyEvent{
+		id: id,
+	}
+	es.sink.Publish(event.Encode())
+
+	return nil
 }
 
 func (es eventStore) ViewThing(ctx context.Context, token, id string) (things.Thing, error) {
 	return es.svc.ViewThing(ctx, token, id)
 }
 
-func (es eventStore) ListThings(ctx context.Context, token string, offset, limit uint64, name string) (things.ThingsPage, error) {
-	return es.svc.ListThings(ctx, token, offset, limit, name)
+func (es eventStore) ListThings(ctx context.Context, token string, offset, limit uint64, name string, compact bool) (things.ThingsPage, error) {
+	return es.svc.ListThings(ctx, token, offset, limit, name, compact)
+}
+
+func (es eventStore) NameAvailable(ctx context.Context, token, name string) (bool, error) {
+	return es.svc.NameAvailable(ctx, token, name)
 }
 
 func (es eventStore) ListThingsByChannel(ctx context.Context, token, id string, offset, limit uint64) (things.ThingsPage, error) {
 	return es.svc.ListThingsByChannel(ctx, token, id, offset, limit)
 }
 
+func (es eventStore) ListThingsByChannelAfter(ctx context.Context, token, id, after string, limit uint64) ([]things.Thing, error) {
+	return es.svc.ListThingsByChannelAfter(ctx, token, id, after, limit)
+}
+
+func (es eventStore) ListThingsByChannels(ctx context.Context, token string, ids []string, offset, limit uint64) (things.ThingsPage, error) {
+	return es.svc.ListThingsByChannels(ctx, token, ids, offset, limit)
+}
+
 func (es eventStore) RemoveThing(ctx context.Context, token, id string) error {
 	if err := es.svc.RemoveThing(ctx, token, id); err != nil {
 		return err
@@ -104,12 +211,7 @@ func (es eventStore) RemoveThing(ctx context.Context, token, id string) error {
 	event := removeThingEvent{
 		id: id,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
@@ -123,15 +225,11 @@ func (es eventStore) CreateChannel(ctx context.Context, token string, channel th
 	event := createChannelEvent{
 		id:       sch.ID,
 		owner:    sch.Owner,
+		parentID: sch.ParentID,
 		name:     sch.Name,
 		metadata: sch.Metadata,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return sch, err
 }
@@ -141,17 +239,18 @@ func (es eventStore) UpdateChannel(ctx context.Context, token string, channel th
 		return err
 	}
 
+	parentID := channel.ParentID
+	if parentID == things.NoParent {
+		parentID = ""
+	}
+
 	event := updateChannelEvent{
 		id:       channel.ID,
+		parentID: parentID,
 		name:     channel.Name,
 		metadata: channel.Metadata,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
@@ -160,34 +259,61 @@ func (es eventStore) ViewChannel(ctx context.Context, token, id string) (things.
 	return es.svc.ViewChannel(ctx, token, id)
 }
 
-func (es eventStore) ListChannels(ctx context.Context, token string, offset, limit uint64, name string) (things.ChannelsPage, error) {
-	return es.svc.ListChannels(ctx, token, offset, limit, name)
+func (es eventStore) ListChannels(ctx context.Context, token string, offset, limit uint64, name, parent string, compact bool) (things.ChannelsPage, error) {
+	return es.svc.ListChannels(ctx, token, offset, limit, name, parent, compact)
 }
 
 func (es eventStore) ListChannelsByThing(ctx context.Context, token, id string, offset, limit uint64) (things.ChannelsPage, error) {
 	return es.svc.ListChannelsByThing(ctx, token, id, offset, limit)
 }
 
-func (es eventStore) RemoveChannel(ctx context.Context, token, id string) error {
-	if err := es.svc.RemoveChannel(ctx, token, id); err != nil {
+func (es eventStore) RemoveChannel(ctx context.Context, token, id string, force bool) error {
+	if err := es.svc.RemoveChannel(ctx, token, id, force); err != nil {
 		return err
 	}
 
 	event := removeChannelEvent{
 		id: id,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
 
-func (es eventStore) Connect(ctx context.Context, token, chanID, thingID string) error {
-	if err := es.svc.Connect(ctx, token, chanID, thingID); err != nil {
+func (es eventStore) IssueChannelToken(ctx context.Context, token, chanID string, ttl time.Duration, scope string) (string, error) {
+	return es.svc.IssueChannelToken(ctx, token, chanID, ttl, scope)
+}
+
+func (es eventStore) RevokeChannelTokens(ctx context.Context, token, chanID string) error {
+	return es.svc.RevokeChannelTokens(ctx, token, chanID)
+}
+
+func (es eventStore) CreateShareLink(ctx context.Context, token, chanID string, ttl time.Duration) (things.ShareLink, error) {
+	return es.svc.CreateShareLink(ctx, token, chanID, ttl)
+}
+
+func (es eventStore) RevokeShareLink(ctx context.Context, token, chanID, linkToken string) error {
+	return es.svc.RevokeShareLink(ctx, token, chanID, linkToken)
+}
+
+func (es eventStore) CanReadShared(ctx context.Context, linkToken, chanID string) error {
+	return es.svc.CanReadShared(ctx, linkToken, chanID)
+}
+
+func (es eventStore) IssueAPIKey(ctx context.Context, token string, scopes []string, ttl time.Duration) (things.APIKey, error) {
+	return es.svc.IssueAPIKey(ctx, token, scopes, ttl)
+}
+
+func (es eventStore) ListAPIKeys(ctx context.Context, token string) ([]things.APIKey, error) {
+	return es.svc.ListAPIKeys(ctx, token)
+}
+
+func (es eventStore) RevokeAPIKey(ctx context.Context, token, id string) error {
+	return es.svc.RevokeAPIKey(ctx, token, id)
+}
+
+func (es eventStore) Connect(ctx context.Context, token, chanID, thingID string, ttl time.Duration) error {
+	if err := es.svc.Connect(ctx, token, chanID, thingID, ttl); err != nil {
 		return err
 	}
 
@@ -195,12 +321,7 @@ func (es eventStore) Connect(ctx context.Context, token, chanID, thingID string)
 		chanID:  chanID,
 		thingID: thingID,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
@@ -214,20 +335,33 @@ func (es eventStore) Disconnect(ctx context.Context, token, chanID, thingID stri
 		chanID:  chanID,
 		thingID: thingID,
 	}
-	record := &redis.XAddArgs{
-		Stream:       streamID,
-		MaxLenApprox: streamLen,
-		Values:       event.Encode(),
-	}
-	es.client.XAdd(record).Err()
+	es.sink.Publish(event.Encode())
 
 	return nil
 }
 
+func (es eventStore) ReapIdle(ctx context.Context, token, chanID string, olderThan time.Duration) ([]string, error) {
+	ids, err := es.svc.ReapIdle(ctx, token, chanID, olderThan)
+
+	for _, id := range ids {
+		event := disconnectThingEvent{
+			chanID:  chanID,
+			thingID: id,
+		}
+		es.sink.Publish(event.Encode())
+	}
+
+	return ids, err
+}
+
 func (es eventStore) CanAccess(ctx context.Context, chanID string, key string) (string, error) {
 	return es.svc.CanAccess(ctx, chanID, key)
 }
 
+func (es eventStore) CanReadMessages(ctx context.Context, chanID string, key string) (string, bool, error) {
+	return es.svc.CanReadMessages(ctx, chanID, key)
+}
+
 func (es eventStore) CanAccessByID(ctx context.Context, chanID string, thingID string) error {
 	return es.svc.CanAccessByID(ctx, chanID, thingID)
 }
@@ -235,3 +369,57 @@ func (es eventStore) CanAccessByID(ctx context.Context, chanID string, thingID s
 func (es eventStore) Identify(ctx context.Context, key string) (string, error) {
 	return es.svc.Identify(ctx, key)
 }
+
+func (es eventStore) IdentifyFull(ctx context.Context, key string) (things.Thing, error) {
+	return es.svc.IdentifyFull(ctx, key)
+}
+
+func (es eventStore) IdentifyBatch(ctx context.Context, keys []string) (map[string]string, error) {
+	return es.svc.IdentifyBatch(ctx, keys)
+}
+
+func (es eventStore) RotateExpiredKeys(ctx context.Context) ([]string, error) {
+	ids, err := es.svc.RotateExpiredKeys(ctx)
+
+	for _, id := range ids {
+		events := []event{
+			expireKeyEvent{id: id},
+			rotateKeyEvent{id: id, expired: true},
+		}
+		for _, event := range events {
+			es.sink.Publish(event.Encode())
+		}
+	}
+
+	return ids, err
+}
+
+func (es eventStore) DisconnectExpired(ctx context.Context) ([]things.Connection, error) {
+	conns, err := es.svc.DisconnectExpired(ctx)
+
+	for _, conn := range conns {
+		event := disconnectThingEvent{
+			chanID:  conn.ChanID,
+			thingID: conn.ThingID,
+		}
+		es.sink.Publish(event.Encode())
+	}
+
+	return conns, err
+}
+
+func (es eventStore) DisableOwner(ctx context.Context, owner string) error {
+	return es.svc.DisableOwner(ctx, owner)
+}
+
+func (es eventStore) EnableOwner(ctx context.Context, owner string) error {
+	return es.svc.EnableOwner(ctx, owner)
+}
+
+func (es eventStore) ChangeOwnerEmail(ctx context.Context, email, newEmail string) error {
+	return es.svc.ChangeOwnerEmail(ctx, email, newEmail)
+}
+
+func (es eventStore) Subscribe(ctx context.Context, token string) (<-chan things.Event, error) {
+	return es.svc.Subscribe(ctx, token)
+}
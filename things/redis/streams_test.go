@@ -38,9 +38,13 @@ const (
 	channelCreate = channelPrefix + "create"
 	channelUpdate = channelPrefix + "update"
 	channelRemove = channelPrefix + "remove"
+
+	keyEventPrefix = "key."
+	keyRotate      = keyEventPrefix + "rotated"
+	keyExpire      = keyEventPrefix + "expired"
 )
 
-func newService(tokens map[string]string) things.Service {
+func newService(tokens map[string]string, opts ...things.Option) things.Service {
 	users := mocks.NewUsersService(tokens)
 	conns := make(chan mocks.Connection)
 	thingsRepo := mocks.NewThingRepository(conns)
@@ -49,14 +53,14 @@ func newService(tokens map[string]string) things.Service {
 	thingCache := mocks.NewThingCache()
 	idp := mocks.NewIdentityProvider()
 
-	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
+	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, opts...)
 }
 
 func TestAddThing(t *testing.T) {
 	redisClient.FlushAll().Err()
 
 	svc := newService(map[string]string{token: email})
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc  string
@@ -105,6 +109,51 @@ func TestAddThing(t *testing.T) {
 	}
 }
 
+func TestProvisionThing(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	svc := newService(map[string]string{token: email})
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
+
+	prov, err := svc.ProvisionThing(context.Background(), token, things.Thing{Name: "a"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	require.Len(t, prov.Channels, 1, fmt.Sprintf("expected 1 channel got %d", len(prov.Channels)))
+	chanID := prov.Channels[0].Channel.ID
+
+	events := []map[string]interface{}{
+		{
+			"id":        prov.Thing.ID,
+			"name":      "a",
+			"owner":     email,
+			"operation": thingCreate,
+		},
+		{
+			"id":        chanID,
+			"name":      "a",
+			"owner":     email,
+			"operation": channelCreate,
+		},
+		{
+			"chan_id":   chanID,
+			"thing_id":  prov.Thing.ID,
+			"operation": thingConnect,
+		},
+	}
+
+	streams := redisClient.XRead(&r.XReadArgs{
+		Streams: []string{streamID, "0"},
+		Count:   int64(len(events) + 1),
+		Block:   time.Second,
+	}).Val()
+	require.Len(t, streams, 1, "expected events stream")
+
+	recorded := []map[string]interface{}{}
+	for _, msg := range streams[0].Messages {
+		recorded = append(recorded, msg.Values)
+	}
+	assert.Equal(t, events, recorded, fmt.Sprintf("expected %v got %v\n", events, recorded))
+}
+
 func TestUpdateThing(t *testing.T) {
 	redisClient.FlushAll().Err()
 
@@ -114,7 +163,7 @@ func TestUpdateThing(t *testing.T) {
 	sth, err := svc.AddThing(context.Background(), token, th)
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc  string
@@ -163,6 +212,124 @@ func TestUpdateThing(t *testing.T) {
 	}
 }
 
+func TestUpdateKey(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	svc := newService(map[string]string{token: email})
+	// Create thing without sending event.
+	sth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
+
+	cases := []struct {
+		desc  string
+		id    string
+		key   string
+		token string
+		err   error
+		event map[string]interface{}
+	}{
+		{
+			desc:  "update key of existing thing successfully",
+			id:    sth.ID,
+			key:   "new-key",
+			token: token,
+			err:   nil,
+			event: map[string]interface{}{
+				"id":        sth.ID,
+				"reason":    "update",
+				"operation": keyRotate,
+			},
+		},
+		{
+			desc:  "update key with invalid credentials",
+			id:    sth.ID,
+			key:   "other-key",
+			token: "",
+			err:   things.ErrUnauthorizedAccess,
+			event: nil,
+		},
+	}
+
+	lastID := "0"
+	for _, tc := range cases {
+		err := svc.UpdateKey(context.Background(), tc.token, tc.id, tc.key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		streams := redisClient.XRead(&r.XReadArgs{
+			Streams: []string{streamID, lastID},
+			Count:   1,
+			Block:   time.Second,
+		}).Val()
+
+		var event map[string]interface{}
+		if len(streams) > 0 && len(streams[0].Messages) > 0 {
+			msg := streams[0].Messages[0]
+			event = msg.Values
+			lastID = msg.ID
+		}
+
+		assert.Equal(t, tc.event, event, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.event, event))
+		for field, value := range event {
+			assert.NotEqual(t, tc.key, value, fmt.Sprintf("%s: expected key to be omitted got it in %s", tc.desc, field))
+		}
+	}
+}
+
+func TestRotateExpiredKeys(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	ttl := 100 * time.Millisecond
+	svc := newService(map[string]string{token: email}, things.WithKeyTTL(ttl))
+	// Create thing without sending event.
+	sth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
+	time.Sleep(ttl)
+
+	ids, err := svc.RotateExpiredKeys(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, []string{sth.ID}, ids, fmt.Sprintf("expected rotated thing %s got %v", sth.ID, ids))
+
+	th, err := svc.ViewThing(context.Background(), token, sth.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	streams := redisClient.XRead(&r.XReadArgs{
+		Streams: []string{streamID, "0"},
+		Count:   10,
+		Block:   time.Second,
+	}).Val()
+
+	events := []map[string]interface{}{}
+	if len(streams) > 0 {
+		for _, msg := range streams[0].Messages {
+			events = append(events, msg.Values)
+		}
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"id":        sth.ID,
+			"operation": keyExpire,
+		},
+		{
+			"id":        sth.ID,
+			"reason":    "expiry",
+			"operation": keyRotate,
+		},
+	}
+	assert.Equal(t, expected, events, fmt.Sprintf("expected %v got %v", expected, events))
+
+	for _, event := range events {
+		for field, value := range event {
+			assert.NotEqual(t, sth.Key, value, fmt.Sprintf("expected expired key to be omitted got it in %s", field))
+			assert.NotEqual(t, th.Key, value, fmt.Sprintf("expected new key to be omitted got it in %s", field))
+		}
+	}
+}
+
 func TestViewThing(t *testing.T) {
 	redisClient.FlushAll().Err()
 
@@ -171,7 +338,7 @@ func TestViewThing(t *testing.T) {
 	sth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 	esth, eserr := essvc.ViewThing(context.Background(), token, sth.ID)
 	th, err := svc.ViewThing(context.Background(), token, sth.ID)
 	assert.Equal(t, th, esth, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", th, esth))
@@ -186,9 +353,9 @@ func TestListThings(t *testing.T) {
 	_, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
-	esths, eserr := essvc.ListThings(context.Background(), token, 0, 10, "")
-	ths, err := svc.ListThings(context.Background(), token, 0, 10, "")
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
+	esths, eserr := essvc.ListThings(context.Background(), token, 0, 10, "", false)
+	ths, err := svc.ListThings(context.Background(), token, 0, 10, "", false)
 	assert.Equal(t, ths, esths, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", ths, esths))
 	assert.Equal(t, err, eserr, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", err, eserr))
 }
@@ -202,10 +369,10 @@ func TestListThingsByChannel(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
-	err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 	esths, eserr := essvc.ListThingsByChannel(context.Background(), token, sch.ID, 0, 10)
 	ths, err := svc.ListThingsByChannel(context.Background(), token, sch.ID, 0, 10)
 	assert.Equal(t, ths, esths, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", ths, esths))
@@ -220,7 +387,7 @@ func TestRemoveThing(t *testing.T) {
 	sth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc  string
@@ -274,7 +441,7 @@ func TestCreateChannel(t *testing.T) {
 	redisClient.FlushAll().Err()
 
 	svc := newService(map[string]string{token: email})
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc    string
@@ -335,7 +502,7 @@ func TestUpdateChannel(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc    string
@@ -402,7 +569,7 @@ func TestViewChannel(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 	esch, eserr := essvc.ViewChannel(context.Background(), token, sch.ID)
 	ch, err := svc.ViewChannel(context.Background(), token, sch.ID)
 	assert.Equal(t, ch, esch, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", ch, esch))
@@ -417,9 +584,9 @@ func TestListChannels(t *testing.T) {
 	_, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
-	eschs, eserr := essvc.ListChannels(context.Background(), token, 0, 10, "")
-	chs, err := svc.ListChannels(context.Background(), token, 0, 10, "")
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
+	eschs, eserr := essvc.ListChannels(context.Background(), token, 0, 10, "", "", false)
+	chs, err := svc.ListChannels(context.Background(), token, 0, 10, "", "", false)
 	assert.Equal(t, chs, eschs, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", chs, eschs))
 	assert.Equal(t, err, eserr, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", err, eserr))
 }
@@ -433,10 +600,10 @@ func TestListChannelsByThing(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
-	err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	essvc := redis.NewEventStoreMiddleware(svc, redisClient)
+	essvc := redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 	eschs, eserr := essvc.ListChannelsByThing(context.Background(), token, sth.ID, 0, 10)
 	chs, err := svc.ListChannelsByThing(context.Background(), token, sth.ID, 0, 10)
 	assert.Equal(t, chs, eschs, fmt.Sprintf("event sourcing changed service behaviour: expected %v got %v", chs, eschs))
@@ -451,7 +618,7 @@ func TestRemoveChannel(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc  string
@@ -481,7 +648,7 @@ func TestRemoveChannel(t *testing.T) {
 
 	lastID := "0"
 	for _, tc := range cases {
-		err := svc.RemoveChannel(context.Background(), tc.key, tc.id)
+		err := svc.RemoveChannel(context.Background(), tc.key, tc.id, false)
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 
 		streams := redisClient.XRead(&r.XReadArgs{
@@ -511,7 +678,7 @@ func TestConnectEvent(t *testing.T) {
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc    string
@@ -545,7 +712,7 @@ func TestConnectEvent(t *testing.T) {
 
 	lastID := "0"
 	for _, tc := range cases {
-		err := svc.Connect(context.Background(), tc.key, tc.chanID, tc.thingID)
+		err := svc.Connect(context.Background(), tc.key, tc.chanID, tc.thingID, 0)
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 
 		streams := redisClient.XRead(&r.XReadArgs{
@@ -574,10 +741,10 @@ func TestDisconnectEvent(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 	sch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
-	err = svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
 
-	svc = redis.NewEventStoreMiddleware(svc, redisClient)
+	svc = redis.NewEventStoreMiddleware(svc, redis.NewStreamSink(redisClient))
 
 	cases := []struct {
 		desc    string
@@ -10,10 +10,35 @@ package things
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
 )
 
+// lastSeenResolution is the precision of the persisted time the things were
+// last seen at.
+const lastSeenResolution = time.Minute
+
+// connectedPageSize is the number of channels retrieved per page while
+// looking up the channels the thing is connected to.
+const connectedPageSize = 100
+
+// ownedPageSize is the number of things retrieved per page while walking the
+// things of the owner.
+const ownedPageSize = 100
+
+// maxChannelDepth is the max number of the channel ancestors.
+const maxChannelDepth = 64
+
+// rotationBatch is the number of things with expired keys retrieved per page
+// while rotating the expired keys.
+const rotationBatch = 100
+
 var (
 	// ErrMalformedEntity indicates malformed entity specification (e.g.
 	// invalid username or password).
@@ -28,6 +53,46 @@ var (
 
 	// ErrConflict indicates that entity already exists.
 	ErrConflict = errors.New("entity already exists")
+
+	// ErrChannelTokensDisabled indicates that the service is not configured
+	// to issue channel tokens.
+	ErrChannelTokensDisabled = errors.New("channel tokens are disabled")
+
+	// ErrKeyExpired indicates that the provided thing key has expired and
+	// has to be rotated.
+	ErrKeyExpired = errors.New("thing key expired")
+
+	// ErrShareLinksDisabled indicates that the service is not configured to
+	// create share links.
+	ErrShareLinksDisabled = errors.New("share links are disabled")
+
+	// ErrRateLimited indicates that the user exceeded the allowed rate of
+	// creating things and channels.
+	ErrRateLimited = errors.New("creation rate limit exceeded")
+
+	// ErrDynamicChannel indicates that the things can't be explicitly
+	// connected to the channel whose membership is defined by the query.
+	ErrDynamicChannel = errors.New("channel membership is defined by query")
+
+	// ErrAPIKeysDisabled indicates that the service is not configured to
+	// issue API keys.
+	ErrAPIKeysDisabled = errors.New("API keys are disabled")
+
+	// ErrProtected indicates that the protected channel can't be removed
+	// unless the removal is forced.
+	ErrProtected = errors.New("channel is protected")
+
+	// ErrOwnerDisabled indicates that the thing key is rejected because the
+	// owner of the thing is disabled.
+	ErrOwnerDisabled = errors.New("thing owner is disabled")
+
+	// ErrOwnerManagementDisabled indicates that the service is not configured to
+	// disable the owners.
+	ErrOwnerManagementDisabled = errors.New("owner management is disabled")
+
+	// ErrNotActivated indicates that the thing key is rejected because the
+	// thing isn't connected to the required number of channels yet.
+	ErrNotActivated = errors.New("thing is not activated")
 )
 
 // Service specifies an API that must be fullfiled by the domain service
@@ -36,10 +101,29 @@ type Service interface {
 	// AddThing adds new thing to the user identified by the provided key.
 	AddThing(context.Context, string, Thing) (Thing, error)
 
+	// AddThings adds the things to the user identified by the provided key.
+	// Things are added one by one, so that the failure to add one of them
+	// doesn't prevent adding the others, unless the adding is told to stop
+	// at the first failure. Unlike AddThing, it keeps the provided thing IDs.
+	// It returns the result of every processed thing, in order.
+	AddThings(context.Context, string, []Thing, bool) ([]BulkResult, error)
+
+	// ProvisionThing adds new thing to the user identified by the provided
+	// key, along with the channels of the provisioning template, and connects
+	// the thing to them as the template says. If any of the resources can't
+	// be created, the ones created already are removed.
+	ProvisionThing(context.Context, string, Thing) (Provision, error)
+
 	// UpdateThing updates the thing identified by the provided ID, that
 	// belongs to the user identified by the provided key.
 	UpdateThing(context.Context, string, Thing) error
 
+	// PatchThingMetadata applies the JSON merge patch (RFC 7386) to the
+	// metadata of the thing identified by the provided ID, that belongs to
+	// the user identified by the provided key, and returns the resulting
+	// metadata. Keys patched with null are removed.
+	PatchThingMetadata(context.Context, string, string, map[string]interface{}) (map[string]interface{}, error)
+
 	// UpdateKey updates key value of the existing thing. A non-nil error is
 	// returned to indicate operation failure.
 	UpdateKey(context.Context, string, string, string) error
@@ -49,14 +133,32 @@ type Service interface {
 	ViewThing(context.Context, string, string) (Thing, error)
 
 	// ListThings retrieves data about subset of things that belongs to the
-	// user identified by the provided key.
-	ListThings(context.Context, string, uint64, uint64, string) (ThingsPage, error)
+	// user identified by the provided key. Compact listing retrieves only the
+	// IDs and the names of the things.
+	ListThings(context.Context, string, uint64, uint64, string, bool) (ThingsPage, error)
+
+	// NameAvailable returns true if the user identified by the provided key
+	// doesn't own a thing with the given name yet.
+	NameAvailable(context.Context, string, string) (bool, error)
 
 	// ListThingsByChannel retrieves data about subset of things that are
 	// connected to specified channel and belong to the user identified by
 	// the provided key.
 	ListThingsByChannel(context.Context, string, string, uint64, uint64) (ThingsPage, error)
 
+	// ListThingsByChannelAfter retrieves up to the limit of things connected
+	// to specified channel and belonging to the user identified by the
+	// provided key, whose IDs follow the given cursor. Things are ordered by
+	// ID, so the ID of the last retrieved thing is the cursor of the next
+	// batch.
+	ListThingsByChannelAfter(context.Context, string, string, string, uint64) ([]Thing, error)
+
+	// ListThingsByChannels retrieves data about subset of things that are
+	// connected to any of the specified channels and belong to the user
+	// identified by the provided key. Every thing is listed once, no matter
+	// how many of the channels it is connected to.
+	ListThingsByChannels(context.Context, string, []string, uint64, uint64) (ThingsPage, error)
+
 	// RemoveThing removes the thing identified with the provided ID, that
 	// belongs to the user identified by the provided key.
 	RemoveThing(context.Context, string, string) error
@@ -65,7 +167,9 @@ type Service interface {
 	CreateChannel(context.Context, string, Channel) (Channel, error)
 
 	// UpdateChannel updates the channel identified by the provided ID, that
-	// belongs to the user identified by the provided key.
+	// belongs to the user identified by the provided key. Empty parent ID
+	// keeps the stored parent of the channel, while NoParent detaches the
+	// channel from its parent.
 	UpdateChannel(context.Context, string, Channel) error
 
 	// ViewChannel retrieves data about the channel identified by the provided
@@ -73,8 +177,10 @@ type Service interface {
 	ViewChannel(context.Context, string, string) (Channel, error)
 
 	// ListChannels retrieves data about subset of channels that belongs to the
-	// user identified by the provided key.
-	ListChannels(context.Context, string, uint64, uint64, string) (ChannelsPage, error)
+	// user identified by the provided key. If the parent channel ID is
+	// provided, only the channels of its subtree are listed. Compact listing
+	// retrieves only the IDs and the names of the channels.
+	ListChannels(context.Context, string, uint64, uint64, string, string, bool) (ChannelsPage, error)
 
 	// ListChannelsByThing retrieves data about subset of channels that have
 	// specified thing connected to them and belong to the user identified by
@@ -82,26 +188,132 @@ type Service interface {
 	ListChannelsByThing(context.Context, string, string, uint64, uint64) (ChannelsPage, error)
 
 	// RemoveChannel removes the thing identified by the provided ID, that
+	// belongs to the user identified by the provided key. Protected channel
+	// is removed only if the removal is forced, otherwise ErrProtected is
+	// returned.
+	RemoveChannel(context.Context, string, string, bool) error
+
+	// IssueChannelToken issues a token that grants access with the given
+	// scope to the channel identified by the provided ID, that belongs to
+	// the user identified by the provided key. The token expires after the
+	// given duration, or once the channel tokens are revoked.
+	IssueChannelToken(context.Context, string, string, time.Duration, string) (string, error)
+
+	// RevokeChannelTokens revokes all the tokens issued so far for the
+	// channel identified by the provided ID, that belongs to the user
+	// identified by the provided key.
+	RevokeChannelTokens(context.Context, string, string) error
+
+	// CreateShareLink creates a link that grants read-only access to the
+	// channel identified by the provided ID, that belongs to the user
+	// identified by the provided key. The link expires after the given
+	// duration.
+	CreateShareLink(context.Context, string, string, time.Duration) (ShareLink, error)
+
+	// RevokeShareLink revokes the share link of the channel identified by
+	// the provided ID, that belongs to the user identified by the provided
+	// key.
+	RevokeShareLink(context.Context, string, string, string) error
+
+	// CanReadShared determines whether the channel can be read using the
+	// provided share link token and returns error if it cannot.
+	CanReadShared(context.Context, string, string) error
+
+	// IssueAPIKey issues the API key granting the given scopes on behalf of
+	// the user identified by the provided key. The API key expires after the
+	// given duration, unless the duration is zero. API keys are accepted in
+	// place of the user key for the granted operations, except for managing
+	// the API keys themselves.
+	IssueAPIKey(context.Context, string, []string, time.Duration) (APIKey, error)
+
+	// ListAPIKeys retrieves the API keys issued by the user identified by
+	// the provided key. Key values are omitted.
+	ListAPIKeys(context.Context, string) ([]APIKey, error)
+
+	// RevokeAPIKey revokes the API key identified by the provided ID, that
 	// belongs to the user identified by the provided key.
-	RemoveChannel(context.Context, string, string) error
+	RevokeAPIKey(context.Context, string, string) error
 
-	// Connect adds thing to the channel's list of connected things.
-	Connect(context.Context, string, string, string) error
+	// Connect adds thing to the channel's list of connected things. Unless
+	// the connections are shared, connecting the thing that is already
+	// connected to another channel either fails with ErrConflict or moves
+	// the thing, depending on the connection mode. The connection expires
+	// after the given duration, unless the duration is zero, after which
+	// the thing can no longer access the channel. Connecting the thing that
+	// is already connected replaces the expiry of the connection.
+	Connect(context.Context, string, string, string, time.Duration) error
 
 	// Disconnect removes thing from the channel's list of connected
 	// things.
 	Disconnect(context.Context, string, string, string) error
 
+	// ReapIdle disconnects the things that haven't been seen for the given
+	// duration from the channel identified by the provided ID, that belongs
+	// to the user identified by the provided key. It returns the IDs of the
+	// disconnected things.
+	ReapIdle(context.Context, string, string, time.Duration) ([]string, error)
+
 	// CanAccess determines whether the channel can be accessed using the
 	// provided key and returns thing's id if access is allowed.
 	CanAccess(context.Context, string, string) (string, error)
 
+	// CanReadMessages determines whether the message history of the channel
+	// can be read using the provided key or channel token. It returns thing's
+	// id if access is allowed, and whether the thing is restricted to its own
+	// messages. Access granted by the channel token isn't restricted, and no
+	// thing ID is returned.
+	CanReadMessages(context.Context, string, string) (string, bool, error)
+
 	// CanAccessByID determines whether the channnel can be accessed by
 	// the given thing and returns error if it cannot.
 	CanAccessByID(context.Context, string, string) error
 
 	// Identify returns thing ID for given thing key.
 	Identify(context.Context, string) (string, error)
+
+	// IdentifyFull returns the thing identified by the given thing key.
+	// The key itself is omitted from the returned thing.
+	IdentifyFull(context.Context, string) (Thing, error)
+
+	// IdentifyBatch returns thing IDs for the given thing keys, mapped by
+	// the key. Unknown and expired keys are omitted from the result rather
+	// than failing the whole batch.
+	IdentifyBatch(context.Context, []string) (map[string]string, error)
+
+	// RotateExpiredKeys assigns new keys to all things whose keys expired.
+	// It is meant to be run periodically as an administrative job. It
+	// returns the IDs of the things whose keys are rotated. It is safe to run
+	// on several replicas at once, since every expired key is rotated once.
+	RotateExpiredKeys(context.Context) ([]string, error)
+
+	// DisconnectExpired removes all the connections that expired, which are
+	// denied access to the channels already, and publishes their removal.
+	// It is meant to be run periodically as an administrative job. It
+	// returns the removed connections.
+	DisconnectExpired(context.Context) ([]Connection, error)
+
+	// DisableOwner disables the user identified by the provided email, so
+	// that the keys of the things that belong to the user are rejected with
+	// ErrOwnerDisabled, and the API keys of the user with
+	// ErrUnauthorizedAccess. The things themselves are kept. It is meant to be
+	// invoked by the administrative tooling, e.g. when the account is
+	// suspended.
+	DisableOwner(context.Context, string) error
+
+	// EnableOwner enables the user identified by the provided email, so that
+	// the keys of the things that belong to the user are accepted again.
+	EnableOwner(context.Context, string) error
+
+	// ChangeOwnerEmail replaces the email of the user, who keeps owning
+	// the things and channels owned under the previous email. It is meant
+	// to be invoked by the administrative tooling once the email of the
+	// account is changed.
+	ChangeOwnerEmail(ctx context.Context, email, newEmail string) error
+
+	// Subscribe streams the topology events of the things and channels
+	// that belong to the user identified by the provided key. The stream is
+	// closed once the context is done.
+	Subscribe(context.Context, string) (<-chan Event, error)
 }
 
 // PageMetadata contains page metadata that helps navigation.
@@ -115,127 +327,437 @@ type PageMetadata struct {
 var _ Service = (*thingsService)(nil)
 
 type thingsService struct {
-	users        mainflux.UsersServiceClient
-	things       ThingRepository
-	channels     ChannelRepository
-	channelCache ChannelCache
-	thingCache   ThingCache
-	idp          IdentityProvider
+	users          mainflux.UsersServiceClient
+	things         ThingRepository
+	channels       ChannelRepository
+	channelCache   ChannelCache
+	thingCache     ThingCache
+	idp            IdentityProvider
+	idPrefix       string
+	maxNameLength  int
+	reservedPrefix string
+	keyTTL         time.Duration
+	keyEncoding    KeyEncoding
+	connMode       ConnectionMode
+	minConns       uint64
+	catchAll       string
+	provisioning   ProvisionTemplate
+	tokenizer      ChannelTokenizer
+	links          ShareLinkRepository
+	apiKeys        APIKeyRepository
+	owners         OwnerRepository
+	auth           Authorizer
+	shareURL       string
+	seenMu         sync.Mutex
+	seen           map[string]time.Time
+	seenPruned     time.Time
+	events         *eventHub
+	logger         log.Logger
 }
 
 // New instantiates the things service implementation.
-func New(users mainflux.UsersServiceClient, things ThingRepository, channels ChannelRepository, ccache ChannelCache, tcache ThingCache, idp IdentityProvider) Service {
-	return &thingsService{
-		users:        users,
-		things:       things,
-		channels:     channels,
-		channelCache: ccache,
-		thingCache:   tcache,
-		idp:          idp,
+func New(users mainflux.UsersServiceClient, things ThingRepository, channels ChannelRepository, ccache ChannelCache, tcache ThingCache, idp IdentityProvider, opts ...Option) Service {
+	ts := &thingsService{
+		users:          users,
+		things:         things,
+		channels:       channels,
+		channelCache:   ccache,
+		thingCache:     tcache,
+		idp:            idp,
+		keyEncoding:    UUIDKeyEncoding,
+		connMode:       SharedConnections,
+		provisioning:   DefaultProvisionTemplate,
+		maxNameLength:  MaxNameLength,
+		reservedPrefix: ReservedMetadataPrefix,
+		auth:           NewOwnerAuthorizer(),
+		seen:           make(map[string]time.Time),
+		events:         newEventHub(),
+	}
+
+	for _, opt := range opts {
+		opt(ts)
 	}
+
+	return ts
 }
 
 func (ts *thingsService) AddThing(ctx context.Context, token string, thing Thing) (Thing, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, CreateAction, Resource{Type: ThingResource})
 	if err != nil {
-		return Thing{}, ErrUnauthorizedAccess
+		return Thing{}, err
+	}
+
+	thing.ID, err = ts.generateID()
+	if err != nil {
+		return Thing{}, err
+	}
+
+	return ts.addThing(ctx, owner, thing)
+}
+
+func (ts *thingsService) AddThings(ctx context.Context, token string, things []Thing, stopOnError bool) ([]BulkResult, error) {
+	owner, err := ts.authorize(ctx, token, CreateAction, Resource{Type: ThingResource})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []BulkResult{}
+	for _, thing := range things {
+		if thing.ID == "" {
+			if thing.ID, err = ts.generateID(); err != nil {
+				return results, err
+			}
+		}
+
+		sth, err := ts.addThing(ctx, owner, thing)
+		results = append(results, BulkResult{Thing: sth, Err: err})
+		if err != nil && stopOnError {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (ts *thingsService) ProvisionThing(ctx context.Context, token string, thing Thing) (Provision, error) {
+	owner, err := ts.authorize(ctx, token, CreateAction, Resource{Type: ThingResource})
+	if err != nil {
+		return Provision{}, err
+	}
+
+	if _, err := ts.authorize(ctx, token, CreateAction, Resource{Type: ChannelResource}); err != nil {
+		return Provision{}, err
+	}
+
+	thing.ID, err = ts.generateID()
+	if err != nil {
+		return Provision{}, err
+	}
+
+	sth, err := ts.addThing(ctx, owner, thing)
+	if err != nil {
+		return Provision{}, err
+	}
+
+	prov := Provision{Thing: sth, Channels: []ProvisionedChannel{}}
+	for _, ct := range ts.provisioning.Channels {
+		pc, err := ts.provisionChannel(ctx, owner, sth, ct)
+		if err != nil {
+			ts.unprovision(ctx, owner, prov)
+			return Provision{}, err
+		}
+		prov.Channels = append(prov.Channels, pc)
+	}
+
+	return prov, nil
+}
+
+// provisionChannel creates the channel of the provisioned thing out of the
+// template, and connects the thing to it if the template says so.
+func (ts *thingsService) provisionChannel(ctx context.Context, owner string, thing Thing, ct ChannelTemplate) (ProvisionedChannel, error) {
+	channel := ct.channel(thing)
+	if !ts.validName(channel.Name) {
+		return ProvisionedChannel{}, ErrMalformedEntity
+	}
+
+	var err error
+	channel.Metadata, err = ts.normalizeMetadata(channel.Metadata)
+	if err != nil {
+		return ProvisionedChannel{}, err
+	}
+
+	channel.ID, err = ts.generateID()
+	if err != nil {
+		return ProvisionedChannel{}, err
+	}
+
+	channel.Owner = owner
+	if channel.ID, err = ts.channels.Save(ctx, channel); err != nil {
+		return ProvisionedChannel{}, err
+	}
+	ts.events.publish(owner, Event{Operation: ChannelCreate, ID: channel.ID})
+
+	pc := ProvisionedChannel{Channel: channel}
+	if !ct.Connect {
+		return pc, nil
+	}
+
+	if err := ts.channels.Connect(ctx, owner, channel.ID, thing.ID, time.Time{}); err != nil {
+		// Channel isn't part of the provision yet, so it's removed here.
+		ts.unprovision(ctx, owner, Provision{Channels: []ProvisionedChannel{pc}})
+		return ProvisionedChannel{}, err
+	}
+	ts.events.publish(owner, Event{Operation: ThingConnect, ChanID: channel.ID, ThingID: thing.ID})
+
+	pc.Connected = true
+	return pc, nil
+}
+
+// unprovision removes the resources of the failed provisioning, the channels
+// first and the thing last. Removal is best effort, since the provisioning
+// has failed already.
+func (ts *thingsService) unprovision(ctx context.Context, owner string, prov Provision) {
+	for i := len(prov.Channels) - 1; i >= 0; i-- {
+		id := prov.Channels[i].Channel.ID
+		ts.channelCache.Remove(ctx, id)
+		if err := ts.channels.Remove(ctx, owner, id); err == nil {
+			ts.events.publish(owner, Event{Operation: ChannelRemove, ID: id})
+		}
+	}
+
+	if prov.Thing.ID == "" {
+		return
+	}
+
+	ts.thingCache.Remove(ctx, prov.Thing.ID)
+	if err := ts.things.Remove(ctx, owner, prov.Thing.ID); err == nil {
+		ts.events.publish(owner, Event{Operation: ThingRemove, ID: prov.Thing.ID})
+	}
+}
+
+// addThing adds the thing, with already assigned ID, to the owner.
+func (ts *thingsService) addThing(ctx context.Context, owner string, thing Thing) (Thing, error) {
+	if !ts.validName(thing.Name) {
+		return Thing{}, ErrMalformedEntity
 	}
 
-	thing.ID, err = ts.idp.ID()
+	var err error
+	thing.Metadata, err = ts.normalizeMetadata(thing.Metadata)
 	if err != nil {
 		return Thing{}, err
 	}
 
-	thing.Owner = res.GetValue()
+	thing.Owner = owner
 
 	if thing.Key == "" {
-		thing.Key, err = ts.idp.ID()
+		thing.Key, err = ts.generateKey()
 		if err != nil {
 			return Thing{}, err
 		}
 	}
 
+	thing.KeyExpiry = ts.keyExpiry()
+	thing.LastSeen = time.Now()
+	thing.CreatedAt = thing.LastSeen
+	thing.UpdatedAt = thing.LastSeen
+
 	id, err := ts.things.Save(ctx, thing)
 	if err != nil {
 		return Thing{}, err
 	}
 
 	thing.ID = id
+	ts.events.publish(thing.Owner, Event{Operation: ThingCreate, ID: id})
 	return thing, nil
 }
 
 func (ts *thingsService) UpdateThing(ctx context.Context, token string, thing Thing) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, UpdateAction, Resource{Type: ThingResource, ID: thing.ID})
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return err
+	}
+
+	if !ts.validName(thing.Name) {
+		return ErrMalformedEntity
+	}
+
+	thing.Metadata, err = ts.normalizeMetadata(thing.Metadata)
+	if err != nil {
+		return err
 	}
 
-	thing.Owner = res.GetValue()
+	thing.Owner = owner
+
+	if err := ts.things.Update(ctx, thing); err != nil {
+		return err
+	}
 
-	return ts.things.Update(ctx, thing)
+	return ts.evictDynamic(ctx, owner, thing.ID)
 }
 
-func (ts *thingsService) UpdateKey(ctx context.Context, token, id, key string) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+func (ts *thingsService) PatchThingMetadata(ctx context.Context, token, id string, patch map[string]interface{}) (map[string]interface{}, error) {
+	owner, err := ts.authorize(ctx, token, UpdateAction, Resource{Type: ThingResource, ID: id})
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return nil, err
+	}
+
+	if patch == nil || ts.hasReservedKey(patch) {
+		return nil, ErrMalformedEntity
+	}
+
+	// Patch is merged into the metadata read beforehand, so the last one of
+	// the concurrent patches of the same thing wins.
+	thing, err := ts.things.RetrieveByID(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	thing.Metadata = mergePatch(thing.Metadata, patch)
+	if err := ts.things.Update(ctx, thing); err != nil {
+		return nil, err
+	}
+
+	if err := ts.evictDynamic(ctx, owner, id); err != nil {
+		return nil, err
 	}
 
-	owner := res.GetValue()
+	return thing.Metadata, nil
+}
+
+// evictDynamic drops the cached connections of the thing to the dynamic
+// channels, since the metadata change may revoke the thing membership in
+// them.
+func (ts *thingsService) evictDynamic(ctx context.Context, owner, thingID string) error {
+	chs, err := ts.channels.RetrieveDynamic(ctx, owner)
+	if err != nil {
+		return err
+	}
+	for _, ch := range chs {
+		ts.channelCache.Disconnect(ctx, ch.ID, thingID)
+	}
+
+	return nil
+}
 
-	return ts.things.UpdateKey(ctx, owner, id, key)
+func (ts *thingsService) UpdateKey(ctx context.Context, token, id, key string) error {
+	owner, err := ts.authorize(ctx, token, UpdateAction, Resource{Type: ThingResource, ID: id})
+	if err != nil {
+		return err
+	}
+
+	if err := ts.things.UpdateKey(ctx, owner, id, key, ts.keyExpiry()); err != nil {
+		return err
+	}
 
+	ts.thingCache.Remove(ctx, id)
+	return nil
 }
 
 func (ts *thingsService) ViewThing(ctx context.Context, token, id string) (Thing, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, ReadAction, Resource{Type: ThingResource, ID: id})
 	if err != nil {
-		return Thing{}, ErrUnauthorizedAccess
+		return Thing{}, err
+	}
+
+	thing, err := ts.things.RetrieveByID(ctx, owner, id)
+	if err != nil {
+		return Thing{}, err
+	}
+
+	thing.Connections, err = ts.channels.CountChannels(ctx, owner, id)
+	if err != nil {
+		return Thing{}, err
 	}
 
-	return ts.things.RetrieveByID(ctx, res.GetValue(), id)
+	return thing, nil
 }
 
-func (ts *thingsService) ListThings(ctx context.Context, token string, offset, limit uint64, name string) (ThingsPage, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+func (ts *thingsService) ListThings(ctx context.Context, token string, offset, limit uint64, name string, compact bool) (ThingsPage, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
+	if err != nil {
+		return ThingsPage{}, err
+	}
+
+	return ts.things.RetrieveAll(ctx, owner, offset, limit, name, compact)
+}
+
+func (ts *thingsService) NameAvailable(ctx context.Context, token, name string) (bool, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
+	if err != nil {
+		return false, err
+	}
+
+	if name == "" || !ts.validName(name) {
+		return false, ErrMalformedEntity
+	}
+
+	exists, err := ts.things.NameExists(ctx, owner, name)
 	if err != nil {
-		return ThingsPage{}, ErrUnauthorizedAccess
+		return false, err
 	}
 
-	return ts.things.RetrieveAll(ctx, res.GetValue(), offset, limit, name)
+	return !exists, nil
 }
 
 func (ts *thingsService) ListThingsByChannel(ctx context.Context, token, channel string, offset, limit uint64) (ThingsPage, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
+	if err != nil {
+		return ThingsPage{}, err
+	}
+
+	return ts.things.RetrieveByChannel(ctx, owner, channel, offset, limit)
+}
+
+func (ts *thingsService) ListThingsByChannelAfter(ctx context.Context, token, channel, after string, limit uint64) ([]Thing, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.things.RetrieveByChannelAfter(ctx, owner, channel, after, limit)
+}
+
+func (ts *thingsService) ListThingsByChannels(ctx context.Context, token string, channels []string, offset, limit uint64) (ThingsPage, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
 	if err != nil {
-		return ThingsPage{}, ErrUnauthorizedAccess
+		return ThingsPage{}, err
+	}
+
+	if len(channels) == 0 {
+		return ThingsPage{}, ErrMalformedEntity
 	}
 
-	return ts.things.RetrieveByChannel(ctx, res.GetValue(), channel, offset, limit)
+	return ts.things.RetrieveByChannels(ctx, owner, channels, offset, limit)
 }
 
 func (ts *thingsService) RemoveThing(ctx context.Context, token, id string) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, DeleteAction, Resource{Type: ThingResource, ID: id})
+	if err == ErrNotFound {
+		// Removing the non-existent thing is a no-op.
+		return nil
+	}
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return err
 	}
 
 	ts.thingCache.Remove(ctx, id)
-	return ts.things.Remove(ctx, res.GetValue(), id)
+	if err := ts.things.Remove(ctx, owner, id); err != nil {
+		return err
+	}
+
+	ts.events.publish(owner, Event{Operation: ThingRemove, ID: id})
+	return nil
 }
 
 func (ts *thingsService) CreateChannel(ctx context.Context, token string, channel Channel) (Channel, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, CreateAction, Resource{Type: ChannelResource})
+	if err != nil {
+		return Channel{}, err
+	}
+
+	if !ts.validName(channel.Name) {
+		return Channel{}, ErrMalformedEntity
+	}
+
+	channel.Metadata, err = ts.normalizeMetadata(channel.Metadata)
 	if err != nil {
-		return Channel{}, ErrUnauthorizedAccess
+		return Channel{}, err
+	}
+
+	if err := channel.Membership.Validate(); err != nil {
+		return Channel{}, err
 	}
 
-	channel.ID, err = ts.idp.ID()
+	channel.ID, err = ts.generateID()
 	if err != nil {
 		return Channel{}, err
 	}
 
-	channel.Owner = res.GetValue()
+	channel.Owner = owner
+
+	if err := ts.validateParent(ctx, channel); err != nil {
+		return Channel{}, err
+	}
 
 	id, err := ts.channels.Save(ctx, channel)
 	if err != nil {
@@ -243,123 +765,1024 @@ func (ts *thingsService) CreateChannel(ctx context.Context, token string, channe
 	}
 
 	channel.ID = id
+	ts.events.publish(channel.Owner, Event{Operation: ChannelCreate, ID: id})
 	return channel, nil
 }
 
 func (ts *thingsService) UpdateChannel(ctx context.Context, token string, channel Channel) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	owner, err := ts.authorize(ctx, token, UpdateAction, Resource{Type: ChannelResource, ID: channel.ID})
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return err
 	}
 
-	channel.Owner = res.GetValue()
-	return ts.channels.Update(ctx, channel)
-}
+	if !ts.validName(channel.Name) {
+		return ErrMalformedEntity
+	}
 
-func (ts *thingsService) ViewChannel(ctx context.Context, token, id string) (Channel, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	channel.Metadata, err = ts.normalizeMetadata(channel.Metadata)
 	if err != nil {
-		return Channel{}, ErrUnauthorizedAccess
+		return err
 	}
 
-	return ts.channels.RetrieveByID(ctx, res.GetValue(), id)
-}
+	if err := channel.Membership.Validate(); err != nil {
+		return err
+	}
 
-func (ts *thingsService) ListChannels(ctx context.Context, token string, offset, limit uint64, name string) (ChannelsPage, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	channel.Owner = owner
+	current, err := ts.channels.RetrieveByID(ctx, channel.Owner, channel.ID)
 	if err != nil {
-		return ChannelsPage{}, ErrUnauthorizedAccess
+		return err
 	}
 
-	return ts.channels.RetrieveAll(ctx, res.GetValue(), offset, limit, name)
-}
+	switch channel.ParentID {
+	case "":
+		channel.ParentID = current.ParentID
+	case NoParent:
+		channel.ParentID = ""
+	}
 
-func (ts *thingsService) ListChannelsByThing(ctx context.Context, token, thing string, offset, limit uint64) (ChannelsPage, error) {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
-	if err != nil {
-		return ChannelsPage{}, ErrUnauthorizedAccess
+	if err := ts.validateParent(ctx, channel); err != nil {
+		return err
 	}
 
-	return ts.channels.RetrieveByThing(ctx, res.GetValue(), thing, offset, limit)
-}
+	// Explicit connections of the channel would be ignored once it becomes
+	// dynamic, so they have to be removed first.
+	if len(channel.Membership) > 0 {
+		count, err := ts.channels.CountThings(ctx, channel.Owner, channel.ID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDynamicChannel
+		}
+	}
 
-func (ts *thingsService) RemoveChannel(ctx context.Context, token, id string) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
-	if err != nil {
-		return ErrUnauthorizedAccess
+	if err := ts.channels.Update(ctx, channel); err != nil {
+		return err
 	}
 
-	ts.channelCache.Remove(ctx, id)
-	return ts.channels.Remove(ctx, res.GetValue(), id)
+	if len(current.Membership) > 0 || len(channel.Membership) > 0 {
+		ts.channelCache.Remove(ctx, channel.ID)
+	}
+
+	return nil
 }
 
-func (ts *thingsService) Connect(ctx context.Context, token, chanID, thingID string) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+func (ts *thingsService) ViewChannel(ctx context.Context, token, id string) (Channel, error) {
+	owner, err := ts.authorize(ctx, token, ReadAction, Resource{Type: ChannelResource, ID: id})
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return Channel{}, err
 	}
 
-	return ts.channels.Connect(ctx, res.GetValue(), chanID, thingID)
-}
+	channel, err := ts.channels.RetrieveByID(ctx, owner, id)
+	if err != nil {
+		return Channel{}, err
+	}
 
-func (ts *thingsService) Disconnect(ctx context.Context, token, chanID, thingID string) error {
-	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	channel.Connections, err = ts.channels.CountThings(ctx, owner, id)
 	if err != nil {
-		return ErrUnauthorizedAccess
+		return Channel{}, err
 	}
 
-	ts.channelCache.Disconnect(ctx, chanID, thingID)
-	return ts.channels.Disconnect(ctx, res.GetValue(), chanID, thingID)
+	return channel, nil
 }
 
-func (ts *thingsService) CanAccess(ctx context.Context, chanID, key string) (string, error) {
-	thingID, err := ts.hasThing(ctx, chanID, key)
-	if err == nil {
-		return thingID, nil
+func (ts *thingsService) ListChannels(ctx context.Context, token string, offset, limit uint64, name, parent string, compact bool) (ChannelsPage, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ChannelResource})
+	if err != nil {
+		return ChannelsPage{}, err
 	}
 
-	thingID, err = ts.channels.HasThing(ctx, chanID, key)
-	if err != nil {
-		return "", ErrUnauthorizedAccess
+	if parent != "" {
+		if _, err := ts.channels.RetrieveByID(ctx, owner, parent); err != nil {
+			return ChannelsPage{}, err
+		}
 	}
 
-	ts.thingCache.Save(ctx, key, thingID)
-	ts.channelCache.Connect(ctx, chanID, thingID)
-	return thingID, nil
+	return ts.channels.RetrieveAll(ctx, owner, offset, limit, name, parent, compact)
 }
 
-func (ts *thingsService) CanAccessByID(ctx context.Context, chanID, thingID string) error {
-	if connected := ts.channelCache.HasThing(ctx, chanID, thingID); connected {
-		return nil
-	}
+// validName checks that the name doesn't exceed the configured length limit,
+// measured in runes.
+func (ts *thingsService) validName(name string) bool {
+	return utf8.RuneCountInString(name) <= ts.maxNameLength
+}
 
-	if err := ts.channels.HasThingByID(ctx, chanID, thingID); err != nil {
-		return ErrUnauthorizedAccess
+// validateParent checks that the channel parent exists and that the channel
+// is not its own ancestor, which would introduce a cycle in the hierarchy.
+// The walk is limited to maxChannelDepth ancestors and stops at the ancestor
+// seen before, so that the cycle already stored by concurrent updates can't
+// make it loop forever.
+func (ts *thingsService) validateParent(ctx context.Context, channel Channel) error {
+	visited := map[string]bool{channel.ID: true}
+	for id, depth := channel.ParentID, 0; id != ""; depth++ {
+		if visited[id] || depth >= maxChannelDepth {
+			return ErrMalformedEntity
+		}
+		visited[id] = true
+
+		parent, err := ts.channels.RetrieveByID(ctx, channel.Owner, id)
+		if err != nil {
+			return err
+		}
+
+		id = parent.ParentID
 	}
 
-	ts.channelCache.Connect(ctx, chanID, thingID)
 	return nil
 }
 
-func (ts *thingsService) Identify(ctx context.Context, key string) (string, error) {
-	id, err := ts.thingCache.ID(ctx, key)
-	if err == nil {
-		return id, nil
-	}
-
-	id, err = ts.things.RetrieveByKey(ctx, key)
+func (ts *thingsService) ListChannelsByThing(ctx context.Context, token, thing string, offset, limit uint64) (ChannelsPage, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ChannelResource})
 	if err != nil {
-		return "", ErrUnauthorizedAccess
+		return ChannelsPage{}, err
 	}
 
-	ts.thingCache.Save(ctx, key, id)
-	return id, nil
+	return ts.channels.RetrieveByThing(ctx, owner, thing, offset, limit)
 }
 
-func (ts *thingsService) hasThing(ctx context.Context, chanID, key string) (string, error) {
-	thingID, err := ts.thingCache.ID(ctx, key)
+func (ts *thingsService) RemoveChannel(ctx context.Context, token, id string, force bool) error {
+	owner, err := ts.authorize(ctx, token, DeleteAction, Resource{Type: ChannelResource, ID: id})
+	if err == ErrNotFound {
+		// Removing the non-existent channel is a no-op.
+		return nil
+	}
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	if !force {
+		channel, err := ts.channels.RetrieveByID(ctx, owner, id)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		if channel.Protected {
+			return ErrProtected
+		}
+	}
+
+	ts.channelCache.Remove(ctx, id)
+	if err := ts.channels.Remove(ctx, owner, id); err != nil {
+		return err
+	}
+
+	ts.events.publish(owner, Event{Operation: ChannelRemove, ID: id})
+	return nil
+}
+
+func (ts *thingsService) IssueChannelToken(ctx context.Context, token, chanID string, ttl time.Duration, scope string) (string, error) {
+	if ts.tokenizer == nil {
+		return "", ErrChannelTokensDisabled
+	}
+
+	owner, err := ts.authorize(ctx, token, ShareAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return "", err
+	}
+
+	if ttl <= 0 || scope != ReadScope {
+		return "", ErrMalformedEntity
+	}
+
+	if _, err := ts.channels.RetrieveByID(ctx, owner, chanID); err != nil {
+		return "", err
+	}
+
+	version, err := ts.channels.RetrieveTokenVersion(ctx, chanID)
+	if err != nil {
+		return "", err
+	}
+
+	ct := ChannelToken{
+		ChanID:    chanID,
+		Scope:     scope,
+		Version:   version,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	signed, err := ts.tokenizer.Issue(ct)
+	if err != nil {
+		return "", err
+	}
+
+	return ChannelTokenPrefix + signed, nil
+}
+
+func (ts *thingsService) RevokeChannelTokens(ctx context.Context, token, chanID string) error {
+	if ts.tokenizer == nil {
+		return ErrChannelTokensDisabled
+	}
+
+	owner, err := ts.authorize(ctx, token, ShareAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return err
+	}
+
+	return ts.channels.RevokeTokens(ctx, owner, chanID)
+}
+
+// canReadByToken checks whether the channel token grants reading the channel
+// and wasn't revoked since it was issued.
+func (ts *thingsService) canReadByToken(ctx context.Context, chanID, token string) error {
+	ct, err := ts.tokenizer.Parse(strings.TrimPrefix(token, ChannelTokenPrefix))
+	if err != nil || ct.ChanID != chanID || ct.Scope != ReadScope {
+		return ErrUnauthorizedAccess
+	}
+
+	version, err := ts.channels.RetrieveTokenVersion(ctx, chanID)
+	if err == ErrNotFound || (err == nil && version != ct.Version) {
+		return ErrUnauthorizedAccess
+	}
+
+	return err
+}
+
+func (ts *thingsService) CreateShareLink(ctx context.Context, token, chanID string, ttl time.Duration) (ShareLink, error) {
+	if ts.links == nil {
+		return ShareLink{}, ErrShareLinksDisabled
+	}
+
+	owner, err := ts.authorize(ctx, token, ShareAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return ShareLink{}, err
+	}
+
+	if ttl <= 0 {
+		return ShareLink{}, ErrMalformedEntity
+	}
+
+	if _, err := ts.channels.RetrieveByID(ctx, owner, chanID); err != nil {
+		return ShareLink{}, err
+	}
+
+	linkToken, err := ts.idp.ID()
+	if err != nil {
+		return ShareLink{}, err
+	}
+
+	link := ShareLink{
+		Token:     hashToken(linkToken),
+		ChanID:    chanID,
+		Owner:     owner,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := ts.links.Save(ctx, link); err != nil {
+		return ShareLink{}, err
+	}
+
+	link.Token = linkToken
+	link.URL = shareURL(ts.shareURL, chanID, linkToken)
+	return link, nil
+}
+
+func (ts *thingsService) RevokeShareLink(ctx context.Context, token, chanID, linkToken string) error {
+	if ts.links == nil {
+		return ErrShareLinksDisabled
+	}
+
+	owner, err := ts.authorize(ctx, token, ShareAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return err
+	}
+
+	return ts.links.Remove(ctx, owner, chanID, hashToken(linkToken))
+}
+
+func (ts *thingsService) CanReadShared(ctx context.Context, linkToken, chanID string) error {
+	if ts.links == nil {
+		return ErrUnauthorizedAccess
+	}
+
+	link, err := ts.links.RetrieveByToken(ctx, hashToken(linkToken))
+	if err != nil {
+		return ErrUnauthorizedAccess
+	}
+
+	if link.ChanID != chanID || link.Expired(time.Now()) {
+		return ErrUnauthorizedAccess
+	}
+
+	return nil
+}
+
+func (ts *thingsService) IssueAPIKey(ctx context.Context, token string, scopes []string, ttl time.Duration) (APIKey, error) {
+	owner, err := ts.identifyUser(ctx, token)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	if ts.apiKeys == nil {
+		return APIKey{}, ErrAPIKeysDisabled
+	}
+
+	if ttl < 0 || !ValidScopes(scopes) {
+		return APIKey{}, ErrMalformedEntity
+	}
+
+	id, err := ts.idp.ID()
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	value, err := ts.idp.ID()
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	key := APIKey{
+		ID:       id,
+		Owner:    owner,
+		Scopes:   scopes,
+		IssuedAt: time.Now(),
+	}
+	if ttl > 0 {
+		key.ExpiresAt = key.IssuedAt.Add(ttl)
+	}
+
+	value = APIKeyPrefix + value
+	key.Key = hashToken(value)
+	if err := ts.apiKeys.Save(ctx, key); err != nil {
+		return APIKey{}, err
+	}
+
+	key.Key = value
+	return key, nil
+}
+
+func (ts *thingsService) ListAPIKeys(ctx context.Context, token string) ([]APIKey, error) {
+	owner, err := ts.identifyUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if ts.apiKeys == nil {
+		return nil, ErrAPIKeysDisabled
+	}
+
+	keys, err := ts.apiKeys.RetrieveAll(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		keys[i].Key = ""
+	}
+
+	return keys, nil
+}
+
+func (ts *thingsService) RevokeAPIKey(ctx context.Context, token, id string) error {
+	owner, err := ts.identifyUser(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if ts.apiKeys == nil {
+		return ErrAPIKeysDisabled
+	}
+
+	return ts.apiKeys.Remove(ctx, owner, id)
+}
+
+func (ts *thingsService) Connect(ctx context.Context, token, chanID, thingID string, ttl time.Duration) error {
+	owner, err := ts.authorizeConnection(ctx, token, chanID, thingID)
+	if err != nil {
+		// The cache may still hold the connection of the removed thing.
+		if err == ErrNotFound {
+			ts.channelCache.Disconnect(ctx, chanID, thingID)
+		}
+		return err
+	}
+
+	if ttl < 0 {
+		return ErrMalformedEntity
+	}
+
+	channel, err := ts.channels.RetrieveByID(ctx, owner, chanID)
+	if err != nil {
+		return err
+	}
+
+	if len(channel.Membership) > 0 {
+		return ErrDynamicChannel
+	}
+
+	previous := []string{}
+	if ts.connMode != SharedConnections {
+		previous, err = ts.connectedChannels(ctx, owner, chanID, thingID)
+		if err != nil {
+			return err
+		}
+
+		if len(previous) > 0 && ts.connMode == ExclusiveConnections {
+			return ErrConflict
+		}
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+		// Expiring connections aren't cached, so the thing that is
+		// connected already is evicted.
+		ts.channelCache.Disconnect(ctx, chanID, thingID)
+	}
+
+	// The repository is the authority on the existence of the channel and
+	// the thing, while the cache may still hold the connection of the
+	// removed one, which is evicted.
+	if err := ts.channels.Connect(ctx, owner, chanID, thingID, expiresAt); err != nil {
+		if err == ErrNotFound {
+			ts.channelCache.Disconnect(ctx, chanID, thingID)
+		}
+		return err
+	}
+
+	ts.events.publish(owner, Event{Operation: ThingConnect, ChanID: chanID, ThingID: thingID})
+
+	for _, id := range previous {
+		ts.channelCache.Disconnect(ctx, id, thingID)
+		if err := ts.channels.Disconnect(ctx, owner, id, thingID); err != nil {
+			return err
+		}
+
+		ts.events.publish(owner, Event{Operation: ThingDisconnect, ChanID: id, ThingID: thingID})
+	}
+
+	return nil
+}
+
+// connectedChannels returns IDs of the static channels, other than the given
+// one, the thing is connected to.
+func (ts *thingsService) connectedChannels(ctx context.Context, owner, chanID, thingID string) ([]string, error) {
+	ids := []string{}
+	for offset := uint64(0); ; offset += connectedPageSize {
+		page, err := ts.channels.RetrieveByThing(ctx, owner, thingID, offset, connectedPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ch := range page.Channels {
+			if ch.ID != chanID && len(ch.Membership) == 0 {
+				ids = append(ids, ch.ID)
+			}
+		}
+
+		if offset+connectedPageSize >= page.Total {
+			return ids, nil
+		}
+	}
+}
+
+func (ts *thingsService) Disconnect(ctx context.Context, token, chanID, thingID string) error {
+	owner, err := ts.authorizeConnection(ctx, token, chanID, thingID)
+	if err != nil {
+		return err
+	}
+
+	ts.channelCache.Disconnect(ctx, chanID, thingID)
+	if err := ts.channels.Disconnect(ctx, owner, chanID, thingID); err != nil {
+		return err
+	}
+
+	ts.events.publish(owner, Event{Operation: ThingDisconnect, ChanID: chanID, ThingID: thingID})
+	return nil
+}
+
+func (ts *thingsService) ReapIdle(ctx context.Context, token, chanID string, olderThan time.Duration) ([]string, error) {
+	owner, err := ts.authorize(ctx, token, ConnectAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return nil, err
+	}
+
+	if olderThan <= 0 {
+		return nil, ErrMalformedEntity
+	}
+
+	if _, err := ts.channels.RetrieveByID(ctx, owner, chanID); err != nil {
+		return nil, err
+	}
+
+	idle, err := ts.things.RetrieveIdle(ctx, owner, chanID, time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, thing := range idle {
+		ts.channelCache.Disconnect(ctx, chanID, thing.ID)
+		if err := ts.channels.Disconnect(ctx, owner, chanID, thing.ID); err != nil {
+			return ids, err
+		}
+		ts.events.publish(owner, Event{Operation: ThingDisconnect, ChanID: chanID, ThingID: thing.ID})
+		ids = append(ids, thing.ID)
+	}
+
+	return ids, nil
+}
+
+func (ts *thingsService) Subscribe(ctx context.Context, token string) (<-chan Event, error) {
+	owner, err := ts.authorize(ctx, token, ListAction, Resource{Type: ThingResource})
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.events.subscribe(ctx, owner), nil
+}
+
+func (ts *thingsService) CanAccess(ctx context.Context, chanID, key string) (string, error) {
+	// Catch-all channel is accessed by the things that aren't connected to
+	// it, so the thing is identified only, and isn't required to be
+	// activated.
+	if ts.catchAll != "" && chanID == ts.catchAll {
+		thingID, err := ts.Identify(ctx, key)
+		if err != nil {
+			return "", err
+		}
+
+		ts.markSeen(ctx, thingID)
+		return thingID, nil
+	}
+
+	return ts.canAccessConnected(ctx, chanID, key)
+}
+
+// canAccessConnected returns the ID of the thing if it is connected to the
+// channel and activated.
+func (ts *thingsService) canAccessConnected(ctx context.Context, chanID, key string) (string, error) {
+	// Cached connections don't reflect the number of connections of the
+	// thing, so the activation is always checked against the repository.
+	if ts.minConns <= 1 {
+		thingID, err := ts.hasThing(ctx, chanID, key)
+		if err == nil {
+			ts.markSeen(ctx, thingID)
+			return thingID, nil
+		}
+	}
+
+	thing, err := ts.retrieveByKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt, err := ts.channels.HasThingByID(ctx, chanID, thing.ID)
+	if err != nil {
+		return "", ErrUnauthorizedAccess
+	}
+
+	if err := ts.checkActivated(ctx, thing); err != nil {
+		return "", err
+	}
+
+	if err := ts.cacheThing(ctx, thing); err != nil {
+		return "", err
+	}
+	ts.cacheConnection(ctx, chanID, thing.ID, expiresAt)
+	ts.markSeen(ctx, thing.ID)
+	return thing.ID, nil
+}
+
+func (ts *thingsService) CanReadMessages(ctx context.Context, chanID, key string) (string, bool, error) {
+	if ts.tokenizer != nil && strings.HasPrefix(key, ChannelTokenPrefix) {
+		return "", false, ts.canReadByToken(ctx, chanID, key)
+	}
+
+	// Publishing to the catch-all channel doesn't grant reading the messages
+	// of the others, so the reader must be connected even to the catch-all
+	// channel.
+	thingID, err := ts.canAccessConnected(ctx, chanID, key)
+	if err != nil {
+		return "", false, err
+	}
+
+	owner, err := ts.channels.RetrieveOwner(ctx, chanID)
+	if err != nil {
+		return "", false, err
+	}
+
+	channel, err := ts.channels.RetrieveByID(ctx, owner, chanID)
+	if err != nil {
+		return "", false, err
+	}
+
+	return thingID, channel.PublisherScoped, nil
+}
+
+func (ts *thingsService) CanAccessByID(ctx context.Context, chanID, thingID string) error {
+	if connected := ts.channelCache.HasThing(ctx, chanID, thingID); connected {
+		return nil
+	}
+
+	expiresAt, err := ts.channels.HasThingByID(ctx, chanID, thingID)
+	if err != nil {
+		return ErrUnauthorizedAccess
+	}
+
+	ts.cacheConnection(ctx, chanID, thingID, expiresAt)
+	return nil
+}
+
+// checkActivated rejects the thing that is connected to fewer channels than
+// required for the activation.
+func (ts *thingsService) checkActivated(ctx context.Context, thing Thing) error {
+	if ts.minConns <= 1 {
+		return nil
+	}
+
+	count, err := ts.channels.CountChannels(ctx, thing.Owner, thing.ID)
+	if err != nil {
+		return err
+	}
+
+	if count < ts.minConns {
+		return ErrNotActivated
+	}
+
+	return nil
+}
+
+// cacheConnection caches the permanent connection only, since the cached
+// connection is accessible until it is disconnected explicitly.
+func (ts *thingsService) cacheConnection(ctx context.Context, chanID, thingID string, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		ts.channelCache.Connect(ctx, chanID, thingID)
+	}
+}
+
+func (ts *thingsService) Identify(ctx context.Context, key string) (string, error) {
+	id, err := ts.thingCache.ID(ctx, key)
+	if err == nil {
+		return id, nil
+	}
+
+	thing, err := ts.retrieveByKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ts.cacheThing(ctx, thing); err != nil {
+		return "", err
+	}
+
+	return thing.ID, nil
+}
+
+func (ts *thingsService) IdentifyFull(ctx context.Context, key string) (Thing, error) {
+	thing, err := ts.retrieveByKey(ctx, key)
+	if err != nil {
+		return Thing{}, err
+	}
+
+	if err := ts.cacheThing(ctx, thing); err != nil {
+		return Thing{}, err
+	}
+
+	thing.Key = ""
+	return thing, nil
+}
+
+func (ts *thingsService) IdentifyBatch(ctx context.Context, keys []string) (map[string]string, error) {
+	ids := make(map[string]string)
+	missing := []string{}
+	for _, key := range keys {
+		if _, ok := ids[key]; ok || key == "" {
+			continue
+		}
+
+		id, err := ts.thingCache.ID(ctx, key)
+		if err != nil {
+			missing = append(missing, key)
+			continue
+		}
+		ids[key] = id
+	}
+
+	if len(missing) == 0 {
+		return ids, nil
+	}
+
+	ths, err := ts.things.RetrieveByKeys(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	disabled := map[string]bool{}
+	for _, thing := range ths {
+		if !thing.KeyExpiry.IsZero() && !now.Before(thing.KeyExpiry) {
+			continue
+		}
+
+		d, ok := disabled[thing.Owner]
+		if !ok {
+			if d, err = ts.ownerDisabled(ctx, thing.Owner); err != nil {
+				return nil, err
+			}
+			disabled[thing.Owner] = d
+		}
+
+		if d {
+			continue
+		}
+
+		if err := ts.cacheThing(ctx, thing); err != nil {
+			continue
+		}
+
+		ids[thing.Key] = thing.ID
+	}
+
+	return ids, nil
+}
+
+func (ts *thingsService) RotateExpiredKeys(ctx context.Context) ([]string, error) {
+	now := time.Now()
+
+	ids := []string{}
+	for {
+		expired, err := ts.things.RetrieveExpired(ctx, now, rotationBatch)
+		if err != nil {
+			return ids, err
+		}
+
+		for _, thing := range expired {
+			key, err := ts.generateKey()
+			if err != nil {
+				return ids, err
+			}
+
+			// The key is replaced only if it is still the expired one, so
+			// that the replicas rotating concurrently rotate it once.
+			err = ts.things.RotateKey(ctx, thing, key, ts.keyExpiry())
+			if err == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return ids, err
+			}
+
+			ts.thingCache.Remove(ctx, thing.ID)
+			ids = append(ids, thing.ID)
+		}
+
+		if uint64(len(expired)) < rotationBatch {
+			return ids, nil
+		}
+	}
+}
+
+func (ts *thingsService) DisconnectExpired(ctx context.Context) ([]Connection, error) {
+	conns, err := ts.channels.RemoveExpired(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conn := range conns {
+		ts.channelCache.Disconnect(ctx, conn.ChanID, conn.ThingID)
+		ts.events.publish(conn.Owner, Event{Operation: ThingDisconnect, ChanID: conn.ChanID, ThingID: conn.ThingID})
+	}
+
+	return conns, nil
+}
+
+func (ts *thingsService) DisableOwner(ctx context.Context, email string) error {
+	if ts.owners == nil {
+		return ErrOwnerManagementDisabled
+	}
+
+	if email == "" {
+		return ErrMalformedEntity
+	}
+
+	owner, err := ts.resolveOwner(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.owners.Disable(ctx, owner); err != nil {
+		return err
+	}
+
+	// Cached keys aren't checked against the owner, so they are evicted for
+	// the disabling to take effect immediately.
+	return ts.uncacheOwned(ctx, owner)
+}
+
+func (ts *thingsService) EnableOwner(ctx context.Context, email string) error {
+	if ts.owners == nil {
+		return ErrOwnerManagementDisabled
+	}
+
+	if email == "" {
+		return ErrMalformedEntity
+	}
+
+	owner, err := ts.resolveOwner(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	return ts.owners.Enable(ctx, owner)
+}
+
+func (ts *thingsService) ChangeOwnerEmail(ctx context.Context, email, newEmail string) error {
+	if ts.owners == nil {
+		return ErrOwnerManagementDisabled
+	}
+
+	if email == "" || newEmail == "" {
+		return ErrMalformedEntity
+	}
+
+	return ts.owners.ChangeEmail(ctx, email, newEmail)
+}
+
+// authorize identifies the owner on whose behalf the operation is performed
+// and checks whether the owner is allowed to perform the action on the
+// resource. It returns the owner of the resource if the resource is a single
+// entity, and the identified owner otherwise.
+func (ts *thingsService) authorize(ctx context.Context, token, action string, res Resource) (string, error) {
+	subject, err := ts.identifySubject(ctx, token, action, res.Type)
+	if err != nil {
+		return "", err
+	}
+
+	return ts.authorizeResource(ctx, subject, action, res)
+}
+
+// authorizeConnection checks whether the owner identified by the token is
+// allowed to connect the thing to the channel, or to disconnect it, and
+// returns the owner of both. Only the entities of the same owner are
+// connected.
+func (ts *thingsService) authorizeConnection(ctx context.Context, token, chanID, thingID string) (string, error) {
+	subject, err := ts.identifySubject(ctx, token, ConnectAction, ChannelResource, ThingResource)
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := ts.authorizeResource(ctx, subject, ConnectAction, Resource{Type: ChannelResource, ID: chanID})
+	if err != nil {
+		return "", err
+	}
+
+	thingOwner, err := ts.authorizeResource(ctx, subject, ConnectAction, Resource{Type: ThingResource, ID: thingID})
+	if err != nil {
+		return "", err
+	}
+
+	if thingOwner != owner {
+		return "", ErrNotFound
+	}
+
+	return owner, nil
+}
+
+// identifySubject identifies the owner on whose behalf the action is
+// performed on the resources of the given types, using either the user token
+// or the API key. API keys are restricted to the operations granted by their
+// scopes, and are rejected once their owner is disabled.
+func (ts *thingsService) identifySubject(ctx context.Context, token, action string, types ...string) (string, error) {
+	if ts.apiKeys == nil || !strings.HasPrefix(token, APIKeyPrefix) {
+		return ts.identifyUser(ctx, token)
+	}
+
+	key, err := ts.apiKeys.RetrieveByKey(ctx, hashToken(token))
+	if err != nil || key.Expired(time.Now()) {
+		return "", ErrUnauthorizedAccess
+	}
+
+	disabled, err := ts.ownerDisabled(ctx, key.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	if disabled {
+		return "", ErrUnauthorizedAccess
+	}
+
+	for _, t := range types {
+		if !key.Grants(action, t) {
+			return "", ErrUnauthorizedAccess
+		}
+	}
+
+	return key.Owner, nil
+}
+
+// authorizeResource resolves the owner of the resource, if it's a single
+// entity, and consults the authorizer. It returns the owner of the resource,
+// or the subject if the resource isn't a single entity.
+func (ts *thingsService) authorizeResource(ctx context.Context, subject, action string, res Resource) (string, error) {
+	if res.ID != "" {
+		owner, err := ts.resourceOwner(ctx, res)
+		if err != nil {
+			return "", err
+		}
+		res.Owner = owner
+	}
+
+	if err := ts.auth.Authorize(ctx, subject, action, res); err != nil {
+		return "", err
+	}
+
+	if res.Owner != "" {
+		return res.Owner, nil
+	}
+
+	return subject, nil
+}
+
+// resourceOwner returns the ID of the owner of the thing or the channel.
+func (ts *thingsService) resourceOwner(ctx context.Context, res Resource) (string, error) {
+	switch res.Type {
+	case ThingResource:
+		return ts.things.RetrieveOwner(ctx, res.ID)
+	case ChannelResource:
+		return ts.channels.RetrieveOwner(ctx, res.ID)
+	default:
+		return "", ErrNotFound
+	}
+}
+
+// identifyUser identifies the user by the user token only, rejecting the
+// API keys. It returns the stable ID of the user.
+func (ts *thingsService) identifyUser(ctx context.Context, token string) (string, error) {
+	if email, ok := identityFrom(ctx, token); ok {
+		return ts.resolveOwner(ctx, email)
+	}
+
+	res, err := ts.users.Identify(ctx, &mainflux.Token{Value: token})
+	if err != nil {
+		return "", ErrUnauthorizedAccess
+	}
+
+	return ts.resolveOwner(ctx, res.GetValue())
+}
+
+// resolveOwner returns the stable ID of the owner having the email, which
+// is assigned once the owner is seen for the first time. Unless the owners
+// are managed, the email is used as the ID. Known owners are looked up only,
+// so that the request of the known owner doesn't write to the database.
+func (ts *thingsService) resolveOwner(ctx context.Context, email string) (string, error) {
+	if ts.owners == nil {
+		return email, nil
+	}
+
+	owner, err := ts.owners.RetrieveByEmail(ctx, email)
+	if err != ErrNotFound {
+		return owner, err
+	}
+
+	id, err := ts.idp.ID()
+	if err != nil {
+		return "", err
+	}
+
+	return ts.owners.Resolve(ctx, email, id)
+}
+
+// markSeen records that the thing has been seen. The time is persisted at
+// most once per lastSeenResolution, so that publishing doesn't turn into a
+// database write per message.
+func (ts *thingsService) markSeen(ctx context.Context, id string) {
+	now := time.Now()
+
+	ts.seenMu.Lock()
+	if now.Sub(ts.seen[id]) < lastSeenResolution {
+		ts.seenMu.Unlock()
+		return
+	}
+	ts.pruneSeen(now)
+	ts.seen[id] = now
+	ts.seenMu.Unlock()
+
+	if err := ts.things.UpdateLastSeen(ctx, id, now); err != nil && ts.logger != nil {
+		ts.logger.Warn(fmt.Sprintf("Failed to update last seen time of thing %s: %s", id, err))
+	}
+}
+
+// pruneSeen removes the things that were seen longer than lastSeenResolution
+// ago, since their time is persisted on the next sight anyway. Things are
+// pruned at most once per lastSeenResolution, so that recording the sight
+// doesn't scan all the things. Caller must hold seenMu.
+func (ts *thingsService) pruneSeen(now time.Time) {
+	if now.Sub(ts.seenPruned) < lastSeenResolution {
+		return
+	}
+
+	for id, seen := range ts.seen {
+		if now.Sub(seen) >= lastSeenResolution {
+			delete(ts.seen, id)
+		}
+	}
+	ts.seenPruned = now
+}
+
+func (ts *thingsService) hasThing(ctx context.Context, chanID, key string) (string, error) {
+	thingID, err := ts.thingCache.ID(ctx, key)
+	if err != nil {
+		return "", err
 	}
 
 	if connected := ts.channelCache.HasThing(ctx, chanID, thingID); !connected {
@@ -368,3 +1791,109 @@ func (ts *thingsService) hasThing(ctx context.Context, chanID, key string) (stri
 
 	return thingID, nil
 }
+
+// retrieveByKey retrieves the thing from the repository and rejects the key
+// if it has expired or if the owner of the thing is disabled.
+func (ts *thingsService) retrieveByKey(ctx context.Context, key string) (Thing, error) {
+	thing, err := ts.things.RetrieveByKey(ctx, key)
+	if err != nil {
+		return Thing{}, ErrUnauthorizedAccess
+	}
+
+	if !thing.KeyExpiry.IsZero() && !time.Now().Before(thing.KeyExpiry) {
+		return Thing{}, ErrKeyExpired
+	}
+
+	disabled, err := ts.ownerDisabled(ctx, thing.Owner)
+	if err != nil {
+		return Thing{}, err
+	}
+
+	if disabled {
+		return Thing{}, ErrOwnerDisabled
+	}
+
+	return thing, nil
+}
+
+// ownerDisabled returns true if the owners can be disabled and the given one
+// is.
+func (ts *thingsService) ownerDisabled(ctx context.Context, owner string) (bool, error) {
+	if ts.owners == nil {
+		return false, nil
+	}
+
+	return ts.owners.Disabled(ctx, owner)
+}
+
+// uncacheOwned removes the keys of the things that belong to the owner from
+// the cache, so that they are validated against the repository again.
+func (ts *thingsService) uncacheOwned(ctx context.Context, owner string) error {
+	for offset := uint64(0); ; offset += ownedPageSize {
+		page, err := ts.things.RetrieveAll(ctx, owner, offset, ownedPageSize, "", true)
+		if err != nil {
+			return err
+		}
+
+		for _, thing := range page.Things {
+			ts.thingCache.Remove(ctx, thing.ID)
+		}
+
+		if offset+ownedPageSize >= page.Total {
+			return nil
+		}
+	}
+}
+
+// cacheThing caches the thing key unless the key has an expiry time, so that
+// expiring keys are always validated against the repository. The owner is
+// checked once again after the key is cached, since the owner could have
+// been disabled, and its keys evicted, after the key was retrieved. If the
+// owner has been disabled in the meantime, the key is evicted and
+// ErrOwnerDisabled is returned.
+func (ts *thingsService) cacheThing(ctx context.Context, thing Thing) error {
+	if !thing.KeyExpiry.IsZero() {
+		return nil
+	}
+
+	ts.thingCache.Save(ctx, thing.Key, thing.ID)
+
+	disabled, err := ts.ownerDisabled(ctx, thing.Owner)
+	if err != nil {
+		ts.thingCache.Remove(ctx, thing.ID)
+		return err
+	}
+
+	if disabled {
+		ts.thingCache.Remove(ctx, thing.ID)
+		return ErrOwnerDisabled
+	}
+
+	return nil
+}
+
+func (ts *thingsService) generateID() (string, error) {
+	id, err := ts.idp.ID()
+	if err != nil {
+		return "", err
+	}
+
+	return ts.idPrefix + id, nil
+}
+
+func (ts *thingsService) generateKey() (string, error) {
+	id, err := ts.idp.ID()
+	if err != nil {
+		return "", err
+	}
+
+	return ts.keyEncoding.encode(id)
+}
+
+func (ts *thingsService) keyExpiry() time.Time {
+	if ts.keyTTL <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(ts.keyTTL)
+}
@@ -9,11 +9,15 @@ package things_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/jwt"
 	"github.com/mainflux/mainflux/things/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,7 +35,7 @@ var (
 	channel = things.Channel{Name: "test"}
 )
 
-func newService(tokens map[string]string) things.Service {
+func newService(tokens map[string]string, opts ...things.Option) things.Service {
 	users := mocks.NewUsersService(tokens)
 	conns := make(chan mocks.Connection)
 	thingsRepo := mocks.NewThingRepository(conns)
@@ -40,7 +44,7 @@ func newService(tokens map[string]string) things.Service {
 	thingCache := mocks.NewThingCache()
 	idp := mocks.NewIdentityProvider()
 
-	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp)
+	return things.New(users, thingsRepo, channelsRepo, chanCache, thingCache, idp, opts...)
 }
 
 func TestAddThing(t *testing.T) {
@@ -72,6 +76,296 @@ func TestAddThing(t *testing.T) {
 	}
 }
 
+func TestAddThings(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	invalid := things.Thing{Name: strings.Repeat("m", 1025)}
+	ths := []things.Thing{{Name: "a"}, invalid, {Name: "c"}}
+
+	cases := []struct {
+		desc        string
+		things      []things.Thing
+		stopOnError bool
+		token       string
+		errs        []error
+		err         error
+	}{
+		{
+			desc:   "add things",
+			things: ths,
+			token:  token,
+			errs:   []error{nil, things.ErrMalformedEntity, nil},
+			err:    nil,
+		},
+		{
+			desc:        "add things stopping on error",
+			things:      ths,
+			stopOnError: true,
+			token:       token,
+			errs:        []error{nil, things.ErrMalformedEntity},
+			err:         nil,
+		},
+		{
+			desc:   "add things with wrong credentials",
+			things: ths,
+			token:  wrongValue,
+			errs:   nil,
+			err:    things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		results, err := svc.AddThings(context.Background(), tc.token, tc.things, tc.stopOnError)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		errs := []error{}
+		for _, res := range results {
+			errs = append(errs, res.Err)
+		}
+		if tc.errs != nil {
+			assert.Equal(t, tc.errs, errs, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.errs, errs))
+		}
+	}
+}
+
+func TestProvisionThing(t *testing.T) {
+	tpl := things.ProvisionTemplate{
+		Channels: []things.ChannelTemplate{
+			{Name: "{name}-data", Metadata: map[string]interface{}{"type": "data"}, Connect: true},
+			{Name: "{name}-control", Connect: false},
+		},
+	}
+
+	cases := []struct {
+		desc      string
+		opts      []things.Option
+		thing     things.Thing
+		token     string
+		channels  []string
+		connected []bool
+		err       error
+	}{
+		{
+			desc:      "provision thing using default template",
+			thing:     things.Thing{Name: "sensor"},
+			token:     token,
+			channels:  []string{"sensor"},
+			connected: []bool{true},
+			err:       nil,
+		},
+		{
+			desc:      "provision thing using custom template",
+			opts:      []things.Option{things.WithProvisionTemplate(tpl)},
+			thing:     things.Thing{Name: "sensor"},
+			token:     token,
+			channels:  []string{"sensor-data", "sensor-control"},
+			connected: []bool{true, false},
+			err:       nil,
+		},
+		{
+			desc:  "provision thing with wrong credentials",
+			thing: things.Thing{Name: "sensor"},
+			token: wrongValue,
+			err:   things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:  "provision thing with invalid name",
+			thing: things.Thing{Name: strings.Repeat("m", 1025)},
+			token: token,
+			err:   things.ErrMalformedEntity,
+		},
+		{
+			desc:  "provision thing failing to create second channel",
+			opts:  []things.Option{things.WithProvisionTemplate(tpl), things.WithMaxNameLength(12)},
+			thing: things.Thing{Name: "sensor"},
+			token: token,
+			err:   things.ErrMalformedEntity,
+		},
+		{
+			desc: "provision thing failing to create first channel",
+			opts: []things.Option{things.WithProvisionTemplate(things.ProvisionTemplate{
+				Channels: []things.ChannelTemplate{{Name: "{name}", Metadata: map[string]interface{}{"mf_key": "data"}, Connect: true}},
+			})},
+			thing: things.Thing{Name: "sensor"},
+			token: token,
+			err:   things.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		svc := newService(map[string]string{token: email}, tc.opts...)
+		prov, err := svc.ProvisionThing(context.Background(), tc.token, tc.thing)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		if tc.token != token {
+			continue
+		}
+
+		// Failed provisioning leaves neither the thing nor the channels.
+		tp, err := svc.ListThings(context.Background(), token, 0, 10, "", false)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		cp, err := svc.ListChannels(context.Background(), token, 0, 10, "", "", false)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		if tc.err != nil {
+			assert.Empty(t, tp.Things, fmt.Sprintf("%s: expected no things got %v", tc.desc, tp.Things))
+			assert.Empty(t, cp.Channels, fmt.Sprintf("%s: expected no channels got %v", tc.desc, cp.Channels))
+			continue
+		}
+
+		assert.Len(t, tp.Things, 1, fmt.Sprintf("%s: expected 1 thing got %d", tc.desc, len(tp.Things)))
+		assert.NotEmpty(t, prov.Thing.Key, fmt.Sprintf("%s: expected thing key", tc.desc))
+		assert.Len(t, cp.Channels, len(tc.channels), fmt.Sprintf("%s: expected %d channels got %d", tc.desc, len(tc.channels), len(cp.Channels)))
+		require.Len(t, prov.Channels, len(tc.channels), fmt.Sprintf("%s: expected %d channels got %d", tc.desc, len(tc.channels), len(prov.Channels)))
+
+		connected, err := svc.ListChannelsByThing(context.Background(), token, prov.Thing.ID, 0, 10)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		ids := map[string]bool{}
+		for _, ch := range connected.Channels {
+			ids[ch.ID] = true
+		}
+
+		for i, pc := range prov.Channels {
+			assert.Equal(t, tc.channels[i], pc.Channel.Name, fmt.Sprintf("%s: expected channel %s got %s", tc.desc, tc.channels[i], pc.Channel.Name))
+			assert.Equal(t, tc.connected[i], pc.Connected, fmt.Sprintf("%s: expected connected %t got %t", tc.desc, tc.connected[i], pc.Connected))
+			assert.Equal(t, tc.connected[i], ids[pc.Channel.ID], fmt.Sprintf("%s: expected thing connected to %s: %t", tc.desc, pc.Channel.Name, tc.connected[i]))
+		}
+	}
+}
+
+func TestProvisionTemplateValidate(t *testing.T) {
+	tpl := things.ProvisionTemplate{
+		Channels: []things.ChannelTemplate{
+			{Name: "{name}-data", Connect: true},
+			{Name: "{name}-control", Connect: true},
+		},
+	}
+
+	cases := []struct {
+		desc string
+		tpl  things.ProvisionTemplate
+		mode things.ConnectionMode
+		err  error
+	}{
+		{
+			desc: "validate default template in exclusive mode",
+			tpl:  things.DefaultProvisionTemplate,
+			mode: things.ExclusiveConnections,
+			err:  nil,
+		},
+		{
+			desc: "validate template connecting multiple channels in shared mode",
+			tpl:  tpl,
+			mode: things.SharedConnections,
+			err:  nil,
+		},
+		{
+			desc: "validate template connecting multiple channels in move mode",
+			tpl:  tpl,
+			mode: things.MoveConnections,
+			err:  things.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.tpl.Validate(tc.mode)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestNormalizeMetadata(t *testing.T) {
+	cases := []struct {
+		desc     string
+		opts     []things.Option
+		metadata map[string]interface{}
+		expected map[string]interface{}
+		err      error
+	}{
+		{
+			desc:     "add thing with metadata",
+			metadata: map[string]interface{}{"test": "data"},
+			expected: map[string]interface{}{"test": "data"},
+			err:      nil,
+		},
+		{
+			desc: "add thing with null values in metadata",
+			metadata: map[string]interface{}{
+				"test":   "data",
+				"null":   nil,
+				"nested": map[string]interface{}{"null": nil, "test": 1.0},
+				"array":  []interface{}{nil, map[string]interface{}{"null": nil}},
+			},
+			expected: map[string]interface{}{
+				"test":   "data",
+				"nested": map[string]interface{}{"test": 1.0},
+				"array":  []interface{}{nil, map[string]interface{}{}},
+			},
+			err: nil,
+		},
+		{
+			desc:     "add thing with reserved key in metadata",
+			metadata: map[string]interface{}{"mf_key": "data"},
+			err:      things.ErrMalformedEntity,
+		},
+		{
+			desc:     "add thing with reserved prefix in nested key of metadata",
+			metadata: map[string]interface{}{"nested": map[string]interface{}{"mf_key": "data"}},
+			expected: map[string]interface{}{"nested": map[string]interface{}{"mf_key": "data"}},
+			err:      nil,
+		},
+		{
+			desc:     "add thing with custom reserved key in metadata",
+			opts:     []things.Option{things.WithReservedMetadataPrefix("internal.")},
+			metadata: map[string]interface{}{"internal.key": "data"},
+			err:      things.ErrMalformedEntity,
+		},
+		{
+			desc:     "add thing with default reserved key and custom reserved prefix",
+			opts:     []things.Option{things.WithReservedMetadataPrefix("internal.")},
+			metadata: map[string]interface{}{"mf_key": "data"},
+			expected: map[string]interface{}{"mf_key": "data"},
+			err:      nil,
+		},
+		{
+			desc:     "add thing with reserved key and no reserved prefix",
+			opts:     []things.Option{things.WithReservedMetadataPrefix("")},
+			metadata: map[string]interface{}{"mf_key": "data"},
+			expected: map[string]interface{}{"mf_key": "data"},
+			err:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		svc := newService(map[string]string{token: email}, tc.opts...)
+
+		th, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a", Metadata: tc.metadata})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		th, err = svc.ViewThing(context.Background(), token, th.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.expected, th.Metadata, fmt.Sprintf("%s: expected metadata %v got %v\n", tc.desc, tc.expected, th.Metadata))
+	}
+
+	svc := newService(map[string]string{token: email})
+	reserved := map[string]interface{}{"mf_key": "data"}
+
+	th, err := svc.AddThing(context.Background(), token, things.Thing{Name: "a"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	th.Metadata = reserved
+	err = svc.UpdateThing(context.Background(), token, th)
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("update thing with reserved key in metadata: expected %s got %s\n", things.ErrMalformedEntity, err))
+
+	_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: "a", Metadata: reserved})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("create channel with reserved key in metadata: expected %s got %s\n", things.ErrMalformedEntity, err))
+
+	ch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "a"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	ch.Metadata = reserved
+	err = svc.UpdateChannel(context.Background(), token, ch)
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("update channel with reserved key in metadata: expected %s got %s\n", things.ErrMalformedEntity, err))
+}
+
 func TestUpdateThing(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	saved, _ := svc.AddThing(context.Background(), token, thing)
@@ -109,6 +403,119 @@ func TestUpdateThing(t *testing.T) {
 	}
 }
 
+func TestPatchThingMetadata(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	th := thing
+	th.Metadata = map[string]interface{}{
+		"site":  "plant-1",
+		"model": "x1",
+		"lora":  map[string]interface{}{"devEUI": "eui", "appID": "app"},
+	}
+	sth, err := svc.AddThing(context.Background(), token, th)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		token    string
+		id       string
+		patch    map[string]interface{}
+		metadata map[string]interface{}
+		err      error
+	}{
+		{
+			desc:  "patch metadata adding key",
+			token: token,
+			id:    sth.ID,
+			patch: map[string]interface{}{"floor": "3"},
+			metadata: map[string]interface{}{
+				"site":  "plant-1",
+				"model": "x1",
+				"floor": "3",
+				"lora":  map[string]interface{}{"devEUI": "eui", "appID": "app"},
+			},
+			err: nil,
+		},
+		{
+			desc:  "patch metadata removing key",
+			token: token,
+			id:    sth.ID,
+			patch: map[string]interface{}{"model": nil},
+			metadata: map[string]interface{}{
+				"site":  "plant-1",
+				"floor": "3",
+				"lora":  map[string]interface{}{"devEUI": "eui", "appID": "app"},
+			},
+			err: nil,
+		},
+		{
+			desc:  "patch metadata replacing and removing nested keys",
+			token: token,
+			id:    sth.ID,
+			patch: map[string]interface{}{"lora": map[string]interface{}{"devEUI": "new", "appID": nil}},
+			metadata: map[string]interface{}{
+				"site":  "plant-1",
+				"floor": "3",
+				"lora":  map[string]interface{}{"devEUI": "new"},
+			},
+			err: nil,
+		},
+		{
+			desc:  "patch metadata removing missing key",
+			token: token,
+			id:    sth.ID,
+			patch: map[string]interface{}{"missing": nil},
+			metadata: map[string]interface{}{
+				"site":  "plant-1",
+				"floor": "3",
+				"lora":  map[string]interface{}{"devEUI": "new"},
+			},
+			err: nil,
+		},
+		{
+			desc:  "patch metadata with reserved key",
+			token: token,
+			id:    sth.ID,
+			patch: map[string]interface{}{things.ReservedMetadataPrefix + "key": "value"},
+			err:   things.ErrMalformedEntity,
+		},
+		{
+			desc:  "patch metadata without patch",
+			token: token,
+			id:    sth.ID,
+			patch: nil,
+			err:   things.ErrMalformedEntity,
+		},
+		{
+			desc:  "patch metadata of non-existing thing",
+			token: token,
+			id:    wrongID,
+			patch: map[string]interface{}{"floor": "4"},
+			err:   things.ErrNotFound,
+		},
+		{
+			desc:  "patch metadata with wrong credentials",
+			token: wrongValue,
+			id:    sth.ID,
+			patch: map[string]interface{}{"floor": "4"},
+			err:   things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		metadata, err := svc.PatchThingMetadata(context.Background(), tc.token, tc.id, tc.patch)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, tc.metadata, metadata, fmt.Sprintf("%s: expected metadata %v got %v\n", tc.desc, tc.metadata, metadata))
+
+		stored, err := svc.ViewThing(context.Background(), token, sth.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.metadata, stored.Metadata, fmt.Sprintf("%s: expected stored metadata %v got %v\n", tc.desc, tc.metadata, stored.Metadata))
+	}
+}
+
 func TestUpdateKey(t *testing.T) {
 	key := "new-key"
 	svc := newService(map[string]string{token: email})
@@ -244,7 +651,7 @@ func TestListThings(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		page, err := svc.ListThings(context.Background(), tc.token, tc.offset, tc.limit, tc.name)
+		page, err := svc.ListThings(context.Background(), tc.token, tc.offset, tc.limit, tc.name, false)
 		size := uint64(len(page.Things))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
@@ -260,7 +667,7 @@ func TestListThingsByChannel(t *testing.T) {
 	for i := uint64(0); i < n; i++ {
 		sth, err := svc.AddThing(context.Background(), token, thing)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		svc.Connect(context.Background(), token, sch.ID, sth.ID)
+		svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	}
 
 	// Wait for things and channels to connect
@@ -340,6 +747,126 @@ func TestListThingsByChannel(t *testing.T) {
 	}
 }
 
+func TestListThingsByChannels(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	ch1, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch2, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Half of the things are connected to the first channel, the other
+	// half to the second one and the shared thing is connected to both.
+	n := uint64(10)
+	for i := uint64(0); i < n; i++ {
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		chID := ch1.ID
+		if i%2 == 0 {
+			chID = ch2.ID
+		}
+		err = svc.Connect(context.Background(), token, chID, sth.ID, 0)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+	shared, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for _, chID := range []string{ch1.ID, ch2.ID} {
+		err = svc.Connect(context.Background(), token, chID, shared.ID, 0)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	// Wait for things and channels to connect
+	time.Sleep(time.Second)
+
+	cases := map[string]struct {
+		token    string
+		channels []string
+		offset   uint64
+		limit    uint64
+		size     uint64
+		total    uint64
+		err      error
+	}{
+		"list things connected to both channels": {
+			token:    token,
+			channels: []string{ch1.ID, ch2.ID},
+			offset:   0,
+			limit:    100,
+			size:     n + 1,
+			total:    n + 1,
+			err:      nil,
+		},
+		"list things connected to single channel": {
+			token:    token,
+			channels: []string{ch1.ID},
+			offset:   0,
+			limit:    100,
+			size:     n/2 + 1,
+			total:    n/2 + 1,
+			err:      nil,
+		},
+		"list things connected to repeated channel": {
+			token:    token,
+			channels: []string{ch1.ID, ch1.ID},
+			offset:   0,
+			limit:    100,
+			size:     n/2 + 1,
+			total:    n/2 + 1,
+			err:      nil,
+		},
+		"list last page of things connected to both channels": {
+			token:    token,
+			channels: []string{ch1.ID, ch2.ID},
+			offset:   n,
+			limit:    5,
+			size:     1,
+			total:    n + 1,
+			err:      nil,
+		},
+		"list things connected to non-existent channel": {
+			token:    token,
+			channels: []string{"non-existent"},
+			offset:   0,
+			limit:    100,
+			size:     0,
+			total:    0,
+			err:      nil,
+		},
+		"list things without channels": {
+			token:    token,
+			channels: []string{},
+			offset:   0,
+			limit:    100,
+			size:     0,
+			total:    0,
+			err:      things.ErrMalformedEntity,
+		},
+		"list things connected to channels with wrong credentials": {
+			token:    wrongValue,
+			channels: []string{ch1.ID, ch2.ID},
+			offset:   0,
+			limit:    100,
+			size:     0,
+			total:    0,
+			err:      things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		page, err := svc.ListThingsByChannels(context.Background(), tc.token, tc.channels, tc.offset, tc.limit)
+		size := uint64(len(page.Things))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d\n", desc, tc.total, page.Total))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+
+		seen := map[string]bool{}
+		for _, th := range page.Things {
+			assert.False(t, seen[th.ID], fmt.Sprintf("%s: thing %s listed more than once\n", desc, th.ID))
+			seen[th.ID] = true
+		}
+	}
+}
+
 func TestRemoveThing(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 	saved, _ := svc.AddThing(context.Background(), token, thing)
@@ -556,7 +1083,7 @@ func TestListChannels(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		page, err := svc.ListChannels(context.Background(), tc.token, tc.offset, tc.limit, tc.name)
+		page, err := svc.ListChannels(context.Background(), tc.token, tc.offset, tc.limit, tc.name, "", false)
 		size := uint64(len(page.Channels))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
@@ -572,7 +1099,7 @@ func TestListChannelsByThing(t *testing.T) {
 	for i := uint64(0); i < n; i++ {
 		sch, err := svc.CreateChannel(context.Background(), token, channel)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		svc.Connect(context.Background(), token, sch.ID, sth.ID)
+		svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 	}
 
 	// Wait for things and channels to connect.
@@ -689,7 +1216,65 @@ func TestRemoveChannel(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		err := svc.RemoveChannel(context.Background(), tc.token, tc.id)
+		err := svc.RemoveChannel(context.Background(), tc.token, tc.id, false)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestRemoveProtectedChannel(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	ch := channel
+	ch.Protected = true
+	saved, err := svc.CreateChannel(context.Background(), token, ch)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.RemoveChannel(context.Background(), token, saved.ID, false)
+	assert.Equal(t, things.ErrProtected, err, fmt.Sprintf("remove protected channel: expected %s got %s\n", things.ErrProtected, err))
+
+	_, err = svc.ViewChannel(context.Background(), token, saved.ID)
+	assert.Nil(t, err, fmt.Sprintf("view protected channel after blocked removal: unexpected error: %s\n", err))
+
+	err = svc.RemoveChannel(context.Background(), token, saved.ID, true)
+	assert.Nil(t, err, fmt.Sprintf("force remove protected channel: unexpected error: %s\n", err))
+
+	_, err = svc.ViewChannel(context.Background(), token, saved.ID)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("view forcibly removed channel: expected %s got %s\n", things.ErrNotFound, err))
+}
+
+func TestToggleChannelProtection(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	saved, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc      string
+		protected bool
+		err       error
+	}{
+		{
+			desc:      "remove channel after enabling protection",
+			protected: true,
+			err:       things.ErrProtected,
+		},
+		{
+			desc:      "remove channel after disabling protection",
+			protected: false,
+			err:       nil,
+		},
+	}
+
+	for _, tc := range cases {
+		saved.Protected = tc.protected
+		err := svc.UpdateChannel(context.Background(), token, saved)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+
+		ch, err := svc.ViewChannel(context.Background(), token, saved.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		assert.Equal(t, tc.protected, ch.Protected, fmt.Sprintf("%s: expected protected %t got %t\n", tc.desc, tc.protected, ch.Protected))
+
+		err = svc.RemoveChannel(context.Background(), token, saved.ID, false)
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 	}
 }
@@ -738,8 +1323,85 @@ func TestConnect(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		err := svc.Connect(context.Background(), tc.token, tc.chanID, tc.thingID)
+		err := svc.Connect(context.Background(), tc.token, tc.chanID, tc.thingID, 0)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestConnectRemovedThing(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Warm up the cache with the thing and its connection.
+	_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.RemoveThing(context.Background(), token, sth.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("connect removed thing: expected %s got %s\n", things.ErrNotFound, err))
+
+	err = svc.CanAccessByID(context.Background(), sch.ID, sth.ID)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access by removed thing: expected %s got %s\n", things.ErrUnauthorizedAccess, err))
+}
+
+func TestConnectionMode(t *testing.T) {
+	cases := []struct {
+		desc      string
+		mode      things.ConnectionMode
+		err       error
+		connected map[int]bool
+	}{
+		{
+			desc:      "connect thing to another channel in shared mode",
+			mode:      things.SharedConnections,
+			err:       nil,
+			connected: map[int]bool{0: true, 1: true},
+		},
+		{
+			desc:      "connect thing to another channel in exclusive mode",
+			mode:      things.ExclusiveConnections,
+			err:       things.ErrConflict,
+			connected: map[int]bool{0: true, 1: false},
+		},
+		{
+			desc:      "connect thing to another channel in move mode",
+			mode:      things.MoveConnections,
+			err:       nil,
+			connected: map[int]bool{0: false, 1: true},
+		},
+	}
+
+	for _, tc := range cases {
+		svc := newService(map[string]string{token: email}, things.WithConnectionMode(tc.mode))
+
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		chans := []things.Channel{}
+		for i := 0; i < 2; i++ {
+			sch, err := svc.CreateChannel(context.Background(), token, channel)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+			chans = append(chans, sch)
+		}
+
+		err = svc.Connect(context.Background(), token, chans[0].ID, sth.ID, 0)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		err = svc.Connect(context.Background(), token, chans[1].ID, sth.ID, 0)
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		for i, ch := range chans {
+			err := svc.CanAccessByID(context.Background(), ch.ID, sth.ID)
+			assert.Equal(t, tc.connected[i], err == nil, fmt.Sprintf("%s: expected connected to channel %d to be %t got %s\n", tc.desc, i, tc.connected[i], err))
+		}
 	}
 }
 
@@ -748,7 +1410,7 @@ func TestDisconnect(t *testing.T) {
 
 	sth, _ := svc.AddThing(context.Background(), token, thing)
 	sch, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 
 	cases := []struct {
 		desc    string
@@ -801,22 +1463,78 @@ func TestDisconnect(t *testing.T) {
 
 }
 
-func TestCanAccess(t *testing.T) {
+func TestConnectionCounts(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 
-	sth, _ := svc.AddThing(context.Background(), token, thing)
-	sch, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	th, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch1, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch2, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
 
-	cases := map[string]struct {
-		token   string
-		channel string
-		err     error
+	cases := []struct {
+		desc    string
+		op      func() error
+		channel uint64
+		thing   uint64
 	}{
-		"allowed access": {
-			token:   sth.Key,
-			channel: sch.ID,
-			err:     nil,
+		{
+			desc:    "count without connections",
+			op:      func() error { return nil },
+			channel: 0,
+			thing:   0,
+		},
+		{
+			desc:    "count after connecting thing to channel",
+			op:      func() error { return svc.Connect(context.Background(), token, ch1.ID, th.ID, 0) },
+			channel: 1,
+			thing:   1,
+		},
+		{
+			desc:    "count after connecting thing to another channel",
+			op:      func() error { return svc.Connect(context.Background(), token, ch2.ID, th.ID, 0) },
+			channel: 1,
+			thing:   2,
+		},
+		{
+			desc:    "count after disconnecting thing from channel",
+			op:      func() error { return svc.Disconnect(context.Background(), token, ch1.ID, th.ID) },
+			channel: 0,
+			thing:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		viewed, err := svc.ViewChannel(context.Background(), token, ch1.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.channel, viewed.Connections, fmt.Sprintf("%s: expected %d connected things got %d", tc.desc, tc.channel, viewed.Connections))
+
+		vth, err := svc.ViewThing(context.Background(), token, th.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.thing, vth.Connections, fmt.Sprintf("%s: expected %d connected channels got %d", tc.desc, tc.thing, vth.Connections))
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	sth, _ := svc.AddThing(context.Background(), token, thing)
+	sch, _ := svc.CreateChannel(context.Background(), token, channel)
+	svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+
+	cases := map[string]struct {
+		token   string
+		channel string
+		err     error
+	}{
+		"allowed access": {
+			token:   sth.Key,
+			channel: sch.ID,
+			err:     nil,
 		},
 		"not-connected cannot access": {
 			token:   wrongValue,
@@ -836,12 +1554,351 @@ func TestCanAccess(t *testing.T) {
 	}
 }
 
+func TestCanAccessActivation(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithMinConnections(2))
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch1, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch2, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc       string
+		connect    string
+		disconnect string
+		err        error
+	}{
+		{
+			desc: "access by thing without connections",
+			err:  things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:    "access by thing connected to fewer channels than required",
+			connect: sch1.ID,
+			err:     things.ErrNotActivated,
+		},
+		{
+			desc:    "access by activated thing",
+			connect: sch2.ID,
+			err:     nil,
+		},
+		{
+			desc:       "access by thing disconnected after the activation",
+			disconnect: sch2.ID,
+			err:        things.ErrNotActivated,
+		},
+	}
+
+	for _, tc := range cases {
+		if tc.connect != "" {
+			err := svc.Connect(context.Background(), token, tc.connect, sth.ID, 0)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		}
+		if tc.disconnect != "" {
+			err := svc.Disconnect(context.Background(), token, tc.disconnect, sth.ID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		}
+
+		_, err := svc.CanAccess(context.Background(), sch1.ID, sth.Key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestCanAccessCatchAll(t *testing.T) {
+	catchAll := "catch-all"
+	svc := newService(map[string]string{token: email}, things.WithCatchAllChannel(catchAll), things.WithMinConnections(2))
+	defSvc := newService(map[string]string{token: email})
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	dth, err := defSvc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		svc    things.Service
+		chanID string
+		key    string
+		id     string
+		err    error
+	}{
+		{
+			desc:   "access catch-all channel by unconnected thing",
+			svc:    svc,
+			chanID: catchAll,
+			key:    sth.Key,
+			id:     sth.ID,
+			err:    nil,
+		},
+		{
+			desc:   "access catch-all channel with invalid key",
+			svc:    svc,
+			chanID: catchAll,
+			key:    wrongValue,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:   "access unconnected channel by thing",
+			svc:    svc,
+			chanID: sch.ID,
+			key:    sth.Key,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:   "access catch-all channel without catch-all channel configured",
+			svc:    defSvc,
+			chanID: catchAll,
+			key:    dth.Key,
+			err:    things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		id, err := tc.svc.CanAccess(context.Background(), tc.chanID, tc.key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.id, id, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.id, id))
+	}
+}
+
+// failingLastSeen fails to persist the time the things were last seen at.
+type failingLastSeen struct {
+	things.ThingRepository
+}
+
+func (fls failingLastSeen) UpdateLastSeen(context.Context, string, time.Time) error {
+	return errors.New("database unavailable")
+}
+
+// warnings records the warnings logged by the service.
+type warnings struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (w *warnings) Debug(string) {}
+func (w *warnings) Info(string)  {}
+func (w *warnings) Error(string) {}
+
+func (w *warnings) Warn(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msg)
+}
+
+func (w *warnings) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.msgs)
+}
+
+func TestCanAccessLogsLastSeenFailure(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{token: email})
+	conns := make(chan mocks.Connection)
+	thingsRepo := mocks.NewThingRepository(conns)
+	channelsRepo := mocks.NewChannelRepository(thingsRepo, conns)
+	logger := &warnings{}
+	svc := things.New(users, failingLastSeen{thingsRepo}, channelsRepo, mocks.NewChannelCache(), mocks.NewThingCache(), mocks.NewIdentityProvider(), things.WithLogger(logger))
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Failure to persist the time doesn't deny the access, and the time is
+	// persisted at most once per resolution.
+	for i := 0; i < 2; i++ {
+		_, err := svc.CanAccess(context.Background(), sch.ID, sth.Key)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+	assert.Equal(t, 1, logger.count(), fmt.Sprintf("expected 1 warning got %d", logger.count()))
+}
+
+func TestCanReadMessagesCatchAll(t *testing.T) {
+	// Catch-all channel is the first channel created, so that it exists and
+	// can be read by the connected things.
+	catchAll := "1"
+	svc := newService(map[string]string{token: email}, things.WithCatchAllChannel(catchAll))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Equal(t, catchAll, sch.ID, fmt.Sprintf("expected catch-all channel %s got %s", catchAll, sch.ID))
+
+	cases := []struct {
+		desc    string
+		connect bool
+		err     error
+	}{
+		{
+			desc:    "read messages of catch-all channel by unconnected thing",
+			connect: false,
+			err:     things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:    "read messages of catch-all channel by connected thing",
+			connect: true,
+			err:     nil,
+		},
+	}
+
+	for _, tc := range cases {
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		if tc.connect {
+			err := svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		}
+
+		_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error accessing catch-all channel: %s", tc.desc, err))
+
+		_, _, err = svc.CanReadMessages(context.Background(), sch.ID, sth.Key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestCanReadMessages(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	sth, _ := svc.AddThing(context.Background(), token, thing)
+	sch, _ := svc.CreateChannel(context.Background(), token, channel)
+	scoped := channel
+	scoped.PublisherScoped = true
+	pch, _ := svc.CreateChannel(context.Background(), token, scoped)
+	svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	svc.Connect(context.Background(), token, pch.ID, sth.ID, 0)
+
+	cases := map[string]struct {
+		token   string
+		channel string
+		thingID string
+		scoped  bool
+		err     error
+	}{
+		"read messages of channel": {
+			token:   sth.Key,
+			channel: sch.ID,
+			thingID: sth.ID,
+			scoped:  false,
+			err:     nil,
+		},
+		"read messages of publisher-scoped channel": {
+			token:   sth.Key,
+			channel: pch.ID,
+			thingID: sth.ID,
+			scoped:  true,
+			err:     nil,
+		},
+		"read messages with wrong key": {
+			token:   wrongValue,
+			channel: pch.ID,
+			err:     things.ErrUnauthorizedAccess,
+		},
+		"read messages of non-existing channel": {
+			token:   sth.Key,
+			channel: wrongID,
+			err:     things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		id, scoped, err := svc.CanReadMessages(context.Background(), tc.channel, tc.token)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		assert.Equal(t, tc.thingID, id, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.thingID, id))
+		assert.Equal(t, tc.scoped, scoped, fmt.Sprintf("%s: expected %t got %t\n", desc, tc.scoped, scoped))
+	}
+}
+
+func TestDynamicChannel(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	floor3 := map[string]interface{}{"floor": float64(3), "type": "sensor"}
+	floor4 := map[string]interface{}{"floor": float64(4), "type": "sensor"}
+
+	sth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "sensor", Metadata: floor3})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	oth, err := svc.AddThing(context.Background(), token, things.Thing{Name: "other", Metadata: floor4})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	dch, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "floor3", Membership: things.MembershipQuery{"floor": float64(3)}})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	members := func() []string {
+		page, err := svc.ListThingsByChannel(context.Background(), token, dch.ID, 0, 10)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		ids := []string{}
+		for _, th := range page.Things {
+			ids = append(ids, th.ID)
+		}
+		return ids
+	}
+
+	cases := []struct {
+		desc     string
+		metadata map[string]interface{}
+		members  []string
+		err      error
+	}{
+		{
+			desc:     "access dynamic channel with matching metadata",
+			metadata: floor3,
+			members:  []string{sth.ID},
+			err:      nil,
+		},
+		{
+			desc:     "access dynamic channel after losing membership",
+			metadata: floor4,
+			members:  []string{},
+			err:      things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:     "access dynamic channel after regaining membership",
+			metadata: floor3,
+			members:  []string{sth.ID},
+			err:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.UpdateThing(context.Background(), token, things.Thing{ID: sth.ID, Name: sth.Name, Metadata: tc.metadata})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		_, err = svc.CanAccess(context.Background(), dch.ID, sth.Key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		err = svc.CanAccessByID(context.Background(), dch.ID, sth.ID)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.ElementsMatch(t, tc.members, members(), fmt.Sprintf("%s: expected members %v got %v\n", tc.desc, tc.members, members()))
+	}
+
+	_, err = svc.CanAccess(context.Background(), dch.ID, oth.Key)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access dynamic channel without matching metadata: expected %s got %s\n", things.ErrUnauthorizedAccess, err))
+
+	err = svc.Connect(context.Background(), token, dch.ID, oth.ID, 0)
+	assert.Equal(t, things.ErrDynamicChannel, err, fmt.Sprintf("connect thing to dynamic channel: expected %s got %s\n", things.ErrDynamicChannel, err))
+
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, oth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch.Membership = things.MembershipQuery{"floor": float64(4)}
+	err = svc.UpdateChannel(context.Background(), token, sch)
+	assert.Equal(t, things.ErrDynamicChannel, err, fmt.Sprintf("make channel with connections dynamic: expected %s got %s\n", things.ErrDynamicChannel, err))
+
+	_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: "nested", Membership: things.MembershipQuery{"floor": map[string]interface{}{"number": 3}}})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("create channel with non-scalar membership query: expected %s got %s\n", things.ErrMalformedEntity, err))
+}
+
 func TestCanAccessByID(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 
 	sth, _ := svc.AddThing(context.Background(), token, thing)
 	sch, _ := svc.CreateChannel(context.Background(), token, channel)
-	svc.Connect(context.Background(), token, sch.ID, sth.ID)
+	svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
 
 	cases := map[string]struct {
 		thingID string
@@ -871,6 +1928,78 @@ func TestCanAccessByID(t *testing.T) {
 	}
 }
 
+func TestReapIdle(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	stale, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	active, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	for _, th := range []things.Thing{stale, active} {
+		err := svc.Connect(context.Background(), token, sch.ID, th.ID, 0)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	idle := 50 * time.Millisecond
+	time.Sleep(2 * idle)
+	_, err = svc.CanAccess(context.Background(), sch.ID, active.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc      string
+		token     string
+		chanID    string
+		olderThan time.Duration
+		reaped    []string
+		err       error
+	}{
+		{
+			desc:      "reap idle things with invalid token",
+			token:     wrongValue,
+			chanID:    sch.ID,
+			olderThan: idle,
+			err:       things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:      "reap idle things without idle period",
+			token:     token,
+			chanID:    sch.ID,
+			olderThan: 0,
+			err:       things.ErrMalformedEntity,
+		},
+		{
+			desc:      "reap idle things of non-existing channel",
+			token:     token,
+			chanID:    wrongID,
+			olderThan: idle,
+			err:       things.ErrNotFound,
+		},
+		{
+			desc:      "reap idle things",
+			token:     token,
+			chanID:    sch.ID,
+			olderThan: idle,
+			reaped:    []string{stale.ID},
+			err:       nil,
+		},
+	}
+
+	for _, tc := range cases {
+		reaped, err := svc.ReapIdle(context.Background(), tc.token, tc.chanID, tc.olderThan)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.ElementsMatch(t, tc.reaped, reaped, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.reaped, reaped))
+		}
+	}
+
+	err = svc.CanAccessByID(context.Background(), sch.ID, stale.ID)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("reaped thing: expected %s got %s\n", things.ErrUnauthorizedAccess, err))
+	err = svc.CanAccessByID(context.Background(), sch.ID, active.ID)
+	assert.Nil(t, err, fmt.Sprintf("active thing: unexpected error: %s", err))
+}
+
 func TestIdentify(t *testing.T) {
 	svc := newService(map[string]string{token: email})
 
@@ -899,3 +2028,1685 @@ func TestIdentify(t *testing.T) {
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
 	}
 }
+
+func TestIdentifyFull(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	th := things.Thing{Name: "full", Metadata: map[string]interface{}{"serial": "123"}}
+	sth, err := svc.AddThing(context.Background(), token, th)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		key   string
+		thing things.Thing
+		err   error
+	}{
+		"identify existing thing": {
+			key: sth.Key,
+			thing: things.Thing{
+				ID:        sth.ID,
+				Owner:     email,
+				Name:      th.Name,
+				Metadata:  th.Metadata,
+				LastSeen:  sth.LastSeen,
+				CreatedAt: sth.CreatedAt,
+				UpdatedAt: sth.UpdatedAt,
+			},
+			err: nil,
+		},
+		"identify non-existent thing": {
+			key:   wrongValue,
+			thing: things.Thing{},
+			err:   things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		thing, err := svc.IdentifyFull(context.Background(), tc.key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		assert.Equal(t, tc.thing, thing, fmt.Sprintf("%s: expected %v got %v\n", desc, tc.thing, thing))
+		assert.Empty(t, thing.Key, fmt.Sprintf("%s: expected key to be omitted got %s\n", desc, thing.Key))
+	}
+}
+
+func TestIdentifyBatch(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	sth1, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sth2, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Resolve the first thing once, so that the batch mixes cached and
+	// uncached keys.
+	_, err = svc.Identify(context.Background(), sth1.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		keys []string
+		ids  map[string]string
+	}{
+		"identify existing things": {
+			keys: []string{sth1.Key, sth2.Key},
+			ids:  map[string]string{sth1.Key: sth1.ID, sth2.Key: sth2.ID},
+		},
+		"identify existing and non-existent things": {
+			keys: []string{wrongValue, sth2.Key, ""},
+			ids:  map[string]string{sth2.Key: sth2.ID},
+		},
+		"identify duplicate keys": {
+			keys: []string{sth1.Key, sth1.Key},
+			ids:  map[string]string{sth1.Key: sth1.ID},
+		},
+		"identify non-existent things": {
+			keys: []string{wrongValue},
+			ids:  map[string]string{},
+		},
+	}
+
+	for desc, tc := range cases {
+		ids, err := svc.IdentifyBatch(context.Background(), tc.keys)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", desc, err))
+		assert.Equal(t, tc.ids, ids, fmt.Sprintf("%s: expected %v got %v\n", desc, tc.ids, ids))
+	}
+}
+
+func TestIdentifyBatchExpiredKey(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email}, things.WithKeyTTL(ttl))
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	ids, err := svc.IdentifyBatch(context.Background(), []string{sth.Key})
+	assert.Nil(t, err, fmt.Sprintf("identify with unexpired key: unexpected error: %s", err))
+	assert.Equal(t, map[string]string{sth.Key: sth.ID}, ids, fmt.Sprintf("identify with unexpired key: expected %s got %v", sth.ID, ids))
+
+	time.Sleep(ttl)
+
+	ids, err = svc.IdentifyBatch(context.Background(), []string{sth.Key})
+	assert.Nil(t, err, fmt.Sprintf("identify with expired key: unexpected error: %s", err))
+	assert.Empty(t, ids, fmt.Sprintf("identify with expired key: expected no ids got %v", ids))
+}
+
+func TestKeyExpiry(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email}, things.WithKeyTTL(ttl))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	unexpired, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.False(t, unexpired.KeyExpiry.IsZero(), "expected key expiry to be set")
+
+	id, err := svc.Identify(context.Background(), unexpired.Key)
+	assert.Nil(t, err, fmt.Sprintf("identify with unexpired key: unexpected error: %s", err))
+	assert.Equal(t, unexpired.ID, id, fmt.Sprintf("identify with unexpired key: expected %s got %s", unexpired.ID, id))
+
+	err = svc.Connect(context.Background(), token, sch.ID, unexpired.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	time.Sleep(ttl)
+
+	_, err = svc.Identify(context.Background(), unexpired.Key)
+	assert.Equal(t, things.ErrKeyExpired, err, fmt.Sprintf("identify with expired key: expected %s got %s", things.ErrKeyExpired, err))
+
+	_, err = svc.CanAccess(context.Background(), sch.ID, unexpired.Key)
+	assert.Equal(t, things.ErrKeyExpired, err, fmt.Sprintf("access with expired key: expected %s got %s", things.ErrKeyExpired, err))
+}
+
+func TestRotateExpiredKeys(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email}, things.WithKeyTTL(ttl))
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	time.Sleep(ttl)
+
+	rotated := time.Now()
+	ids, err := svc.RotateExpiredKeys(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, []string{sth.ID}, ids, fmt.Sprintf("expected rotated thing %s got %v", sth.ID, ids))
+
+	th, err := svc.ViewThing(context.Background(), token, sth.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.NotEqual(t, sth.Key, th.Key, "expected expired key to be replaced")
+	assert.True(t, th.KeyExpiry.After(rotated), fmt.Sprintf("expected key expiry to be reset after %s got %s", rotated, th.KeyExpiry))
+
+	_, err = svc.Identify(context.Background(), sth.Key)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("identify with rotated key: expected %s got %s", things.ErrUnauthorizedAccess, err))
+
+	id, err := svc.Identify(context.Background(), th.Key)
+	assert.Nil(t, err, fmt.Sprintf("identify with new key: unexpected error: %s", err))
+	assert.Equal(t, sth.ID, id, fmt.Sprintf("identify with new key: expected %s got %s", sth.ID, id))
+}
+
+func TestRotateExpiredKeysConcurrently(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email}, things.WithKeyTTL(ttl))
+
+	// Exceed a single page of the expired things.
+	n := 250
+	for i := 0; i < n; i++ {
+		_, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	time.Sleep(ttl)
+
+	var wg sync.WaitGroup
+	results := make([][]string, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids, err := svc.RotateExpiredKeys(context.Background())
+			assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+			results[i] = ids
+		}(i)
+	}
+	wg.Wait()
+
+	rotated := map[string]int{}
+	for _, ids := range results {
+		for _, id := range ids {
+			rotated[id]++
+		}
+	}
+
+	assert.Equal(t, n, len(rotated), fmt.Sprintf("expected %d rotated things got %d", n, len(rotated)))
+	for id, cnt := range rotated {
+		assert.Equal(t, 1, cnt, fmt.Sprintf("expected key of thing %s to be rotated once got %d", id, cnt))
+	}
+}
+
+func TestConnectionExpiry(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email})
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	temporary, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	permanent, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.Connect(context.Background(), token, sch.ID, temporary.ID, -ttl)
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("connect with negative ttl: expected %s got %s", things.ErrMalformedEntity, err))
+
+	err = svc.Connect(context.Background(), token, sch.ID, temporary.ID, ttl)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, permanent.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Access checks are repeated, since the successful ones populate the
+	// cache.
+	for i := 0; i < 2; i++ {
+		id, err := svc.CanAccess(context.Background(), sch.ID, temporary.Key)
+		assert.Nil(t, err, fmt.Sprintf("access before expiry: unexpected error: %s", err))
+		assert.Equal(t, temporary.ID, id, fmt.Sprintf("access before expiry: expected %s got %s", temporary.ID, id))
+
+		err = svc.CanAccessByID(context.Background(), sch.ID, temporary.ID)
+		assert.Nil(t, err, fmt.Sprintf("access by ID before expiry: unexpected error: %s", err))
+	}
+
+	page, err := svc.ListThingsByChannel(context.Background(), token, sch.ID, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Len(t, page.Things, 2, fmt.Sprintf("list before expiry: expected 2 things got %d", len(page.Things)))
+
+	time.Sleep(ttl)
+
+	_, err = svc.CanAccess(context.Background(), sch.ID, temporary.Key)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access after expiry: expected %s got %s", things.ErrUnauthorizedAccess, err))
+
+	err = svc.CanAccessByID(context.Background(), sch.ID, temporary.ID)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access by ID after expiry: expected %s got %s", things.ErrUnauthorizedAccess, err))
+
+	id, err := svc.CanAccess(context.Background(), sch.ID, permanent.Key)
+	assert.Nil(t, err, fmt.Sprintf("access of permanent connection: unexpected error: %s", err))
+	assert.Equal(t, permanent.ID, id, fmt.Sprintf("access of permanent connection: expected %s got %s", permanent.ID, id))
+
+	page, err = svc.ListThingsByChannel(context.Background(), token, sch.ID, 0, 10)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, page.Things, 1, fmt.Sprintf("list after expiry: expected 1 thing got %d", len(page.Things)))
+	assert.Equal(t, permanent.ID, page.Things[0].ID, fmt.Sprintf("list after expiry: expected %s got %s", permanent.ID, page.Things[0].ID))
+
+	conns, err := svc.DisconnectExpired(context.Background())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, conns, 1, fmt.Sprintf("expected 1 expired connection got %d", len(conns)))
+	assert.Equal(t, sch.ID, conns[0].ChanID, fmt.Sprintf("expected expired connection to %s got %s", sch.ID, conns[0].ChanID))
+	assert.Equal(t, temporary.ID, conns[0].ThingID, fmt.Sprintf("expected expired connection of %s got %s", temporary.ID, conns[0].ThingID))
+
+	err = svc.Disconnect(context.Background(), token, sch.ID, temporary.ID)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("disconnect removed connection: expected %s got %s", things.ErrNotFound, err))
+
+	err = svc.Connect(context.Background(), token, sch.ID, temporary.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = svc.CanAccess(context.Background(), sch.ID, temporary.Key)
+	assert.Nil(t, err, fmt.Sprintf("access after reconnecting: unexpected error: %s", err))
+}
+
+func TestConnectionExpiryReplacesCached(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	svc := newService(map[string]string{token: email})
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Permanent connection is cached by now, and reconnecting it with the
+	// expiry has to take effect on the cached connection as well.
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, ttl)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	time.Sleep(ttl)
+
+	_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("access after expiry: expected %s got %s", things.ErrUnauthorizedAccess, err))
+}
+
+func TestDisableOwner(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithOwners(mocks.NewOwnerRepository()))
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Keys are cached by the successful checks, so the disabling has to
+	// take effect on the cached keys as well.
+	_, err = svc.Identify(context.Background(), sth.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		op     func(context.Context, string) error
+		owner  string
+		err    error
+		access error
+	}{
+		{
+			desc:   "disable owner",
+			op:     svc.DisableOwner,
+			owner:  email,
+			err:    nil,
+			access: things.ErrOwnerDisabled,
+		},
+		{
+			desc:   "disable disabled owner",
+			op:     svc.DisableOwner,
+			owner:  email,
+			err:    nil,
+			access: things.ErrOwnerDisabled,
+		},
+		{
+			desc:   "disable owner with empty ID",
+			op:     svc.DisableOwner,
+			owner:  "",
+			err:    things.ErrMalformedEntity,
+			access: things.ErrOwnerDisabled,
+		},
+		{
+			desc:   "enable owner",
+			op:     svc.EnableOwner,
+			owner:  email,
+			err:    nil,
+			access: nil,
+		},
+		{
+			desc:   "disable other owner",
+			op:     svc.DisableOwner,
+			owner:  "other@example.com",
+			err:    nil,
+			access: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op(context.Background(), tc.owner)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		_, err = svc.Identify(context.Background(), sth.Key)
+		assert.Equal(t, tc.access, err, fmt.Sprintf("%s: identify: expected %s got %s\n", tc.desc, tc.access, err))
+
+		_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+		assert.Equal(t, tc.access, err, fmt.Sprintf("%s: can access: expected %s got %s\n", tc.desc, tc.access, err))
+
+		ids, err := svc.IdentifyBatch(context.Background(), []string{sth.Key})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		_, identified := ids[sth.Key]
+		assert.Equal(t, tc.access == nil, identified, fmt.Sprintf("%s: identify batch: expected identified %t got %t\n", tc.desc, tc.access == nil, identified))
+	}
+}
+
+// disablingOwners disables the owner right after the first check, as if the
+// owner got disabled, and its keys evicted, while the key was being checked.
+type disablingOwners struct {
+	things.OwnerRepository
+	checked bool
+}
+
+func (do *disablingOwners) Disabled(ctx context.Context, owner string) (bool, error) {
+	disabled, err := do.OwnerRepository.Disabled(ctx, owner)
+	if !do.checked {
+		do.checked = true
+		do.OwnerRepository.Disable(ctx, owner)
+	}
+
+	return disabled, err
+}
+
+func TestDisableOwnerWhileCaching(t *testing.T) {
+	owners := &disablingOwners{OwnerRepository: mocks.NewOwnerRepository()}
+	svc := newService(map[string]string{token: email}, things.WithOwners(owners))
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = svc.Identify(context.Background(), sth.Key)
+	assert.Equal(t, things.ErrOwnerDisabled, err, fmt.Sprintf("identify thing of owner disabled while caching: expected %s got %s\n", things.ErrOwnerDisabled, err))
+
+	_, err = svc.Identify(context.Background(), sth.Key)
+	assert.Equal(t, things.ErrOwnerDisabled, err, fmt.Sprintf("identify thing of disabled owner: expected %s got %s\n", things.ErrOwnerDisabled, err))
+}
+
+func TestDisableOwnerUnsupported(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	err := svc.DisableOwner(context.Background(), email)
+	assert.Equal(t, things.ErrOwnerManagementDisabled, err, fmt.Sprintf("disable owner: expected %s got %s\n", things.ErrOwnerManagementDisabled, err))
+
+	err = svc.EnableOwner(context.Background(), email)
+	assert.Equal(t, things.ErrOwnerManagementDisabled, err, fmt.Sprintf("enable owner: expected %s got %s\n", things.ErrOwnerManagementDisabled, err))
+
+	err = svc.ChangeOwnerEmail(context.Background(), email, "changed@example.com")
+	assert.Equal(t, things.ErrOwnerManagementDisabled, err, fmt.Sprintf("change owner email: expected %s got %s\n", things.ErrOwnerManagementDisabled, err))
+}
+
+// countingOwners counts the owners saved while resolving them.
+type countingOwners struct {
+	things.OwnerRepository
+	mu    sync.Mutex
+	calls int
+}
+
+func (co *countingOwners) Resolve(ctx context.Context, email, id string) (string, error) {
+	co.mu.Lock()
+	co.calls++
+	co.mu.Unlock()
+	return co.OwnerRepository.Resolve(ctx, email, id)
+}
+
+func (co *countingOwners) count() int {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	return co.calls
+}
+
+func TestResolveKnownOwner(t *testing.T) {
+	owners := &countingOwners{OwnerRepository: mocks.NewOwnerRepository()}
+	svc := newService(map[string]string{token: email}, things.WithOwners(owners))
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.ListThings(context.Background(), token, 0, 10, "", false)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	assert.Equal(t, 1, owners.count(), fmt.Sprintf("expected owner to be saved once got %d", owners.count()))
+}
+
+func TestChangeOwnerEmail(t *testing.T) {
+	newEmail := "changed@example.com"
+	otherEmail := "other@example.com"
+	newToken := "new-token"
+	otherToken := "other-token"
+	staleToken := "stale-token"
+	svc := newService(map[string]string{
+		token:      email,
+		newToken:   newEmail,
+		otherToken: otherEmail,
+		staleToken: email,
+	}, things.WithOwners(mocks.NewOwnerRepository()))
+
+	sth, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Connect(context.Background(), token, sch.ID, sth.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = svc.ListThings(context.Background(), otherToken, 0, 10, "", false)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		email    string
+		newEmail string
+		err      error
+	}{
+		{
+			desc:     "change email of unknown owner",
+			email:    "unknown@example.com",
+			newEmail: newEmail,
+			err:      things.ErrNotFound,
+		},
+		{
+			desc:     "change email to email of another owner",
+			email:    email,
+			newEmail: otherEmail,
+			err:      things.ErrConflict,
+		},
+		{
+			desc:     "change email to empty email",
+			email:    email,
+			newEmail: "",
+			err:      things.ErrMalformedEntity,
+		},
+		{
+			desc:     "change email",
+			email:    email,
+			newEmail: newEmail,
+			err:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.ChangeOwnerEmail(context.Background(), tc.email, tc.newEmail)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	// Token issued to the new email identifies the owner of the resources
+	// created under the previous one.
+	th, err := svc.ViewThing(context.Background(), newToken, sth.ID)
+	assert.Nil(t, err, fmt.Sprintf("view thing after email change: unexpected error: %s", err))
+	assert.Equal(t, sth.ID, th.ID, fmt.Sprintf("view thing after email change: expected %s got %s\n", sth.ID, th.ID))
+
+	ch, err := svc.ViewChannel(context.Background(), newToken, sch.ID)
+	assert.Nil(t, err, fmt.Sprintf("view channel after email change: unexpected error: %s", err))
+	assert.Equal(t, uint64(1), ch.Connections, fmt.Sprintf("view channel after email change: expected %d connections got %d\n", 1, ch.Connections))
+
+	page, err := svc.ListThings(context.Background(), newToken, 0, 10, "", false)
+	assert.Nil(t, err, fmt.Sprintf("list things after email change: unexpected error: %s", err))
+	assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("list things after email change: expected %d things got %d\n", 1, page.Total))
+
+	_, err = svc.CanAccess(context.Background(), sch.ID, sth.Key)
+	assert.Nil(t, err, fmt.Sprintf("access after email change: unexpected error: %s", err))
+
+	// Owner is disabled by the current email.
+	err = svc.DisableOwner(context.Background(), newEmail)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = svc.Identify(context.Background(), sth.Key)
+	assert.Equal(t, things.ErrOwnerDisabled, err, fmt.Sprintf("identify thing of disabled owner: expected %s got %s\n", things.ErrOwnerDisabled, err))
+	err = svc.EnableOwner(context.Background(), newEmail)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Previous email no longer grants access to the resources, e.g. once it
+	// is registered by someone else.
+	_, err = svc.ViewThing(context.Background(), staleToken, sth.ID)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("view thing by previous email: expected %s got %s\n", things.ErrNotFound, err))
+}
+
+func TestKeyEncoding(t *testing.T) {
+	cases := map[string]struct {
+		encoding things.KeyEncoding
+		pattern  string
+	}{
+		"generate uuid encoded key": {
+			encoding: things.UUIDKeyEncoding,
+			pattern:  `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
+		},
+		"generate hex encoded key": {
+			encoding: things.HexKeyEncoding,
+			pattern:  `^[0-9a-f]{32}$`,
+		},
+		"generate base64url encoded key": {
+			encoding: things.Base64URLKeyEncoding,
+			pattern:  `^[A-Za-z0-9_-]{22}$`,
+		},
+	}
+
+	for desc, tc := range cases {
+		svc := newService(map[string]string{token: email}, things.WithKeyEncoding(tc.encoding))
+
+		sth, err := svc.AddThing(context.Background(), token, thing)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+		assert.Regexp(t, tc.pattern, sth.Key, fmt.Sprintf("%s: key %s doesn't match expected format", desc, sth.Key))
+
+		th, err := svc.ViewThing(context.Background(), token, sth.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+		assert.Equal(t, sth.Key, th.Key, fmt.Sprintf("%s: expected viewed key %s got %s", desc, sth.Key, th.Key))
+
+		id, err := svc.Identify(context.Background(), sth.Key)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+		assert.Equal(t, sth.ID, id, fmt.Sprintf("%s: expected %s got %s", desc, sth.ID, id))
+	}
+}
+
+func TestIssueChannelToken(t *testing.T) {
+	tokenizer := jwt.New("secret")
+	svc := newService(map[string]string{token: email}, things.WithChannelTokenizer(tokenizer))
+	saved, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id    string
+		token string
+		ttl   time.Duration
+		scope string
+		err   error
+	}{
+		"issue token for existing channel": {
+			id:    saved.ID,
+			token: token,
+			ttl:   time.Hour,
+			scope: things.ReadScope,
+			err:   nil,
+		},
+		"issue token with wrong credentials": {
+			id:    saved.ID,
+			token: wrongValue,
+			ttl:   time.Hour,
+			scope: things.ReadScope,
+			err:   things.ErrUnauthorizedAccess,
+		},
+		"issue token for non-existing channel": {
+			id:    wrongID,
+			token: token,
+			ttl:   time.Hour,
+			scope: things.ReadScope,
+			err:   things.ErrNotFound,
+		},
+		"issue token without ttl": {
+			id:    saved.ID,
+			token: token,
+			ttl:   0,
+			scope: things.ReadScope,
+			err:   things.ErrMalformedEntity,
+		},
+		"issue token with unsupported scope": {
+			id:    saved.ID,
+			token: token,
+			ttl:   time.Hour,
+			scope: "write",
+			err:   things.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		tok, err := svc.IssueChannelToken(context.Background(), tc.token, tc.id, tc.ttl, tc.scope)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		assert.True(t, strings.HasPrefix(tok, things.ChannelTokenPrefix), fmt.Sprintf("%s: expected token prefixed with %s got %s\n", desc, things.ChannelTokenPrefix, tok))
+		ct, err := tokenizer.Parse(strings.TrimPrefix(tok, things.ChannelTokenPrefix))
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+		assert.Equal(t, tc.id, ct.ChanID, fmt.Sprintf("%s: expected channel %s got %s\n", desc, tc.id, ct.ChanID))
+		assert.Equal(t, tc.scope, ct.Scope, fmt.Sprintf("%s: expected scope %s got %s\n", desc, tc.scope, ct.Scope))
+	}
+
+	disabled := newService(map[string]string{token: email})
+	_, err = disabled.IssueChannelToken(context.Background(), token, saved.ID, time.Hour, things.ReadScope)
+	assert.Equal(t, things.ErrChannelTokensDisabled, err, fmt.Sprintf("expected %s got %s\n", things.ErrChannelTokensDisabled, err))
+}
+
+func TestCanReadMessagesWithChannelToken(t *testing.T) {
+	tokenizer := jwt.New("secret")
+	svc := newService(map[string]string{token: email}, things.WithChannelTokenizer(tokenizer))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	other, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	valid, err := svc.IssueChannelToken(context.Background(), token, sch.ID, time.Hour, things.ReadScope)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	expired, err := tokenizer.Issue(things.ChannelToken{
+		ChanID:    sch.ID,
+		Scope:     things.ReadScope,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	forged, err := jwt.New("wrong-secret").Issue(things.ChannelToken{
+		ChanID:    sch.ID,
+		Scope:     things.ReadScope,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		chanID string
+		token  string
+		err    error
+	}{
+		"read messages with valid channel token": {
+			chanID: sch.ID,
+			token:  valid,
+			err:    nil,
+		},
+		"read messages with expired channel token": {
+			chanID: sch.ID,
+			token:  things.ChannelTokenPrefix + expired,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		"read messages of other channel with channel token": {
+			chanID: other.ID,
+			token:  valid,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		"read messages with channel token signed by other secret": {
+			chanID: sch.ID,
+			token:  things.ChannelTokenPrefix + forged,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		"read messages with channel token without prefix": {
+			chanID: sch.ID,
+			token:  strings.TrimPrefix(valid, things.ChannelTokenPrefix),
+			err:    things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		_, _, err := svc.CanReadMessages(context.Background(), tc.chanID, tc.token)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+	}
+}
+
+func TestRevokeChannelTokens(t *testing.T) {
+	svc := newService(map[string]string{token: email, wrongValue: "other@example.com"}, things.WithChannelTokenizer(jwt.New("secret")))
+	sch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	revoked, err := svc.IssueChannelToken(context.Background(), token, sch.ID, time.Hour, things.ReadScope)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc  string
+		token string
+		id    string
+		err   error
+		read  error
+	}{
+		{
+			desc:  "revoke tokens as other user",
+			token: wrongValue,
+			id:    sch.ID,
+			err:   things.ErrNotFound,
+			read:  nil,
+		},
+		{
+			desc:  "revoke tokens of non-existing channel",
+			token: token,
+			id:    wrongID,
+			err:   things.ErrNotFound,
+			read:  nil,
+		},
+		{
+			desc:  "revoke tokens",
+			token: token,
+			id:    sch.ID,
+			err:   nil,
+			read:  things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.RevokeChannelTokens(context.Background(), tc.token, tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		_, _, err = svc.CanReadMessages(context.Background(), sch.ID, revoked)
+		assert.Equal(t, tc.read, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.read, err))
+	}
+
+	issued, err := svc.IssueChannelToken(context.Background(), token, sch.ID, time.Hour, things.ReadScope)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, _, err = svc.CanReadMessages(context.Background(), sch.ID, issued)
+	assert.Nil(t, err, fmt.Sprintf("read messages with token issued after revocation: unexpected error: %s", err))
+
+	disabled := newService(map[string]string{token: email})
+	err = disabled.RevokeChannelTokens(context.Background(), token, sch.ID)
+	assert.Equal(t, things.ErrChannelTokensDisabled, err, fmt.Sprintf("expected %s got %s\n", things.ErrChannelTokensDisabled, err))
+}
+
+func TestCreateShareLink(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithShareLinks(mocks.NewShareLinkRepository(), "http://localhost/reader/"))
+	saved, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		id    string
+		token string
+		ttl   time.Duration
+		err   error
+	}{
+		"create link for existing channel": {
+			id:    saved.ID,
+			token: token,
+			ttl:   time.Hour,
+			err:   nil,
+		},
+		"create link with wrong credentials": {
+			id:    saved.ID,
+			token: wrongValue,
+			ttl:   time.Hour,
+			err:   things.ErrUnauthorizedAccess,
+		},
+		"create link for non-existing channel": {
+			id:    wrongID,
+			token: token,
+			ttl:   time.Hour,
+			err:   things.ErrNotFound,
+		},
+		"create link without ttl": {
+			id:    saved.ID,
+			token: token,
+			ttl:   0,
+			err:   things.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		link, err := svc.CreateShareLink(context.Background(), tc.token, tc.id, tc.ttl)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		url := fmt.Sprintf("http://localhost/reader/channels/%s/messages?share=%s", tc.id, link.Token)
+		assert.Equal(t, tc.id, link.ChanID, fmt.Sprintf("%s: expected channel %s got %s\n", desc, tc.id, link.ChanID))
+		assert.Equal(t, url, link.URL, fmt.Sprintf("%s: expected url %s got %s\n", desc, url, link.URL))
+		assert.True(t, link.ExpiresAt.After(time.Now()), fmt.Sprintf("%s: expected link to expire in the future", desc))
+	}
+
+	disabled := newService(map[string]string{token: email})
+	_, err = disabled.CreateShareLink(context.Background(), token, saved.ID, time.Hour)
+	assert.Equal(t, things.ErrShareLinksDisabled, err, fmt.Sprintf("expected %s got %s\n", things.ErrShareLinksDisabled, err))
+}
+
+func TestShareLinkTokenHash(t *testing.T) {
+	links := mocks.NewShareLinkRepository()
+	svc := newService(map[string]string{token: email}, things.WithShareLinks(links, ""))
+	ch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	link, err := svc.CreateShareLink(context.Background(), token, ch.ID, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = links.RetrieveByToken(context.Background(), link.Token)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve link by plaintext token: expected %s got %s\n", things.ErrNotFound, err))
+}
+
+func TestCanReadShared(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithShareLinks(mocks.NewShareLinkRepository(), ""))
+	ch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	other, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	link, err := svc.CreateShareLink(context.Background(), token, ch.ID, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	expired, err := svc.CreateShareLink(context.Background(), token, ch.ID, time.Millisecond)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	time.Sleep(10 * time.Millisecond)
+
+	cases := map[string]struct {
+		link   string
+		chanID string
+		err    error
+	}{
+		"read channel using valid link": {
+			link:   link.Token,
+			chanID: ch.ID,
+			err:    nil,
+		},
+		"read other channel using valid link": {
+			link:   link.Token,
+			chanID: other.ID,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		"read channel using expired link": {
+			link:   expired.Token,
+			chanID: ch.ID,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		"read channel using non-existing link": {
+			link:   wrongValue,
+			chanID: ch.ID,
+			err:    things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for desc, tc := range cases {
+		err := svc.CanReadShared(context.Background(), tc.link, tc.chanID)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+	}
+}
+
+func TestRevokeShareLink(t *testing.T) {
+	svc := newService(map[string]string{token: email, wrongValue: "other@example.com"}, things.WithShareLinks(mocks.NewShareLinkRepository(), ""))
+	ch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	link, err := svc.CreateShareLink(context.Background(), token, ch.ID, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc      string
+		token     string
+		chanID    string
+		linkToken string
+		err       error
+		readErr   error
+	}{
+		{
+			desc:      "revoke link as other user",
+			token:     wrongValue,
+			chanID:    ch.ID,
+			linkToken: link.Token,
+			err:       things.ErrNotFound,
+			readErr:   nil,
+		},
+		{
+			desc:      "revoke link of other channel",
+			token:     token,
+			chanID:    wrongID,
+			linkToken: link.Token,
+			err:       things.ErrNotFound,
+			readErr:   nil,
+		},
+		{
+			desc:      "revoke unknown link",
+			token:     token,
+			chanID:    ch.ID,
+			linkToken: wrongValue,
+			err:       things.ErrNotFound,
+			readErr:   nil,
+		},
+		{
+			desc:      "revoke link",
+			token:     token,
+			chanID:    ch.ID,
+			linkToken: link.Token,
+			err:       nil,
+			readErr:   things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:      "revoke already revoked link",
+			token:     token,
+			chanID:    ch.ID,
+			linkToken: link.Token,
+			err:       things.ErrNotFound,
+			readErr:   things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.RevokeShareLink(context.Background(), tc.token, tc.chanID, tc.linkToken)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		err = svc.CanReadShared(context.Background(), link.Token, ch.ID)
+		assert.Equal(t, tc.readErr, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.readErr, err))
+	}
+
+	err = svc.RevokeShareLink(context.Background(), "", ch.ID, link.Token)
+	assert.Equal(t, things.ErrUnauthorizedAccess, err, fmt.Sprintf("expected %s got %s\n", things.ErrUnauthorizedAccess, err))
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+	disabled := newService(map[string]string{token: email})
+
+	key, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:*"}, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		svc    things.Service
+		token  string
+		scopes []string
+		ttl    time.Duration
+		err    error
+	}{
+		{
+			desc:   "issue key",
+			svc:    svc,
+			token:  token,
+			scopes: []string{"thing:read", "channel:*"},
+			ttl:    time.Hour,
+			err:    nil,
+		},
+		{
+			desc:   "issue non-expiring key",
+			svc:    svc,
+			token:  token,
+			scopes: []string{"*:*"},
+			ttl:    0,
+			err:    nil,
+		},
+		{
+			desc:   "issue key without scopes",
+			svc:    svc,
+			token:  token,
+			scopes: []string{},
+			ttl:    time.Hour,
+			err:    things.ErrMalformedEntity,
+		},
+		{
+			desc:   "issue key with unknown action",
+			svc:    svc,
+			token:  token,
+			scopes: []string{"thing:destroy"},
+			ttl:    time.Hour,
+			err:    things.ErrMalformedEntity,
+		},
+		{
+			desc:   "issue key with malformed scope",
+			svc:    svc,
+			token:  token,
+			scopes: []string{"thing"},
+			ttl:    time.Hour,
+			err:    things.ErrMalformedEntity,
+		},
+		{
+			desc:   "issue key with negative ttl",
+			svc:    svc,
+			token:  token,
+			scopes: []string{"thing:read"},
+			ttl:    -time.Hour,
+			err:    things.ErrMalformedEntity,
+		},
+		{
+			desc:   "issue key with wrong credentials",
+			svc:    svc,
+			token:  wrongValue,
+			scopes: []string{"thing:read"},
+			ttl:    time.Hour,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:   "issue key using API key",
+			svc:    svc,
+			token:  key.Key,
+			scopes: []string{"thing:read"},
+			ttl:    time.Hour,
+			err:    things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:   "issue key with API keys disabled",
+			svc:    disabled,
+			token:  token,
+			scopes: []string{"thing:read"},
+			ttl:    time.Hour,
+			err:    things.ErrAPIKeysDisabled,
+		},
+	}
+
+	for _, tc := range cases {
+		key, err := tc.svc.IssueAPIKey(context.Background(), tc.token, tc.scopes, tc.ttl)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+		assert.True(t, strings.HasPrefix(key.Key, things.APIKeyPrefix), fmt.Sprintf("%s: expected key prefixed with %s got %s\n", tc.desc, things.APIKeyPrefix, key.Key))
+		assert.Equal(t, email, key.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, email, key.Owner))
+		assert.Equal(t, tc.ttl == 0, key.ExpiresAt.IsZero(), fmt.Sprintf("%s: unexpected expiry %s\n", tc.desc, key.ExpiresAt))
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+
+	th, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	reader, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:read", "thing:list"}, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	channels, err := svc.IssueAPIKey(context.Background(), token, []string{"channel:*"}, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	admin, err := svc.IssueAPIKey(context.Background(), token, []string{"*:*"}, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	expired, err := svc.IssueAPIKey(context.Background(), token, []string{"*:*"}, time.Millisecond)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	time.Sleep(2 * time.Millisecond)
+
+	view := func(key string) error {
+		_, err := svc.ViewThing(context.Background(), key, th.ID)
+		return err
+	}
+	list := func(key string) error {
+		_, err := svc.ListThings(context.Background(), key, 0, 10, "", false)
+		return err
+	}
+	add := func(key string) error {
+		_, err := svc.AddThing(context.Background(), key, thing)
+		return err
+	}
+	create := func(key string) error {
+		_, err := svc.CreateChannel(context.Background(), key, channel)
+		return err
+	}
+
+	cases := []struct {
+		desc string
+		key  string
+		op   func(string) error
+		err  error
+	}{
+		{desc: "view thing with thing read scope", key: reader.Key, op: view, err: nil},
+		{desc: "list things with thing list scope", key: reader.Key, op: list, err: nil},
+		{desc: "add thing without thing create scope", key: reader.Key, op: add, err: things.ErrUnauthorizedAccess},
+		{desc: "create channel without channel scope", key: reader.Key, op: create, err: things.ErrUnauthorizedAccess},
+		{desc: "create channel with channel wildcard scope", key: channels.Key, op: create, err: nil},
+		{desc: "view thing with channel wildcard scope", key: channels.Key, op: view, err: things.ErrUnauthorizedAccess},
+		{desc: "add thing with wildcard scope", key: admin.Key, op: add, err: nil},
+		{desc: "create channel with wildcard scope", key: admin.Key, op: create, err: nil},
+		{desc: "view thing with expired key", key: expired.Key, op: view, err: things.ErrUnauthorizedAccess},
+		{desc: "view thing with unknown key", key: things.APIKeyPrefix + wrongValue, op: view, err: things.ErrUnauthorizedAccess},
+	}
+
+	for _, tc := range cases {
+		err := tc.op(tc.key)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	svc := newService(map[string]string{token: email, wrongValue: "other@example.com"}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+
+	n := 3
+	for i := 0; i < n; i++ {
+		_, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:read"}, time.Hour)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	cases := []struct {
+		desc  string
+		token string
+		size  int
+		err   error
+	}{
+		{
+			desc:  "list keys",
+			token: token,
+			size:  n,
+			err:   nil,
+		},
+		{
+			desc:  "list keys of other user",
+			token: wrongValue,
+			size:  0,
+			err:   nil,
+		},
+		{
+			desc:  "list keys with wrong credentials",
+			token: "",
+			size:  0,
+			err:   things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		keys, err := svc.ListAPIKeys(context.Background(), tc.token)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.size, len(keys), fmt.Sprintf("%s: expected %d keys got %d\n", tc.desc, tc.size, len(keys)))
+		for _, key := range keys {
+			assert.Empty(t, key.Key, fmt.Sprintf("%s: expected key value to be omitted\n", tc.desc))
+		}
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	svc := newService(map[string]string{token: email, wrongValue: "other@example.com"}, things.WithAPIKeys(mocks.NewAPIKeyRepository()))
+
+	key, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:list"}, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		token   string
+		id      string
+		err     error
+		listErr error
+	}{
+		{
+			desc:    "revoke key as other user",
+			token:   wrongValue,
+			id:      key.ID,
+			err:     nil,
+			listErr: nil,
+		},
+		{
+			desc:    "revoke key using API key",
+			token:   key.Key,
+			id:      key.ID,
+			err:     things.ErrUnauthorizedAccess,
+			listErr: nil,
+		},
+		{
+			desc:    "revoke key",
+			token:   token,
+			id:      key.ID,
+			err:     nil,
+			listErr: things.ErrUnauthorizedAccess,
+		},
+		{
+			desc:    "revoke already revoked key",
+			token:   token,
+			id:      key.ID,
+			err:     nil,
+			listErr: things.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.RevokeAPIKey(context.Background(), tc.token, tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		_, err = svc.ListThings(context.Background(), key.Key, 0, 10, "", false)
+		assert.Equal(t, tc.listErr, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.listErr, err))
+	}
+}
+
+func TestAPIKeyStoredHashed(t *testing.T) {
+	keys := mocks.NewAPIKeyRepository()
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(keys))
+
+	key, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:list"}, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = keys.RetrieveByKey(context.Background(), key.Key)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("retrieve key by value: expected %s got %s\n", things.ErrNotFound, err))
+
+	saved, err := keys.RetrieveAll(context.Background(), email)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	require.Len(t, saved, 1, fmt.Sprintf("expected 1 key got %d\n", len(saved)))
+	assert.NotEqual(t, key.Key, saved[0].Key, "expected key value not to be stored\n")
+}
+
+func TestDisableOwnerAPIKey(t *testing.T) {
+	svc := newService(map[string]string{token: email}, things.WithAPIKeys(mocks.NewAPIKeyRepository()), things.WithOwners(mocks.NewOwnerRepository()))
+
+	key, err := svc.IssueAPIKey(context.Background(), token, []string{"thing:list"}, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc string
+		op   func(context.Context, string) error
+		err  error
+	}{
+		{
+			desc: "list things using API key of disabled owner",
+			op:   svc.DisableOwner,
+			err:  things.ErrUnauthorizedAccess,
+		},
+		{
+			desc: "list things using API key of enabled owner",
+			op:   svc.EnableOwner,
+			err:  nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op(context.Background(), email)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		_, err = svc.ListThings(context.Background(), key.Key, 0, 10, "", false)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestChannelHierarchy(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	building, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "building"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	floor, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "floor", ParentID: building.ID})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	room, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "room", ParentID: floor.ID})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: "other"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: "orphan", ParentID: wrongValue})
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("create channel with non-existing parent: expected %s got %s\n", things.ErrNotFound, err))
+
+	view, err := svc.ViewChannel(context.Background(), token, room.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, floor.ID, view.ParentID, fmt.Sprintf("expected parent %s got %s\n", floor.ID, view.ParentID))
+
+	listCases := map[string]struct {
+		parent string
+		ids    []string
+		err    error
+	}{
+		"list subtree of the root channel": {
+			parent: building.ID,
+			ids:    []string{floor.ID, room.ID},
+			err:    nil,
+		},
+		"list subtree of the intermediate channel": {
+			parent: floor.ID,
+			ids:    []string{room.ID},
+			err:    nil,
+		},
+		"list subtree of the leaf channel": {
+			parent: room.ID,
+			ids:    []string{},
+			err:    nil,
+		},
+		"list subtree of non-existing channel": {
+			parent: wrongValue,
+			ids:    nil,
+			err:    things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range listCases {
+		page, err := svc.ListChannels(context.Background(), token, 0, 10, "", tc.parent, false)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		ids := []string{}
+		for _, ch := range page.Channels {
+			ids = append(ids, ch.ID)
+		}
+		assert.Equal(t, tc.ids, ids, fmt.Sprintf("%s: expected %v got %v\n", desc, tc.ids, ids))
+	}
+
+	updateCases := []struct {
+		desc    string
+		channel things.Channel
+		parent  string
+		err     error
+	}{
+		{
+			desc:    "make channel its own parent",
+			channel: things.Channel{ID: floor.ID, ParentID: floor.ID},
+			parent:  building.ID,
+			err:     things.ErrMalformedEntity,
+		},
+		{
+			desc:    "make channel child of its descendant",
+			channel: things.Channel{ID: building.ID, ParentID: room.ID},
+			parent:  "",
+			err:     things.ErrMalformedEntity,
+		},
+		{
+			desc:    "move channel under non-existing parent",
+			channel: things.Channel{ID: room.ID, ParentID: wrongValue},
+			parent:  floor.ID,
+			err:     things.ErrNotFound,
+		},
+		{
+			desc:    "update channel without parent",
+			channel: things.Channel{ID: room.ID, Name: "renamed"},
+			parent:  floor.ID,
+			err:     nil,
+		},
+		{
+			desc:    "move channel under other parent",
+			channel: things.Channel{ID: room.ID, ParentID: building.ID},
+			parent:  building.ID,
+			err:     nil,
+		},
+		{
+			desc:    "move channel to the top level",
+			channel: things.Channel{ID: room.ID, ParentID: things.NoParent},
+			parent:  "",
+			err:     nil,
+		},
+	}
+
+	for _, tc := range updateCases {
+		err := svc.UpdateChannel(context.Background(), token, tc.channel)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		ch, err := svc.ViewChannel(context.Background(), token, tc.channel.ID)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		assert.Equal(t, tc.parent, ch.ParentID, fmt.Sprintf("%s: expected parent %s got %s\n", tc.desc, tc.parent, ch.ParentID))
+	}
+}
+
+func TestChannelHierarchyCycle(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{token: email})
+	conns := make(chan mocks.Connection)
+	thingsRepo := mocks.NewThingRepository(conns)
+	channelsRepo := mocks.NewChannelRepository(thingsRepo, conns)
+	svc := things.New(users, thingsRepo, channelsRepo, mocks.NewChannelCache(), mocks.NewThingCache(), mocks.NewIdentityProvider())
+
+	first, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "first"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	second, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "second", ParentID: first.ID})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	other, err := svc.CreateChannel(context.Background(), token, things.Channel{Name: "other"})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	// Cycle can be stored only by the concurrent updates, which is
+	// simulated by updating the repository directly.
+	first.ParentID = second.ID
+	err = channelsRepo.Update(context.Background(), first)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.UpdateChannel(context.Background(), token, things.Channel{ID: other.ID, ParentID: second.ID})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("move channel under cycle: expected %s got %s\n", things.ErrMalformedEntity, err))
+
+	_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: "child", ParentID: first.ID})
+	assert.Equal(t, things.ErrMalformedEntity, err, fmt.Sprintf("create channel under cycle: expected %s got %s\n", things.ErrMalformedEntity, err))
+
+	err = svc.UpdateChannel(context.Background(), token, things.Channel{ID: first.ID, ParentID: things.NoParent})
+	assert.Nil(t, err, fmt.Sprintf("break cycle: unexpected error: %s\n", err))
+}
+
+type thingRepositoryRecorder struct {
+	things.ThingRepository
+	ids []string
+}
+
+func (trr *thingRepositoryRecorder) Save(ctx context.Context, thing things.Thing) (string, error) {
+	trr.ids = append(trr.ids, thing.ID)
+	return trr.ThingRepository.Save(ctx, thing)
+}
+
+type channelRepositoryRecorder struct {
+	things.ChannelRepository
+	ids []string
+}
+
+func (crr *channelRepositoryRecorder) Save(ctx context.Context, channel things.Channel) (string, error) {
+	crr.ids = append(crr.ids, channel.ID)
+	return crr.ChannelRepository.Save(ctx, channel)
+}
+
+func TestIDPrefix(t *testing.T) {
+	prefix := "prod-"
+	users := mocks.NewUsersService(map[string]string{token: email})
+	conns := make(chan mocks.Connection)
+	thingsRepo := mocks.NewThingRepository(conns)
+	channelsRepo := mocks.NewChannelRepository(thingsRepo, conns)
+	tr := &thingRepositoryRecorder{ThingRepository: thingsRepo}
+	cr := &channelRepositoryRecorder{ChannelRepository: channelsRepo}
+	svc := things.New(users, tr, cr, mocks.NewChannelCache(), mocks.NewThingCache(), mocks.NewIdentityProvider(), things.WithIDPrefix(prefix))
+
+	th, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	require.Len(t, tr.ids, 1, "expected thing to be saved")
+	require.Len(t, cr.ids, 1, "expected channel to be saved")
+	assert.True(t, strings.HasPrefix(tr.ids[0], prefix), fmt.Sprintf("expected thing ID %s to start with %s", tr.ids[0], prefix))
+	assert.True(t, strings.HasPrefix(cr.ids[0], prefix), fmt.Sprintf("expected channel ID %s to start with %s", cr.ids[0], prefix))
+	assert.False(t, strings.HasPrefix(th.Key, prefix), fmt.Sprintf("expected thing key %s not to be prefixed", th.Key))
+
+	err = svc.Connect(context.Background(), token, ch.ID, th.ID, 0)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	id, err := svc.Identify(context.Background(), th.Key)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, th.ID, id, fmt.Sprintf("expected identified thing %s got %s", th.ID, id))
+
+	id, err = svc.CanAccess(context.Background(), ch.ID, th.Key)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, th.ID, id, fmt.Sprintf("expected thing %s to access channel, got %s", th.ID, id))
+}
+
+func TestValidIDPrefix(t *testing.T) {
+	cases := map[string]struct {
+		prefix string
+		valid  bool
+	}{
+		"empty prefix":                    {prefix: "", valid: true},
+		"environment prefix":              {prefix: "prod-", valid: true},
+		"prefix with underscore":          {prefix: "staging_eu_", valid: true},
+		"prefix with URL unsafe chars":    {prefix: "prod/", valid: false},
+		"prefix with cache key separator": {prefix: "prod:", valid: false},
+		"too long prefix":                 {prefix: strings.Repeat("a", 65), valid: false},
+	}
+
+	for desc, tc := range cases {
+		valid := things.ValidIDPrefix(tc.prefix)
+		assert.Equal(t, tc.valid, valid, fmt.Sprintf("%s: expected %t got %t", desc, tc.valid, valid))
+	}
+}
+
+func TestMaxNameLength(t *testing.T) {
+	maxLen := 10
+	svc := newService(map[string]string{token: email}, things.WithMaxNameLength(maxLen))
+	savedThing, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	savedChannel, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := map[string]struct {
+		name string
+		err  error
+	}{
+		"name at the limit": {
+			name: strings.Repeat("a", maxLen),
+			err:  nil,
+		},
+		"name over the limit": {
+			name: strings.Repeat("a", maxLen+1),
+			err:  things.ErrMalformedEntity,
+		},
+		"multibyte name at the limit": {
+			name: strings.Repeat("ž", maxLen),
+			err:  nil,
+		},
+		"multibyte name over the limit": {
+			name: strings.Repeat("日", maxLen+1),
+			err:  things.ErrMalformedEntity,
+		},
+	}
+
+	for desc, tc := range cases {
+		_, err := svc.AddThing(context.Background(), token, things.Thing{Name: tc.name})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: add thing: expected %s got %s\n", desc, tc.err, err))
+
+		th := savedThing
+		th.Name = tc.name
+		err = svc.UpdateThing(context.Background(), token, th)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: update thing: expected %s got %s\n", desc, tc.err, err))
+
+		_, err = svc.CreateChannel(context.Background(), token, things.Channel{Name: tc.name})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: create channel: expected %s got %s\n", desc, tc.err, err))
+
+		ch := savedChannel
+		ch.Name = tc.name
+		err = svc.UpdateChannel(context.Background(), token, ch)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: update channel: expected %s got %s\n", desc, tc.err, err))
+	}
+}
+
+func TestValidMaxNameLength(t *testing.T) {
+	cases := map[string]struct {
+		length int
+		valid  bool
+	}{
+		"default length":      {length: things.MaxNameLength, valid: true},
+		"short length":        {length: 64, valid: true},
+		"zero length":         {length: 0, valid: false},
+		"negative length":     {length: -1, valid: false},
+		"length over the max": {length: things.MaxNameLength + 1, valid: false},
+	}
+
+	for desc, tc := range cases {
+		valid := things.ValidMaxNameLength(tc.length)
+		assert.Equal(t, tc.valid, valid, fmt.Sprintf("%s: expected %t got %t", desc, tc.valid, valid))
+	}
+}
+
+// policyAuthorizer denies the listed actions of the listed resource types and
+// records the subject of every authorized request.
+type policyAuthorizer struct {
+	denied   map[string]string
+	subjects []string
+}
+
+func (pa *policyAuthorizer) Authorize(_ context.Context, subject, action string, res things.Resource) error {
+	pa.subjects = append(pa.subjects, subject)
+	if pa.denied[action] == res.Type {
+		return things.ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+func TestAuthorizer(t *testing.T) {
+	auth := &policyAuthorizer{
+		denied: map[string]string{
+			things.DeleteAction:  things.ThingResource,
+			things.ConnectAction: things.ChannelResource,
+			things.ListAction:    things.ChannelResource,
+		},
+	}
+	svc := newService(map[string]string{token: email}, things.WithAuthorizer(auth))
+
+	th, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch, err := svc.CreateChannel(context.Background(), token, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc string
+		op   func() error
+		err  error
+	}{
+		{
+			desc: "view allowed thing",
+			op: func() error {
+				_, err := svc.ViewThing(context.Background(), token, th.ID)
+				return err
+			},
+			err: nil,
+		},
+		{
+			desc: "list allowed things",
+			op: func() error {
+				_, err := svc.ListThings(context.Background(), token, 0, 10, "", false)
+				return err
+			},
+			err: nil,
+		},
+		{
+			desc: "update allowed channel",
+			op: func() error {
+				return svc.UpdateChannel(context.Background(), token, ch)
+			},
+			err: nil,
+		},
+		{
+			desc: "remove denied thing",
+			op: func() error {
+				return svc.RemoveThing(context.Background(), token, th.ID)
+			},
+			err: things.ErrUnauthorizedAccess,
+		},
+		{
+			desc: "list denied channels",
+			op: func() error {
+				_, err := svc.ListChannels(context.Background(), token, 0, 10, "", "", false)
+				return err
+			},
+			err: things.ErrUnauthorizedAccess,
+		},
+		{
+			desc: "connect denied channel",
+			op: func() error {
+				return svc.Connect(context.Background(), token, ch.ID, th.ID, 0)
+			},
+			err: things.ErrUnauthorizedAccess,
+		},
+		{
+			desc: "disconnect denied channel",
+			op: func() error {
+				return svc.Disconnect(context.Background(), token, ch.ID, th.ID)
+			},
+			err: things.ErrUnauthorizedAccess,
+		},
+		{
+			desc: "remove allowed channel",
+			op: func() error {
+				return svc.RemoveChannel(context.Background(), token, ch.ID, false)
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.op()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	for _, subject := range auth.subjects {
+		assert.Equal(t, email, subject, fmt.Sprintf("expected subject %s got %s\n", email, subject))
+	}
+
+	_, err = svc.ViewThing(context.Background(), token, th.ID)
+	assert.Nil(t, err, fmt.Sprintf("view thing after denied removal: unexpected error: %s", err))
+}
+
+// sharingAuthorizer allows every action and records the authorized resources.
+type sharingAuthorizer struct {
+	resources []things.Resource
+}
+
+func (sa *sharingAuthorizer) Authorize(_ context.Context, _, _ string, res things.Resource) error {
+	sa.resources = append(sa.resources, res)
+	return nil
+}
+
+func TestResourceOwner(t *testing.T) {
+	otherToken := "other-token"
+	otherEmail := "other@example.com"
+	tokens := map[string]string{token: email, otherToken: otherEmail}
+
+	svc := newService(tokens)
+	th, err := svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch, err := svc.CreateChannel(context.Background(), otherToken, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	_, err = svc.ViewThing(context.Background(), otherToken, th.ID)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("view thing of other user: expected %s got %s\n", things.ErrNotFound, err))
+
+	err = svc.Connect(context.Background(), otherToken, ch.ID, th.ID, 0)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("connect thing of other user: expected %s got %s\n", things.ErrNotFound, err))
+
+	err = svc.Disconnect(context.Background(), otherToken, ch.ID, th.ID)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("disconnect thing of other user: expected %s got %s\n", things.ErrNotFound, err))
+
+	auth := &sharingAuthorizer{}
+	svc = newService(tokens, things.WithAuthorizer(auth))
+	th, err = svc.AddThing(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch, err = svc.CreateChannel(context.Background(), otherToken, channel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	auth.resources = nil
+	res, err := svc.ViewThing(context.Background(), otherToken, th.ID)
+	assert.Nil(t, err, fmt.Sprintf("view shared thing: unexpected error: %s", err))
+	assert.Equal(t, th.ID, res.ID, fmt.Sprintf("view shared thing: expected %s got %s\n", th.ID, res.ID))
+	require.Len(t, auth.resources, 1)
+	assert.Equal(t, email, auth.resources[0].Owner, fmt.Sprintf("expected resource owner %s got %s\n", email, auth.resources[0].Owner))
+
+	err = svc.Connect(context.Background(), otherToken, ch.ID, th.ID, 0)
+	assert.Equal(t, things.ErrNotFound, err, fmt.Sprintf("connect entities of different owners: expected %s got %s\n", things.ErrNotFound, err))
+}
@@ -7,16 +7,28 @@
 
 package things
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Thing represents a Mainflux thing. Each thing is owned by one user, and
 // it is assigned with the unique identifier and (temporary) access key.
+// Zero KeyExpiry value indicates that the key never expires. LastSeen is the
+// time the thing was created or last accessed a channel at. Connections is
+// the number of channels the thing is connected to and it is populated only
+// when a single thing is viewed.
 type Thing struct {
-	ID       string
-	Owner    string
-	Name     string
-	Key      string
-	Metadata map[string]interface{}
+	ID          string
+	Owner       string
+	Name        string
+	Key         string
+	KeyExpiry   time.Time
+	LastSeen    time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Metadata    map[string]interface{}
+	Connections uint64
 }
 
 // ThingsPage contains page related metadata as well as list of things that
@@ -26,6 +38,13 @@ type ThingsPage struct {
 	Things []Thing
 }
 
+// BulkResult is the result of adding a single thing of the bulk. It contains
+// either the added thing or the error the thing failed to be added with.
+type BulkResult struct {
+	Thing Thing
+	Err   error
+}
+
 // ThingRepository specifies a thing persistence API.
 type ThingRepository interface {
 	// Save persists the thing. Successful operation is indicated by non-nil
@@ -36,24 +55,70 @@ type ThingRepository interface {
 	// returned to indicate operation failure.
 	Update(context.Context, Thing) error
 
-	// UpdateKey updates key value of the existing thing. A non-nil error is
-	// returned to indicate operation failure.
-	UpdateKey(context.Context, string, string, string) error
+	// UpdateKey updates key value and key expiry time of the existing thing.
+	// A non-nil error is returned to indicate operation failure.
+	UpdateKey(context.Context, string, string, string, time.Time) error
+
+	// RotateKey replaces the key of the given thing with the new key and key
+	// expiry time, provided that the thing still holds the given thing key.
+	// Concurrent rotations of the same key thus succeed only once, while the
+	// others return ErrNotFound.
+	RotateKey(context.Context, Thing, string, time.Time) error
 
 	// RetrieveByID retrieves the thing having the provided identifier, that is owned
 	// by the specified user.
 	RetrieveByID(context.Context, string, string) (Thing, error)
 
-	// RetrieveByKey returns thing ID for given thing key.
-	RetrieveByKey(context.Context, string) (string, error)
+	// RetrieveOwner retrieves the ID of the owner of the thing having the
+	// provided identifier.
+	RetrieveOwner(context.Context, string) (string, error)
+
+	// RetrieveByKey retrieves the thing identified by the given thing key.
+	RetrieveByKey(context.Context, string) (Thing, error)
+
+	// RetrieveByKeys retrieves the things identified by the given thing
+	// keys. Keys that don't identify any thing are skipped.
+	RetrieveByKeys(context.Context, []string) ([]Thing, error)
+
+	// RetrieveExpired retrieves at most the given number of things whose keys
+	// expired before the given time, the longest expired first.
+	RetrieveExpired(context.Context, time.Time, uint64) ([]Thing, error)
+
+	// NameExists returns true if the specified user owns a thing with the
+	// given name.
+	NameExists(context.Context, string, string) (bool, error)
 
 	// RetrieveAll retrieves the subset of things owned by the specified user.
-	RetrieveAll(context.Context, string, uint64, uint64, string) (ThingsPage, error)
+	// Compact retrieval populates only the IDs and the names of the things.
+	RetrieveAll(context.Context, string, uint64, uint64, string, bool) (ThingsPage, error)
 
 	// RetrieveByChannel retrieves the subset of things owned by the specified
-	// user and connected to specified channel.
+	// user and connected to specified channel. Members of the dynamic channel
+	// are the things matching its membership query. Things whose connections
+	// expired are omitted, here and in the rest of the repository.
 	RetrieveByChannel(context.Context, string, string, uint64, uint64) (ThingsPage, error)
 
+	// RetrieveByChannelAfter retrieves up to the limit of things owned by the
+	// specified user and connected to specified channel, whose IDs follow the
+	// given cursor. Things are ordered by ID, and the empty cursor starts from
+	// the first thing. Members of the dynamic channel are the things matching
+	// its membership query.
+	RetrieveByChannelAfter(context.Context, string, string, string, uint64) ([]Thing, error)
+
+	// RetrieveByChannels retrieves the subset of things owned by the
+	// specified user and connected to any of the specified channels. Things
+	// connected to several of the channels are retrieved only once.
+	RetrieveByChannels(context.Context, string, []string, uint64, uint64) (ThingsPage, error)
+
+	// RetrieveIdle retrieves all things owned by the specified user and
+	// connected to the specified channel, that were last seen before the
+	// given time.
+	RetrieveIdle(context.Context, string, string, time.Time) ([]Thing, error)
+
+	// UpdateLastSeen updates the time the thing identified by the provided
+	// ID was last seen at.
+	UpdateLastSeen(context.Context, string, time.Time) error
+
 	// Remove removes the thing having the provided identifier, that is owned
 	// by the specified user.
 	Remove(context.Context, string, string) error
@@ -0,0 +1,39 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package things
+
+import "time"
+
+// ReadScope allows reading channel messages.
+const ReadScope = "read"
+
+// ChannelTokenPrefix prefixes the issued channel tokens, which distinguishes
+// them from the thing keys.
+const ChannelTokenPrefix = "mfc_"
+
+// ChannelToken grants access to a single channel until it expires,
+// independently of thing keys. Token carries the version of the channel
+// tokens it was issued with, so the tokens issued before the channel tokens
+// are revoked are rejected.
+type ChannelToken struct {
+	ChanID    string
+	Scope     string
+	Version   uint64
+	ExpiresAt time.Time
+}
+
+// ChannelTokenizer specifies an API for issuing and validating signed
+// channel tokens.
+type ChannelTokenizer interface {
+	// Issue produces signed representation of the channel token.
+	Issue(ChannelToken) (string, error)
+
+	// Parse validates the signed token and returns the channel token it
+	// represents. Invalid and expired tokens are rejected.
+	Parse(string) (ChannelToken, error)
+}
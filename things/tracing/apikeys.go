@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux/things"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	saveAPIKeyOp          = "save_api_key"
+	retrieveAPIKeyByKeyOp = "retrieve_api_key_by_key"
+	retrieveAPIKeysOp     = "retrieve_api_keys"
+	removeAPIKeyOp        = "remove_api_key"
+)
+
+var _ things.APIKeyRepository = (*apiKeyRepositoryMiddleware)(nil)
+
+type apiKeyRepositoryMiddleware struct {
+	tracer opentracing.Tracer
+	repo   things.APIKeyRepository
+}
+
+// APIKeyRepositoryMiddleware tracks request and their latency, and adds
+// spans to context.
+func APIKeyRepositoryMiddleware(tracer opentracing.Tracer, repo things.APIKeyRepository) things.APIKeyRepository {
+	return apiKeyRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (akrm apiKeyRepositoryMiddleware) Save(ctx context.Context, key things.APIKey) error {
+	span := createSpan(ctx, akrm.tracer, saveAPIKeyOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return akrm.repo.Save(ctx, key)
+}
+
+func (akrm apiKeyRepositoryMiddleware) RetrieveByKey(ctx context.Context, value string) (things.APIKey, error) {
+	span := createSpan(ctx, akrm.tracer, retrieveAPIKeyByKeyOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return akrm.repo.RetrieveByKey(ctx, value)
+}
+
+func (akrm apiKeyRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string) ([]things.APIKey, error) {
+	span := createSpan(ctx, akrm.tracer, retrieveAPIKeysOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return akrm.repo.RetrieveAll(ctx, owner)
+}
+
+func (akrm apiKeyRepositoryMiddleware) Remove(ctx context.Context, owner, id string) error {
+	span := createSpan(ctx, akrm.tracer, removeAPIKeyOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return akrm.repo.Remove(ctx, owner, id)
+}
@@ -9,6 +9,7 @@ package tracing
 
 import (
 	"context"
+	"time"
 
 	"github.com/mainflux/mainflux/things"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -18,13 +19,20 @@ const (
 	saveChannelOp             = "save_channel"
 	updateChannelOp           = "update_channel"
 	retrieveChannelByIDOp     = "retrieve_channel_by_id"
+	retrieveChannelOwnerOp    = "retrieve_channel_owner"
+	retrieveTokenVersionOp    = "retrieve_channel_token_version"
+	revokeChannelTokensOp     = "revoke_channel_tokens"
 	retrieveAllChannelsOp     = "retrieve_all_channels"
 	retrieveChannelsByThingOp = "retrieve_channels_by_thing"
+	retrieveDynamicChannelsOp = "retrieve_dynamic_channels"
 	removeChannelOp           = "retrieve_channel"
 	connectOp                 = "connect"
 	disconnectOp              = "disconnect"
+	removeExpiredOp           = "remove_expired_connections"
 	hasThingOp                = "has_thing"
 	hasThingByIDOp            = "has_thing_by_id"
+	countThingsOp             = "count_things"
+	countChannelsOp           = "count_channels"
 )
 
 var (
@@ -70,12 +78,36 @@ func (crm channelRepositoryMiddleware) RetrieveByID(ctx context.Context, owner,
 	return crm.repo.RetrieveByID(ctx, owner, id)
 }
 
-func (crm channelRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string) (things.ChannelsPage, error) {
+func (crm channelRepositoryMiddleware) RetrieveOwner(ctx context.Context, id string) (string, error) {
+	span := createSpan(ctx, crm.tracer, retrieveChannelOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.RetrieveOwner(ctx, id)
+}
+
+func (crm channelRepositoryMiddleware) RetrieveTokenVersion(ctx context.Context, id string) (uint64, error) {
+	span := createSpan(ctx, crm.tracer, retrieveTokenVersionOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.RetrieveTokenVersion(ctx, id)
+}
+
+func (crm channelRepositoryMiddleware) RevokeTokens(ctx context.Context, owner, id string) error {
+	span := createSpan(ctx, crm.tracer, revokeChannelTokensOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.RevokeTokens(ctx, owner, id)
+}
+
+func (crm channelRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name, parent string, compact bool) (things.ChannelsPage, error) {
 	span := createSpan(ctx, crm.tracer, retrieveAllChannelsOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
 
-	return crm.repo.RetrieveAll(ctx, owner, offset, limit, name)
+	return crm.repo.RetrieveAll(ctx, owner, offset, limit, name, parent, compact)
 }
 
 func (crm channelRepositoryMiddleware) RetrieveByThing(ctx context.Context, owner, thing string, offset, limit uint64) (things.ChannelsPage, error) {
@@ -86,6 +118,14 @@ func (crm channelRepositoryMiddleware) RetrieveByThing(ctx context.Context, owne
 	return crm.repo.RetrieveByThing(ctx, owner, thing, offset, limit)
 }
 
+func (crm channelRepositoryMiddleware) RetrieveDynamic(ctx context.Context, owner string) ([]things.Channel, error) {
+	span := createSpan(ctx, crm.tracer, retrieveDynamicChannelsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.RetrieveDynamic(ctx, owner)
+}
+
 func (crm channelRepositoryMiddleware) Remove(ctx context.Context, owner, id string) error {
 	span := createSpan(ctx, crm.tracer, removeChannelOp)
 	defer span.Finish()
@@ -94,12 +134,12 @@ func (crm channelRepositoryMiddleware) Remove(ctx context.Context, owner, id str
 	return crm.repo.Remove(ctx, owner, id)
 }
 
-func (crm channelRepositoryMiddleware) Connect(ctx context.Context, owner, chanID, thingID string) error {
+func (crm channelRepositoryMiddleware) Connect(ctx context.Context, owner, chanID, thingID string, expiresAt time.Time) error {
 	span := createSpan(ctx, crm.tracer, connectOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
 
-	return crm.repo.Connect(ctx, owner, chanID, thingID)
+	return crm.repo.Connect(ctx, owner, chanID, thingID, expiresAt)
 }
 
 func (crm channelRepositoryMiddleware) Disconnect(ctx context.Context, owner, chanID, thingID string) error {
@@ -110,6 +150,14 @@ func (crm channelRepositoryMiddleware) Disconnect(ctx context.Context, owner, ch
 	return crm.repo.Disconnect(ctx, owner, chanID, thingID)
 }
 
+func (crm channelRepositoryMiddleware) RemoveExpired(ctx context.Context, t time.Time) ([]things.Connection, error) {
+	span := createSpan(ctx, crm.tracer, removeExpiredOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.RemoveExpired(ctx, t)
+}
+
 func (crm channelRepositoryMiddleware) HasThing(ctx context.Context, chanID, key string) (string, error) {
 	span := createSpan(ctx, crm.tracer, hasThingOp)
 	defer span.Finish()
@@ -118,7 +166,7 @@ func (crm channelRepositoryMiddleware) HasThing(ctx context.Context, chanID, key
 	return crm.repo.HasThing(ctx, chanID, key)
 }
 
-func (crm channelRepositoryMiddleware) HasThingByID(ctx context.Context, chanID, thingID string) error {
+func (crm channelRepositoryMiddleware) HasThingByID(ctx context.Context, chanID, thingID string) (time.Time, error) {
 	span := createSpan(ctx, crm.tracer, hasThingByIDOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
@@ -126,6 +174,22 @@ func (crm channelRepositoryMiddleware) HasThingByID(ctx context.Context, chanID,
 	return crm.repo.HasThingByID(ctx, chanID, thingID)
 }
 
+func (crm channelRepositoryMiddleware) CountThings(ctx context.Context, owner, chanID string) (uint64, error) {
+	span := createSpan(ctx, crm.tracer, countThingsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.CountThings(ctx, owner, chanID)
+}
+
+func (crm channelRepositoryMiddleware) CountChannels(ctx context.Context, owner, thingID string) (uint64, error) {
+	span := createSpan(ctx, crm.tracer, countChannelsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return crm.repo.CountChannels(ctx, owner, thingID)
+}
+
 type channelCacheMiddleware struct {
 	tracer opentracing.Tracer
 	cache  things.ChannelCache
@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux/things"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	saveShareLinkOp            = "save_share_link"
+	retrieveShareLinkByTokenOp = "retrieve_share_link_by_token"
+	removeShareLinkOp          = "remove_share_link"
+)
+
+var _ things.ShareLinkRepository = (*shareLinkRepositoryMiddleware)(nil)
+
+type shareLinkRepositoryMiddleware struct {
+	tracer opentracing.Tracer
+	repo   things.ShareLinkRepository
+}
+
+// ShareLinkRepositoryMiddleware tracks request and their latency, and adds
+// spans to context.
+func ShareLinkRepositoryMiddleware(tracer opentracing.Tracer, repo things.ShareLinkRepository) things.ShareLinkRepository {
+	return shareLinkRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (slrm shareLinkRepositoryMiddleware) Save(ctx context.Context, link things.ShareLink) error {
+	span := createSpan(ctx, slrm.tracer, saveShareLinkOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return slrm.repo.Save(ctx, link)
+}
+
+func (slrm shareLinkRepositoryMiddleware) RetrieveByToken(ctx context.Context, token string) (things.ShareLink, error) {
+	span := createSpan(ctx, slrm.tracer, retrieveShareLinkByTokenOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return slrm.repo.RetrieveByToken(ctx, token)
+}
+
+func (slrm shareLinkRepositoryMiddleware) Remove(ctx context.Context, owner, chanID, token string) error {
+	span := createSpan(ctx, slrm.tracer, removeShareLinkOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return slrm.repo.Remove(ctx, owner, chanID, token)
+}
@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/mainflux/mainflux/things"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	saveOutboxEventOp       = "save_outbox_event"
+	retrieveDueEventsOp     = "retrieve_due_outbox_events"
+	retryOutboxEventOp      = "retry_outbox_event"
+	markEventDeliveredOp    = "mark_outbox_event_delivered"
+	removeDeliveredEventsOp = "remove_delivered_outbox_events"
+)
+
+var _ things.OutboxRepository = (*outboxRepositoryMiddleware)(nil)
+
+type outboxRepositoryMiddleware struct {
+	tracer opentracing.Tracer
+	repo   things.OutboxRepository
+}
+
+// OutboxRepositoryMiddleware tracks request and their latency, and adds
+// spans to context.
+func OutboxRepositoryMiddleware(tracer opentracing.Tracer, repo things.OutboxRepository) things.OutboxRepository {
+	return outboxRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (orm outboxRepositoryMiddleware) Save(ctx context.Context, event things.OutboxEvent) error {
+	span := createSpan(ctx, orm.tracer, saveOutboxEventOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Save(ctx, event)
+}
+
+func (orm outboxRepositoryMiddleware) RetrieveDue(ctx context.Context, now time.Time, limit uint64) ([]things.OutboxEvent, error) {
+	span := createSpan(ctx, orm.tracer, retrieveDueEventsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.RetrieveDue(ctx, now, limit)
+}
+
+func (orm outboxRepositoryMiddleware) Retry(ctx context.Context, id uint64, next time.Time) error {
+	span := createSpan(ctx, orm.tracer, retryOutboxEventOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Retry(ctx, id, next)
+}
+
+func (orm outboxRepositoryMiddleware) MarkDelivered(ctx context.Context, id uint64, at time.Time) error {
+	span := createSpan(ctx, orm.tracer, markEventDeliveredOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.MarkDelivered(ctx, id, at)
+}
+
+func (orm outboxRepositoryMiddleware) RemoveDelivered(ctx context.Context, before time.Time) error {
+	span := createSpan(ctx, orm.tracer, removeDeliveredEventsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.RemoveDelivered(ctx, before)
+}
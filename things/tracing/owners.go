@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux/things"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	retrieveOwnerOp = "retrieve_owner_by_email"
+	resolveOwnerOp  = "resolve_owner"
+	changeEmailOp   = "change_owner_email"
+	disableOwnerOp  = "disable_owner"
+	enableOwnerOp   = "enable_owner"
+	ownerDisabledOp = "owner_disabled"
+)
+
+var _ things.OwnerRepository = (*ownerRepositoryMiddleware)(nil)
+
+type ownerRepositoryMiddleware struct {
+	tracer opentracing.Tracer
+	repo   things.OwnerRepository
+}
+
+// OwnerRepositoryMiddleware tracks request and their latency, and adds spans
+// to context.
+func OwnerRepositoryMiddleware(tracer opentracing.Tracer, repo things.OwnerRepository) things.OwnerRepository {
+	return ownerRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (orm ownerRepositoryMiddleware) RetrieveByEmail(ctx context.Context, email string) (string, error) {
+	span := createSpan(ctx, orm.tracer, retrieveOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.RetrieveByEmail(ctx, email)
+}
+
+func (orm ownerRepositoryMiddleware) Resolve(ctx context.Context, email, id string) (string, error) {
+	span := createSpan(ctx, orm.tracer, resolveOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Resolve(ctx, email, id)
+}
+
+func (orm ownerRepositoryMiddleware) ChangeEmail(ctx context.Context, email, newEmail string) error {
+	span := createSpan(ctx, orm.tracer, changeEmailOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.ChangeEmail(ctx, email, newEmail)
+}
+
+func (orm ownerRepositoryMiddleware) Disable(ctx context.Context, owner string) error {
+	span := createSpan(ctx, orm.tracer, disableOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Disable(ctx, owner)
+}
+
+func (orm ownerRepositoryMiddleware) Enable(ctx context.Context, owner string) error {
+	span := createSpan(ctx, orm.tracer, enableOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Enable(ctx, owner)
+}
+
+func (orm ownerRepositoryMiddleware) Disabled(ctx context.Context, owner string) (bool, error) {
+	span := createSpan(ctx, orm.tracer, ownerDisabledOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return orm.repo.Disabled(ctx, owner)
+}
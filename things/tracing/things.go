@@ -9,6 +9,7 @@ package tracing
 
 import (
 	"context"
+	"time"
 
 	"github.com/mainflux/mainflux/things"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -18,10 +19,19 @@ const (
 	saveThingOp               = "save_thing"
 	updateThingOp             = "update_thing"
 	updateThingKeyOp          = "update_thing_by_key"
+	rotateThingKeyOp          = "rotate_thing_key"
 	retrieveThingByIDOp       = "retrieve_thing_by_id"
+	retrieveThingOwnerOp      = "retrieve_thing_owner"
 	retrieveThingByKeyOp      = "retrieve_thing_by_key"
+	retrieveThingsByKeysOp    = "retrieve_things_by_keys"
+	retrieveIdleThingsOp      = "retrieve_idle_things"
+	updateLastSeenOp          = "update_last_seen"
+	retrieveExpiredThingsOp   = "retrieve_expired_things"
+	thingNameExistsOp         = "thing_name_exists"
 	retrieveAllThingsOp       = "retrieve_all_things"
 	retrieveThingsByChannelOp = "retrieve_things_by_chan"
+	retrieveThingsAfterOp     = "retrieve_things_by_chan_after"
+	retrieveThingsByChansOp   = "retrieve_things_by_chans"
 	removeThingOp             = "remove_thing"
 	retrieveThingIDByKeyOp    = "retrieve_id_by_key"
 )
@@ -61,12 +71,20 @@ func (trm thingRepositoryMiddleware) Update(ctx context.Context, th things.Thing
 	return trm.repo.Update(ctx, th)
 }
 
-func (trm thingRepositoryMiddleware) UpdateKey(ctx context.Context, owner, id, key string) error {
+func (trm thingRepositoryMiddleware) UpdateKey(ctx context.Context, owner, id, key string, expiry time.Time) error {
 	span := createSpan(ctx, trm.tracer, updateThingKeyOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
 
-	return trm.repo.UpdateKey(ctx, owner, id, key)
+	return trm.repo.UpdateKey(ctx, owner, id, key, expiry)
+}
+
+func (trm thingRepositoryMiddleware) RotateKey(ctx context.Context, thing things.Thing, key string, expiry time.Time) error {
+	span := createSpan(ctx, trm.tracer, rotateThingKeyOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RotateKey(ctx, thing, key, expiry)
 }
 
 func (trm thingRepositoryMiddleware) RetrieveByID(ctx context.Context, owner, id string) (things.Thing, error) {
@@ -77,7 +95,15 @@ func (trm thingRepositoryMiddleware) RetrieveByID(ctx context.Context, owner, id
 	return trm.repo.RetrieveByID(ctx, owner, id)
 }
 
-func (trm thingRepositoryMiddleware) RetrieveByKey(ctx context.Context, key string) (string, error) {
+func (trm thingRepositoryMiddleware) RetrieveOwner(ctx context.Context, id string) (string, error) {
+	span := createSpan(ctx, trm.tracer, retrieveThingOwnerOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveOwner(ctx, id)
+}
+
+func (trm thingRepositoryMiddleware) RetrieveByKey(ctx context.Context, key string) (things.Thing, error) {
 	span := createSpan(ctx, trm.tracer, retrieveThingByKeyOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
@@ -85,12 +111,52 @@ func (trm thingRepositoryMiddleware) RetrieveByKey(ctx context.Context, key stri
 	return trm.repo.RetrieveByKey(ctx, key)
 }
 
-func (trm thingRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string) (things.ThingsPage, error) {
+func (trm thingRepositoryMiddleware) RetrieveByKeys(ctx context.Context, keys []string) ([]things.Thing, error) {
+	span := createSpan(ctx, trm.tracer, retrieveThingsByKeysOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveByKeys(ctx, keys)
+}
+
+func (trm thingRepositoryMiddleware) RetrieveExpired(ctx context.Context, t time.Time, limit uint64) ([]things.Thing, error) {
+	span := createSpan(ctx, trm.tracer, retrieveExpiredThingsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveExpired(ctx, t, limit)
+}
+
+func (trm thingRepositoryMiddleware) RetrieveIdle(ctx context.Context, owner, chanID string, t time.Time) ([]things.Thing, error) {
+	span := createSpan(ctx, trm.tracer, retrieveIdleThingsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveIdle(ctx, owner, chanID, t)
+}
+
+func (trm thingRepositoryMiddleware) UpdateLastSeen(ctx context.Context, id string, t time.Time) error {
+	span := createSpan(ctx, trm.tracer, updateLastSeenOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.UpdateLastSeen(ctx, id, t)
+}
+
+func (trm thingRepositoryMiddleware) NameExists(ctx context.Context, owner, name string) (bool, error) {
+	span := createSpan(ctx, trm.tracer, thingNameExistsOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.NameExists(ctx, owner, name)
+}
+
+func (trm thingRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string, compact bool) (things.ThingsPage, error) {
 	span := createSpan(ctx, trm.tracer, retrieveAllThingsOp)
 	defer span.Finish()
 	ctx = opentracing.ContextWithSpan(ctx, span)
 
-	return trm.repo.RetrieveAll(ctx, owner, offset, limit, name)
+	return trm.repo.RetrieveAll(ctx, owner, offset, limit, name, compact)
 }
 
 func (trm thingRepositoryMiddleware) RetrieveByChannel(ctx context.Context, owner, channel string, offset, limit uint64) (things.ThingsPage, error) {
@@ -101,6 +167,22 @@ func (trm thingRepositoryMiddleware) RetrieveByChannel(ctx context.Context, owne
 	return trm.repo.RetrieveByChannel(ctx, owner, channel, offset, limit)
 }
 
+func (trm thingRepositoryMiddleware) RetrieveByChannelAfter(ctx context.Context, owner, channel, after string, limit uint64) ([]things.Thing, error) {
+	span := createSpan(ctx, trm.tracer, retrieveThingsAfterOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveByChannelAfter(ctx, owner, channel, after, limit)
+}
+
+func (trm thingRepositoryMiddleware) RetrieveByChannels(ctx context.Context, owner string, channels []string, offset, limit uint64) (things.ThingsPage, error) {
+	span := createSpan(ctx, trm.tracer, retrieveThingsByChansOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveByChannels(ctx, owner, channels, offset, limit)
+}
+
 func (trm thingRepositoryMiddleware) Remove(ctx context.Context, owner, id string) error {
 	span := createSpan(ctx, trm.tracer, removeThingOp)
 	defer span.Finish()
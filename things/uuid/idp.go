@@ -30,3 +30,14 @@ func (idp *uuidIdentityProvider) ID() (string, error) {
 
 	return id.String(), nil
 }
+
+// Valid returns true if the identifier is the UUID in the canonical form, i.e.
+// the form of the identifiers generated by the provider.
+func Valid(id string) bool {
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		return false
+	}
+
+	return uid.String() == id
+}
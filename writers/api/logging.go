@@ -42,3 +42,16 @@ func (lm *loggingMiddleware) Save(msg mainflux.Message) (err error) {
 
 	return lm.svc.Save(msg)
 }
+
+func (lm *loggingMiddleware) SaveAll(msgs []mainflux.Message) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method SaveAll for %d messages took %s to complete", len(msgs), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.SaveAll(msgs)
+}
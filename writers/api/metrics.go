@@ -38,3 +38,11 @@ func (mm *metricsMiddleware) Save(msg mainflux.Message) error {
 	}(time.Now())
 	return mm.repo.Save(msg)
 }
+
+func (mm *metricsMiddleware) SaveAll(msgs []mainflux.Message) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "handle_messages").Add(1)
+		mm.latency.With("method", "handle_messages").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return mm.repo.SaveAll(msgs)
+}
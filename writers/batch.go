@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux"
+)
+
+// ErrInvalidBatch indicates the batch size that is negative, or that is set
+// without the flush interval.
+var ErrInvalidBatch = errors.New("invalid writer batch")
+
+// Batch configures saving of the consumed messages in batches. Messages are
+// buffered until there are Size of them, or until the Interval elapses,
+// whichever comes first, and then saved at once. Zero value, as well as the
+// size of one, disables the batching, so that every message is saved as soon
+// as it's consumed.
+type Batch struct {
+	Size     int
+	Interval time.Duration
+}
+
+// Enabled returns true if the messages are saved in batches.
+func (b Batch) Enabled() bool {
+	return b.Size > 1
+}
+
+// Validate returns ErrInvalidBatch if the batch is malformed. Interval is
+// required for the enabled batching, so that the messages consumed at the
+// low rate don't stay buffered indefinitely.
+func (b Batch) Validate() error {
+	if b.Size < 0 || b.Enabled() && b.Interval <= 0 {
+		return ErrInvalidBatch
+	}
+
+	return nil
+}
+
+// buffer holds the consumed messages until they are saved as the batch.
+type buffer struct {
+	mu   sync.Mutex
+	size int
+	msgs []mainflux.Message
+}
+
+func newBuffer(size int) *buffer {
+	return &buffer{size: size}
+}
+
+// add buffers the message. Once the buffer is full, it's emptied and the
+// buffered messages are returned.
+func (b *buffer) add(msg mainflux.Message) []mainflux.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.msgs = append(b.msgs, msg)
+	if len(b.msgs) < b.size {
+		return nil
+	}
+
+	msgs := b.msgs
+	b.msgs = nil
+	return msgs
+}
+
+// len returns the number of the buffered messages.
+func (b *buffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.msgs)
+}
+
+// take empties the buffer and returns the buffered messages.
+func (b *buffer) take() []mainflux.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	msgs := b.msgs
+	b.msgs = nil
+	return msgs
+}
@@ -0,0 +1,355 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
+	nats "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRepository fails the batches with the given errors, one per batch,
+// saving the messages the partially failed batch reports as saved, and fails
+// the single saves of the messages with the invalid name.
+type batchRepository struct {
+	errs    []error
+	invalid string
+	batches []int
+	saved   []string
+}
+
+func (repo *batchRepository) Save(msg mainflux.Message) error {
+	if msg.Name == repo.invalid {
+		return NewError(ErrInvalidMessage, errors.New("invalid value"))
+	}
+
+	repo.saved = append(repo.saved, msg.Name)
+	return nil
+}
+
+func (repo *batchRepository) SaveAll(msgs []mainflux.Message) error {
+	repo.batches = append(repo.batches, len(msgs))
+
+	saved := len(msgs)
+	var err error
+	if len(repo.errs) > 0 {
+		err, repo.errs = repo.errs[0], repo.errs[1:]
+		saved = 0
+		if e, ok := err.(BatchError); ok {
+			saved = e.Saved
+		}
+	}
+
+	for _, msg := range msgs[:saved] {
+		repo.saved = append(repo.saved, msg.Name)
+	}
+
+	return err
+}
+
+func names(n int) []string {
+	names := []string{}
+	for i := 0; i < n; i++ {
+		names = append(names, fmt.Sprintf("m%d", i))
+	}
+
+	return names
+}
+
+func consumeNamed(t *testing.T, c *consumer, names []string) {
+	for _, name := range names {
+		msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http", Name: name}
+		data, err := msg.Marshal()
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		c.consume(&nats.Msg{Data: data})
+	}
+}
+
+func TestBatchValidate(t *testing.T) {
+	cases := []struct {
+		desc  string
+		batch Batch
+		err   error
+	}{
+		{
+			desc:  "validate disabled batch",
+			batch: Batch{},
+			err:   nil,
+		},
+		{
+			desc:  "validate batch of single message without interval",
+			batch: Batch{Size: 1},
+			err:   nil,
+		},
+		{
+			desc:  "validate batch with interval",
+			batch: Batch{Size: 100, Interval: time.Second},
+			err:   nil,
+		},
+		{
+			desc:  "validate batch without interval",
+			batch: Batch{Size: 100},
+			err:   ErrInvalidBatch,
+		},
+		{
+			desc:  "validate batch of negative size",
+			batch: Batch{Size: -1, Interval: time.Second},
+			err:   ErrInvalidBatch,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.batch.Validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestConsumeBatch(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	repo := &batchRepository{}
+	c := consumer{
+		channels: map[string]bool{"*": true},
+		buffer:   newBuffer(3),
+		repo:     repo,
+		logger:   logger,
+	}
+
+	consumeNamed(t, &c, names(7))
+	assert.Equal(t, []int{3, 3}, repo.batches, fmt.Sprintf("expected full batches %v got %v", []int{3, 3}, repo.batches))
+	assert.Equal(t, names(6), repo.saved, fmt.Sprintf("expected saved messages %v got %v", names(6), repo.saved))
+
+	c.flush()
+	assert.Equal(t, []int{3, 3, 1}, repo.batches, fmt.Sprintf("expected flushed batches %v got %v", []int{3, 3, 1}, repo.batches))
+	assert.Equal(t, names(7), repo.saved, fmt.Sprintf("expected saved messages %v got %v", names(7), repo.saved))
+
+	c.flush()
+	assert.Equal(t, []int{3, 3, 1}, repo.batches, "expected empty buffer not to be saved")
+}
+
+func TestConsumeBatchFailures(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	transient := NewError(ErrTransient, errors.New("connection reset"))
+	invalid := NewError(ErrInvalidMessage, errors.New("invalid value"))
+	storage := NewError(ErrStorage, errors.New("disk full"))
+
+	cases := map[string]struct {
+		errs     []error
+		invalid  string
+		batches  []int
+		saved    []string
+		failures map[string]float64
+	}{
+		"save batch": {
+			batches:  []int{4},
+			saved:    names(4),
+			failures: map[string]float64{},
+		},
+		"save batch after transient failure": {
+			errs:    []error{transient},
+			batches: []int{4, 4},
+			saved:   names(4),
+			failures: map[string]float64{
+				"[class transient action retry]": 4,
+			},
+		},
+		"save batch with persistent transient failure": {
+			errs:    []error{transient, transient, transient, transient},
+			batches: []int{4, 4, 4, 4},
+			saved:   []string{},
+			failures: map[string]float64{
+				"[class transient action retry]":       4 * maxRetries,
+				"[class transient action dead_letter]": 4,
+			},
+		},
+		"save partially saved batch after transient failure": {
+			errs:    []error{BatchError{Saved: 3, Err: transient}},
+			batches: []int{4, 1},
+			saved:   names(4),
+			failures: map[string]float64{
+				"[class transient action retry]": 1,
+			},
+		},
+		"save batch with invalid message": {
+			errs:    []error{invalid},
+			invalid: "m2",
+			batches: []int{4},
+			saved:   []string{"m0", "m1", "m3"},
+			failures: map[string]float64{
+				"[class invalid_message action drop]": 1,
+			},
+		},
+		"save partially saved batch with invalid message": {
+			errs:    []error{BatchError{Saved: 2, Err: invalid}},
+			invalid: "m2",
+			batches: []int{4},
+			saved:   []string{"m0", "m1", "m3"},
+			failures: map[string]float64{
+				"[class invalid_message action drop]": 1,
+			},
+		},
+		"save batch with storage failure": {
+			errs:     []error{storage},
+			batches:  []int{4},
+			saved:    names(4),
+			failures: map[string]float64{},
+		},
+	}
+
+	for desc, tc := range cases {
+		repo := &batchRepository{errs: tc.errs, invalid: tc.invalid}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels: map[string]bool{"*": true},
+			buffer:   newBuffer(4),
+			repo:     repo,
+			failures: failures,
+			logger:   logger,
+		}
+
+		consumeNamed(t, &c, names(4))
+		if repo.saved == nil {
+			repo.saved = []string{}
+		}
+		assert.Equal(t, tc.batches, repo.batches, fmt.Sprintf("%s: expected batches %v got %v", desc, tc.batches, repo.batches))
+		assert.Equal(t, tc.saved, repo.saved, fmt.Sprintf("%s: expected saved messages %v got %v", desc, tc.saved, repo.saved))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", desc, tc.failures, failures.counts))
+	}
+}
+
+// flushedRepository sends the saved batches to the channel, since they are
+// saved by the flushing goroutine.
+type flushedRepository struct {
+	batches chan []mainflux.Message
+}
+
+func (repo flushedRepository) Save(msg mainflux.Message) error {
+	return repo.SaveAll([]mainflux.Message{msg})
+}
+
+func (repo flushedRepository) SaveAll(msgs []mainflux.Message) error {
+	repo.batches <- msgs
+	return nil
+}
+
+func TestFlushInterval(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	repo := flushedRepository{batches: make(chan []mainflux.Message, 1)}
+	c := consumer{
+		channels: map[string]bool{"*": true},
+		buffer:   newBuffer(100),
+		repo:     repo,
+		logger:   logger,
+	}
+
+	consumeNamed(t, &c, names(3))
+	assert.Equal(t, 3, c.Buffered(), fmt.Sprintf("expected 3 buffered messages got %d", c.Buffered()))
+
+	go c.flushEvery(10 * time.Millisecond)
+
+	select {
+	case msgs := <-repo.batches:
+		assert.Equal(t, 3, len(msgs), fmt.Sprintf("expected batch of 3 messages got %d", len(msgs)))
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected buffered messages to be flushed after the interval")
+	}
+
+	assert.Equal(t, 0, c.Buffered(), fmt.Sprintf("expected empty buffer got %d messages", c.Buffered()))
+}
+
+func TestCloseStopsFlushInterval(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	repo := flushedRepository{batches: make(chan []mainflux.Message, 1)}
+	c := &consumer{
+		done:     make(chan struct{}),
+		channels: map[string]bool{"*": true},
+		buffer:   newBuffer(100),
+		repo:     repo,
+		logger:   logger,
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		c.flushEvery(10 * time.Millisecond)
+		close(stopped)
+	}()
+
+	assert.Nil(t, c.Close(), "unexpected error closing consumer")
+	assert.Nil(t, c.Close(), "unexpected error closing closed consumer")
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "expected periodic flush to stop after close")
+	}
+
+	// Messages buffered after the close are kept until they are flushed.
+	consumeNamed(t, c, names(3))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 3, c.Buffered(), fmt.Sprintf("expected 3 buffered messages got %d", c.Buffered()))
+	assert.Nil(t, FlushBuffer(c, time.Second, logger), "unexpected error flushing buffer")
+	assert.Equal(t, 0, c.Buffered(), fmt.Sprintf("expected empty buffer got %d messages", c.Buffered()))
+}
+
+func TestFlushBuffer(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		buffer *buffer
+		batch  int
+	}{
+		{
+			desc:   "flush partial batch on shutdown",
+			buffer: newBuffer(100),
+			batch:  3,
+		},
+		{
+			desc:   "flush on shutdown without batching",
+			buffer: nil,
+			batch:  0,
+		},
+	}
+
+	for _, tc := range cases {
+		repo := &batchRepository{}
+		c := &consumer{
+			channels: map[string]bool{"*": true},
+			buffer:   tc.buffer,
+			repo:     repo,
+			logger:   logger,
+		}
+
+		consumeNamed(t, c, names(3))
+
+		err := FlushBuffer(c, time.Second, logger)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, names(3), repo.saved, fmt.Sprintf("%s: expected saved messages %v got %v", tc.desc, names(3), repo.saved))
+		if tc.batch > 0 {
+			assert.Equal(t, []int{tc.batch}, repo.batches, fmt.Sprintf("%s: expected flushed batches %v got %v", tc.desc, []int{tc.batch}, repo.batches))
+		}
+		assert.Equal(t, 0, c.Buffered(), fmt.Sprintf("%s: expected empty buffer got %d messages", tc.desc, c.Buffered()))
+	}
+}
@@ -13,6 +13,16 @@ import (
 	"github.com/mainflux/mainflux/writers"
 )
 
+const insertCQL = `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
+			name, unit, value, string_value, bool_value, data_value, value_sum,
+			time, update_time, link)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// maxBatchStatements is the number of the inserts executed by a single batch,
+// which keeps the batch well below the default Cassandra batch size failure
+// threshold of 50 KB for the messages of the typical size.
+const maxBatchStatements = 100
+
 var _ writers.MessageRepository = (*cassandraRepository)(nil)
 
 type cassandraRepository struct {
@@ -25,10 +35,38 @@ func New(session *gocql.Session) writers.MessageRepository {
 }
 
 func (cr *cassandraRepository) Save(msg mainflux.Message) error {
-	cql := `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
-			name, unit, value, string_value, bool_value, data_value, value_sum,
-			time, update_time, link)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	return cr.session.Query(insertCQL, insertValues(msg)...).Exec()
+}
+
+// SaveAll inserts the messages using the logged batches of up to
+// maxBatchStatements inserts. Every batch is applied atomically, so the
+// failure of the batch other than the first one is reported as the
+// writers.BatchError with the messages of the preceding batches saved.
+func (cr *cassandraRepository) SaveAll(msgs []mainflux.Message) error {
+	for start := 0; start < len(msgs); start += maxBatchStatements {
+		end := start + maxBatchStatements
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+
+		batch := cr.session.NewBatch(gocql.LoggedBatch)
+		for _, msg := range msgs[start:end] {
+			batch.Query(insertCQL, insertValues(msg)...)
+		}
+
+		if err := cr.session.ExecuteBatch(batch); err != nil {
+			if start == 0 {
+				return err
+			}
+			return writers.BatchError{Saved: start, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// insertValues returns the values of the insert statement columns.
+func insertValues(msg mainflux.Message) []interface{} {
 	id := gocql.TimeUUID()
 
 	var floatVal, valSum *float64
@@ -54,7 +92,7 @@ func (cr *cassandraRepository) Save(msg mainflux.Message) error {
 		valSum = &v
 	}
 
-	return cr.session.Query(cql, id, msg.GetChannel(), msg.GetSubtopic(), msg.GetPublisher(),
+	return []interface{}{id, msg.GetChannel(), msg.GetSubtopic(), msg.GetPublisher(),
 		msg.GetProtocol(), msg.GetName(), msg.GetUnit(), floatVal,
-		strVal, boolVal, dataVal, valSum, msg.GetTime(), msg.GetUpdateTime(), msg.GetLink()).Exec()
+		strVal, boolVal, dataVal, valSum, msg.GetTime(), msg.GetUpdateTime(), msg.GetLink()}
 }
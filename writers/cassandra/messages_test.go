@@ -63,3 +63,35 @@ func TestSave(t *testing.T) {
 		assert.Nil(t, err, fmt.Sprintf("expected no error, got %s", err))
 	}
 }
+
+func TestSaveAll(t *testing.T) {
+	session, err := cassandra.Connect(cassandra.DBConfig{
+		Hosts:    []string{addr},
+		Keyspace: keyspace,
+	})
+	require.Nil(t, err, fmt.Sprintf("failed to connect to Cassandra: %s", err))
+
+	repo := cassandra.New(session)
+	now := time.Now().Unix()
+
+	// Batch exceeding the number of statements of the single Cassandra batch
+	// is split into several of them.
+	msgs := []mainflux.Message{}
+	for i := 0; i < 250; i++ {
+		msgs = append(msgs, mainflux.Message{
+			Channel:   "batch",
+			Publisher: "1",
+			Protocol:  "mqtt",
+			Value:     &mainflux.Message_FloatValue{FloatValue: float64(i)},
+			Time:      float64(now + int64(i)),
+		})
+	}
+
+	err = repo.SaveAll(msgs)
+	assert.Nil(t, err, fmt.Sprintf("expected no error, got %s", err))
+
+	var count int
+	err = session.Query(`SELECT COUNT(*) FROM messages WHERE channel = ?`, "batch").Scan(&count)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, len(msgs), count, fmt.Sprintf("expected %d saved messages got %d", len(msgs), count))
+}
@@ -0,0 +1,68 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	nats "github.com/nats-io/go-nats"
+)
+
+// ErrInvalidDeadLetterQueue indicates the dead-letter subject that messages
+// can't be published to, e.g. the one containing wildcards.
+var ErrInvalidDeadLetterQueue = errors.New("invalid dead-letter subject")
+
+// DeadLetterQueue receives the raw data of the messages that fail to
+// unmarshal or to be saved, so that they can be replayed once the failure is
+// resolved instead of being lost.
+type DeadLetterQueue interface {
+	// Publish republishes the raw data along with the class of the failure,
+	// i.e. one of the error classes the failures are counted by.
+	Publish(data []byte, class error) error
+}
+
+// publisher publishes the data to the NATS subject. It is implemented by the
+// NATS connection.
+type publisher interface {
+	Publish(string, []byte) error
+}
+
+type natsDeadLetterQueue struct {
+	pub     publisher
+	subject string
+}
+
+// NewDeadLetterQueue returns the dead-letter queue publishing the raw data to
+// the NATS subject. Failure reason is appended to the subject as its last
+// token, e.g. writers.dlq.storage, since NATS messages carry no headers, so
+// the queue is consumed by subscribing to writers.dlq.>. Empty subject
+// disables the dead-letter queue, in which case nil is returned.
+func NewDeadLetterQueue(nc *nats.Conn, subject string) (DeadLetterQueue, error) {
+	if subject == "" {
+		return nil, nil
+	}
+
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" || token == "*" || token == ">" || strings.ContainsAny(token, " \t\r\n") {
+			return nil, ErrInvalidDeadLetterQueue
+		}
+	}
+
+	return natsDeadLetterQueue{pub: nc, subject: subject}, nil
+}
+
+func (q natsDeadLetterQueue) Publish(data []byte, class error) error {
+	reason, ok := classLabels[class]
+	if !ok {
+		reason = classLabels[ErrStorage]
+	}
+
+	return q.pub.Publish(fmt.Sprintf("%s.%s", q.subject, reason), data)
+}
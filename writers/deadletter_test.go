@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
+	nats "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dlqSubject = "writers.dlq"
+
+// publisherMock records the data published to every subject, or fails the
+// publishing with the given error.
+type publisherMock struct {
+	published map[string][][]byte
+	err       error
+}
+
+func (pm *publisherMock) Publish(subject string, data []byte) error {
+	if pm.err != nil {
+		return pm.err
+	}
+
+	pm.published[subject] = append(pm.published[subject], data)
+	return nil
+}
+
+func TestNewDeadLetterQueue(t *testing.T) {
+	cases := map[string]struct {
+		subject string
+		nilDLQ  bool
+		err     error
+	}{
+		"create disabled dead-letter queue":              {subject: "", nilDLQ: true, err: nil},
+		"create dead-letter queue":                       {subject: dlqSubject, nilDLQ: false, err: nil},
+		"create dead-letter queue of wildcard subject":   {subject: "writers.*", nilDLQ: true, err: ErrInvalidDeadLetterQueue},
+		"create dead-letter queue of full wildcard":      {subject: "writers.>", nilDLQ: true, err: ErrInvalidDeadLetterQueue},
+		"create dead-letter queue of empty token":        {subject: "writers..dlq", nilDLQ: true, err: ErrInvalidDeadLetterQueue},
+		"create dead-letter queue of subject with space": {subject: "writers dlq", nilDLQ: true, err: ErrInvalidDeadLetterQueue},
+	}
+
+	for desc, tc := range cases {
+		dlq, err := NewDeadLetterQueue(nil, tc.subject)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+		assert.Equal(t, tc.nilDLQ, dlq == nil, fmt.Sprintf("%s: expected nil dead-letter queue %t got %t", desc, tc.nilDLQ, dlq == nil))
+	}
+}
+
+func TestConsumeDeadLetter(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	transient := NewError(ErrTransient, errors.New("connection reset"))
+	invalid := NewError(ErrInvalidMessage, errors.New("document validation failed"))
+	storage := NewError(ErrStorage, errors.New("disk full"))
+
+	msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http"}
+	valid, err := msg.Marshal()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	garbage := []byte{0xff, 0xff, 0xff, 0xff}
+
+	cases := map[string]struct {
+		data       []byte
+		errs       []error
+		publishErr error
+		disabled   bool
+		published  map[string][][]byte
+		failures   map[string]float64
+	}{
+		"dead-letter message with storage failure": {
+			data:      valid,
+			errs:      []error{storage},
+			published: map[string][][]byte{dlqSubject + ".storage": {valid}},
+			failures: map[string]float64{
+				"[class storage action dead_letter]": 1,
+			},
+		},
+		"dead-letter message with persistent transient failure": {
+			data:      valid,
+			errs:      []error{transient, transient, transient, transient},
+			published: map[string][][]byte{dlqSubject + ".transient": {valid}},
+			failures: map[string]float64{
+				"[class transient action retry]":       maxRetries,
+				"[class transient action dead_letter]": 1,
+			},
+		},
+		"dead-letter invalid message": {
+			data:      valid,
+			errs:      []error{invalid},
+			published: map[string][][]byte{dlqSubject + ".invalid_message": {valid}},
+			failures: map[string]float64{
+				"[class invalid_message action dead_letter]": 1,
+			},
+		},
+		"dead-letter corrupt data": {
+			data:      garbage,
+			published: map[string][][]byte{dlqSubject + ".corrupt": {garbage}},
+			failures: map[string]float64{
+				"[class corrupt action dead_letter]": 1,
+			},
+		},
+		"save message after transient failure": {
+			data:      valid,
+			errs:      []error{transient},
+			published: map[string][][]byte{},
+			failures: map[string]float64{
+				"[class transient action retry]": 1,
+			},
+		},
+		"dead-letter corrupt data to failing queue": {
+			data:       garbage,
+			publishErr: errors.New("nats: connection closed"),
+			published:  map[string][][]byte{},
+			failures: map[string]float64{
+				"[class corrupt action drop]": 1,
+			},
+		},
+		"drop invalid message without dead-letter queue": {
+			data:      valid,
+			errs:      []error{invalid},
+			disabled:  true,
+			published: map[string][][]byte{},
+			failures: map[string]float64{
+				"[class invalid_message action drop]": 1,
+			},
+		},
+		"drop corrupt data without dead-letter queue": {
+			data:      garbage,
+			disabled:  true,
+			published: map[string][][]byte{},
+			failures: map[string]float64{
+				"[class corrupt action drop]": 1,
+			},
+		},
+	}
+
+	for desc, tc := range cases {
+		pub := &publisherMock{published: map[string][][]byte{}, err: tc.publishErr}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels: map[string]bool{"*": true},
+			repo:     &failingRepository{errs: tc.errs},
+			failures: failures,
+			logger:   logger,
+		}
+		if !tc.disabled {
+			c.dlq = natsDeadLetterQueue{pub: pub, subject: dlqSubject}
+		}
+
+		c.consume(&nats.Msg{Data: tc.data})
+		assert.Equal(t, tc.published, pub.published, fmt.Sprintf("%s: expected dead-lettered data %v got %v", desc, tc.published, pub.published))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", desc, tc.failures, failures.counts))
+	}
+}
+
+func TestConsumeBatchDeadLetter(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	transient := NewError(ErrTransient, errors.New("connection reset"))
+	repo := &batchRepository{errs: []error{
+		BatchError{Saved: 1, Err: transient},
+		transient,
+		transient,
+		transient,
+	}}
+	pub := &publisherMock{published: map[string][][]byte{}}
+	c := consumer{
+		channels: map[string]bool{"*": true},
+		dlq:      natsDeadLetterQueue{pub: pub, subject: dlqSubject},
+		buffer:   newBuffer(3),
+		repo:     repo,
+		logger:   logger,
+	}
+
+	consumeNamed(t, &c, names(3))
+	assert.Equal(t, names(1), repo.saved, fmt.Sprintf("expected saved messages %v got %v", names(1), repo.saved))
+
+	dead := []string{}
+	for _, data := range pub.published[dlqSubject+".transient"] {
+		var msg mainflux.Message
+		err := msg.Unmarshal(data)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		dead = append(dead, msg.Name)
+	}
+	assert.Equal(t, names(3)[1:], dead, fmt.Sprintf("expected dead-lettered messages %v got %v", names(3)[1:], dead))
+}
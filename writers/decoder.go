@@ -0,0 +1,68 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/normalizer"
+)
+
+var _ Decoder = (*senmlDecoder)(nil)
+
+// Decoder decodes the payload of the raw message, published in the format the
+// decoder is registered for, into the normalized messages.
+type Decoder interface {
+	// Decode turns the raw message into one or more normalized messages.
+	Decode(mainflux.RawMessage) ([]mainflux.Message, error)
+}
+
+// DecoderFunc is an adapter allowing the use of the ordinary function as the
+// decoder.
+type DecoderFunc func(mainflux.RawMessage) ([]mainflux.Message, error)
+
+// Decode calls f(msg).
+func (f DecoderFunc) Decode(msg mainflux.RawMessage) ([]mainflux.Message, error) {
+	return f(msg)
+}
+
+// Decoders registers the decoders by the content type or by the protocol of
+// the raw messages they decode. Content type takes precedence over the
+// protocol, while the raw messages matching neither of them are decoded as
+// SenML.
+type Decoders map[string]Decoder
+
+func (d Decoders) decode(msg mainflux.RawMessage) ([]mainflux.Message, error) {
+	if dec, ok := d[msg.ContentType]; ok {
+		return dec.Decode(msg)
+	}
+
+	if dec, ok := d[msg.Protocol]; ok {
+		return dec.Decode(msg)
+	}
+
+	return SenMLDecoder().Decode(msg)
+}
+
+type senmlDecoder struct {
+	normalizer normalizer.Service
+}
+
+// SenMLDecoder returns the default decoder, which normalizes the SenML JSON
+// and CBOR payloads into one message per SenML record.
+func SenMLDecoder() Decoder {
+	return senmlDecoder{normalizer: normalizer.New()}
+}
+
+func (sd senmlDecoder) Decode(msg mainflux.RawMessage) ([]mainflux.Message, error) {
+	nd, err := sd.normalizer.Normalize(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return nd.Messages, nil
+}
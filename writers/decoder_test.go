@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
+	nats "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvContentType = "text/csv"
+
+// recordingRepository records the saved messages.
+type recordingRepository struct {
+	msgs []mainflux.Message
+}
+
+func (repo *recordingRepository) Save(msg mainflux.Message) error {
+	repo.msgs = append(repo.msgs, msg)
+	return nil
+}
+
+func (repo *recordingRepository) SaveAll(msgs []mainflux.Message) error {
+	repo.msgs = append(repo.msgs, msgs...)
+	return nil
+}
+
+// csvDecoder expands the comma separated readings, formatted as name=value,
+// into one message per reading.
+var csvDecoder = DecoderFunc(func(raw mainflux.RawMessage) ([]mainflux.Message, error) {
+	msgs := []mainflux.Message{}
+	for _, reading := range strings.Split(string(raw.Payload), ",") {
+		parts := strings.SplitN(reading, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("malformed reading")
+		}
+
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, mainflux.Message{
+			Channel:   raw.Channel,
+			Publisher: raw.Publisher,
+			Protocol:  raw.Protocol,
+			Name:      parts[0],
+			Value:     &mainflux.Message_FloatValue{FloatValue: v},
+		})
+	}
+
+	return msgs, nil
+})
+
+func TestConsumeRaw(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	decoders := Decoders{csvContentType: csvDecoder}
+
+	cases := []struct {
+		desc     string
+		decoders Decoders
+		raw      mainflux.RawMessage
+		names    []string
+		values   []float64
+		failures map[string]float64
+	}{
+		{
+			desc:     "consume raw message of registered content type",
+			decoders: decoders,
+			raw:      mainflux.RawMessage{Channel: "1", Publisher: "1", Protocol: "http", ContentType: csvContentType, Payload: []byte("temp=20,hum=40,co2=400")},
+			names:    []string{"temp", "hum", "co2"},
+			values:   []float64{20, 40, 400},
+			failures: map[string]float64{},
+		},
+		{
+			desc:     "consume raw message of registered protocol",
+			decoders: Decoders{"lora": csvDecoder},
+			raw:      mainflux.RawMessage{Channel: "1", Publisher: "1", Protocol: "lora", Payload: []byte("temp=20,hum=40")},
+			names:    []string{"temp", "hum"},
+			values:   []float64{20, 40},
+			failures: map[string]float64{},
+		},
+		{
+			desc:     "consume raw SenML message",
+			decoders: decoders,
+			raw:      mainflux.RawMessage{Channel: "1", Publisher: "1", Protocol: "http", ContentType: mainflux.SenMLJSON, Payload: []byte(`[{"n":"temp","v":20},{"n":"hum","v":40}]`)},
+			names:    []string{"temp", "hum"},
+			values:   []float64{20, 40},
+			failures: map[string]float64{},
+		},
+		{
+			desc:     "consume raw message without registered decoders",
+			decoders: Decoders{},
+			raw:      mainflux.RawMessage{Channel: "1", Publisher: "1", Protocol: "http", Payload: []byte(`[{"n":"temp","v":20}]`)},
+			names:    []string{"temp"},
+			values:   []float64{20},
+			failures: map[string]float64{},
+		},
+		{
+			desc:     "consume malformed raw message",
+			decoders: decoders,
+			raw:      mainflux.RawMessage{Channel: "1", Publisher: "1", Protocol: "http", ContentType: csvContentType, Payload: []byte("temp")},
+			failures: map[string]float64{
+				"[class corrupt action drop]": 1,
+			},
+		},
+		{
+			desc:     "consume raw message of ignored channel",
+			decoders: decoders,
+			raw:      mainflux.RawMessage{Channel: "2", Publisher: "1", Protocol: "http", ContentType: csvContentType, Payload: []byte("temp=20")},
+			failures: map[string]float64{},
+		},
+	}
+
+	for _, tc := range cases {
+		repo := &recordingRepository{}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels: map[string]bool{"1": true},
+			decoders: tc.decoders,
+			repo:     repo,
+			failures: failures,
+			logger:   logger,
+		}
+
+		data, err := tc.raw.Marshal()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		c.consume(&nats.Msg{Data: data})
+
+		names := []string{}
+		values := []float64{}
+		for _, msg := range repo.msgs {
+			assert.Equal(t, tc.raw.Channel, msg.Channel, fmt.Sprintf("%s: expected channel %s got %s", tc.desc, tc.raw.Channel, msg.Channel))
+			names = append(names, msg.Name)
+			values = append(values, msg.GetFloatValue())
+		}
+		if tc.names == nil {
+			tc.names, tc.values = []string{}, []float64{}
+		}
+		assert.Equal(t, tc.names, names, fmt.Sprintf("%s: expected names %v got %v", tc.desc, tc.names, names))
+		assert.Equal(t, tc.values, values, fmt.Sprintf("%s: expected values %v got %v", tc.desc, tc.values, values))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", tc.desc, tc.failures, failures.counts))
+	}
+}
@@ -0,0 +1,72 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTransient indicates a temporary failure (e.g. lost connection or
+	// timeout) after which saving the same message may succeed.
+	ErrTransient = errors.New("transient failure")
+
+	// ErrInvalidMessage indicates that the message can't be saved no matter
+	// how many times it is retried (e.g. it violates the storage schema).
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// ErrStorage indicates a non-temporary failure of the storage itself.
+	// Unclassified errors are treated as storage errors.
+	ErrStorage = errors.New("storage failure")
+)
+
+// Error is the message saving failure classified as one of ErrTransient,
+// ErrInvalidMessage or ErrStorage.
+type Error struct {
+	Class error
+	Err   error
+}
+
+// NewError wraps the repository error into the error of the given class.
+func NewError(class, err error) error {
+	return Error{Class: class, Err: err}
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Class, e.Err)
+}
+
+// BatchError is the failure of the batch that is saved only partially. The
+// messages are saved in the batch order, so the first Saved messages of the
+// batch are saved, while the rest of them are not.
+type BatchError struct {
+	Saved int
+	Err   error
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("batch failed after %d saved messages: %s", e.Saved, e.Err)
+}
+
+// Classify returns the class of the message saving error. Class of the batch
+// error is the class of the error the batch failed with.
+func Classify(err error) error {
+	switch e := err.(type) {
+	case Error:
+		return e.Class
+	case BatchError:
+		return Classify(e.Err)
+	default:
+		switch err {
+		case ErrTransient, ErrInvalidMessage:
+			return err
+		}
+		return ErrStorage
+	}
+}
@@ -0,0 +1,77 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/mainflux/mainflux/logger"
+)
+
+// ErrFlushTimeout indicates that the buffered messages weren't flushed
+// within the given timeout.
+var ErrFlushTimeout = errors.New("flush timed out")
+
+// Flusher is implemented by the message repositories, as well as the consumer,
+// that buffer the messages before writing them to the database.
+type Flusher interface {
+	// Flush writes the buffered messages to the database.
+	Flush() error
+
+	// Buffered returns the number of the buffered messages.
+	Buffered() int
+}
+
+// Flush writes the messages buffered by the repository, if it buffers them,
+// when the writer shuts down. It gives up after the timeout, so that the stuck
+// database can't block the shutdown forever. The number of the buffered
+// messages lost due to the timeout or the failed flush is logged. Zero timeout
+// waits for the flush to complete.
+func Flush(repo MessageRepository, timeout time.Duration, logger log.Logger) error {
+	f, ok := repo.(Flusher)
+	if !ok {
+		return nil
+	}
+
+	return FlushBuffer(f, timeout, logger)
+}
+
+// FlushBuffer writes the messages buffered by the flusher, e.g. the one
+// returned by Start, giving up after the non-zero timeout the same way Flush
+// does.
+func FlushBuffer(f Flusher, timeout time.Duration, logger log.Logger) error {
+	// Buffered messages are counted upfront, since the stuck flush may hold
+	// the buffer.
+	buffered := f.Buffered()
+	if buffered == 0 {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f.Flush()
+	}()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		timer = time.After(timeout)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to flush buffered messages, %d messages lost: %s", buffered, err))
+		}
+		return err
+	case <-timer:
+		logger.Error(fmt.Sprintf("Flush timed out after %s, %d buffered messages lost", timeout, buffered))
+		return ErrFlushTimeout
+	}
+}
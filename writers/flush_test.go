@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/writers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferingRepository buffers the saved messages and flushes them once the
+// flush is unblocked.
+type bufferingRepository struct {
+	buffered int
+	unblock  chan struct{}
+	err      error
+}
+
+func (repo *bufferingRepository) Save(mainflux.Message) error {
+	repo.buffered++
+	return nil
+}
+
+func (repo *bufferingRepository) SaveAll(msgs []mainflux.Message) error {
+	repo.buffered += len(msgs)
+	return nil
+}
+
+func (repo *bufferingRepository) Flush() error {
+	<-repo.unblock
+	return repo.err
+}
+
+func (repo *bufferingRepository) Buffered() int {
+	return repo.buffered
+}
+
+func TestFlush(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	timeout := 50 * time.Millisecond
+	unblocked := make(chan struct{})
+	close(unblocked)
+
+	cases := []struct {
+		desc     string
+		repo     writers.MessageRepository
+		buffered int
+		err      error
+	}{
+		{
+			desc: "flush non-buffering repository",
+			repo: &repoMock{},
+			err:  nil,
+		},
+		{
+			desc:     "flush repository",
+			repo:     &bufferingRepository{unblock: unblocked},
+			buffered: 3,
+			err:      nil,
+		},
+		{
+			desc:     "flush repository failing to flush",
+			repo:     &bufferingRepository{unblock: unblocked, err: errSave},
+			buffered: 3,
+			err:      errSave,
+		},
+		{
+			desc:     "flush blocked repository",
+			repo:     &bufferingRepository{unblock: make(chan struct{})},
+			buffered: 3,
+			err:      writers.ErrFlushTimeout,
+		},
+		{
+			desc: "flush blocked empty repository",
+			repo: &bufferingRepository{unblock: make(chan struct{})},
+			err:  nil,
+		},
+	}
+
+	for _, tc := range cases {
+		for i := 0; i < tc.buffered; i++ {
+			tc.repo.Save(mainflux.Message{})
+		}
+
+		start := time.Now()
+		err := writers.Flush(tc.repo, timeout, logger)
+		elapsed := time.Since(start)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.True(t, elapsed < 2*timeout, fmt.Sprintf("%s: expected flush to complete within %s, took %s", tc.desc, timeout, elapsed))
+	}
+}
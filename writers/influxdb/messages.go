@@ -22,7 +22,10 @@ import (
 
 const pointName = "messages"
 
-var _ writers.MessageRepository = (*influxRepo)(nil)
+var (
+	_ writers.MessageRepository = (*influxRepo)(nil)
+	_ writers.Flusher           = (*influxRepo)(nil)
+)
 
 var (
 	errZeroValueSize    = errors.New("zero value batch size")
@@ -125,6 +128,39 @@ func (repo *influxRepo) Save(msg mainflux.Message) error {
 	return repo.savePoint(pt)
 }
 
+// SaveAll adds the messages to the batched points one by one. Failed write
+// keeps the points batched, so the points of the messages reported as saved
+// by the writers.BatchError may be written later, while rewriting the same
+// point on retry just overwrites it.
+func (repo *influxRepo) SaveAll(msgs []mainflux.Message) error {
+	for i, msg := range msgs {
+		if err := repo.Save(msg); err != nil {
+			if i == 0 {
+				return err
+			}
+			return writers.BatchError{Saved: i, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Flush writes the points batched so far.
+func (repo *influxRepo) Flush() error {
+	return repo.savePoint(nil)
+}
+
+func (repo *influxRepo) Buffered() int {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.batch == nil {
+		return 0
+	}
+
+	return len(repo.batch.Points())
+}
+
 func (repo *influxRepo) tagsOf(msg *mainflux.Message) tags {
 	return tags{
 		"channel":   msg.Channel,
@@ -15,4 +15,10 @@ type MessageRepository interface {
 	// Save method is used to save published message. A non-nil
 	// error is returned to indicate  operation failure.
 	Save(mainflux.Message) error
+
+	// SaveAll method is used to save the batch of published messages at
+	// once. If the batch fails after some of its messages are saved, the
+	// BatchError reporting the number of the saved messages is returned.
+	// Any other error indicates that none of the messages is saved.
+	SaveAll([]mainflux.Message) error
 }
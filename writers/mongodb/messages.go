@@ -9,19 +9,33 @@ package mongodb
 
 import (
 	"context"
+	"net"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
 
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/writers"
 )
 
-const collectionName string = "mainflux"
+const (
+	collectionName string = "mainflux"
+	naturalKeyName string = "natural_key"
+)
+
+// Error labels MongoDB attaches to the retryable command errors.
+const (
+	labelNetworkError     = "NetworkError"
+	labelTransientFailure = "TransientTransactionError"
+)
 
 var _ writers.MessageRepository = (*mongoRepo)(nil)
 
 type mongoRepo struct {
-	db *mongo.Database
+	db     *mongo.Database
+	upsert bool
 }
 
 // Message struct is used as a MongoDB representation of Mainflux message.
@@ -44,11 +58,81 @@ type message struct {
 
 // New returns new MongoDB writer.
 func New(db *mongo.Database) writers.MessageRepository {
-	return &mongoRepo{db}
+	return &mongoRepo{db: db}
+}
+
+// NewUpsert returns new MongoDB writer that replaces the message with the
+// same channel, publisher, time and name instead of inserting a duplicate,
+// e.g. when the corrected historical data is re-ingested. It creates the
+// unique index on these fields, which fails if the stored messages already
+// contain duplicates.
+func NewUpsert(db *mongo.Database) (writers.MessageRepository, error) {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel", Value: 1},
+			{Key: "publisher", Value: 1},
+			{Key: "time", Value: 1},
+			{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetName(naturalKeyName).SetUnique(true),
+	}
+
+	coll := db.Collection(collectionName)
+	if _, err := coll.Indexes().CreateOne(context.Background(), index); err != nil {
+		return nil, err
+	}
+
+	return &mongoRepo{db: db, upsert: true}, nil
 }
 
 func (repo *mongoRepo) Save(msg mainflux.Message) error {
 	coll := repo.db.Collection(collectionName)
+	m := toDocument(msg)
+
+	if !repo.upsert {
+		_, err := coll.InsertOne(context.Background(), m)
+		return classify(err)
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := coll.ReplaceOne(context.Background(), naturalKey(m), m, opts)
+	return classify(err)
+}
+
+// SaveAll inserts the messages, or upserts them, using the single ordered
+// bulk write. Ordered write stops at the first failing message, so the write
+// error is reported as the writers.BatchError with the messages preceding
+// the failing one saved.
+func (repo *mongoRepo) SaveAll(msgs []mainflux.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	coll := repo.db.Collection(collectionName)
+	var err error
+	if !repo.upsert {
+		docs := []interface{}{}
+		for _, msg := range msgs {
+			docs = append(docs, toDocument(msg))
+		}
+		_, err = coll.InsertMany(context.Background(), docs, options.InsertMany().SetOrdered(true))
+	} else {
+		models := []mongo.WriteModel{}
+		for _, msg := range msgs {
+			m := toDocument(msg)
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(naturalKey(m)).SetReplacement(m).SetUpsert(true))
+		}
+		_, err = coll.BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(true))
+	}
+
+	if e, ok := err.(mongo.BulkWriteException); ok && len(e.WriteErrors) > 0 && e.WriteErrors[0].Index > 0 {
+		return writers.BatchError{Saved: e.WriteErrors[0].Index, Err: classify(err)}
+	}
+
+	return classify(err)
+}
+
+func toDocument(msg mainflux.Message) message {
 	m := message{
 		Channel:    msg.Channel,
 		Subtopic:   msg.Subtopic,
@@ -81,6 +165,68 @@ func (repo *mongoRepo) Save(msg mainflux.Message) error {
 		m.ValueSum = &valueSum
 	}
 
-	_, err := coll.InsertOne(context.Background(), m)
-	return err
+	return m
+}
+
+// naturalKey returns the filter matching the stored message with the same
+// natural key. Empty fields are omitted from the stored messages, so they are
+// matched by null, which matches the missing fields as well.
+func naturalKey(m message) bson.D {
+	var channel, publisher, time, name interface{}
+	if m.Channel != "" {
+		channel = m.Channel
+	}
+	if m.Publisher != "" {
+		publisher = m.Publisher
+	}
+	if m.Time != 0 {
+		time = m.Time
+	}
+	if m.Name != "" {
+		name = m.Name
+	}
+
+	return bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "publisher", Value: publisher},
+		{Key: "time", Value: time},
+		{Key: "name", Value: name},
+	}
+}
+
+// classify wraps the MongoDB error into the writers error of the matching
+// class. Write errors (e.g. document validation failures) are caused by the
+// message itself, while network failures and unsatisfied write concerns are
+// expected to be temporary.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case mongo.WriteException:
+		if len(e.WriteErrors) > 0 {
+			return writers.NewError(writers.ErrInvalidMessage, err)
+		}
+		return writers.NewError(writers.ErrTransient, err)
+	case mongo.BulkWriteException:
+		if len(e.WriteErrors) > 0 {
+			return writers.NewError(writers.ErrInvalidMessage, err)
+		}
+		return writers.NewError(writers.ErrTransient, err)
+	case mongo.CommandError:
+		if e.HasErrorLabel(labelNetworkError) || e.HasErrorLabel(labelTransientFailure) {
+			return writers.NewError(writers.ErrTransient, err)
+		}
+		return writers.NewError(writers.ErrStorage, err)
+	case net.Error:
+		return writers.NewError(writers.ErrTransient, err)
+	}
+
+	switch err {
+	case topology.ErrServerSelectionTimeout, context.DeadlineExceeded:
+		return writers.NewError(writers.ErrTransient, err)
+	default:
+		return writers.NewError(writers.ErrStorage, err)
+	}
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/writers"
 	"github.com/mainflux/mainflux/writers/mongodb"
 
 	log "github.com/mainflux/mainflux/logger"
@@ -85,3 +86,128 @@ func TestSave(t *testing.T) {
 	assert.Nil(t, err, fmt.Sprintf("Querying database expected to succeed: %s.\n", err))
 	assert.Equal(t, int64(msgsNum), count, fmt.Sprintf("Expected to have %d value, found %d instead.\n", msgsNum, count))
 }
+
+func TestSaveErrorClasses(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database("classified")
+	cmd := bson.D{
+		{Key: "create", Value: collection},
+		{Key: "validator", Value: bson.M{"protocol": "http"}},
+	}
+	err = db.RunCommand(context.Background(), cmd).Err()
+	require.Nil(t, err, fmt.Sprintf("Creating validated collection expected to succeed: %s.\n", err))
+
+	repo := mongodb.New(db)
+
+	err = repo.Save(mainflux.Message{Channel: "45", Publisher: "2580", Protocol: "http"})
+	assert.Nil(t, err, fmt.Sprintf("Save operation expected to succeed: %s.\n", err))
+
+	err = repo.Save(mainflux.Message{Channel: "45", Publisher: "2580", Protocol: "coap"})
+	assert.Equal(t, writers.ErrInvalidMessage, writers.Classify(err), fmt.Sprintf("Saving message violating the schema expected to fail with %s: %s.\n", writers.ErrInvalidMessage, err))
+
+	err = client.Disconnect(context.Background())
+	require.Nil(t, err, fmt.Sprintf("Disconnecting MongoDB client expected to succeed: %s.\n", err))
+
+	err = repo.Save(mainflux.Message{Channel: "45", Publisher: "2580", Protocol: "http"})
+	assert.Equal(t, writers.ErrStorage, writers.Classify(err), fmt.Sprintf("Saving message using disconnected client expected to fail with %s: %s.\n", writers.ErrStorage, err))
+}
+
+func TestSaveUpsert(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database("upserted")
+	repo, err := mongodb.NewUpsert(db)
+	require.Nil(t, err, fmt.Sprintf("Creating upserting repository expected to succeed: %s.\n", err))
+
+	msg := mainflux.Message{
+		Channel:   "45",
+		Publisher: "2580",
+		Name:      "temperature",
+		Value:     &mainflux.Message_FloatValue{FloatValue: 24},
+		Time:      13451312,
+	}
+	err = repo.Save(msg)
+	assert.Nil(t, err, fmt.Sprintf("Save operation expected to succeed: %s.\n", err))
+
+	msg.Value = &mainflux.Message_FloatValue{FloatValue: 25}
+	err = repo.Save(msg)
+	assert.Nil(t, err, fmt.Sprintf("Re-ingesting message expected to succeed: %s.\n", err))
+
+	other := msg
+	other.Name = ""
+	err = repo.Save(other)
+	assert.Nil(t, err, fmt.Sprintf("Save operation expected to succeed: %s.\n", err))
+	err = repo.Save(other)
+	assert.Nil(t, err, fmt.Sprintf("Re-ingesting message without name expected to succeed: %s.\n", err))
+
+	count, err := db.Collection(collection).CountDocuments(context.Background(), bson.D{})
+	assert.Nil(t, err, fmt.Sprintf("Querying database expected to succeed: %s.\n", err))
+	assert.Equal(t, int64(2), count, fmt.Sprintf("Expected to have %d values, found %d instead.\n", 2, count))
+
+	var saved struct {
+		Value float64 `bson:"value"`
+	}
+	err = db.Collection(collection).FindOne(context.Background(), bson.M{"name": "temperature"}).Decode(&saved)
+	assert.Nil(t, err, fmt.Sprintf("Querying database expected to succeed: %s.\n", err))
+	assert.Equal(t, float64(25), saved.Value, fmt.Sprintf("Expected re-ingested value %f, found %f instead.\n", float64(25), saved.Value))
+}
+
+func TestSaveAll(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database("batched")
+	repo := mongodb.New(db)
+
+	msgs := []mainflux.Message{}
+	for i := 0; i < msgsNum; i++ {
+		msgs = append(msgs, mainflux.Message{
+			Channel:   "45",
+			Publisher: "2580",
+			Protocol:  "http",
+			Value:     &mainflux.Message_FloatValue{FloatValue: float64(i)},
+			Time:      float64(13451312 + i),
+		})
+	}
+
+	err = repo.SaveAll(msgs)
+	assert.Nil(t, err, fmt.Sprintf("SaveAll operation expected to succeed: %s.\n", err))
+
+	count, err := db.Collection(collection).CountDocuments(context.Background(), bson.D{})
+	assert.Nil(t, err, fmt.Sprintf("Querying database expected to succeed: %s.\n", err))
+	assert.Equal(t, int64(msgsNum), count, fmt.Sprintf("Expected to have %d values, found %d instead.\n", msgsNum, count))
+}
+
+func TestSaveAllPartialFailure(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database("partial")
+	cmd := bson.D{
+		{Key: "create", Value: collection},
+		{Key: "validator", Value: bson.M{"protocol": "http"}},
+	}
+	err = db.RunCommand(context.Background(), cmd).Err()
+	require.Nil(t, err, fmt.Sprintf("Creating validated collection expected to succeed: %s.\n", err))
+
+	repo := mongodb.New(db)
+
+	msgs := []mainflux.Message{
+		{Channel: "45", Publisher: "2580", Protocol: "http"},
+		{Channel: "45", Publisher: "2580", Protocol: "http"},
+		{Channel: "45", Publisher: "2580", Protocol: "coap"},
+		{Channel: "45", Publisher: "2580", Protocol: "http"},
+	}
+	err = repo.SaveAll(msgs)
+	be, ok := err.(writers.BatchError)
+	require.True(t, ok, fmt.Sprintf("Saving batch violating the schema expected to fail with batch error: %s.\n", err))
+	assert.Equal(t, 2, be.Saved, fmt.Sprintf("Expected %d saved messages, found %d instead.\n", 2, be.Saved))
+	assert.Equal(t, writers.ErrInvalidMessage, writers.Classify(err), fmt.Sprintf("Saving batch violating the schema expected to fail with %s: %s.\n", writers.ErrInvalidMessage, err))
+
+	count, err := db.Collection(collection).CountDocuments(context.Background(), bson.D{})
+	assert.Nil(t, err, fmt.Sprintf("Querying database expected to succeed: %s.\n", err))
+	assert.Equal(t, int64(2), count, fmt.Sprintf("Expected to have %d values, found %d instead.\n", 2, count))
+}
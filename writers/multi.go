@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/mainflux/mainflux"
+)
+
+// FailurePolicy specifies how the multi-sink repository reacts to the
+// failure of some of its sinks.
+type FailurePolicy int
+
+const (
+	// FailOnAny reports an error if saving to any of the sinks fails.
+	FailOnAny FailurePolicy = iota
+
+	// BestEffort reports an error only if saving to all of the sinks fails.
+	BestEffort
+)
+
+var _ MessageRepository = (*multiRepository)(nil)
+
+// Sink represents a named message repository that is written to by the
+// multi-sink repository.
+type Sink struct {
+	Name string
+	Repo MessageRepository
+}
+
+type multiRepository struct {
+	sinks   []Sink
+	policy  FailurePolicy
+	counter metrics.Counter
+}
+
+// NewMultiRepository returns message repository that saves every message to
+// all of the given sinks. Outcome of every save is counted per sink using
+// the "sink" and "status" labels.
+func NewMultiRepository(policy FailurePolicy, counter metrics.Counter, sinks ...Sink) MessageRepository {
+	return &multiRepository{
+		sinks:   sinks,
+		policy:  policy,
+		counter: counter,
+	}
+}
+
+func (mr *multiRepository) Save(msg mainflux.Message) error {
+	return mr.save(func(repo MessageRepository) error {
+		return repo.Save(msg)
+	})
+}
+
+// SaveAll saves the batch to all of the sinks. Partially saved batch of the
+// sink is reported as the failure of the sink, since the sinks may differ in
+// the number of the saved messages.
+func (mr *multiRepository) SaveAll(msgs []mainflux.Message) error {
+	return mr.save(func(repo MessageRepository) error {
+		return repo.SaveAll(msgs)
+	})
+}
+
+func (mr *multiRepository) save(save func(MessageRepository) error) error {
+	errs := []string{}
+	for _, sink := range mr.sinks {
+		if err := save(sink.Repo); err != nil {
+			mr.counter.With("sink", sink.Name, "status", "failed").Add(1)
+			errs = append(errs, fmt.Sprintf("%s: %s", sink.Name, err))
+			continue
+		}
+		mr.counter.With("sink", sink.Name, "status", "saved").Add(1)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if mr.policy == BestEffort && len(errs) < len(mr.sinks) {
+		return nil
+	}
+
+	return fmt.Errorf("failed to save message to sinks: %s", strings.Join(errs, "; "))
+}
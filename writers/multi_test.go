@@ -0,0 +1,144 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/writers"
+	"github.com/stretchr/testify/assert"
+)
+
+var errSave = errors.New("failed to save")
+
+type repoMock struct {
+	err      error
+	messages []mainflux.Message
+}
+
+func (repo *repoMock) Save(msg mainflux.Message) error {
+	if repo.err != nil {
+		return repo.err
+	}
+
+	repo.messages = append(repo.messages, msg)
+	return nil
+}
+
+func (repo *repoMock) SaveAll(msgs []mainflux.Message) error {
+	if repo.err != nil {
+		return repo.err
+	}
+
+	repo.messages = append(repo.messages, msgs...)
+	return nil
+}
+
+type counterMock struct {
+	mu     *sync.Mutex
+	labels []string
+	counts map[string]float64
+}
+
+func newCounter() counterMock {
+	return counterMock{
+		mu:     &sync.Mutex{},
+		counts: map[string]float64{},
+	}
+}
+
+func (c counterMock) With(labelValues ...string) metrics.Counter {
+	return counterMock{
+		mu:     c.mu,
+		labels: append(c.labels, labelValues...),
+		counts: c.counts,
+	}
+}
+
+func (c counterMock) Add(delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[fmt.Sprint(c.labels)] += delta
+}
+
+func (c counterMock) count(sink, status string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.counts[fmt.Sprint([]string{"sink", sink, "status", status})]
+}
+
+func TestMultiRepositorySave(t *testing.T) {
+	msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "mqtt"}
+
+	cases := map[string]struct {
+		policy   writers.FailurePolicy
+		failures map[string]bool
+		err      bool
+	}{
+		"save to all sinks with fail on any policy": {
+			policy:   writers.FailOnAny,
+			failures: map[string]bool{},
+			err:      false,
+		},
+		"save with one failing sink with fail on any policy": {
+			policy:   writers.FailOnAny,
+			failures: map[string]bool{"archive": true},
+			err:      true,
+		},
+		"save to all sinks with best effort policy": {
+			policy:   writers.BestEffort,
+			failures: map[string]bool{},
+			err:      false,
+		},
+		"save with one failing sink with best effort policy": {
+			policy:   writers.BestEffort,
+			failures: map[string]bool{"archive": true},
+			err:      false,
+		},
+		"save with all sinks failing with best effort policy": {
+			policy:   writers.BestEffort,
+			failures: map[string]bool{"db": true, "archive": true},
+			err:      true,
+		},
+	}
+
+	for desc, tc := range cases {
+		counter := newCounter()
+		repos := map[string]*repoMock{}
+		sinks := []writers.Sink{}
+		for _, name := range []string{"db", "archive"} {
+			repo := &repoMock{}
+			if tc.failures[name] {
+				repo.err = errSave
+			}
+			repos[name] = repo
+			sinks = append(sinks, writers.Sink{Name: name, Repo: repo})
+		}
+
+		repo := writers.NewMultiRepository(tc.policy, counter, sinks...)
+		err := repo.Save(msg)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", desc, tc.err, err))
+
+		for name, r := range repos {
+			saved, failed := float64(1), float64(0)
+			if tc.failures[name] {
+				saved, failed = 0, 1
+			}
+			assert.Equal(t, int(saved), len(r.messages), fmt.Sprintf("%s: expected %d messages in %s got %d", desc, int(saved), name, len(r.messages)))
+			assert.Equal(t, saved, counter.count(name, "saved"), fmt.Sprintf("%s: unexpected saved count for %s", desc, name))
+			assert.Equal(t, failed, counter.count(name, "failed"), fmt.Sprintf("%s: unexpected failed count for %s", desc, name))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import "github.com/go-kit/kit/metrics"
+
+// Option configures optional behaviour of the writer started by Start.
+type Option func(*consumer)
+
+// WithMetrics sets the lag gauge and the failures counter. The lag gauge is
+// set to the difference in seconds between the time of consumption and the
+// time of the consumed message. Every failure is counted using the "class"
+// and "action" labels.
+func WithMetrics(lag metrics.Gauge, failures metrics.Counter) Option {
+	return func(c *consumer) {
+		c.lag = lag
+		c.failures = failures
+	}
+}
+
+// WithPartition partitions the channels between the replicas of the writer.
+// Every replica receives all the messages and saves only the ones of the
+// channels it owns, instead of sharing the messages through the queue group.
+func WithPartition(partition Partition) Option {
+	return func(c *consumer) {
+		c.partition = partition
+	}
+}
+
+// WithMaxSize sets the maximum serialized size of the message in bytes.
+// Larger messages are dropped without being saved, so that the pathological
+// payloads don't bloat the storage. Zero size, which is the default, doesn't
+// limit the size.
+func WithMaxSize(size int) Option {
+	return func(c *consumer) {
+		c.maxSize = size
+	}
+}
+
+// WithQuarantine sets the quarantine capturing the raw data that can't be
+// unmarshaled into the message, along with the unmarshal error. Without the
+// quarantine, such data is only logged.
+func WithQuarantine(quarantine Quarantine) Option {
+	return func(c *consumer) {
+		c.quarantine = quarantine
+	}
+}
+
+// WithDeadLetterQueue sets the queue the dead-lettered and the invalid
+// messages are republished to, as well as the raw data that can't be
+// unmarshaled if there's no quarantine.
+func WithDeadLetterQueue(dlq DeadLetterQueue) Option {
+	return func(c *consumer) {
+		c.dlq = dlq
+	}
+}
+
+// WithDecoders makes the writer consume the serialized mainflux.RawMessage
+// instead of mainflux.Message. Raw messages are decoded into the messages by
+// the decoder registered for their format before they are saved, and the ones
+// that fail to decode are handled as the data that can't be unmarshaled. The
+// writers shipped in this repository don't set the decoders.
+func WithDecoders(decoders Decoders) Option {
+	return func(c *consumer) {
+		c.decoders = decoders
+	}
+}
+
+// WithBatch makes the writer buffer the messages and save them in batches.
+// Batch that fails for the reason other than the transient failure is saved
+// again message by message, so that only the messages causing the failure
+// are dropped or dead-lettered.
+func WithBatch(batch Batch) Option {
+	return func(c *consumer) {
+		c.batch = batch
+	}
+}
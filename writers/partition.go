@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+const partitionSeparator = "/"
+
+// ErrInvalidPartition indicates malformed writer partition.
+var ErrInvalidPartition = errors.New("invalid writer partition")
+
+// Partition assigns the channels to the replicas of the writer, so that each
+// channel is owned by exactly one of them. Replica is identified by its
+// index, ranging from zero up to, but not including, the number of the
+// replicas. Zero value doesn't partition the channels.
+type Partition struct {
+	Index uint64
+	Count uint64
+}
+
+// ParsePartition parses the partition given as <index>/<count>, e.g. 0/3 for
+// the first of three replicas. Empty value yields the zero partition.
+func ParsePartition(value string) (Partition, error) {
+	if value == "" {
+		return Partition{}, nil
+	}
+
+	parts := strings.SplitN(value, partitionSeparator, 2)
+	if len(parts) != 2 {
+		return Partition{}, ErrInvalidPartition
+	}
+
+	index, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return Partition{}, ErrInvalidPartition
+	}
+
+	count, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || count == 0 || index >= count {
+		return Partition{}, ErrInvalidPartition
+	}
+
+	return Partition{Index: index, Count: count}, nil
+}
+
+// Partitioned returns true if the channels are split between several
+// replicas.
+func (p Partition) Partitioned() bool {
+	return p.Count > 1
+}
+
+// Owns returns true if the channel is assigned to the replica. Channels are
+// assigned using the jump consistent hash, so that changing the number of
+// the replicas moves only the channels of the added or removed ones.
+func (p Partition) Owns(channel string) bool {
+	if !p.Partitioned() {
+		return true
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(channel))
+
+	return jumpHash(h.Sum64(), p.Count) == p.Index
+}
+
+// jumpHash maps the key to one of the given number of buckets, as described
+// in "A Fast, Minimal Memory, Consistent Hash Algorithm" by Lamping and Veach.
+func jumpHash(key, buckets uint64) uint64 {
+	var b, j int64 = -1, 0
+	for uint64(j) < buckets {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return uint64(b)
+}
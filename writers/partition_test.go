@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartition(t *testing.T) {
+	cases := []struct {
+		desc      string
+		value     string
+		partition Partition
+		err       error
+	}{
+		{
+			desc:      "parse empty partition",
+			value:     "",
+			partition: Partition{},
+			err:       nil,
+		},
+		{
+			desc:      "parse partition",
+			value:     "1/3",
+			partition: Partition{Index: 1, Count: 3},
+			err:       nil,
+		},
+		{
+			desc:      "parse partition of single replica",
+			value:     "0/1",
+			partition: Partition{Index: 0, Count: 1},
+			err:       nil,
+		},
+		{
+			desc:      "parse partition with index out of range",
+			value:     "3/3",
+			partition: Partition{},
+			err:       ErrInvalidPartition,
+		},
+		{
+			desc:      "parse partition without replicas",
+			value:     "0/0",
+			partition: Partition{},
+			err:       ErrInvalidPartition,
+		},
+		{
+			desc:      "parse partition without count",
+			value:     "1",
+			partition: Partition{},
+			err:       ErrInvalidPartition,
+		},
+		{
+			desc:      "parse partition with negative index",
+			value:     "-1/3",
+			partition: Partition{},
+			err:       ErrInvalidPartition,
+		},
+	}
+
+	for _, tc := range cases {
+		p, err := ParsePartition(tc.value)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.partition, p, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.partition, p))
+	}
+}
+
+func TestPartitionOwns(t *testing.T) {
+	count := uint64(3)
+	owned := make([]int, count)
+	moved := 0
+	for i := 0; i < 3000; i++ {
+		channel := fmt.Sprintf("channel-%d", i)
+
+		owners := 0
+		for index := uint64(0); index < count; index++ {
+			if (Partition{Index: index, Count: count}).Owns(channel) {
+				owners++
+				owned[index]++
+			}
+		}
+		assert.Equal(t, 1, owners, fmt.Sprintf("expected channel %s to be owned by one replica got %d", channel, owners))
+
+		// Adding the replica moves the channel only to the new replica.
+		for index := uint64(0); index < count; index++ {
+			before := Partition{Index: index, Count: count}.Owns(channel)
+			after := Partition{Index: index, Count: count + 1}.Owns(channel)
+			if before && !after {
+				moved++
+				assert.True(t, Partition{Index: count, Count: count + 1}.Owns(channel), fmt.Sprintf("expected channel %s to move to the new replica", channel))
+			}
+		}
+	}
+
+	for index, n := range owned {
+		assert.InDelta(t, 1000, n, 150, fmt.Sprintf("expected replica %d to own about a third of the channels got %d", index, n))
+	}
+	assert.InDelta(t, 750, moved, 150, fmt.Sprintf("expected about a quarter of the channels to move got %d", moved))
+
+	assert.True(t, Partition{}.Owns("channel"), "expected zero partition to own every channel")
+}
@@ -26,8 +26,33 @@ var ErrInvalidMessage = errors.New("invalid message representation")
 
 var _ writers.MessageRepository = (*postgresRepo)(nil)
 
+const (
+	insertQuery = `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
+    name, unit, value, string_value, bool_value, data_value, value_sum,
+    time, update_time, link)
+    VALUES (:id, :channel, :subtopic, :publisher, :protocol, :name, :unit,
+    :value, :string_value, :bool_value, :data_value, :value_sum,
+    :time, :update_time, :link)`
+
+	upsertClause = ` ON CONFLICT (channel, publisher, time, name) DO UPDATE SET
+    subtopic = EXCLUDED.subtopic, protocol = EXCLUDED.protocol,
+    unit = EXCLUDED.unit, value = EXCLUDED.value,
+    string_value = EXCLUDED.string_value, bool_value = EXCLUDED.bool_value,
+    data_value = EXCLUDED.data_value, value_sum = EXCLUDED.value_sum,
+    update_time = EXCLUDED.update_time, link = EXCLUDED.link`
+
+	naturalKeyIndex = `CREATE UNIQUE INDEX IF NOT EXISTS messages_natural_key
+    ON messages (channel, publisher, time, name)`
+)
+
+// maxBatchRows is the number of rows inserted by a single statement, which
+// keeps the number of the statement parameters, one per column of every
+// row, below the PostgreSQL limit of 65535.
+const maxBatchRows = 65535 / 15
+
 type postgresRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	upsert bool
 }
 
 // New returns new PostgreSQL writer.
@@ -35,13 +60,24 @@ func New(db *sqlx.DB) writers.MessageRepository {
 	return &postgresRepo{db: db}
 }
 
+// NewUpsert returns new PostgreSQL writer that updates the message with the
+// same channel, publisher, time and name instead of inserting a duplicate,
+// e.g. when the corrected historical data is re-ingested. It creates the
+// unique index on these columns, which fails if the stored messages already
+// contain duplicates.
+func NewUpsert(db *sqlx.DB) (writers.MessageRepository, error) {
+	if _, err := db.Exec(naturalKeyIndex); err != nil {
+		return nil, err
+	}
+
+	return &postgresRepo{db: db, upsert: true}, nil
+}
+
 func (pr postgresRepo) Save(msg mainflux.Message) error {
-	q := `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
-    name, unit, value, string_value, bool_value, data_value, value_sum,
-    time, update_time, link)
-    VALUES (:id, :channel, :subtopic, :publisher, :protocol, :name, :unit,
-    :value, :string_value, :bool_value, :data_value, :value_sum,
-    :time, :update_time, :link);`
+	q := insertQuery
+	if pr.upsert {
+		q += upsertClause
+	}
 
 	dbth, err := toDBMessage(msg)
 	if err != nil {
@@ -49,18 +85,101 @@ func (pr postgresRepo) Save(msg mainflux.Message) error {
 	}
 
 	if _, err := pr.db.NamedExec(q, dbth); err != nil {
-		pqErr, ok := err.(*pq.Error)
-		if ok {
-			switch pqErr.Code.Name() {
-			case errInvalid:
-				return ErrInvalidMessage
-			}
+		return classify(err)
+	}
+
+	return nil
+}
+
+// SaveAll inserts the messages using the multi-row INSERT statements, which
+// are executed in a single transaction, so that the batch is saved either
+// completely or not at all.
+func (pr postgresRepo) SaveAll(msgs []mainflux.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	rows := []dbMessage{}
+	for _, msg := range msgs {
+		dbth, err := toDBMessage(msg)
+		if err != nil {
+			return err
 		}
+		rows = append(rows, dbth)
+	}
 
+	if pr.upsert {
+		rows = lastByNaturalKey(rows)
+	}
+
+	tx, err := pr.db.Beginx()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	for start := 0; start < len(rows); start += maxBatchRows {
+		end := start + maxBatchRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		// Multi-row VALUES list is bound before the upsert clause is
+		// appended, since it's generated by repeating the trailing
+		// parenthesized list of the query.
+		q, args, err := pr.db.BindNamed(insertQuery, rows[start:end])
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if pr.upsert {
+			q += upsertClause
+		}
+
+		if _, err := tx.Exec(q, args...); err != nil {
+			tx.Rollback()
+			return classify(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// lastByNaturalKey keeps only the last of the rows sharing the natural key,
+// since the single upsert statement can't update the same row twice, while
+// the sequential upserts would leave the last of them stored.
+func lastByNaturalKey(rows []dbMessage) []dbMessage {
+	type naturalKey struct {
+		channel   string
+		publisher string
+		time      float64
+		name      string
+	}
+
+	last := map[naturalKey]int{}
+	for i, row := range rows {
+		last[naturalKey{row.Channel, row.Publisher, row.Time, row.Name}] = i
+	}
+
+	unique := []dbMessage{}
+	for i, row := range rows {
+		if last[naturalKey{row.Channel, row.Publisher, row.Time, row.Name}] == i {
+			unique = append(unique, row)
+		}
+	}
+
+	return unique
+}
+
+func classify(err error) error {
+	pqErr, ok := err.(*pq.Error)
+	if ok {
+		switch pqErr.Code.Name() {
+		case errInvalid:
+			return ErrInvalidMessage
+		}
+	}
+
+	return err
 }
 
 type dbMessage struct {
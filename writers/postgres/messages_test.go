@@ -62,3 +62,85 @@ func TestMessageSave(t *testing.T) {
 
 	}
 }
+
+func TestMessageSaveUpsert(t *testing.T) {
+	messageRepo, err := postgres.NewUpsert(db)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	pubid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	msg := mainflux.Message{
+		Channel:   chid.String(),
+		Publisher: pubid.String(),
+		Name:      "temperature",
+		Value:     &mainflux.Message_FloatValue{FloatValue: 24},
+		Time:      float64(time.Now().Unix()),
+	}
+	err = messageRepo.Save(msg)
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	msg.Value = &mainflux.Message_FloatValue{FloatValue: 25}
+	err = messageRepo.Save(msg)
+	assert.Nil(t, err, fmt.Sprintf("re-ingest message: expected no error got %s\n", err))
+
+	var values []float64
+	err = db.Select(&values, "SELECT value FROM messages WHERE channel = $1", msg.Channel)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, []float64{25}, values, fmt.Sprintf("expected single updated value got %v", values))
+}
+
+func TestMessageSaveAll(t *testing.T) {
+	messageRepo := postgres.New(db)
+
+	chid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	now := time.Now().Unix()
+	msgs := []mainflux.Message{}
+	for i := 0; i < msgsNum; i++ {
+		msgs = append(msgs, mainflux.Message{
+			Channel:   chid.String(),
+			Publisher: chid.String(),
+			Value:     &mainflux.Message_FloatValue{FloatValue: float64(i)},
+			Time:      float64(now + int64(i)),
+		})
+	}
+
+	err = messageRepo.SaveAll(msgs)
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	var count int
+	err = db.Get(&count, "SELECT COUNT(*) FROM messages WHERE channel = $1", chid.String())
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, msgsNum, count, fmt.Sprintf("expected %d saved messages got %d", msgsNum, count))
+}
+
+func TestMessageSaveAllUpsert(t *testing.T) {
+	messageRepo, err := postgres.NewUpsert(db)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	msg := mainflux.Message{
+		Channel:   chid.String(),
+		Publisher: chid.String(),
+		Name:      "temperature",
+		Value:     &mainflux.Message_FloatValue{FloatValue: 24},
+		Time:      float64(time.Now().Unix()),
+	}
+	corrected := msg
+	corrected.Value = &mainflux.Message_FloatValue{FloatValue: 25}
+
+	// Batch re-ingesting the message it contains keeps only the last of them.
+	err = messageRepo.SaveAll([]mainflux.Message{msg, corrected})
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	var values []float64
+	err = db.Select(&values, "SELECT value FROM messages WHERE channel = $1", msg.Channel)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, []float64{25}, values, fmt.Sprintf("expected single updated value got %v", values))
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	nats "github.com/nats-io/go-nats"
+)
+
+// Prefixes of the quarantine target selecting the sink type.
+const (
+	filePrefix = "file:"
+	natsPrefix = "nats:"
+)
+
+// ErrInvalidQuarantine indicates malformed quarantine target.
+var ErrInvalidQuarantine = errors.New("invalid quarantine target")
+
+// Quarantine captures the raw received data that can't be unmarshaled into
+// the message, so that the corrupt frames can be inspected later.
+type Quarantine interface {
+	// Quarantine records the raw data along with the unmarshal error.
+	Quarantine(data []byte, err error) error
+}
+
+// Record is the quarantined frame. Data is encoded as base64 in JSON.
+type Record struct {
+	Time  float64 `json:"time"`
+	Error string  `json:"error"`
+	Data  []byte  `json:"data"`
+}
+
+func newRecord(data []byte, err error) Record {
+	return Record{
+		Time:  float64(time.Now().UnixNano()) / float64(time.Second),
+		Error: err.Error(),
+		Data:  data,
+	}
+}
+
+// NewQuarantine creates the quarantine sink from the target, which is either
+// file:<path>, appending the JSON encoded records to the file, one per line,
+// or nats:<subject>, publishing them to the NATS subject. Empty target
+// disables the quarantine, in which case nil is returned.
+func NewQuarantine(nc *nats.Conn, target string) (Quarantine, error) {
+	switch {
+	case target == "":
+		return nil, nil
+	case strings.HasPrefix(target, filePrefix) && len(target) > len(filePrefix):
+		return NewFileQuarantine(strings.TrimPrefix(target, filePrefix))
+	case strings.HasPrefix(target, natsPrefix) && len(target) > len(natsPrefix):
+		return NewNATSQuarantine(nc, strings.TrimPrefix(target, natsPrefix)), nil
+	default:
+		return nil, ErrInvalidQuarantine
+	}
+}
+
+type fileQuarantine struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileQuarantine returns the quarantine appending the records to the file
+// at the given path, which is created if it doesn't exist.
+func NewFileQuarantine(path string) (Quarantine, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileQuarantine{file: f}, nil
+}
+
+func (q *fileQuarantine) Quarantine(data []byte, err error) error {
+	b, err := json.Marshal(newRecord(data, err))
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, err = q.file.Write(append(b, '\n'))
+	return err
+}
+
+type natsQuarantine struct {
+	nc      *nats.Conn
+	subject string
+}
+
+// NewNATSQuarantine returns the quarantine publishing the records to the
+// NATS subject.
+func NewNATSQuarantine(nc *nats.Conn, subject string) Quarantine {
+	return natsQuarantine{nc: nc, subject: subject}
+}
+
+func (q natsQuarantine) Quarantine(data []byte, err error) error {
+	b, err := json.Marshal(newRecord(data, err))
+	if err != nil {
+		return err
+	}
+
+	return q.nc.Publish(q.subject, b)
+}
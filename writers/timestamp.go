@@ -0,0 +1,58 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"time"
+
+	"github.com/mainflux/mainflux"
+)
+
+var _ MessageRepository = (*timestampRepository)(nil)
+
+type timestampRepository struct {
+	repo   MessageRepository
+	window time.Duration
+}
+
+// NewTimestampRepository returns message repository that stamps the messages
+// having implausible time with the time they are received at, before saving
+// them to the wrapped repository. Message time is implausible if it is missing
+// or if it is more than the given window ahead of the receive time.
+func NewTimestampRepository(repo MessageRepository, window time.Duration) MessageRepository {
+	return &timestampRepository{
+		repo:   repo,
+		window: window,
+	}
+}
+
+func (tr *timestampRepository) Save(msg mainflux.Message) error {
+	return tr.repo.Save(tr.stamp(time.Now(), msg))
+}
+
+func (tr *timestampRepository) SaveAll(msgs []mainflux.Message) error {
+	now := time.Now()
+	stamped := make([]mainflux.Message, len(msgs))
+	for i, msg := range msgs {
+		stamped[i] = tr.stamp(now, msg)
+	}
+
+	return tr.repo.SaveAll(stamped)
+}
+
+// stamp replaces the implausible message time with the receive time.
+func (tr *timestampRepository) stamp(now time.Time, msg mainflux.Message) mainflux.Message {
+	received := float64(now.UnixNano()) / float64(time.Second)
+	limit := float64(now.Add(tr.window).UnixNano()) / float64(time.Second)
+
+	if msg.Time <= 0 || msg.Time > limit {
+		msg.Time = received
+	}
+
+	return msg
+}
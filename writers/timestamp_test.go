@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/writers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampSave(t *testing.T) {
+	window := time.Hour
+	now := float64(time.Now().Unix())
+
+	cases := []struct {
+		desc       string
+		time       float64
+		overridden bool
+	}{
+		{
+			desc:       "save message with plausible time",
+			time:       now - 60,
+			overridden: false,
+		},
+		{
+			desc:       "save message with time within window",
+			time:       now + 60,
+			overridden: false,
+		},
+		{
+			desc:       "save message without time",
+			time:       0,
+			overridden: true,
+		},
+		{
+			desc:       "save message with negative time",
+			time:       -1,
+			overridden: true,
+		},
+		{
+			desc:       "save message with time far in the future",
+			time:       now + 2*window.Seconds(),
+			overridden: true,
+		},
+	}
+
+	for _, tc := range cases {
+		sink := &repoMock{}
+		repo := writers.NewTimestampRepository(sink, window)
+
+		before := float64(time.Now().UnixNano()) / float64(time.Second)
+		err := repo.Save(mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http", Time: tc.time})
+		after := float64(time.Now().UnixNano()) / float64(time.Second)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		require.Len(t, sink.messages, 1, fmt.Sprintf("%s: expected message to be saved", tc.desc))
+
+		saved := sink.messages[0].Time
+		if !tc.overridden {
+			assert.Equal(t, tc.time, saved, fmt.Sprintf("%s: expected time %f got %f", tc.desc, tc.time, saved))
+			continue
+		}
+		assert.True(t, saved >= before && saved <= after, fmt.Sprintf("%s: expected receive time in [%f, %f] got %f", tc.desc, before, after, saved))
+	}
+}
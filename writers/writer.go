@@ -8,49 +8,452 @@
 package writers
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/go-kit/kit/metrics"
 	"github.com/gogo/protobuf/proto"
 	"github.com/mainflux/mainflux"
 	log "github.com/mainflux/mainflux/logger"
 	nats "github.com/nats-io/go-nats"
 )
 
+const (
+	maxRetries = 3
+	retryDelay = 20 * time.Millisecond
+
+	// maxBackoff bounds the total time spent waiting between the retries
+	// while handling the single delivery, since the retries block the NATS
+	// callback.
+	maxBackoff = 150 * time.Millisecond
+)
+
+// Failure handling actions, used as the "action" label of the failures
+// counter.
+const (
+	actionRetry      = "retry"
+	actionDeadLetter = "dead_letter"
+	actionDrop       = "drop"
+	actionQuarantine = "quarantine"
+)
+
+// ErrEmptySubject indicates that the writer is started without the NATS
+// subject to consume the messages from.
+var ErrEmptySubject = errors.New("empty subject")
+
+// errTooLarge indicates the message exceeding the maximum size, which is
+// dropped before it reaches the repository.
+var errTooLarge = errors.New("message too large")
+
+// errCorrupt indicates the received data that can't be unmarshaled into the
+// message.
+var errCorrupt = errors.New("corrupt message")
+
+var classLabels = map[error]string{
+	ErrTransient:      "transient",
+	ErrInvalidMessage: "invalid_message",
+	ErrStorage:        "storage",
+	errTooLarge:       "too_large",
+	errCorrupt:        "corrupt",
+}
+
+// subscriber subscribes the handlers to the NATS subjects. It is implemented
+// by the NATS connection.
+type subscriber interface {
+	Subscribe(string, nats.MsgHandler) (*nats.Subscription, error)
+	QueueSubscribe(string, string, nats.MsgHandler) (*nats.Subscription, error)
+}
+
+// Consumer is the running writer returned by Start. Its buffered messages
+// are flushed once it is closed, when the writer shuts down.
+type Consumer interface {
+	Flusher
+
+	// Close stops consuming the messages and flushing the buffer
+	// periodically. Buffered messages are kept, so that they can be flushed.
+	Close() error
+}
+
+var _ Consumer = (*consumer)(nil)
+
 type consumer struct {
-	nc       *nats.Conn
-	channels map[string]bool
-	repo     MessageRepository
-	logger   log.Logger
+	nc         *nats.Conn
+	sub        *nats.Subscription
+	done       chan struct{}
+	closeOnce  sync.Once
+	channels   map[string]bool
+	partition  Partition
+	maxSize    int
+	quarantine Quarantine
+	dlq        DeadLetterQueue
+	decoders   Decoders
+	batch      Batch
+	buffer     *buffer
+	repo       MessageRepository
+	lag        metrics.Gauge
+	failures   metrics.Counter
+	retryDelay time.Duration
+	maxBackoff time.Duration
+	logger     log.Logger
+}
+
+// Start method starts to consume the messages received from NATS on the
+// subject, e.g. mainflux.OutputSenML for the normalized SenML stream, and
+// saves the ones of the listed channels. Data published to the subject must
+// be the serialized mainflux.Message, unless the decoders are set, and the
+// subject must not be empty. Replicas of the writer share the messages
+// through the queue group, unless the channels are partitioned between them.
+//
+// Messages that fail to save are handled depending on the error class:
+// transient failures are retried, invalid messages are dropped and the
+// messages failing due to the storage errors are dead-lettered.
+//
+// Returned consumer saves the messages still buffered when the writer shuts
+// down, which should be done once it is closed and before the NATS
+// connection is closed, so that the last partial batch isn't lost.
+func Start(nc *nats.Conn, repo MessageRepository, subject, queue string, channels map[string]bool, logger log.Logger, opts ...Option) (Consumer, error) {
+	c := &consumer{
+		nc:         nc,
+		done:       make(chan struct{}),
+		channels:   channels,
+		repo:       repo,
+		retryDelay: retryDelay,
+		maxBackoff: maxBackoff,
+		logger:     logger,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := c.batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.batch.Enabled() {
+		c.buffer = newBuffer(c.batch.Size)
+	}
+
+	if err := c.subscribe(nc, subject, queue); err != nil {
+		return nil, err
+	}
+
+	if c.buffer != nil {
+		go c.flushEvery(c.batch.Interval)
+	}
+
+	return c, nil
 }
 
-// Start method starts to consume normalized messages received from NATS.
-func Start(nc *nats.Conn, repo MessageRepository, queue string, channels map[string]bool, logger log.Logger) error {
-	c := consumer{
-		nc:       nc,
-		channels: channels,
-		repo:     repo,
-		logger:   logger,
+func (c *consumer) subscribe(sub subscriber, subject, queue string) error {
+	if subject == "" {
+		return ErrEmptySubject
+	}
+
+	var err error
+	if c.partition.Partitioned() {
+		c.sub, err = sub.Subscribe(subject, c.consume)
+		return err
 	}
 
-	_, err := nc.QueueSubscribe(mainflux.OutputSenML, queue, c.consume)
+	c.sub, err = sub.QueueSubscribe(subject, queue, c.consume)
 	return err
 }
 
 func (c *consumer) consume(m *nats.Msg) {
-	msg := &mainflux.Message{}
-	if err := proto.Unmarshal(m.Data, msg); err != nil {
+	msgs, err := c.unmarshal(m.Data)
+	if err != nil {
+		c.quarantineData(m.Data, err)
+		return
+	}
+
+	for _, msg := range msgs {
+		if !c.channelExists(msg.GetChannel()) || !c.partition.Owns(msg.GetChannel()) {
+			continue
+		}
+
+		c.observeLag(msg)
+
+		if c.maxSize > 0 && len(m.Data) > c.maxSize {
+			c.countFailure(errTooLarge, actionDrop)
+			c.logger.Warn(fmt.Sprintf("Dropping message of channel %s published by %s: size of %d bytes exceeds %d bytes", msg.Channel, msg.Publisher, len(m.Data), c.maxSize))
+			continue
+		}
+
+		c.add(msg)
+	}
+}
+
+// add saves the message, or buffers it if the messages are saved in batches,
+// in which case the full buffer is saved as the batch.
+func (c *consumer) add(msg mainflux.Message) {
+	if c.buffer == nil {
+		c.save(msg, c.newBackoff())
+		return
+	}
+
+	if msgs := c.buffer.add(msg); len(msgs) > 0 {
+		c.saveAll(msgs, c.newBackoff())
+	}
+}
+
+// flush saves the buffered messages as the batch.
+func (c *consumer) flush() {
+	if msgs := c.buffer.take(); len(msgs) > 0 {
+		c.saveAll(msgs, c.newBackoff())
+	}
+}
+
+// Flush saves the buffered messages. Messages failing to be saved are
+// handled as the failed batch, so no error is returned.
+func (c *consumer) Flush() error {
+	if c.buffer != nil {
+		c.flush()
+	}
+
+	return nil
+}
+
+// Buffered returns the number of the buffered messages.
+func (c *consumer) Buffered() int {
+	if c.buffer == nil {
+		return 0
+	}
+
+	return c.buffer.len()
+}
+
+// Close unsubscribes the consumer and stops the periodic flush.
+func (c *consumer) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.sub != nil {
+			err = c.sub.Unsubscribe()
+		}
+	})
+
+	return err
+}
+
+// flushEvery flushes the buffer periodically, so that the messages consumed
+// at the rate too low to fill the batch are saved as well, until the consumer
+// is closed.
+func (c *consumer) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// unmarshal turns the received data into the messages. Without the decoders
+// the data is the serialized message, otherwise it is the serialized raw
+// message, decoded by the decoder registered for its format.
+func (c *consumer) unmarshal(data []byte) ([]mainflux.Message, error) {
+	if c.decoders == nil {
+		msg := mainflux.Message{}
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+
+		return []mainflux.Message{msg}, nil
+	}
+
+	raw := mainflux.RawMessage{}
+	if err := proto.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	return c.decoders.decode(raw)
+}
+
+// backoff is the remaining time the delivery may spend waiting between the
+// retries.
+type backoff struct {
+	left time.Duration
+}
+
+func (c *consumer) newBackoff() *backoff {
+	return &backoff{left: c.maxBackoff}
+}
+
+// wait sleeps for the given delay and returns true, unless the delay exceeds
+// the remaining time, in which case it returns false right away.
+func (b *backoff) wait(delay time.Duration) bool {
+	if delay > b.left {
+		return false
+	}
+
+	b.left -= delay
+	time.Sleep(delay)
+	return true
+}
+
+// save saves the message, retrying transient failures with exponential
+// backoff. Messages still failing after the last retry, or once the backoff
+// time is spent, are dead-lettered.
+func (c *consumer) save(msg mainflux.Message, b *backoff) {
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := c.repo.Save(msg)
+		if err == nil {
+			return
+		}
+
+		class := Classify(err)
+		switch {
+		case class == ErrTransient && attempt < maxRetries && b.wait(delay):
+			c.countFailure(class, actionRetry)
+			c.logger.Warn(fmt.Sprintf("Failed to save message, retrying: %s", err))
+			delay *= 2
+		case class == ErrInvalidMessage && c.dlq == nil:
+			c.countFailure(class, actionDrop)
+			c.logger.Warn(fmt.Sprintf("Dropping invalid message of channel %s: %s", msg.Channel, err))
+			return
+		default:
+			c.countFailure(class, actionDeadLetter)
+			c.deadLetter(msg, err)
+			return
+		}
+	}
+}
+
+// saveAll saves the batch of messages, retrying transient failures with
+// exponential backoff. Messages the partially saved batch reports as saved
+// aren't saved again. Messages still failing after the last retry, or once
+// the backoff time is spent, are dead-lettered, while the batch failing due
+// to the other errors is saved message by message, since the error may be
+// caused by some of the messages only, e.g. the single invalid one. Messages
+// saved one by one share the backoff time of the batch.
+func (c *consumer) saveAll(msgs []mainflux.Message, b *backoff) {
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := c.repo.SaveAll(msgs)
+		if err == nil {
+			return
+		}
+
+		if e, ok := err.(BatchError); ok && e.Saved > 0 && e.Saved <= len(msgs) {
+			msgs = msgs[e.Saved:]
+		}
+
+		class := Classify(err)
+		switch {
+		case len(msgs) == 0:
+			return
+		case class == ErrTransient && attempt < maxRetries && b.wait(delay):
+			c.countFailures(class, actionRetry, len(msgs))
+			c.logger.Warn(fmt.Sprintf("Failed to save batch of %d messages, retrying: %s", len(msgs), err))
+			delay *= 2
+		case class == ErrTransient:
+			c.countFailures(class, actionDeadLetter, len(msgs))
+			for _, msg := range msgs {
+				c.deadLetter(msg, err)
+			}
+			return
+		default:
+			c.logger.Warn(fmt.Sprintf("Failed to save batch of %d messages, saving them one by one: %s", len(msgs), err))
+			for _, msg := range msgs {
+				c.save(msg, b)
+			}
+			return
+		}
+	}
+}
+
+// deadLetter records the message that can't be saved, so that it can be
+// recovered once the storage failure is resolved. Message is republished to
+// the dead-letter queue, if any, and logged if there's none or if it fails
+// to be republished.
+func (c *consumer) deadLetter(msg mainflux.Message, err error) {
+	if c.dlq != nil {
+		data, derr := msg.Marshal()
+		if derr == nil {
+			derr = c.dlq.Publish(data, Classify(err))
+		}
+		if derr == nil {
+			return
+		}
+		c.logger.Error(fmt.Sprintf("Failed to publish message of channel %s to dead-letter queue: %s", msg.Channel, derr))
+	}
+
+	c.logger.Error(fmt.Sprintf("Failed to save message of channel %s published by %s at %f: %s", msg.Channel, msg.Publisher, msg.Time, err))
+}
+
+// quarantineData captures the data that can't be unmarshaled. Without the
+// quarantine, the data is republished to the dead-letter queue instead. The
+// data is dropped if there is neither, or if capturing it fails.
+func (c *consumer) quarantineData(data []byte, err error) {
+	if c.quarantine == nil && c.dlq != nil {
+		c.deadLetterData(data, err)
+		return
+	}
+
+	if c.quarantine == nil {
+		c.countFailure(errCorrupt, actionDrop)
 		c.logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
 		return
 	}
 
-	if !c.channelExists(msg.GetChannel()) {
+	if qerr := c.quarantine.Quarantine(data, err); qerr != nil {
+		c.countFailure(errCorrupt, actionDrop)
+		c.logger.Error(fmt.Sprintf("Failed to quarantine %d bytes that failed to unmarshal with %s: %s", len(data), err, qerr))
 		return
 	}
 
-	if err := c.repo.Save(*msg); err != nil {
-		c.logger.Warn(fmt.Sprintf("Failed to save message: %s", err))
+	c.countFailure(errCorrupt, actionQuarantine)
+	c.logger.Warn(fmt.Sprintf("Quarantined %d bytes that failed to unmarshal: %s", len(data), err))
+}
+
+// deadLetterData republishes the data that can't be unmarshaled to the
+// dead-letter queue.
+func (c *consumer) deadLetterData(data []byte, err error) {
+	if derr := c.dlq.Publish(data, errCorrupt); derr != nil {
+		c.countFailure(errCorrupt, actionDrop)
+		c.logger.Error(fmt.Sprintf("Failed to publish %d bytes that failed to unmarshal with %s to dead-letter queue: %s", len(data), err, derr))
 		return
 	}
+
+	c.countFailure(errCorrupt, actionDeadLetter)
+	c.logger.Warn(fmt.Sprintf("Dead-lettered %d bytes that failed to unmarshal: %s", len(data), err))
+}
+
+func (c *consumer) countFailure(class error, action string) {
+	c.countFailures(class, action, 1)
+}
+
+func (c *consumer) countFailures(class error, action string, n int) {
+	if c.failures == nil {
+		return
+	}
+
+	c.failures.With("class", classLabels[class], "action", action).Add(float64(n))
+}
+
+// observeLag measures how far behind the real time the consumed message is.
+// Messages without time are ignored, while the ones with time in the future
+// are treated as if there is no lag.
+func (c *consumer) observeLag(msg mainflux.Message) {
+	if c.lag == nil || msg.Time <= 0 {
+		return
+	}
+
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	lag := now - msg.Time
+	if lag < 0 {
+		lag = 0
+	}
+
+	c.lag.Set(lag)
 }
 
 func (c *consumer) channelExists(channel string) bool {
@@ -0,0 +1,631 @@
+//
+// Copyright (c) 2019
+// Mainflux
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package writers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
+	nats "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopRepository struct{}
+
+func (repo nopRepository) Save(mainflux.Message) error {
+	return nil
+}
+
+func (repo nopRepository) SaveAll([]mainflux.Message) error {
+	return nil
+}
+
+// failingRepository fails the saves with the given errors, one per save,
+// and succeeds once the errors are exhausted.
+type failingRepository struct {
+	errs  []error
+	saves int
+}
+
+func (repo *failingRepository) Save(mainflux.Message) error {
+	repo.saves++
+	if len(repo.errs) == 0 {
+		return nil
+	}
+
+	err := repo.errs[0]
+	repo.errs = repo.errs[1:]
+	return err
+}
+
+func (repo *failingRepository) SaveAll(msgs []mainflux.Message) error {
+	for _, msg := range msgs {
+		if err := repo.Save(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// channelRepository counts the saved messages per channel.
+type channelRepository struct {
+	saves map[string]int
+}
+
+func (repo channelRepository) Save(msg mainflux.Message) error {
+	repo.saves[msg.Channel]++
+	return nil
+}
+
+func (repo channelRepository) SaveAll(msgs []mainflux.Message) error {
+	for _, msg := range msgs {
+		repo.saves[msg.Channel]++
+	}
+
+	return nil
+}
+
+type counterMock struct {
+	labels string
+	counts map[string]float64
+}
+
+func (c *counterMock) With(labelValues ...string) metrics.Counter {
+	return &counterMock{labels: fmt.Sprint(labelValues), counts: c.counts}
+}
+
+func (c *counterMock) Add(delta float64) {
+	c.counts[c.labels] += delta
+}
+
+type gaugeMock struct {
+	value float64
+	set   bool
+}
+
+func (g *gaugeMock) With(labelValues ...string) metrics.Gauge {
+	return g
+}
+
+func (g *gaugeMock) Set(value float64) {
+	g.value = value
+	g.set = true
+}
+
+func (g *gaugeMock) Add(delta float64) {
+	g.value += delta
+}
+
+func TestConsumeLag(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	now := float64(time.Now().Unix())
+
+	cases := map[string]struct {
+		channel string
+		time    float64
+		set     bool
+		min     float64
+		max     float64
+	}{
+		"consume message from the past": {
+			channel: "1",
+			time:    now - 60,
+			set:     true,
+			min:     60,
+			max:     70,
+		},
+		"consume message from the future": {
+			channel: "1",
+			time:    now + 3600,
+			set:     true,
+			min:     0,
+			max:     0,
+		},
+		"consume message without time": {
+			channel: "1",
+			time:    0,
+			set:     false,
+		},
+		"consume message from ignored channel": {
+			channel: "2",
+			time:    now - 60,
+			set:     false,
+		},
+	}
+
+	for desc, tc := range cases {
+		lag := &gaugeMock{}
+		c := consumer{
+			channels: map[string]bool{"1": true},
+			repo:     nopRepository{},
+			lag:      lag,
+			logger:   logger,
+		}
+
+		msg := mainflux.Message{Channel: tc.channel, Publisher: "1", Protocol: "http", Time: tc.time}
+		data, err := msg.Marshal()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+
+		c.consume(&nats.Msg{Data: data})
+		assert.Equal(t, tc.set, lag.set, fmt.Sprintf("%s: expected lag observed %t got %t", desc, tc.set, lag.set))
+		if !tc.set {
+			continue
+		}
+		assert.True(t, lag.value >= tc.min && lag.value <= tc.max, fmt.Sprintf("%s: expected lag in [%f, %f] got %f", desc, tc.min, tc.max, lag.value))
+	}
+}
+
+func TestConsumeFailures(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	transient := NewError(ErrTransient, errors.New("connection reset"))
+	invalid := NewError(ErrInvalidMessage, errors.New("document validation failed"))
+	storage := NewError(ErrStorage, errors.New("disk full"))
+
+	cases := map[string]struct {
+		errs       []error
+		retryDelay time.Duration
+		maxBackoff time.Duration
+		saves      int
+		failures   map[string]float64
+	}{
+		"save message": {
+			errs:     nil,
+			saves:    1,
+			failures: map[string]float64{},
+		},
+		"save message after transient failure": {
+			errs:  []error{transient},
+			saves: 2,
+			failures: map[string]float64{
+				"[class transient action retry]": 1,
+			},
+		},
+		"save message with persistent transient failure": {
+			errs:  []error{transient, transient, transient, transient},
+			saves: maxRetries + 1,
+			failures: map[string]float64{
+				"[class transient action retry]":       maxRetries,
+				"[class transient action dead_letter]": 1,
+			},
+		},
+		"save message with transient failure outlasting backoff": {
+			errs:       []error{transient, transient, transient, transient},
+			retryDelay: 20 * time.Millisecond,
+			maxBackoff: 50 * time.Millisecond,
+			saves:      2,
+			failures: map[string]float64{
+				"[class transient action retry]":       1,
+				"[class transient action dead_letter]": 1,
+			},
+		},
+		"save invalid message": {
+			errs:  []error{invalid},
+			saves: 1,
+			failures: map[string]float64{
+				"[class invalid_message action drop]": 1,
+			},
+		},
+		"save message with storage failure": {
+			errs:  []error{storage},
+			saves: 1,
+			failures: map[string]float64{
+				"[class storage action dead_letter]": 1,
+			},
+		},
+		"save message with unclassified failure": {
+			errs:  []error{errors.New("unknown")},
+			saves: 1,
+			failures: map[string]float64{
+				"[class storage action dead_letter]": 1,
+			},
+		},
+	}
+
+	for desc, tc := range cases {
+		repo := &failingRepository{errs: tc.errs}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels:   map[string]bool{"*": true},
+			repo:       repo,
+			failures:   failures,
+			retryDelay: tc.retryDelay,
+			maxBackoff: tc.maxBackoff,
+			logger:     logger,
+		}
+
+		msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http"}
+		data, err := msg.Marshal()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", desc, err))
+
+		c.consume(&nats.Msg{Data: data})
+		assert.Equal(t, tc.saves, repo.saves, fmt.Sprintf("%s: expected %d saves got %d", desc, tc.saves, repo.saves))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", desc, tc.failures, failures.counts))
+	}
+}
+
+func TestConsumeMaxSize(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http"}
+	data, err := msg.Marshal()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	size := len(data)
+
+	cases := map[string]struct {
+		maxSize  int
+		saves    int
+		failures map[string]float64
+	}{
+		"consume message within size limit": {
+			maxSize:  size + 1,
+			saves:    1,
+			failures: map[string]float64{},
+		},
+		"consume message of maximum size": {
+			maxSize:  size,
+			saves:    1,
+			failures: map[string]float64{},
+		},
+		"consume message exceeding size limit": {
+			maxSize: size - 1,
+			saves:   0,
+			failures: map[string]float64{
+				"[class too_large action drop]": 1,
+			},
+		},
+		"consume message without size limit": {
+			maxSize:  0,
+			saves:    1,
+			failures: map[string]float64{},
+		},
+	}
+
+	for desc, tc := range cases {
+		repo := &failingRepository{}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels: map[string]bool{"*": true},
+			maxSize:  tc.maxSize,
+			repo:     repo,
+			failures: failures,
+			logger:   logger,
+		}
+
+		c.consume(&nats.Msg{Data: data})
+		assert.Equal(t, tc.saves, repo.saves, fmt.Sprintf("%s: expected %d saves got %d", desc, tc.saves, repo.saves))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", desc, tc.failures, failures.counts))
+	}
+}
+
+type failingQuarantine struct{}
+
+func (q failingQuarantine) Quarantine([]byte, error) error {
+	return errors.New("disk full")
+}
+
+func TestConsumePartitioned(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	channels := []string{}
+	for i := 0; i < 100; i++ {
+		channels = append(channels, fmt.Sprintf("channel-%d", i))
+	}
+
+	// Second replica lists only some of the channels.
+	listed := map[string]bool{}
+	for _, ch := range channels[:50] {
+		listed[ch] = true
+	}
+
+	cases := []struct {
+		desc       string
+		partitions []Partition
+		lists      []map[string]bool
+		saves      map[string]int
+	}{
+		{
+			desc:       "consume with replicas listing all channels",
+			partitions: []Partition{{Index: 0, Count: 2}, {Index: 1, Count: 2}},
+			lists:      []map[string]bool{{"*": true}, {"*": true}},
+			saves:      counts(channels, 1),
+		},
+		{
+			desc:       "consume with replicas listing overlapping channels",
+			partitions: []Partition{{Index: 0, Count: 2}, {Index: 1, Count: 2}},
+			lists:      []map[string]bool{{"*": true}, listed},
+		},
+		{
+			desc:       "consume with replicas not partitioned",
+			partitions: []Partition{{}, {}},
+			lists:      []map[string]bool{{"*": true}, {"*": true}},
+			saves:      counts(channels, 2),
+		},
+	}
+
+	for _, tc := range cases {
+		repo := channelRepository{saves: map[string]int{}}
+		consumers := []*consumer{}
+		for i, p := range tc.partitions {
+			consumers = append(consumers, &consumer{
+				channels:  tc.lists[i],
+				partition: p,
+				repo:      repo,
+				logger:    logger,
+			})
+		}
+
+		// Partitioned replicas don't share the queue group, so each of them
+		// receives every message.
+		for _, ch := range channels {
+			msg := mainflux.Message{Channel: ch, Publisher: "1", Protocol: "http"}
+			data, err := msg.Marshal()
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+			for _, c := range consumers {
+				c.consume(&nats.Msg{Data: data})
+			}
+		}
+
+		if tc.saves != nil {
+			assert.Equal(t, tc.saves, repo.saves, fmt.Sprintf("%s: expected saves %v got %v", tc.desc, tc.saves, repo.saves))
+			continue
+		}
+
+		for ch, n := range repo.saves {
+			assert.Equal(t, 1, n, fmt.Sprintf("%s: expected channel %s to be saved once got %d", tc.desc, ch, n))
+		}
+	}
+}
+
+// subscriberMock delivers the published data to the handlers subscribed to
+// the exact subject.
+type subscriberMock struct {
+	handlers map[string][]nats.MsgHandler
+	queues   map[string]string
+}
+
+func (sm *subscriberMock) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	sm.handlers[subject] = append(sm.handlers[subject], cb)
+	return nil, nil
+}
+
+func (sm *subscriberMock) QueueSubscribe(subject, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	sm.queues[subject] = queue
+	return sm.Subscribe(subject, cb)
+}
+
+func (sm *subscriberMock) publish(subject string, data []byte) {
+	for _, cb := range sm.handlers[subject] {
+		cb(&nats.Msg{Subject: subject, Data: data})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	rawSubject := "out.raw"
+	subjects := []string{mainflux.OutputSenML, rawSubject}
+
+	cases := []struct {
+		desc    string
+		subject string
+		queue   string
+		saves   map[string]int
+		err     error
+	}{
+		{
+			desc:    "subscribe to normalized subject",
+			subject: mainflux.OutputSenML,
+			queue:   "writers",
+			saves:   map[string]int{mainflux.OutputSenML: 1},
+			err:     nil,
+		},
+		{
+			desc:    "subscribe to custom subject",
+			subject: rawSubject,
+			queue:   "writers",
+			saves:   map[string]int{rawSubject: 1},
+			err:     nil,
+		},
+		{
+			desc:    "subscribe to empty subject",
+			subject: "",
+			saves:   map[string]int{},
+			err:     ErrEmptySubject,
+		},
+	}
+
+	for _, tc := range cases {
+		repo := channelRepository{saves: map[string]int{}}
+		c := consumer{
+			channels: map[string]bool{"*": true},
+			repo:     repo,
+			logger:   logger,
+		}
+		sub := &subscriberMock{handlers: map[string][]nats.MsgHandler{}, queues: map[string]string{}}
+
+		err := c.subscribe(sub, tc.subject, "writers")
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.queue, sub.queues[tc.subject], fmt.Sprintf("%s: expected queue %s got %s", tc.desc, tc.queue, sub.queues[tc.subject]))
+
+		// Messages are sent to the channels named after the subjects they
+		// are published to.
+		for _, subject := range subjects {
+			msg := mainflux.Message{Channel: subject, Publisher: "1", Protocol: "http"}
+			data, err := msg.Marshal()
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+			sub.publish(subject, data)
+		}
+
+		assert.Equal(t, tc.saves, repo.saves, fmt.Sprintf("%s: expected saves %v got %v", tc.desc, tc.saves, repo.saves))
+	}
+}
+
+func counts(channels []string, n int) map[string]int {
+	res := map[string]int{}
+	for _, ch := range channels {
+		res[ch] = n
+	}
+
+	return res
+}
+
+func TestConsumeCorrupt(t *testing.T) {
+	logger, err := log.New(os.Stdout, log.Info.String())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	dir, err := ioutil.TempDir("", "quarantine")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "corrupt.log")
+	fileQuarantine, err := NewFileQuarantine(path)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	msg := mainflux.Message{Channel: "1", Publisher: "1", Protocol: "http"}
+	valid, err := msg.Marshal()
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	truncated := valid[:len(valid)-1]
+	garbage := []byte{0xff, 0xff, 0xff, 0xff}
+
+	cases := map[string]struct {
+		data        []byte
+		quarantine  Quarantine
+		quarantined bool
+		failures    map[string]float64
+	}{
+		"consume truncated message": {
+			data:        truncated,
+			quarantine:  fileQuarantine,
+			quarantined: true,
+			failures: map[string]float64{
+				"[class corrupt action quarantine]": 1,
+			},
+		},
+		"consume garbage": {
+			data:        garbage,
+			quarantine:  fileQuarantine,
+			quarantined: true,
+			failures: map[string]float64{
+				"[class corrupt action quarantine]": 1,
+			},
+		},
+		"consume garbage without quarantine": {
+			data:       garbage,
+			quarantine: nil,
+			failures: map[string]float64{
+				"[class corrupt action drop]": 1,
+			},
+		},
+		"consume garbage with failing quarantine": {
+			data:       garbage,
+			quarantine: failingQuarantine{},
+			failures: map[string]float64{
+				"[class corrupt action drop]": 1,
+			},
+		},
+	}
+
+	var expected [][]byte
+	for desc, tc := range cases {
+		repo := &failingRepository{}
+		failures := &counterMock{counts: map[string]float64{}}
+		c := consumer{
+			channels:   map[string]bool{"*": true},
+			quarantine: tc.quarantine,
+			repo:       repo,
+			failures:   failures,
+			logger:     logger,
+		}
+
+		c.consume(&nats.Msg{Data: tc.data})
+		assert.Equal(t, 0, repo.saves, fmt.Sprintf("%s: expected no saves got %d", desc, repo.saves))
+		assert.Equal(t, tc.failures, failures.counts, fmt.Sprintf("%s: expected failures %v got %v", desc, tc.failures, failures.counts))
+		if tc.quarantined {
+			expected = append(expected, tc.data)
+		}
+	}
+
+	f, err := os.Open(path)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	defer f.Close()
+
+	var quarantined [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		assert.NotEmpty(t, rec.Error, "expected quarantined record to carry the unmarshal error")
+		assert.True(t, rec.Time > 0, "expected quarantined record to carry the time")
+		quarantined = append(quarantined, rec.Data)
+	}
+	assert.ElementsMatch(t, expected, quarantined, fmt.Sprintf("expected quarantined data %v got %v", expected, quarantined))
+}
+
+func TestNewQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quarantine")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		target string
+		err    error
+	}{
+		"create disabled quarantine":           {target: "", err: nil},
+		"create file quarantine":               {target: "file:" + filepath.Join(dir, "corrupt.log"), err: nil},
+		"create NATS quarantine":               {target: "nats:quarantine", err: nil},
+		"create file quarantine without path":  {target: "file:", err: ErrInvalidQuarantine},
+		"create NATS quarantine without topic": {target: "nats:", err: ErrInvalidQuarantine},
+		"create quarantine of unknown type":    {target: "s3:bucket", err: ErrInvalidQuarantine},
+	}
+
+	for desc, tc := range cases {
+		_, err := NewQuarantine(nil, tc.target)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", desc, tc.err, err))
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cause := errors.New("cause")
+
+	cases := map[string]struct {
+		err   error
+		class error
+	}{
+		"classify transient error":    {err: NewError(ErrTransient, cause), class: ErrTransient},
+		"classify invalid message":    {err: NewError(ErrInvalidMessage, cause), class: ErrInvalidMessage},
+		"classify storage error":      {err: NewError(ErrStorage, cause), class: ErrStorage},
+		"classify bare class error":   {err: ErrTransient, class: ErrTransient},
+		"classify unclassified error": {err: cause, class: ErrStorage},
+	}
+
+	for desc, tc := range cases {
+		class := Classify(tc.err)
+		assert.Equal(t, tc.class, class, fmt.Sprintf("%s: expected %s got %s", desc, tc.class, class))
+	}
+}
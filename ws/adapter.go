@@ -12,7 +12,9 @@ package ws
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
+	"unicode"
 
 	"github.com/mainflux/mainflux"
 	broker "github.com/nats-io/go-nats"
@@ -27,6 +29,14 @@ var (
 
 	// ErrFailedConnection indicates that service couldn't connect to message broker.
 	ErrFailedConnection = errors.New("failed to connect to message broker")
+
+	// ErrMalformedSubtopic indicates that subtopic contains illegal characters.
+	ErrMalformedSubtopic = errors.New("malformed subtopic")
+)
+
+const (
+	wildcardOne = "*"
+	wildcardAll = ">"
 )
 
 // Service specifies web socket service API.
@@ -88,6 +98,19 @@ func New(pubsub Service) Service {
 }
 
 func (as *adapterService) Publish(ctx context.Context, token string, msg mainflux.RawMessage) error {
+	subtopic, err := CanonicalSubtopic(msg.Subtopic)
+	if err != nil {
+		return err
+	}
+
+	// Wildcards are allowed only when subscribing.
+	for _, elem := range strings.Split(subtopic, ".") {
+		if elem == wildcardOne || elem == wildcardAll {
+			return ErrMalformedSubtopic
+		}
+	}
+	msg.Subtopic = subtopic
+
 	if err := as.pubsub.Publish(ctx, token, msg); err != nil {
 		switch err {
 		case broker.ErrConnectionClosed, broker.ErrInvalidConnection:
@@ -100,8 +123,54 @@ func (as *adapterService) Publish(ctx context.Context, token string, msg mainflu
 }
 
 func (as *adapterService) Subscribe(chanID, subtopic string, channel *Channel) error {
+	subtopic, err := CanonicalSubtopic(subtopic)
+	if err != nil {
+		return err
+	}
+
 	if err := as.pubsub.Subscribe(chanID, subtopic, channel); err != nil {
 		return ErrFailedSubscription
 	}
 	return nil
 }
+
+// CanonicalSubtopic validates the subtopic and converts it to the form used
+// in NATS subjects, i.e. both "/" and "." are treated as separators and empty
+// elements are removed. Subtopic elements must not contain whitespace,
+// control characters or partial wildcards. Whole-element "*" and trailing ">"
+// wildcards are allowed.
+func CanonicalSubtopic(subtopic string) (string, error) {
+	if subtopic == "" {
+		return subtopic, nil
+	}
+
+	subtopic = strings.Replace(subtopic, "/", ".", -1)
+
+	elems := strings.Split(subtopic, ".")
+	filteredElems := []string{}
+	for i, elem := range elems {
+		if elem == "" {
+			continue
+		}
+
+		if elem == wildcardAll && i != len(elems)-1 {
+			return "", ErrMalformedSubtopic
+		}
+
+		if len(elem) > 1 && strings.ContainsAny(elem, wildcardOne+wildcardAll) {
+			return "", ErrMalformedSubtopic
+		}
+
+		if strings.IndexFunc(elem, illegalRune) != -1 {
+			return "", ErrMalformedSubtopic
+		}
+
+		filteredElems = append(filteredElems, elem)
+	}
+
+	return strings.Join(filteredElems, "."), nil
+}
+
+func illegalRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
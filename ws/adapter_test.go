@@ -122,3 +122,103 @@ func TestClose(t *testing.T) {
 	}()
 	channel.Close()
 }
+
+func TestPublishSubtopic(t *testing.T) {
+	cases := []struct {
+		desc     string
+		subtopic string
+		expected string
+		err      error
+	}{
+		{
+			desc:     "publish message with valid subtopic",
+			subtopic: "sub/topic",
+			expected: "sub.topic",
+			err:      nil,
+		},
+		{
+			desc:     "publish message with subtopic containing spaces",
+			subtopic: "sub topic",
+			err:      ws.ErrMalformedSubtopic,
+		},
+		{
+			desc:     "publish message with subtopic containing wildcards",
+			subtopic: "sub.*",
+			err:      ws.ErrMalformedSubtopic,
+		},
+		{
+			desc:     "publish message with subtopic containing partial wildcards",
+			subtopic: "sub.a>b",
+			err:      ws.ErrMalformedSubtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		channel := ws.NewChannel()
+		svc := newService(channel)
+
+		m := msg
+		m.Subtopic = tc.subtopic
+
+		received := make(chan mainflux.RawMessage, 1)
+		if tc.err == nil {
+			go func() {
+				received <- <-channel.Messages
+			}()
+		}
+
+		err := svc.Publish(context.Background(), "", m)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			receivedMsg := <-received
+			assert.Equal(t, tc.expected, receivedMsg.Subtopic, fmt.Sprintf("%s: expected subtopic %s got %s\n", tc.desc, tc.expected, receivedMsg.Subtopic))
+		}
+	}
+}
+
+func TestSubscribeSubtopic(t *testing.T) {
+	channel := ws.NewChannel()
+	subs := map[string]*ws.Channel{
+		chanID:               channel,
+		chanID + "sub.topic": channel,
+		chanID + "sub.>":     channel,
+	}
+	svc := ws.New(mocks.NewService(subs, broker.ErrInvalidMsg))
+
+	cases := []struct {
+		desc     string
+		subtopic string
+		err      error
+	}{
+		{
+			desc:     "subscribe to valid subtopic",
+			subtopic: "sub/topic",
+			err:      nil,
+		},
+		{
+			desc:     "subscribe to all subtopics using wildcard",
+			subtopic: "sub/>",
+			err:      nil,
+		},
+		{
+			desc:     "subscribe to subtopic containing spaces",
+			subtopic: "sub/to pic",
+			err:      ws.ErrMalformedSubtopic,
+		},
+		{
+			desc:     "subscribe to subtopic with non-trailing wildcard",
+			subtopic: ">/sub",
+			err:      ws.ErrMalformedSubtopic,
+		},
+		{
+			desc:     "subscribe to subtopic containing partial wildcards",
+			subtopic: "sub/a*b",
+			err:      ws.ErrMalformedSubtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.Subscribe(chanID, tc.subtopic, channel)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/go-zoo/bone"
@@ -126,31 +125,12 @@ func parseSubtopic(subtopic string) (string, error) {
 		return subtopic, nil
 	}
 
-	var err error
-	subtopic, err = url.QueryUnescape(subtopic)
+	subtopic, err := url.QueryUnescape(subtopic)
 	if err != nil {
 		return "", errMalformedSubtopic
 	}
 
-	subtopic = strings.Replace(subtopic, "/", ".", -1)
-
-	elems := strings.Split(subtopic, ".")
-	filteredElems := []string{}
-	for _, elem := range elems {
-		if elem == "" {
-			continue
-		}
-
-		if len(elem) > 1 && (strings.Contains(elem, "*") || strings.Contains(elem, ">")) {
-			return "", errMalformedSubtopic
-		}
-
-		filteredElems = append(filteredElems, elem)
-	}
-
-	subtopic = strings.Join(filteredElems, ".")
-
-	return subtopic, nil
+	return ws.CanonicalSubtopic(subtopic)
 }
 
 func authorize(r *http.Request) (subscription, error) {